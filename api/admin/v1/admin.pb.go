@@ -0,0 +1,479 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.32.1
+// source: api/admin/v1/admin.proto
+
+package admin_v1
+
+import (
+	v1 "github.com/kentakki416/proglog/api/v1"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ACLRule: allows subject to perform action on object; "*" in any field matches everything
+type ACLRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subject       string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"` // client identity, e.g. the certificate common name
+	Object        string                 `protobuf:"bytes,2,opt,name=object,proto3" json:"object,omitempty"`   // topic name, or "*" for the default log and every topic
+	Action        string                 `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"`   // e.g. "produce", "consume", "admin:create_topic"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ACLRule) Reset() {
+	*x = ACLRule{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ACLRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ACLRule) ProtoMessage() {}
+
+func (x *ACLRule) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ACLRule.ProtoReflect.Descriptor instead.
+func (*ACLRule) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ACLRule) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *ACLRule) GetObject() string {
+	if x != nil {
+		return x.Object
+	}
+	return ""
+}
+
+func (x *ACLRule) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+type ListACLsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListACLsRequest) Reset() {
+	*x = ListACLsRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListACLsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListACLsRequest) ProtoMessage() {}
+
+func (x *ListACLsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListACLsRequest.ProtoReflect.Descriptor instead.
+func (*ListACLsRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{1}
+}
+
+type ListACLsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rules         []*ACLRule             `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"` // sorted by subject, object, and action
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListACLsResponse) Reset() {
+	*x = ListACLsResponse{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListACLsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListACLsResponse) ProtoMessage() {}
+
+func (x *ListACLsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListACLsResponse.ProtoReflect.Descriptor instead.
+func (*ListACLsResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListACLsResponse) GetRules() []*ACLRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+// AddACLRequest: adding a rule that already exists succeeds without change
+type AddACLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          *ACLRule               `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddACLRequest) Reset() {
+	*x = AddACLRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddACLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddACLRequest) ProtoMessage() {}
+
+func (x *AddACLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddACLRequest.ProtoReflect.Descriptor instead.
+func (*AddACLRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AddACLRequest) GetRule() *ACLRule {
+	if x != nil {
+		return x.Rule
+	}
+	return nil
+}
+
+type AddACLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddACLResponse) Reset() {
+	*x = AddACLResponse{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddACLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddACLResponse) ProtoMessage() {}
+
+func (x *AddACLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddACLResponse.ProtoReflect.Descriptor instead.
+func (*AddACLResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{4}
+}
+
+// RemoveACLRequest: removing a rule that does not exist fails with NOT_FOUND
+type RemoveACLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          *ACLRule               `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveACLRequest) Reset() {
+	*x = RemoveACLRequest{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveACLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveACLRequest) ProtoMessage() {}
+
+func (x *RemoveACLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveACLRequest.ProtoReflect.Descriptor instead.
+func (*RemoveACLRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RemoveACLRequest) GetRule() *ACLRule {
+	if x != nil {
+		return x.Rule
+	}
+	return nil
+}
+
+type RemoveACLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveACLResponse) Reset() {
+	*x = RemoveACLResponse{}
+	mi := &file_api_admin_v1_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveACLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveACLResponse) ProtoMessage() {}
+
+func (x *RemoveACLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveACLResponse.ProtoReflect.Descriptor instead.
+func (*RemoveACLResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_proto_rawDescGZIP(), []int{6}
+}
+
+var File_api_admin_v1_admin_proto protoreflect.FileDescriptor
+
+const file_api_admin_v1_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x18api/admin/v1/admin.proto\x12\badmin.v1\x1a\x10api/v1/log.proto\"S\n" +
+	"\aACLRule\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12\x16\n" +
+	"\x06object\x18\x02 \x01(\tR\x06object\x12\x16\n" +
+	"\x06action\x18\x03 \x01(\tR\x06action\"\x11\n" +
+	"\x0fListACLsRequest\";\n" +
+	"\x10ListACLsResponse\x12'\n" +
+	"\x05rules\x18\x01 \x03(\v2\x11.admin.v1.ACLRuleR\x05rules\"6\n" +
+	"\rAddACLRequest\x12%\n" +
+	"\x04rule\x18\x01 \x01(\v2\x11.admin.v1.ACLRuleR\x04rule\"\x10\n" +
+	"\x0eAddACLResponse\"9\n" +
+	"\x10RemoveACLRequest\x12%\n" +
+	"\x04rule\x18\x01 \x01(\v2\x11.admin.v1.ACLRuleR\x04rule\"\x13\n" +
+	"\x11RemoveACLResponse2\x8a\t\n" +
+	"\x05Admin\x12H\n" +
+	"\vCreateTopic\x12\x1a.log.v1.CreateTopicRequest\x1a\x1b.log.v1.CreateTopicResponse\"\x00\x12H\n" +
+	"\vDeleteTopic\x12\x1a.log.v1.DeleteTopicRequest\x1a\x1b.log.v1.DeleteTopicResponse\"\x00\x12N\n" +
+	"\rUndeleteTopic\x12\x1c.log.v1.UndeleteTopicRequest\x1a\x1d.log.v1.UndeleteTopicResponse\"\x00\x12E\n" +
+	"\n" +
+	"ListTopics\x12\x19.log.v1.ListTopicsRequest\x1a\x1a.log.v1.ListTopicsResponse\"\x00\x12H\n" +
+	"\vTruncateLog\x12\x1a.log.v1.TruncateLogRequest\x1a\x1b.log.v1.TruncateLogResponse\"\x00\x12?\n" +
+	"\bFlushLog\x12\x17.log.v1.FlushLogRequest\x1a\x18.log.v1.FlushLogResponse\"\x00\x12E\n" +
+	"\n" +
+	"GetLogInfo\x12\x19.log.v1.GetLogInfoRequest\x1a\x1a.log.v1.GetLogInfoResponse\"\x00\x12N\n" +
+	"\rExportOffsets\x12\x1c.log.v1.ExportOffsetsRequest\x1a\x1d.log.v1.ExportOffsetsResponse\"\x00\x12N\n" +
+	"\rImportOffsets\x12\x1c.log.v1.ImportOffsetsRequest\x1a\x1d.log.v1.ImportOffsetsResponse\"\x00\x12H\n" +
+	"\vStartExport\x12\x1a.log.v1.StartExportRequest\x1a\x1b.log.v1.StartExportResponse\"\x00\x12:\n" +
+	"\tGetExport\x12\x18.log.v1.GetExportRequest\x1a\x11.log.v1.ExportJob\"\x00\x12Q\n" +
+	"\x0eGetConsumerLag\x12\x1d.log.v1.GetConsumerLagRequest\x1a\x1e.log.v1.GetConsumerLagResponse\"\x00\x12?\n" +
+	"\bGetUsage\x12\x17.log.v1.GetUsageRequest\x1a\x18.log.v1.GetUsageResponse\"\x00\x12C\n" +
+	"\bListACLs\x12\x19.admin.v1.ListACLsRequest\x1a\x1a.admin.v1.ListACLsResponse\"\x00\x12=\n" +
+	"\x06AddACL\x12\x17.admin.v1.AddACLRequest\x1a\x18.admin.v1.AddACLResponse\"\x00\x12F\n" +
+	"\tRemoveACL\x12\x1a.admin.v1.RemoveACLRequest\x1a\x1b.admin.v1.RemoveACLResponse\"\x00B6Z4github.com/kentakki416/proglog/api/admin/v1;admin_v1b\x06proto3"
+
+var (
+	file_api_admin_v1_admin_proto_rawDescOnce sync.Once
+	file_api_admin_v1_admin_proto_rawDescData []byte
+)
+
+func file_api_admin_v1_admin_proto_rawDescGZIP() []byte {
+	file_api_admin_v1_admin_proto_rawDescOnce.Do(func() {
+		file_api_admin_v1_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_admin_v1_admin_proto_rawDesc), len(file_api_admin_v1_admin_proto_rawDesc)))
+	})
+	return file_api_admin_v1_admin_proto_rawDescData
+}
+
+var file_api_admin_v1_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_api_admin_v1_admin_proto_goTypes = []any{
+	(*ACLRule)(nil),                   // 0: admin.v1.ACLRule
+	(*ListACLsRequest)(nil),           // 1: admin.v1.ListACLsRequest
+	(*ListACLsResponse)(nil),          // 2: admin.v1.ListACLsResponse
+	(*AddACLRequest)(nil),             // 3: admin.v1.AddACLRequest
+	(*AddACLResponse)(nil),            // 4: admin.v1.AddACLResponse
+	(*RemoveACLRequest)(nil),          // 5: admin.v1.RemoveACLRequest
+	(*RemoveACLResponse)(nil),         // 6: admin.v1.RemoveACLResponse
+	(*v1.CreateTopicRequest)(nil),     // 7: log.v1.CreateTopicRequest
+	(*v1.DeleteTopicRequest)(nil),     // 8: log.v1.DeleteTopicRequest
+	(*v1.UndeleteTopicRequest)(nil),   // 9: log.v1.UndeleteTopicRequest
+	(*v1.ListTopicsRequest)(nil),      // 10: log.v1.ListTopicsRequest
+	(*v1.TruncateLogRequest)(nil),     // 11: log.v1.TruncateLogRequest
+	(*v1.FlushLogRequest)(nil),        // 12: log.v1.FlushLogRequest
+	(*v1.GetLogInfoRequest)(nil),      // 13: log.v1.GetLogInfoRequest
+	(*v1.ExportOffsetsRequest)(nil),   // 14: log.v1.ExportOffsetsRequest
+	(*v1.ImportOffsetsRequest)(nil),   // 15: log.v1.ImportOffsetsRequest
+	(*v1.StartExportRequest)(nil),     // 16: log.v1.StartExportRequest
+	(*v1.GetExportRequest)(nil),       // 17: log.v1.GetExportRequest
+	(*v1.GetConsumerLagRequest)(nil),  // 18: log.v1.GetConsumerLagRequest
+	(*v1.GetUsageRequest)(nil),        // 19: log.v1.GetUsageRequest
+	(*v1.CreateTopicResponse)(nil),    // 20: log.v1.CreateTopicResponse
+	(*v1.DeleteTopicResponse)(nil),    // 21: log.v1.DeleteTopicResponse
+	(*v1.UndeleteTopicResponse)(nil),  // 22: log.v1.UndeleteTopicResponse
+	(*v1.ListTopicsResponse)(nil),     // 23: log.v1.ListTopicsResponse
+	(*v1.TruncateLogResponse)(nil),    // 24: log.v1.TruncateLogResponse
+	(*v1.FlushLogResponse)(nil),       // 25: log.v1.FlushLogResponse
+	(*v1.GetLogInfoResponse)(nil),     // 26: log.v1.GetLogInfoResponse
+	(*v1.ExportOffsetsResponse)(nil),  // 27: log.v1.ExportOffsetsResponse
+	(*v1.ImportOffsetsResponse)(nil),  // 28: log.v1.ImportOffsetsResponse
+	(*v1.StartExportResponse)(nil),    // 29: log.v1.StartExportResponse
+	(*v1.ExportJob)(nil),              // 30: log.v1.ExportJob
+	(*v1.GetConsumerLagResponse)(nil), // 31: log.v1.GetConsumerLagResponse
+	(*v1.GetUsageResponse)(nil),       // 32: log.v1.GetUsageResponse
+}
+var file_api_admin_v1_admin_proto_depIdxs = []int32{
+	0,  // 0: admin.v1.ListACLsResponse.rules:type_name -> admin.v1.ACLRule
+	0,  // 1: admin.v1.AddACLRequest.rule:type_name -> admin.v1.ACLRule
+	0,  // 2: admin.v1.RemoveACLRequest.rule:type_name -> admin.v1.ACLRule
+	7,  // 3: admin.v1.Admin.CreateTopic:input_type -> log.v1.CreateTopicRequest
+	8,  // 4: admin.v1.Admin.DeleteTopic:input_type -> log.v1.DeleteTopicRequest
+	9,  // 5: admin.v1.Admin.UndeleteTopic:input_type -> log.v1.UndeleteTopicRequest
+	10, // 6: admin.v1.Admin.ListTopics:input_type -> log.v1.ListTopicsRequest
+	11, // 7: admin.v1.Admin.TruncateLog:input_type -> log.v1.TruncateLogRequest
+	12, // 8: admin.v1.Admin.FlushLog:input_type -> log.v1.FlushLogRequest
+	13, // 9: admin.v1.Admin.GetLogInfo:input_type -> log.v1.GetLogInfoRequest
+	14, // 10: admin.v1.Admin.ExportOffsets:input_type -> log.v1.ExportOffsetsRequest
+	15, // 11: admin.v1.Admin.ImportOffsets:input_type -> log.v1.ImportOffsetsRequest
+	16, // 12: admin.v1.Admin.StartExport:input_type -> log.v1.StartExportRequest
+	17, // 13: admin.v1.Admin.GetExport:input_type -> log.v1.GetExportRequest
+	18, // 14: admin.v1.Admin.GetConsumerLag:input_type -> log.v1.GetConsumerLagRequest
+	19, // 15: admin.v1.Admin.GetUsage:input_type -> log.v1.GetUsageRequest
+	1,  // 16: admin.v1.Admin.ListACLs:input_type -> admin.v1.ListACLsRequest
+	3,  // 17: admin.v1.Admin.AddACL:input_type -> admin.v1.AddACLRequest
+	5,  // 18: admin.v1.Admin.RemoveACL:input_type -> admin.v1.RemoveACLRequest
+	20, // 19: admin.v1.Admin.CreateTopic:output_type -> log.v1.CreateTopicResponse
+	21, // 20: admin.v1.Admin.DeleteTopic:output_type -> log.v1.DeleteTopicResponse
+	22, // 21: admin.v1.Admin.UndeleteTopic:output_type -> log.v1.UndeleteTopicResponse
+	23, // 22: admin.v1.Admin.ListTopics:output_type -> log.v1.ListTopicsResponse
+	24, // 23: admin.v1.Admin.TruncateLog:output_type -> log.v1.TruncateLogResponse
+	25, // 24: admin.v1.Admin.FlushLog:output_type -> log.v1.FlushLogResponse
+	26, // 25: admin.v1.Admin.GetLogInfo:output_type -> log.v1.GetLogInfoResponse
+	27, // 26: admin.v1.Admin.ExportOffsets:output_type -> log.v1.ExportOffsetsResponse
+	28, // 27: admin.v1.Admin.ImportOffsets:output_type -> log.v1.ImportOffsetsResponse
+	29, // 28: admin.v1.Admin.StartExport:output_type -> log.v1.StartExportResponse
+	30, // 29: admin.v1.Admin.GetExport:output_type -> log.v1.ExportJob
+	31, // 30: admin.v1.Admin.GetConsumerLag:output_type -> log.v1.GetConsumerLagResponse
+	32, // 31: admin.v1.Admin.GetUsage:output_type -> log.v1.GetUsageResponse
+	2,  // 32: admin.v1.Admin.ListACLs:output_type -> admin.v1.ListACLsResponse
+	4,  // 33: admin.v1.Admin.AddACL:output_type -> admin.v1.AddACLResponse
+	6,  // 34: admin.v1.Admin.RemoveACL:output_type -> admin.v1.RemoveACLResponse
+	19, // [19:35] is the sub-list for method output_type
+	3,  // [3:19] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_api_admin_v1_admin_proto_init() }
+func file_api_admin_v1_admin_proto_init() {
+	if File_api_admin_v1_admin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_admin_v1_admin_proto_rawDesc), len(file_api_admin_v1_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_admin_v1_admin_proto_goTypes,
+		DependencyIndexes: file_api_admin_v1_admin_proto_depIdxs,
+		MessageInfos:      file_api_admin_v1_admin_proto_msgTypes,
+	}.Build()
+	File_api_admin_v1_admin_proto = out.File
+	file_api_admin_v1_admin_proto_goTypes = nil
+	file_api_admin_v1_admin_proto_depIdxs = nil
+}
@@ -0,0 +1,708 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.32.1
+// source: api/admin/v1/admin.proto
+
+package admin_v1
+
+import (
+	context "context"
+	v1 "github.com/kentakki416/proglog/api/v1"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Admin_CreateTopic_FullMethodName    = "/admin.v1.Admin/CreateTopic"
+	Admin_DeleteTopic_FullMethodName    = "/admin.v1.Admin/DeleteTopic"
+	Admin_UndeleteTopic_FullMethodName  = "/admin.v1.Admin/UndeleteTopic"
+	Admin_ListTopics_FullMethodName     = "/admin.v1.Admin/ListTopics"
+	Admin_TruncateLog_FullMethodName    = "/admin.v1.Admin/TruncateLog"
+	Admin_FlushLog_FullMethodName       = "/admin.v1.Admin/FlushLog"
+	Admin_GetLogInfo_FullMethodName     = "/admin.v1.Admin/GetLogInfo"
+	Admin_ExportOffsets_FullMethodName  = "/admin.v1.Admin/ExportOffsets"
+	Admin_ImportOffsets_FullMethodName  = "/admin.v1.Admin/ImportOffsets"
+	Admin_StartExport_FullMethodName    = "/admin.v1.Admin/StartExport"
+	Admin_GetExport_FullMethodName      = "/admin.v1.Admin/GetExport"
+	Admin_GetConsumerLag_FullMethodName = "/admin.v1.Admin/GetConsumerLag"
+	Admin_GetUsage_FullMethodName       = "/admin.v1.Admin/GetUsage"
+	Admin_ListACLs_FullMethodName       = "/admin.v1.Admin/ListACLs"
+	Admin_AddACL_FullMethodName         = "/admin.v1.Admin/AddACL"
+	Admin_RemoveACL_FullMethodName      = "/admin.v1.Admin/RemoveACL"
+)
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Admin: control-plane API (topics, retention, offsets, exports, usage).
+// It is versioned separately from log.v1.Log (see GetCapabilitiesResponse.admin_api_version)
+// and authorized with its own ACL actions ("admin:" followed by the action name,
+// e.g. "admin:create_topic"), so policies can grant data-plane access without admin access.
+// Requests and responses reuse the log.v1 messages until they need to diverge.
+type AdminClient interface {
+	CreateTopic(ctx context.Context, in *v1.CreateTopicRequest, opts ...grpc.CallOption) (*v1.CreateTopicResponse, error)
+	DeleteTopic(ctx context.Context, in *v1.DeleteTopicRequest, opts ...grpc.CallOption) (*v1.DeleteTopicResponse, error)
+	UndeleteTopic(ctx context.Context, in *v1.UndeleteTopicRequest, opts ...grpc.CallOption) (*v1.UndeleteTopicResponse, error)
+	ListTopics(ctx context.Context, in *v1.ListTopicsRequest, opts ...grpc.CallOption) (*v1.ListTopicsResponse, error)
+	TruncateLog(ctx context.Context, in *v1.TruncateLogRequest, opts ...grpc.CallOption) (*v1.TruncateLogResponse, error)
+	FlushLog(ctx context.Context, in *v1.FlushLogRequest, opts ...grpc.CallOption) (*v1.FlushLogResponse, error)
+	GetLogInfo(ctx context.Context, in *v1.GetLogInfoRequest, opts ...grpc.CallOption) (*v1.GetLogInfoResponse, error)
+	ExportOffsets(ctx context.Context, in *v1.ExportOffsetsRequest, opts ...grpc.CallOption) (*v1.ExportOffsetsResponse, error)
+	ImportOffsets(ctx context.Context, in *v1.ImportOffsetsRequest, opts ...grpc.CallOption) (*v1.ImportOffsetsResponse, error)
+	StartExport(ctx context.Context, in *v1.StartExportRequest, opts ...grpc.CallOption) (*v1.StartExportResponse, error)
+	GetExport(ctx context.Context, in *v1.GetExportRequest, opts ...grpc.CallOption) (*v1.ExportJob, error)
+	GetConsumerLag(ctx context.Context, in *v1.GetConsumerLagRequest, opts ...grpc.CallOption) (*v1.GetConsumerLagResponse, error)
+	GetUsage(ctx context.Context, in *v1.GetUsageRequest, opts ...grpc.CallOption) (*v1.GetUsageResponse, error)
+	// ACL rules, changed at runtime and persisted in an internal topic that is replicated like
+	// any other topic; the server must be configured with an ACL policy (feature "acl_management")
+	ListACLs(ctx context.Context, in *ListACLsRequest, opts ...grpc.CallOption) (*ListACLsResponse, error)
+	AddACL(ctx context.Context, in *AddACLRequest, opts ...grpc.CallOption) (*AddACLResponse, error)
+	RemoveACL(ctx context.Context, in *RemoveACLRequest, opts ...grpc.CallOption) (*RemoveACLResponse, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) CreateTopic(ctx context.Context, in *v1.CreateTopicRequest, opts ...grpc.CallOption) (*v1.CreateTopicResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.CreateTopicResponse)
+	err := c.cc.Invoke(ctx, Admin_CreateTopic_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) DeleteTopic(ctx context.Context, in *v1.DeleteTopicRequest, opts ...grpc.CallOption) (*v1.DeleteTopicResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.DeleteTopicResponse)
+	err := c.cc.Invoke(ctx, Admin_DeleteTopic_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) UndeleteTopic(ctx context.Context, in *v1.UndeleteTopicRequest, opts ...grpc.CallOption) (*v1.UndeleteTopicResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.UndeleteTopicResponse)
+	err := c.cc.Invoke(ctx, Admin_UndeleteTopic_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListTopics(ctx context.Context, in *v1.ListTopicsRequest, opts ...grpc.CallOption) (*v1.ListTopicsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.ListTopicsResponse)
+	err := c.cc.Invoke(ctx, Admin_ListTopics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) TruncateLog(ctx context.Context, in *v1.TruncateLogRequest, opts ...grpc.CallOption) (*v1.TruncateLogResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.TruncateLogResponse)
+	err := c.cc.Invoke(ctx, Admin_TruncateLog_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) FlushLog(ctx context.Context, in *v1.FlushLogRequest, opts ...grpc.CallOption) (*v1.FlushLogResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.FlushLogResponse)
+	err := c.cc.Invoke(ctx, Admin_FlushLog_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetLogInfo(ctx context.Context, in *v1.GetLogInfoRequest, opts ...grpc.CallOption) (*v1.GetLogInfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.GetLogInfoResponse)
+	err := c.cc.Invoke(ctx, Admin_GetLogInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ExportOffsets(ctx context.Context, in *v1.ExportOffsetsRequest, opts ...grpc.CallOption) (*v1.ExportOffsetsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.ExportOffsetsResponse)
+	err := c.cc.Invoke(ctx, Admin_ExportOffsets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ImportOffsets(ctx context.Context, in *v1.ImportOffsetsRequest, opts ...grpc.CallOption) (*v1.ImportOffsetsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.ImportOffsetsResponse)
+	err := c.cc.Invoke(ctx, Admin_ImportOffsets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) StartExport(ctx context.Context, in *v1.StartExportRequest, opts ...grpc.CallOption) (*v1.StartExportResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.StartExportResponse)
+	err := c.cc.Invoke(ctx, Admin_StartExport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetExport(ctx context.Context, in *v1.GetExportRequest, opts ...grpc.CallOption) (*v1.ExportJob, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.ExportJob)
+	err := c.cc.Invoke(ctx, Admin_GetExport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetConsumerLag(ctx context.Context, in *v1.GetConsumerLagRequest, opts ...grpc.CallOption) (*v1.GetConsumerLagResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.GetConsumerLagResponse)
+	err := c.cc.Invoke(ctx, Admin_GetConsumerLag_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetUsage(ctx context.Context, in *v1.GetUsageRequest, opts ...grpc.CallOption) (*v1.GetUsageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.GetUsageResponse)
+	err := c.cc.Invoke(ctx, Admin_GetUsage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListACLs(ctx context.Context, in *ListACLsRequest, opts ...grpc.CallOption) (*ListACLsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListACLsResponse)
+	err := c.cc.Invoke(ctx, Admin_ListACLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) AddACL(ctx context.Context, in *AddACLRequest, opts ...grpc.CallOption) (*AddACLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddACLResponse)
+	err := c.cc.Invoke(ctx, Admin_AddACL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) RemoveACL(ctx context.Context, in *RemoveACLRequest, opts ...grpc.CallOption) (*RemoveACLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveACLResponse)
+	err := c.cc.Invoke(ctx, Admin_RemoveACL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility.
+//
+// Admin: control-plane API (topics, retention, offsets, exports, usage).
+// It is versioned separately from log.v1.Log (see GetCapabilitiesResponse.admin_api_version)
+// and authorized with its own ACL actions ("admin:" followed by the action name,
+// e.g. "admin:create_topic"), so policies can grant data-plane access without admin access.
+// Requests and responses reuse the log.v1 messages until they need to diverge.
+type AdminServer interface {
+	CreateTopic(context.Context, *v1.CreateTopicRequest) (*v1.CreateTopicResponse, error)
+	DeleteTopic(context.Context, *v1.DeleteTopicRequest) (*v1.DeleteTopicResponse, error)
+	UndeleteTopic(context.Context, *v1.UndeleteTopicRequest) (*v1.UndeleteTopicResponse, error)
+	ListTopics(context.Context, *v1.ListTopicsRequest) (*v1.ListTopicsResponse, error)
+	TruncateLog(context.Context, *v1.TruncateLogRequest) (*v1.TruncateLogResponse, error)
+	FlushLog(context.Context, *v1.FlushLogRequest) (*v1.FlushLogResponse, error)
+	GetLogInfo(context.Context, *v1.GetLogInfoRequest) (*v1.GetLogInfoResponse, error)
+	ExportOffsets(context.Context, *v1.ExportOffsetsRequest) (*v1.ExportOffsetsResponse, error)
+	ImportOffsets(context.Context, *v1.ImportOffsetsRequest) (*v1.ImportOffsetsResponse, error)
+	StartExport(context.Context, *v1.StartExportRequest) (*v1.StartExportResponse, error)
+	GetExport(context.Context, *v1.GetExportRequest) (*v1.ExportJob, error)
+	GetConsumerLag(context.Context, *v1.GetConsumerLagRequest) (*v1.GetConsumerLagResponse, error)
+	GetUsage(context.Context, *v1.GetUsageRequest) (*v1.GetUsageResponse, error)
+	// ACL rules, changed at runtime and persisted in an internal topic that is replicated like
+	// any other topic; the server must be configured with an ACL policy (feature "acl_management")
+	ListACLs(context.Context, *ListACLsRequest) (*ListACLsResponse, error)
+	AddACL(context.Context, *AddACLRequest) (*AddACLResponse, error)
+	RemoveACL(context.Context, *RemoveACLRequest) (*RemoveACLResponse, error)
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServer struct{}
+
+func (UnimplementedAdminServer) CreateTopic(context.Context, *v1.CreateTopicRequest) (*v1.CreateTopicResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTopic not implemented")
+}
+func (UnimplementedAdminServer) DeleteTopic(context.Context, *v1.DeleteTopicRequest) (*v1.DeleteTopicResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTopic not implemented")
+}
+func (UnimplementedAdminServer) UndeleteTopic(context.Context, *v1.UndeleteTopicRequest) (*v1.UndeleteTopicResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UndeleteTopic not implemented")
+}
+func (UnimplementedAdminServer) ListTopics(context.Context, *v1.ListTopicsRequest) (*v1.ListTopicsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTopics not implemented")
+}
+func (UnimplementedAdminServer) TruncateLog(context.Context, *v1.TruncateLogRequest) (*v1.TruncateLogResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TruncateLog not implemented")
+}
+func (UnimplementedAdminServer) FlushLog(context.Context, *v1.FlushLogRequest) (*v1.FlushLogResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FlushLog not implemented")
+}
+func (UnimplementedAdminServer) GetLogInfo(context.Context, *v1.GetLogInfoRequest) (*v1.GetLogInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLogInfo not implemented")
+}
+func (UnimplementedAdminServer) ExportOffsets(context.Context, *v1.ExportOffsetsRequest) (*v1.ExportOffsetsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportOffsets not implemented")
+}
+func (UnimplementedAdminServer) ImportOffsets(context.Context, *v1.ImportOffsetsRequest) (*v1.ImportOffsetsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportOffsets not implemented")
+}
+func (UnimplementedAdminServer) StartExport(context.Context, *v1.StartExportRequest) (*v1.StartExportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartExport not implemented")
+}
+func (UnimplementedAdminServer) GetExport(context.Context, *v1.GetExportRequest) (*v1.ExportJob, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetExport not implemented")
+}
+func (UnimplementedAdminServer) GetConsumerLag(context.Context, *v1.GetConsumerLagRequest) (*v1.GetConsumerLagResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConsumerLag not implemented")
+}
+func (UnimplementedAdminServer) GetUsage(context.Context, *v1.GetUsageRequest) (*v1.GetUsageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsage not implemented")
+}
+func (UnimplementedAdminServer) ListACLs(context.Context, *ListACLsRequest) (*ListACLsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListACLs not implemented")
+}
+func (UnimplementedAdminServer) AddACL(context.Context, *AddACLRequest) (*AddACLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddACL not implemented")
+}
+func (UnimplementedAdminServer) RemoveACL(context.Context, *RemoveACLRequest) (*RemoveACLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveACL not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+func (UnimplementedAdminServer) testEmbeddedByValue()               {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_CreateTopic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.CreateTopicRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).CreateTopic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_CreateTopic_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).CreateTopic(ctx, req.(*v1.CreateTopicRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_DeleteTopic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.DeleteTopicRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).DeleteTopic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_DeleteTopic_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).DeleteTopic(ctx, req.(*v1.DeleteTopicRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_UndeleteTopic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.UndeleteTopicRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).UndeleteTopic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_UndeleteTopic_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).UndeleteTopic(ctx, req.(*v1.UndeleteTopicRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListTopics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.ListTopicsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListTopics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_ListTopics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListTopics(ctx, req.(*v1.ListTopicsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_TruncateLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.TruncateLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).TruncateLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_TruncateLog_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).TruncateLog(ctx, req.(*v1.TruncateLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_FlushLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.FlushLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).FlushLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_FlushLog_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).FlushLog(ctx, req.(*v1.FlushLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetLogInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.GetLogInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetLogInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GetLogInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetLogInfo(ctx, req.(*v1.GetLogInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ExportOffsets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.ExportOffsetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ExportOffsets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_ExportOffsets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ExportOffsets(ctx, req.(*v1.ExportOffsetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ImportOffsets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.ImportOffsetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ImportOffsets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_ImportOffsets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ImportOffsets(ctx, req.(*v1.ImportOffsetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_StartExport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.StartExportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).StartExport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_StartExport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).StartExport(ctx, req.(*v1.StartExportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetExport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.GetExportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetExport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GetExport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetExport(ctx, req.(*v1.GetExportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetConsumerLag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.GetConsumerLagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetConsumerLag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GetConsumerLag_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetConsumerLag(ctx, req.(*v1.GetConsumerLagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.GetUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GetUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetUsage(ctx, req.(*v1.GetUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListACLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListACLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListACLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_ListACLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListACLs(ctx, req.(*ListACLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_AddACL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddACLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).AddACL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_AddACL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).AddACL(ctx, req.(*AddACLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_RemoveACL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveACLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).RemoveACL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_RemoveACL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).RemoveACL(ctx, req.(*RemoveACLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.v1.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateTopic",
+			Handler:    _Admin_CreateTopic_Handler,
+		},
+		{
+			MethodName: "DeleteTopic",
+			Handler:    _Admin_DeleteTopic_Handler,
+		},
+		{
+			MethodName: "UndeleteTopic",
+			Handler:    _Admin_UndeleteTopic_Handler,
+		},
+		{
+			MethodName: "ListTopics",
+			Handler:    _Admin_ListTopics_Handler,
+		},
+		{
+			MethodName: "TruncateLog",
+			Handler:    _Admin_TruncateLog_Handler,
+		},
+		{
+			MethodName: "FlushLog",
+			Handler:    _Admin_FlushLog_Handler,
+		},
+		{
+			MethodName: "GetLogInfo",
+			Handler:    _Admin_GetLogInfo_Handler,
+		},
+		{
+			MethodName: "ExportOffsets",
+			Handler:    _Admin_ExportOffsets_Handler,
+		},
+		{
+			MethodName: "ImportOffsets",
+			Handler:    _Admin_ImportOffsets_Handler,
+		},
+		{
+			MethodName: "StartExport",
+			Handler:    _Admin_StartExport_Handler,
+		},
+		{
+			MethodName: "GetExport",
+			Handler:    _Admin_GetExport_Handler,
+		},
+		{
+			MethodName: "GetConsumerLag",
+			Handler:    _Admin_GetConsumerLag_Handler,
+		},
+		{
+			MethodName: "GetUsage",
+			Handler:    _Admin_GetUsage_Handler,
+		},
+		{
+			MethodName: "ListACLs",
+			Handler:    _Admin_ListACLs_Handler,
+		},
+		{
+			MethodName: "AddACL",
+			Handler:    _Admin_AddACL_Handler,
+		},
+		{
+			MethodName: "RemoveACL",
+			Handler:    _Admin_RemoveACL_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/admin/v1/admin.proto",
+}
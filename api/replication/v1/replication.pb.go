@@ -0,0 +1,267 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.32.1
+// source: api/replication/v1/replication.proto
+
+package replication_v1
+
+import (
+	v1 "github.com/kentakki416/proglog/api/v1"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ReplicateEntriesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Topic string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"` // empty for the default log
+	Term  uint64                 `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`  // leader term; requests from an older term for the topic are rejected
+	// records with their offsets set; offsets must continue from the follower's next offset
+	// (records below it are treated as already replicated and skipped)
+	Records       []*v1.Record `protobuf:"bytes,3,rep,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicateEntriesRequest) Reset() {
+	*x = ReplicateEntriesRequest{}
+	mi := &file_api_replication_v1_replication_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicateEntriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicateEntriesRequest) ProtoMessage() {}
+
+func (x *ReplicateEntriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_replication_v1_replication_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicateEntriesRequest.ProtoReflect.Descriptor instead.
+func (*ReplicateEntriesRequest) Descriptor() ([]byte, []int) {
+	return file_api_replication_v1_replication_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ReplicateEntriesRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *ReplicateEntriesRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *ReplicateEntriesRequest) GetRecords() []*v1.Record {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type ReplicateEntriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NextOffset    uint64                 `protobuf:"varint,1,opt,name=next_offset,json=nextOffset,proto3" json:"next_offset,omitempty"` // follower's next offset after applying the request
+	Term          uint64                 `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`                               // highest term the follower has seen for the topic
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicateEntriesResponse) Reset() {
+	*x = ReplicateEntriesResponse{}
+	mi := &file_api_replication_v1_replication_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicateEntriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicateEntriesResponse) ProtoMessage() {}
+
+func (x *ReplicateEntriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_replication_v1_replication_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicateEntriesResponse.ProtoReflect.Descriptor instead.
+func (*ReplicateEntriesResponse) Descriptor() ([]byte, []int) {
+	return file_api_replication_v1_replication_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ReplicateEntriesResponse) GetNextOffset() uint64 {
+	if x != nil {
+		return x.NextOffset
+	}
+	return 0
+}
+
+func (x *ReplicateEntriesResponse) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+type FetchSegmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"` // empty for the default log
+	FromOffset    uint64                 `protobuf:"varint,2,opt,name=from_offset,json=fromOffset,proto3" json:"from_offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchSegmentRequest) Reset() {
+	*x = FetchSegmentRequest{}
+	mi := &file_api_replication_v1_replication_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchSegmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchSegmentRequest) ProtoMessage() {}
+
+func (x *FetchSegmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_replication_v1_replication_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchSegmentRequest.ProtoReflect.Descriptor instead.
+func (*FetchSegmentRequest) Descriptor() ([]byte, []int) {
+	return file_api_replication_v1_replication_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FetchSegmentRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *FetchSegmentRequest) GetFromOffset() uint64 {
+	if x != nil {
+		return x.FromOffset
+	}
+	return 0
+}
+
+var File_api_replication_v1_replication_proto protoreflect.FileDescriptor
+
+const file_api_replication_v1_replication_proto_rawDesc = "" +
+	"\n" +
+	"$api/replication/v1/replication.proto\x12\x0ereplication.v1\x1a\x10api/v1/log.proto\"m\n" +
+	"\x17ReplicateEntriesRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x04R\x04term\x12(\n" +
+	"\arecords\x18\x03 \x03(\v2\x0e.log.v1.RecordR\arecords\"O\n" +
+	"\x18ReplicateEntriesResponse\x12\x1f\n" +
+	"\vnext_offset\x18\x01 \x01(\x04R\n" +
+	"nextOffset\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x04R\x04term\"L\n" +
+	"\x13FetchSegmentRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1f\n" +
+	"\vfrom_offset\x18\x02 \x01(\x04R\n" +
+	"fromOffset2\xc5\x01\n" +
+	"\vReplication\x12g\n" +
+	"\x10ReplicateEntries\x12'.replication.v1.ReplicateEntriesRequest\x1a(.replication.v1.ReplicateEntriesResponse\"\x00\x12M\n" +
+	"\fFetchSegment\x12#.replication.v1.FetchSegmentRequest\x1a\x14.log.v1.SegmentChunk\"\x000\x01BBZ@github.com/kentakki416/proglog/api/replication/v1;replication_v1b\x06proto3"
+
+var (
+	file_api_replication_v1_replication_proto_rawDescOnce sync.Once
+	file_api_replication_v1_replication_proto_rawDescData []byte
+)
+
+func file_api_replication_v1_replication_proto_rawDescGZIP() []byte {
+	file_api_replication_v1_replication_proto_rawDescOnce.Do(func() {
+		file_api_replication_v1_replication_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_replication_v1_replication_proto_rawDesc), len(file_api_replication_v1_replication_proto_rawDesc)))
+	})
+	return file_api_replication_v1_replication_proto_rawDescData
+}
+
+var file_api_replication_v1_replication_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_api_replication_v1_replication_proto_goTypes = []any{
+	(*ReplicateEntriesRequest)(nil),  // 0: replication.v1.ReplicateEntriesRequest
+	(*ReplicateEntriesResponse)(nil), // 1: replication.v1.ReplicateEntriesResponse
+	(*FetchSegmentRequest)(nil),      // 2: replication.v1.FetchSegmentRequest
+	(*v1.Record)(nil),                // 3: log.v1.Record
+	(*v1.SegmentChunk)(nil),          // 4: log.v1.SegmentChunk
+}
+var file_api_replication_v1_replication_proto_depIdxs = []int32{
+	3, // 0: replication.v1.ReplicateEntriesRequest.records:type_name -> log.v1.Record
+	0, // 1: replication.v1.Replication.ReplicateEntries:input_type -> replication.v1.ReplicateEntriesRequest
+	2, // 2: replication.v1.Replication.FetchSegment:input_type -> replication.v1.FetchSegmentRequest
+	1, // 3: replication.v1.Replication.ReplicateEntries:output_type -> replication.v1.ReplicateEntriesResponse
+	4, // 4: replication.v1.Replication.FetchSegment:output_type -> log.v1.SegmentChunk
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_api_replication_v1_replication_proto_init() }
+func file_api_replication_v1_replication_proto_init() {
+	if File_api_replication_v1_replication_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_replication_v1_replication_proto_rawDesc), len(file_api_replication_v1_replication_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_replication_v1_replication_proto_goTypes,
+		DependencyIndexes: file_api_replication_v1_replication_proto_depIdxs,
+		MessageInfos:      file_api_replication_v1_replication_proto_msgTypes,
+	}.Build()
+	File_api_replication_v1_replication_proto = out.File
+	file_api_replication_v1_replication_proto_goTypes = nil
+	file_api_replication_v1_replication_proto_depIdxs = nil
+}
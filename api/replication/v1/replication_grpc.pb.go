@@ -0,0 +1,176 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.32.1
+// source: api/replication/v1/replication.proto
+
+package replication_v1
+
+import (
+	context "context"
+	v1 "github.com/kentakki416/proglog/api/v1"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Replication_ReplicateEntries_FullMethodName = "/replication.v1.Replication/ReplicateEntries"
+	Replication_FetchSegment_FullMethodName     = "/replication.v1.Replication/FetchSegment"
+)
+
+// ReplicationClient is the client API for Replication service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Replication: node-to-node API used by followers to copy a leader's log.
+// It is served only when replication is enabled and is authorized against the configured
+// peer identities instead of the public ACL, so client policies never block replication.
+type ReplicationClient interface {
+	// Append records that already carry the offsets assigned by the leader.
+	ReplicateEntries(ctx context.Context, in *ReplicateEntriesRequest, opts ...grpc.CallOption) (*ReplicateEntriesResponse, error)
+	// Stream sealed segment files starting at from_offset (for catching up).
+	FetchSegment(ctx context.Context, in *FetchSegmentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[v1.SegmentChunk], error)
+}
+
+type replicationClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReplicationClient(cc grpc.ClientConnInterface) ReplicationClient {
+	return &replicationClient{cc}
+}
+
+func (c *replicationClient) ReplicateEntries(ctx context.Context, in *ReplicateEntriesRequest, opts ...grpc.CallOption) (*ReplicateEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplicateEntriesResponse)
+	err := c.cc.Invoke(ctx, Replication_ReplicateEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *replicationClient) FetchSegment(ctx context.Context, in *FetchSegmentRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[v1.SegmentChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Replication_ServiceDesc.Streams[0], Replication_FetchSegment_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FetchSegmentRequest, v1.SegmentChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Replication_FetchSegmentClient = grpc.ServerStreamingClient[v1.SegmentChunk]
+
+// ReplicationServer is the server API for Replication service.
+// All implementations must embed UnimplementedReplicationServer
+// for forward compatibility.
+//
+// Replication: node-to-node API used by followers to copy a leader's log.
+// It is served only when replication is enabled and is authorized against the configured
+// peer identities instead of the public ACL, so client policies never block replication.
+type ReplicationServer interface {
+	// Append records that already carry the offsets assigned by the leader.
+	ReplicateEntries(context.Context, *ReplicateEntriesRequest) (*ReplicateEntriesResponse, error)
+	// Stream sealed segment files starting at from_offset (for catching up).
+	FetchSegment(*FetchSegmentRequest, grpc.ServerStreamingServer[v1.SegmentChunk]) error
+	mustEmbedUnimplementedReplicationServer()
+}
+
+// UnimplementedReplicationServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedReplicationServer struct{}
+
+func (UnimplementedReplicationServer) ReplicateEntries(context.Context, *ReplicateEntriesRequest) (*ReplicateEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplicateEntries not implemented")
+}
+func (UnimplementedReplicationServer) FetchSegment(*FetchSegmentRequest, grpc.ServerStreamingServer[v1.SegmentChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method FetchSegment not implemented")
+}
+func (UnimplementedReplicationServer) mustEmbedUnimplementedReplicationServer() {}
+func (UnimplementedReplicationServer) testEmbeddedByValue()                     {}
+
+// UnsafeReplicationServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReplicationServer will
+// result in compilation errors.
+type UnsafeReplicationServer interface {
+	mustEmbedUnimplementedReplicationServer()
+}
+
+func RegisterReplicationServer(s grpc.ServiceRegistrar, srv ReplicationServer) {
+	// If the following call pancis, it indicates UnimplementedReplicationServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Replication_ServiceDesc, srv)
+}
+
+func _Replication_ReplicateEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicateEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReplicationServer).ReplicateEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Replication_ReplicateEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicationServer).ReplicateEntries(ctx, req.(*ReplicateEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Replication_FetchSegment_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FetchSegmentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReplicationServer).FetchSegment(m, &grpc.GenericServerStream[FetchSegmentRequest, v1.SegmentChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Replication_FetchSegmentServer = grpc.ServerStreamingServer[v1.SegmentChunk]
+
+// Replication_ServiceDesc is the grpc.ServiceDesc for Replication service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Replication_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "replication.v1.Replication",
+	HandlerType: (*ReplicationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ReplicateEntries",
+			Handler:    _Replication_ReplicateEntries_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "FetchSegment",
+			Handler:       _Replication_FetchSegment_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/replication/v1/replication.proto",
+}
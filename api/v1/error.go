@@ -30,3 +30,248 @@ func (e ErrOffsetOutOfRange) GRPCStatus() *status.Status {
 func (e ErrOffsetOutOfRange) Error() string {
 	return e.GRPCStatus().Err().Error()
 }
+
+// ErrOffsetGap: 割り当てられたオフセットが直前のオフセットから連続していない場合のエラー
+// 複製のバグなどでレコードの順序が壊れたことを表す。
+type ErrOffsetGap struct {
+	Expected uint64
+	Actual   uint64
+}
+
+func (e ErrOffsetGap) GRPCStatus() *status.Status {
+	return status.New(
+		codes.DataLoss,
+		fmt.Sprintf("offset gap: expected %d, got %d", e.Expected, e.Actual),
+	)
+}
+
+func (e ErrOffsetGap) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// ErrOffsetMismatch: 指定されたオフセットでレコードを追加しようとしたが、次に割り当てられるオフセットと異なる場合のエラー
+// 複製先が遅れている、または先行している場合に発生する。
+type ErrOffsetMismatch struct {
+	Expected uint64 // 次に割り当てられるオフセット
+	Actual   uint64 // 指定されたオフセット
+}
+
+func (e ErrOffsetMismatch) GRPCStatus() *status.Status {
+	return status.New(
+		codes.FailedPrecondition,
+		fmt.Sprintf("offset mismatch: next offset is %d, got %d", e.Expected, e.Actual),
+	)
+}
+
+func (e ErrOffsetMismatch) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// ErrTopicNotFound: 指定されたトピックが存在しない場合のエラー
+type ErrTopicNotFound struct {
+	Topic string
+}
+
+func (e ErrTopicNotFound) GRPCStatus() *status.Status {
+	return status.New(codes.NotFound, fmt.Sprintf("topic not found: %q", e.Topic))
+}
+
+func (e ErrTopicNotFound) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// ErrTopicExists: 作成しようとしたトピックが既に存在する場合のエラー
+type ErrTopicExists struct {
+	Topic string
+}
+
+func (e ErrTopicExists) GRPCStatus() *status.Status {
+	return status.New(codes.AlreadyExists, fmt.Sprintf("topic already exists: %q", e.Topic))
+}
+
+func (e ErrTopicExists) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// ErrKeyNotFound: 指定されたキーの値がビューに存在しない場合のエラー
+type ErrKeyNotFound struct {
+	Key []byte
+}
+
+func (e ErrKeyNotFound) GRPCStatus() *status.Status {
+	return status.New(codes.NotFound, fmt.Sprintf("key not found: %q", e.Key))
+}
+
+func (e ErrKeyNotFound) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// ErrOutOfOrderSequence: プロデューサーのシーケンス番号が、既に追加したレコードより古い場合のエラー
+type ErrOutOfOrderSequence struct {
+	ProducerId string
+	Sequence   uint64
+	Last       uint64
+}
+
+func (e ErrOutOfOrderSequence) GRPCStatus() *status.Status {
+	return status.New(
+		codes.FailedPrecondition,
+		fmt.Sprintf("out of order sequence for producer %q: got %d, last %d", e.ProducerId, e.Sequence, e.Last),
+	)
+}
+
+func (e ErrOutOfOrderSequence) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// ErrOffsetNotCommitted: コンシューマーグループがトピックのオフセットをコミットしていない場合のエラー
+type ErrOffsetNotCommitted struct {
+	Group string
+	Topic string
+}
+
+func (e ErrOffsetNotCommitted) GRPCStatus() *status.Status {
+	return status.New(
+		codes.NotFound,
+		fmt.Sprintf("no committed offset for group %q on topic %q", e.Group, e.Topic),
+	)
+}
+
+func (e ErrOffsetNotCommitted) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// ErrCorruptSegment: セグメントの破損を検出して隔離しているため、レコードを読み取れない場合のエラー
+// 隔離中のセグメントのオフセット [BaseOffset, NextOffset) のレコードはすべて読み取れない。
+type ErrCorruptSegment struct {
+	BaseOffset uint64 // 隔離中のセグメントの開始オフセット
+	NextOffset uint64 // 隔離中のセグメントの次のオフセット（最後のレコードのオフセット + 1）
+	Reason     string // 検出した破損の内容
+}
+
+func (e ErrCorruptSegment) GRPCStatus() *status.Status {
+	st := status.New(
+		codes.DataLoss,
+		fmt.Sprintf("segment %d is quarantined: %s", e.BaseOffset, e.Reason),
+	)
+	std, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: "CORRUPT_SEGMENT",
+		Domain: "proglog",
+		Metadata: map[string]string{
+			"base_offset": fmt.Sprint(e.BaseOffset),
+			"next_offset": fmt.Sprint(e.NextOffset),
+		},
+	})
+	if err != nil {
+		return st
+	}
+	return std
+}
+
+func (e ErrCorruptSegment) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// ErrCorruptRecord: レコードのデータが壊れていて読み取れない場合のエラー
+// 壊れているのはこのオフセットのレコードだけで、後続のレコードは読み取れる場合がある。
+type ErrCorruptRecord struct {
+	Offset uint64 // 読み取れないレコードのオフセット
+	Reason string // 検出した破損の内容
+}
+
+func (e ErrCorruptRecord) GRPCStatus() *status.Status {
+	st := status.New(codes.DataLoss, fmt.Sprintf("record %d is corrupt: %s", e.Offset, e.Reason))
+	std, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   "CORRUPT_RECORD",
+		Domain:   "proglog",
+		Metadata: map[string]string{"offset": fmt.Sprint(e.Offset)},
+	})
+	if err != nil {
+		return st
+	}
+	return std
+}
+
+func (e ErrCorruptRecord) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// ErrTreeSize: 証明を要求したツリーのサイズが、Merkle ツリーに追加済みのレコードの数より大きい場合のエラー
+// ツリーにはレコードを非同期に追加するため、しばらく待ってから再試行できる。
+type ErrTreeSize struct {
+	Size     uint64 // 要求されたツリーのサイズ
+	TreeSize uint64 // ツリーに追加済みのレコードの数
+}
+
+func (e ErrTreeSize) GRPCStatus() *status.Status {
+	st := status.New(codes.OutOfRange, fmt.Sprintf("tree size %d is larger than the tree (%d records)", e.Size, e.TreeSize))
+	std, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: "TREE_SIZE_OUT_OF_RANGE",
+		Domain: "proglog",
+		Metadata: map[string]string{
+			"size":      fmt.Sprint(e.Size),
+			"tree_size": fmt.Sprint(e.TreeSize),
+		},
+	})
+	if err != nil {
+		return st
+	}
+	return std
+}
+
+func (e ErrTreeSize) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// ErrRecordDelayed: 読み取るレコードの配信時刻（deliver_after）がまだ来ていない場合のエラー
+// 配信時刻を過ぎると読み取れるため、コンシューマーは配信時刻まで待ってから再試行できる。
+type ErrRecordDelayed struct {
+	Offset       uint64 // レコードのオフセット
+	DeliverAfter int64  // レコードの配信時刻（Unix ミリ秒）
+}
+
+func (e ErrRecordDelayed) GRPCStatus() *status.Status {
+	st := status.New(codes.FailedPrecondition, fmt.Sprintf("record %d is delayed until %d", e.Offset, e.DeliverAfter))
+	std, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: "RECORD_DELAYED",
+		Domain: "proglog",
+		Metadata: map[string]string{
+			"offset":        fmt.Sprint(e.Offset),
+			"deliver_after": fmt.Sprint(e.DeliverAfter),
+		},
+	})
+	if err != nil {
+		return st
+	}
+	return std
+}
+
+func (e ErrRecordDelayed) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// ErrDiskFull: ディスクが一杯のためログが読み取り専用になっている場合のエラー
+// 空き容量が戻ると自動的に書き込みを再開するため、プロデューサーは時間をおいて再送できる。
+type ErrDiskFull struct {
+	Reason string // 書き込みに失敗した原因（ENOSPC のエラーメッセージ）
+}
+
+func (e ErrDiskFull) GRPCStatus() *status.Status {
+	st := status.New(
+		codes.ResourceExhausted,
+		fmt.Sprintf("log is read-only because the disk is full: %s", e.Reason),
+	)
+	std, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   "DISK_FULL",
+		Domain:   "proglog",
+		Metadata: map[string]string{"cause": e.Reason},
+	})
+	if err != nil {
+		return st
+	}
+	return std
+}
+
+func (e ErrDiskFull) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
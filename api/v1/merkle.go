@@ -0,0 +1,245 @@
+package log_v1
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrInvalidTreeHead: ツリーヘッドの署名が検証できない場合のエラー
+var ErrInvalidTreeHead = errors.New("invalid tree head")
+
+// ErrInvalidProof: 包含証明または一貫性証明がツリーのルートハッシュと一致しない場合のエラー
+var ErrInvalidProof = errors.New("invalid merkle proof")
+
+// RFC 6962 のハッシュのドメイン分離用のプレフィックス（葉と内部ノードのハッシュが衝突しないようにする）
+const (
+	leafHashPrefix = 0x00
+	nodeHashPrefix = 0x01
+)
+
+// LeafHash: レコードの Merkle ツリーの葉のハッシュを返す
+// サーバーが保存したレコード（オフセットとタイムスタンプを設定したもの）の決定的なエンコーディングを
+// SHA-256(0x00 || エンコーディング) でハッシュする。
+func LeafHash(record *Record) ([]byte, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write([]byte{leafHashPrefix})
+	h.Write(b)
+	return h.Sum(nil), nil
+}
+
+// NodeHash: 左右の子のハッシュから内部ノードのハッシュ SHA-256(0x01 || left || right) を返す
+func NodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodeHashPrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// RootHash: 葉のハッシュを順に並べた Merkle ツリーのルートハッシュ（RFC 6962 の MTH）を返す
+// 監査でログのレコードをすべて読み取り、ツリーヘッドの root_hash と比べるときに使用する。
+// 葉がない場合は空文字列の SHA-256 を返す。
+func RootHash(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	case 1:
+		return leaves[0]
+	}
+	// 葉の数より小さい最大の2のべき乗で左右に分ける
+	k := 1
+	for k*2 < len(leaves) {
+		k *= 2
+	}
+	return NodeHash(RootHash(leaves[:k]), RootHash(leaves[k:]))
+}
+
+// VerifyInclusion: 葉が size 個の葉を持つツリーの index 番目に含まれることを包含証明で検証する（RFC 9162 2.1.3.2）
+// 引数:
+//   - leaf: 葉のハッシュ（LeafHash の戻り値）
+//   - index: 葉の位置
+//   - size: ツリーの葉の数
+//   - proof: GetInclusionProof のレスポンスの hashes
+//   - root: size 個の葉を持つツリーのルートハッシュ（検証済みのツリーヘッドの root_hash）
+//
+// 戻り値:
+//   - error: 検証に失敗した場合（ErrInvalidProof をラップしたエラー）
+func VerifyInclusion(leaf []byte, index, size uint64, proof [][]byte, root []byte) error {
+	if index >= size {
+		return fmt.Errorf("%w: index %d is not in a tree of size %d", ErrInvalidProof, index, size)
+	}
+	fn, sn := index, size-1
+	r := leaf
+	for _, p := range proof {
+		if sn == 0 {
+			return fmt.Errorf("%w: proof is too long", ErrInvalidProof)
+		}
+		if fn&1 == 1 || fn == sn {
+			r = NodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = NodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return fmt.Errorf("%w: proof is too short", ErrInvalidProof)
+	}
+	if !bytes.Equal(r, root) {
+		return fmt.Errorf("%w: root hash does not match", ErrInvalidProof)
+	}
+	return nil
+}
+
+// VerifyRecordInclusion: レコードがツリーヘッドのツリーに含まれることを包含証明で検証する
+// 監査で読み取ったレコードが、以前に保存したツリーヘッドの時点から書き換えられていないことを確認するときに使用する。
+// ツリーヘッドの署名は VerifyTreeHead で別に検証する。
+// 引数:
+//   - head: 検証済みのツリーヘッド
+//   - record: ログから読み取ったレコード
+//   - proof: tree_size に head.TreeSize を指定した GetInclusionProof のレスポンスの hashes
+//
+// 戻り値:
+//   - error: 検証に失敗した場合（ErrInvalidProof をラップしたエラー）
+func VerifyRecordInclusion(head *TreeHead, record *Record, proof [][]byte) error {
+	if record.Offset < head.FirstOffset {
+		return fmt.Errorf("%w: record %d is before the first offset %d of the tree", ErrInvalidProof, record.Offset, head.FirstOffset)
+	}
+	leaf, err := LeafHash(record)
+	if err != nil {
+		return err
+	}
+	return VerifyInclusion(leaf, record.Offset-head.FirstOffset, head.TreeSize, proof, head.RootHash)
+}
+
+// VerifyConsistency: oldSize 個の葉のツリーが newSize 個の葉のツリーの先頭部分であることを一貫性証明で検証する（RFC 9162 2.1.4.2）
+// 監査者は以前に保存したツリーヘッドと新しいツリーヘッドの間で検証し、過去のレコードが書き換えられていないことを確認する。
+// 引数:
+//   - oldSize: 古いツリーの葉の数
+//   - newSize: 新しいツリーの葉の数
+//   - oldRoot: 古いツリーのルートハッシュ
+//   - newRoot: 新しいツリーのルートハッシュ
+//   - proof: GetConsistencyProof のレスポンスの hashes
+//
+// 戻り値:
+//   - error: 検証に失敗した場合（ErrInvalidProof をラップしたエラー）
+func VerifyConsistency(oldSize, newSize uint64, oldRoot, newRoot []byte, proof [][]byte) error {
+	switch {
+	case oldSize > newSize:
+		return fmt.Errorf("%w: old size %d is larger than new size %d", ErrInvalidProof, oldSize, newSize)
+	case oldSize == newSize:
+		if len(proof) != 0 {
+			return fmt.Errorf("%w: proof for equal sizes must be empty", ErrInvalidProof)
+		}
+		if !bytes.Equal(oldRoot, newRoot) {
+			return fmt.Errorf("%w: root hashes of the same size differ", ErrInvalidProof)
+		}
+		return nil
+	case oldSize == 0:
+		// 空のツリーはどのツリーの先頭部分でもある
+		if len(proof) != 0 {
+			return fmt.Errorf("%w: proof from an empty tree must be empty", ErrInvalidProof)
+		}
+		return nil
+	}
+	if len(proof) == 0 {
+		return fmt.Errorf("%w: proof is empty", ErrInvalidProof)
+	}
+	// 古いツリーが完全二分木の場合、そのルートは証明に含まれないため先頭に補う
+	if oldSize&(oldSize-1) == 0 {
+		proof = append([][]byte{oldRoot}, proof...)
+	}
+	fn, sn := oldSize-1, newSize-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return fmt.Errorf("%w: proof is too long", ErrInvalidProof)
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = NodeHash(c, fr)
+			sr = NodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = NodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return fmt.Errorf("%w: proof is too short", ErrInvalidProof)
+	}
+	if !bytes.Equal(fr, oldRoot) || !bytes.Equal(sr, newRoot) {
+		return fmt.Errorf("%w: root hash does not match", ErrInvalidProof)
+	}
+	return nil
+}
+
+// SignedBytes: ツリーヘッドの署名の対象のバイト列（signature を除いたツリーヘッドの決定的なエンコーディング）を返す
+func (x *TreeHead) SignedBytes() ([]byte, error) {
+	unsigned := proto.Clone(x).(*TreeHead)
+	unsigned.Signature = nil
+	return proto.MarshalOptions{Deterministic: true}.Marshal(unsigned)
+}
+
+// SignTreeHead: ツリーヘッドに署名して signature を設定する（署名の方式は SignReceipt と同じ）
+// 引数:
+//   - head: 署名するツリーヘッド
+//   - key: サーバーの署名鍵
+//
+// 戻り値:
+//   - error: 署名できなかった場合
+func SignTreeHead(head *TreeHead, key crypto.Signer) error {
+	b, err := head.SignedBytes()
+	if err != nil {
+		return err
+	}
+	sig, err := sign(b, key)
+	if err != nil {
+		return err
+	}
+	head.Signature = sig
+	return nil
+}
+
+// VerifyTreeHead: ツリーヘッドの署名を検証する
+// 引数:
+//   - pub: サーバーの公開鍵（ed25519.PublicKey、*ecdsa.PublicKey、*rsa.PublicKey）
+//   - head: 検証するツリーヘッド
+//
+// 戻り値:
+//   - error: 検証に失敗した場合（ErrInvalidTreeHead をラップしたエラー）
+func VerifyTreeHead(pub crypto.PublicKey, head *TreeHead) error {
+	b, err := head.SignedBytes()
+	if err != nil {
+		return err
+	}
+	ok, err := verifySignature(pub, b, head.Signature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: bad signature", ErrInvalidTreeHead)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package log_v1
+
+import "google.golang.org/grpc"
+
+// MessageSizeCallOptions: サーバーの最大メッセージサイズに合わせて、クライアントの送受信の上限を上げる呼び出しのオプションを返す
+// gRPC のクライアントの既定の受信の上限は 4MiB のため、大きなレコードを読み取るクライアントは
+// grpc.WithDefaultCallOptions または RPC ごとのオプションとして指定する。
+// 引数:
+//   - caps: GetCapabilities のレスポンス
+//
+// 戻り値:
+//   - []grpc.CallOption: 送受信の上限のオプション（サーバーが上限を返さない場合は nil）
+func MessageSizeCallOptions(caps *GetCapabilitiesResponse) []grpc.CallOption {
+	n := int(caps.GetMaxMessageBytes())
+	if n == 0 {
+		return nil
+	}
+	return []grpc.CallOption{grpc.MaxCallRecvMsgSize(n), grpc.MaxCallSendMsgSize(n)}
+}
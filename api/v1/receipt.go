@@ -0,0 +1,122 @@
+package log_v1
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrInvalidReceipt: 受領証の署名が検証できない、または受領証がレコードと一致しない場合のエラー
+var ErrInvalidReceipt = errors.New("invalid produce receipt")
+
+// RecordChecksum: 受領証の checksum と比べるレコードの SHA-256 を返す
+// サーバーが保存したレコード（オフセットとタイムスタンプを設定したもの）を決定的なエンコーディングでハッシュする。
+func RecordChecksum(record *Record) ([]byte, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(b)
+	return sum[:], nil
+}
+
+// SignedBytes: 受領証の署名の対象のバイト列（signature を除いた受領証の決定的なエンコーディング）を返す
+func (x *ProduceReceipt) SignedBytes() ([]byte, error) {
+	unsigned := proto.Clone(x).(*ProduceReceipt)
+	unsigned.Signature = nil
+	return proto.MarshalOptions{Deterministic: true}.Marshal(unsigned)
+}
+
+// SignReceipt: 受領証に署名して signature を設定する
+// Ed25519 の鍵は SignedBytes をそのまま署名し、それ以外の鍵（ECDSA、RSA）は SignedBytes の SHA-256 を署名する。
+// 引数:
+//   - receipt: 署名する受領証
+//   - key: サーバーの受領証の署名鍵
+//
+// 戻り値:
+//   - error: 署名できなかった場合
+func SignReceipt(receipt *ProduceReceipt, key crypto.Signer) error {
+	b, err := receipt.SignedBytes()
+	if err != nil {
+		return err
+	}
+	sig, err := sign(b, key)
+	if err != nil {
+		return err
+	}
+	receipt.Signature = sig
+	return nil
+}
+
+// VerifyReceipt: 受領証の署名を検証し、レコードが指定された場合は受領証と一致することを確認する
+// プロデューサーが受け取った受領証を保存する前や、監査で受領証とログのレコードを突き合わせるときに使用する。
+// 引数:
+//   - pub: サーバーの受領証の公開鍵（ed25519.PublicKey、*ecdsa.PublicKey、*rsa.PublicKey）
+//   - receipt: 検証する受領証
+//   - record: 受領証のオフセットから読み取ったレコード（nil の場合は署名だけを検証する）
+//
+// 戻り値:
+//   - error: 検証に失敗した場合（ErrInvalidReceipt をラップしたエラー）
+func VerifyReceipt(pub crypto.PublicKey, receipt *ProduceReceipt, record *Record) error {
+	b, err := receipt.SignedBytes()
+	if err != nil {
+		return err
+	}
+	ok, err := verifySignature(pub, b, receipt.Signature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: bad signature", ErrInvalidReceipt)
+	}
+	if record == nil {
+		return nil
+	}
+	if record.Offset != receipt.Offset {
+		return fmt.Errorf("%w: record offset %d, receipt offset %d", ErrInvalidReceipt, record.Offset, receipt.Offset)
+	}
+	sum, err := RecordChecksum(record)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(sum, receipt.Checksum) {
+		return fmt.Errorf("%w: record checksum does not match", ErrInvalidReceipt)
+	}
+	return nil
+}
+
+// sign: 署名の対象のバイト列に署名する（受領証とツリーヘッドで共通の方式）
+// Ed25519 の鍵はバイト列をそのまま署名し、それ以外の鍵（ECDSA、RSA）はバイト列の SHA-256 を署名する。
+func sign(b []byte, key crypto.Signer) ([]byte, error) {
+	if _, ok := key.Public().(ed25519.PublicKey); ok {
+		return key.Sign(rand.Reader, b, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(b)
+	return key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// verifySignature: sign で作成した署名を検証する
+// 戻り値:
+//   - bool: 署名が正しい場合は true
+//   - error: 対応していない種類の公開鍵の場合
+func verifySignature(pub crypto.PublicKey, b, sig []byte) (bool, error) {
+	digest := sha256.Sum256(b)
+	switch pub := pub.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(pub, b, sig), nil
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(pub, digest[:], sig), nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil, nil
+	default:
+		return false, fmt.Errorf("unsupported signing key type %T", pub)
+	}
+}
@@ -0,0 +1,195 @@
+// Package logtest: proglog を使用するアプリケーションのテスト用のヘルパー
+// ネットワークを使わずにメモリ上のログと通信する Fake と、
+// 実際のログストアを使用する gRPC サーバーをランダムなポートで起動する StartServer を提供する。
+package logtest
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	adminpb "github.com/kentakki416/proglog/api/admin/v1"
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/kentakki416/proglog/internal/offsets"
+	"github.com/kentakki416/proglog/internal/server"
+	"github.com/kentakki416/proglog/internal/sessions"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// bufSize: Fake のメモリ上の接続のバッファのバイト数
+const bufSize = 1024 * 1024
+
+// Fake: メモリ上のログと通信する api.LogClient
+// 実際のサーバーの実装をメモリ上の接続で呼び出すため、エラーコードなどの振る舞いはサーバーと同じになる。
+// トピック、コンシューマーグループのオフセット、セッションなど、ディスクを使用する機能は無効になっている
+// （必要な場合は StartServer を使用する）。
+type Fake struct {
+	api.LogClient
+	log *memLog
+}
+
+// NewFake: メモリ上のログと通信するクライアントを作成する
+// テストの終了時にサーバーと接続を閉じる。
+// 引数:
+//   - t: テスト（終了時の後片付けと失敗の報告に使用する）
+//
+// 戻り値:
+//   - *Fake: 作成されたクライアント
+func NewFake(t testing.TB) *Fake {
+	t.Helper()
+	mlog := &memLog{}
+	gsrv, err := server.NewGRPCServer(&server.Config{CommitLog: mlog})
+	if err != nil {
+		t.Fatalf("logtest: create server: %v", err)
+	}
+	l := bufconn.Listen(bufSize)
+	go gsrv.Serve(l)
+
+	cc, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("logtest: dial: %v", err)
+	}
+	t.Cleanup(func() {
+		cc.Close()
+		gsrv.Stop()
+	})
+	return &Fake{LogClient: api.NewLogClient(cc), log: mlog}
+}
+
+// Records: これまでに書き込まれたレコードのコピーをオフセットの順に返す（Truncate で削除したレコードは含まない）
+func (f *Fake) Records() []*api.Record {
+	return f.log.snapshot()
+}
+
+// memLog: レコードをメモリ上に保持するログストア（server.CommitLog の実装）
+type memLog struct {
+	mu      sync.RWMutex
+	records []*api.Record
+	lowest  uint64 // records[0] のオフセット
+}
+
+// Append: レコードを追加し、割り当てたオフセットを返す
+func (l *memLog) Append(record *api.Record) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	record.Offset = l.lowest + uint64(len(l.records))
+	l.records = append(l.records, proto.Clone(record).(*api.Record))
+	return record.Offset, nil
+}
+
+// Read: 指定されたオフセットのレコードを読み取る
+func (l *memLog) Read(off uint64) (*api.Record, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if off < l.lowest || off >= l.lowest+uint64(len(l.records)) {
+		return nil, api.ErrOffsetOutOfRange{Offset: off}
+	}
+	return proto.Clone(l.records[off-l.lowest]).(*api.Record), nil
+}
+
+// Truncate: 指定されたオフセット以下のレコードを削除する
+func (l *memLog) Truncate(lowest uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for len(l.records) > 0 && l.lowest <= lowest {
+		l.records = l.records[1:]
+		l.lowest++
+	}
+	return nil
+}
+
+// LowestOffset: 保持している最小のオフセットを返す
+func (l *memLog) LowestOffset() (uint64, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.lowest, nil
+}
+
+// snapshot: 保持しているレコードのコピーを返す
+func (l *memLog) snapshot() []*api.Record {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	records := make([]*api.Record, len(l.records))
+	for i, record := range l.records {
+		records[i] = proto.Clone(record).(*api.Record)
+	}
+	return records
+}
+
+// Server: StartServer で起動した gRPC サーバー
+type Server struct {
+	Addr   string              // サーバーが待ち受けているアドレス（127.0.0.1 のランダムなポート）
+	Client api.LogClient       // サーバーに接続した Log サービスのクライアント
+	Admin  adminpb.AdminClient // サーバーに接続した管理 API のクライアント
+}
+
+// StartServer: 一時ディレクトリのログストアを使用する gRPC サーバーをランダムなポートで起動する
+// トピック、コンシューマーグループのオフセット、セッションを有効にした、TLS と認可のないサーバーを起動する。
+// テストの終了時にサーバーを停止し、一時ディレクトリを削除する。
+// 引数:
+//   - t: テスト（終了時の後片付けと失敗の報告に使用する）
+//
+// 戻り値:
+//   - *Server: 起動したサーバーと、接続済みのクライアント
+func StartServer(t testing.TB) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	clog, err := log.NewLog(t.TempDir(), log.Config{})
+	if err != nil {
+		t.Fatalf("logtest: open log: %v", err)
+	}
+	t.Cleanup(func() { clog.Close() })
+	topics, err := log.NewTopics(t.TempDir(), log.Config{})
+	if err != nil {
+		t.Fatalf("logtest: open topics: %v", err)
+	}
+	t.Cleanup(func() { topics.Close() })
+	offsetStore, err := offsets.NewStore(filepath.Join(dir, "offsets.json"))
+	if err != nil {
+		t.Fatalf("logtest: open offsets: %v", err)
+	}
+
+	gsrv, err := server.NewGRPCServer(&server.Config{
+		CommitLog: clog,
+		Topics:    topics,
+		Offsets:   offsetStore,
+		Sessions:  sessions.NewRegistry(10*time.Second, log.SystemClock),
+		ExportDir: filepath.Join(dir, "exports"),
+	})
+	if err != nil {
+		t.Fatalf("logtest: create server: %v", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("logtest: listen: %v", err)
+	}
+	go gsrv.Serve(l)
+
+	cc, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		gsrv.Stop()
+		t.Fatalf("logtest: dial: %v", err)
+	}
+	// t.Cleanup は登録と逆の順に実行されるため、ログストアを閉じる前にサーバーが停止する
+	t.Cleanup(func() {
+		cc.Close()
+		gsrv.Stop()
+	})
+	return &Server{
+		Addr:   l.Addr().String(),
+		Client: api.NewLogClient(cc),
+		Admin:  adminpb.NewAdminClient(cc),
+	}
+}
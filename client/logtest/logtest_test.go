@@ -0,0 +1,47 @@
+package logtest
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFake(t *testing.T) {
+	fake := NewFake(t)
+	ctx := context.Background()
+
+	for _, v := range []string{"first", "second"} {
+		_, err := fake.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte(v)}})
+		require.NoError(t, err)
+	}
+	res, err := fake.Consume(ctx, &api.ConsumeRequest{Offset: 1})
+	require.NoError(t, err)
+	require.Equal(t, "second", string(res.Record.Value))
+
+	records := fake.Records()
+	require.Len(t, records, 2)
+	require.Equal(t, uint64(1), records[1].Offset)
+
+	// 範囲外の読み取りやディスクを使用する機能は、実際のサーバーと同じエラーを返す
+	_, err = fake.Consume(ctx, &api.ConsumeRequest{Offset: 2})
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+	_, err = fake.Produce(ctx, &api.ProduceRequest{Topic: "orders", Record: &api.Record{Value: []byte("x")}})
+	require.Error(t, err)
+}
+
+func TestStartServer(t *testing.T) {
+	srv := StartServer(t)
+	ctx := context.Background()
+
+	_, err := srv.Admin.CreateTopic(ctx, &api.CreateTopicRequest{Name: "orders"})
+	require.NoError(t, err)
+	produce, err := srv.Client.Produce(ctx, &api.ProduceRequest{Topic: "orders", Record: &api.Record{Value: []byte("hello")}})
+	require.NoError(t, err)
+	res, err := srv.Client.Consume(ctx, &api.ConsumeRequest{Topic: "orders", Offset: produce.Offset})
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(res.Record.Value))
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/backup"
+	"github.com/kentakki416/proglog/internal/config"
+	"github.com/kentakki416/proglog/internal/descriptors"
+	"github.com/kentakki416/proglog/internal/export"
+	plog "github.com/kentakki416/proglog/internal/log"
+	"github.com/kentakki416/proglog/internal/redact"
+	"google.golang.org/grpc"
+)
+
+const usage = `usage: proglogctl [flags] <command>
+
+commands:
+  offsets export [-o file]            export committed consumer-group offsets as JSON
+  offsets import [-replace] [-f file] import committed consumer-group offsets from JSON
+  export parquet [-topic t] [-from n] [-to n] [-since time] [-until time] [-columns spec] [-wait]
+                                      export a range of the log as a Parquet file on the server
+  export jsonl [-topic t] [-from n] [-to n] [-since time] [-until time] [-encoding e] [-redact file] [-o file]
+                                      export a range of the log as JSON Lines
+  import jsonl [-topic t] [-encoding e] [-f file]
+                                      append records from JSON Lines to the log
+  log flush [-topic t]                sync the log to disk before taking a filesystem snapshot
+  backup list -bucket dir             list backups in a bucket (runs locally)
+  backup restore -bucket dir -dir dir [-name n] [-to-offset n] [-to-timestamp time]
+                 [-max-store-bytes n] [-max-index-bytes n]
+                                      restore a backup, optionally up to a point, into an empty log directory (runs locally)
+  descriptors generate [-o file]      write a FileDescriptorSet of the API protos for non-Go clients (runs locally)
+
+flags:
+`
+
+func main() {
+	addr := flag.String("addr", "localhost:8400", "server address")
+	caFile := flag.String("ca", "", "CA certificate file (plaintext if empty)")
+	certFile := flag.String("cert", "", "client certificate file")
+	keyFile := flag.String("key", "", "client key file")
+	timeout := flag.Duration("timeout", 10*time.Second, "request timeout")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	provider := config.Insecure
+	if *caFile != "" {
+		provider = config.TLSConfig{
+			CertFile: *certFile,
+			KeyFile:  *keyFile,
+			CAFile:   *caFile,
+		}
+	}
+	creds, err := provider.TransportCredentials()
+	if err != nil {
+		log.Fatal(err)
+	}
+	cc, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer cc.Close()
+	client := api.NewLogClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	switch args[0] + " " + args[1] {
+	case "offsets export":
+		err = exportOffsets(ctx, client, args[2:])
+	case "offsets import":
+		err = importOffsets(ctx, client, args[2:])
+	case "export parquet":
+		err = exportParquet(ctx, client, args[2:])
+	case "export jsonl":
+		err = exportJSONL(ctx, client, args[2:])
+	case "import jsonl":
+		err = importJSONL(ctx, client, args[2:])
+	case "log flush":
+		err = flushLog(ctx, client, args[2:])
+	case "backup list":
+		err = listBackups(args[2:])
+	case "backup restore":
+		err = restoreBackup(args[2:])
+	case "descriptors generate":
+		err = generateDescriptors(args[2:])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// exportOffsets: コミット済みオフセットを JSON でファイル（または標準出力）に書き出す
+func exportOffsets(ctx context.Context, client api.LogClient, args []string) error {
+	fs := flag.NewFlagSet("offsets export", flag.ExitOnError)
+	out := fs.String("o", "-", "output file (- for stdout)")
+	fs.Parse(args)
+
+	res, err := client.ExportOffsets(ctx, &api.ExportOffsetsRequest{})
+	if err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err = fmt.Fprintln(os.Stdout, string(res.Checkpoint))
+		return err
+	}
+	return os.WriteFile(*out, res.Checkpoint, 0600)
+}
+
+// importOffsets: ファイル（または標準入力）の JSON からコミット済みオフセットを取り込む
+func importOffsets(ctx context.Context, client api.LogClient, args []string) error {
+	fs := flag.NewFlagSet("offsets import", flag.ExitOnError)
+	in := fs.String("f", "-", "input file (- for stdin)")
+	replace := fs.Bool("replace", false, "replace all existing offsets instead of merging")
+	fs.Parse(args)
+
+	var (
+		b   []byte
+		err error
+	)
+	if *in == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(*in)
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := client.ImportOffsets(ctx, &api.ImportOffsetsRequest{
+		Checkpoint: b,
+		Replace:    *replace,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d offsets\n", res.Imported)
+	return nil
+}
+
+// exportParquet: サーバーでログの範囲を Parquet ファイルにエクスポートするジョブを開始する
+// -wait を指定した場合はジョブが完了するまで待つ（-timeout の時間内に完了する必要がある）。
+func exportParquet(ctx context.Context, client api.LogClient, args []string) error {
+	fs := flag.NewFlagSet("export parquet", flag.ExitOnError)
+	topic := fs.String("topic", "", "topic to export (default log if empty)")
+	from := fs.Uint64("from", 0, "first offset to export")
+	to := fs.Uint64("to", 0, "offset to stop before (0 for end of log)")
+	since := fs.String("since", "", "export records with timestamp at or after this RFC 3339 time")
+	until := fs.String("until", "", "export records with timestamp before this RFC 3339 time")
+	columns := fs.String("columns", "", "columns as [name=]field[:string],... (default offset,timestamp,key,value)")
+	wait := fs.Bool("wait", false, "wait for the export to finish")
+	fs.Parse(args)
+
+	req := &api.StartExportRequest{
+		Topic:      *topic,
+		FromOffset: *from,
+		ToOffset:   *to,
+		Columns:    *columns,
+	}
+	var err error
+	if req.FromTime, err = parseTime(*since); err != nil {
+		return err
+	}
+	if req.ToTime, err = parseTime(*until); err != nil {
+		return err
+	}
+
+	res, err := client.StartExport(ctx, req)
+	if err != nil {
+		return err
+	}
+	if !*wait {
+		fmt.Println(res.JobId)
+		return nil
+	}
+	for {
+		job, err := client.GetExport(ctx, &api.GetExportRequest{JobId: res.JobId})
+		if err != nil {
+			return err
+		}
+		switch job.State {
+		case api.ExportJob_DONE:
+			fmt.Printf("exported %d records to %s\n", job.Records, job.Path)
+			return nil
+		case api.ExportJob_FAILED:
+			return fmt.Errorf("export %s failed: %s", job.JobId, job.Error)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// parseTime: RFC 3339 形式の時刻を unix ミリ秒に変換する（空の場合は 0）
+func parseTime(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, err
+	}
+	return t.UnixMilli(), nil
+}
+
+// exportJSONL: ログの範囲のレコードを JSON Lines でファイル（または標準出力）に書き出す
+func exportJSONL(ctx context.Context, client api.LogClient, args []string) error {
+	fs := flag.NewFlagSet("export jsonl", flag.ExitOnError)
+	topic := fs.String("topic", "", "topic to export (default log if empty)")
+	from := fs.Uint64("from", 0, "first offset to export")
+	to := fs.Uint64("to", 0, "offset to stop before (0 for end of log)")
+	since := fs.String("since", "", "export records with timestamp at or after this RFC 3339 time")
+	until := fs.String("until", "", "export records with timestamp before this RFC 3339 time")
+	encoding := fs.String("encoding", "base64", "key and value encoding (base64 or utf8)")
+	redactFile := fs.String("redact", "", "JSON file with redaction rules applied before writing")
+	out := fs.String("o", "-", "output file (- for stdout)")
+	fs.Parse(args)
+
+	enc, err := export.ParseEncoding(*encoding)
+	if err != nil {
+		return err
+	}
+	var redactor *redact.Redactor
+	if *redactFile != "" {
+		f, err := os.Open(*redactFile)
+		if err != nil {
+			return err
+		}
+		redactor, err = redact.ParseRules(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	r := export.Range{FromOffset: *from, ToOffset: *to}
+	if r.FromTime, err = parseTime(*since); err != nil {
+		return err
+	}
+	if r.ToTime, err = parseTime(*until); err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if *out != "-" {
+		if w, err = os.Create(*out); err != nil {
+			return err
+		}
+		defer w.Close()
+	}
+
+	// 空の絞り込み条件の Query でログの末尾まで読み取る
+	stream, err := client.Query(ctx, &api.QueryRequest{Topic: *topic, FromOffset: r.FromOffset})
+	if err != nil {
+		return err
+	}
+	jw := export.NewJSONLWriter(w, enc)
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if r.ToOffset != 0 && res.Record.Offset >= r.ToOffset {
+			break
+		}
+		if !r.Contains(res.Record) {
+			continue
+		}
+		if redactor != nil {
+			redactor.Apply(res.Record)
+		}
+		if err := jw.Write(res.Record); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "exported %d records\n", jw.Rows())
+	if *out != "-" {
+		return w.Sync()
+	}
+	return nil
+}
+
+// flushLog: サーバーのログをディスクに同期し、同期したオフセットを表示する
+func flushLog(ctx context.Context, client api.LogClient, args []string) error {
+	fs := flag.NewFlagSet("log flush", flag.ExitOnError)
+	topic := fs.String("topic", "", "topic to flush (default log if empty)")
+	fs.Parse(args)
+
+	res, err := client.FlushLog(ctx, &api.FlushLogRequest{Topic: *topic})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("flushed up to offset %d\n", res.NextOffset)
+	return nil
+}
+
+// importJSONL: ファイル（または標準入力）の JSON Lines のレコードをログに追加する
+func importJSONL(ctx context.Context, client api.LogClient, args []string) error {
+	fs := flag.NewFlagSet("import jsonl", flag.ExitOnError)
+	topic := fs.String("topic", "", "topic to append to (default log if empty)")
+	encoding := fs.String("encoding", "base64", "key and value encoding (base64 or utf8)")
+	in := fs.String("f", "-", "input file (- for stdin)")
+	fs.Parse(args)
+
+	enc, err := export.ParseEncoding(*encoding)
+	if err != nil {
+		return err
+	}
+	r := os.Stdin
+	if *in != "-" {
+		if r, err = os.Open(*in); err != nil {
+			return err
+		}
+		defer r.Close()
+	}
+
+	n, err := export.ImportJSONL(r, &producer{ctx: ctx, client: client, topic: *topic}, enc)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d records\n", n)
+	return nil
+}
+
+// producer: gRPC の Produce でレコードを追加する export.Appender
+type producer struct {
+	ctx    context.Context
+	client api.LogClient
+	topic  string
+}
+
+func (p *producer) Append(record *api.Record) (uint64, error) {
+	res, err := p.client.Produce(p.ctx, &api.ProduceRequest{Record: record, Topic: p.topic})
+	if err != nil {
+		return 0, err
+	}
+	return res.Offset, nil
+}
+
+// listBackups: バケット内のバックアップを古い順に表示する（サーバーには接続しない）
+func listBackups(args []string) error {
+	fs := flag.NewFlagSet("backup list", flag.ExitOnError)
+	bucket := fs.String("bucket", "", "backup bucket directory")
+	fs.Parse(args)
+	if *bucket == "" {
+		return fmt.Errorf("-bucket is required")
+	}
+
+	manifests, err := backup.List(backup.DirBucket(*bucket))
+	if err != nil {
+		return err
+	}
+	for _, m := range manifests {
+		var from, to uint64
+		if n := len(m.Segments); n > 0 {
+			from, to = m.Segments[0].BaseOffset, m.Segments[n-1].NextOffset
+		}
+		fmt.Printf("%s\t%s\tepoch=%d\toffsets=[%d,%d)\tsegments=%d\n",
+			m.Name, m.Created.Format(time.RFC3339), m.Epoch, from, to, len(m.Segments))
+	}
+	return nil
+}
+
+// restoreBackup: バックアップから新しいログディレクトリを作成する（サーバーには接続しない）
+// セグメントの最大サイズは、バックアップしたサーバーの設定と同じ値を指定する。
+// -to-offset または -to-timestamp を指定した場合は、その時点より前のレコードだけを復元する。
+func restoreBackup(args []string) error {
+	fs := flag.NewFlagSet("backup restore", flag.ExitOnError)
+	bucket := fs.String("bucket", "", "backup bucket directory")
+	dir := fs.String("dir", "", "empty directory to restore the log into")
+	name := fs.String("name", "", "backup to restore (default latest)")
+	toOffset := fs.Uint64("to-offset", 0, "restore records before this offset")
+	toTimestamp := fs.String("to-timestamp", "", "restore records before the first one at or after this RFC 3339 time")
+	maxStoreBytes := fs.Uint64("max-store-bytes", 0, "segment store size of the restored log (default 1KB)")
+	maxIndexBytes := fs.Uint64("max-index-bytes", 0, "segment index size of the restored log (default 1KB)")
+	fs.Parse(args)
+	if *bucket == "" || *dir == "" {
+		return fmt.Errorf("-bucket and -dir are required")
+	}
+
+	target := backup.Target{ToOffset: *toOffset}
+	var err error
+	if target.ToTime, err = parseTime(*toTimestamp); err != nil {
+		return err
+	}
+
+	c := plog.Config{}
+	c.Segment.MaxStoreBytes = *maxStoreBytes
+	c.Segment.MaxIndexBytes = *maxIndexBytes
+	m, err := backup.Restore(backup.DirBucket(*bucket), *name, *dir, c, target)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("restored backup %s (%d segments) to %s\n", m.Name, len(m.Segments), *dir)
+	return nil
+}
+
+// generateDescriptors: API の .proto と依存関係の FileDescriptorSet をファイル（または標準出力）に書き出す（サーバーには接続しない）
+// protoc の --descriptor_set_out --include_imports と同じ形式で、buf や grpcurl の入力にも使用できる。
+func generateDescriptors(args []string) error {
+	fs := flag.NewFlagSet("descriptors generate", flag.ExitOnError)
+	out := fs.String("o", "-", "output file (- for stdout)")
+	fs.Parse(args)
+
+	b, err := descriptors.Marshal()
+	if err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(*out, b, 0644)
+}
@@ -0,0 +1,87 @@
+// Package embedded: proglog のログストアをアプリケーションに組み込んで使用するための薄い API
+// サーバーを起動せずに、同じ保存形式のログストアをプロセス内で読み書きする。
+// このパッケージと生成された API（api/v1、api/admin/v1）は、サーバーだけが使用する依存関係
+// （Prometheus、bbolt、gorilla/mux など）を読み込まないようにしている（embedded_test.go で確認している）。
+package embedded
+
+import (
+	"os"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// Config: 組み込みのログストアの設定
+// 0 の項目はログストアのデフォルト値を使用する。
+type Config struct {
+	MaxStoreBytes  uint64 // セグメントのストアファイルの最大バイト数
+	MaxIndexBytes  uint64 // セグメントのインデックスファイルの最大バイト数
+	MaxRecordBytes uint64 // 1レコードの最大バイト数
+	InitialOffset  uint64 // 新規ログストアで最初のレコードに割り当てるオフセット
+}
+
+// Log: プロセスに組み込んだログストア
+// 複数のゴルーチンから同時に使用できる。同じディレクトリを複数のプロセスで同時に開くことはできない。
+type Log struct {
+	log *log.Log
+}
+
+// Open: ディレクトリのログストアを開く（存在しない場合は作成する）
+// 引数:
+//   - dir: セグメントファイルを保存するディレクトリ
+//   - c: ログストアの設定
+//
+// 戻り値:
+//   - *Log: 開いたログストア
+//   - error: ディレクトリを作成できない場合や、他のプロセスがログストアを開いている場合
+func Open(dir string, c Config) (*Log, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var lc log.Config
+	lc.Segment.MaxStoreBytes = c.MaxStoreBytes
+	lc.Segment.MaxIndexBytes = c.MaxIndexBytes
+	lc.Segment.MaxRecordBytes = c.MaxRecordBytes
+	lc.Segment.InitialOffset = c.InitialOffset
+	l, err := log.NewLog(dir, lc)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{log: l}, nil
+}
+
+// Append: レコードを追加し、割り当てられたオフセットを返す
+func (l *Log) Append(record *api.Record) (uint64, error) {
+	return l.log.Append(record)
+}
+
+// Read: 指定されたオフセットのレコードを読み取る
+// 範囲外のオフセットの場合は api.ErrOffsetOutOfRange を返す。
+func (l *Log) Read(off uint64) (*api.Record, error) {
+	return l.log.Read(off)
+}
+
+// LowestOffset: 保持している最小のオフセットを返す
+func (l *Log) LowestOffset() (uint64, error) {
+	return l.log.LowestOffset()
+}
+
+// NextOffset: 次に追加するレコードに割り当てられるオフセットを返す
+func (l *Log) NextOffset() uint64 {
+	return l.log.NextOffset()
+}
+
+// Truncate: 指定されたオフセット以下のレコードのみを含むセグメントを削除する
+func (l *Log) Truncate(lowest uint64) error {
+	return l.log.Truncate(lowest)
+}
+
+// Flush: 書き込んだデータをディスクに同期する
+func (l *Log) Flush() error {
+	return l.log.Flush()
+}
+
+// Close: ログストアを閉じる
+func (l *Log) Close() error {
+	return l.log.Close()
+}
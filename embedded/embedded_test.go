@@ -0,0 +1,57 @@
+package embedded
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "log")
+	l, err := Open(dir, Config{InitialOffset: 10})
+	require.NoError(t, err)
+
+	off, err := l.Append(&api.Record{Value: []byte("hello")})
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), off)
+	require.Equal(t, uint64(11), l.NextOffset())
+	require.NoError(t, l.Close())
+
+	// 開き直しても書き込んだレコードを読み取れる
+	l, err = Open(dir, Config{})
+	require.NoError(t, err)
+	defer l.Close()
+	record, err := l.Read(10)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(record.Value))
+	_, err = l.Read(11)
+	require.IsType(t, api.ErrOffsetOutOfRange{}, err)
+}
+
+// TestDependencies: 組み込み用のパッケージがサーバーだけが使用する依存関係を読み込まないことをテストする
+func TestDependencies(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	out, err := exec.Command("go", "list", "-deps",
+		"github.com/kentakki416/proglog/embedded",
+		"github.com/kentakki416/proglog/api/v1",
+		"github.com/kentakki416/proglog/api/admin/v1",
+	).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	for _, dep := range strings.Fields(string(out)) {
+		for _, forbidden := range []string{
+			"github.com/kentakki416/proglog/internal/server",
+			"github.com/prometheus/",
+			"go.etcd.io/bbolt",
+			"github.com/gorilla/",
+		} {
+			require.False(t, strings.HasPrefix(dep, forbidden), "client-facing packages must not import %s", dep)
+		}
+	}
+}
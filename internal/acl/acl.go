@@ -0,0 +1,346 @@
+// Package acl: 実行中に変更できる ACL のポリシー
+// ルールの追加と削除をログストア（内部のトピック）にレコードとして記録し、ログストアを再生してポリシーを復元する。
+// サーバーは内部のトピック（DefaultTopic）へのクライアントの書き込みを拒否するため、レコードを追加できるのは
+// Policy と、複製元のノード（server の Config.Replication.Peers）からの ReplicateEntries だけになる。
+// ログストアのレコードは、ローカルで追加したものも、複製で追加されたものや転送したセグメント（replicator の追いつき）の
+// ものも同じように読み取って反映するため、リーダーで変更したルールはクラスタのすべてのノードで有効になる。
+package acl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// DefaultTopic: ポリシーを記録する内部のトピックの名前
+// 保持期間で古いレコードを削除すると、そのルールの変更が失われるため、このトピックは削除しない設定で運用する。
+const DefaultTopic = "_acl"
+
+// Wildcard: ルールのサブジェクト、オブジェクト、アクションで、すべてに一致する値
+const Wildcard = "*"
+
+// ErrRuleNotFound: 削除するルールが存在しない場合のエラー
+var ErrRuleNotFound = errors.New("acl rule not found")
+
+// ErrInvalidRule: 項目が指定されていないルールを追加しようとした場合のエラー
+var ErrInvalidRule = errors.New("invalid acl rule")
+
+// ErrInvalidRecord: ログストアのレコードをルールの変更として解釈できない場合のエラー
+var ErrInvalidRecord = errors.New("invalid acl record")
+
+// DefaultRetryInterval: 反映に失敗したレコードを再試行する間隔（Config.RetryInterval が 0 の場合）
+const DefaultRetryInterval = time.Second
+
+// Rule: サブジェクトにオブジェクトに対するアクションを許可するルール
+// どの項目も Wildcard を指定すると、すべてに一致する。
+type Rule struct {
+	Subject string `json:"subject"`
+	Object  string `json:"object"`
+	Action  string `json:"action"`
+}
+
+// matches: ルールがサブジェクト、オブジェクト、アクションに一致するかを返す（内部関数）
+func (r Rule) matches(subject, object, action string) bool {
+	match := func(pattern, v string) bool { return pattern == Wildcard || pattern == v }
+	return match(r.Subject, subject) && match(r.Object, object) && match(r.Action, action)
+}
+
+// validate: ルールのすべての項目が指定されているかを確認する（内部関数）
+func (r Rule) validate() error {
+	if r.Subject == "" || r.Object == "" || r.Action == "" {
+		return fmt.Errorf("%w: a rule needs a subject, an object, and an action (use %q to match any): %+v", ErrInvalidRule, Wildcard, r)
+	}
+	return nil
+}
+
+// change: ログストアに記録するルールの変更
+type change struct {
+	Op   string `json:"op"` // "add" または "remove"
+	Rule Rule   `json:"rule"`
+}
+
+// ルールの変更の種類
+const (
+	opAdd    = "add"
+	opRemove = "remove"
+)
+
+// Config: ポリシーの設定
+type Config struct {
+	// ルールにかかわらず、すべての操作を許可するサブジェクト
+	// ルールがない状態からルールを追加する管理者（ブートストラップ）に使用する。
+	Superusers []string
+	// 反映に失敗したレコードを再試行する間隔（0 の場合は DefaultRetryInterval）
+	RetryInterval time.Duration
+	// 再試行の間隔を測る時計（nil の場合は log.SystemClock）
+	Clock log.Clock
+	// ログストアの追跡でレコードを反映できなかったときのエラーを受け取るコールバック（nil の場合は捨てる）
+	// 失敗したレコードより後のレコードは反映せず、RetryInterval ごとに再試行する。
+	OnError func(error)
+}
+
+// Policy: ログストアに記録したルールで認可する ACL のポリシー（server.Authorizer を実装する）
+// ルールを変更したら OnChange で登録した関数を呼び出す（認可の判定のキャッシュを捨てるために使用する）。
+type Policy struct {
+	log        *log.Log
+	superusers map[string]bool
+	config     Config
+
+	mu        sync.RWMutex
+	rules     map[Rule]bool
+	next      uint64        // 次に反映するレコードのオフセット
+	listeners []func()      // ルールの変更で呼び出す関数
+	applyMu   sync.Mutex    // レコードの反映を直列化する
+	writeMu   sync.Mutex    // ルールの存在の確認から記録までを直列化する（Add と Remove）
+	done      chan struct{} // ログストアの追跡を停止するためのチャネル
+	closeOnce sync.Once
+	wg        sync.WaitGroup // ログストアを追跡するゴルーチンの終了待ち
+}
+
+// NewPolicy: ログストアを再生してポリシーを復元し、追加されるレコードの追跡を開始する
+// 引数:
+//   - l: ルールの変更を記録するログストア（DefaultTopic のトピックなど）
+//   - c: ポリシーの設定
+//
+// 戻り値:
+//   - *Policy: 復元したポリシー（Close で追跡を停止する）
+//   - error: ログストアを読み取れない場合、ルールの変更として解釈できないレコードがある場合（ErrInvalidRecord）
+func NewPolicy(l *log.Log, c Config) (*Policy, error) {
+	lowest, err := l.LowestOffset()
+	if err != nil {
+		return nil, err
+	}
+	if c.RetryInterval == 0 {
+		c.RetryInterval = DefaultRetryInterval
+	}
+	if c.Clock == nil {
+		c.Clock = log.SystemClock
+	}
+	p := &Policy{
+		log:        l,
+		superusers: make(map[string]bool),
+		config:     c,
+		rules:      make(map[Rule]bool),
+		next:       lowest,
+		done:       make(chan struct{}),
+	}
+	for _, s := range c.Superusers {
+		p.superusers[s] = true
+	}
+	if err := p.catchUp(); err != nil {
+		return nil, err
+	}
+	p.wg.Add(1)
+	go p.follow()
+	return p, nil
+}
+
+// follow: ログストアに追加されたレコード（複製で受け取ったものを含む）をポリシーに反映し続ける（内部関数）
+// 反映に失敗した場合はエラーを OnError に渡し、レコードの追加を待たずに RetryInterval ごとに再試行する。
+func (p *Policy) follow() {
+	defer p.wg.Done()
+	var ticker log.Ticker
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+	for {
+		appended := p.log.Appended()
+		var retry <-chan time.Time
+		if err := p.catchUp(); err != nil {
+			if ticker == nil {
+				ticker = p.config.Clock.NewTicker(p.config.RetryInterval)
+			}
+			retry = ticker.C()
+			if p.config.OnError != nil {
+				p.config.OnError(err)
+			}
+		} else if ticker != nil {
+			ticker.Stop()
+			ticker = nil
+		}
+		select {
+		case <-appended:
+		case <-retry:
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// catchUp: まだ反映していないレコードをすべてポリシーに反映する（内部関数）
+// 読み取れない、またはルールの変更として解釈できないレコードがある場合は、そのレコードで止めてエラーを返す
+// （読み飛ばすと、そのレコードで削除したはずのルールが有効なままになるため）。次の呼び出しでそのレコードから再試行する。
+func (p *Policy) catchUp() error {
+	p.applyMu.Lock()
+	defer p.applyMu.Unlock()
+	p.mu.RLock()
+	off := p.next
+	p.mu.RUnlock()
+
+	next, changed, err := p.apply(off)
+	p.mu.Lock()
+	p.next = next
+	listeners := p.listeners
+	p.mu.Unlock()
+	if changed {
+		for _, fn := range listeners {
+			fn()
+		}
+	}
+	return err
+}
+
+// apply: off から末尾までのレコードをポリシーに反映する（内部関数）
+// 引数:
+//   - off: 反映を開始するオフセット
+//
+// 戻り値:
+//   - uint64: 次に反映するオフセット（失敗した場合は反映できなかったレコードのオフセット）
+//   - bool: ルールを変更した場合は true
+//   - error: レコードを読み取れない、または解釈できない場合
+func (p *Policy) apply(off uint64) (uint64, bool, error) {
+	var changed bool
+	end := p.log.NextOffset()
+	for ; off < end; off++ {
+		record, err := p.log.Read(off)
+		if _, ok := err.(api.ErrOffsetOutOfRange); ok {
+			// 削除されたレコード: 保持している最小のオフセットから続ける
+			lowest, err := p.log.LowestOffset()
+			if err != nil {
+				return off, changed, err
+			}
+			if lowest > off {
+				off = lowest - 1
+			}
+			continue
+		}
+		if err != nil {
+			return off, changed, fmt.Errorf("read acl record at offset %d: %w", off, err)
+		}
+		var c change
+		if err := json.Unmarshal(record.Value, &c); err != nil {
+			return off, changed, fmt.Errorf("%w at offset %d: %v", ErrInvalidRecord, off, err)
+		}
+		p.mu.Lock()
+		switch c.Op {
+		case opAdd:
+			p.rules[c.Rule] = true
+		case opRemove:
+			delete(p.rules, c.Rule)
+		default:
+			p.mu.Unlock()
+			return off, changed, fmt.Errorf("%w at offset %d: unknown op %q", ErrInvalidRecord, off, c.Op)
+		}
+		p.mu.Unlock()
+		changed = true
+	}
+	return off, changed, nil
+}
+
+// Authorize: サブジェクトがオブジェクトに対するアクションを許可されているかを判定する
+// 戻り値:
+//   - error: 一致するルールがない場合（サーバーは codes.PermissionDenied で返す）
+func (p *Policy) Authorize(subject, object, action string) error {
+	if p.superusers[subject] {
+		return nil
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for r := range p.rules {
+		if r.matches(subject, object, action) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not permitted to %s on %s", subject, action, object)
+}
+
+// Rules: すべてのルールをサブジェクト、オブジェクト、アクションの順に返す
+func (p *Policy) Rules() []Rule {
+	p.mu.RLock()
+	rules := make([]Rule, 0, len(p.rules))
+	for r := range p.rules {
+		rules = append(rules, r)
+	}
+	p.mu.RUnlock()
+	sort.Slice(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		if a.Subject != b.Subject {
+			return a.Subject < b.Subject
+		}
+		if a.Object != b.Object {
+			return a.Object < b.Object
+		}
+		return a.Action < b.Action
+	})
+	return rules
+}
+
+// Add: ルールを追加する（既に存在する場合は何もしない）
+// ログストアに記録し、ポリシーに反映してから返す。
+func (p *Policy) Add(r Rule) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	// 確認してから記録するまでの間に、ほかの Add や Remove が同じルールを変更しないようにする
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	p.mu.RLock()
+	exists := p.rules[r]
+	p.mu.RUnlock()
+	if exists {
+		return nil
+	}
+	return p.record(change{Op: opAdd, Rule: r})
+}
+
+// Remove: ルールを削除する
+// ログストアに記録し、ポリシーに反映してから返す。
+// 戻り値:
+//   - error: ルールが存在しない場合は ErrRuleNotFound
+func (p *Policy) Remove(r Rule) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	p.mu.RLock()
+	exists := p.rules[r]
+	p.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("%+v: %w", r, ErrRuleNotFound)
+	}
+	return p.record(change{Op: opRemove, Rule: r})
+}
+
+// record: ルールの変更をログストアに追加して、ポリシーに反映する（内部関数）
+func (p *Policy) record(c change) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if _, err := p.log.Append(&api.Record{Value: b}); err != nil {
+		return err
+	}
+	return p.catchUp()
+}
+
+// OnChange: ルールが変更されたときに呼び出す関数を登録する
+// 認可の判定をキャッシュしている場合は、キャッシュを捨てる関数（server.CachingAuthorizer.Invalidate）を登録する
+// （server.Config の ACL と Authorizer に設定した場合は、server.NewGRPCServer が登録する）。
+func (p *Policy) OnChange(fn func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listeners = append(p.listeners, fn)
+}
+
+// Close: ログストアの追跡を停止する（ログストアは閉じない）
+// 複数回呼び出してもよい。
+func (p *Policy) Close() {
+	p.closeOnce.Do(func() { close(p.done) })
+	p.wg.Wait()
+}
@@ -0,0 +1,139 @@
+package acl
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPolicy: ルールの追加と削除が認可に反映され、ログストアから復元できることをテストする
+func TestPolicy(t *testing.T) {
+	dir := t.TempDir()
+	l, err := log.NewLog(dir, log.Config{})
+	require.NoError(t, err)
+
+	p, err := NewPolicy(l, Config{Superusers: []string{"root"}})
+	require.NoError(t, err)
+	var changes int
+	p.OnChange(func() { changes++ })
+
+	require.NoError(t, p.Authorize("root", "orders", "produce"))
+	require.Error(t, p.Authorize("alice", "orders", "produce"))
+
+	require.NoError(t, p.Add(Rule{Subject: "alice", Object: Wildcard, Action: "produce"}))
+	require.NoError(t, p.Add(Rule{Subject: "bob", Object: "orders", Action: "consume"}))
+	// 既に存在するルールは記録しない
+	require.NoError(t, p.Add(Rule{Subject: "bob", Object: "orders", Action: "consume"}))
+	require.ErrorIs(t, p.Add(Rule{Subject: "bob"}), ErrInvalidRule)
+	require.Equal(t, 2, changes)
+	require.Equal(t, uint64(2), l.NextOffset())
+
+	require.NoError(t, p.Authorize("alice", "orders", "produce"))
+	require.NoError(t, p.Authorize("bob", "orders", "consume"))
+	require.Error(t, p.Authorize("bob", "payments", "consume"))
+
+	require.NoError(t, p.Remove(Rule{Subject: "alice", Object: Wildcard, Action: "produce"}))
+	require.ErrorIs(t, p.Remove(Rule{Subject: "alice", Object: Wildcard, Action: "produce"}), ErrRuleNotFound)
+	require.Error(t, p.Authorize("alice", "orders", "produce"))
+	p.Close()
+	require.NoError(t, l.Close())
+
+	// 再起動後もログストアからルールを復元する
+	l, err = log.NewLog(dir, log.Config{})
+	require.NoError(t, err)
+	defer l.Close()
+	p, err = NewPolicy(l, Config{})
+	require.NoError(t, err)
+	defer p.Close()
+	require.Equal(t, []Rule{{Subject: "bob", Object: "orders", Action: "consume"}}, p.Rules())
+
+	// 転送したセグメントなどでログストアに直接追加されたレコードも反映する
+	b, err := json.Marshal(change{Op: opAdd, Rule: Rule{Subject: "carol", Object: Wildcard, Action: Wildcard}})
+	require.NoError(t, err)
+	_, err = l.Append(&api.Record{Value: b})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return p.Authorize("carol", "orders", "truncate") == nil
+	}, time.Second, 10*time.Millisecond)
+
+	// 同じルールを同時に追加しても1回だけ記録する
+	next := l.NextOffset()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, p.Add(Rule{Subject: "dave", Object: "orders", Action: "produce"}))
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, next+1, l.NextOffset())
+
+	// 複数回閉じてもよい
+	p.Close()
+	p.Close()
+}
+
+// TestPolicyInvalidRecord: 解釈できないレコードで反映を止め、後のレコードを反映せずに再試行することをテストする
+func TestPolicyInvalidRecord(t *testing.T) {
+	appendChange := func(l *log.Log, c change) {
+		b, err := json.Marshal(c)
+		require.NoError(t, err)
+		_, err = l.Append(&api.Record{Value: b})
+		require.NoError(t, err)
+	}
+	alice := Rule{Subject: "alice", Object: Wildcard, Action: Wildcard}
+
+	// 再生中に解釈できないレコードがある場合は、ポリシーを作成できない
+	l, err := log.NewLog(t.TempDir(), log.Config{})
+	require.NoError(t, err)
+	defer l.Close()
+	appendChange(l, change{Op: opAdd, Rule: alice})
+	_, err = l.Append(&api.Record{Value: []byte("not json")})
+	require.NoError(t, err)
+	_, err = NewPolicy(l, Config{})
+	require.ErrorIs(t, err, ErrInvalidRecord)
+
+	// 追跡中に解釈できないレコードが追加された場合は、エラーを報告して後のレコードを反映しない
+	l, err = log.NewLog(t.TempDir(), log.Config{})
+	require.NoError(t, err)
+	defer l.Close()
+	appendChange(l, change{Op: opAdd, Rule: alice})
+	clock := log.NewFakeClock(time.Now())
+	errs := make(chan error, 8)
+	p, err := NewPolicy(l, Config{
+		Clock:         clock,
+		RetryInterval: time.Minute,
+		OnError:       func(err error) { errs <- err },
+	})
+	require.NoError(t, err)
+	defer p.Close()
+	require.NoError(t, p.Authorize("alice", "orders", "produce"))
+
+	appendChange(l, change{Op: "rename", Rule: alice})
+	appendChange(l, change{Op: opRemove, Rule: alice})
+	select {
+	case err := <-errs:
+		require.ErrorIs(t, err, ErrInvalidRecord)
+	case <-time.After(time.Second):
+		t.Fatal("expected an error for the invalid record")
+	}
+	require.NoError(t, p.Authorize("alice", "orders", "produce"))
+	require.Equal(t, []Rule{alice}, p.Rules())
+
+	// レコードの追加を待たずに、間隔ごとに同じレコードから再試行する
+	clock.Advance(time.Minute)
+	select {
+	case err := <-errs:
+		require.ErrorIs(t, err, ErrInvalidRecord)
+		require.Contains(t, err.Error(), "offset 1")
+	case <-time.After(time.Second):
+		t.Fatal("expected the invalid record to be retried")
+	}
+	require.Equal(t, []Rule{alice}, p.Rules())
+}
@@ -0,0 +1,350 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// バケット内のオブジェクトの配置
+// セグメントは書き込みが終わると変更されないため、一度アップロードしたセグメントは以降のバックアップで共有する。
+const (
+	manifestPrefix = "manifests/" // manifests/{作成時刻の unix ミリ秒（20桁）}.json
+	segmentPrefix  = "segments/"  // segments/{エポック}/{baseOffset}-{nextOffset}.store|.index
+)
+
+// ErrNoBackup: 復元するバックアップがバケットにない場合のエラー
+var ErrNoBackup = errors.New("no backup found")
+
+// Manifest: 1つのバックアップに含まれるセグメントの一覧
+// マニフェストはすべてのセグメントをアップロードした後に書き込むため、マニフェストがあるバックアップは完全に復元できる。
+type Manifest struct {
+	Name     string    `json:"name"`
+	Created  time.Time `json:"created"`
+	Epoch    uint64    `json:"epoch"`  // バックアップしたときのログのオフセットのエポック
+	Format   int       `json:"format"` // セグメントの保存形式（log.FormatV1 または log.FormatV2）
+	Segments []Segment `json:"segments"`
+}
+
+// Segment: バックアップしたセグメント
+type Segment struct {
+	BaseOffset uint64 `json:"base_offset"`
+	NextOffset uint64 `json:"next_offset"`
+	Store      string `json:"store"` // ストアファイルのオブジェクト名
+	Index      string `json:"index"` // インデックスファイルのオブジェクト名
+}
+
+// Backup: ログの書き込み済みセグメントのスナップショットをバケットにアップロードする
+// 以前のバックアップでアップロード済みのセグメントはアップロードせず（差分のみ）、マニフェストを最後に書き込む。
+// 書き込み済みセグメントが前回のバックアップから変わっていない場合は、新しいバックアップを作成しない。
+// 書き込み後、新しい順に retain 個を超えるバックアップと、どのバックアップからも参照されないセグメントを削除する。
+// 引数:
+//   - l: バックアップするログストア
+//   - b: 保存先のバケット
+//   - retain: 保持するバックアップの数（0 の場合は削除しない）
+//   - now: バックアップの作成時刻（バックアップの名前になる）
+//
+// 戻り値:
+//   - Manifest: 作成した（または変更がない場合は最新の）バックアップのマニフェスト
+//   - bool: 新しいバックアップを作成した場合 true
+//   - error: エラーが発生した場合
+func Backup(l *log.Log, b Bucket, retain int, now time.Time) (Manifest, bool, error) {
+	m := Manifest{
+		Name:    fmt.Sprintf("%020d", now.UnixMilli()),
+		Created: now.UTC(),
+		Epoch:   l.Epoch(),
+		Format:  l.Config.Segment.Format,
+	}
+	uploaded, err := b.List(fmt.Sprintf("%s%d/", segmentPrefix, m.Epoch))
+	if err != nil {
+		return Manifest{}, false, err
+	}
+	exists := make(map[string]bool, len(uploaded))
+	for _, name := range uploaded {
+		exists[name] = true
+	}
+
+	sealed := l.SealedSegments(0)
+	defer func() {
+		for _, s := range sealed {
+			s.Close()
+		}
+	}()
+	for _, s := range sealed {
+		name := fmt.Sprintf("%s%d/%d-%d", segmentPrefix, m.Epoch, s.BaseOffset, s.NextOffset)
+		seg := Segment{
+			BaseOffset: s.BaseOffset,
+			NextOffset: s.NextOffset,
+			Store:      name + ".store",
+			Index:      name + ".index",
+		}
+		// インデックスはストアの後にアップロードするため、インデックスがあればストアもアップロード済み
+		if !exists[seg.Index] {
+			if err = b.Put(seg.Store, s.Store); err != nil {
+				return Manifest{}, false, err
+			}
+			if err = b.Put(seg.Index, s.Index); err != nil {
+				return Manifest{}, false, err
+			}
+		}
+		m.Segments = append(m.Segments, seg)
+	}
+
+	latest, err := Latest(b)
+	if err == nil && sameSegments(latest, m) {
+		return latest, false, nil
+	}
+	if err != nil && err != ErrNoBackup {
+		return Manifest{}, false, err
+	}
+
+	p, err := json.Marshal(m)
+	if err != nil {
+		return Manifest{}, false, err
+	}
+	if err = b.Put(manifestPrefix+m.Name+".json", bytes.NewReader(p)); err != nil {
+		return Manifest{}, false, err
+	}
+	if retain > 0 {
+		if err = prune(b, retain); err != nil {
+			return m, true, err
+		}
+	}
+	return m, true, nil
+}
+
+// sameSegments: 2つのバックアップが同じセグメントを含むかどうかを判定する（内部関数）
+func sameSegments(a, b Manifest) bool {
+	if a.Epoch != b.Epoch || len(a.Segments) != len(b.Segments) {
+		return false
+	}
+	for i := range a.Segments {
+		if a.Segments[i] != b.Segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// prune: 新しい順に retain 個を超えるバックアップと、残ったバックアップから参照されないセグメントを削除する（内部関数）
+// マニフェストを先に削除するため、途中で失敗しても残っているバックアップは復元できる。
+func prune(b Bucket, retain int) error {
+	names, err := b.List(manifestPrefix)
+	if err != nil {
+		return err
+	}
+	if len(names) > retain {
+		for _, name := range names[:len(names)-retain] {
+			if err = b.Delete(name); err != nil {
+				return err
+			}
+		}
+		names = names[len(names)-retain:]
+	}
+
+	referenced := make(map[string]bool)
+	for _, name := range names {
+		m, err := readManifest(b, name)
+		if err != nil {
+			return err
+		}
+		for _, s := range m.Segments {
+			referenced[s.Store] = true
+			referenced[s.Index] = true
+		}
+	}
+	segments, err := b.List(segmentPrefix)
+	if err != nil {
+		return err
+	}
+	for _, name := range segments {
+		if !referenced[name] {
+			if err = b.Delete(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// List: バケット内のバックアップのマニフェストを古い順に返す
+func List(b Bucket) ([]Manifest, error) {
+	names, err := b.List(manifestPrefix)
+	if err != nil {
+		return nil, err
+	}
+	manifests := make([]Manifest, 0, len(names))
+	for _, name := range names {
+		m, err := readManifest(b, name)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+// Latest: バケット内の最新のバックアップのマニフェストを返す
+// 戻り値:
+//   - Manifest: 最新のバックアップのマニフェスト
+//   - error: バックアップがない場合（ErrNoBackup）
+func Latest(b Bucket) (Manifest, error) {
+	names, err := b.List(manifestPrefix)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if len(names) == 0 {
+		return Manifest{}, ErrNoBackup
+	}
+	return readManifest(b, names[len(names)-1])
+}
+
+// readManifest: バケットからマニフェストを読み取る（内部関数）
+func readManifest(b Bucket, name string) (Manifest, error) {
+	r, err := b.Get(name)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer r.Close()
+	var m Manifest
+	if err = json.NewDecoder(r).Decode(&m); err != nil {
+		return Manifest{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return m, nil
+}
+
+// Target: 復元する時点
+// 指定した時点より前のレコードだけを復元する（0 の項目は制限しない）。
+// 誤った書き込みやアプリケーションのバグの直前の状態に戻すために使用する。
+type Target struct {
+	ToOffset uint64 // このオフセットより前のレコードまで復元する
+	ToTime   int64  // タイムスタンプ（unix ミリ秒）がこの時刻以降の最初のレコードより前まで復元する
+}
+
+// reached: レコードが復元する時点に達しているかどうかを判定する（内部関数）
+func (t Target) reached(record *api.Record) bool {
+	return (t.ToOffset != 0 && record.Offset >= t.ToOffset) ||
+		(t.ToTime != 0 && record.Timestamp >= t.ToTime)
+}
+
+// Restore: バックアップのセグメントから新しいログストアを作成する
+// 復元する時点が指定されている場合は、その時点より前のレコードだけを復元する。
+// 時点を含むセグメントは、レコードを1件ずつ読み取って時点の直前まで追加し直す。
+// 引数:
+//   - b: バックアップのバケット
+//   - name: 復元するバックアップの名前（空文字の場合は最新のバックアップ）
+//   - dir: 復元先のディレクトリ（存在しないか空である必要がある）
+//   - c: 復元したログストアの設定（保存形式と開始オフセットはバックアップに合わせる）
+//   - target: 復元する時点（ゼロ値の場合はバックアップ全体を復元する）
+//
+// 戻り値:
+//   - Manifest: 復元したバックアップのマニフェスト
+//   - error: エラーが発生した場合（バックアップがない場合は ErrNoBackup）
+func Restore(b Bucket, name, dir string, c log.Config, target Target) (Manifest, error) {
+	var (
+		m   Manifest
+		err error
+	)
+	if name == "" {
+		m, err = Latest(b)
+	} else {
+		m, err = readManifest(b, path.Join(manifestPrefix, name+".json"))
+		if os.IsNotExist(err) {
+			err = ErrNoBackup
+		}
+	}
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return Manifest{}, fmt.Errorf("restore directory is not empty: %s", dir)
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return Manifest{}, err
+	}
+
+	c.Segment.Format = m.Format
+	if len(m.Segments) > 0 {
+		c.Segment.InitialOffset = m.Segments[0].BaseOffset
+	}
+	l, err := log.NewLog(dir, c)
+	if err != nil {
+		return Manifest{}, err
+	}
+	for _, s := range m.Segments {
+		// 時刻を指定していない場合、時点より前に収まるセグメントはそのまま追加する
+		if target.ToTime == 0 && (target.ToOffset == 0 || s.NextOffset <= target.ToOffset) {
+			err = installSegment(l, b, s, m.Format)
+		} else {
+			var done bool
+			done, err = replaySegment(l, b, s, c, target)
+			if err == nil && done {
+				break
+			}
+		}
+		if err != nil {
+			l.Close()
+			return Manifest{}, err
+		}
+	}
+	return m, l.Close()
+}
+
+// installSegment: バケットからセグメントを読み取ってログストアの末尾に追加する（内部関数）
+func installSegment(l *log.Log, b Bucket, s Segment, format int) error {
+	store, err := b.Get(s.Store)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	index, err := b.Get(s.Index)
+	if err != nil {
+		return err
+	}
+	defer index.Close()
+	return l.InstallSegment(s.BaseOffset, format, store, index)
+}
+
+// replaySegment: バケットのセグメントのレコードを、復元する時点の直前までログストアに追加する（内部関数）
+// セグメントを一時的なログストアに展開してから、レコードを順に読み取る。
+// 戻り値:
+//   - bool: 復元する時点に達した場合 true（以降のセグメントは復元しない）
+//   - error: エラーが発生した場合
+func replaySegment(l *log.Log, b Bucket, s Segment, c log.Config, target Target) (bool, error) {
+	tmp, err := os.MkdirTemp("", "proglog-restore")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(tmp)
+	c.Segment.InitialOffset = s.BaseOffset
+	src, err := log.NewLog(tmp, c)
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+	if err = installSegment(src, b, s, c.Segment.Format); err != nil {
+		return false, err
+	}
+
+	for off := s.BaseOffset; off < s.NextOffset; off++ {
+		record, err := src.Read(off)
+		if err != nil {
+			return false, err
+		}
+		if target.reached(record) {
+			return true, nil
+		}
+		if _, err = l.Append(record); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,142 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRestore(t *testing.T) {
+	c := log.Config{}
+	c.Segment.MaxIndexBytes = 12 * 3
+	l, err := log.NewLog(t.TempDir(), c)
+	require.NoError(t, err)
+	defer l.Close()
+	appendN := func(n int) {
+		for i := 0; i < n; i++ {
+			_, err := l.Append(&api.Record{Value: []byte("hello world")})
+			require.NoError(t, err)
+		}
+	}
+	bucket := DirBucket(t.TempDir())
+	now := time.UnixMilli(1700000000000)
+
+	// 書き込み済みセグメント: [0, 3)
+	appendN(4)
+	first, created, err := Backup(l, bucket, 2, now)
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Len(t, first.Segments, 1)
+
+	// 書き込み済みセグメントが変わっていなければバックアップしない
+	_, created, err = Backup(l, bucket, 2, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.False(t, created)
+
+	// 書き込み済みセグメント: [0, 3), [3, 6), [6, 9)（追加分のみアップロードする）
+	appendN(6)
+	second, created, err := Backup(l, bucket, 2, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Len(t, second.Segments, 3)
+	require.Equal(t, first.Segments[0], second.Segments[0])
+
+	// 古いセグメントを削除した後のバックアップで、保持数を超えた最初のバックアップが削除される
+	require.NoError(t, l.Truncate(2))
+	third, _, err := Backup(l, bucket, 2, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, third.Segments, 2)
+	manifests, err := List(bucket)
+	require.NoError(t, err)
+	require.Equal(t, []string{second.Name, third.Name}, []string{manifests[0].Name, manifests[1].Name})
+	// 2番目のバックアップが参照するセグメントは残る
+	segments, err := bucket.List(segmentPrefix)
+	require.NoError(t, err)
+	require.Len(t, segments, 6)
+
+	// 最新のバックアップを復元する
+	dir := t.TempDir()
+	m, err := Restore(bucket, "", dir, c, Target{})
+	require.NoError(t, err)
+	require.Equal(t, third.Name, m.Name)
+	restored, err := log.NewLog(dir, c)
+	require.NoError(t, err)
+	lowest, err := restored.LowestOffset()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), lowest)
+	require.Equal(t, uint64(9), restored.NextOffset())
+	record, err := restored.Read(8)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello world"), record.Value)
+	require.NoError(t, restored.Close())
+
+	// 名前を指定して復元する（復元先は空である必要がある）
+	_, err = Restore(bucket, second.Name, dir, c, Target{})
+	require.ErrorContains(t, err, "not empty")
+	m, err = Restore(bucket, second.Name, t.TempDir(), c, Target{})
+	require.NoError(t, err)
+	require.Len(t, m.Segments, 3)
+	_, err = Restore(bucket, first.Name, t.TempDir(), c, Target{})
+	require.ErrorIs(t, err, ErrNoBackup)
+}
+
+func TestRestoreTarget(t *testing.T) {
+	c := log.Config{}
+	c.Segment.MaxIndexBytes = 12 * 3
+	l, err := log.NewLog(t.TempDir(), c)
+	require.NoError(t, err)
+	defer l.Close()
+	for i := 0; i < 10; i++ {
+		_, err := l.Append(&api.Record{Value: []byte("hello world"), Timestamp: int64(1000 * i)})
+		require.NoError(t, err)
+	}
+	bucket := DirBucket(t.TempDir())
+	// 書き込み済みセグメント: [0, 3), [3, 6), [6, 9)
+	_, _, err = Backup(l, bucket, 0, time.UnixMilli(1700000000000))
+	require.NoError(t, err)
+
+	for scenario, tc := range map[string]struct {
+		target Target
+		next   uint64
+	}{
+		"offset inside a segment":    {Target{ToOffset: 5}, 5},
+		"offset at segment boundary": {Target{ToOffset: 3}, 3},
+		"offset past the backup":     {Target{ToOffset: 100}, 9},
+		"timestamp":                  {Target{ToTime: 6500}, 7},
+		"earliest of both":           {Target{ToOffset: 8, ToTime: 4000}, 4},
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			dir := t.TempDir()
+			_, err := Restore(bucket, "", dir, c, tc.target)
+			require.NoError(t, err)
+			restored, err := log.NewLog(dir, c)
+			require.NoError(t, err)
+			defer restored.Close()
+			require.Equal(t, tc.next, restored.NextOffset())
+			if tc.next > 0 {
+				record, err := restored.Read(tc.next - 1)
+				require.NoError(t, err)
+				require.Equal(t, int64(1000*(tc.next-1)), record.Timestamp)
+			}
+		})
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	l, err := log.NewLog(t.TempDir(), log.Config{})
+	require.NoError(t, err)
+	defer l.Close()
+	clock := log.NewFakeClock(time.UnixMilli(1700000000000))
+	bucket := DirBucket(t.TempDir())
+	s := Start(Config{Log: l, Bucket: bucket, Interval: time.Hour, Clock: clock})
+	defer s.Close()
+
+	clock.Advance(time.Hour)
+	require.Eventually(t, func() bool {
+		m, err := Latest(bucket)
+		return err == nil && m.Created.Equal(clock.Now())
+	}, time.Second, 10*time.Millisecond)
+}
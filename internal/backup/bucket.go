@@ -0,0 +1,92 @@
+package backup
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Bucket: バックアップの保存先のオブジェクトストレージ（例: S3、GCS）
+// オブジェクト名は "/" で区切られたパスで、Put は書き込みが完了するまで他から見えない必要がある。
+type Bucket interface {
+	Put(name string, r io.Reader) error
+	Get(name string) (io.ReadCloser, error)
+	// List: 名前が prefix で始まるオブジェクトの名前を昇順で返す
+	List(prefix string) ([]string, error)
+	// Delete: オブジェクトを削除する（存在しない場合も成功する）
+	Delete(name string) error
+}
+
+// DirBucket: ローカルのディレクトリをバケットとして使用する Bucket
+// テストや、ネットワークファイルシステム（マウントしたオブジェクトストレージなど）への保存に使用する。
+type DirBucket string
+
+// Put: 一時ファイルに書き込んでからリネームする（途中で失敗しても不完全なオブジェクトが見えないように）
+func (d DirBucket) Put(name string, r io.Reader) error {
+	path := d.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (d DirBucket) Get(name string) (io.ReadCloser, error) {
+	return os.Open(d.path(name))
+}
+
+func (d DirBucket) List(prefix string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(string(d), func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(string(d), path)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+func (d DirBucket) Delete(name string) error {
+	if err := os.Remove(d.path(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path: オブジェクト名をファイルのパスに変換する（内部関数）
+func (d DirBucket) path(name string) string {
+	return filepath.Join(string(d), filepath.FromSlash(name))
+}
@@ -0,0 +1,70 @@
+package backup
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// DefaultInterval: 間隔が指定されていない場合の、バックアップを作成する間隔
+const DefaultInterval = 6 * time.Hour
+
+// DefaultRetain: 保持する数が指定されていない場合の、保持するバックアップの数
+const DefaultRetain = 7
+
+// Config: 定期的なバックアップの設定
+type Config struct {
+	Log    *log.Log // バックアップするログストア
+	Bucket Bucket   // 保存先のバケット
+	// バックアップを作成する間隔（0 の場合は DefaultInterval）
+	// アップロードによるディスクとネットワークの負荷を抑えるため、この間隔より頻繁にはバックアップしない。
+	Interval time.Duration
+	// 保持するバックアップの数（0 の場合は DefaultRetain）
+	Retain int
+	// 定期的な実行と作成時刻に使用する時計（nil の場合は log.SystemClock）
+	Clock log.Clock
+	// バックアップに失敗したときのエラーを受け取るコールバック（nil の場合は捨てる）
+	// 失敗しても次の間隔で再試行する。
+	OnError func(error)
+}
+
+// Scheduler: ログストアのバックアップを定期的に作成する
+type Scheduler struct {
+	config Config
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// Start: バックアップの定期的な作成を開始する
+// 最初のバックアップは Interval の経過後に作成する。
+// 引数:
+//   - config: バックアップの設定
+//
+// 戻り値:
+//   - *Scheduler: 開始したスケジューラー（使い終わったら Close を呼び出す）
+func Start(config Config) *Scheduler {
+	if config.Interval == 0 {
+		config.Interval = DefaultInterval
+	}
+	if config.Retain == 0 {
+		config.Retain = DefaultRetain
+	}
+	if config.Clock == nil {
+		config.Clock = log.SystemClock
+	}
+	s := &Scheduler{config: config, done: make(chan struct{})}
+	log.Every(config.Clock, config.Interval, s.done, &s.wg, func(time.Time) {
+		_, _, err := Backup(s.config.Log, s.config.Bucket, s.config.Retain, s.config.Clock.Now())
+		if err != nil && s.config.OnError != nil {
+			s.config.OnError(err)
+		}
+	})
+	return s
+}
+
+// Close: バックアップの定期的な作成を停止する（作成中のバックアップがある場合は完了を待つ）
+func (s *Scheduler) Close() {
+	close(s.done)
+	s.wg.Wait()
+}
@@ -0,0 +1,144 @@
+// Package compression: gRPC の通信で使用する圧縮方式（コンプレッサー）を登録する
+// このパッケージをインポートすると gzip と zstd のコンプレッサーが gRPC に登録される。
+// サーバーはクライアントが使用した圧縮方式でリクエストを展開し、同じ方式でレスポンスを圧縮する。
+// クライアントは CallOption（または DialOption）で使用する圧縮方式を選択する。
+// ストレージ上の圧縮とは独立しており、大きなテキストのペイロードで通信量を削減するために使用する。
+package compression
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// 登録されている圧縮方式の名前
+const (
+	None = ""        // 圧縮しない
+	Gzip = gzip.Name // gzip（gRPC 標準）
+	Zstd = "zstd"    // zstd（gzip より高速で圧縮率も高い）
+)
+
+func init() {
+	c := &zstdCompressor{}
+	c.encoders.New = func() any {
+		e, err := zstd.NewWriter(nil)
+		if err != nil {
+			panic(err)
+		}
+		return &zstdWriter{Encoder: e, pool: &c.encoders}
+	}
+	encoding.RegisterCompressor(c)
+}
+
+// Registered: gRPC に登録されている圧縮方式の名前を返す（None を除く）
+// サーバーが展開できる圧縮方式をクライアントに知らせるために使用する。
+func Registered() []string {
+	var names []string
+	for _, name := range []string{Gzip, Zstd} {
+		if encoding.GetCompressor(name) != nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// CallOption: 指定された圧縮方式でリクエストを圧縮する CallOption を返す
+// 引数:
+//   - name: 圧縮方式の名前（None, Gzip, Zstd）
+//
+// 戻り値:
+//   - grpc.CallOption: RPC の呼び出しに渡すオプション
+//   - error: 登録されていない圧縮方式の場合
+func CallOption(name string) (grpc.CallOption, error) {
+	if name == None {
+		return grpc.EmptyCallOption{}, nil
+	}
+	if encoding.GetCompressor(name) == nil {
+		return nil, fmt.Errorf("unknown compressor: %q", name)
+	}
+	return grpc.UseCompressor(name), nil
+}
+
+// DialOption: クライアントのすべての RPC を指定された圧縮方式で圧縮する DialOption を返す
+// 引数:
+//   - name: 圧縮方式の名前（None, Gzip, Zstd）
+//
+// 戻り値:
+//   - grpc.DialOption: grpc.NewClient に渡すオプション
+//   - error: 登録されていない圧縮方式の場合
+func DialOption(name string) (grpc.DialOption, error) {
+	opt, err := CallOption(name)
+	if err != nil {
+		return nil, err
+	}
+	return grpc.WithDefaultCallOptions(opt), nil
+}
+
+// zstdCompressor: gRPC の encoding.Compressor を zstd で実装する
+// エンコーダーとデコーダーは生成コストが高いため、プールして再利用する。
+type zstdCompressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+// zstdWriter: プールに戻すために Close を差し替えたエンコーダー
+type zstdWriter struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+// zstdReader: 読み取りが終わったらプールに戻すデコーダー
+type zstdReader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+// Compress: w に圧縮して書き込む Writer を返す
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	z := c.encoders.Get().(*zstdWriter)
+	z.Encoder.Reset(w)
+	return z, nil
+}
+
+// Close: 圧縮を完了してエンコーダーをプールに戻す
+func (z *zstdWriter) Close() error {
+	defer z.pool.Put(z)
+	return z.Encoder.Close()
+}
+
+// Decompress: r から展開して読み取る Reader を返す
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	z, ok := c.decoders.Get().(*zstdReader)
+	if !ok {
+		// gRPC は読み取りが終わった Reader を閉じないため、ゴルーチンを使わないデコーダーにする
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return &zstdReader{Decoder: d, pool: &c.decoders}, nil
+	}
+	if err := z.Reset(r); err != nil {
+		c.decoders.Put(z)
+		return nil, err
+	}
+	return z, nil
+}
+
+// Read: 展開したデータを読み取り、最後まで読み取ったらデコーダーをプールに戻す
+func (z *zstdReader) Read(p []byte) (int, error) {
+	n, err := z.Decoder.Read(p)
+	if err == io.EOF {
+		z.pool.Put(z)
+	}
+	return n, err
+}
+
+// Name: 圧縮方式の名前を返す（grpc-encoding ヘッダーで使用される）
+func (c *zstdCompressor) Name() string {
+	return Zstd
+}
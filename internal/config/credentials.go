@@ -0,0 +1,36 @@
+package config
+
+import (
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// CredentialsProvider: gRPC のトランスポートの認証情報を作成する
+// TLSConfig のほかに、ALTS や SPIRE の Workload API、社内の PKI などの認証方式を、
+// SetupTLSConfig を変更せずにサーバー（server.Config.Credentials）とクライアントに組み込むために使用する。
+type CredentialsProvider interface {
+	TransportCredentials() (credentials.TransportCredentials, error)
+}
+
+// CredentialsFunc: 関数を CredentialsProvider として使用するためのアダプター
+// 例えば ALTS の場合は、alts.NewServerCreds(alts.DefaultServerOptions()) を返す関数を渡す。
+type CredentialsFunc func() (credentials.TransportCredentials, error)
+
+// TransportCredentials: 関数を呼び出して認証情報を作成する
+func (f CredentialsFunc) TransportCredentials() (credentials.TransportCredentials, error) {
+	return f()
+}
+
+// Insecure: TLS を使用しない（平文の）認証情報を作成する CredentialsProvider
+var Insecure CredentialsProvider = CredentialsFunc(func() (credentials.TransportCredentials, error) {
+	return insecure.NewCredentials(), nil
+})
+
+// TransportCredentials: TLS の設定から認証情報を作成する
+func (c TLSConfig) TransportCredentials() (credentials.TransportCredentials, error) {
+	tlsConfig, err := SetupTLSConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
@@ -3,11 +3,15 @@ package config
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"os"
 )
 
 func SetupTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid TLS config: %w", err)
+	}
 	var err error
 	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS13}
 	if cfg.CertFile != "" && cfg.KeyFile != "" {
@@ -47,3 +51,32 @@ type TLSConfig struct {
 	ServerAddress string
 	Server        bool
 }
+
+// Validate: TLS の設定を確認する
+// 証明書の読み込みや接続の確立で分かりにくいエラーになる前に、設定の誤りを具体的なメッセージで返す。
+// 戻り値:
+//   - error: 満たしていない条件をすべてまとめたエラー（すべて満たしている場合は nil）
+func (c TLSConfig) Validate() error {
+	var errs []error
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("CertFile and KeyFile must be set together (CertFile %q, KeyFile %q)", c.CertFile, c.KeyFile))
+	}
+	if c.Server && c.CertFile == "" {
+		errs = append(errs, errors.New("a server TLS config requires CertFile and KeyFile"))
+	}
+	for _, f := range []struct{ name, path string }{
+		{"CertFile", c.CertFile},
+		{"KeyFile", c.KeyFile},
+		{"CAFile", c.CAFile},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if fi, err := os.Stat(f.path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w (set CONFIG_DIR or generate the certificates)", f.name, err))
+		} else if fi.IsDir() {
+			errs = append(errs, fmt.Errorf("%s %q is a directory, not a PEM file", f.name, f.path))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,71 @@
+// Package descriptors: 公開している API の .proto から、依存関係を含む FileDescriptorSet を作成する
+// Go 以外のクライアント（リフレクションを使う gRPC クライアント、スキーマレジストリ、grpcurl など）が、
+// .proto を手でコピーせずに API の定義を取り込めるようにする。
+// 生成済みの Go のコードに埋め込まれた定義から作成するため、protoc や buf は不要だが、
+// .proto のコメント（source_code_info）は含まれない。
+package descriptors
+
+import (
+	"fmt"
+
+	_ "github.com/kentakki416/proglog/api/admin/v1"
+	_ "github.com/kentakki416/proglog/api/replication/v1"
+	_ "github.com/kentakki416/proglog/api/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Files: FileDescriptorSet に含める API の .proto のパス
+var Files = []string{
+	"api/v1/log.proto",
+	"api/admin/v1/admin.proto",
+	"api/replication/v1/replication.proto",
+}
+
+// Generate: 指定された .proto（空の場合は Files）と、それらがインポートするファイルの FileDescriptorSet を作成する
+// ファイルは依存されるものから順に並べる（protoc の --include_imports と同じ順序）。
+// 引数:
+//   - paths: 含める .proto のパス
+//
+// 戻り値:
+//   - *descriptorpb.FileDescriptorSet: 作成した FileDescriptorSet
+//   - error: 登録されていない .proto のパスを指定した場合
+func Generate(paths ...string) (*descriptorpb.FileDescriptorSet, error) {
+	if len(paths) == 0 {
+		paths = Files
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	for _, path := range paths {
+		fd, err := protoregistry.GlobalFiles.FindFileByPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("descriptors: %s: %w", path, err)
+		}
+		add(fd)
+	}
+	return set, nil
+}
+
+// Marshal: Generate で作成した FileDescriptorSet をバイナリ形式（protoc の --descriptor_set_out と同じ形式）で返す
+func Marshal(paths ...string) ([]byte, error) {
+	set, err := Generate(paths...)
+	if err != nil {
+		return nil, err
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(set)
+}
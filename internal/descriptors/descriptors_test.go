@@ -0,0 +1,41 @@
+package descriptors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGenerate(t *testing.T) {
+	b, err := Marshal()
+	require.NoError(t, err)
+	set := &descriptorpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(b, set))
+
+	// 依存されるファイルが先に並び、他の定義なしで解決できる
+	var paths []string
+	for _, f := range set.File {
+		paths = append(paths, f.GetName())
+	}
+	require.Equal(t, Files, paths)
+	files, err := protodesc.NewFiles(set)
+	require.NoError(t, err)
+	d, err := files.FindDescriptorByName("log.v1.Log")
+	require.NoError(t, err)
+	require.NotNil(t, d.(protoreflect.ServiceDescriptor).Methods().ByName("Produce"))
+	_, err = files.FindDescriptorByName("admin.v1.Admin")
+	require.NoError(t, err)
+
+	// 指定したファイルとその依存関係だけを含める
+	set, err = Generate("api/admin/v1/admin.proto")
+	require.NoError(t, err)
+	require.Len(t, set.File, 2)
+	require.Equal(t, "api/v1/log.proto", set.File[0].GetName())
+
+	_, err = Generate("api/v2/missing.proto")
+	require.Error(t, err)
+}
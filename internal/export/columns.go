@@ -0,0 +1,135 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// Column: 出力する列と、列の値として取り出すレコードのフィールドの対応
+type Column struct {
+	Name  string // 列名
+	Field string // レコードのフィールド（offset, timestamp, key, value, producer_id, sequence, header.<名前>）
+	UTF8  bool   // key と value を文字列（UTF-8）として出力するかどうか（指定しない場合はバイナリ）
+}
+
+// DefaultColumns: 列の指定がない場合に出力する列
+var DefaultColumns = []Column{
+	{Name: "offset", Field: "offset"},
+	{Name: "timestamp", Field: "timestamp"},
+	{Name: "key", Field: "key"},
+	{Name: "value", Field: "value"},
+}
+
+// ParseColumns: 列の指定を解析する
+// 列の指定はカンマ区切りで、各列は "[列名=]フィールド[:string]" の形式（列名を省略した場合はフィールド名）。
+// :string を付けた key と value は、バイナリではなく文字列として出力する。
+// 例: offset,time=timestamp,value:string,source=header.source
+// 引数:
+//   - spec: 列の指定（空の場合は DefaultColumns）
+//
+// 戻り値:
+//   - []Column: 解析した列
+//   - error: 列の指定が不正な場合
+func ParseColumns(spec string) ([]Column, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultColumns, nil
+	}
+	var cols []Column
+	names := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		col := Column{}
+		if name, field, ok := strings.Cut(part, "="); ok {
+			col.Name, part = strings.TrimSpace(name), strings.TrimSpace(field)
+		}
+		if field, ok := strings.CutSuffix(part, ":string"); ok {
+			col.UTF8, part = true, field
+		}
+		col.Field = part
+		if col.Name == "" {
+			col.Name = col.Field
+		}
+		if err := col.validate(); err != nil {
+			return nil, err
+		}
+		if names[col.Name] {
+			return nil, fmt.Errorf("duplicate column %q", col.Name)
+		}
+		names[col.Name] = true
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// validate: 列の指定が正しいかを確認する
+func (c Column) validate() error {
+	switch c.Field {
+	case "offset", "timestamp", "producer_id", "sequence":
+		if c.UTF8 {
+			return fmt.Errorf(":string is not supported for field %q", c.Field)
+		}
+	case "key", "value":
+	default:
+		name, ok := strings.CutPrefix(c.Field, "header.")
+		if !ok || name == "" {
+			return fmt.Errorf("unknown field %q", c.Field)
+		}
+		if c.UTF8 {
+			return fmt.Errorf(":string is not supported for field %q", c.Field)
+		}
+	}
+	return nil
+}
+
+// int64Field: 列のフィールドが整数かどうか
+func (c Column) int64Field() bool {
+	switch c.Field {
+	case "offset", "timestamp", "sequence":
+		return true
+	}
+	return false
+}
+
+// intValue: レコードから整数の列の値を取り出す
+// 戻り値:
+//   - int64: 列の値
+//   - bool: 値がある場合 true（タイムスタンプが設定されていない場合は false）
+func (c Column) intValue(record *api.Record) (int64, bool) {
+	switch c.Field {
+	case "offset":
+		return int64(record.Offset), true
+	case "timestamp":
+		return record.Timestamp, record.Timestamp != 0
+	case "sequence":
+		return int64(record.Sequence), true
+	}
+	return 0, false
+}
+
+// bytesValue: レコードからバイト列の列の値を取り出す
+// 戻り値:
+//   - []byte: 列の値
+//   - bool: 値がある場合 true（空のキーやプロデューサー ID、存在しないヘッダーは false）
+func (c Column) bytesValue(record *api.Record) ([]byte, bool) {
+	switch c.Field {
+	case "key":
+		return record.Key, len(record.Key) > 0
+	case "value":
+		return record.Value, true
+	case "producer_id":
+		return []byte(record.ProducerId), record.ProducerId != ""
+	}
+	v, ok := record.Headers[strings.TrimPrefix(c.Field, "header.")]
+	return []byte(v), ok
+}
+
+// required: 列が常に値を持つかどうか（値を持たない可能性がある列は OPTIONAL として出力する）
+func (c Column) required() bool {
+	switch c.Field {
+	case "offset", "sequence", "value":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package export
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// Encoding: JSON Lines でのキーと値のエンコーディング
+type Encoding int
+
+const (
+	// EncodingBase64: キーと値を base64 の文字列として出力する（任意のバイト列を扱える）
+	EncodingBase64 Encoding = iota
+	// EncodingUTF8: キーと値をそのまま文字列として出力する（UTF-8 として正しくない値はエラーになる）
+	EncodingUTF8
+)
+
+// ParseEncoding: エンコーディングの名前（"base64" または "utf8"）を解析する
+func ParseEncoding(s string) (Encoding, error) {
+	switch s {
+	case "base64":
+		return EncodingBase64, nil
+	case "utf8":
+		return EncodingUTF8, nil
+	}
+	return 0, fmt.Errorf("unknown encoding %q", s)
+}
+
+// jsonRecord: JSON Lines の1行の形式
+type jsonRecord struct {
+	Offset     uint64            `json:"offset"`
+	Timestamp  int64             `json:"timestamp,omitempty"`
+	Key        string            `json:"key,omitempty"`
+	Value      string            `json:"value"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	ProducerId string            `json:"producer_id,omitempty"`
+	Sequence   uint64            `json:"sequence,omitempty"`
+}
+
+// JSONLWriter: レコードを JSON Lines 形式で書き込む
+type JSONLWriter struct {
+	enc  *json.Encoder
+	kind Encoding
+	rows int64
+}
+
+// NewJSONLWriter: レコードを JSON Lines 形式で書き込むライターを作成する
+// 引数:
+//   - w: 書き込み先
+//   - enc: キーと値のエンコーディング
+//
+// 戻り値:
+//   - *JSONLWriter: 作成されたライター
+func NewJSONLWriter(w io.Writer, enc Encoding) *JSONLWriter {
+	je := json.NewEncoder(w)
+	je.SetEscapeHTML(false)
+	return &JSONLWriter{enc: je, kind: enc}
+}
+
+// Write: レコードを1行として書き込む
+func (jw *JSONLWriter) Write(record *api.Record) error {
+	key, err := jw.encode(record.Key)
+	if err != nil {
+		return fmt.Errorf("key at offset %d: %w", record.Offset, err)
+	}
+	value, err := jw.encode(record.Value)
+	if err != nil {
+		return fmt.Errorf("value at offset %d: %w", record.Offset, err)
+	}
+	err = jw.enc.Encode(jsonRecord{
+		Offset:     record.Offset,
+		Timestamp:  record.Timestamp,
+		Key:        key,
+		Value:      value,
+		Headers:    record.Headers,
+		ProducerId: record.ProducerId,
+		Sequence:   record.Sequence,
+	})
+	if err != nil {
+		return err
+	}
+	jw.rows++
+	return nil
+}
+
+// Rows: 書き込んだ行数を返す
+func (jw *JSONLWriter) Rows() int64 {
+	return jw.rows
+}
+
+// encode: キーまたは値を文字列にエンコードする
+func (jw *JSONLWriter) encode(b []byte) (string, error) {
+	if jw.kind == EncodingBase64 {
+		return base64.StdEncoding.EncodeToString(b), nil
+	}
+	if !utf8.Valid(b) {
+		return "", fmt.Errorf("not valid UTF-8 (use base64 encoding)")
+	}
+	return string(b), nil
+}
+
+// JSONLReader: JSON Lines 形式のレコードを読み取る
+type JSONLReader struct {
+	dec  *json.Decoder
+	kind Encoding
+	line int
+}
+
+// NewJSONLReader: JSON Lines 形式のレコードを読み取るリーダーを作成する
+// 引数:
+//   - r: 読み取り元
+//   - enc: キーと値のエンコーディング（書き込んだときと同じもの）
+//
+// 戻り値:
+//   - *JSONLReader: 作成されたリーダー
+func NewJSONLReader(r io.Reader, enc Encoding) *JSONLReader {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	dec.DisallowUnknownFields()
+	return &JSONLReader{dec: dec, kind: enc}
+}
+
+// Read: 次のレコードを読み取る
+// 戻り値:
+//   - *api.Record: 読み取ったレコード（オフセットは書き込んだときのログでのオフセット）
+//   - error: 末尾に達した場合は io.EOF、形式が不正な場合はエラー
+func (jr *JSONLReader) Read() (*api.Record, error) {
+	var rec jsonRecord
+	if err := jr.dec.Decode(&rec); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("record %d: %w", jr.line+1, err)
+	}
+	jr.line++
+	key, err := jr.decode(rec.Key)
+	if err != nil {
+		return nil, fmt.Errorf("record %d: key: %w", jr.line, err)
+	}
+	value, err := jr.decode(rec.Value)
+	if err != nil {
+		return nil, fmt.Errorf("record %d: value: %w", jr.line, err)
+	}
+	return &api.Record{
+		Offset:     rec.Offset,
+		Timestamp:  rec.Timestamp,
+		Key:        key,
+		Value:      value,
+		Headers:    rec.Headers,
+		ProducerId: rec.ProducerId,
+		Sequence:   rec.Sequence,
+	}, nil
+}
+
+// decode: 文字列からキーまたは値をデコードする
+func (jr *JSONLReader) decode(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if jr.kind == EncodingBase64 {
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return []byte(s), nil
+}
+
+// Appender: インポートしたレコードを追加するログストア（例: log.Log）
+type Appender interface {
+	Append(*api.Record) (uint64, error)
+}
+
+// ImportJSONL: JSON Lines 形式のレコードをログに追加する
+// オフセットは追加先のログで新しく割り当てられる。プロデューサー ID とシーケンス番号は保持するため、
+// 同じファイルを再度インポートしても重複排除によって二重に追加されない。
+// 引数:
+//   - r: 読み取り元
+//   - a: 追加先のログストア
+//   - enc: キーと値のエンコーディング
+//
+// 戻り値:
+//   - int64: 読み取ったレコードの数
+//   - error: エラーが発生した場合
+func ImportJSONL(r io.Reader, a Appender, enc Encoding) (int64, error) {
+	jr := NewJSONLReader(r, enc)
+	var n int64
+	for {
+		record, err := jr.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		record.Offset = 0
+		if _, err := a.Append(record); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+)
+
+// appendLog: テスト用のレコードを追加できるメモリ上のログストア
+type appendLog struct {
+	memLog
+}
+
+func (l *appendLog) Append(record *api.Record) (uint64, error) {
+	record.Offset = uint64(len(l.memLog))
+	l.memLog = append(l.memLog, record)
+	return record.Offset, nil
+}
+
+func TestJSONL(t *testing.T) {
+	l := memLog{
+		{Offset: 0, Value: []byte("skipped")},
+		{Offset: 1, Key: []byte("k"), Value: []byte(`{"a":"<b>"}`), Timestamp: 1000,
+			Headers: map[string]string{"source": "web"}, ProducerId: "p", Sequence: 3},
+		{Offset: 2, Value: []byte{0xff, 0x00}},
+	}
+
+	for scenario, tc := range map[string]struct {
+		enc  Encoding
+		want string
+		err  string
+	}{
+		"base64": {
+			enc: EncodingBase64,
+			want: `{"offset":1,"timestamp":1000,"key":"aw==","value":"eyJhIjoiPGI+In0=","headers":{"source":"web"},"producer_id":"p","sequence":3}
+{"offset":2,"value":"/wA="}
+`,
+		},
+		"utf8 rejects binary values": {
+			enc: EncodingUTF8,
+			want: `{"offset":1,"timestamp":1000,"key":"k","value":"{\"a\":\"<b>\"}","headers":{"source":"web"},"producer_id":"p","sequence":3}
+`,
+			err: "value at offset 2",
+		},
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := JSONL(&buf, l, Range{FromOffset: 1}, tc.enc)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.want, buf.String())
+
+			// 書き込んだレコードを別のログに取り込むと、オフセット以外が一致する
+			dst := &appendLog{}
+			imported, err := ImportJSONL(&buf, dst, tc.enc)
+			require.NoError(t, err)
+			require.Equal(t, n, imported)
+			for i, record := range dst.memLog {
+				want := l[i+1]
+				require.Equal(t, uint64(i), record.Offset)
+				require.Equal(t, want.Value, record.Value)
+				require.Equal(t, want.Headers, record.Headers)
+				require.Equal(t, want.Timestamp, record.Timestamp)
+				require.Equal(t, want.ProducerId, record.ProducerId)
+			}
+		})
+	}
+
+	_, err := ImportJSONL(strings.NewReader(`{"offset":0,"value":"!"}`), &appendLog{}, EncodingBase64)
+	require.ErrorContains(t, err, "record 1: value")
+	_, err = ImportJSONL(strings.NewReader(`{"value":"a","size":1}`), &appendLog{}, EncodingUTF8)
+	require.Error(t, err)
+}
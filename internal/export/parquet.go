@@ -0,0 +1,310 @@
+package export
+
+import (
+	"encoding/binary"
+	"io"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// Parquet ファイルの形式:
+//
+//	"PAR1" [行グループ]... [ファイルメタデータ(Thrift)] [メタデータの長さ(4バイト、リトルエンディアン)] "PAR1"
+//
+// 各行グループは列ごとに1つのデータページ（PLAIN エンコーディング、非圧縮）を持つ。
+// 依存ライブラリを増やさないように、レコードの出力に必要な最小限の機能だけを実装している。
+const (
+	parquetMagic = "PAR1"
+	createdBy    = "proglog"
+
+	// RowGroupRows: 1つの行グループに含める最大の行数（メモリ上にバッファする行数）
+	RowGroupRows = 64 * 1024
+)
+
+// Parquet の列の物理型
+const (
+	typeInt64     int32 = 2
+	typeByteArray int32 = 6
+)
+
+// Parquet の列の繰り返しの種類
+const (
+	repetitionRequired int32 = 0
+	repetitionOptional int32 = 1
+)
+
+// Parquet の列の論理型（ConvertedType）
+const (
+	convertedUTF8            int32 = 0
+	convertedTimestampMillis int32 = 9
+	convertedUint64          int32 = 14
+)
+
+// Parquet のエンコーディング、圧縮形式、ページの種類
+const (
+	encodingPlain      int32 = 0
+	encodingRLE        int32 = 3
+	codecUncompressed  int32 = 0
+	pageTypeDataPage   int32 = 0
+	parquetFileVersion int32 = 1
+)
+
+// ParquetWriter: レコードを Parquet 形式で書き込む
+// RowGroupRows 行ごとに行グループとして書き出し、Close でファイルメタデータを書き込む。
+type ParquetWriter struct {
+	w    *countingWriter
+	cols []Column
+
+	pages     []page     // 書き込み中の行グループの列ごとのデータ
+	rows      int64      // 書き込み中の行グループの行数
+	total     int64      // 書き込んだ行数
+	rowGroups []rowGroup // 書き出した行グループ
+}
+
+// page: 1つの列のデータページの内容
+type page struct {
+	values  []byte // PLAIN エンコーディングした値（null を除く）
+	defined []bool // 行ごとに値があるかどうか（OPTIONAL の列のみ）
+	count   int    // 値の数（null を含む）
+}
+
+// rowGroup: 書き出した行グループのメタデータ
+type rowGroup struct {
+	columns []columnChunk
+	rows    int64
+	size    int64
+}
+
+// columnChunk: 書き出した列のメタデータ
+type columnChunk struct {
+	offset int64 // データページの位置
+	size   int64 // ページヘッダーを含むバイト数
+	values int64 // 値の数（null を含む）
+}
+
+// NewParquetWriter: レコードを Parquet 形式で書き込むライターを作成する
+// 引数:
+//   - w: 書き込み先
+//   - cols: 出力する列
+//
+// 戻り値:
+//   - *ParquetWriter: 作成されたライター
+//   - error: 書き込みに失敗した場合
+func NewParquetWriter(w io.Writer, cols []Column) (*ParquetWriter, error) {
+	pw := &ParquetWriter{
+		w:     &countingWriter{w: w},
+		cols:  cols,
+		pages: make([]page, len(cols)),
+	}
+	if _, err := io.WriteString(pw.w, parquetMagic); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// Write: レコードを1行として書き込む
+func (pw *ParquetWriter) Write(record *api.Record) error {
+	for i, c := range pw.cols {
+		p := &pw.pages[i]
+		var ok bool
+		if c.int64Field() {
+			var v int64
+			if v, ok = c.intValue(record); ok {
+				p.values = binary.LittleEndian.AppendUint64(p.values, uint64(v))
+			}
+		} else {
+			var v []byte
+			if v, ok = c.bytesValue(record); ok {
+				p.values = binary.LittleEndian.AppendUint32(p.values, uint32(len(v)))
+				p.values = append(p.values, v...)
+			}
+		}
+		if !c.required() {
+			p.defined = append(p.defined, ok)
+		}
+		p.count++
+	}
+	pw.rows++
+	pw.total++
+	if pw.rows >= RowGroupRows {
+		return pw.flush()
+	}
+	return nil
+}
+
+// Rows: 書き込んだ行数を返す
+func (pw *ParquetWriter) Rows() int64 {
+	return pw.total
+}
+
+// Close: 残りの行を書き出し、ファイルメタデータを書き込む（書き込み先は閉じない）
+func (pw *ParquetWriter) Close() error {
+	if pw.rows > 0 {
+		if err := pw.flush(); err != nil {
+			return err
+		}
+	}
+	meta := pw.fileMetaData()
+	if _, err := pw.w.Write(meta); err != nil {
+		return err
+	}
+	if _, err := pw.w.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(meta)))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(pw.w, parquetMagic)
+	return err
+}
+
+// flush: バッファした行を行グループとして書き出す
+func (pw *ParquetWriter) flush() error {
+	rg := rowGroup{rows: pw.rows}
+	for i, c := range pw.cols {
+		p := &pw.pages[i]
+		data := p.values
+		if !c.required() {
+			data = append(encodeLevels(p.defined), data...)
+		}
+
+		t := &thriftWriter{}
+		t.begin()
+		t.i32(1, pageTypeDataPage)
+		t.i32(2, int32(len(data)))
+		t.i32(3, int32(len(data)))
+		t.beginStruct(5)
+		t.i32(1, int32(p.count))
+		t.i32(2, encodingPlain)
+		t.i32(3, encodingRLE)
+		t.i32(4, encodingRLE)
+		t.end()
+		t.end()
+
+		chunk := columnChunk{
+			offset: pw.w.n,
+			size:   int64(len(t.buf) + len(data)),
+			values: int64(p.count),
+		}
+		if _, err := pw.w.Write(t.buf); err != nil {
+			return err
+		}
+		if _, err := pw.w.Write(data); err != nil {
+			return err
+		}
+		rg.columns = append(rg.columns, chunk)
+		rg.size += chunk.size
+		*p = page{values: p.values[:0], defined: p.defined[:0]}
+	}
+	pw.rowGroups = append(pw.rowGroups, rg)
+	pw.rows = 0
+	return nil
+}
+
+// encodeLevels: 定義レベル（値があれば 1、null なら 0）を RLE/ビットパッキングのハイブリッド形式でエンコードする
+// データページ v1 の形式に従い、先頭に4バイトの長さを付ける。値は8個ずつビットパッキングする。
+func encodeLevels(defined []bool) []byte {
+	groups := (len(defined) + 7) / 8
+	b := binary.LittleEndian.AppendUint32(nil, 0)
+	b = binary.AppendUvarint(b, uint64(groups)<<1|1)
+	packed := make([]byte, groups)
+	for i, ok := range defined {
+		if ok {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	b = append(b, packed...)
+	binary.LittleEndian.PutUint32(b, uint32(len(b)-4))
+	return b
+}
+
+// fileMetaData: ファイルメタデータ（スキーマと行グループの位置）をエンコードする
+func (pw *ParquetWriter) fileMetaData() []byte {
+	t := &thriftWriter{}
+	t.begin()
+	t.i32(1, parquetFileVersion)
+
+	// スキーマ: ルート要素と、列ごとの要素
+	t.list(2, ctStruct, len(pw.cols)+1)
+	t.begin()
+	t.string(4, "schema")
+	t.i32(5, int32(len(pw.cols)))
+	t.end()
+	for _, c := range pw.cols {
+		t.begin()
+		t.i32(1, c.physicalType())
+		repetition := repetitionOptional
+		if c.required() {
+			repetition = repetitionRequired
+		}
+		t.i32(3, repetition)
+		t.string(4, c.Name)
+		if converted, ok := c.convertedType(); ok {
+			t.i32(6, converted)
+		}
+		t.end()
+	}
+
+	t.i64(3, pw.total)
+	t.list(4, ctStruct, len(pw.rowGroups))
+	for _, rg := range pw.rowGroups {
+		t.begin()
+		t.list(1, ctStruct, len(rg.columns))
+		for i, chunk := range rg.columns {
+			c := pw.cols[i]
+			t.begin()
+			t.i64(2, chunk.offset)
+			t.beginStruct(3)
+			t.i32(1, c.physicalType())
+			t.list(2, ctI32, 2)
+			t.listI32(encodingPlain)
+			t.listI32(encodingRLE)
+			t.list(3, ctBinary, 1)
+			t.listString(c.Name)
+			t.i32(4, codecUncompressed)
+			t.i64(5, chunk.values)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.end()
+			t.end()
+		}
+		t.i64(2, rg.size)
+		t.i64(3, rg.rows)
+		t.end()
+	}
+	t.string(6, createdBy)
+	t.end()
+	return t.buf
+}
+
+// physicalType: 列の物理型を返す
+func (c Column) physicalType() int32 {
+	if c.int64Field() {
+		return typeInt64
+	}
+	return typeByteArray
+}
+
+// convertedType: 列の論理型を返す（指定しない場合は false）
+func (c Column) convertedType() (int32, bool) {
+	switch c.Field {
+	case "offset", "sequence":
+		return convertedUint64, true
+	case "timestamp":
+		return convertedTimestampMillis, true
+	case "key", "value":
+		return convertedUTF8, c.UTF8
+	}
+	// プロデューサー ID とヘッダーは文字列
+	return convertedUTF8, true
+}
+
+// countingWriter: 書き込んだバイト数を数える io.Writer（ページの位置を記録するため）
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,163 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+)
+
+// memLog: テスト用のメモリ上のログストア
+type memLog []*api.Record
+
+func (l memLog) Read(off uint64) (*api.Record, error) {
+	if off >= uint64(len(l)) {
+		return nil, api.ErrOffsetOutOfRange{Offset: off}
+	}
+	return l[off], nil
+}
+
+func TestParquet(t *testing.T) {
+	var l memLog
+	for i, v := range []string{"a", "bb", "ccc", "dddd"} {
+		record := &api.Record{
+			Offset:    uint64(i),
+			Value:     []byte(v),
+			Timestamp: int64(1000 * (i + 1)),
+		}
+		if i%2 == 0 {
+			record.Headers = map[string]string{"source": "web"}
+		}
+		l = append(l, record)
+	}
+
+	cols, err := ParseColumns("offset,time=timestamp,value:string,source=header.source")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	n, err := Parquet(&buf, l, Range{FromOffset: 1, FromTime: 2000}, cols)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n)
+
+	b := buf.Bytes()
+	require.Equal(t, parquetMagic, string(b[:4]))
+	require.Equal(t, parquetMagic, string(b[len(b)-4:]))
+	size := binary.LittleEndian.Uint32(b[len(b)-8:])
+	meta := readStruct(t, bytes.NewReader(b[len(b)-8-int(size):len(b)-8]))
+
+	// スキーマ: ルート要素 + 4列
+	require.Equal(t, int64(3), meta[3])
+	schema := meta[2].([]any)
+	require.Len(t, schema, 5)
+	var names []string
+	for _, e := range schema[1:] {
+		names = append(names, string(e.(map[int16]any)[4].([]byte)))
+	}
+	require.Equal(t, []string{"offset", "time", "value", "source"}, names)
+	require.Equal(t, int64(repetitionOptional), schema[4].(map[int16]any)[3])
+
+	// 列ごとのデータページの値
+	rowGroups := meta[4].([]any)
+	require.Len(t, rowGroups, 1)
+	columns := rowGroups[0].(map[int16]any)[1].([]any)
+	values := func(i int) []byte {
+		md := columns[i].(map[int16]any)[3].(map[int16]any)
+		r := bytes.NewReader(b[md[9].(int64):])
+		header := readStruct(t, r)
+		require.Equal(t, int64(3), header[5].(map[int16]any)[1])
+		data := make([]byte, header[3].(int64))
+		_, err := r.Read(data)
+		require.NoError(t, err)
+		return data
+	}
+
+	offsets := values(0)
+	for i, want := range []uint64{1, 2, 3} {
+		require.Equal(t, want, binary.LittleEndian.Uint64(offsets[i*8:]))
+	}
+	times := values(1)
+	require.Equal(t, uint64(2000), binary.LittleEndian.Uint64(times[1+4+1:]))
+
+	// OPTIONAL の列は定義レベル（offset 2 だけがヘッダーを持つ）の後に、値があるものだけが続く
+	source := values(3)
+	require.Equal(t, []byte{2, 0, 0, 0, 3, 0b010}, source[:6])
+	require.Equal(t, append([]byte{3, 0, 0, 0}, "web"...), source[6:])
+}
+
+func TestParseColumns(t *testing.T) {
+	cols, err := ParseColumns("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultColumns, cols)
+
+	cols, err = ParseColumns("offset, k=key:string, header.user")
+	require.NoError(t, err)
+	require.Equal(t, []Column{
+		{Name: "offset", Field: "offset"},
+		{Name: "k", Field: "key", UTF8: true},
+		{Name: "header.user", Field: "header.user"},
+	}, cols)
+
+	for _, spec := range []string{"size", "header.", "offset:string", "a=offset,a=key"} {
+		_, err = ParseColumns(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+// readStruct: Thrift Compact Protocol の構造体を、フィールド ID から値への map として読み取る
+// （Parquet のメタデータを検証するためのテスト用の最小限のデコーダー）
+func readStruct(t *testing.T, r *bytes.Reader) map[int16]any {
+	t.Helper()
+	fields := make(map[int16]any)
+	var last int16
+	for {
+		h, err := r.ReadByte()
+		require.NoError(t, err)
+		if h == 0 {
+			return fields
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			v, err := binary.ReadVarint(r)
+			require.NoError(t, err)
+			id = int16(v)
+		}
+		last = id
+		fields[id] = readValue(t, r, h&0x0f)
+	}
+}
+
+func readValue(t *testing.T, r *bytes.Reader, typ byte) any {
+	t.Helper()
+	switch typ {
+	case ctI32, ctI64:
+		v, err := binary.ReadVarint(r)
+		require.NoError(t, err)
+		return v
+	case ctBinary:
+		n, err := binary.ReadUvarint(r)
+		require.NoError(t, err)
+		b := make([]byte, n)
+		_, err = r.Read(b)
+		require.NoError(t, err)
+		return b
+	case ctList:
+		h, err := r.ReadByte()
+		require.NoError(t, err)
+		n := uint64(h >> 4)
+		if n == 15 {
+			n, err = binary.ReadUvarint(r)
+			require.NoError(t, err)
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = readValue(t, r, h&0x0f)
+		}
+		return list
+	case ctStruct:
+		return readStruct(t, r)
+	}
+	t.Fatalf("unexpected thrift type %d", typ)
+	return nil
+}
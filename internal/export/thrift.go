@@ -0,0 +1,91 @@
+package export
+
+import "encoding/binary"
+
+// Thrift Compact Protocol の型（Parquet のファイルメタデータとページヘッダーのエンコードに使用する）
+const (
+	ctI32    byte = 5
+	ctI64    byte = 6
+	ctBinary byte = 8
+	ctList   byte = 9
+	ctStruct byte = 12
+)
+
+// thriftWriter: Thrift Compact Protocol で構造体をエンコードする
+// Parquet のメタデータを書き込むのに必要な型（i32, i64, binary, list, struct）だけを扱う。
+type thriftWriter struct {
+	buf   []byte
+	last  int16   // 現在の構造体で最後に書き込んだフィールド ID
+	stack []int16 // 入れ子の構造体の外側の last
+}
+
+// field: フィールドヘッダーを書き込む（直前のフィールド ID との差分が 1〜15 の場合は1バイトに詰める）
+func (w *thriftWriter) field(id int16, typ byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.buf = binary.AppendVarint(w.buf, int64(id))
+	}
+	w.last = id
+}
+
+// i32: i32 のフィールドを書き込む
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, ctI32)
+	w.buf = binary.AppendVarint(w.buf, int64(v))
+}
+
+// i64: i64 のフィールドを書き込む
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, ctI64)
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+// string: binary（文字列）のフィールドを書き込む
+func (w *thriftWriter) string(id int16, s string) {
+	w.field(id, ctBinary)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// list: list のフィールドのヘッダーを書き込む（続けて n 個の要素を書き込む）
+func (w *thriftWriter) list(id int16, elem byte, n int) {
+	w.field(id, ctList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|elem)
+		return
+	}
+	w.buf = append(w.buf, 0xf0|elem)
+	w.buf = binary.AppendUvarint(w.buf, uint64(n))
+}
+
+// listI32: list の i32 の要素を書き込む
+func (w *thriftWriter) listI32(v int32) {
+	w.buf = binary.AppendVarint(w.buf, int64(v))
+}
+
+// listString: list の文字列の要素を書き込む
+func (w *thriftWriter) listString(s string) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// beginStruct: struct のフィールドを開始する
+func (w *thriftWriter) beginStruct(id int16) {
+	w.field(id, ctStruct)
+	w.begin()
+}
+
+// begin: 構造体を開始する（list の要素の struct や、最上位の struct の場合はフィールドヘッダーなし）
+func (w *thriftWriter) begin() {
+	w.stack = append(w.stack, w.last)
+	w.last = 0
+}
+
+// end: 構造体を終了する（STOP を書き込む）
+func (w *thriftWriter) end() {
+	w.buf = append(w.buf, 0)
+	w.last = w.stack[len(w.stack)-1]
+	w.stack = w.stack[:len(w.stack)-1]
+}
@@ -0,0 +1,89 @@
+package health
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// DiskFree: ディレクトリのファイルシステムの空き容量が minBytes 未満の場合に異常とするチェックを作成する
+// 引数:
+//   - dir: 確認するディレクトリ（ログディレクトリなど）
+//   - minBytes: 正常とみなす最小の空き容量
+func DiskFree(dir string, minBytes uint64) Check {
+	return Check{Name: "disk_free", Run: func() error {
+		var fs syscall.Statfs_t
+		if err := syscall.Statfs(dir, &fs); err != nil {
+			return err
+		}
+		if free := fs.Bavail * uint64(fs.Bsize); free < minBytes {
+			return fmt.Errorf("%d bytes free in %s, below %d", free, dir, minBytes)
+		}
+		return nil
+	}}
+}
+
+// FsyncLatency: ディレクトリに書き込んだファイルの fsync が max 以上かかる場合に異常とするチェックを作成する
+// チェックのたびに小さな一時ファイルを作成して削除する。
+// 引数:
+//   - dir: 確認するディレクトリ（ログディレクトリなど）
+//   - max: 正常とみなす fsync の最大の所要時間
+//   - clock: 所要時間の計測に使用する時計（nil の場合は log.SystemClock）
+func FsyncLatency(dir string, max time.Duration, clock log.Clock) Check {
+	if clock == nil {
+		clock = log.SystemClock
+	}
+	return Check{Name: "fsync_latency", Run: func() error {
+		f, err := os.CreateTemp(dir, ".fsync-check-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		if _, err = f.Write([]byte{0}); err != nil {
+			return err
+		}
+		start := clock.Now()
+		if err = f.Sync(); err != nil {
+			return err
+		}
+		if d := clock.Now().Sub(start); d >= max {
+			return fmt.Errorf("fsync took %s, limit %s", d, max)
+		}
+		return nil
+	}}
+}
+
+// IndexConsistency: ログストアのインデックスとストアが食い違っている場合に異常とするチェックを作成する
+// 引数:
+//   - l: 確認するログストア（log.Log など）
+func IndexConsistency(l interface{ VerifyIndex() error }) Check {
+	return Check{Name: "index_consistency", Run: l.VerifyIndex}
+}
+
+// Writable: ディスクが一杯のためログストアが読み取り専用になっている場合に異常とするチェックを作成する
+// 引数:
+//   - l: 確認するログストア（log.Log など）
+func Writable(l interface{ DiskFull() error }) Check {
+	return Check{Name: "writable", Run: l.DiskFull}
+}
+
+// ReplicationLag: レプリカの遅れが max レコードを超えた場合に異常とするチェックを作成する
+// 引数:
+//   - lag: リーダーの末尾からの遅れ（レコード数）を返す関数（リーダーに到達できない場合はエラー）
+//   - max: 正常とみなす最大の遅れ
+func ReplicationLag(lag func() (uint64, error), max uint64) Check {
+	return Check{Name: "replication_lag", Run: func() error {
+		n, err := lag()
+		if err != nil {
+			return err
+		}
+		if n > max {
+			return fmt.Errorf("%d records behind leader, limit %d", n, max)
+		}
+		return nil
+	}}
+}
@@ -0,0 +1,141 @@
+package health
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// DefaultInterval: 間隔が指定されていない場合の、セルフチェックを実行する間隔
+const DefaultInterval = 30 * time.Second
+
+// Check: 1つのセルフチェック
+// Run は異常を検出した場合に、その理由を表すエラーを返す。
+type Check struct {
+	Name string // チェックの名前（メトリクスのラベルや理由の接頭辞に使用する）
+	Run  func() error
+}
+
+// Result: セルフチェックの結果
+type Result struct {
+	Name      string
+	Err       error     // 異常の理由（正常な場合は nil）
+	CheckedAt time.Time // チェックを実行した時刻
+}
+
+// Status: すべてのセルフチェックの結果をまとめた状態
+type Status struct {
+	Healthy bool     // すべてのチェックが正常な場合 true
+	Results []Result // Config.Checks の順に並べた結果
+}
+
+// Reasons: 異常なチェックの理由を "名前: 理由" の形式で返す
+func (s Status) Reasons() []string {
+	var reasons []string
+	for _, r := range s.Results {
+		if r.Err != nil {
+			reasons = append(reasons, fmt.Sprintf("%s: %v", r.Name, r.Err))
+		}
+	}
+	return reasons
+}
+
+// Config: セルフチェックの設定
+type Config struct {
+	Checks   []Check
+	Interval time.Duration // チェックを実行する間隔（0 の場合は DefaultInterval）
+	Clock    log.Clock     // 時刻の取得と定期的な実行に使用する時計（nil の場合は log.SystemClock）
+	// OnChange: 状態が正常と異常の間で変わったとき、または異常の理由が変わったときに呼び出される（任意）
+	OnChange func(Status)
+}
+
+// Monitor: セルフチェックを定期的に実行し、最新の結果を保持する
+// 監視システムが特定の状態で警告できるように、チェックごとの結果と異常の理由を公開する。
+type Monitor struct {
+	Config
+
+	mu     sync.RWMutex
+	status Status
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewMonitor: セルフチェックを1回実行した後、Interval ごとに実行するゴルーチンを開始する
+// 引数:
+//   - c: セルフチェックの設定
+//
+// 戻り値:
+//   - *Monitor: 開始したモニター（Close で停止する）
+func NewMonitor(c Config) *Monitor {
+	if c.Interval == 0 {
+		c.Interval = DefaultInterval
+	}
+	if c.Clock == nil {
+		c.Clock = log.SystemClock
+	}
+	m := &Monitor{Config: c, done: make(chan struct{})}
+	m.RunChecks()
+
+	log.Every(c.Clock, c.Interval, m.done, &m.wg, func(time.Time) {
+		m.RunChecks()
+	})
+	return m
+}
+
+// RunChecks: すべてのセルフチェックを実行して状態を更新する
+// 間隔を待たずに状態を確認したい場合（起動直後や障害からの復旧後など）にも使用する。
+// 戻り値:
+//   - Status: 更新後の状態
+func (m *Monitor) RunChecks() Status {
+	status := Status{Healthy: true}
+	for _, c := range m.Checks {
+		r := Result{Name: c.Name, Err: c.Run(), CheckedAt: m.Clock.Now()}
+		if r.Err != nil {
+			status.Healthy = false
+		}
+		status.Results = append(status.Results, r)
+	}
+
+	m.mu.Lock()
+	prev := m.status
+	m.status = status
+	m.mu.Unlock()
+
+	if m.OnChange != nil && changed(prev, status) {
+		m.OnChange(status)
+	}
+	return status
+}
+
+// changed: 状態または異常の理由が変わったかどうかを判定する（内部関数）
+func changed(prev, next Status) bool {
+	if prev.Results == nil {
+		return true
+	}
+	a, b := prev.Reasons(), next.Reasons()
+	if prev.Healthy != next.Healthy || len(a) != len(b) {
+		return true
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// Status: 最後に実行したセルフチェックの状態を返す
+func (m *Monitor) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Close: セルフチェックの定期的な実行を停止する
+func (m *Monitor) Close() {
+	close(m.done)
+	m.wg.Wait()
+}
@@ -0,0 +1,76 @@
+package health
+
+import (
+	"errors"
+	"math"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitor(t *testing.T) {
+	clock := log.NewFakeClock(time.UnixMilli(1700000000000))
+	var lag atomic.Uint64
+	changes := make(chan Status, 10)
+	m := NewMonitor(Config{
+		Checks: []Check{
+			{Name: "ok", Run: func() error { return nil }},
+			ReplicationLag(func() (uint64, error) { return lag.Load(), nil }, 100),
+		},
+		Interval: time.Second,
+		Clock:    clock,
+		OnChange: func(s Status) { changes <- s },
+	})
+	defer m.Close()
+
+	// 作成時に1回実行される
+	status := <-changes
+	require.True(t, status.Healthy)
+	require.Len(t, status.Results, 2)
+	require.Empty(t, status.Reasons())
+
+	// 間隔ごとに実行され、異常の理由が公開される
+	lag.Store(150)
+	clock.Advance(time.Second)
+	select {
+	case status = <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("checks did not run")
+	}
+	require.False(t, status.Healthy)
+	require.Equal(t, []string{"replication_lag: 150 records behind leader, limit 100"}, status.Reasons())
+	require.Equal(t, status, m.Status())
+
+	// 理由が変わらない場合は通知しない
+	m.RunChecks()
+	lag.Store(0)
+	require.True(t, m.RunChecks().Healthy)
+	require.True(t, (<-changes).Healthy)
+	require.Empty(t, changes)
+}
+
+func TestChecks(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, DiskFree(dir, 1).Run())
+	err := DiskFree(dir, math.MaxUint64).Run()
+	require.ErrorContains(t, err, "below")
+
+	require.NoError(t, FsyncLatency(dir, time.Minute, nil).Run())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	l, err := log.NewLog(dir, log.Config{})
+	require.NoError(t, err)
+	defer l.Close()
+	require.NoError(t, IndexConsistency(l).Run())
+	require.NoError(t, Writable(l).Run())
+
+	err = ReplicationLag(func() (uint64, error) { return 0, errors.New("leader unreachable") }, 0).Run()
+	require.EqualError(t, err, "leader unreachable")
+}
@@ -0,0 +1,204 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// GELF の分割されたメッセージ（チャンク）の定数
+const (
+	gelfChunkMagic0   = 0x1e
+	gelfChunkMagic1   = 0x0f
+	gelfChunkHeader   = 12              // マジック(2) + メッセージ ID(8) + 番号(1) + 数(1)
+	gelfMaxChunks     = 128             // 1つのメッセージの最大のチャンク数
+	gelfMaxPending    = 1024            // チャンクが揃うのを待つメッセージの最大数
+	gelfChunksTimeout = 5 * time.Second // すべてのチャンクが揃うのを待つ時間
+)
+
+// ListenGELF: GELF のメッセージを受信してレコードとして追加するリスナーを開始する
+// TCP では null バイト区切りの非圧縮のメッセージ、UDP では gzip・zlib で圧縮された（分割された）メッセージも受け付ける。
+// レコードの値は GELF の JSON そのもの（query の value.short_message などで絞り込める）で、
+// ヘッダーに host、level と、"_" で始まる追加フィールド（"_" を除いた名前）を記録する。
+// タイムスタンプはメッセージの timestamp（ない場合は受信時刻）。
+// 引数:
+//   - network: "tcp" または "udp"
+//   - addr: 受信するアドレス（例: ":12201"）
+//   - config: リスナーの設定
+//
+// 戻り値:
+//   - *Listener: 開始したリスナー（使い終わったら Close を呼び出す）
+//   - error: エラーが発生した場合
+func ListenGELF(network, addr string, config Config) (*Listener, error) {
+	return listen(network, addr, config, &gelfProtocol{
+		clock:  config.clock(),
+		max:    config.maxMessageBytes(),
+		chunks: make(map[string]*gelfChunks),
+	})
+}
+
+// gelfProtocol: GELF のメッセージの処理
+type gelfProtocol struct {
+	clock log.Clock
+	max   int
+
+	mu     sync.Mutex
+	chunks map[string]*gelfChunks // メッセージ ID ごとの受信中のチャンク
+}
+
+// gelfChunks: 受信中の分割されたメッセージ
+type gelfChunks struct {
+	parts    [][]byte
+	received int
+	bytes    int       // 受信したチャンクの合計バイト数
+	first    time.Time // 最初のチャンクを受信した時刻
+}
+
+// readFrame: null バイト区切りのメッセージを読み取る
+func (p *gelfProtocol) readFrame(r *bufio.Reader, max int) ([]byte, error) {
+	b, err := readDelimited(r, 0, max)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b, []byte{0}), nil
+}
+
+// datagram: チャンクを組み立て、圧縮されたメッセージを展開する
+func (p *gelfProtocol) datagram(b []byte) ([]byte, error) {
+	if len(b) >= 2 && b[0] == gelfChunkMagic0 && b[1] == gelfChunkMagic1 {
+		var err error
+		if b, err = p.chunk(b); b == nil || err != nil {
+			return nil, err
+		}
+	}
+	return p.decompress(b)
+}
+
+// chunk: チャンクを記録し、すべて揃った場合は組み立てたメッセージを返す（揃っていない場合は nil）
+func (p *gelfProtocol) chunk(b []byte) ([]byte, error) {
+	if len(b) < gelfChunkHeader {
+		return nil, errors.New("gelf: truncated chunk")
+	}
+	id, seq, count := string(b[2:10]), int(b[10]), int(b[11])
+	if count == 0 || count > gelfMaxChunks || seq >= count {
+		return nil, fmt.Errorf("gelf: invalid chunk %d of %d", seq, count)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.clock.Now()
+	// 期限内に揃わなかったメッセージを捨てる
+	for k, c := range p.chunks {
+		if now.Sub(c.first) >= gelfChunksTimeout {
+			delete(p.chunks, k)
+		}
+	}
+	c, ok := p.chunks[id]
+	if !ok {
+		// 揃わないメッセージを大量に送られてもメモリを使い切らないように、待つメッセージの数を制限する
+		if len(p.chunks) >= gelfMaxPending {
+			return nil, fmt.Errorf("gelf: too many incomplete chunked messages (%d)", len(p.chunks))
+		}
+		c = &gelfChunks{parts: make([][]byte, count), first: now}
+		p.chunks[id] = c
+	}
+	if len(c.parts) != count {
+		delete(p.chunks, id)
+		return nil, errors.New("gelf: inconsistent chunk count")
+	}
+	if c.parts[seq] == nil {
+		c.parts[seq] = b[gelfChunkHeader:]
+		c.received++
+		c.bytes += len(b) - gelfChunkHeader
+	}
+	if c.bytes > p.max {
+		delete(p.chunks, id)
+		return nil, fmt.Errorf("gelf: chunked message exceeds %d bytes", p.max)
+	}
+	if c.received < count {
+		return nil, nil
+	}
+	delete(p.chunks, id)
+	return bytes.Join(c.parts, nil), nil
+}
+
+// decompress: gzip または zlib で圧縮されたメッセージを展開する（圧縮されていない場合はそのまま返す）
+func (p *gelfProtocol) decompress(b []byte) ([]byte, error) {
+	var (
+		r   io.Reader
+		err error
+	)
+	switch {
+	case len(b) >= 2 && b[0] == 0x1f && b[1] == 0x8b:
+		r, err = gzip.NewReader(bytes.NewReader(b))
+	case len(b) >= 2 && b[0] == 0x78 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0:
+		r, err = zlib.NewReader(bytes.NewReader(b))
+	default:
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gelf: %w", err)
+	}
+	// 展開後の大きさも制限する（圧縮率の高いメッセージでメモリを使い切らないように）
+	out, err := io.ReadAll(io.LimitReader(r, int64(p.max)+1))
+	if err != nil {
+		return nil, fmt.Errorf("gelf: %w", err)
+	}
+	return out, nil
+}
+
+// parse: GELF の JSON を解析する
+func (p *gelfProtocol) parse(b []byte, now time.Time) (*api.Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var msg map[string]any
+	if err := dec.Decode(&msg); err != nil {
+		return nil, fmt.Errorf("gelf: %w", err)
+	}
+	host, _ := msg["host"].(string)
+	short, _ := msg["short_message"].(string)
+	if host == "" || short == "" {
+		return nil, errors.New("gelf: host and short_message are required")
+	}
+
+	record := &api.Record{
+		Value:     b,
+		Timestamp: now.UnixMilli(),
+		Headers:   map[string]string{"host": host},
+	}
+	if ts, ok := msg["timestamp"].(json.Number); ok {
+		sec, err := ts.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("gelf: invalid timestamp %q", ts)
+		}
+		record.Timestamp = int64(math.Round(sec * 1000))
+	}
+	if level, ok := msg["level"].(json.Number); ok {
+		record.Headers["level"] = level.String()
+	}
+	for k, v := range msg {
+		name, ok := strings.CutPrefix(k, "_")
+		if !ok || name == "" || name == "id" {
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			record.Headers[name] = v
+		case json.Number:
+			record.Headers[name] = v.String()
+		}
+	}
+	return record, nil
+}
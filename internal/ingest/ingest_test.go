@@ -0,0 +1,331 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/acl"
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/kentakki416/proglog/internal/redact"
+	"github.com/stretchr/testify/require"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// chanAppender: 追加されたレコードをチャネルに送るテスト用のログストア
+type chanAppender chan *api.Record
+
+func (a chanAppender) Append(record *api.Record) (uint64, error) {
+	a <- record
+	return 0, nil
+}
+
+func TestParseSyslog(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	for scenario, tc := range map[string]struct {
+		msg     string
+		value   string
+		ts      int64
+		headers map[string]string
+		err     bool
+	}{
+		"full message": {
+			msg:   `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventID="1011"] ` + "\xef\xbb\xbf" + `An application event`,
+			value: "An application event",
+			ts:    time.Date(2003, 10, 11, 22, 14, 15, 3e6, time.UTC).UnixMilli(),
+			headers: map[string]string{
+				"facility":        "20",
+				"severity":        "5",
+				"hostname":        "mymachine.example.com",
+				"app_name":        "evntslog",
+				"msgid":           "ID47",
+				"structured_data": `[exampleSDID@32473 iut="3" eventID="1011"]`,
+			},
+		},
+		"nil values and escaped structured data": {
+			msg:     `<34>1 - - su 42 - [a x="\]"][b] 'su root' failed`,
+			value:   "'su root' failed",
+			ts:      now.UnixMilli(),
+			headers: map[string]string{"facility": "4", "severity": "2", "app_name": "su", "procid": "42", "structured_data": `[a x="\]"][b]`},
+		},
+		"no message": {
+			msg:     `<13>1 - host app - - -`,
+			value:   "",
+			ts:      now.UnixMilli(),
+			headers: map[string]string{"facility": "1", "severity": "5", "hostname": "host", "app_name": "app"},
+		},
+		"bsd syslog is rejected":  {msg: `<13>Oct 11 22:14:15 host app: hi`, err: true},
+		"invalid priority":        {msg: `<200>1 - - - - - -`, err: true},
+		"unterminated structured": {msg: `<13>1 - - - - - [a x="]`, err: true},
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			record, err := syslogProtocol{}.parse([]byte(tc.msg), now)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.value, string(record.Value))
+			require.Equal(t, tc.ts, record.Timestamp)
+			require.Equal(t, tc.headers, record.Headers)
+		})
+	}
+}
+
+func TestFrameLimits(t *testing.T) {
+	for scenario, tc := range map[string]struct {
+		proto protocol
+		input string
+		frame string
+		err   error
+	}{
+		"syslog line within limit":      {proto: syslogProtocol{}, input: "<13>1 hi\n", frame: "<13>1 hi"},
+		"syslog line over limit":        {proto: syslogProtocol{}, input: "<13>1 - - - - - - too long\n", err: errFrameTooLarge},
+		"syslog unterminated line":      {proto: syslogProtocol{}, input: strings.Repeat("x", 64), err: errFrameTooLarge},
+		"syslog octet count over limit": {proto: syslogProtocol{}, input: "12345678 <13>1", err: errFrameTooLarge},
+		"gelf frame within limit":       {proto: &gelfProtocol{}, input: "{}\x00", frame: "{}"},
+		"gelf frame over limit":         {proto: &gelfProtocol{}, input: strings.Repeat("x", 64) + "\x00", err: errFrameTooLarge},
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			// バッファより大きいメッセージも、溜め込まずに上限で打ち切る
+			r := bufio.NewReaderSize(strings.NewReader(tc.input), 16)
+			b, err := tc.proto.readFrame(r, 16)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.frame, string(b))
+		})
+	}
+
+	// 揃わないチャンクのメッセージは数と大きさを制限する
+	p := &gelfProtocol{clock: log.NewFakeClock(time.Now()), max: 16, chunks: make(map[string]*gelfChunks)}
+	chunk := func(id int, data string) []byte {
+		return append([]byte{0x1e, 0x0f, 0, 0, 0, 0, 0, 0, byte(id >> 8), byte(id), 0, 2}, data...)
+	}
+	for id := 0; id < gelfMaxPending; id++ {
+		_, err := p.datagram(chunk(id, "x"))
+		require.NoError(t, err)
+	}
+	_, err := p.datagram(chunk(gelfMaxPending, "x"))
+	require.Error(t, err)
+	p.chunks = make(map[string]*gelfChunks)
+	_, err = p.datagram(chunk(0, strings.Repeat("x", 17)))
+	require.Error(t, err)
+	require.Empty(t, p.chunks)
+}
+
+func TestListeners(t *testing.T) {
+	records := make(chanAppender, 16)
+	config := Config{Appender: records, Clock: log.NewFakeClock(time.UnixMilli(1700000000000))}
+	recv := func() *api.Record {
+		select {
+		case record := <-records:
+			return record
+		case <-time.After(time.Second):
+			t.Fatal("no record received")
+			return nil
+		}
+	}
+
+	// ACL の内部のトピックには書き込めない
+	_, err := ListenSyslog("tcp", "127.0.0.1:0", Config{Appender: records, Topic: acl.DefaultTopic})
+	require.Error(t, err)
+	require.Error(t, RegisterOTLPLogs(grpc.NewServer(), Config{Appender: records, Topic: acl.DefaultTopic}))
+
+	// syslog over TCP: オクテットカウントと改行区切りが混在してもよい
+	l, err := ListenSyslog("tcp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	msg := "<13>1 - host app - - - first"
+	_, err = conn.Write([]byte("28 " + msg + "<13>1 - host app - - - second\n"))
+	require.NoError(t, err)
+	require.Equal(t, "first", string(recv().Value))
+	require.Equal(t, "second", string(recv().Value))
+	conn.Close()
+	require.NoError(t, l.Close())
+
+	// syslog over UDP
+	l, err = ListenSyslog("udp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	conn, err = net.Dial("udp", l.Addr().String())
+	require.NoError(t, err)
+	_, err = conn.Write([]byte(msg))
+	require.NoError(t, err)
+	require.Equal(t, "host", recv().Headers["hostname"])
+	conn.Close()
+	require.NoError(t, l.Close())
+
+	// GELF over UDP: zlib で圧縮し、2つのチャンクに分割したメッセージ
+	gelf := `{"version":"1.1","host":"web-1","short_message":"boom","timestamp":1700000000.5,"level":3,"_user_id":42,"_id":"x"}`
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte(gelf))
+	zw.Close()
+	payload := buf.Bytes()
+	chunk := func(seq int, data []byte) []byte {
+		return append([]byte{0x1e, 0x0f, 1, 2, 3, 4, 5, 6, 7, 8, byte(seq), 2}, data...)
+	}
+	l, err = ListenGELF("udp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	conn, err = net.Dial("udp", l.Addr().String())
+	require.NoError(t, err)
+	half := len(payload) / 2
+	_, err = conn.Write(chunk(1, payload[half:]))
+	require.NoError(t, err)
+	_, err = conn.Write(chunk(0, payload[:half]))
+	require.NoError(t, err)
+	record := recv()
+	require.Equal(t, gelf, string(record.Value))
+	require.Equal(t, int64(1700000000500), record.Timestamp)
+	require.Equal(t, map[string]string{"host": "web-1", "level": "3", "user_id": "42"}, record.Headers)
+	conn.Close()
+	require.NoError(t, l.Close())
+
+	// GELF over TCP: null バイト区切り
+	l, err = ListenGELF("tcp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	conn, err = net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	_, err = conn.Write([]byte(`{"version":"1.1","host":"a","short_message":"b"}` + "\x00"))
+	require.NoError(t, err)
+	require.Equal(t, int64(1700000000000), recv().Timestamp)
+	// 接続したまま閉じても待ち続けない
+	require.NoError(t, l.Close())
+	conn.Close()
+}
+
+func TestOTLPLogs(t *testing.T) {
+	records := make(chanAppender, 16)
+	srv := grpc.NewServer()
+	require.NoError(t, RegisterOTLPLogs(srv, Config{Appender: records, Clock: log.NewFakeClock(time.UnixMilli(1700000000000))}))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	cc, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer cc.Close()
+
+	str := func(s string) *commonv1.AnyValue {
+		return &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: s}}
+	}
+	req := &logsv1.LogsData{ResourceLogs: []*logsv1.ResourceLogs{{
+		Resource: &resourcev1.Resource{Attributes: []*commonv1.KeyValue{{Key: "service.name", Value: str("checkout")}}},
+		ScopeLogs: []*logsv1.ScopeLogs{{
+			Scope: &commonv1.InstrumentationScope{Name: "app"},
+			LogRecords: []*logsv1.LogRecord{
+				{
+					TimeUnixNano:   1700000001000 * 1e6,
+					SeverityText:   "ERROR",
+					SeverityNumber: logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR,
+					Body:           str("payment failed"),
+					Attributes: []*commonv1.KeyValue{
+						{Key: "retries", Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: 3}}},
+					},
+					TraceId: []byte{0xab, 0xcd},
+				},
+				{
+					Body: &commonv1.AnyValue{Value: &commonv1.AnyValue_KvlistValue{KvlistValue: &commonv1.KeyValueList{
+						Values: []*commonv1.KeyValue{{Key: "order", Value: str("o-1")}},
+					}}},
+				},
+			},
+		}},
+	}}}
+	err = cc.Invoke(context.Background(), "/opentelemetry.proto.collector.logs.v1.LogsService/Export", req, &emptypb.Empty{})
+	require.NoError(t, err)
+
+	record := <-records
+	require.Equal(t, "payment failed", string(record.Value))
+	require.Equal(t, int64(1700000001000), record.Timestamp)
+	require.Equal(t, map[string]string{
+		"service.name":    "checkout",
+		"attr.retries":    "3",
+		"severity":        "ERROR",
+		"severity_number": "17",
+		"scope":           "app",
+		"trace_id":        "abcd",
+	}, record.Headers)
+
+	record = <-records
+	require.Equal(t, `{"order":"o-1"}`, string(record.Value))
+	require.Equal(t, int64(1700000000000), record.Timestamp)
+}
+
+// authorizerFunc: 関数で判定するテスト用の Authorizer
+type authorizerFunc func(subject, object, action string) error
+
+func (f authorizerFunc) Authorize(subject, object, action string) error {
+	return f(subject, object, action)
+}
+
+func TestOTLPLogsErrors(t *testing.T) {
+	// 再送しても成功しないエラーは InvalidArgument、それ以外は再送できる Unavailable
+	require.Equal(t, codes.InvalidArgument, status.Code(otlpError(fmt.Errorf("append: %w", log.ErrRecordTooLarge))))
+	require.Equal(t, codes.InvalidArgument, status.Code(otlpError(log.ErrEmptyBatch)))
+	require.Equal(t, codes.Unavailable, status.Code(otlpError(errors.New("disk full"))))
+
+	// 追加先のトピックへの書き込みを認可する
+	records := make(chanAppender, 16)
+	var object, action string
+	deny := authorizerFunc(func(s, o, a string) error {
+		object, action = o, a
+		return errors.New("denied")
+	})
+	srv := grpc.NewServer()
+	require.NoError(t, RegisterOTLPLogs(srv, Config{Appender: records, Topic: "orders", Authorizer: deny}))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	cc, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer cc.Close()
+
+	req := &logsv1.LogsData{ResourceLogs: []*logsv1.ResourceLogs{{
+		ScopeLogs: []*logsv1.ScopeLogs{{LogRecords: []*logsv1.LogRecord{{}}}},
+	}}}
+	err = cc.Invoke(context.Background(), "/opentelemetry.proto.collector.logs.v1.LogsService/Export", req, &emptypb.Empty{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Equal(t, "orders", object)
+	require.Equal(t, "produce", action)
+	require.Empty(t, records)
+}
+
+func TestRedaction(t *testing.T) {
+	redactor, err := redact.New([]redact.Rule{{Field: "value", Pattern: `\d{4}-\d{4}`, Replace: "****"}})
+	require.NoError(t, err)
+	records := make(chanAppender, 16)
+	config := Config{Appender: records, Redactor: redactor, Clock: log.NewFakeClock(time.UnixMilli(1700000000000))}
+	l, err := ListenSyslog("udp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	defer l.Close()
+
+	// リスナーで受け取ったメッセージも、追加する前にマスキングされる
+	conn, err := net.Dial("udp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("<34>1 2023-11-14T22:13:20Z host app - - - card 1234-5678"))
+	require.NoError(t, err)
+	require.Equal(t, "card ****", string((<-records).Value))
+}
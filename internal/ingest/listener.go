@@ -0,0 +1,283 @@
+package ingest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/acl"
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/kentakki416/proglog/internal/redact"
+)
+
+// Appender: 受け取ったメッセージをレコードとして追加するログストア（例: log.Log、トピックのログ）
+type Appender interface {
+	Append(*api.Record) (uint64, error)
+}
+
+// Authorizer: サブジェクトがオブジェクトに対するアクションを許可されているかを判定する（server.Authorizer と同じ）
+type Authorizer interface {
+	Authorize(subject, object, action string) error
+}
+
+// Config: リスナーの設定
+type Config struct {
+	// レコードの追加先（トピックに書き込む場合は log.Topics.Get で取得したログ）
+	Appender Appender
+	// 追加先のトピック名（デフォルトのログストアの場合は空）
+	// ACL のルールを記録する内部のトピック（acl.DefaultTopic）は acl.Policy だけが書き込めるように予約されているため指定できない。
+	Topic string
+	// タイムスタンプを含まないメッセージの受信時刻に使用する時計（nil の場合は log.SystemClock）
+	Clock log.Clock
+	// OTLP の Export で、クライアントのトピックへの書き込み（"produce"）を認可する（nil の場合は認可しない）
+	// サブジェクトは TLS のクライアント証明書のコモンネーム、オブジェクトは Topic（空の場合は "*"）。
+	// syslog と GELF のリスナーは接続を認証しないため使用しない。
+	Authorizer Authorizer
+	// 追加する前にレコードの個人情報などをマスキングする（nil の場合はマスキングしない）
+	// サーバーの Config.ProduceRedactor と同じものを指定すると、gRPC の書き込みと同じルールでマスキングされる。
+	Redactor *redact.Redactor
+	// 1つのメッセージの最大バイト数（0 の場合は 64KB）
+	MaxMessageBytes int
+	// 解析できないメッセージや追加に失敗したメッセージのエラーを受け取るコールバック（nil の場合は捨てる）
+	// 1つのメッセージのエラーで受信は止めない。
+	OnError func(error)
+}
+
+// clock: 設定された時計を返す（設定されていない場合は log.SystemClock）
+func (c Config) clock() log.Clock {
+	if c.Clock == nil {
+		return log.SystemClock
+	}
+	return c.Clock
+}
+
+// maxMessageBytes: 1つのメッセージの最大バイト数を返す
+func (c Config) maxMessageBytes() int {
+	if c.MaxMessageBytes == 0 {
+		return 64 * 1024
+	}
+	return c.MaxMessageBytes
+}
+
+// append: レコードをマスキングしてから追加する（内部関数）
+// すべてのリスナーと OTLP の Export はこれで追加する。Appender が batchAppender も実装している場合は、
+// レコードをすべて追加するか、まったく追加しないかのどちらかになる。
+func (c Config) append(records ...*api.Record) error {
+	if c.Redactor != nil {
+		for _, record := range records {
+			c.Redactor.Apply(record)
+		}
+	}
+	if ba, ok := c.Appender.(batchAppender); ok && len(records) > 1 {
+		_, err := ba.AppendBatch(records)
+		return err
+	}
+	for _, record := range records {
+		if _, err := c.Appender.Append(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate: 設定を検証する（内部関数）
+func (c Config) validate() error {
+	if c.Appender == nil {
+		return errors.New("ingest: appender is required")
+	}
+	if c.Topic == acl.DefaultTopic {
+		return fmt.Errorf("ingest: topic %q is reserved", c.Topic)
+	}
+	return nil
+}
+
+// errFrameTooLarge: 区切り文字までのメッセージが最大バイト数を超えた場合のエラー
+var errFrameTooLarge = errors.New("ingest: frame exceeds the message size limit")
+
+// readDelimited: 区切り文字までのメッセージを、最大 max バイト（区切り文字を除く）まで読み取る（内部関数）
+// 区切り文字が来ないまま max バイトを超えた場合は、それ以上バッファに溜めずに errFrameTooLarge を返す
+// （区切り文字を送らない接続でメモリを使い切らないように）。ストリームの途中から再開できないため、呼び出し側は接続を閉じる。
+// 戻り値:
+//   - []byte: 区切り文字を含むメッセージ（ストリームの終端に達した場合は区切り文字を含まない）
+//   - error: 読み取れない場合、または max バイトを超えた場合
+func readDelimited(r *bufio.Reader, delim byte, max int) ([]byte, error) {
+	var frame []byte
+	for {
+		b, err := r.ReadSlice(delim)
+		if len(frame)+len(b) > max+1 || (err != nil && len(frame)+len(b) > max) {
+			return nil, errFrameTooLarge
+		}
+		frame = append(frame, b...)
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && len(frame) > 0:
+			return frame, nil
+		case err != nil:
+			return nil, err
+		}
+		return frame, nil
+	}
+}
+
+// protocol: メッセージの形式ごとの処理
+type protocol interface {
+	// readFrame: ストリーム（TCP）から1つのメッセージを読み取る
+	readFrame(r *bufio.Reader, max int) ([]byte, error)
+	// datagram: データグラム（UDP）を1つのメッセージに変換する（分割されたメッセージの途中の場合は nil）
+	datagram(b []byte) ([]byte, error)
+	// parse: メッセージをレコードに変換する
+	parse(b []byte, now time.Time) (*api.Record, error)
+}
+
+// Listener: メッセージを受信してレコードとして追加するリスナー
+type Listener struct {
+	config Config
+	proto  protocol
+
+	ln    net.Listener   // TCP の場合
+	pc    net.PacketConn // UDP の場合
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// listen: 指定したネットワークとアドレスで受信を開始する（内部関数）
+func listen(network, addr string, config Config, proto protocol) (*Listener, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	l := &Listener{config: config, proto: proto, conns: make(map[net.Conn]struct{})}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		ln, err := net.Listen(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		l.ln = ln
+		l.wg.Add(1)
+		go l.accept()
+	case "udp", "udp4", "udp6":
+		pc, err := net.ListenPacket(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		l.pc = pc
+		l.wg.Add(1)
+		go l.readPackets()
+	default:
+		return nil, fmt.Errorf("ingest: unsupported network %q", network)
+	}
+	return l, nil
+}
+
+// Addr: 受信しているアドレスを返す
+func (l *Listener) Addr() net.Addr {
+	if l.ln != nil {
+		return l.ln.Addr()
+	}
+	return l.pc.LocalAddr()
+}
+
+// Close: 受信を停止し、すべての接続を閉じる
+func (l *Listener) Close() error {
+	var err error
+	if l.ln != nil {
+		err = l.ln.Close()
+	} else {
+		err = l.pc.Close()
+	}
+	l.mu.Lock()
+	for conn := range l.conns {
+		conn.Close()
+	}
+	l.mu.Unlock()
+	l.wg.Wait()
+	return err
+}
+
+// accept: TCP の接続を受け付ける
+func (l *Listener) accept() {
+	defer l.wg.Done()
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			return
+		}
+		l.mu.Lock()
+		l.conns[conn] = struct{}{}
+		l.mu.Unlock()
+		l.wg.Add(1)
+		go l.serveConn(conn)
+	}
+}
+
+// serveConn: TCP の接続からメッセージを読み取る
+func (l *Listener) serveConn(conn net.Conn) {
+	defer l.wg.Done()
+	defer func() {
+		l.mu.Lock()
+		delete(l.conns, conn)
+		l.mu.Unlock()
+		conn.Close()
+	}()
+	r := bufio.NewReader(conn)
+	for {
+		b, err := l.proto.readFrame(r, l.config.maxMessageBytes())
+		if err != nil {
+			if err != io.EOF && !errors.Is(err, net.ErrClosed) {
+				l.error(err)
+			}
+			return
+		}
+		l.handle(b)
+	}
+}
+
+// readPackets: UDP のデータグラムからメッセージを読み取る
+func (l *Listener) readPackets() {
+	defer l.wg.Done()
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := l.pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		b, err := l.proto.datagram(append([]byte(nil), buf[:n]...))
+		if err != nil {
+			l.error(err)
+			continue
+		}
+		if b != nil {
+			l.handle(b)
+		}
+	}
+}
+
+// handle: メッセージをレコードに変換して追加する
+func (l *Listener) handle(b []byte) {
+	if len(b) > l.config.maxMessageBytes() {
+		l.error(fmt.Errorf("ingest: message of %d bytes exceeds limit", len(b)))
+		return
+	}
+	record, err := l.proto.parse(b, l.config.clock().Now())
+	if err != nil {
+		l.error(err)
+		return
+	}
+	if err := l.config.append(record); err != nil {
+		l.error(err)
+	}
+}
+
+// error: エラーをコールバックに渡す
+func (l *Listener) error(err error) {
+	if l.config.OnError != nil {
+		l.config.OnError(err)
+	}
+}
@@ -0,0 +1,258 @@
+package ingest
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// batchAppender: 複数のレコードを1つのバッチとして追加できるログストア（例: log.Log）
+// Appender がこのインターフェースも実装している場合、OTLP の1つのリクエストのレコードはすべて追加されるか
+// まったく追加されないかのどちらかになり、クライアントが再送してもレコードが重複しない。
+type batchAppender interface {
+	AppendBatch([]*api.Record) (uint64, error)
+}
+
+// otlpLogsServer: OTLP の LogsService の実装
+type otlpLogsServer struct {
+	config Config
+}
+
+// RegisterOTLPLogs: OpenTelemetry の Logs（OTLP/gRPC）を受け付けるサービスを gRPC サーバーに登録する
+// 受け取った LogRecord を1つずつレコードとして追加し、OTel Collector などのエクスポーターの送信先として使用できる。
+// レコードの値は LogRecord の body（文字列とバイト列はそのまま、それ以外は JSON）で、ヘッダーには
+// リソースの属性（キーはそのまま）、LogRecord の属性（"attr." を付けたキー）、severity、severity_number、
+// scope、trace_id、span_id を記録する。タイムスタンプは time_unix_nano（ない場合は observed_time_unix_nano、
+// どちらもない場合は受信時刻）。
+// 引数:
+//   - s: 登録先の gRPC サーバー
+//   - config: レコードの追加先などの設定（MaxMessageBytes と OnError は使用しない）
+//
+// 戻り値:
+//   - error: 設定が不正な場合（追加先がない、予約されたトピックを指定したなど）
+func RegisterOTLPLogs(s *grpc.Server, config Config) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+	s.RegisterService(&otlpLogsServiceDesc, &otlpLogsServer{config: config})
+	return nil
+}
+
+// otlpLogsServiceDesc: opentelemetry.proto.collector.logs.v1.LogsService の定義
+// collector のパッケージは grpc-gateway に依存するため、Export の定義だけをここで持つ。
+// ExportLogsServiceRequest は LogsData と、ExportLogsServiceResponse（部分的な成功を返さない場合）は
+// 空のメッセージとワイヤー形式が同じため、それぞれのメッセージ型で読み書きする。
+var otlpLogsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "opentelemetry.proto.collector.logs.v1.LogsService",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Export",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(logsv1.LogsData)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			export := func(ctx context.Context, req any) (any, error) {
+				return srv.(*otlpLogsServer).Export(ctx, req.(*logsv1.LogsData))
+			}
+			if interceptor == nil {
+				return export(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: "/opentelemetry.proto.collector.logs.v1.LogsService/Export",
+			}
+			return interceptor(ctx, req, info, export)
+		},
+	}},
+	Metadata: "opentelemetry/proto/collector/logs/v1/logs_service.proto",
+}
+
+// Export: 受け取った LogRecord をレコードとして追加する
+// Config.Authorizer が設定されている場合は、追加先のトピックへの書き込みを認可してから追加する。
+// 追加に失敗した場合は、クライアントが再送するように codes.Unavailable を返す
+// （レコードが大きすぎるなど、再送しても成功しないリクエストは codes.InvalidArgument）。
+func (s *otlpLogsServer) Export(ctx context.Context, req *logsv1.LogsData) (*emptypb.Empty, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	now := s.config.clock().Now()
+	var records []*api.Record
+	for _, rl := range req.ResourceLogs {
+		resource := make(map[string]string)
+		for _, kv := range rl.GetResource().GetAttributes() {
+			resource[kv.Key] = attributeString(kv.Value)
+		}
+		for _, sl := range rl.ScopeLogs {
+			for _, lr := range sl.LogRecords {
+				records = append(records, logRecord(lr, sl.GetScope(), resource, now.UnixMilli()))
+			}
+		}
+	}
+	if len(records) == 0 {
+		return &emptypb.Empty{}, nil
+	}
+
+	if err := s.config.append(records...); err != nil {
+		return nil, otlpError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// OTLP の Export の認可で使用するオブジェクトとアクション（サーバーの ACL と同じ）
+const (
+	otlpObject = "*"       // ログ全体（トピックを指定しない場合）を表すオブジェクト
+	otlpAction = "produce" // レコードの書き込み
+)
+
+// authorize: クライアントが追加先のトピックに書き込めるかを判定する（内部関数）
+// 戻り値:
+//   - error: 許可されていない場合は codes.PermissionDenied（Authorizer が gRPC のステータスを返した場合はそのまま）
+func (s *otlpLogsServer) authorize(ctx context.Context) error {
+	if s.config.Authorizer == nil {
+		return nil
+	}
+	object := s.config.Topic
+	if object == "" {
+		object = otlpObject
+	}
+	err := s.config.Authorizer.Authorize(peerSubject(ctx), object, otlpAction)
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.PermissionDenied, err.Error())
+}
+
+// peerSubject: クライアント証明書のコモンネームを返す（TLS を使用していない場合は空文字）
+func peerSubject(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName
+}
+
+// logRecord: LogRecord をレコードに変換する
+func logRecord(lr *logsv1.LogRecord, scope *commonv1.InstrumentationScope, resource map[string]string, now int64) *api.Record {
+	headers := make(map[string]string, len(resource)+len(lr.Attributes)+5)
+	for k, v := range resource {
+		headers[k] = v
+	}
+	for _, kv := range lr.Attributes {
+		headers["attr."+kv.Key] = attributeString(kv.Value)
+	}
+	if lr.SeverityText != "" {
+		headers["severity"] = lr.SeverityText
+	}
+	if lr.SeverityNumber != logsv1.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED {
+		headers["severity_number"] = strconv.Itoa(int(lr.SeverityNumber))
+	}
+	if name := scope.GetName(); name != "" {
+		headers["scope"] = name
+	}
+	if len(lr.TraceId) > 0 {
+		headers["trace_id"] = hex.EncodeToString(lr.TraceId)
+	}
+	if len(lr.SpanId) > 0 {
+		headers["span_id"] = hex.EncodeToString(lr.SpanId)
+	}
+
+	ts := now
+	if lr.TimeUnixNano != 0 {
+		ts = int64(lr.TimeUnixNano / 1e6)
+	} else if lr.ObservedTimeUnixNano != 0 {
+		ts = int64(lr.ObservedTimeUnixNano / 1e6)
+	}
+
+	var value []byte
+	switch body := lr.Body.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		value = []byte(body.StringValue)
+	case *commonv1.AnyValue_BytesValue:
+		value = body.BytesValue
+	case nil:
+	default:
+		value, _ = json.Marshal(anyValue(lr.Body))
+	}
+	return &api.Record{Value: value, Timestamp: ts, Headers: headers}
+}
+
+// attributeString: 属性の値をヘッダーの文字列に変換する（配列と key-value のリストは JSON）
+func attributeString(v *commonv1.AnyValue) string {
+	switch v := v.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		return v.StringValue
+	case *commonv1.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+	case *commonv1.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *commonv1.AnyValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'g', -1, 64)
+	case nil:
+		return ""
+	}
+	b, _ := json.Marshal(anyValue(v))
+	return string(b)
+}
+
+// anyValue: AnyValue を JSON に変換できる値にする（バイト列は base64 の文字列になる）
+func anyValue(v *commonv1.AnyValue) any {
+	switch v := v.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		return v.StringValue
+	case *commonv1.AnyValue_BoolValue:
+		return v.BoolValue
+	case *commonv1.AnyValue_IntValue:
+		return v.IntValue
+	case *commonv1.AnyValue_DoubleValue:
+		return v.DoubleValue
+	case *commonv1.AnyValue_BytesValue:
+		return v.BytesValue
+	case *commonv1.AnyValue_ArrayValue:
+		values := make([]any, len(v.ArrayValue.Values))
+		for i, e := range v.ArrayValue.Values {
+			values[i] = anyValue(e)
+		}
+		return values
+	case *commonv1.AnyValue_KvlistValue:
+		m := make(map[string]any, len(v.KvlistValue.Values))
+		for _, kv := range v.KvlistValue.Values {
+			m[kv.Key] = anyValue(kv.Value)
+		}
+		return m
+	}
+	return nil
+}
+
+// otlpError: 追加のエラーを gRPC のステータスに変換する
+// 再送しても成功しないエラー（レコードが大きすぎる、空のバッチ）は codes.InvalidArgument にし、
+// それ以外のステータスを持たないエラー（ディスクの書き込みの失敗など）は、再送できるように codes.Unavailable にする。
+func otlpError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, log.ErrRecordTooLarge) || errors.Is(err, log.ErrEmptyBatch) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
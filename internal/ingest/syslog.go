@@ -0,0 +1,169 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// ListenSyslog: syslog（RFC 5424）のメッセージを受信してレコードとして追加するリスナーを開始する
+// TCP では RFC 6587 のオクテットカウント（"長さ SP メッセージ"）と改行区切りの両方を受け付け、
+// UDP では1つのデータグラムを1つのメッセージとして扱う。
+// レコードの値は MSG で、ヘッダーに facility、severity と、"-" 以外の hostname、app_name、procid、msgid、
+// structured_data（STRUCTURED-DATA の文字列）を記録する。タイムスタンプはメッセージの TIMESTAMP
+// （"-" の場合は受信時刻）。
+// 引数:
+//   - network: "tcp" または "udp"
+//   - addr: 受信するアドレス（例: ":6514"）
+//   - config: リスナーの設定
+//
+// 戻り値:
+//   - *Listener: 開始したリスナー（使い終わったら Close を呼び出す）
+//   - error: エラーが発生した場合
+func ListenSyslog(network, addr string, config Config) (*Listener, error) {
+	return listen(network, addr, config, syslogProtocol{})
+}
+
+// syslogProtocol: syslog（RFC 5424）のメッセージの処理
+type syslogProtocol struct{}
+
+// readFrame: オクテットカウントまたは改行区切りのメッセージを読み取る
+func (syslogProtocol) readFrame(r *bufio.Reader, max int) ([]byte, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if first[0] < '0' || first[0] > '9' {
+		// 改行区切り
+		line, err := readDelimited(r, '\n', max)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.TrimRight(line, "\r\n"), nil
+	}
+	// オクテットカウント（長さは max の桁数まで）
+	prefix, err := readDelimited(r, ' ', len(strconv.Itoa(max)))
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(string(bytes.TrimSuffix(prefix, []byte{' '})))
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("syslog: invalid frame length %q", prefix)
+	}
+	if n > max {
+		return nil, fmt.Errorf("syslog: frame of %d bytes exceeds limit", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// datagram: UDP のデータグラムはそのまま1つのメッセージになる
+func (syslogProtocol) datagram(b []byte) ([]byte, error) {
+	return bytes.TrimRight(b, "\r\n"), nil
+}
+
+// parse: RFC 5424 のメッセージを解析する
+// 形式: <PRI>VERSION SP TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID SP STRUCTURED-DATA [SP MSG]
+func (syslogProtocol) parse(b []byte, now time.Time) (*api.Record, error) {
+	if len(b) == 0 || b[0] != '<' {
+		return nil, errors.New("syslog: missing priority")
+	}
+	end := bytes.IndexByte(b, '>')
+	if end < 2 || end > 4 {
+		return nil, errors.New("syslog: invalid priority")
+	}
+	pri, err := strconv.Atoi(string(b[1:end]))
+	if err != nil || pri > 191 {
+		return nil, fmt.Errorf("syslog: invalid priority %q", b[1:end])
+	}
+	rest := b[end+1:]
+
+	// VERSION、TIMESTAMP、HOSTNAME、APP-NAME、PROCID、MSGID
+	var fields [6]string
+	for i := range fields {
+		sp := bytes.IndexByte(rest, ' ')
+		if sp <= 0 {
+			return nil, errors.New("syslog: truncated header")
+		}
+		fields[i], rest = string(rest[:sp]), rest[sp+1:]
+	}
+	if fields[0] != "1" {
+		return nil, fmt.Errorf("syslog: unsupported version %q", fields[0])
+	}
+
+	sd, rest, err := splitStructuredData(rest)
+	if err != nil {
+		return nil, err
+	}
+	msg := bytes.TrimPrefix(bytes.TrimPrefix(rest, []byte(" ")), []byte("\xef\xbb\xbf"))
+
+	record := &api.Record{
+		Value:     msg,
+		Timestamp: now.UnixMilli(),
+		Headers: map[string]string{
+			"facility": strconv.Itoa(pri / 8),
+			"severity": strconv.Itoa(pri % 8),
+		},
+	}
+	if fields[1] != "-" {
+		ts, err := time.Parse(time.RFC3339Nano, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("syslog: invalid timestamp %q", fields[1])
+		}
+		record.Timestamp = ts.UnixMilli()
+	}
+	for i, name := range []string{"hostname", "app_name", "procid", "msgid"} {
+		if v := fields[i+2]; v != "-" {
+			record.Headers[name] = v
+		}
+	}
+	if sd != "-" {
+		record.Headers["structured_data"] = sd
+	}
+	return record, nil
+}
+
+// splitStructuredData: STRUCTURED-DATA（"-" または1つ以上の "[...]"）と残りを分ける
+// パラメーターの値は引用符で囲まれ、その中では \" \\ \] がエスケープされる。
+func splitStructuredData(b []byte) (string, []byte, error) {
+	if len(b) == 0 {
+		return "", nil, errors.New("syslog: missing structured data")
+	}
+	if b[0] == '-' {
+		return "-", b[1:], nil
+	}
+	i := 0
+	for i < len(b) && b[i] == '[' {
+		quoted := false
+		for i++; ; i++ {
+			if i >= len(b) {
+				return "", nil, errors.New("syslog: unterminated structured data")
+			}
+			c := b[i]
+			if quoted && c == '\\' {
+				i++
+				continue
+			}
+			if c == '"' {
+				quoted = !quoted
+			}
+			if c == ']' && !quoted {
+				i++
+				break
+			}
+		}
+	}
+	if i == 0 {
+		return "", nil, errors.New("syslog: invalid structured data")
+	}
+	return string(b[:i]), b[i:], nil
+}
@@ -0,0 +1,122 @@
+package log
+
+import (
+	"errors"
+	"hash/crc32"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// バッチの形式（ストアの1つのエントリ [長さ(8バイト)][データ] のデータ部分）:
+//
+//	[batchMagic(1バイト)][先頭のオフセット(8バイト)][レコード数(4バイト)][CRC32C(4バイト)]
+//	[長さ(4バイト)][レコード] をレコードの数だけ繰り返す
+//
+// 複数のレコードを1回の書き込みで保存し、レコードごとの長さ情報を 8 バイトから 4 バイトに減らす。
+// チェックサムはバッチ全体のレコード部分に対して1つだけ計算する。
+// インデックスにはレコードごとにエントリを記録し、バッチ内のすべてのレコードがバッチの位置を指す。
+// Protocol Buffers のメッセージはフィールド番号 0 から始まることがないため、先頭のバイトが
+// batchMagic（0x00）のデータは単一のレコードと区別できる。
+const (
+	batchMagic       byte = 0x00
+	batchHeaderWidth      = 1 + 8 + 4 + crcWidth
+	batchLenWidth         = 4
+)
+
+// ErrEmptyBatch: レコードを含まないバッチを追加しようとした場合のエラー
+var ErrEmptyBatch = errors.New("empty batch")
+
+// isBatch: ストアのエントリのデータがバッチかどうかを判定する
+func isBatch(p []byte) bool {
+	return len(p) > 0 && p[0] == batchMagic
+}
+
+// encodeBatch: シリアライズ済みのレコードをバッチに変換する
+// 引数:
+//   - base: バッチの先頭のレコードのオフセット
+//   - records: Protocol Buffers 形式にシリアライズしたレコード
+//
+// 戻り値:
+//   - []byte: ストアに保存するバッチのデータ
+func encodeBatch(base uint64, records [][]byte) []byte {
+	size := batchHeaderWidth
+	for _, r := range records {
+		size += batchLenWidth + len(r)
+	}
+	p := make([]byte, batchHeaderWidth, size)
+	p[0] = batchMagic
+	enc.PutUint64(p[1:], base)
+	enc.PutUint32(p[9:], uint32(len(records)))
+	for _, r := range records {
+		p = enc.AppendUint32(p, uint32(len(r)))
+		p = append(p, r...)
+	}
+	enc.PutUint32(p[13:], crc32.Checksum(p[batchHeaderWidth:], castagnoli))
+	return p
+}
+
+// decodeBatch: バッチのチェックサムを検証し、レコードに分割する
+// 引数:
+//   - p: ストアから読み取ったバッチのデータ
+//
+// 戻り値:
+//   - uint64: バッチの先頭のレコードのオフセット
+//   - [][]byte: シリアライズされたレコード（p を参照する）
+//   - error: バッチが壊れている場合（ErrCorruptRecord）
+func decodeBatch(p []byte) (uint64, [][]byte, error) {
+	if len(p) < batchHeaderWidth || p[0] != batchMagic {
+		return 0, nil, ErrCorruptRecord
+	}
+	base := enc.Uint64(p[1:])
+	count := enc.Uint32(p[9:])
+	body := p[batchHeaderWidth:]
+	if crc32.Checksum(body, castagnoli) != enc.Uint32(p[13:]) {
+		return 0, nil, ErrCorruptRecord
+	}
+	// レコード数が壊れていても巨大なスライスを確保しないように、データの長さで上限を設ける
+	if uint64(count) > uint64(len(body))/batchLenWidth {
+		return 0, nil, ErrCorruptRecord
+	}
+	records := make([][]byte, 0, count)
+	for i := uint32(0); i < count; i++ {
+		if len(body) < batchLenWidth {
+			return 0, nil, ErrCorruptRecord
+		}
+		n := uint64(enc.Uint32(body))
+		body = body[batchLenWidth:]
+		if n > uint64(len(body)) {
+			return 0, nil, ErrCorruptRecord
+		}
+		records = append(records, body[:n])
+		body = body[n:]
+	}
+	if len(body) != 0 {
+		return 0, nil, ErrCorruptRecord
+	}
+	return base, records, nil
+}
+
+// unmarshalRecord: ストアのエントリのデータから、指定されたオフセットのレコードを取り出す
+// 引数:
+//   - p: ストアから読み取ったデータ（単一のレコードまたはバッチ）
+//   - off: 取り出すレコードのオフセット
+//
+// 戻り値:
+//   - *api.Record: 取り出したレコード
+//   - error: エラーが発生した場合
+func unmarshalRecord(p []byte, off uint64) (*api.Record, error) {
+	if isBatch(p) {
+		base, records, err := decodeBatch(p)
+		if err != nil {
+			return nil, err
+		}
+		if off < base || off-base >= uint64(len(records)) {
+			return nil, ErrCorruptRecord
+		}
+		p = records[off-base]
+	}
+	record := &api.Record{}
+	err := proto.Unmarshal(p, record)
+	return record, err
+}
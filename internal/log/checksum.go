@@ -0,0 +1,164 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// チェックサム付きストリームの形式:
+//
+//	フレーム: [長さ(8バイト)][CRC32C(4バイト)][データ] をレコードの数だけ繰り返す
+//	トレーラー: [trailerMarker(8バイト)][それまでのすべてのフレームの SHA-256(32バイト)]
+//
+// レコードごとの CRC32C で破損したレコードを検出し、トレーラーのダイジェストで
+// レコードの欠落や途中での切断（トレーラーがない）を検出する。
+const (
+	crcWidth      = 4
+	trailerMarker = math.MaxUint64 // トレーラーを表す長さフィールドの値
+)
+
+// castagnoli: レコードのチェックサムに使用する CRC32 テーブル
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrCorruptStream: チェックサム付きストリームの検証に失敗した場合のエラー
+var ErrCorruptStream = errors.New("corrupt log stream")
+
+// ChecksumReader: レコードごとのチェックサムとトレーラーのダイジェストを付けたストリームを返す
+// スナップショットや複製などでログ全体を転送する際に、転送中の破損を検出するために使用する。
+// 受信側は VerifyStream で検証しながらレコードを読み取る。
+// Reader と同様に、途中で読み取りをやめる場合は Close を呼び出す必要がある。
+// 戻り値:
+//   - io.ReadCloser: チェックサム付きのストリーム
+func (l *Log) ChecksumReader() io.ReadCloser {
+	r := l.Reader()
+	return &checksumReader{
+		Closer: r,
+		src:    bufio.NewReader(r),
+		hash:   sha256.New(),
+		max:    l.Config.Segment.MaxRecordBytes,
+		format: l.Config.Segment.Format,
+	}
+}
+
+// checksumReader: ストアの内容（[長さ][データ] の繰り返し）をチェックサム付きのフレームに変換する Reader
+// フレームの長さは、ストアの保存形式にかかわらず 8 バイトで出力する。
+type checksumReader struct {
+	io.Closer               // ストアの Reader（セグメントの参照を外す）
+	src       *bufio.Reader // ストアの内容
+	buf       bytes.Buffer  // まだ読み取られていないフレーム
+	hash      hash.Hash     // これまでに出力したフレームのダイジェスト
+	done      bool          // トレーラーを出力済みかどうか
+	max       uint64        // 1レコードの最大バイト数（0 の場合は制限なし）
+	format    int           // ストアの保存形式
+}
+
+// Read: チェックサム付きのストリームを読み取る
+func (r *checksumReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	return r.buf.Read(p)
+}
+
+// next: 次のレコードをフレームに変換してバッファに追加する
+// ストアの末尾に達した場合はトレーラーを追加する。
+func (r *checksumReader) next() error {
+	size, err := readHeader(r.src, r.format)
+	if err == io.EOF {
+		trailer := make([]byte, lenWidth)
+		enc.PutUint64(trailer, trailerMarker)
+		r.buf.Write(trailer)
+		r.buf.Write(r.hash.Sum(nil))
+		r.done = true
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if r.max > 0 && size > r.max {
+		return ErrCorruptRecord
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.src, data); err != nil {
+		return err
+	}
+	frame := make([]byte, 0, lenWidth+crcWidth+len(data))
+	frame = enc.AppendUint64(frame, size)
+	frame = enc.AppendUint32(frame, crc32.Checksum(data, castagnoli))
+	frame = append(frame, data...)
+
+	r.hash.Write(frame)
+	r.buf.Write(frame)
+	return nil
+}
+
+// VerifyStream: ChecksumReader が出力したストリームを検証しながらレコードを読み取る
+// 各レコードは検証された後に fn に渡される。トレーラーのダイジェストはストリームの最後でしか
+// 検証できないため、エラーが返された場合は fn に渡したレコードを破棄する必要がある。
+// 引数:
+//   - r: チェックサム付きのストリーム
+//   - fn: 検証済みのレコード（ストアに保存されていたデータ）を受け取る関数（nil の場合は検証のみ）
+//
+// 戻り値:
+//   - uint64: 読み取ったエントリ（レコードまたはバッチ）の数
+//   - error: 検証に失敗した場合（ErrCorruptStream）、または fn がエラーを返した場合
+func VerifyStream(r io.Reader, fn func([]byte) error) (uint64, error) {
+	h := sha256.New()
+	var n uint64
+	for {
+		header := make([]byte, lenWidth+crcWidth)
+		if _, err := io.ReadFull(r, header[:lenWidth]); err != nil {
+			// トレーラーの前でストリームが終わった（途中で切断された）
+			return n, ErrCorruptStream
+		}
+
+		// トレーラー: これまでのフレームのダイジェストを検証する
+		if enc.Uint64(header[:lenWidth]) == trailerMarker {
+			digest := make([]byte, sha256.Size)
+			if _, err := io.ReadFull(r, digest); err != nil {
+				return n, ErrCorruptStream
+			}
+			if !bytes.Equal(digest, h.Sum(nil)) {
+				return n, ErrCorruptStream
+			}
+			return n, nil
+		}
+
+		if _, err := io.ReadFull(r, header[lenWidth:]); err != nil {
+			return n, ErrCorruptStream
+		}
+		// 長さフィールドが壊れていても巨大なバッファを確保しないように、受信した分だけ読み込む
+		size := enc.Uint64(header[:lenWidth])
+		if size > math.MaxInt64 {
+			return n, ErrCorruptStream
+		}
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, r, int64(size)); err != nil {
+			return n, ErrCorruptStream
+		}
+		data := buf.Bytes()
+		if crc32.Checksum(data, castagnoli) != enc.Uint32(header[lenWidth:]) {
+			return n, ErrCorruptStream
+		}
+
+		h.Write(header)
+		h.Write(data)
+		n++
+		if fn != nil {
+			if err := fn(data); err != nil {
+				return n, err
+			}
+		}
+	}
+}
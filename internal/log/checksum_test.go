@@ -0,0 +1,66 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestChecksumReader(t *testing.T) {
+	dir, err := os.MkdirTemp("", "checksum-reader-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := Config{}
+	c.Segment.MaxStoreBytes = 32
+	log, err := NewLog(dir, c)
+	require.NoError(t, err)
+	defer log.Close()
+
+	want := &api.Record{Value: []byte("hello world")}
+	for i := 0; i < 3; i++ {
+		_, err = log.Append(want)
+		require.NoError(t, err)
+	}
+
+	b, err := io.ReadAll(log.ChecksumReader())
+	require.NoError(t, err)
+
+	// 検証しながらすべてのレコードを読み取れる
+	var records []*api.Record
+	n, err := VerifyStream(bytes.NewReader(b), func(p []byte) error {
+		record := &api.Record{}
+		if err := proto.Unmarshal(p, record); err != nil {
+			return err
+		}
+		records = append(records, record)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), n)
+	for i, record := range records {
+		require.Equal(t, want.Value, record.Value)
+		require.Equal(t, uint64(i), record.Offset)
+	}
+
+	// レコードのデータが破損している
+	corrupt := bytes.Clone(b)
+	corrupt[lenWidth+crcWidth] ^= 0xff
+	_, err = VerifyStream(bytes.NewReader(corrupt), nil)
+	require.Equal(t, ErrCorruptStream, err)
+
+	// トレーラーの前で切断されている
+	_, err = VerifyStream(bytes.NewReader(b[:len(b)-lenWidth-32]), nil)
+	require.Equal(t, ErrCorruptStream, err)
+
+	// トレーラーのダイジェストが一致しない
+	corrupt = bytes.Clone(b)
+	corrupt[len(corrupt)-1] ^= 0xff
+	_, err = VerifyStream(bytes.NewReader(corrupt), nil)
+	require.Equal(t, ErrCorruptStream, err)
+}
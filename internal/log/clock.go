@@ -0,0 +1,147 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock: ログストアが現在時刻の取得と定期的な処理に使用する時計
+// テストでは FakeClock を設定することで、時間の経過を待たずに経過時間による
+// セグメントの切り替えやトピックの保持期間の処理を確認できる。
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker: Clock が作成する定期的なタイマー（time.Ticker に相当）
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Every: 時計が interval 進むごとに fn を実行するゴルーチンを開始する
+// ゴルーチンの開始を待たずに時計を進めても見逃さないように、タイマーは先に作成してからゴルーチンを開始する。
+// done を閉じると停止する。wg.Wait で、実行中の fn を含めてゴルーチンの終了を待てる。
+// 引数:
+//   - c: 間隔を測る時計
+//   - interval: 実行する間隔
+//   - done: 閉じると停止するチャネル
+//   - wg: ゴルーチンの終了待ちに使用する WaitGroup
+//   - fn: 実行する関数（引数はタイマーが送信した時刻）
+func Every(c Clock, interval time.Duration, done <-chan struct{}, wg *sync.WaitGroup, fn func(now time.Time)) {
+	ticker := c.NewTicker(interval)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C():
+				fn(now)
+			}
+		}
+	}()
+}
+
+// SystemClock: システムの時計（Config.Clock が設定されていない場合に使用する）
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// FakeClock: Advance を呼び出したときだけ進む時計
+// 進めた時間がタイマーの間隔に達すると、time.Ticker と同様にタイマーのチャネルに送信する
+// （受信されていない場合は送信せずに捨てる）。
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// NewFakeClock: 指定した時刻から始まる時計を作成する
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now: 時計の現在時刻を返す
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTicker: 時計が d 進むごとに送信するタイマーを作成する
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{
+		clock: c,
+		c:     make(chan time.Time, 1),
+		d:     d,
+		next:  c.now.Add(d),
+	}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance: 時計を d 進め、間隔に達したタイマーに送信する
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		if t.next.After(c.now) {
+			continue
+		}
+		select {
+		case t.c <- c.now:
+		default:
+		}
+		// 複数回分の間隔を進めた場合も、送信は1回にまとめる
+		for !t.next.After(c.now) {
+			t.next = t.next.Add(t.d)
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock *FakeClock
+	c     chan time.Time
+	d     time.Duration
+	next  time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	for i, other := range t.clock.tickers {
+		if other == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
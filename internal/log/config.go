@@ -1,9 +1,154 @@
 package log
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultMaxRecordBytes: Config.Segment.MaxRecordBytes が 0 の場合の1レコードの最大バイト数（gRPC の既定の最大メッセージサイズ）
+const DefaultMaxRecordBytes = 4 * 1024 * 1024
+
 type Config struct {
 	Segment struct {
 		MaxStoreBytes uint64
 		MaxIndexBytes uint64
+		// 新規ログストア（または Reset 後）で最初のレコードに割り当てるオフセット
 		InitialOffset uint64
+		// 順次読み取り（SequentialReader）で一度に先読みするストアファイルのバイト数
+		ReadAheadBytes uint64
+		// 1レコードの最大バイト数（長さ情報を除く）
+		// 読み取り時にこれを超える長さ情報は破損とみなし、巨大なバッファを確保しないようにする。
+		MaxRecordBytes uint64
+		// アクティブセグメントのストアのバッファをファイルに書き出す間隔（0 の場合は定期的に書き出さない）
+		// バッファは読み取り時と Close 時にしか書き出されないため、読み取りがないまま
+		// プロセスが終了すると書き込んだデータが失われる。
+		FlushInterval time.Duration
+		// アクティブセグメントを作成してからこの時間が経過したら、サイズに達していなくても新しいセグメントに切り替える
+		// （0 の場合は経過時間では切り替えない）。書き込みの少ないログでも保持期間の処理が一定の粒度で行われるようにする。
+		MaxAge time.Duration
+		// 新規ログストアのストアの保存形式（FormatV1 または FormatV2、0 の場合は FormatV1）
+		// 既存のログストアは、Reset 後も作成時の形式をマニフェストから読み取って使い続ける。
+		Format int
+		// true の場合、ストアファイルの書き出し済みのデータを O_DIRECT で読み取り、OS のページキャッシュを使わない（Linux のみ）
+		// 大量のリプレイ（先頭からの順次読み取り）で、同じホストの他のサービスが使うページキャッシュを追い出さないようにする。
+		// 読み取りは 4KiB の境界にそろえて行うため、ReadAheadBytes も 4KiB の倍数にすると無駄が少ない。
+		// O_DIRECT に対応していないファイルシステム（tmpfs など）ではセグメントを開けない。
+		DirectIO bool
+		// インデックスのメモリマップに対する madvise のヒント
+		// メモリが逼迫していても、最近のセグメントの検索の遅延が予測できるようにする（古いセグメントの検索はディスクから読み直す）。
+		// ログストアを開いたときと、アクティブセグメントを切り替えたときに適用する。
+		IndexHints struct {
+			// 新しい順にこの数のセグメント（アクティブセグメントを含む）のインデックスを、MADV_WILLNEED を指定して
+			// すべてのページに触れ、メモリに読み込んでおく（0 の場合は何もしない）
+			Warm int
+			// 新しい順にこの数より古いセグメントのインデックスに MADV_DONTNEED を指定して、メモリから手放す（0 の場合は何もしない）
+			// 共有のマッピングのため内容は失われず、次に検索するときにファイルから読み直す。
+			ColdAfter int
+		}
+		// スループットに応じたセグメントのサイズの自動調整
+		// 書き込みが多く、セグメントが目標の間隔より短い時間で一杯になった場合は次のセグメントのストアのサイズを2倍にし
+		// （切り替えの回数を減らす）、目標の間隔の4倍以上かかった場合は半分にする。MaxStoreBytes が下限になる。
+		Adaptive struct {
+			// true の場合だけサイズを調整する
+			Enabled bool
+			// ストアのサイズの上限（0 の場合は MaxStoreBytes の16倍）
+			// インデックスのファイルは上限のサイズに合わせて MaxIndexBytes を同じ倍率で拡張する。
+			MaxStoreBytes uint64
+			// セグメントを切り替える目標の間隔（0 の場合は1分）
+			TargetInterval time.Duration
+		}
+		// 書き込み済みセグメントの定期的な検査（スクラブ）
+		// 読み取られないまま劣化（ビット腐敗）したセグメントを、コンシューマーが読み取る前に検出して隔離する。
+		Scrub struct {
+			// すべての書き込み済みセグメントを検査する間隔（0 の場合は定期的に検査しない）
+			Interval time.Duration
+			// 検査で読み取る1秒あたりの最大バイト数（0 の場合は制限しない）
+			// 書き込みや読み取りのディスクの帯域を奪わないように制限する。
+			BytesPerSecond uint64
+		}
+	}
+	// ログストアのイベントで呼び出されるコールバック
+	Hooks Hooks
+	// 現在時刻の取得と定期的な処理に使用する時計（nil の場合は SystemClock）
+	Clock Clock
+	Topic struct {
+		// 削除したトピックをゴミ箱に保持する期間（0 の場合はすぐに削除し、元に戻せない）
+		DeleteRetention time.Duration
+		// true の場合、トピックのセグメントファイルをパーティションのディレクトリに分けず、トピックのディレクトリに直接保存する
+		// （以前のレイアウト）。false の場合は以前のレイアウトのトピックを開くときにパーティションのディレクトリに移行する。
+		FlatLayout bool
+		// NewTopics の dir に加えてトピックを配置するデータディレクトリ（別々のディスクのマウントポイントなど）
+		// 新しいトピックは空き容量が最も大きいデータディレクトリに配置し、トピックのファイルはすべてそのディレクトリに置く。
+		// 既存のトピックは配置されているデータディレクトリから開くため、後からデータディレクトリを追加してもよい
+		// （取り除くと、そこに配置したトピックは見えなくなる）。
+		DataDirs []string
+	}
+
+	// ディスクに書き込んだバイト数のカウンター（NewLog がログストアごとに作成し、セグメントに引き継ぐ）
+	writes *writeStats
+	// true の場合、NewLog は定期的な処理（フラッシュ、経過時間による切り替え、スクラブ）のゴルーチンを開始しない
+	// （Manager が開いたログストアで、Manager の共有のゴルーチンが代わりに行う）
+	managed bool
+}
+
+// clock: 設定された時計を返す（設定されていない場合は SystemClock）
+func (c Config) clock() Clock {
+	if c.Clock == nil {
+		return SystemClock
+	}
+	return c.Clock
+}
+
+// Validate: 設定の不変条件を確認する
+// NewLog はデフォルト値を設定する前にこれを呼び出し、誤った設定のログストアを開かずにすぐに失敗する。
+// 0 の項目はデフォルト値を使用するため確認しない。
+// 戻り値:
+//   - error: 満たしていない条件をすべてまとめたエラー（すべて満たしている場合は nil）
+func (c Config) Validate() error {
+	seg := c.Segment
+	var errs []error
+	if n := seg.MaxIndexBytes; n != 0 && (n < entWidth || n%entWidth != 0) {
+		// インデックスは固定長のエントリを並べるため、端数のバイトは使われず、エントリの数も分かりにくくなる
+		lower := max(n/entWidth*entWidth, entWidth)
+		errs = append(errs, fmt.Errorf(
+			"Segment.MaxIndexBytes %d must be a positive multiple of the index entry width %d (e.g. %d or %d)",
+			n, entWidth, lower, lower+entWidth))
+	}
+	storeBytes := seg.MaxStoreBytes
+	if storeBytes == 0 {
+		storeBytes = 1024
+	}
+	// デフォルトの MaxRecordBytes（gRPC の上限）はデフォルトの MaxStoreBytes より大きいため、明示的に指定した場合だけ確認する
+	// （ストアの上限を超えるレコードも保存はできるが、1レコードごとにセグメントを切り替えることになる）
+	if r := seg.MaxRecordBytes; r != 0 && r != DefaultMaxRecordBytes && storeBytes <= r {
+		errs = append(errs, fmt.Errorf(
+			"Segment.MaxStoreBytes %d must be greater than Segment.MaxRecordBytes %d so that a segment can hold the largest record",
+			storeBytes, seg.MaxRecordBytes))
+	}
+	if seg.Format != 0 && seg.Format != FormatV1 && seg.Format != FormatV2 {
+		errs = append(errs, fmt.Errorf("unsupported store format: %d (Segment.Format must be FormatV1 or FormatV2)", seg.Format))
+	}
+	if a := seg.Adaptive; a.Enabled && a.MaxStoreBytes != 0 && a.MaxStoreBytes < storeBytes {
+		errs = append(errs, fmt.Errorf("adaptive max store bytes %d is less than max store bytes %d", a.MaxStoreBytes, storeBytes))
+	}
+	for name, d := range map[string]time.Duration{
+		"Segment.FlushInterval":           seg.FlushInterval,
+		"Segment.MaxAge":                  seg.MaxAge,
+		"Segment.Scrub.Interval":          seg.Scrub.Interval,
+		"Segment.Adaptive.TargetInterval": seg.Adaptive.TargetInterval,
+		"Topic.DeleteRetention":           c.Topic.DeleteRetention,
+	} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative: %v", name, d))
+		}
+	}
+	if h := seg.IndexHints; h.Warm < 0 || h.ColdAfter < 0 {
+		errs = append(errs, fmt.Errorf("Segment.IndexHints must not be negative: Warm %d, ColdAfter %d", h.Warm, h.ColdAfter))
+	} else if h.Warm > 0 && h.ColdAfter > 0 && h.ColdAfter < h.Warm {
+		errs = append(errs, fmt.Errorf(
+			"Segment.IndexHints.ColdAfter %d must not be less than Warm %d (the newest segments cannot be both warmed and released)",
+			h.ColdAfter, h.Warm))
 	}
+	return errors.Join(errs...)
 }
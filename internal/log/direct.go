@@ -0,0 +1,53 @@
+package log
+
+import (
+	"io"
+	"unsafe"
+)
+
+// directAlignment: O_DIRECT で読み取る際のバッファ、位置、長さの境界（バイト）
+// 多くのファイルシステムの論理ブロックサイズ（512 バイト）とページサイズの両方を満たすように 4KiB にする。
+const directAlignment = 4096
+
+// alignedBuffer: アドレスが directAlignment の倍数になる n バイト以上のバッファを確保する（内部関数）
+// O_DIRECT ではカーネルがユーザーのバッファに直接 DMA するため、バッファの先頭もそろえる必要がある。
+func alignedBuffer(n int) []byte {
+	n = alignUp(n)
+	b := make([]byte, n+directAlignment)
+	shift := 0
+	if rem := int(uintptr(unsafe.Pointer(&b[0])) & (directAlignment - 1)); rem != 0 {
+		shift = directAlignment - rem
+	}
+	return b[shift : shift+n : shift+n]
+}
+
+// alignUp: n を directAlignment の倍数に切り上げる（内部関数）
+func alignUp(n int) int {
+	return (n + directAlignment - 1) &^ (directAlignment - 1)
+}
+
+// directReadAt: O_DIRECT で開いたファイルから off の位置のデータを p に読み取る（内部関数）
+// 読み取る範囲を directAlignment の境界に広げて再利用するバッファに読み込み、必要な部分だけを p にコピーする。
+// 呼び出し側で s.mu のロックを取得しておく必要がある。
+func (s *store) directReadAt(p []byte, off int64) (int, error) {
+	start := off &^ (directAlignment - 1)
+	size := alignUp(int(off-start) + len(p))
+	if cap(s.directBuf) < size {
+		s.directBuf = alignedBuffer(size)
+	}
+	buf := s.directBuf[:size]
+
+	// ファイルの末尾を含む場合、O_DIRECT の読み取りは末尾までの短いバイト数を返す
+	k, err := s.direct.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	n := 0
+	if skip := int(off - start); k > skip {
+		n = copy(p, buf[skip:k])
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
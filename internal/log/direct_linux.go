@@ -0,0 +1,14 @@
+//go:build linux
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// openDirect: ページキャッシュを使わずに読み取るため、ファイルを O_DIRECT で読み取り専用で開く（内部関数）
+// ファイルシステムが O_DIRECT に対応していない場合（tmpfs など）はエラーを返す。
+func openDirect(name string) (*os.File, error) {
+	return os.OpenFile(name, os.O_RDONLY|syscall.O_DIRECT, 0)
+}
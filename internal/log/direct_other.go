@@ -0,0 +1,14 @@
+//go:build !linux
+
+package log
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// openDirect: O_DIRECT は Linux 以外ではサポートしない
+func openDirect(name string) (*os.File, error) {
+	return nil, fmt.Errorf("direct I/O is not supported on %s", runtime.GOOS)
+}
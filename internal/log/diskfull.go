@@ -0,0 +1,56 @@
+package log
+
+import (
+	"errors"
+	"syscall"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// isDiskFull: ディスクの空き容量が足りずに書き込みに失敗したかどうかを判定する（内部関数）
+func isDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
+
+// writeError: 書き込みのエラーがディスクが一杯であることを表す場合、ログを読み取り専用にする（内部関数）
+// 読み取り専用の間は、書き込みのたびにディスクに触れて不定の状態で失敗し続けるのではなく、
+// api.ErrDiskFull を返す。読み取りは書き出せなかったデータも含めて引き続き行える。
+// 引数:
+//   - err: 書き込みで発生したエラー
+//
+// 戻り値:
+//   - error: ディスクが一杯の場合は api.ErrDiskFull、それ以外は err をそのまま返す
+func (l *Log) writeError(err error) error {
+	if !isDiskFull(err) {
+		return err
+	}
+	e := api.ErrDiskFull{Reason: err.Error()}
+	l.diskFull.Store(&e)
+	return e
+}
+
+// checkWritable: ログが読み取り専用の場合に、空き容量が戻ったかを確認する（内部関数）
+// アクティブセグメントの書き出せなかったデータを書き出せた場合は、空き容量が戻ったとみなして書き込みを再開する。
+// 呼び出し側で l.mu のロックを取得しておく必要がある。
+// 戻り値:
+//   - error: まだ空き容量が足りない場合（api.ErrDiskFull）
+func (l *Log) checkWritable() error {
+	if l.diskFull.Load() == nil {
+		return nil
+	}
+	if err := l.activeSegment.store.Flush(); err != nil {
+		return l.writeError(err)
+	}
+	l.diskFull.Store(nil)
+	return nil
+}
+
+// DiskFull: ディスクが一杯のためログが読み取り専用になっているかを確認する
+// 戻り値:
+//   - error: 読み取り専用の場合は api.ErrDiskFull（書き込み可能な場合は nil）
+func (l *Log) DiskFull() error {
+	if e := l.diskFull.Load(); e != nil {
+		return *e
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package log
+
+// 障害注入（フェイルポイント）の名前
+// フェイルポイントは failpoints ビルドタグを付けてビルドした場合のみ有効になり、
+// 通常のビルドでは常に nil を返す（コストはかからない）。
+// 例: go test -tags failpoints ./internal/log
+const (
+	FailpointStoreWrite     = "store-write"     // ストアへのレコードの書き込み
+	FailpointIndexWrite     = "index-write"     // インデックスへのエントリの書き込み
+	FailpointSync           = "sync"            // ファイルのディスクへの同期（fsync）
+	FailpointSegmentRoll    = "segment-roll"    // 新しいアクティブセグメントの作成
+	FailpointSegmentInstall = "segment-install" // 転送されたセグメントのファイルを配置した後、開く前
+	FailpointSegmentSplit   = "segment-split"   // 分割した後半のセグメントのファイルを配置した後、前半のファイルを置き換える前
+)
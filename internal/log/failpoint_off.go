@@ -0,0 +1,8 @@
+//go:build !failpoints
+
+package log
+
+// failpoint: 通常のビルドではフェイルポイントは無効
+func failpoint(name string) error {
+	return nil
+}
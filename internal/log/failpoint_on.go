@@ -0,0 +1,29 @@
+//go:build failpoints
+
+package log
+
+import "sync"
+
+// failpoints: 有効なフェイルポイントの名前と、注入するエラー
+var failpoints sync.Map
+
+// EnableFailpoint: フェイルポイントを有効にし、その箇所で err を返すようにする
+// 引数:
+//   - name: フェイルポイントの名前（Failpoint* 定数）
+//   - err: 注入するエラー
+func EnableFailpoint(name string, err error) {
+	failpoints.Store(name, err)
+}
+
+// DisableFailpoint: フェイルポイントを無効にする
+func DisableFailpoint(name string) {
+	failpoints.Delete(name)
+}
+
+// failpoint: フェイルポイントが有効な場合は注入するエラーを返す
+func failpoint(name string) error {
+	if err, ok := failpoints.Load(name); ok {
+		return err.(error)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+//go:build failpoints
+
+package log
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+)
+
+var errInjected = errors.New("injected failure")
+
+func TestFailpoints(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T, dir string, log *Log,
+	){
+		"store write failure appends nothing":    testFailStoreWrite,
+		"index write failure rolls back store":   testFailIndexWrite,
+		"index write failure rolls back batch":   testFailIndexWriteBatch,
+		"segment roll failure keeps active":      testFailSegmentRoll,
+		"sync failure on close recovers on open": testFailSync,
+		"install failure restores active":        testFailSegmentInstall,
+		"split failure completes on open":        testFailSegmentSplit,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			dir, err := os.MkdirTemp("", "failpoint-test")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			c := Config{}
+			c.Segment.MaxStoreBytes = 64
+			log, err := NewLog(dir, c)
+			require.NoError(t, err)
+
+			fn(t, dir, log)
+		})
+	}
+}
+
+// appendN: n 件のレコードを追加する
+func appendN(t *testing.T, log *Log, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		_, err := log.Append(&api.Record{Value: []byte("hello world")})
+		require.NoError(t, err)
+	}
+}
+
+// requireConsistent: 0 から next-1 までのすべてのオフセットを読み取れ、次に追加するレコードのオフセットが next であることを確認する
+func requireConsistent(t *testing.T, log *Log, next uint64) {
+	t.Helper()
+	for off := uint64(0); off < next; off++ {
+		record, err := log.Read(off)
+		require.NoError(t, err)
+		require.Equal(t, off, record.Offset)
+	}
+	_, err := log.Read(next)
+	require.Error(t, err)
+
+	off, err := log.Append(&api.Record{Value: []byte("hello world")})
+	require.NoError(t, err)
+	require.Equal(t, next, off)
+}
+
+func testFailStoreWrite(t *testing.T, _ string, log *Log) {
+	appendN(t, log, 2)
+
+	EnableFailpoint(FailpointStoreWrite, errInjected)
+	_, err := log.Append(&api.Record{Value: []byte("hello world")})
+	DisableFailpoint(FailpointStoreWrite)
+	require.ErrorIs(t, err, errInjected)
+
+	requireConsistent(t, log, 2)
+	require.NoError(t, log.Close())
+}
+
+func testFailIndexWrite(t *testing.T, dir string, log *Log) {
+	appendN(t, log, 2)
+	before, err := log.SegmentStats()
+	require.NoError(t, err)
+
+	EnableFailpoint(FailpointIndexWrite, errInjected)
+	_, err = log.Append(&api.Record{Value: []byte("hello world")})
+	DisableFailpoint(FailpointIndexWrite)
+	require.ErrorIs(t, err, errInjected)
+
+	// インデックスに記録できなかったレコードはストアに残らない
+	after, err := log.SegmentStats()
+	require.NoError(t, err)
+	require.Equal(t, before[0].StoreBytes, after[0].StoreBytes)
+	require.Equal(t, before[0].IndexBytes, after[0].IndexBytes)
+
+	requireConsistent(t, log, 2)
+	require.NoError(t, log.Close())
+
+	log, err = NewLog(dir, log.Config)
+	require.NoError(t, err)
+	requireConsistent(t, log, 3)
+	require.NoError(t, log.Close())
+}
+
+func testFailIndexWriteBatch(t *testing.T, dir string, log *Log) {
+	appendN(t, log, 1)
+
+	EnableFailpoint(FailpointIndexWrite, errInjected)
+	_, err := log.AppendBatch([]*api.Record{
+		{Value: []byte("a")},
+		{Value: []byte("b")},
+	})
+	DisableFailpoint(FailpointIndexWrite)
+	require.ErrorIs(t, err, errInjected)
+
+	requireConsistent(t, log, 1)
+	require.NoError(t, log.Close())
+
+	log, err = NewLog(dir, log.Config)
+	require.NoError(t, err)
+	requireConsistent(t, log, 2)
+	require.NoError(t, log.Close())
+}
+
+func testFailSegmentRoll(t *testing.T, dir string, log *Log) {
+	// アクティブセグメントが最大サイズに達するまで追加する
+	for !log.activeSegment.IsMaxed() {
+		appendN(t, log, 1)
+	}
+	next := log.activeSegment.nextOffset
+	segments := len(log.segments)
+
+	EnableFailpoint(FailpointSegmentRoll, errInjected)
+	_, err := log.Append(&api.Record{Value: []byte("hello world")})
+	DisableFailpoint(FailpointSegmentRoll)
+	require.ErrorIs(t, err, errInjected)
+	require.Equal(t, segments, len(log.segments))
+
+	// 再試行すると新しいセグメントが作成される
+	requireConsistent(t, log, next)
+	require.Equal(t, segments+1, len(log.segments))
+	require.NoError(t, log.Close())
+
+	log, err = NewLog(dir, log.Config)
+	require.NoError(t, err)
+	requireConsistent(t, log, next+1)
+	require.NoError(t, log.Close())
+}
+
+func testFailSync(t *testing.T, dir string, log *Log) {
+	appendN(t, log, 3)
+
+	// インデックスの同期に失敗しても、ストアのデータは書き込まれる
+	EnableFailpoint(FailpointSync, errInjected)
+	err := log.Close()
+	DisableFailpoint(FailpointSync)
+	require.ErrorIs(t, err, errInjected)
+
+	log, err = NewLog(dir, log.Config)
+	require.NoError(t, err)
+	requireConsistent(t, log, 3)
+	require.NoError(t, log.Close())
+}
+
+func testFailSegmentInstall(t *testing.T, dir string, log *Log) {
+	// インストールするセグメントを持つログ
+	srcDir, err := os.MkdirTemp("", "failpoint-source")
+	require.NoError(t, err)
+	defer os.RemoveAll(srcDir)
+	src, err := NewLog(srcDir, log.Config)
+	require.NoError(t, err)
+	defer src.Close()
+	appendN(t, src, 10)
+	sealed := src.SealedSegments(0)
+	require.NotEmpty(t, sealed)
+	for _, s := range sealed {
+		defer s.Close()
+	}
+
+	// 空のアクティブセグメントを置き換えた後に失敗しても、アクティブセグメントが作り直される
+	EnableFailpoint(FailpointSegmentInstall, errInjected)
+	err = log.InstallSegment(0, sealed[0].Format, sealed[0].Store, sealed[0].Index)
+	DisableFailpoint(FailpointSegmentInstall)
+	require.ErrorIs(t, err, errInjected)
+	require.NoError(t, log.Healthy())
+	require.Equal(t, 1, len(log.segments))
+	require.Equal(t, uint64(0), log.NextOffset())
+
+	// 再試行するとインストールできる
+	_, err = sealed[0].Store.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	_, err = sealed[0].Index.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	require.NoError(t, log.InstallSegment(0, sealed[0].Format, sealed[0].Store, sealed[0].Index))
+	requireConsistent(t, log, sealed[0].NextOffset)
+}
+
+func testFailSegmentSplit(t *testing.T, dir string, log *Log) {
+	appendN(t, log, 3)
+	require.Greater(t, log.segments[0].nextOffset, uint64(1))
+
+	// 一時ファイルの書き込みに失敗した場合は、元のセグメントのまま使い続けられる
+	EnableFailpoint(FailpointSync, errInjected)
+	err := log.SplitSegment(0, 1)
+	DisableFailpoint(FailpointSync)
+	require.ErrorIs(t, err, errInjected)
+	require.NoError(t, log.Healthy())
+	tmps, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	require.NoError(t, err)
+	require.Empty(t, tmps)
+	requireConsistent(t, log, 3)
+
+	// 一時ファイルの配置の途中で失敗した場合は、書き込みを拒否し、開き直すときに分割を完了する
+	EnableFailpoint(FailpointSegmentSplit, errInjected)
+	err = log.SplitSegment(0, 1)
+	DisableFailpoint(FailpointSegmentSplit)
+	require.ErrorIs(t, err, errInjected)
+	require.Error(t, log.Healthy())
+	require.NoError(t, log.Close())
+
+	log, err = NewLog(dir, log.Config)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), log.segments[0].nextOffset)
+	require.Equal(t, uint64(1), log.segments[1].baseOffset)
+	markers, err := filepath.Glob(filepath.Join(dir, "*"+splitExt))
+	require.NoError(t, err)
+	require.Empty(t, markers)
+	requireConsistent(t, log, 4)
+	require.NoError(t, log.Close())
+}
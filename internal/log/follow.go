@@ -0,0 +1,119 @@
+package log
+
+import (
+	"io"
+	"sync"
+)
+
+// FollowReader: 先頭のセグメントから読み取り、末尾に達したら追加されるレコードを待って読み続ける Reader を返す
+// tail -f のように、ログストアのストアファイルの内容（[長さ][データ] の繰り返し）を途切れなく読み取る。
+// Read は読み取れるデータがあればバッファが一杯にならなくてもすぐに返し、データがない場合だけ追加を待つ。
+// セグメントの切り替えをまたいで読み続け、読み取り中のセグメントが Truncate で削除されても読み終わるまでファイルは残る。
+// 読み取りをやめる場合は Close を呼び出す（待っている Read は io.EOF を返す）。
+// ログストアが閉じられた場合も Read は io.EOF を返すが、セグメントの参照を外すため Close は呼び出す必要がある。
+// 戻り値:
+//   - io.ReadCloser: 追加されるレコードを待ちながら読み続ける Reader
+func (l *Log) FollowReader() io.ReadCloser {
+	return &followReader{log: l, done: make(chan struct{})}
+}
+
+// followReader: 追加されるレコードを待ちながらすべてのセグメントを順番に読み取る Reader
+type followReader struct {
+	log *Log
+
+	mu   sync.Mutex
+	seg  *segment // 読み取り中のセグメント（参照を取得している）
+	off  int64    // seg のストアファイル内の読み取り位置
+	done chan struct{}
+	once sync.Once
+}
+
+// Read: ストアファイルのデータを読み取る
+// 読み取り中のセグメントの末尾に達した場合は次のセグメントに進み、
+// アクティブセグメントの末尾に達した場合はレコードが追加されるか Close が呼び出されるまで待つ。
+func (r *followReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for {
+		// ログストアが閉じられた場合は読み取りを終了する
+		select {
+		case <-r.done:
+			return 0, io.EOF
+		case <-r.log.closed:
+			return 0, io.EOF
+		default:
+		}
+
+		r.log.mu.RLock()
+		// リセットに失敗してセグメントがない場合も読み取りを終了する
+		if r.seg == nil && len(r.log.segments) == 0 {
+			r.log.mu.RUnlock()
+			return 0, io.EOF
+		}
+		if r.seg == nil {
+			r.seg = r.log.segments[0]
+			r.seg.acquire()
+		}
+		n, err := r.seg.store.ReadAt(p, r.off)
+		r.off += int64(n)
+		if err != nil && err != io.EOF {
+			r.log.mu.RUnlock()
+			return n, err
+		}
+		if n > 0 {
+			r.log.mu.RUnlock()
+			return n, nil
+		}
+
+		// 書き込みは l.mu のロックを取得するため、末尾に達した後に次のセグメントがあれば、このセグメントには追加されない
+		if next := r.log.segmentAfter(r.seg); next != nil {
+			next.acquire()
+			r.log.mu.RUnlock()
+			// 削除に失敗しても、以降はこのセグメントを読み取らないためエラーは無視する
+			_ = r.seg.release()
+			r.seg, r.off = next, 0
+			continue
+		}
+		// ロックを外す前に通知用のチャネルを取得しておく（ロックを外した後の追加を取りこぼさないため）
+		appended := r.log.appended
+		r.log.mu.RUnlock()
+
+		select {
+		case <-appended:
+		case <-r.log.closed:
+		case <-r.done:
+			return 0, io.EOF
+		}
+	}
+}
+
+// Close: 読み取りを終了し、読み取り中のセグメントの参照を外す
+// 待っている Read は io.EOF を返す。
+func (r *followReader) Close() error {
+	r.once.Do(func() { close(r.done) })
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seg == nil {
+		return nil
+	}
+	err := r.seg.release()
+	r.seg = nil
+	return err
+}
+
+// segmentAfter: 指定されたセグメントの次のセグメントを返す（内部関数）
+// 指定されたセグメントが Truncate で削除されている場合も、その後ろに残っている最初のセグメントを返す。
+// 呼び出し側で l.mu のロックを取得しておく必要がある。
+// 戻り値:
+//   - *segment: 次のセグメント（指定されたセグメントがアクティブセグメントの場合は nil）
+func (l *Log) segmentAfter(s *segment) *segment {
+	for _, next := range l.segments {
+		if next != s && next.baseOffset >= s.nextOffset {
+			return next
+		}
+	}
+	return nil
+}
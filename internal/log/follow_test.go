@@ -0,0 +1,138 @@
+package log
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReaderSnapshot(t *testing.T) {
+	c := Config{}
+	c.Segment.MaxStoreBytes = 32
+	log, err := NewLog(t.TempDir(), c)
+	require.NoError(t, err)
+	defer log.Close()
+
+	_, err = log.Append(&api.Record{Value: []byte("a")})
+	require.NoError(t, err)
+	reader := log.Reader()
+	defer reader.Close()
+
+	// Reader を作成した後の追加やセグメントの切り替えは含まない
+	for i := 0; i < 4; i++ {
+		_, err = log.Append(&api.Record{Value: []byte("b")})
+		require.NoError(t, err)
+	}
+	b, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, storeValues(t, b))
+}
+
+func TestFollowReader(t *testing.T) {
+	c := Config{}
+	c.Segment.MaxStoreBytes = 32
+	log, err := NewLog(t.TempDir(), c)
+	require.NoError(t, err)
+	defer log.Close()
+
+	_, err = log.Append(&api.Record{Value: []byte("0")})
+	require.NoError(t, err)
+	reader := log.FollowReader()
+
+	// 追加されたデータは、バッファが一杯になるのを待たずに返す
+	buf := make([]byte, 1024)
+	n, err := reader.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0"}, storeValues(t, buf[:n]))
+
+	// セグメントの切り替えをまたいで、追加されるレコードを読み続ける
+	chunks := make(chan []byte)
+	stopped := make(chan error)
+	go func() {
+		for {
+			buf := make([]byte, 1024)
+			n, err := reader.Read(buf)
+			if err != nil {
+				stopped <- err
+				return
+			}
+			chunks <- buf[:n]
+		}
+	}()
+	for _, v := range []string{"1", "2", "3", "4"} {
+		_, err = log.Append(&api.Record{Value: []byte(v)})
+		require.NoError(t, err)
+	}
+	var b []byte
+	for len(storeValues(t, b)) < 4 {
+		select {
+		case chunk := <-chunks:
+			b = append(b, chunk...)
+		case <-time.After(time.Second):
+			t.Fatal("follow reader did not return appended records")
+		}
+	}
+	require.Equal(t, []string{"1", "2", "3", "4"}, storeValues(t, b))
+	lowest, err := log.LowestOffset()
+	require.NoError(t, err)
+	require.NotEqual(t, lowest, log.activeSegment.baseOffset)
+
+	// Close で待っている Read が終了する
+	require.NoError(t, reader.Close())
+	select {
+	case err := <-stopped:
+		require.Equal(t, io.EOF, err)
+	case <-time.After(time.Second):
+		t.Fatal("follow reader did not stop after Close")
+	}
+}
+
+func TestFollowReaderEnd(t *testing.T) {
+	log, err := NewLog(t.TempDir(), Config{})
+	require.NoError(t, err)
+
+	// セグメントがない場合は（リセットに失敗した場合など）、待たずに io.EOF を返す
+	segments := log.segments
+	log.segments = nil
+	reader := log.FollowReader()
+	_, err = reader.Read(make([]byte, 1024))
+	require.Equal(t, io.EOF, err)
+	require.NoError(t, reader.Close())
+	log.segments = segments
+
+	// ログストアが閉じられたら、待っている Read は io.EOF を返す
+	reader = log.FollowReader()
+	defer reader.Close()
+	stopped := make(chan error)
+	go func() {
+		_, err := reader.Read(make([]byte, 1024))
+		stopped <- err
+	}()
+	require.NoError(t, log.Close())
+	select {
+	case err := <-stopped:
+		require.Equal(t, io.EOF, err)
+	case <-time.After(time.Second):
+		t.Fatal("follow reader did not stop after the log was closed")
+	}
+}
+
+// storeValues: ストアファイルの内容（[長さ][データ] の繰り返し）からレコードの値を取り出す
+func storeValues(t *testing.T, b []byte) []string {
+	t.Helper()
+	var values []string
+	for len(b) > 0 {
+		require.GreaterOrEqual(t, len(b), lenWidth)
+		size := enc.Uint64(b[:lenWidth])
+		b = b[lenWidth:]
+		record := &api.Record{}
+		require.NoError(t, proto.Unmarshal(b[:size], record))
+		values = append(values, string(record.Value))
+		b = b[size:]
+	}
+	return values
+}
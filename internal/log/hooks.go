@@ -0,0 +1,118 @@
+package log
+
+import (
+	api "github.com/kentakki416/proglog/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Hooks: ログストアのイベントで呼び出されるコールバック
+// 埋め込み側がパッケージをフォークせずに、インデックス作成やキャッシュ、通知などを行うために使用する。
+// コールバックはログストアのロックを保持したまま同期的に呼び出されるため、イベントの順序が保たれる。
+// そのため、コールバックからログストアのメソッドを呼び出してはならず、時間のかかる処理は
+// NewAsyncHooks でバックグラウンドに移す必要がある。nil のコールバックは呼び出されない。
+type Hooks struct {
+	// レコードが追加されたときに呼び出される（record は変更してはならない）
+	OnAppend func(record *api.Record, offset uint64)
+	// Truncate でセグメントが削除されたときに、削除後の最小オフセットで呼び出される
+	OnTruncate func(lowest uint64)
+	// アクティブセグメントが最大サイズに達し、新しいセグメントが作成されたときに呼び出される
+	OnSegmentRoll func(baseOffset uint64)
+	// スクラブで書き込み済みセグメントの破損を検出し、セグメントを隔離したときに呼び出される
+	// クラスタで複製している場合は、他のノードからセグメントを取得し直すきっかけに使用する。
+	OnCorruptSegment func(baseOffset uint64, err error)
+}
+
+// onAppend: OnAppend が設定されていれば呼び出す
+func (h Hooks) onAppend(record *api.Record, offset uint64) {
+	if h.OnAppend != nil {
+		h.OnAppend(record, offset)
+	}
+}
+
+// onTruncate: OnTruncate が設定されていれば呼び出す
+func (h Hooks) onTruncate(lowest uint64) {
+	if h.OnTruncate != nil {
+		h.OnTruncate(lowest)
+	}
+}
+
+// onSegmentRoll: OnSegmentRoll が設定されていれば呼び出す
+func (h Hooks) onSegmentRoll(baseOffset uint64) {
+	if h.OnSegmentRoll != nil {
+		h.OnSegmentRoll(baseOffset)
+	}
+}
+
+// onCorruptSegment: OnCorruptSegment が設定されていれば呼び出す
+func (h Hooks) onCorruptSegment(baseOffset uint64, err error) {
+	if h.OnCorruptSegment != nil {
+		h.OnCorruptSegment(baseOffset, err)
+	}
+}
+
+// AsyncHooks: コールバックをバッファ付きのキューを介してバックグラウンドで呼び出すディスパッチャー
+// イベントは発生した順に1つのゴルーチンで呼び出される。キューが一杯の場合は
+// 空きができるまでログストアへの書き込みがブロックされる。
+type AsyncHooks struct {
+	hooks  Hooks
+	events chan func()   // 呼び出し待ちのイベント
+	done   chan struct{} // バックグラウンドのゴルーチンが終了したときに閉じられるチャネル
+}
+
+// NewAsyncHooks: コールバックをバックグラウンドで呼び出すディスパッチャーを作成する
+// 引数:
+//   - h: バックグラウンドで呼び出すコールバック
+//   - buffer: キューに保持できるイベントの数
+//
+// 戻り値:
+//   - *AsyncHooks: ディスパッチャー（Hooks() の戻り値を Config.Hooks に設定する）
+func NewAsyncHooks(h Hooks, buffer int) *AsyncHooks {
+	a := &AsyncHooks{
+		hooks:  h,
+		events: make(chan func(), buffer),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(a.done)
+		for fn := range a.events {
+			fn()
+		}
+	}()
+	return a
+}
+
+// Hooks: イベントをキューに追加するコールバックを返す
+// 元のコールバックが nil のイベントはキューに追加しない。
+// レコードは呼び出し元で再利用される可能性があるため、コピーしてからキューに追加する。
+func (a *AsyncHooks) Hooks() Hooks {
+	var h Hooks
+	if fn := a.hooks.OnAppend; fn != nil {
+		h.OnAppend = func(record *api.Record, offset uint64) {
+			record = proto.Clone(record).(*api.Record)
+			a.events <- func() { fn(record, offset) }
+		}
+	}
+	if fn := a.hooks.OnTruncate; fn != nil {
+		h.OnTruncate = func(lowest uint64) {
+			a.events <- func() { fn(lowest) }
+		}
+	}
+	if fn := a.hooks.OnSegmentRoll; fn != nil {
+		h.OnSegmentRoll = func(baseOffset uint64) {
+			a.events <- func() { fn(baseOffset) }
+		}
+	}
+	if fn := a.hooks.OnCorruptSegment; fn != nil {
+		h.OnCorruptSegment = func(baseOffset uint64, err error) {
+			a.events <- func() { fn(baseOffset, err) }
+		}
+	}
+	return h
+}
+
+// Close: キューに残っているイベントをすべて呼び出してからディスパッチャーを停止する
+// ログストアを閉じた後に呼び出す必要がある（停止後にイベントが発生すると panic する）。
+func (a *AsyncHooks) Close() {
+	close(a.events)
+	<-a.done
+}
@@ -0,0 +1,81 @@
+package log
+
+import (
+	"os"
+	"testing"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooks(t *testing.T) {
+	dir, err := os.MkdirTemp("", "hooks-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var events []string
+	c := Config{}
+	c.Segment.MaxStoreBytes = 32
+	c.Hooks = Hooks{
+		OnAppend: func(record *api.Record, offset uint64) {
+			require.Equal(t, offset, record.Offset)
+			events = append(events, "append")
+		},
+		OnSegmentRoll: func(baseOffset uint64) {
+			require.Equal(t, uint64(2), baseOffset)
+			events = append(events, "roll")
+		},
+		OnTruncate: func(lowest uint64) {
+			require.Equal(t, uint64(2), lowest)
+			events = append(events, "truncate")
+		},
+	}
+	log, err := NewLog(dir, c)
+	require.NoError(t, err)
+	defer log.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := log.Append(&api.Record{Value: []byte("hello world")})
+		require.NoError(t, err)
+	}
+	// 削除されるセグメントがない場合は呼び出されない
+	require.NoError(t, log.Truncate(0))
+	require.NoError(t, log.Truncate(1))
+
+	require.Equal(t, []string{"append", "append", "roll", "append", "truncate"}, events)
+}
+
+func TestAsyncHooks(t *testing.T) {
+	dir, err := os.MkdirTemp("", "async-hooks-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var offsets []uint64
+	var values []string
+	async := NewAsyncHooks(Hooks{
+		OnAppend: func(record *api.Record, offset uint64) {
+			offsets = append(offsets, offset)
+			values = append(values, string(record.Value))
+		},
+	}, 1)
+
+	c := Config{}
+	c.Hooks = async.Hooks()
+	require.Nil(t, c.Hooks.OnTruncate)
+	log, err := NewLog(dir, c)
+	require.NoError(t, err)
+
+	record := &api.Record{}
+	for _, v := range []string{"a", "b", "c"} {
+		// 呼び出し元がレコードを再利用しても、コールバックにはコピーが渡される
+		record.Value = []byte(v)
+		_, err := log.Append(record)
+		require.NoError(t, err)
+	}
+	require.NoError(t, log.Close())
+
+	// Close でキューに残っているイベントがすべて呼び出される
+	async.Close()
+	require.Equal(t, []uint64{0, 1, 2}, offsets)
+	require.Equal(t, []string{"a", "b", "c"}, values)
+}
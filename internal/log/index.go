@@ -21,8 +21,24 @@ type index struct {
 	file *os.File    // インデックスファイルのファイルハンドル
 	mmap gommap.MMap // メモリマップドファイル（インデックスファイルをメモリ上にマッピングして高速アクセスを実現）
 	size uint64      // 現在のインデックスファイルの有効なデータサイズ（バイト単位）
+
+	writes *writeStats // 書き込んだバイト数のカウンター
+
+	hint indexHint // 最後に適用した madvise のヒント（同じヒントを繰り返し適用しないため）
 }
 
+// indexHint: インデックスのメモリマップに適用した madvise のヒント
+type indexHint int
+
+const (
+	hintNone indexHint = iota // ヒントを適用していない
+	hintWarm                  // MADV_WILLNEED を指定してメモリに読み込んだ
+	hintCold                  // MADV_DONTNEED を指定してメモリから手放した
+)
+
+// touched: warm で触れたページの値の合計（読み取りがコンパイラに取り除かれないように保存する）
+var touched byte
+
 // newIndex: 指定されたファイルからインデックスを作成
 // インデックスファイルをメモリマップドファイルとして開き、高速な読み書きを可能にする
 // 引数:
@@ -34,7 +50,8 @@ type index struct {
 //   - error: エラーが発生した場合
 func newIndex(f *os.File, c Config) (*index, error) {
 	idx := &index{
-		file: f,
+		file:   f,
+		writes: c.writes,
 	}
 
 	// 既存ファイルのサイズを取得（既存のインデックスエントリがある場合に備える）
@@ -66,14 +83,7 @@ func newIndex(f *os.File, c Config) (*index, error) {
 // 戻り値:
 //   - error: エラーが発生した場合
 func (i *index) Close() error {
-	// メモリマップの変更をファイルに同期的に書き込む（MS_SYNC: 同期的に書き込み）
-	// これにより、メモリ上の変更が確実にディスクに反映される
-	if err := i.mmap.Sync(gommap.MS_SYNC); err != nil {
-		return err
-	}
-
-	// ファイルの変更をディスクに同期的に書き込む
-	if err := i.file.Sync(); err != nil {
+	if err := i.Sync(); err != nil {
 		return err
 	}
 
@@ -87,6 +97,47 @@ func (i *index) Close() error {
 	return i.file.Close()
 }
 
+// warm: インデックスの有効なエントリの範囲に MADV_WILLNEED を指定し、すべてのページに触れてメモリに読み込む
+// madvise はヒントのため、失敗しても検索の遅延が予測しにくくなるだけで、エラーにはしない。
+func (i *index) warm() {
+	if i.hint == hintWarm || i.size == 0 {
+		return
+	}
+	i.hint = hintWarm
+	_ = i.mmap[:i.size].Advise(gommap.MADV_WILLNEED)
+	page := uint64(os.Getpagesize())
+	var sum byte
+	for p := uint64(0); p < i.size; p += page {
+		sum += i.mmap[p]
+	}
+	touched += sum
+}
+
+// cold: インデックスのメモリマップに MADV_DONTNEED を指定して、メモリから手放す
+// 共有のマッピングのため内容は失われず、次に読み取るときにファイルから読み直す。
+func (i *index) cold() {
+	if i.hint == hintCold {
+		return
+	}
+	i.hint = hintCold
+	_ = i.mmap.Advise(gommap.MADV_DONTNEED)
+}
+
+// Sync: メモリマップの変更をファイルに書き込み、ディスクに同期する
+func (i *index) Sync() error {
+	// メモリマップの変更をファイルに同期的に書き込む（MS_SYNC: 同期的に書き込み）
+	// これにより、メモリ上の変更が確実にディスクに反映される
+	if err := failpoint(FailpointSync); err != nil {
+		return err
+	}
+	if err := i.mmap.Sync(gommap.MS_SYNC); err != nil {
+		return err
+	}
+
+	// ファイルの変更をディスクに同期的に書き込む
+	return i.file.Sync()
+}
+
 // Read: インデックスからエントリを読み取る
 // 引数:
 //   - in: 読み取るエントリのインデックス番号（-1の場合は最後のエントリを読み取る）
@@ -142,6 +193,9 @@ func (i *index) Write(off uint32, pos uint64) error {
 	if i.isMaxed() {
 		return io.EOF
 	}
+	if err := failpoint(FailpointIndexWrite); err != nil {
+		return err
+	}
 
 	// メモリマップにオフセットを書き込む（現在のサイズ位置から4バイト）
 	// 例: size = 24の場合、mmap[24:28] に4バイト書き込む
@@ -153,6 +207,7 @@ func (i *index) Write(off uint32, pos uint64) error {
 
 	// 有効なデータサイズを1エントリ分（12バイト）増やす
 	i.size += uint64(entWidth)
+	i.writes.addIndex(entWidth)
 
 	return nil
 }
@@ -168,6 +223,12 @@ func (i *index) isMaxed() bool {
 	return uint64(len(i.mmap)) < i.size+entWidth
 }
 
+// hasRoom: インデックスに n 個のエントリを追加する余地があるかどうかを判定する
+// バッチのレコードはすべて同じセグメントに記録する必要があるため、追加する前に確認する。
+func (i *index) hasRoom(n uint64) bool {
+	return uint64(len(i.mmap)) >= i.size+n*entWidth
+}
+
 func (i *index) Name() string {
 	return i.file.Name()
 }
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// defaultPartition: トピックのログを保存するパーティションのディレクトリ名
+// 現在はトピックごとに1つのパーティションだけを使用する。
+const defaultPartition = "0"
+
+// migratingDir: 古いレイアウトから移行中のファイルを集めるディレクトリ名（トピックのディレクトリ内）
+// ファイルをすべて移動してからパーティションのディレクトリにリネームするため、
+// 移行の途中でプロセスが終了しても、次回起動時に残りのファイルを移動して移行を完了できる。
+const migratingDir = ".migrating"
+
+// topicDir: トピックのディレクトリのパスを返す（内部関数）
+// トピックを配置したデータディレクトリの下のパスを返す（配置されていない場合は Dir の下）。
+func (t *Topics) topicDir(name string) string {
+	dataDir, ok := t.placement[name]
+	if !ok {
+		dataDir = t.Dir
+	}
+	return filepath.Join(dataDir, name)
+}
+
+// logDir: トピックのログのセグメントファイルを保存するディレクトリのパスを返す（内部関数）
+// レイアウト:
+//   - デフォルト: "{データディレクトリ}/{トピック名}/{パーティション}/{baseOffset}.store|.index"
+//   - Config.Topic.FlatLayout: "{データディレクトリ}/{トピック名}/{baseOffset}.store|.index"
+func (t *Topics) logDir(name string) string {
+	if t.Config.Topic.FlatLayout {
+		return t.topicDir(name)
+	}
+	return filepath.Join(t.topicDir(name), defaultPartition)
+}
+
+// openLayout: トピックのディレクトリを設定されたレイアウトで開けるようにする（内部関数）
+// パーティションのレイアウトでは、古いレイアウト（トピックのディレクトリ直下にファイルがある）のトピックを移行する。
+// 古いレイアウトでは、パーティションのレイアウトに移行済みのトピックは開けない。
+// 引数:
+//   - name: トピック名
+//
+// 戻り値:
+//   - error: 移行に失敗した場合、またはレイアウトが設定と一致しない場合
+func (t *Topics) openLayout(name string) error {
+	dir := t.topicDir(name)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var files []string
+	migrating, partitioned := false, false
+	for _, e := range entries {
+		switch {
+		case e.Name() == migratingDir:
+			migrating = true
+		case e.Name() == defaultPartition && e.IsDir():
+			partitioned = true
+		case !e.IsDir():
+			files = append(files, e.Name())
+		}
+	}
+
+	if t.Config.Topic.FlatLayout {
+		if partitioned || migrating {
+			return fmt.Errorf("topic %q uses the partitioned directory layout", name)
+		}
+		return nil
+	}
+	if len(files) == 0 && !migrating {
+		return nil
+	}
+	if partitioned {
+		return fmt.Errorf("topic %q has files in both the flat and the partitioned directory layout", name)
+	}
+
+	// ファイルを移行用のディレクトリに集めてから、パーティションのディレクトリにリネームする
+	tmp := filepath.Join(dir, migratingDir)
+	if err = os.MkdirAll(tmp, 0755); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err = os.Rename(filepath.Join(dir, f), filepath.Join(tmp, f)); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp, filepath.Join(dir, defaultPartition))
+}
@@ -0,0 +1,37 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockFile: ログディレクトリを排他的に開くためのロックファイルの名前
+const lockFile = "LOCK"
+
+// ErrLocked: ログディレクトリが別のプロセス（または同じプロセスの別の Log）で開かれている場合のエラー
+var ErrLocked = errors.New("log directory is locked by another process")
+
+// openExclusive: ログディレクトリのロックファイルに排他ロック（flock）を取得する（内部関数）
+// ロックはファイルを閉じるか、プロセスが終了すると解放される。
+// 引数:
+//   - dir: ログディレクトリ
+//
+// 戻り値:
+//   - *os.File: ロックを保持しているロックファイル（閉じるとロックが解放される）
+//   - error: 別のプロセスがロックを保持している場合（ErrLocked）
+func openExclusive(dir string) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, lockFile), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+	return f, nil
+}
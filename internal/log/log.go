@@ -1,13 +1,17 @@
 package log
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"path"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	api "github.com/kentakki416/proglog/api/v1"
 )
@@ -16,14 +20,34 @@ import (
 // ディスク容量が有限なため、ログを複数のセグメントに分割して管理する。
 // 各セグメントは baseOffset から始まる連続したオフセット範囲を担当し、
 // セグメントが最大サイズに達すると新しいセグメントが作成される。
+// セグメントを変更するのは Log のメソッドだけで、複数のプロセスから同じディレクトリに書き込むと
+// セグメントが壊れるため、ディレクトリは1つの Log でしか開けない（ロックファイルで排他する）。
 type Log struct {
 	mu sync.RWMutex // 読み書きロック（複数のgoroutineからの同時アクセス制御）
 
 	Dir    string // セグメントファイルを保存するディレクトリ
 	Config Config // ログストアの設定（セグメントの最大サイズなど）
 
-	activeSegment *segment   // 現在書き込み中のセグメント（最新のセグメント）
-	segments      []*segment // すべてのセグメント（baseOffset の昇順でソートされている）
+	activeSegment *writableSegment         // 現在書き込み中のセグメント（最新のセグメント、それ以外は読み取り専用）
+	segments      []*segment               // すべてのセグメント（baseOffset の昇順でソートされている）
+	epoch         uint64                   // オフセットのエポック（Reset のたびにインクリメントされる）
+	unhealthy     error                    // オフセットの不変条件が破られた場合のエラー（設定されると書き込みを拒否する）
+	appended      chan struct{}            // 次の書き込みで閉じられるチャネル（新しいレコードを待つ読み取り側に通知するため）
+	producers     map[string]producerState // プロデューサーごとの重複排除の状態
+	lock          *os.File                 // ディレクトリの排他ロックを保持しているロックファイル
+	closed        chan struct{}            // Close で閉じられるチャネル（購読を終了させるため）
+	closeOnce     sync.Once
+	diskFull      atomic.Pointer[api.ErrDiskFull] // ディスクが一杯で読み取り専用になっている場合のエラー
+	records       atomic.Int64                    // 保持しているレコード数（Size で返す）
+	bytes         atomic.Int64                    // ストアファイルの合計バイト数（Size で返す）
+	storeBytes    uint64                          // 新しく作成するセグメントのストアの最大バイト数（Adaptive で調整する）
+
+	flushDone chan struct{}  // 定期的なフラッシュを停止するためのチャネル
+	flushWG   sync.WaitGroup // 定期的なフラッシュを行うゴルーチンの終了待ち
+	rollDone  chan struct{}  // 経過時間によるセグメントの切り替えを停止するためのチャネル
+	rollWG    sync.WaitGroup // 経過時間によるセグメントの切り替えを行うゴルーチンの終了待ち
+	scrubDone chan struct{}  // 定期的なスクラブを停止するためのチャネル
+	scrubWG   sync.WaitGroup // 定期的なスクラブを行うゴルーチンの終了待ち
 }
 
 // NewLog: 新しいログストアを作成または既存のログストアを開く
@@ -36,78 +60,342 @@ type Log struct {
 //   - *Log: 初期化されたログストア構造体
 //   - error: エラーが発生した場合
 func NewLog(dir string, c Config) (*Log, error) {
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid log config: %w", err)
+	}
 	// デフォルト値の設定（設定が指定されていない場合）
 	if c.Segment.MaxStoreBytes == 0 {
 		c.Segment.MaxStoreBytes = 1024 // デフォルト: 1KB
 	}
 	if c.Segment.MaxIndexBytes == 0 {
-		c.Segment.MaxIndexBytes = 1024 // デフォルト: 1KB
+		c.Segment.MaxIndexBytes = 1024 / entWidth * entWidth // デフォルト: 1KB に収まるエントリ数（85エントリ）
+	}
+	if c.Segment.ReadAheadBytes == 0 {
+		c.Segment.ReadAheadBytes = 64 * 1024 // デフォルト: 64KB
+	}
+	if c.Segment.MaxRecordBytes == 0 {
+		c.Segment.MaxRecordBytes = DefaultMaxRecordBytes
+	}
+	if c.Segment.Format == 0 {
+		c.Segment.Format = FormatV1 // デフォルト: 長さ情報を固定長で保存する（以前のバージョンと互換）
 	}
+	if a := &c.Segment.Adaptive; a.Enabled {
+		if a.MaxStoreBytes == 0 {
+			a.MaxStoreBytes = 16 * c.Segment.MaxStoreBytes
+		}
+		if a.TargetInterval == 0 {
+			a.TargetInterval = time.Minute
+		}
+		// 既存のインデックスを切り詰めないように、すべてのセグメントのインデックスを上限のサイズに合わせる
+		c.Segment.MaxIndexBytes *= (a.MaxStoreBytes + c.Segment.MaxStoreBytes - 1) / c.Segment.MaxStoreBytes
+	}
+	// Config は複数のログストアで使い回される（トピックなど）ため、カウンターは常に新しく作成する
+	c.writes = &writeStats{}
 	l := &Log{
-		Dir:    dir,
-		Config: c,
+		Dir:        dir,
+		Config:     c,
+		appended:   make(chan struct{}),
+		closed:     make(chan struct{}),
+		storeBytes: c.Segment.MaxStoreBytes,
 	}
 
 	// 既存のセグメントファイルを読み込んでセグメントを復元
-	return l, l.setup()
+	if err := l.setup(); err != nil {
+		l.unlock()
+		return nil, err
+	}
+
+	// Manager が開いたログストアは、定期的な処理を Manager の共有のゴルーチンで行う
+	if c.managed {
+		return l, nil
+	}
+	// アクティブセグメントのバッファを定期的にファイルに書き出す
+	l.startFlusher()
+	// アクティブセグメントを経過時間で切り替える
+	l.startRoller()
+	// 書き込み済みセグメントを定期的に検査する
+	l.startScrubber()
+	return l, nil
+}
+
+// startFlusher: アクティブセグメントのストアのバッファを定期的に書き出すゴルーチンを開始する（内部関数）
+// FlushInterval が設定されていない場合は何もしない。
+func (l *Log) startFlusher() {
+	if l.Config.Segment.FlushInterval == 0 {
+		return
+	}
+	l.flushDone = make(chan struct{})
+	Every(l.Config.clock(), l.Config.Segment.FlushInterval, l.flushDone, &l.flushWG, func(time.Time) {
+		l.flushActive()
+	})
+}
+
+// flushActive: アクティブセグメントのストアのバッファをファイルに書き出す（内部関数）
+// 失敗しても次の間隔で再試行する（書き込みや読み取りでもエラーとして検出される）。
+// ディスクが一杯の場合は読み取り専用にし、書き出せた場合は書き込みを再開する。
+func (l *Log) flushActive() {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if err := l.activeSegment.store.Flush(); err != nil {
+		_ = l.writeError(err)
+	} else {
+		l.diskFull.Store(nil)
+	}
+}
+
+// roll: 新しいアクティブセグメントを作成する（内部関数）
+// 引数:
+//   - off: 新しいセグメントの baseOffset
+//
+// 戻り値:
+//   - error: エラーが発生した場合
+func (l *Log) roll(off uint64) error {
+	if err := failpoint(FailpointSegmentRoll); err != nil {
+		return err
+	}
+	l.adaptSegmentSize()
+	if err := l.newSegment(off); err != nil {
+		return err
+	}
+	l.adviseIndexes()
+	// セグメントを切り替えるたびに重複排除の状態を保存し、再起動時に読み直すレコードを減らす
+	if err := l.writeProducers(); err != nil {
+		return err
+	}
+	l.Config.Hooks.onSegmentRoll(off)
+	return nil
+}
+
+// adaptSegmentSize: 切り替えるアクティブセグメントが一杯になるまでの時間から、次のセグメントのサイズを決める（内部関数）
+// 目標の間隔より短い時間で一杯になった場合は2倍にし、目標の間隔の4倍以上かかった場合は半分にする
+// （MaxAge で切り替えた場合など、一杯になっていなくても縮める）。Adaptive が無効の場合は何もしない。
+// 呼び出し側で l.mu のロックを取得しておく必要がある。
+func (l *Log) adaptSegmentSize() {
+	a := l.Config.Segment.Adaptive
+	s := l.activeSegment
+	if !a.Enabled || s == nil {
+		return
+	}
+	elapsed := l.Config.clock().Now().Sub(s.createdAt)
+	switch {
+	case s.IsMaxed() && elapsed < a.TargetInterval:
+		l.storeBytes = min(l.storeBytes*2, a.MaxStoreBytes)
+	case elapsed >= 4*a.TargetInterval:
+		l.storeBytes = max(l.storeBytes/2, l.Config.Segment.MaxStoreBytes)
+	}
+}
+
+// SegmentStoreBytes: 新しく作成するセグメントのストアの最大バイト数を返す
+// Adaptive が無効の場合は常に MaxStoreBytes を返す。
+func (l *Log) SegmentStoreBytes() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.storeBytes
+}
+
+// aged: アクティブセグメントが MaxAge に達しているかどうかを判定する（内部関数）
+// 空のセグメントは切り替えても意味がないため、経過時間にかかわらず false を返す。
+func (l *Log) aged() bool {
+	s := l.activeSegment
+	return l.Config.Segment.MaxAge > 0 &&
+		s.nextOffset > s.baseOffset &&
+		l.Config.clock().Now().Sub(s.createdAt) >= l.Config.Segment.MaxAge
+}
+
+// startRoller: アクティブセグメントを MaxAge ごとに切り替えるゴルーチンを開始する（内部関数）
+// 書き込みが少ないログでも、書き込みを待たずにセグメントを切り替えて保持期間の処理が進むようにする。
+// 経過時間は MaxAge の 1/4 ごとに確認するため、切り替えは最大で MaxAge の 1/4 遅れる。
+// MaxAge が設定されていない場合は何もしない。
+func (l *Log) startRoller() {
+	if l.Config.Segment.MaxAge == 0 {
+		return
+	}
+	l.rollDone = make(chan struct{})
+	Every(l.Config.clock(), max(l.Config.Segment.MaxAge/4, time.Millisecond), l.rollDone, &l.rollWG, func(time.Time) {
+		l.rollAged()
+	})
+}
+
+// rollAged: アクティブセグメントが MaxAge に達していれば切り替える（内部関数）
+// 失敗しても次の間隔で再試行する（書き込み時にも切り替えを試みる）。
+func (l *Log) rollAged() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.unhealthy == nil && l.aged() {
+		_ = l.roll(l.activeSegment.nextOffset)
+	}
+}
+
+// stopRoller: セグメントの切り替えを停止し、ゴルーチンの終了を待つ（内部関数）
+// ゴルーチンはロックを取得するため、ロックを取得する前に呼び出す必要がある。
+func (l *Log) stopRoller() {
+	if l.rollDone == nil {
+		return
+	}
+	close(l.rollDone)
+	l.rollDone = nil
+	l.rollWG.Wait()
+}
+
+// stopFlusher: 定期的なフラッシュを停止し、ゴルーチンの終了を待つ（内部関数）
+// ゴルーチンはロックを取得するため、ロックを取得する前に呼び出す必要がある。
+func (l *Log) stopFlusher() {
+	if l.flushDone == nil {
+		return
+	}
+	close(l.flushDone)
+	l.flushDone = nil
+	l.flushWG.Wait()
 }
 
 // setup: 既存のセグメントファイルを読み込んでセグメントを復元する
 // ディレクトリ内のファイル名から baseOffset を抽出し、セグメントを順番に開く。
-// 既存のセグメントがない場合は、InitialOffset から新しいセグメントを作成する。
+// 既存のセグメントがない場合は、マニフェストの InitialOffset から新しいセグメントを作成する。
 // 戻り値:
 //   - error: エラーが発生した場合
 func (l *Log) setup() error {
+	// 別のプロセスが同じディレクトリを開いていないことを保証する
+	if l.lock == nil {
+		lock, err := openExclusive(l.Dir)
+		if err != nil {
+			return err
+		}
+		l.lock = lock
+	}
+
+	// マニフェストを読み込み、オフセットのエポックを復元する
+	// マニフェストがない場合（新規ログストア、または以前のバージョンで作成されたログストア）は作成する
+	m, ok, err := readManifest(l.Dir)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		m = manifest{
+			InitialOffset: l.Config.Segment.InitialOffset,
+			Format:        l.Config.Segment.Format,
+		}
+		if err = writeManifest(l.Dir, m); err != nil {
+			return err
+		}
+	}
+	l.epoch = m.Epoch
+	// 既存のセグメントは作成時の保存形式で読み書きする
+	if m.Format == 0 {
+		m.Format = FormatV1
+	}
+	l.Config.Segment.Format = m.Format
+
+	// 保存形式のエンコードがこのバイナリで想定どおりであることを、既存のセグメントを開く前に確認する
+	if err = selfTest(l.Dir, l.Config); err != nil {
+		return err
+	}
+
+	// 分割の途中でクラッシュしたセグメントは、一時ファイルを削除する前に分割を完了する
+	if err = resumeSplits(l.Dir); err != nil {
+		return err
+	}
+
 	// ディレクトリ内のすべてのファイルを読み込む
 	files, err := os.ReadDir(l.Dir)
 	if err != nil {
 		return err
 	}
 
-	// ファイル名から baseOffset を抽出
+	// ストアファイルのファイル名から baseOffset を抽出
 	// ファイル名の形式: "{baseOffset}.store" または "{baseOffset}.index"
 	// 例: "0.store", "0.index", "1000.store", "1000.index"
+	// セグメントのファイルはインデックス、ストアの順に作成するため、ストアファイルがあるセグメントだけを開く。
+	// ストアファイルのないインデックスファイルと一時ファイルは、セグメントの作成中にクラッシュした残りなので削除する。
 	var baseOffsets []uint64
+	indexes := make(map[uint64]string)
 	for _, file := range files {
-		// ファイル名から拡張子を除いた部分を取得（例: "0.store" → "0"）
-		offStr := strings.TrimSuffix(
-			file.Name(),
-			path.Ext(file.Name()),
-		)
-		// 文字列を数値に変換（例: "0" → 0, "1000" → 1000）
-		off, _ := strconv.ParseUint(offStr, 10, 0)
+		name := file.Name()
+		if strings.HasSuffix(name, ".store.tmp") || strings.HasSuffix(name, ".index.tmp") || strings.HasSuffix(name, scrubExt+".tmp") || strings.HasSuffix(name, timersExt+".tmp") || strings.HasSuffix(name, splitExt+".tmp") {
+			if err = os.Remove(filepath.Join(l.Dir, name)); err != nil {
+				return err
+			}
+			continue
+		}
+		// 自己診断の途中でクラッシュした場合の一時ディレクトリも削除する
+		if file.IsDir() && strings.HasPrefix(name, selfTestDir) {
+			if err = os.RemoveAll(filepath.Join(l.Dir, name)); err != nil {
+				return err
+			}
+			continue
+		}
+		// セグメントファイル以外（マニフェストなど）は無視する
+		ext := path.Ext(name)
+		if ext != ".store" && ext != ".index" {
+			continue
+		}
+		// 文字列を数値に変換（例: "0.store" → 0, "1000.store" → 1000）
+		off, err := strconv.ParseUint(strings.TrimSuffix(name, ext), 10, 0)
+		if err != nil {
+			continue
+		}
+		if ext == ".index" {
+			indexes[off] = name
+			continue
+		}
 		baseOffsets = append(baseOffsets, off)
 	}
+	for _, off := range baseOffsets {
+		delete(indexes, off)
+	}
+	for _, name := range indexes {
+		if err = os.Remove(filepath.Join(l.Dir, name)); err != nil {
+			return err
+		}
+	}
 
 	// baseOffset を昇順にソート（セグメントを順番に処理するため）
 	sort.Slice(baseOffsets, func(i, j int) bool {
 		return baseOffsets[i] < baseOffsets[j]
 	})
 
-	// 各 baseOffset に対してセグメントを作成
-	// 注意: 同じ baseOffset に対して ".store" と ".index" の2つのファイルが存在するため、
-	// 重複を避けるために i++ でスキップする
-	for i := 0; i < len(baseOffsets); i++ {
-		if err = l.newSegment(baseOffsets[i]); err != nil {
+	// 各 baseOffset に対してセグメントを開く
+	for _, off := range baseOffsets {
+		if err = l.newSegment(off); err != nil {
 			return err
 		}
-		// baseOffset は index と store の両方で重複しているため、重複をスキップ
-		i++
 	}
 
-	// 既存のセグメントがない場合（新規ログストア）、InitialOffset から新しいセグメントを作成
+	// 既存のセグメントがない場合（新規ログストア、または ResetAt の直後）、マニフェストの InitialOffset から新しいセグメントを作成
 	if l.segments == nil {
 		if err = l.newSegment(
-			l.Config.Segment.InitialOffset,
+			m.InitialOffset,
 		); err != nil {
 			return err
 		}
 	}
-	return nil
+
+	l.adviseIndexes()
+
+	// 再起動後も冪等な書き込みを保証するため、プロデューサーの重複排除の状態を復元する
+	return l.loadProducers()
+}
+
+// adviseIndexes: Segment.IndexHints に従って、セグメントのインデックスのメモリマップに madvise のヒントを適用する（内部関数）
+// 呼び出し側で l.mu のロックを取得しておく必要がある（setup の場合を除く）。
+func (l *Log) adviseIndexes() {
+	h := l.Config.Segment.IndexHints
+	if h.Warm == 0 && h.ColdAfter == 0 {
+		return
+	}
+	for i, s := range l.segments {
+		// 新しい順の順位（アクティブセグメントが 0）
+		switch rank := len(l.segments) - 1 - i; {
+		case rank < h.Warm:
+			s.index.warm()
+		case h.ColdAfter > 0 && rank >= h.ColdAfter:
+			s.index.cold()
+		}
+	}
 }
 
 // Append: レコードをログストアに追加する
 // アクティブセグメントが最大サイズに達している場合は、新しいセグメントを作成してから追加する。
+// プロデューサー ID 付きのレコードは、同じプロデューサーのシーケンス番号が直前と同じ場合は再送とみなして追加しない。
 // プロセス:
 //  1. 現在の最高オフセットを取得
 //  2. アクティブセグメントが最大サイズに達している場合、新しいセグメントを作成
@@ -122,6 +410,45 @@ func (l *Log) setup() error {
 func (l *Log) Append(record *api.Record) (uint64, error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	return l.append(record)
+}
+
+// AppendAt: 指定されたオフセットにレコードを追加する
+// 複製など、オフセットが別のノードで割り当て済みのレコードを同じオフセットで追加するために使用する。
+// オフセットが次に割り当てられるオフセットと一致しない場合は追加しない。
+// 引数:
+//   - record: 追加するレコード
+//   - off: レコードに割り当てるオフセット
+//
+// 戻り値:
+//   - uint64: 割り当てられたオフセット（off と同じ）
+//   - error: オフセットが一致しない場合は api.ErrOffsetMismatch、その他のエラーが発生した場合はそのエラー
+func (l *Log) AppendAt(record *api.Record, off uint64) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if next := l.activeSegment.nextOffset; next != off {
+		return 0, api.ErrOffsetMismatch{Expected: next, Actual: off}
+	}
+	return l.append(record)
+}
+
+// append: レコードをアクティブセグメントに追加する（内部関数）
+// 呼び出し側で l.mu のロックを取得しておく必要がある。
+func (l *Log) append(record *api.Record) (uint64, error) {
+	// オフセットの不変条件が破られたログには書き込まない
+	if l.unhealthy != nil {
+		return 0, l.unhealthy
+	}
+	// ディスクが一杯の間は書き込まない
+	if err := l.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	// 同じプロデューサーが再送したレコードは追加せず、最初に追加したときのオフセットを返す
+	if off, dup, err := l.checkProducer(record); err != nil || dup {
+		record.Offset = off
+		return off, err
+	}
 
 	// 現在の最高オフセットを取得（新しいセグメントの baseOffset を決定するため）
 	highestOffset, err := l.highestOffset()
@@ -129,24 +456,174 @@ func (l *Log) Append(record *api.Record) (uint64, error) {
 		return 0, err
 	}
 
-	// アクティブセグメントが最大サイズに達している場合、新しいセグメントを作成
+	// 次に割り当てられるべきオフセット（ギャップを検出するため）
+	expected := l.activeSegment.nextOffset
+
+	// アクティブセグメントが最大サイズまたは最大経過時間に達している場合、新しいセグメントを作成
 	// 新しいセグメントの baseOffset は、現在の最高オフセット + 1
 	// 例: 現在の最高オフセットが 999 の場合、新しいセグメントの baseOffset は 1000
-	if l.activeSegment.IsMaxed() {
-		err = l.newSegment(highestOffset + 1)
-		if err != nil {
-			return 0, err
+	if l.activeSegment.IsMaxed() || l.aged() {
+		if err = l.roll(highestOffset + 1); err != nil {
+			return 0, l.writeError(err)
 		}
 	}
 
 	// アクティブセグメントにレコードを追加
+	size := l.activeSegment.store.size
 	off, err := l.activeSegment.Append(record)
 	if err != nil {
+		return 0, l.writeError(err)
+	}
+	// 保持しているレコード数とバイト数を更新する
+	l.records.Add(1)
+	l.bytes.Add(int64(l.activeSegment.store.size - size))
+
+	// オフセットが直前のオフセットから連続していることを確認
+	if err = l.checkOffset(expected, off); err != nil {
 		return 0, err
 	}
+
+	l.trackProducer(record)
+
+	// 新しいレコードを待っている読み取り側に通知する
+	close(l.appended)
+	l.appended = make(chan struct{})
+	l.Config.Hooks.onAppend(record, off)
 	return off, err
 }
 
+// AppendBatch: 複数のレコードを1つのバッチとしてログストアに追加する
+// バッチはストアに1回で書き込まれ、すべてのレコードが同じセグメントに連続したオフセットで追加される
+// （一部のレコードだけが追加されることはない）。レコードごとに書き込むよりも長さ情報のオーバーヘッドが小さく、
+// 順次読み取りも効率がよい。バッチ全体のバイト数は MaxRecordBytes 以下である必要がある。
+// プロデューサー ID 付きのレコードは、バッチの最後のレコードが直前に追加したレコードと同じ場合は
+// バッチ全体の再送とみなして追加しない。
+// 引数:
+//   - records: 追加するレコード（Offset フィールドは自動設定される）
+//
+// 戻り値:
+//   - uint64: 先頭のレコードに割り当てられたオフセット（続くレコードには連続したオフセットが割り当てられる）
+//   - error: エラーが発生した場合
+func (l *Log) AppendBatch(records []*api.Record) (uint64, error) {
+	if len(records) == 0 {
+		return 0, ErrEmptyBatch
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// オフセットの不変条件が破られたログには書き込まない
+	if l.unhealthy != nil {
+		return 0, l.unhealthy
+	}
+	// ディスクが一杯の間は書き込まない
+	if err := l.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	// 同じプロデューサーが再送したバッチは追加せず、最初に追加したときのオフセットを返す
+	n := uint64(len(records))
+	if off, dup, err := l.checkProducer(records[n-1]); err == nil && dup {
+		for i, record := range records {
+			record.Offset = off - (n - 1) + uint64(i)
+		}
+		return records[0].Offset, nil
+	}
+	for _, record := range records {
+		if _, dup, err := l.checkProducer(record); err != nil {
+			return 0, err
+		} else if dup {
+			// バッチの途中までが追加済みになることはないため、部分的な再送は順序の誤りとして扱う
+			p := l.producers[record.ProducerId]
+			return 0, api.ErrOutOfOrderSequence{
+				ProducerId: record.ProducerId,
+				Sequence:   record.Sequence,
+				Last:       p.Sequence,
+			}
+		}
+	}
+
+	highestOffset, err := l.highestOffset()
+	if err != nil {
+		return 0, err
+	}
+	expected := l.activeSegment.nextOffset
+
+	// アクティブセグメントが最大サイズまたは最大経過時間に達している場合、
+	// またはバッチのすべてのレコードをインデックスに記録できない場合は、新しいセグメントを作成
+	if l.activeSegment.IsMaxed() || l.aged() ||
+		(!l.activeSegment.index.hasRoom(n) && l.activeSegment.nextOffset > l.activeSegment.baseOffset) {
+		if err = l.roll(highestOffset + 1); err != nil {
+			return 0, l.writeError(err)
+		}
+	}
+	// 空のセグメントにも収まらないバッチは追加できない
+	if !l.activeSegment.index.hasRoom(n) {
+		return 0, ErrRecordTooLarge
+	}
+
+	size := l.activeSegment.store.size
+	off, err := l.activeSegment.AppendBatch(records)
+	if err != nil {
+		return 0, l.writeError(err)
+	}
+	l.records.Add(int64(n))
+	l.bytes.Add(int64(l.activeSegment.store.size - size))
+	if err = l.checkOffset(expected, off); err != nil {
+		return 0, err
+	}
+
+	for _, record := range records {
+		l.trackProducer(record)
+	}
+
+	// 新しいレコードを待っている読み取り側に通知する
+	close(l.appended)
+	l.appended = make(chan struct{})
+	for _, record := range records {
+		l.Config.Hooks.onAppend(record, record.Offset)
+	}
+	return off, nil
+}
+
+// Appended: 次にレコードが追加されたときに閉じられるチャネルを返す
+// 新しいレコードを待つ読み取り側（ロングポーリングなど）は、読み取りの前にチャネルを取得しておき、
+// レコードが見つからなければチャネルが閉じられるまで待つ。
+// 戻り値:
+//   - <-chan struct{}: 次の書き込みで閉じられるチャネル
+func (l *Log) Appended() <-chan struct{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.appended
+}
+
+// checkOffset: オフセットが期待通り連続しているかを確認する（内部関数）
+// 連続していない場合はログを異常状態にして、以降の書き込みを拒否する。
+// 呼び出し側で l.mu のロックを取得しておく必要がある。
+// 引数:
+//   - expected: 期待されるオフセット（直前のオフセット + 1）
+//   - actual: 実際に割り当てられたオフセット
+//
+// 戻り値:
+//   - error: 連続していない場合（api.ErrOffsetGap）
+func (l *Log) checkOffset(expected, actual uint64) error {
+	if expected == actual {
+		return nil
+	}
+	l.unhealthy = api.ErrOffsetGap{Expected: expected, Actual: actual}
+	return l.unhealthy
+}
+
+// Healthy: ログが正常な状態かを確認する
+// オフセットの不変条件が破られている場合、その原因のエラーを返す。
+// 戻り値:
+//   - error: 異常状態の場合のエラー（正常な場合は nil）
+func (l *Log) Healthy() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.unhealthy
+}
+
 // Read: 指定されたオフセットのレコードを読み取る
 // 指定されたオフセットが含まれるセグメントを検索し、そのセグメントからレコードを読み取る。
 // 引数:
@@ -160,40 +637,134 @@ func (l *Log) Read(off uint64) (*api.Record, error) {
 	defer l.mu.RUnlock()
 
 	// 指定されたオフセットが含まれるセグメントを検索
-	// 条件: segment.baseOffset <= off < segment.nextOffset
-	// 例: baseOffset = 1000, nextOffset = 2000 の場合、1000 <= off < 2000 の範囲を担当
-	var s *segment
-	for _, segment := range l.segments {
-		if segment.baseOffset <= off && off < segment.nextOffset {
-			s = segment
-			break
-		}
-	}
+	s := l.findSegment(off)
 
 	// 該当するセグメントが見つからない場合、エラーを返す
-	if s == nil || s.nextOffset <= off {
+	if s == nil {
 		return nil, api.ErrOffsetOutOfRange{Offset: off}
 	}
+	// 隔離中のセグメントは、壊れたデータを返さないように読み取らない
+	if s.quarantine != nil {
+		return nil, *s.quarantine
+	}
 
 	// セグメントからレコードを読み取る
 	return s.Read(off)
 }
 
+// Locate: 指定されたオフセットのレコードが保存されている場所を返す
+// 外部のインデックスを作成したり、レコードの配置を調べたりするために使用する。
+// 引数:
+//   - off: 検索するオフセット
+//
+// 戻り値:
+//   - uint64: レコードを含むセグメントの baseOffset
+//   - uint64: セグメントのストアファイル内のバイト位置（バッチの場合はバッチの先頭の位置）
+//   - error: オフセットが範囲外の場合（api.ErrOffsetOutOfRange）
+func (l *Log) Locate(off uint64) (uint64, uint64, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	s := l.findSegment(off)
+	if s == nil {
+		return 0, 0, api.ErrOffsetOutOfRange{Offset: off}
+	}
+	_, pos, err := s.index.Read(int64(off - s.baseOffset))
+	if err != nil {
+		return 0, 0, err
+	}
+	return s.baseOffset, pos, nil
+}
+
+// findSegment: 指定されたオフセットが含まれるセグメントを検索する（内部関数）
+// 呼び出し側で l.mu のロックを取得しておく必要がある。
+// 条件: segment.baseOffset <= off < segment.nextOffset
+// 例: baseOffset = 1000, nextOffset = 2000 の場合、1000 <= off < 2000 の範囲を担当
+// 引数:
+//   - off: 検索するオフセット（絶対オフセット）
+//
+// 戻り値:
+//   - *segment: 該当するセグメント（見つからない場合は nil）
+func (l *Log) findSegment(off uint64) *segment {
+	for _, segment := range l.segments {
+		if segment.baseOffset <= off && off < segment.nextOffset {
+			return segment
+		}
+	}
+	return nil
+}
+
+// Flush: すべてのセグメントのデータをディスクに同期する
+// アクティブセグメントのストアのバッファを書き出し、ストアとインデックスのメモリマップを同期する。
+// 同期している間は書き込みを止めるため、Flush から戻った時点でそれまでに追加したレコードはすべてディスクにある。
+// プロセスを止めずに、ファイルシステムのスナップショットやバックアップを一貫した状態で作成するために使用する。
+// 戻り値:
+//   - error: エラーが発生した場合（ディスクが一杯の場合は api.ErrDiskFull）
+func (l *Log) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.segments {
+		if err := s.store.Sync(); err != nil {
+			return l.writeError(err)
+		}
+		if err := s.index.Sync(); err != nil {
+			return l.writeError(err)
+		}
+		if s.timersFile != nil {
+			if err := s.timersFile.Sync(); err != nil {
+				return l.writeError(err)
+			}
+		}
+	}
+	// 書き出せた場合は、ディスクが一杯で読み取り専用になっていても書き込みを再開する
+	l.diskFull.Store(nil)
+	// 再起動時と同じ重複排除の状態をスナップショットに含める
+	return l.writeProducers()
+}
+
 // Close: ログストアを閉じてリソースをクリーンアップ
 // すべてのセグメントを閉じる（メモリマップの同期、ファイルのクローズなど）。
 // 戻り値:
 //   - error: エラーが発生した場合
 func (l *Log) Close() error {
+	// 購読を終了させる（ResetAt の中で閉じる場合は、リセット後も購読を続けられるように終了させない）
+	l.closeOnce.Do(func() { close(l.closed) })
+	return l.closeSegments()
+}
+
+// closeSegments: 定期的な処理を停止し、すべてのセグメントを閉じてディレクトリのロックを解放する（内部関数）
+func (l *Log) closeSegments() error {
+	// 定期的なフラッシュ、セグメントの切り替え、スクラブを停止（セグメントを閉じた後に読み書きしないように）
+	l.stopFlusher()
+	l.stopRoller()
+	l.stopScrubber()
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	// すべてのセグメントを閉じる
+	// 重複排除の状態を保存する
+	err := l.writeProducers()
+
+	// すべてのセグメントを閉じる（失敗しても残りのセグメントは閉じる）
 	for _, segment := range l.segments {
-		if err := segment.Close(); err != nil {
-			return err
+		if cerr := segment.Close(); cerr != nil && err == nil {
+			err = cerr
 		}
 	}
-	return nil
+	// 他の Log がディレクトリを開けるように、失敗した場合もロックを解放する
+	if uerr := l.unlock(); err == nil {
+		err = uerr
+	}
+	return err
+}
+
+// unlock: ディレクトリの排他ロックを解放する（内部関数）
+func (l *Log) unlock() error {
+	if l.lock == nil {
+		return nil
+	}
+	err := l.lock.Close()
+	l.lock = nil
+	return err
 }
 
 // Remove: ログストアを削除する
@@ -210,16 +781,101 @@ func (l *Log) Remove() error {
 }
 
 // Reset: ログストアをリセットする
-// すべてのセグメントを削除した後、新規ログストアとして初期化する。
+// すべてのセグメントを削除した後、設定の InitialOffset（Config.Segment.InitialOffset）から始まる新規ログストアとして初期化する。
 // 戻り値:
 //   - error: エラーが発生した場合
 func (l *Log) Reset() error {
-	// すべてのセグメントを削除
-	if err := l.Remove(); err != nil {
+	return l.ResetAt(l.Config.Segment.InitialOffset)
+}
+
+// ResetAt: 指定されたオフセットから始まるログストアとしてリセットする
+// 別クラスタのオフセット番号に合わせる場合などに使用する。
+// リセットのたびにオフセットのエポックをインクリメントしてマニフェストに記録するため、
+// コンシューマーはエポックの変化からリセットを検知できる。
+// 引数:
+//   - offset: リセット後に最初のレコードへ割り当てるオフセット
+//
+// 戻り値:
+//   - error: エラーが発生した場合
+func (l *Log) ResetAt(offset uint64) error {
+	// 定期的なフラッシュ、セグメントの切り替え、スクラブを停止（ゴルーチンはロックを取得するため、ロックを取得する前に停止する）
+	l.stopFlusher()
+	l.stopRoller()
+	l.stopScrubber()
+
+	// リセットの間に読み書きされないように、リセットが終わるまでロックを保持する
+	// （Remove と異なり、購読は終了させずにリセット後のレコードを待たせる）
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// すべてのセグメントを閉じ、削除したセグメントへの参照を破棄
+	var err error
+	for _, segment := range l.segments {
+		if cerr := segment.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	l.segments = nil
+	l.activeSegment = nil
+	l.records.Store(0)
+	l.bytes.Store(0)
+	if err != nil {
+		return err
+	}
+
+	// 別の Log や別のプロセスがディレクトリを開けないように、ディレクトリとそのロックは残したまま、
+	// ロックファイル以外のファイル（セグメント、マニフェスト、重複排除の状態など）を削除する
+	entries, err := os.ReadDir(l.Dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Name() == lockFile {
+			continue
+		}
+		if err = os.RemoveAll(filepath.Join(l.Dir, e.Name())); err != nil {
+			return err
+		}
+	}
+
+	// 新しいエポックでマニフェストを作成（setup はマニフェストの InitialOffset から最初のセグメントを作成する）
+	if err = writeManifest(l.Dir, manifest{
+		Epoch:         l.epoch + 1,
+		InitialOffset: offset,
+		Format:        l.Config.Segment.Format,
+	}); err != nil {
 		return err
 	}
+
 	// 新規ログストアとして初期化
-	return l.setup()
+	if err = l.setup(); err != nil {
+		return err
+	}
+
+	// 停止した定期的なフラッシュ、セグメントの切り替え、スクラブを再開する
+	// （Manager が開いたログストアは Manager の共有のゴルーチンで行うため、NewLog と同じく開始しない）
+	if !l.Config.managed {
+		l.startFlusher()
+		l.startRoller()
+		l.startScrubber()
+	}
+	return nil
+}
+
+// Epoch: オフセットのエポックを取得する
+// ログストアが Reset されるたびにインクリメントされる。
+// 戻り値:
+//   - uint64: 現在のエポック
+func (l *Log) Epoch() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.epoch
+}
+
+// MaxRecordBytes: 追加できる1レコードの最大バイト数を返す（長さ情報を除く）
+// クライアントが書き込む前に上限を知り、ErrRecordTooLarge で失敗しないようにするために使用する。
+func (l *Log) MaxRecordBytes() uint64 {
+	return l.Config.Segment.MaxRecordBytes
 }
 
 // LowestOffset: ログストア内の最小オフセットを取得する
@@ -246,6 +902,16 @@ func (l *Log) HighestOffset() (uint64, error) {
 	return l.highestOffset()
 }
 
+// NextOffset: 次に追加するレコードに割り当てられるオフセットを返す
+// HighestOffset と異なり、空のログストアと1つのレコードだけを持つログストアを区別できる。
+// 戻り値:
+//   - uint64: 次に追加するレコードのオフセット（最後のセグメントの nextOffset）
+func (l *Log) NextOffset() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.segments[len(l.segments)-1].nextOffset
+}
+
 // highestOffset: ログストア内の最大オフセットを計算する（内部関数）
 // 最後のセグメントの nextOffset - 1 を返す。
 // nextOffset は次のレコード用のオフセットなので、実際の最後のレコードのオフセットは -1 する必要がある。
@@ -267,6 +933,7 @@ func (l *Log) highestOffset() (uint64, error) {
 // Truncate: 指定されたオフセットより前のセグメントを削除する
 // ログのローテーションや古いデータの削除に使用される。
 // 指定されたオフセット（lowest）より前のすべてのレコードを含むセグメントを削除する。
+// ただし、書き込み中のセグメント（アクティブセグメント）は削除しない。
 // 引数:
 //   - lowest: 保持する最小オフセット（このオフセットより前のセグメントを削除）
 //
@@ -278,14 +945,18 @@ func (l *Log) Truncate(lowest uint64) error {
 
 	// 保持するセグメントのリスト
 	var segments []*segment
+	removed := false
 	for _, s := range l.segments {
-		// セグメントの nextOffset が lowest + 1 以下の場合、そのセグメントを削除
+		// セグメントの nextOffset が lowest + 1 以下の場合、そのセグメントを削除（アクティブセグメントを除く）
 		// 例: lowest = 1000 の場合、nextOffset <= 1001 のセグメントを削除
 		//     （nextOffset = 1001 は、最後のレコードのオフセットが 1000 を意味する）
-		if s.nextOffset <= lowest+1 {
-			if err := s.Remove(); err != nil {
+		// 読み取り中の Reader などがある場合、セグメントの削除は最後の読み取りが終わるまで遅らせる
+		if s != l.activeSegment.segment && s.nextOffset <= lowest+1 {
+			l.subSize(s)
+			if err := s.release(); err != nil {
 				return err
 			}
+			removed = true
 			continue
 		}
 		// 保持するセグメントをリストに追加
@@ -293,36 +964,68 @@ func (l *Log) Truncate(lowest uint64) error {
 	}
 	// 保持するセグメントのリストで更新
 	l.segments = segments
+	if removed {
+		l.Config.Hooks.onTruncate(l.segments[0].baseOffset)
+	}
 	return nil
 }
 
-// Reader: すべてのセグメントを順番に読み取る Reader を返す
+// Reader: 呼び出した時点のすべてのセグメントを順番に読み取る Reader を返す（スナップショット）
 // ログストア全体をストリームとして読み取る場合に使用される。
-// すべてのセグメントのストアを順番に結合した Reader を返す。
+// 呼び出した時点の各セグメントのストアのサイズまでを読み取り、その後に追加されたレコードやセグメントは含まない。
+// 読み取り中にセグメントが切り替わったり、Truncate でセグメントが削除されたりしても、読み取りが終わるまでセグメントのファイルは残る。
+// 各セグメントの参照は末尾まで読み取った時点で外れるが、途中で読み取りをやめる場合は Close を呼び出す必要がある。
+// 追加されるレコードを待ちながら読み続ける場合は FollowReader を使用する。
+// 転送中の破損を検出する必要がある場合は ChecksumReader を使用する。
 // 戻り値:
-//   - io.Reader: すべてのセグメントを順番に読み取る Reader
-func (l *Log) Reader() io.Reader {
+//   - io.ReadCloser: すべてのセグメントを順番に読み取る Reader
+func (l *Log) Reader() io.ReadCloser {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
 	// すべてのセグメントのストアから Reader を作成
+	// 書き込みは l.mu のロックを取得するため、ここで読み取ったストアのサイズはレコードの境界になる
+	origins := make([]*originReader, len(l.segments))
 	readers := make([]io.Reader, len(l.segments))
 	for i, segment := range l.segments {
-		readers[i] = &originReader{segment.store, 0}
+		segment.acquire()
+		origins[i] = &originReader{store: segment.store, limit: int64(segment.store.size), seg: segment}
+		readers[i] = origins[i]
 	}
 	// 複数の Reader を順番に結合した Reader を返す
-	return io.MultiReader(readers...)
+	return &logReader{Reader: io.MultiReader(readers...), origins: origins}
+}
+
+// logReader: すべてのセグメントを順番に読み取る Reader（Close でセグメントの参照を外す）
+type logReader struct {
+	io.Reader
+	origins []*originReader
+}
+
+// Close: まだ読み取りが終わっていないセグメントの参照を外す
+func (r *logReader) Close() error {
+	var err error
+	for _, o := range r.origins {
+		if rerr := o.release(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	return err
 }
 
 // originReader: ストアから順番に読み取る Reader
 // ストアファイルの先頭から順番に読み取るための Reader 実装。
 type originReader struct {
-	*store       // ストアファイル
-	off    int64 // 現在の読み取り位置（バイト位置）
+	*store             // ストアファイル
+	off      int64     // 現在の読み取り位置（バイト位置）
+	limit    int64     // 読み取るストアファイルのサイズ（Reader を作成した時点のサイズ）
+	seg      *segment  // 参照を取得したセグメント
+	released sync.Once // セグメントの参照を一度だけ外すため
 }
 
 // Read: ストアからデータを読み取る
 // io.Reader インターフェースの実装。
+// 末尾（Reader を作成した時点のサイズ）まで読み取った場合（またはエラーの場合）は、セグメントの参照を外す。
 // 引数:
 //   - p: 読み取ったデータを格納するバッファ
 //
@@ -330,16 +1033,34 @@ type originReader struct {
 //   - int: 読み取ったバイト数
 //   - error: エラーが発生した場合
 func (o *originReader) Read(p []byte) (int, error) {
+	if o.off >= o.limit {
+		_ = o.release()
+		return 0, io.EOF
+	}
+	if int64(len(p)) > o.limit-o.off {
+		p = p[:o.limit-o.off]
+	}
 	// 現在の位置からデータを読み取る
 	n, err := o.ReadAt(p, o.off)
 	// 読み取り位置を進める
 	o.off += int64(n)
+	if err != nil {
+		// Truncate 後のセグメントの削除に失敗しても、この Reader の読み取りには影響しないためエラーは無視する
+		_ = o.release()
+	}
 	return n, err
 }
 
+// release: セグメントの参照を外す（2回目以降の呼び出しは何もしない）
+func (o *originReader) release() error {
+	var err error
+	o.released.Do(func() { err = o.seg.release() })
+	return err
+}
+
 // newSegment: 新しいセグメントを作成してログストアに追加する
 // 指定された baseOffset で新しいセグメントを作成し、セグメントリストに追加する。
-// 新しく作成されたセグメントがアクティブセグメントになる。
+// 新しく作成されたセグメントがアクティブセグメントになり、それまでのアクティブセグメントは読み取り専用にする。
 // 引数:
 //   - off: 新しいセグメントの baseOffset
 //
@@ -347,12 +1068,22 @@ func (o *originReader) Read(p []byte) (int, error) {
 //   - error: エラーが発生した場合
 func (l *Log) newSegment(off uint64) error {
 	// 新しいセグメントを作成
-	s, err := newSegment(l.Dir, off, l.Config)
+	c := l.Config
+	c.Segment.MaxStoreBytes = l.storeBytes
+	s, err := newWritableSegment(l.Dir, off, c)
 	if err != nil {
 		return err
 	}
-	// セグメントリストに追加
-	l.segments = append(l.segments, s)
+	// それまでのアクティブセグメントは書き込み済みになるため、ファイルを読み取り専用にする
+	if l.activeSegment != nil {
+		if err := l.activeSegment.seal(); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	// セグメントリストに追加（既存のセグメントを開いた場合は、含まれるレコードを合計に加える）
+	l.segments = append(l.segments, s.segment)
+	l.addSize(s.segment)
 	// 新しく作成されたセグメントをアクティブセグメントに設定
 	l.activeSegment = s
 	return nil
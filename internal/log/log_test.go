@@ -1,12 +1,19 @@
 package log
 
 import (
+	"bytes"
 	"io"
 	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
 	api "github.com/kentakki416/proglog/api/v1"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -15,10 +22,26 @@ func TestLog(t *testing.T) {
 		t *testing.T, log *Log,
 	){
 		"append and read a record succeeds": testAppendRead,
+		"append at offset":                  testAppendAt,
 		"offset out of range error":         testOutOfRangeErr,
 		"init with existing segments":       testInitExisting,
 		"reader":                            testReader,
 		"truncate":                          testTruncate,
+		"sequential reader":                 testSequentialReader,
+		"reset at offset":                   testResetAt,
+		"install sealed segments":           testInstallSegment,
+		"install segment with offset gap":   testInstallSegmentGap,
+		"split segment":                     testSplitSegment,
+		"sealed segments are read-only":     testSealedSegments,
+		"segment stats":                     testSegmentStats,
+		"idempotent produce":                testIdempotentProduce,
+		"append batch":                      testAppendBatch,
+		"locate":                            testLocate,
+		"flush":                             testFlush,
+		"truncate during read":              testTruncateDuringRead,
+		"size":                              testSize,
+		"write stats":                       testWriteStats,
+		"delayed records":                   testDelayedRecords,
 	} {
 		t.Run(scenario, func(t *testing.T) {
 			dir, err := os.MkdirTemp("", "store-test")
@@ -49,6 +72,22 @@ func testAppendRead(t *testing.T, log *Log) {
 	require.NoError(t, log.Close())
 }
 
+func testAppendAt(t *testing.T, log *Log) {
+	off, err := log.AppendAt(&api.Record{Value: []byte("first")}, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), off)
+
+	// 次に割り当てられるオフセットと異なる場合は追加しない
+	_, err = log.AppendAt(&api.Record{Value: []byte("gap")}, 2)
+	require.Equal(t, api.ErrOffsetMismatch{Expected: 1, Actual: 2}, err)
+	require.Equal(t, uint64(1), log.NextOffset())
+	require.NoError(t, log.Healthy())
+
+	off, err = log.AppendAt(&api.Record{Value: []byte("second")}, 1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), off)
+}
+
 func testOutOfRangeErr(t *testing.T, log *Log) {
 	read, err := log.Read(1)
 	require.Nil(t, read)
@@ -120,3 +159,1139 @@ func testTruncate(t *testing.T, log *Log) {
 	require.Error(t, err)
 	require.NoError(t, log.Close())
 }
+
+func testSequentialReader(t *testing.T, log *Log) {
+	append := &api.Record{
+		Value: []byte("hello world"),
+	}
+	// MaxStoreBytes = 32 なので、複数のセグメントにまたがる
+	for i := 0; i < 5; i++ {
+		_, err := log.Append(append)
+		require.NoError(t, err)
+	}
+	require.True(t, len(log.segments) > 1)
+
+	r := log.NewSequentialReader()
+	for i := uint64(0); i < 5; i++ {
+		read, err := r.Read(i)
+		require.NoError(t, err)
+		require.Equal(t, append.Value, read.Value)
+		require.Equal(t, i, read.Offset)
+	}
+
+	_, err := r.Read(5)
+	apiErr := err.(api.ErrOffsetOutOfRange)
+	require.Equal(t, uint64(5), apiErr.Offset)
+	require.NoError(t, log.Close())
+}
+
+func testResetAt(t *testing.T, log *Log) {
+	append := &api.Record{
+		Value: []byte("hello world"),
+	}
+	for i := 0; i < 3; i++ {
+		_, err := log.Append(append)
+		require.NoError(t, err)
+	}
+	require.Equal(t, uint64(0), log.Epoch())
+	before, err := os.Stat(log.Dir)
+	require.NoError(t, err)
+
+	require.NoError(t, log.ResetAt(100))
+	require.Equal(t, uint64(1), log.Epoch())
+
+	// ディレクトリは作り直さず、ディレクトリのロックも保持し続ける
+	after, err := os.Stat(log.Dir)
+	require.NoError(t, err)
+	require.True(t, os.SameFile(before, after))
+	_, err = NewLog(log.Dir, Config{})
+	require.ErrorIs(t, err, ErrLocked)
+
+	_, err = log.Read(0)
+	require.Error(t, err)
+
+	off, err := log.Append(append)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), off)
+	require.NoError(t, log.Close())
+
+	// エポックと開始オフセットは開き直しても復元される
+	n, err := NewLog(log.Dir, Config{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), n.Epoch())
+	off, err = n.LowestOffset()
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), off)
+	require.NoError(t, n.Close())
+
+	// セグメントのファイルがなくても、マニフェストに記録した開始オフセットから始める
+	for _, pattern := range []string{"*.store", "*.index"} {
+		names, err := filepath.Glob(filepath.Join(log.Dir, pattern))
+		require.NoError(t, err)
+		for _, name := range names {
+			require.NoError(t, os.Remove(name))
+		}
+	}
+	n, err = NewLog(log.Dir, Config{})
+	require.NoError(t, err)
+	off, err = n.LowestOffset()
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), off)
+	require.NoError(t, n.Close())
+}
+
+func testInstallSegment(t *testing.T, log *Log) {
+	append := &api.Record{
+		Value: []byte("hello world"),
+	}
+	for i := 0; i < 5; i++ {
+		_, err := log.Append(append)
+		require.NoError(t, err)
+	}
+	sealed := log.SealedSegments(0)
+	require.NotEmpty(t, sealed)
+
+	dir, err := os.MkdirTemp("", "install-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	follower, err := NewLog(dir, log.Config)
+	require.NoError(t, err)
+
+	for _, s := range sealed {
+		require.NoError(t, follower.InstallSegment(s.BaseOffset, s.Format, s.Store, s.Index))
+	}
+
+	// 書き込み済みセグメントのレコードをすべて読み取れる
+	last := sealed[len(sealed)-1].NextOffset
+	for i := uint64(0); i < last; i++ {
+		read, err := follower.Read(i)
+		require.NoError(t, err)
+		require.Equal(t, append.Value, read.Value)
+	}
+
+	// インストール後は続きのオフセットから書き込める
+	off, err := follower.Append(append)
+	require.NoError(t, err)
+	require.Equal(t, last, off)
+
+	// 保存形式が異なるセグメントはインストールできない
+	other := FormatV2
+	if sealed[0].Format == FormatV2 {
+		other = FormatV1
+	}
+	err = follower.InstallSegment(last, other, sealed[0].Store, sealed[0].Index)
+	require.ErrorContains(t, err, "store format")
+	require.NoError(t, follower.Healthy())
+
+	// 続かないオフセットのセグメントはインストールできないが、ログは壊れていないため正常な状態のまま
+	err = follower.InstallSegment(0, sealed[0].Format, sealed[0].Store, sealed[0].Index)
+	require.Equal(t, api.ErrOffsetGap{Expected: last + 1, Actual: 0}, err)
+	require.NoError(t, follower.Healthy())
+	off, err = follower.Append(append)
+	require.NoError(t, err)
+	require.Equal(t, last+1, off)
+	require.NoError(t, follower.Close())
+	require.NoError(t, log.Close())
+}
+
+func testInstallSegmentGap(t *testing.T, log *Log) {
+	// 相対オフセット 0, 2 のエントリを持つ（1 が抜けている）インデックス
+	index := make([]byte, entWidth*2)
+	enc.PutUint32(index[0:offWidth], 0)
+	enc.PutUint32(index[entWidth:entWidth+offWidth], 2)
+
+	err := log.InstallSegment(0, log.Config.Segment.Format, bytes.NewReader(nil), bytes.NewReader(index))
+	require.Equal(t, api.ErrOffsetGap{Expected: 1, Actual: 2}, err)
+	require.NoError(t, log.Healthy())
+
+	// 一時ファイルは残らず、ログには書き込みを続けられる
+	_, err = os.Stat(filepath.Join(log.Dir, "0.index.tmp"))
+	require.True(t, os.IsNotExist(err))
+	off, err := log.Append(&api.Record{Value: []byte("hello world")})
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), off)
+	require.NoError(t, log.Close())
+}
+
+func testSplitSegment(t *testing.T, o *Log) {
+	append := &api.Record{
+		Value: []byte("hello world"),
+	}
+	// 1つのセグメントに4件のレコードを書き込むため、ストアの上限を一時的に広げる
+	o.Config.Segment.MaxStoreBytes = 1024
+	o.activeSegment.config = o.Config
+	for i := 0; i < 4; i++ {
+		_, err := o.Append(append)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, len(o.segments))
+
+	require.Error(t, o.SplitSegment(0, 4))
+	require.Error(t, o.SplitSegment(1, 2))
+
+	// 分割の前に作成した Reader は、分割後も元のセグメントを最後まで読み取れる
+	size := o.activeSegment.store.size
+	reader := o.Reader()
+	require.NoError(t, o.SplitSegment(0, 2))
+	b, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, size, uint64(len(b)))
+	require.NoError(t, reader.Close())
+	require.Equal(t, 2, len(o.segments))
+	require.Equal(t, uint64(2), o.activeSegment.baseOffset)
+	requireFileMode(t, o.segments[0].store.Name(), sealedFileMode)
+	requireFileMode(t, o.segments[1].store.Name(), segmentFileMode)
+
+	for i := uint64(0); i < 4; i++ {
+		read, err := o.Read(i)
+		require.NoError(t, err)
+		require.Equal(t, i, read.Offset)
+	}
+
+	// 後半のセグメントはアクティブセグメントとして書き込みを続けられる
+	off, err := o.Append(append)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), off)
+	require.NoError(t, o.Close())
+
+	// 分割後のセグメントは開き直しても復元される
+	n, err := NewLog(o.Dir, o.Config)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(n.segments))
+	for i := uint64(0); i < 5; i++ {
+		read, err := n.Read(i)
+		require.NoError(t, err)
+		require.Equal(t, i, read.Offset)
+	}
+	require.NoError(t, n.Close())
+}
+
+func testSealedSegments(t *testing.T, o *Log) {
+	append := &api.Record{
+		Value: []byte("hello world"),
+	}
+	for i := 0; i < 3; i++ {
+		_, err := o.Append(append)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, len(o.segments))
+
+	// アクティブセグメント以外のファイルは読み取り専用になる
+	for _, s := range o.segments {
+		mode := os.FileMode(sealedFileMode)
+		if s == o.activeSegment.segment {
+			mode = segmentFileMode
+		}
+		requireFileMode(t, s.store.Name(), mode)
+		requireFileMode(t, s.index.Name(), mode)
+	}
+	require.NoError(t, o.Close())
+
+	// 読み取り専用のセグメントも開き直して読み取れ、アクティブセグメントには書き込みを続けられる
+	n, err := NewLog(o.Dir, o.Config)
+	require.NoError(t, err)
+	defer n.Close()
+	for i := uint64(0); i < 3; i++ {
+		read, err := n.Read(i)
+		require.NoError(t, err)
+		require.Equal(t, i, read.Offset)
+	}
+	off, err := n.Append(append)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), off)
+	requireFileMode(t, n.segments[len(n.segments)-2].store.Name(), sealedFileMode)
+}
+
+// requireFileMode: ファイルのパーミッションが mode であることを確認する
+func requireFileMode(t *testing.T, name string, mode os.FileMode) {
+	t.Helper()
+	fi, err := os.Stat(name)
+	require.NoError(t, err)
+	require.Equal(t, mode, fi.Mode().Perm(), name)
+}
+
+func TestLogFlushInterval(t *testing.T) {
+	dir, err := os.MkdirTemp("", "flush-interval-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := Config{}
+	c.Segment.FlushInterval = 10 * time.Millisecond
+	log, err := NewLog(dir, c)
+	require.NoError(t, err)
+
+	_, err = log.Append(&api.Record{Value: []byte("hello world")})
+	require.NoError(t, err)
+
+	// 読み取りや Close をしなくても、バッファの内容がファイルに書き出される
+	name := log.activeSegment.store.Name()
+	require.Eventually(t, func() bool {
+		fi, err := os.Stat(name)
+		return err == nil && fi.Size() > 0
+	}, time.Second, 10*time.Millisecond)
+
+	// リセット後も定期的なフラッシュは続く
+	require.NoError(t, log.Reset())
+	_, err = log.Append(&api.Record{Value: []byte("hello world")})
+	require.NoError(t, err)
+	name = log.activeSegment.store.Name()
+	require.Eventually(t, func() bool {
+		fi, err := os.Stat(name)
+		return err == nil && fi.Size() > 0
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, log.Close())
+}
+
+func TestLogMaxAge(t *testing.T) {
+	dir, err := os.MkdirTemp("", "max-age-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clock := NewFakeClock(time.Now())
+	c := Config{Clock: clock}
+	c.Segment.MaxAge = 20 * time.Millisecond
+	log, err := NewLog(dir, c)
+	require.NoError(t, err)
+	defer log.Close()
+
+	segments := func() int {
+		log.mu.RLock()
+		defer log.mu.RUnlock()
+		return len(log.segments)
+	}
+
+	// 空のアクティブセグメントは経過時間が過ぎても切り替えない
+	clock.Advance(c.Segment.MaxAge)
+	require.False(t, log.aged())
+	require.Equal(t, 1, segments())
+
+	// MaxAge が経過するまでは切り替えない
+	_, err = log.Append(&api.Record{Value: []byte("hello world")})
+	require.NoError(t, err)
+	clock.Advance(c.Segment.MaxAge / 2)
+	require.Equal(t, 1, segments())
+
+	// 書き込みがなくても、MaxAge が経過するとサイズに達していないセグメントが切り替わる
+	clock.Advance(c.Segment.MaxAge / 2)
+	require.Eventually(t, func() bool {
+		return segments() == 2
+	}, time.Second, time.Millisecond)
+
+	off, err := log.Append(&api.Record{Value: []byte("hello world")})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), off)
+	read, err := log.Read(0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello world"), read.Value)
+}
+
+func TestLogAdaptiveSegmentSize(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	c := Config{Clock: clock}
+	c.Segment.MaxStoreBytes = 32
+	c.Segment.Adaptive.Enabled = true
+	c.Segment.Adaptive.MaxStoreBytes = 128
+	c.Segment.Adaptive.TargetInterval = time.Minute
+	log, err := NewLog(t.TempDir(), c)
+	require.NoError(t, err)
+	defer log.Close()
+	require.Equal(t, uint64(32), log.SegmentStoreBytes())
+
+	segments := func() int {
+		log.mu.RLock()
+		defer log.mu.RUnlock()
+		return len(log.segments)
+	}
+	// セグメントが切り替わるまで書き込む
+	fill := func() {
+		n := segments()
+		for segments() == n {
+			_, err := log.Append(&api.Record{Value: []byte("hello world")})
+			require.NoError(t, err)
+		}
+	}
+
+	// 目標の間隔より短い時間で一杯になるたびに2倍にし、上限で止まる
+	for _, want := range []uint64{64, 128, 128} {
+		fill()
+		require.Equal(t, want, log.SegmentStoreBytes())
+	}
+	require.Greater(t, log.activeSegment.config.Segment.MaxStoreBytes, uint64(32))
+
+	// 目標の間隔の4倍以上かかった場合は半分にする
+	clock.Advance(4 * time.Minute)
+	fill()
+	require.Equal(t, uint64(64), log.SegmentStoreBytes())
+
+	// 書き込んだレコードはすべて読み取れる
+	next := log.NextOffset()
+	for off := uint64(0); off < next; off++ {
+		_, err := log.Read(off)
+		require.NoError(t, err)
+	}
+}
+
+func testSegmentStats(t *testing.T, log *Log) {
+	append := &api.Record{
+		Value: []byte("hello world"),
+	}
+	for i := 0; i < 3; i++ {
+		_, err := log.Append(append)
+		require.NoError(t, err)
+	}
+
+	stats, err := log.SegmentStats()
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+
+	require.Equal(t, uint64(0), stats[0].BaseOffset)
+	require.Equal(t, uint64(2), stats[0].Records)
+	require.Equal(t, 2*entWidth, stats[0].IndexBytes)
+	require.False(t, stats[0].Active)
+
+	require.Equal(t, uint64(2), stats[1].BaseOffset)
+	require.Equal(t, uint64(3), stats[1].NextOffset)
+	require.Equal(t, uint64(1), stats[1].Records)
+	require.NotZero(t, stats[1].StoreBytes)
+	require.True(t, stats[1].Active)
+	require.False(t, stats[1].CreatedAt.IsZero())
+	require.NoError(t, log.Close())
+}
+
+func testIdempotentProduce(t *testing.T, log *Log) {
+	produce := func(l *Log, seq uint64) (uint64, error) {
+		return l.Append(&api.Record{
+			Value:      []byte("hello world"),
+			ProducerId: "producer-1",
+			Sequence:   seq,
+		})
+	}
+	for seq := uint64(1); seq <= 3; seq++ {
+		off, err := produce(log, seq)
+		require.NoError(t, err)
+		require.Equal(t, seq-1, off)
+	}
+
+	// 直前のシーケンス番号の再送は追加されず、最初のオフセットが返される
+	off, err := produce(log, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), off)
+	highest, err := log.HighestOffset()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), highest)
+
+	// 古いシーケンス番号は拒否される
+	_, err = produce(log, 1)
+	require.Equal(t, api.ErrOutOfOrderSequence{ProducerId: "producer-1", Sequence: 1, Last: 3}, err)
+	require.NoError(t, log.Close())
+
+	// 再起動後も重複排除の状態が保たれる
+	n, err := NewLog(log.Dir, log.Config)
+	require.NoError(t, err)
+	off, err = produce(n, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), off)
+	require.NoError(t, n.Close())
+
+	// スナップショットがなくても、レコードから状態を再構築する
+	require.NoError(t, os.Remove(filepath.Join(log.Dir, producersFile)))
+	n, err = NewLog(log.Dir, log.Config)
+	require.NoError(t, err)
+	off, err = produce(n, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), off)
+	off, err = produce(n, 4)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), off)
+	require.NoError(t, n.Close())
+}
+
+// testLocate: レコードを含むセグメントとストア内の位置を取得できることをテストする
+func testLocate(t *testing.T, log *Log) {
+	// 3件目まではアクティブセグメントに、4件目はストアが最大サイズに達したため次のセグメントに書き込まれる
+	for i := 0; i < 4; i++ {
+		_, err := log.Append(&api.Record{Value: []byte("a")})
+		require.NoError(t, err)
+	}
+	for off, want := range [][2]uint64{{0, 0}, {0, 11}, {0, 24}, {3, 0}} {
+		base, pos, err := log.Locate(uint64(off))
+		require.NoError(t, err)
+		require.Equal(t, want, [2]uint64{base, pos}, "offset %d", off)
+	}
+	_, _, err := log.Locate(4)
+	require.Equal(t, api.ErrOffsetOutOfRange{Offset: 4}, err)
+}
+
+// testFlush: Flush でバッファのデータがストアファイルに書き出されることをテストする
+func testFlush(t *testing.T, log *Log) {
+	_, err := log.Append(&api.Record{Value: []byte("a")})
+	require.NoError(t, err)
+	path := filepath.Join(log.Dir, "0.store")
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Zero(t, fi.Size())
+
+	require.NoError(t, log.Flush())
+	fi, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, int64(11), fi.Size())
+}
+
+// testTruncateDuringRead: 読み取り中に Truncate で削除されたセグメントを最後まで読み取れ、
+// 読み取りが終わった時点でファイルが削除されることをテストする
+func testTruncateDuringRead(t *testing.T, log *Log) {
+	for i := 0; i < 4; i++ {
+		_, err := log.Append(&api.Record{Value: []byte("hello world")})
+		require.NoError(t, err)
+	}
+	full, err := io.ReadAll(log.Reader())
+	require.NoError(t, err)
+
+	reader := log.Reader()
+	partial := log.Reader()
+	sealed := log.SealedSegments(0)
+	require.NotEmpty(t, sealed)
+	head := make([]byte, 1)
+	_, err = partial.Read(head)
+	require.NoError(t, err)
+
+	require.NoError(t, log.Truncate(3))
+	_, err = log.Read(0)
+	require.Error(t, err)
+	storePath := filepath.Join(log.Dir, "0.store")
+	require.FileExists(t, storePath)
+
+	// 削除されたセグメントも最後まで読み取れる
+	b, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, full, b)
+	store, err := io.ReadAll(sealed[0].Store)
+	require.NoError(t, err)
+	require.NotEmpty(t, store)
+
+	// すべての参照が外れるまでファイルは残る
+	require.NoError(t, sealed[0].Close())
+	require.NoError(t, sealed[0].Close())
+	require.FileExists(t, storePath)
+	require.NoError(t, partial.Close())
+	require.NoFileExists(t, storePath)
+}
+
+// testSize: 書き込み、Truncate、再起動の後も、レコード数とバイト数がセグメントの統計情報と一致することをテストする
+func testSize(t *testing.T, log *Log) {
+	requireSize := func(l *Log, records uint64) {
+		stats, err := l.SegmentStats()
+		require.NoError(t, err)
+		var bytes uint64
+		for _, st := range stats {
+			bytes += st.StoreBytes
+		}
+		gotRecords, gotBytes := l.Size()
+		require.Equal(t, records, gotRecords)
+		require.Equal(t, bytes, gotBytes)
+	}
+	requireSize(log, 0)
+
+	for i := 0; i < 4; i++ {
+		_, err := log.Append(&api.Record{Value: []byte("hello world")})
+		require.NoError(t, err)
+	}
+	_, err := log.AppendBatch([]*api.Record{{Value: []byte("a")}, {Value: []byte("b")}})
+	require.NoError(t, err)
+	requireSize(log, 6)
+
+	lowest, err := log.LowestOffset()
+	require.NoError(t, err)
+	require.NoError(t, log.Truncate(lowest))
+	lowest, err = log.LowestOffset()
+	require.NoError(t, err)
+	requireSize(log, 6-lowest)
+
+	require.NoError(t, log.Close())
+	reopened, err := NewLog(log.Dir, log.Config)
+	require.NoError(t, err)
+	defer reopened.Close()
+	requireSize(reopened, 6-lowest)
+}
+
+func testWriteStats(t *testing.T, log *Log) {
+	for i := 0; i < 4; i++ {
+		_, err := log.Append(&api.Record{Value: []byte("hello world")})
+		require.NoError(t, err)
+	}
+	require.NoError(t, log.Flush())
+
+	// 書き出したストアのバイト数は、レコードのデータと長さ情報の合計になる
+	_, bytes := log.Size()
+	w := log.WriteStats()
+	require.Equal(t, bytes, w.StoreBytes)
+	require.Equal(t, bytes-4*lenWidth, w.RecordBytes)
+	require.Equal(t, uint64(4*entWidth), w.IndexBytes)
+	require.Zero(t, w.RewriteBytes)
+
+	// 分割では前半と後半のセグメントのレコードとインデックスのエントリをすべて書き直す
+	base, _, err := log.Locate(1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), base)
+	stats, err := log.SegmentStats()
+	require.NoError(t, err)
+	require.NoError(t, log.SplitSegment(0, 1))
+	w = log.WriteStats()
+	require.Equal(t, stats[0].StoreBytes+stats[0].IndexBytes, w.RewriteBytes)
+	require.Equal(t, bytes, w.StoreBytes)
+}
+
+func testAppendBatch(t *testing.T, log *Log) {
+	_, err := log.AppendBatch(nil)
+	require.Equal(t, ErrEmptyBatch, err)
+
+	batch := func(values ...string) []*api.Record {
+		records := make([]*api.Record, len(values))
+		for i, v := range values {
+			records[i] = &api.Record{Value: []byte(v)}
+		}
+		return records
+	}
+
+	off, err := log.Append(&api.Record{Value: []byte("single")})
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), off)
+	off, err = log.AppendBatch(batch("a", "b", "c"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), off)
+	off, err = log.AppendBatch(batch("d", "e"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), off)
+
+	want := []string{"single", "a", "b", "c", "d", "e"}
+	requireValues := func(read func(uint64) (*api.Record, error)) {
+		for i, v := range want {
+			record, err := read(uint64(i))
+			require.NoError(t, err)
+			require.Equal(t, uint64(i), record.Offset)
+			require.Equal(t, []byte(v), record.Value)
+		}
+	}
+	requireValues(log.Read)
+	requireValues(log.NewSequentialReader().Read)
+
+	// バッチの途中では分割できない
+	require.Error(t, log.SplitSegment(log.activeSegment.baseOffset, 5))
+
+	// 開き直してもバッチのレコードを読み取れる
+	require.NoError(t, log.Close())
+	n, err := NewLog(log.Dir, log.Config)
+	require.NoError(t, err)
+	requireValues(n.Read)
+	require.NoError(t, n.Close())
+}
+
+func testDelayedRecords(t *testing.T, log *Log) {
+	_, err := log.Append(&api.Record{Value: []byte("now")})
+	require.NoError(t, err)
+	_, err = log.Append(&api.Record{Value: []byte("later"), DeliverAfter: 2000})
+	require.NoError(t, err)
+	_, err = log.AppendBatch([]*api.Record{
+		{Value: []byte("a"), DeliverAfter: 1000},
+		{Value: []byte("b")},
+		{Value: []byte("c"), DeliverAfter: 3000},
+	})
+	require.NoError(t, err)
+
+	// 配信時刻が since より後で、オフセットが before より前のレコードだけを返す
+	require.Equal(t, []uint64{1, 2, 4}, log.DelayedSince(0, 5))
+	require.Equal(t, []uint64{1, 4}, log.DelayedSince(1000, 5))
+	require.Equal(t, []uint64{1, 2}, log.DelayedSince(0, 4))
+	require.Empty(t, log.DelayedSince(3000, 5))
+
+	// 開き直してもタイマーインデックスから読み込める
+	require.NoError(t, log.Close())
+	n, err := NewLog(log.Dir, log.Config)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2, 4}, n.DelayedSince(0, 5))
+	require.NoError(t, n.Close())
+
+	// タイマーインデックスがない場合（この機能より前のセグメントなど）はレコードから作り直す
+	paths, err := filepath.Glob(filepath.Join(log.Dir, "*"+timersExt))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+	for _, path := range paths {
+		require.NoError(t, os.Remove(path))
+	}
+	n, err = NewLog(log.Dir, log.Config)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2, 4}, n.DelayedSince(0, 5))
+
+	// 削除したセグメントのレコードは返さない
+	require.NoError(t, n.Truncate(1))
+	require.Equal(t, []uint64{2, 4}, n.DelayedSince(0, 5))
+	require.NoError(t, n.Close())
+}
+
+func TestIndexHints(t *testing.T) {
+	dir := t.TempDir()
+	c := Config{}
+	c.Segment.MaxStoreBytes = 32
+	c.Segment.IndexHints.Warm = 1
+	c.Segment.IndexHints.ColdAfter = 2
+	l, err := NewLog(dir, c)
+	require.NoError(t, err)
+	for i := 0; i < 6; i++ {
+		_, err = l.Append(&api.Record{Value: []byte("hello world")})
+		require.NoError(t, err)
+	}
+
+	requireHints := func(l *Log, want ...indexHint) {
+		t.Helper()
+		var got []indexHint
+		for _, s := range l.segments {
+			got = append(got, s.index.hint)
+		}
+		require.Equal(t, want, got)
+	}
+	// 切り替えたときに、新しいセグメントから順に適用する（空のアクティブセグメントには適用しない）
+	requireHints(l, hintCold, hintNone, hintNone)
+	require.NoError(t, l.Close())
+
+	// 開いたときにも適用し、温めたインデックスはメモリに読み込まれている
+	l, err = NewLog(dir, c)
+	require.NoError(t, err)
+	defer l.Close()
+	requireHints(l, hintCold, hintNone, hintWarm)
+	resident, err := l.activeSegment.index.mmap[:l.activeSegment.index.size].IsResident()
+	require.NoError(t, err)
+	require.NotContains(t, resident, false)
+}
+
+func TestLogFormatV2(t *testing.T) {
+	dir, err := os.MkdirTemp("", "format-v2-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := Config{}
+	c.Segment.MaxStoreBytes = 64
+	c.Segment.Format = FormatV2
+	log, err := NewLog(dir, c)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = log.Append(&api.Record{Value: []byte("hello world")})
+		require.NoError(t, err)
+	}
+	_, err = log.AppendBatch([]*api.Record{{Value: []byte("a")}, {Value: []byte("b")}})
+	require.NoError(t, err)
+
+	requireRecords := func(l *Log) {
+		reader := l.NewSequentialReader()
+		for off := uint64(0); off < 7; off++ {
+			for _, read := range []func(uint64) (*api.Record, error){l.Read, reader.Read} {
+				record, err := read(off)
+				require.NoError(t, err)
+				require.Equal(t, off, record.Offset)
+			}
+		}
+		n, err := VerifyStream(l.ChecksumReader(), nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(6), n)
+	}
+	requireRecords(log)
+	require.NoError(t, log.Close())
+
+	// 保存形式はマニフェストに記録され、設定で別の形式を指定しても作成時の形式で開く
+	c.Segment.Format = FormatV1
+	log, err = NewLog(dir, c)
+	require.NoError(t, err)
+	require.Equal(t, FormatV2, log.Config.Segment.Format)
+	requireRecords(log)
+	require.NoError(t, log.Close())
+
+	c.Segment.Format = 3
+	_, err = NewLog(dir, c)
+	require.Error(t, err)
+}
+
+func TestLogExclusive(t *testing.T) {
+	dir, err := os.MkdirTemp("", "exclusive-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	log, err := NewLog(dir, Config{})
+	require.NoError(t, err)
+
+	// 開いている間は、同じディレクトリを別の Log で開けない
+	_, err = NewLog(dir, Config{})
+	require.Equal(t, ErrLocked, err)
+
+	// リセット後もロックを保持し続ける
+	require.NoError(t, log.Reset())
+	_, err = NewLog(dir, Config{})
+	require.Equal(t, ErrLocked, err)
+
+	// 閉じるとロックが解放される
+	require.NoError(t, log.Close())
+	log, err = NewLog(dir, Config{})
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+}
+
+// TestLogPartialSegment: セグメントの作成中にクラッシュして残ったファイルが、開き直したときに削除されることをテストする
+func TestLogPartialSegment(t *testing.T) {
+	dir := t.TempDir()
+	c := Config{}
+	c.Segment.MaxStoreBytes = 32
+	log, err := NewLog(dir, c)
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		_, err = log.Append(&api.Record{Value: []byte("hello world")})
+		require.NoError(t, err)
+	}
+	require.NoError(t, log.Close())
+
+	// インデックスだけを作成した状態と、一時ファイルを作成した状態
+	for _, name := range []string{"100.index", "100.store.tmp", "200.index.tmp"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+
+	log, err = NewLog(dir, c)
+	require.NoError(t, err)
+	defer log.Close()
+	for _, name := range []string{"100.index", "100.store.tmp", "200.index.tmp"} {
+		require.NoFileExists(t, filepath.Join(dir, name))
+	}
+	require.Equal(t, uint64(4), log.NextOffset())
+	off, err := log.Append(&api.Record{Value: []byte("hello world")})
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), off)
+
+	// 新しいセグメントの作成後に一時ファイルは残らない
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		require.NotEqual(t, ".tmp", filepath.Ext(e.Name()))
+	}
+}
+
+func TestLogSubscribe(t *testing.T) {
+	dir, err := os.MkdirTemp("", "subscribe-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	log, err := NewLog(dir, Config{})
+	require.NoError(t, err)
+	appendN := func(n int) {
+		for i := 0; i < n; i++ {
+			_, err := log.Append(&api.Record{Value: []byte("hello world")})
+			require.NoError(t, err)
+		}
+	}
+	recv := func(s *Subscription) *api.Record {
+		select {
+		case record := <-s.C:
+			return record
+		case <-time.After(time.Second):
+			t.Fatal("no record received")
+			return nil
+		}
+	}
+	appendN(3)
+
+	// 既存のレコードを受け取った後、新しく追加されたレコードを受け取る
+	sub := log.Subscribe(1, SubscribeOptions{})
+	require.Equal(t, uint64(1), recv(sub).Offset)
+	require.Equal(t, uint64(2), recv(sub).Offset)
+	appendN(1)
+	require.Equal(t, uint64(3), recv(sub).Offset)
+	sub.Close()
+	require.NoError(t, sub.Err())
+
+	// 遅れすぎた購読者は終了する
+	slow := log.Subscribe(0, SubscribeOptions{MaxLag: 2, Policy: SlowDisconnect})
+	_, ok := <-slow.C
+	require.False(t, ok)
+	require.Equal(t, ErrSlowSubscriber, slow.Err())
+
+	// 遅れすぎた購読者は、末尾から MaxLag 件前まで読み飛ばす
+	skip := log.Subscribe(0, SubscribeOptions{MaxLag: 2, Policy: SlowSkip})
+	require.Equal(t, uint64(2), recv(skip).Offset)
+	skip.Close()
+
+	// リセットしても購読は終了せず、リセット後に購読することもできる
+	waiting := log.Subscribe(3, SubscribeOptions{})
+	require.Equal(t, uint64(3), recv(waiting).Offset)
+	require.NoError(t, log.ResetAt(10))
+	reset := log.Subscribe(10, SubscribeOptions{})
+	appendN(1)
+	require.Equal(t, uint64(10), recv(reset).Offset)
+	require.Equal(t, uint64(10), recv(waiting).Offset)
+
+	// ログを閉じると購読も終了する
+	require.NoError(t, log.Close())
+	for _, sub := range []*Subscription{waiting, reset} {
+		for range sub.C {
+		}
+		require.NoError(t, sub.Err())
+	}
+}
+
+// TestLogVerifyIndex: インデックスとストアの食い違いが検出されることをテストする
+func TestLogVerifyIndex(t *testing.T) {
+	dir, err := os.MkdirTemp("", "verify-index-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := Config{}
+	c.Segment.MaxIndexBytes = entWidth * 3
+	log, err := NewLog(dir, c)
+	require.NoError(t, err)
+	defer log.Close()
+	for i := 0; i < 4; i++ {
+		_, err = log.Append(&api.Record{Value: []byte("hello world")})
+		require.NoError(t, err)
+	}
+	require.NoError(t, log.VerifyIndex())
+
+	// 最後のエントリが失われたセグメント
+	s := log.segments[0]
+	s.index.size -= entWidth
+	err = log.VerifyIndex()
+	require.ErrorIs(t, err, ErrIndexMismatch)
+	require.Contains(t, err.Error(), "segment 0")
+	s.index.size += entWidth
+
+	// ストアの末尾を超える位置を指すエントリ
+	size := s.store.size
+	s.store.size = 10
+	require.ErrorIs(t, log.VerifyIndex(), ErrIndexMismatch)
+	s.store.size = size
+	require.NoError(t, log.VerifyIndex())
+}
+
+// corruptStore: セグメントのストアファイルの old の最初の出現を new に書き換える（テスト用）
+func corruptStore(t *testing.T, s *segment, old, new string) {
+	t.Helper()
+	b, err := os.ReadFile(s.store.Name())
+	require.NoError(t, err)
+	i := bytes.Index(b, []byte(old))
+	require.NotEqual(t, -1, i)
+	copy(b[i:], new)
+	require.NoError(t, os.Chmod(s.store.Name(), segmentFileMode))
+	require.NoError(t, os.WriteFile(s.store.Name(), b, segmentFileMode))
+}
+
+// TestLogScrub: スクラブが書き込み済みセグメントの破損を検出して隔離することをテストする
+func TestLogScrub(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(time.Now())
+	corrupted := make(chan uint64, 1)
+	c := Config{Clock: clock}
+	c.Segment.MaxIndexBytes = entWidth * 3
+	c.Segment.Scrub.Interval = time.Hour
+	c.Hooks.OnCorruptSegment = func(baseOffset uint64, err error) { corrupted <- baseOffset }
+	log, err := NewLog(dir, c)
+	require.NoError(t, err)
+	defer log.Close()
+
+	// 書き込み済みセグメント: 単一のレコード、2件のバッチ
+	_, err = log.Append(&api.Record{Value: []byte("single")})
+	require.NoError(t, err)
+	_, err = log.AppendBatch([]*api.Record{{Value: []byte("batched")}, {Value: []byte("batched")}})
+	require.NoError(t, err)
+	_, err = log.Append(&api.Record{Value: []byte("active")})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(log.segments))
+
+	// 最初の検査でチェックサムを記録する（アクティブセグメントは検査しない）
+	result, err := log.Scrub()
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Segments)
+	require.Equal(t, log.segments[0].store.size, result.Bytes)
+	require.Empty(t, result.Corrupt)
+	require.FileExists(t, filepath.Join(dir, "0"+scrubExt))
+
+	// 構造が壊れていなくても、記録したチェックサムと一致しなければ破損として隔離する
+	corruptStore(t, log.segments[0], "single", "SINGLE")
+	clock.Advance(c.Segment.Scrub.Interval)
+	select {
+	case off := <-corrupted:
+		require.Equal(t, uint64(0), off)
+	case <-time.After(5 * time.Second):
+		t.Fatal("corrupt segment not reported")
+	}
+
+	_, err = log.Read(1)
+	var quarantined api.ErrCorruptSegment
+	require.ErrorAs(t, err, &quarantined)
+	require.Equal(t, uint64(3), quarantined.NextOffset)
+	require.Contains(t, quarantined.Reason, "checksum")
+	require.Equal(t, codes.DataLoss, status.Code(err))
+	_, err = log.Read(3)
+	require.NoError(t, err)
+	stats, err := log.SegmentStats()
+	require.NoError(t, err)
+	require.True(t, stats[0].Quarantined)
+	require.Empty(t, log.SealedSegments(0))
+	require.Error(t, log.SplitSegment(0, 1))
+
+	// 隔離中のセグメントは検査し直さない
+	result, err = log.Scrub()
+	require.NoError(t, err)
+	require.Equal(t, 0, result.Segments)
+
+	// チェックサムを記録する前に壊れたセグメントも、レコードの構造から検出する
+	dir = t.TempDir()
+	c.Segment.Scrub.Interval = 0
+	c.Hooks = Hooks{}
+	log, err = NewLog(dir, c)
+	require.NoError(t, err)
+	defer log.Close()
+	for _, value := range []string{"first", "second", "third", "fourth"} {
+		_, err = log.Append(&api.Record{Value: []byte(value)})
+		require.NoError(t, err)
+	}
+	require.NoError(t, log.Flush())
+	// 値の長さを壊して、レコードをデコードできないようにする
+	corruptStore(t, log.segments[0], "\x06second", "\x7f")
+	// 隔離する前は、壊れたレコードだけが読み取れない
+	_, err = log.Read(1)
+	require.Equal(t, codes.DataLoss, status.Code(err))
+	require.IsType(t, api.ErrCorruptRecord{}, err)
+	_, err = log.Read(2)
+	require.NoError(t, err)
+	result, err = log.Scrub()
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0}, result.Corrupt)
+	require.NoFileExists(t, filepath.Join(dir, "0"+scrubExt))
+}
+
+// fullWriter: full が true の間、先頭の数バイトだけを書き込んで ENOSPC を返すテスト用の Writer
+type fullWriter struct {
+	f    *os.File
+	full *atomic.Bool
+}
+
+func (w fullWriter) Write(p []byte) (int, error) {
+	if !w.full.Load() {
+		return w.f.Write(p)
+	}
+	n, _ := w.f.Write(p[:min(len(p), 10)])
+	return n, &os.PathError{Op: "write", Path: w.f.Name(), Err: syscall.ENOSPC}
+}
+
+// TestLogDiskFull: ディスクが一杯の間は読み取り専用になり、空き容量が戻ると書き込みを再開することをテストする
+func TestLogDiskFull(t *testing.T) {
+	dir, err := os.MkdirTemp("", "disk-full-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := Config{}
+	c.Segment.MaxStoreBytes = 1 << 20
+	c.Segment.MaxIndexBytes = entWidth << 16
+	log, err := NewLog(dir, c)
+	require.NoError(t, err)
+	var full atomic.Bool
+	s := log.activeSegment.store
+	s.buf.w = fullWriter{f: s.File, full: &full}
+
+	value := bytes.Repeat([]byte("a"), 1000)
+	full.Store(true)
+	var appended uint64
+	for ; ; appended++ {
+		_, err = log.Append(&api.Record{Value: value})
+		if err != nil {
+			break
+		}
+	}
+	// バッファが一杯になって書き出そうとしたときに、読み取り専用になる
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	var diskFull api.ErrDiskFull
+	require.ErrorAs(t, err, &diskFull)
+	require.ErrorAs(t, log.DiskFull(), &diskFull)
+	require.Greater(t, appended, uint64(0))
+
+	// 書き出せなかったレコードも含めて読み取れる
+	for off := uint64(0); off < appended; off++ {
+		record, err := log.Read(off)
+		require.NoError(t, err)
+		require.Equal(t, value, record.Value)
+	}
+	_, err = log.AppendBatch([]*api.Record{{Value: value}})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// 空き容量が戻ると書き込みを再開する
+	full.Store(false)
+	off, err := log.Append(&api.Record{Value: value})
+	require.NoError(t, err)
+	require.Equal(t, appended, off)
+	require.NoError(t, log.DiskFull())
+	require.NoError(t, log.Close())
+
+	log, err = NewLog(dir, c)
+	require.NoError(t, err)
+	defer log.Close()
+	for off := uint64(0); off <= appended; off++ {
+		record, err := log.Read(off)
+		require.NoError(t, err)
+		require.Equal(t, value, record.Value)
+	}
+}
+
+// TestConfigValidate: 不変条件を満たさない設定で NewLog がすぐに失敗し、直し方の分かるエラーを返すことをテストする
+func TestConfigValidate(t *testing.T) {
+	for scenario, tc := range map[string]struct {
+		configure func(c *Config)
+		message   string
+	}{
+		"index not a multiple of the entry width": {
+			configure: func(c *Config) { c.Segment.MaxIndexBytes = 1024 },
+			message:   "e.g. 1020 or 1032",
+		},
+		"index smaller than one entry": {
+			configure: func(c *Config) { c.Segment.MaxIndexBytes = 5 },
+			message:   "e.g. 12 or 24",
+		},
+		"store smaller than the largest record": {
+			configure: func(c *Config) {
+				c.Segment.MaxStoreBytes = 1024
+				c.Segment.MaxRecordBytes = 4096
+			},
+			message: "Segment.MaxStoreBytes 1024 must be greater than Segment.MaxRecordBytes 4096",
+		},
+		"unsupported format": {
+			configure: func(c *Config) { c.Segment.Format = 3 },
+			message:   "unsupported store format: 3",
+		},
+		"negative interval": {
+			configure: func(c *Config) { c.Segment.FlushInterval = -time.Second },
+			message:   "Segment.FlushInterval must not be negative",
+		},
+		"cold before warm": {
+			configure: func(c *Config) {
+				c.Segment.IndexHints.Warm = 3
+				c.Segment.IndexHints.ColdAfter = 2
+			},
+			message: "Segment.IndexHints.ColdAfter 2 must not be less than Warm 3",
+		},
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			var c Config
+			tc.configure(&c)
+			_, err := NewLog(t.TempDir(), c)
+			require.ErrorContains(t, err, tc.message)
+		})
+	}
+
+	// 複数の誤りはまとめて返す
+	var c Config
+	c.Segment.MaxIndexBytes = 100
+	c.Segment.Format = 3
+	err := c.Validate()
+	require.ErrorContains(t, err, "Segment.MaxIndexBytes 100")
+	require.ErrorContains(t, err, "unsupported store format")
+
+	// デフォルト値を設定した後の設定も有効（NewLog が返したログストアの Config で開き直せる）
+	dir := t.TempDir()
+	l, err := NewLog(dir, Config{})
+	require.NoError(t, err)
+	require.NoError(t, l.Config.Validate())
+	require.NoError(t, l.Close())
+}
@@ -0,0 +1,314 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrLogInUse: 使用中（Acquire して Release していない）のログストアを削除しようとした場合のエラー
+var ErrLogInUse = errors.New("log is in use")
+
+// ManagerConfig: Manager の設定
+type ManagerConfig struct {
+	// 各ログストアの設定
+	// Segment.FlushInterval、Segment.MaxAge、Segment.Scrub.Interval の定期的な処理は、
+	// ログストアごとではなく Manager の共有のゴルーチンがすべての開いているログストアに対して行う。
+	Log Config
+	// 開いているログストアが使用するファイルディスクリプタの数の上限（0 の場合は制限しない）
+	// ログストアはロックファイルと、セグメントごとのストアとインデックスのファイルを開いたままにする。
+	MaxOpenFiles int
+	// 開いているログストアがメモリにマップするインデックスの合計バイト数の上限（0 の場合は制限しない）
+	// セグメントごとに Segment.MaxIndexBytes をマップする。
+	MaxMemoryBytes uint64
+}
+
+// Manager: 1つのディレクトリの下の多数のログストアを管理する
+// 各ログストアは "{Dir}/{名前}/" ディレクトリに保存し、Acquire で初めて使用するときに開く。
+// 数百のログストアを組み込むプログラムが、ログストアごとに定期的な処理のゴルーチンを起動しないように、
+// 定期的な処理は処理の種類ごとに1つのゴルーチンで行う。また、ファイルディスクリプタとメモリの上限を超えた場合は、
+// 使用していない（Release 済みの）ログストアを最後に使用した時刻の古い順に閉じる（次の Acquire で開き直す）。
+// 使用中のログストアは閉じないため、上限より多くのログストアを同時に使用すると上限を超える。
+type Manager struct {
+	Dir    string
+	Config ManagerConfig
+
+	mu    sync.Mutex
+	logs  map[string]*managedLog
+	clock uint64 // Acquire のたびに進めるカウンター（最後に使用した順の比較に使用する）
+
+	done chan struct{}  // 定期的な処理を停止するためのチャネル
+	wg   sync.WaitGroup // 定期的な処理を行うゴルーチンの終了待ち
+}
+
+// managedLog: Manager が管理するログストア
+type managedLog struct {
+	log      *Log   // 開いているログストア（閉じている場合は nil）
+	refs     int    // Acquire の数と、定期的な処理で使用している数の合計（0 より大きい間は閉じない）
+	lastUsed uint64 // 最後に Acquire したときの Manager.clock
+}
+
+// NewManager: ログストアの管理を作成し、定期的な処理を開始する
+// 既存のログストアは一覧に加えるだけで、Acquire するまで開かない。
+// 引数:
+//   - dir: ログストアのディレクトリを保存するディレクトリ
+//   - c: Manager の設定
+//
+// 戻り値:
+//   - *Manager: 初期化された Manager（Close ですべてのログストアを閉じる）
+//   - error: エラーが発生した場合
+func NewManager(dir string, c ManagerConfig) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	c.Log.managed = true
+	m := &Manager{
+		Dir:    dir,
+		Config: c,
+		logs:   make(map[string]*managedLog),
+		done:   make(chan struct{}),
+	}
+	for _, e := range entries {
+		if e.IsDir() && topicName.MatchString(e.Name()) {
+			m.logs[e.Name()] = &managedLog{}
+		}
+	}
+
+	seg := c.Log.Segment
+	m.start(seg.FlushInterval, (*Log).flushActive)
+	if seg.MaxAge > 0 {
+		m.start(max(seg.MaxAge/4, time.Millisecond), (*Log).rollAged)
+	}
+	m.start(seg.Scrub.Interval, func(l *Log) {
+		// 検査の途中でも、Close で中断する（破損は Hooks.OnCorruptSegment で通知する）
+		_, _ = l.scrub(m.done)
+	})
+	return m, nil
+}
+
+// start: interval ごとに、開いているすべてのログストアに fn を実行するゴルーチンを開始する（内部関数）
+// interval が 0 の場合は何もしない。
+func (m *Manager) start(interval time.Duration, fn func(*Log)) {
+	if interval == 0 {
+		return
+	}
+	Every(m.Config.Log.clock(), interval, m.done, &m.wg, func(time.Time) {
+		for _, l := range m.pinOpen() {
+			fn(l)
+			m.unpin(l)
+		}
+	})
+}
+
+// pinOpen: 開いているすべてのログストアを、処理の間に閉じられないように参照を取得して返す（内部関数）
+func (m *Manager) pinOpen() []*Log {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var logs []*Log
+	for _, ml := range m.logs {
+		if ml.log != nil {
+			ml.refs++
+			logs = append(logs, ml.log)
+		}
+	}
+	return logs
+}
+
+// unpin: pinOpen で取得した参照を外す（内部関数）
+func (m *Manager) unpin(l *Log) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logs[filepath.Base(l.Dir)].refs--
+}
+
+// Acquire: ログストアを使用する（存在しない場合は作成し、閉じている場合は開く）
+// 使い終わったら Release を呼び出す。Release するまでログストアは閉じられない。
+// 引数:
+//   - name: ログストアの名前（トピック名と同じ文字を使用できる）
+//
+// 戻り値:
+//   - *Log: ログストア
+//   - error: エラーが発生した場合
+func (m *Manager) Acquire(name string) (*Log, error) {
+	if !topicName.MatchString(name) {
+		return nil, fmt.Errorf("invalid log name: %q", name)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.logs == nil {
+		return nil, errors.New("manager is closed")
+	}
+	ml, ok := m.logs[name]
+	if !ok {
+		ml = &managedLog{}
+		m.logs[name] = ml
+	}
+	if ml.log == nil {
+		dir := filepath.Join(m.Dir, name)
+		err := os.MkdirAll(dir, 0755)
+		var l *Log
+		if err == nil {
+			l, err = NewLog(dir, m.Config.Log)
+		}
+		if err != nil {
+			if !ok {
+				delete(m.logs, name)
+			}
+			return nil, err
+		}
+		ml.log = l
+	}
+	m.clock++
+	ml.lastUsed = m.clock
+	ml.refs++
+	// 上限を超えた場合は使用していないログストアを閉じる（失敗しても次の Acquire で再試行する）
+	_ = m.evict()
+	return ml.log, nil
+}
+
+// Release: Acquire したログストアの使用を終える
+func (m *Manager) Release(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ml, ok := m.logs[name]; ok && ml.refs > 0 {
+		ml.refs--
+	}
+	_ = m.evict()
+}
+
+// evict: ファイルディスクリプタとメモリの上限を超えている間、使用していないログストアを古い順に閉じる（内部関数）
+// 呼び出し側で m.mu のロックを取得しておく必要がある。
+func (m *Manager) evict() error {
+	if m.Config.MaxOpenFiles == 0 && m.Config.MaxMemoryBytes == 0 {
+		return nil
+	}
+	var (
+		files  int
+		memory uint64
+		idle   []string
+	)
+	for name, ml := range m.logs {
+		if ml.log == nil {
+			continue
+		}
+		f, mem := ml.log.resources()
+		files += f
+		memory += mem
+		if ml.refs == 0 {
+			idle = append(idle, name)
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool { return m.logs[idle[i]].lastUsed < m.logs[idle[j]].lastUsed })
+	for _, name := range idle {
+		if m.withinBudget(files, memory) {
+			break
+		}
+		ml := m.logs[name]
+		f, mem := ml.log.resources()
+		err := ml.log.Close()
+		ml.log = nil
+		if err != nil {
+			return err
+		}
+		files -= f
+		memory -= mem
+	}
+	return nil
+}
+
+// withinBudget: 使用量が上限以内かどうかを返す（内部関数）
+func (m *Manager) withinBudget(files int, memory uint64) bool {
+	return (m.Config.MaxOpenFiles == 0 || files <= m.Config.MaxOpenFiles) &&
+		(m.Config.MaxMemoryBytes == 0 || memory <= m.Config.MaxMemoryBytes)
+}
+
+// resources: 開いているログストアが使用するファイルディスクリプタの数と、メモリにマップしたインデックスのバイト数を返す（内部関数）
+func (l *Log) resources() (files int, memory uint64) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	files = 1 + 2*len(l.segments) // ロックファイルと、セグメントごとのストアとインデックス
+	if l.activeSegment.timersFile != nil {
+		files++
+	}
+	return files, uint64(len(l.segments)) * l.Config.Segment.MaxIndexBytes
+}
+
+// Open: 開いているログストアの名前を名前の順に返す
+func (m *Manager) Open() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var names []string
+	for name, ml := range m.logs {
+		if ml.log != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// List: すべてのログストア（閉じているものを含む）の名前を名前の順に返す
+func (m *Manager) List() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.logs))
+	for name := range m.logs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Remove: ログストアを閉じてディレクトリごと削除する
+// 戻り値:
+//   - error: 使用中の場合は ErrLogInUse、存在しない場合は os.ErrNotExist をラップしたエラー
+func (m *Manager) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ml, ok := m.logs[name]
+	if !ok {
+		return fmt.Errorf("log %q: %w", name, os.ErrNotExist)
+	}
+	if ml.refs > 0 {
+		return fmt.Errorf("log %q: %w", name, ErrLogInUse)
+	}
+	if ml.log != nil {
+		if err := ml.log.Close(); err != nil {
+			return err
+		}
+		ml.log = nil
+	}
+	delete(m.logs, name)
+	return os.RemoveAll(filepath.Join(m.Dir, name))
+}
+
+// Close: 定期的な処理を停止し、開いているすべてのログストアを閉じる
+// 戻り値:
+//   - error: エラーが発生した場合（失敗しても残りのログストアは閉じる）
+func (m *Manager) Close() error {
+	// 定期的な処理はロックを取得するため、ロックを取得する前に停止を待つ
+	close(m.done)
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var err error
+	for _, ml := range m.logs {
+		if ml.log == nil {
+			continue
+		}
+		if cerr := ml.log.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	m.logs = nil
+	return err
+}
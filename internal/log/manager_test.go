@@ -0,0 +1,88 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(time.UnixMilli(1700000000000))
+	c := ManagerConfig{MaxOpenFiles: 6} // 1つのセグメントのログストア（3ファイル）を2つまで開いておく
+	c.Log.Segment.FlushInterval = time.Second
+	c.Log.Segment.MaxAge = time.Hour
+	c.Log.Segment.Scrub.Interval = time.Hour
+	c.Log.Clock = clock
+	m, err := NewManager(dir, c)
+	require.NoError(t, err)
+
+	for _, name := range []string{"a", "b", "c"} {
+		l, err := m.Acquire(name)
+		require.NoError(t, err)
+		_, err = l.Append(&api.Record{Value: []byte(name)})
+		require.NoError(t, err)
+		m.Release(name)
+	}
+	// 上限を超えたため、最後に使用した時刻の古いログストアから閉じる
+	require.Equal(t, []string{"b", "c"}, m.Open())
+	require.Equal(t, []string{"a", "b", "c"}, m.List())
+
+	// 閉じたログストアは次の Acquire で開き直す（使用中のログストアは閉じない）
+	a, err := m.Acquire("a")
+	require.NoError(t, err)
+	record, err := a.Read(0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("a"), record.Value)
+	require.Equal(t, []string{"a", "c"}, m.Open())
+	_, err = m.Acquire("b")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, m.Open())
+	require.ErrorIs(t, m.Remove("a"), ErrLogInUse)
+
+	// 定期的なフラッシュは Manager の共有のゴルーチンが開いているログストアに対して行う
+	_, err = a.Append(&api.Record{Value: []byte("flushed")})
+	require.NoError(t, err)
+	store := filepath.Join(dir, "a", "0.store")
+	before, err := os.Stat(store)
+	require.NoError(t, err)
+	clock.Advance(time.Second)
+	require.Eventually(t, func() bool {
+		fi, err := os.Stat(store)
+		return err == nil && fi.Size() > before.Size()
+	}, time.Second, 10*time.Millisecond)
+
+	// リセットしても、ログストアごとの定期的な処理のゴルーチンは開始しない
+	require.NoError(t, a.ResetAt(5))
+	require.Nil(t, a.flushDone)
+	require.Nil(t, a.rollDone)
+	require.Nil(t, a.scrubDone)
+	off, err := a.Append(&api.Record{Value: []byte("reset")})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), off)
+
+	m.Release("a")
+	m.Release("b")
+	require.NoError(t, m.Remove("c"))
+	require.NoDirExists(t, filepath.Join(dir, "c"))
+	require.Error(t, m.Remove("c"))
+	_, err = m.Acquire("../x")
+	require.Error(t, err)
+	require.NoError(t, m.Close())
+
+	// 開き直した Manager は既存のログストアを一覧に加えるが、Acquire するまで開かない
+	m, err = NewManager(dir, c)
+	require.NoError(t, err)
+	defer m.Close()
+	require.Equal(t, []string{"a", "b"}, m.List())
+	require.Empty(t, m.Open())
+	b, err := m.Acquire("b")
+	require.NoError(t, err)
+	record, err = b.Read(0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("b"), record.Value)
+}
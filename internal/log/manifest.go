@@ -0,0 +1,69 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// manifestFile: ログディレクトリに置くマニフェストファイルの名前
+const manifestFile = "manifest.json"
+
+// manifest: ログ全体に関するメタデータ
+// セグメントファイルとは別に保存し、ログを開き直しても状態を復元できるようにする。
+type manifest struct {
+	// Epoch: オフセットのエポック（Reset されるたびにインクリメントされる）
+	// コンシューマーはエポックの変化を見てログがリセットされたことを検知し、
+	// 古いチェックポイント（コミット済みオフセット）を無効にできる。
+	Epoch uint64 `json:"epoch"`
+	// InitialOffset: 現在のエポックでログが開始したオフセット
+	// セグメントがない場合（新規ログストア、または ResetAt の直後）は、このオフセットから最初のセグメントを作成する。
+	InitialOffset uint64 `json:"initial_offset"`
+	// Format: ストアの保存形式（以前のバージョンで作成されたマニフェストにはないため、0 の場合は FormatV1）
+	Format int `json:"format,omitempty"`
+}
+
+// readManifest: ディレクトリからマニフェストを読み込む
+// 引数:
+//   - dir: ログディレクトリ
+//
+// 戻り値:
+//   - manifest: 読み込んだマニフェスト
+//   - bool: マニフェストファイルが存在した場合 true
+//   - error: エラーが発生した場合
+func readManifest(dir string) (manifest, bool, error) {
+	var m manifest
+	b, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, false, nil
+	}
+	if err != nil {
+		return m, false, err
+	}
+	if err = json.Unmarshal(b, &m); err != nil {
+		return m, false, err
+	}
+	return m, true, nil
+}
+
+// writeManifest: マニフェストをディレクトリに書き込む
+// 一時ファイルに書き込んでからリネームすることで、書き込み途中でクラッシュしても
+// 壊れたマニフェストが残らないようにする。
+// 引数:
+//   - dir: ログディレクトリ
+//   - m: 書き込むマニフェスト
+//
+// 戻り値:
+//   - error: エラーが発生した場合
+func writeManifest(dir string, m manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, manifestFile+".tmp")
+	if err = os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, manifestFile))
+}
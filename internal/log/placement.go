@@ -0,0 +1,97 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// dataDirs: トピックを配置するデータディレクトリの一覧を作成する（内部関数）
+// 追加のデータディレクトリは存在しなければ作成する。
+// 引数:
+//   - dir: NewTopics に指定されたディレクトリ（最初のデータディレクトリ）
+//   - extra: Config.Topic.DataDirs
+//
+// 戻り値:
+//   - []string: データディレクトリ（dir が先頭）
+//   - error: 同じディレクトリが重複している場合や、ディレクトリを作成できない場合
+func dataDirs(dir string, extra []string) ([]string, error) {
+	dirs := []string{dir}
+	seen := map[string]bool{filepath.Clean(dir): true}
+	for _, d := range extra {
+		if seen[filepath.Clean(d)] {
+			return nil, fmt.Errorf("duplicate data directory: %s", d)
+		}
+		seen[filepath.Clean(d)] = true
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs, nil
+}
+
+// DataDir: トピックを配置しているデータディレクトリを返す
+// 引数:
+//   - name: トピック名
+//
+// 戻り値:
+//   - string: データディレクトリ（Dir または Config.Topic.DataDirs のいずれか）
+//   - error: トピックが存在しない場合（api.ErrTopicNotFound）
+func (t *Topics) DataDir(name string) (string, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	dataDir, ok := t.placement[name]
+	if !ok {
+		return "", api.ErrTopicNotFound{Topic: name}
+	}
+	return dataDir, nil
+}
+
+// place: 新しいトピックを配置するデータディレクトリを選ぶ（内部関数）
+// 空き容量が最も大きいデータディレクトリを選び、同じ場合は配置しているトピックが少ない方を選ぶ。
+// 空き容量を取得できないデータディレクトリ（ディスクの障害など）には配置しない。
+// 呼び出し側で t.mu のロックを取得しておく必要がある。
+// 戻り値:
+//   - string: 選んだデータディレクトリ
+//   - error: どのデータディレクトリの空き容量も取得できない場合
+func (t *Topics) place() (string, error) {
+	if len(t.dirs) == 1 {
+		return t.dirs[0], nil
+	}
+	counts := make(map[string]int, len(t.dirs))
+	for _, dataDir := range t.placement {
+		counts[dataDir]++
+	}
+	var best string
+	var bestFree uint64
+	var bestCount int
+	var lastErr error
+	for _, dataDir := range t.dirs {
+		free, err := t.freeBytes(dataDir)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if best == "" || free > bestFree || (free == bestFree && counts[dataDir] < bestCount) {
+			best, bestFree, bestCount = dataDir, free, counts[dataDir]
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no usable data directory: %w", lastErr)
+	}
+	return best, nil
+}
+
+// diskFreeBytes: ディレクトリのファイルシステムの空き容量（一般ユーザーが使用できるバイト数）を返す
+func diskFreeBytes(dir string) (uint64, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(dir, &fs); err != nil {
+		return 0, err
+	}
+	return fs.Bavail * uint64(fs.Bsize), nil
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// producersFile: プロデューサーの重複排除の状態を保存するファイルの名前
+const producersFile = "producers.json"
+
+// producerState: プロデューサーごとの重複排除の状態
+type producerState struct {
+	Sequence uint64 `json:"sequence"` // 最後に追加したレコードのシーケンス番号
+	Offset   uint64 `json:"offset"`   // 最後に追加したレコードのオフセット
+}
+
+// producerSnapshot: プロデューサーの重複排除の状態のスナップショット
+// スナップショットの後に追加されたレコードは、レコード自体に記録されたプロデューサー ID と
+// シーケンス番号から再構築するため、スナップショットは毎回の書き込みで保存する必要はない。
+type producerSnapshot struct {
+	// NextOffset: スナップショットに反映されているのは、このオフセットより前のレコード
+	NextOffset uint64                   `json:"next_offset"`
+	Producers  map[string]producerState `json:"producers"`
+}
+
+// checkProducer: プロデューサー ID 付きのレコードが重複していないかを確認する（内部関数）
+// 引数:
+//   - record: 追加するレコード
+//
+// 戻り値:
+//   - uint64: 重複したレコードの場合、最初に追加したときのオフセット
+//   - bool: 重複したレコード（直前のシーケンス番号の再送）の場合 true
+//   - error: 直前より古いシーケンス番号の場合（api.ErrOutOfOrderSequence）
+func (l *Log) checkProducer(record *api.Record) (uint64, bool, error) {
+	if record.ProducerId == "" {
+		return 0, false, nil
+	}
+	p, ok := l.producers[record.ProducerId]
+	if !ok || record.Sequence > p.Sequence {
+		return 0, false, nil
+	}
+	if record.Sequence == p.Sequence {
+		return p.Offset, true, nil
+	}
+	return 0, false, api.ErrOutOfOrderSequence{
+		ProducerId: record.ProducerId,
+		Sequence:   record.Sequence,
+		Last:       p.Sequence,
+	}
+}
+
+// trackProducer: 追加したレコードのシーケンス番号を記録する（内部関数）
+func (l *Log) trackProducer(record *api.Record) {
+	if record.ProducerId == "" {
+		return
+	}
+	l.producers[record.ProducerId] = producerState{
+		Sequence: record.Sequence,
+		Offset:   record.Offset,
+	}
+}
+
+// loadProducers: スナップショットとその後に追加されたレコードから重複排除の状態を復元する（内部関数）
+// 戻り値:
+//   - error: エラーが発生した場合
+func (l *Log) loadProducers() error {
+	var snap producerSnapshot
+	b, err := os.ReadFile(filepath.Join(l.Dir, producersFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err = json.Unmarshal(b, &snap); err != nil {
+			return err
+		}
+	}
+	l.producers = snap.Producers
+	if l.producers == nil {
+		l.producers = make(map[string]producerState)
+	}
+	return l.scanProducers(snap.NextOffset)
+}
+
+// scanProducers: from 以降のレコードを読み取り、重複排除の状態に反映する（内部関数）
+// 引数:
+//   - from: 読み取りを開始するオフセット
+//
+// 戻り値:
+//   - error: エラーが発生した場合
+func (l *Log) scanProducers(from uint64) error {
+	for _, s := range l.segments {
+		off := max(from, s.baseOffset)
+		for ; off < s.nextOffset; off++ {
+			record, err := s.Read(off)
+			if err != nil {
+				return err
+			}
+			l.trackProducer(record)
+		}
+	}
+	return nil
+}
+
+// writeProducers: 重複排除の状態のスナップショットを保存する（内部関数）
+// マニフェストと同様に、一時ファイルに書き込んでからリネームする。
+// 戻り値:
+//   - error: エラーが発生した場合
+func (l *Log) writeProducers() error {
+	b, err := json.Marshal(producerSnapshot{
+		NextOffset: l.activeSegment.nextOffset,
+		Producers:  l.producers,
+	})
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(l.Dir, producersFile+".tmp")
+	if err = os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(l.Dir, producersFile))
+}
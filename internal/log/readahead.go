@@ -0,0 +1,172 @@
+package log
+
+import (
+	"io"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// SequentialReader: オフセット順にレコードを読み取るための先読みリーダー
+// ConsumeStream のように連続したオフセットを順番に読む場合、レコードごとにストアファイルへ
+// ランダムアクセスするとコールドキャッシュ時にシークが毎回発生してしまう。
+// そこでストアファイルの次のチャンクをまとめてバッファに読み込み、続くレコードはバッファから返す。
+// ストリームごとに1つ作成して使う（並行利用は想定しない）。
+type SequentialReader struct {
+	log *Log
+
+	seg  *segment // buf を読み込んだセグメント（セグメントが変わったらバッファを捨てる）
+	buf  []byte   // 先読みしたストアファイルのデータ
+	base uint64   // buf[0] に対応するストアファイル内の位置
+
+	batch     [][]byte // 直前に読み取ったバッチのレコード（同じバッチのレコードを続けて読むときに再利用する）
+	batchPos  uint64   // batch のストアファイル内の位置
+	batchBase uint64   // batch の先頭のレコードのオフセット
+}
+
+// NewSequentialReader: 新しい先読みリーダーを作成する
+// 戻り値:
+//   - *SequentialReader: このログから順次読み取りを行うリーダー
+func (l *Log) NewSequentialReader() *SequentialReader {
+	return &SequentialReader{log: l}
+}
+
+// Read: 指定されたオフセットのレコードを読み取る
+// 先読みバッファにレコードが含まれていればバッファから返し、含まれていなければ
+// レコードの位置から ReadAheadBytes 分をまとめて読み込み直す。
+// 引数:
+//   - off: 読み取るレコードのオフセット（絶対オフセット）
+//
+// 戻り値:
+//   - *api.Record: 読み取ったレコード
+//   - error: エラーが発生した場合（オフセットが見つからない場合など）
+func (r *SequentialReader) Read(off uint64) (*api.Record, error) {
+	r.log.mu.RLock()
+	defer r.log.mu.RUnlock()
+
+	s := r.log.findSegment(off)
+	if s == nil {
+		return nil, api.ErrOffsetOutOfRange{Offset: off}
+	}
+	if s.quarantine != nil {
+		return nil, *s.quarantine
+	}
+
+	// インデックスからストア内の位置を取得
+	_, pos, err := s.index.Read(int64(off - s.baseOffset))
+	if err != nil {
+		return nil, err
+	}
+
+	// セグメントが切り替わった場合、以前のバッファは使えないので捨てる
+	if r.seg != s {
+		r.seg = s
+		r.buf = r.buf[:0]
+		r.batch = nil
+	}
+
+	// バッチは一度だけ検証・分割し、同じバッチのレコードは分割済みのレコードから取り出す
+	if r.batch == nil || r.batchPos != pos {
+		p, err := r.record(pos)
+		if err == ErrCorruptRecord {
+			return nil, corruptRecord(off, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !isBatch(p) {
+			record := &api.Record{}
+			if err = proto.Unmarshal(p, record); err != nil {
+				return nil, corruptRecord(off, err)
+			}
+			return record, nil
+		}
+		base, records, err := decodeBatch(p)
+		if err != nil {
+			return nil, corruptRecord(off, err)
+		}
+		r.batch, r.batchPos, r.batchBase = records, pos, base
+	}
+	if off < r.batchBase || off-r.batchBase >= uint64(len(r.batch)) {
+		return nil, corruptRecord(off, ErrCorruptRecord)
+	}
+	record := &api.Record{}
+	if err = proto.Unmarshal(r.batch[off-r.batchBase], record); err != nil {
+		return nil, corruptRecord(off, err)
+	}
+	return record, nil
+}
+
+// record: ストア内の位置 pos にあるレコードのデータを先読みバッファ経由で取得する
+// 引数:
+//   - pos: レコードの長さ情報が始まるストアファイル内の位置
+//
+// 戻り値:
+//   - []byte: レコードのデータ（長さ情報を除く）
+//   - error: エラーが発生した場合
+func (r *SequentialReader) record(pos uint64) ([]byte, error) {
+	// 長さ情報がバッファに含まれていなければ、pos から先読みし直す
+	size, n := r.header(pos)
+	if n == 0 {
+		if err := r.fill(pos); err != nil {
+			return nil, err
+		}
+		if size, n = r.header(pos); n == 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+	}
+
+	// レコードがチャンクより大きい、またはバッファの末尾をまたぐ場合
+	if !r.buffered(pos, n+size) {
+		if size+n > r.log.Config.Segment.ReadAheadBytes {
+			// チャンクに収まらない大きなレコードは直接読み取る
+			return r.seg.store.Read(pos)
+		}
+		if err := r.fill(pos); err != nil {
+			return nil, err
+		}
+		if !r.buffered(pos, n+size) {
+			return nil, io.ErrUnexpectedEOF
+		}
+	}
+
+	start := pos - r.base + n
+	b := make([]byte, size)
+	copy(b, r.buf[start:start+size])
+	return b, nil
+}
+
+// header: バッファから pos にあるレコードの長さ情報をデコードする
+// 戻り値:
+//   - uint64: データのバイト数
+//   - uint64: 長さ情報のバイト数（長さ情報がバッファに含まれていない場合は 0）
+func (r *SequentialReader) header(pos uint64) (uint64, uint64) {
+	if !r.buffered(pos, 1) {
+		return 0, 0
+	}
+	return decodeHeader(r.buf[pos-r.base:], r.seg.store.format)
+}
+
+// buffered: ストア内の [pos, pos+n) の範囲がバッファに含まれているかを判定する
+func (r *SequentialReader) buffered(pos, n uint64) bool {
+	return pos >= r.base && pos+n <= r.base+uint64(len(r.buf))
+}
+
+// fill: ストアファイルの pos から ReadAheadBytes 分をバッファに読み込む
+// ファイル末尾に達した場合は、読み込めた分だけをバッファとして保持する。
+func (r *SequentialReader) fill(pos uint64) error {
+	size := r.log.Config.Segment.ReadAheadBytes
+	if uint64(cap(r.buf)) < size {
+		r.buf = make([]byte, size)
+	}
+	r.buf = r.buf[:size]
+
+	n, err := r.seg.store.ReadAt(r.buf, int64(pos))
+	if err != nil && err != io.EOF {
+		r.buf = r.buf[:0]
+		return err
+	}
+	r.buf = r.buf[:n]
+	r.base = pos
+	return nil
+}
@@ -0,0 +1,348 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// scrubExt: スクラブで記録したストアのチェックサムのファイルの拡張子
+// ファイルの形式: [ストアのバイト数(8バイト)][ストアの CRC32C(4バイト)]
+// 書き込み済みセグメントのストアは変更されないため、最初の検査で記録した値と比べて劣化を検出する
+// （ストアにはレコードごとのチェックサムがないため、最初の検査までに壊れた場合は構造の検査でしか検出できない）。
+const scrubExt = ".scrub"
+
+// scrubChunk: スクラブで一度に読み取るバイト数（読み取りの速度の制限もこの単位で行う）
+const scrubChunk = 64 * 1024
+
+// errScrubStopped: ログストアを閉じたためにスクラブを中断した場合のエラー
+var errScrubStopped = errors.New("scrub stopped")
+
+// corruptionError: スクラブで検出したセグメントの破損（検査を続けられないエラーと区別する）
+type corruptionError struct {
+	error
+}
+
+// ScrubResult: スクラブの結果
+type ScrubResult struct {
+	Segments int      // 検査した書き込み済みセグメントの数
+	Bytes    uint64   // 検査したストアのバイト数
+	Corrupt  []uint64 // 破損を検出して隔離したセグメントの baseOffset
+}
+
+// scrubPath: ストアファイルのパスから、スクラブのチェックサムのファイルのパスを返す
+// 例: "/data/0.store" → "/data/0.scrub"
+func scrubPath(storePath string) string {
+	return strings.TrimSuffix(storePath, ".store") + scrubExt
+}
+
+// Scrub: すべての書き込み済みセグメントを読み取り、破損がないかを検査する
+// ストアのレコードがインデックスのエントリと一致し、すべてのレコード（バッチ）をデコードできることを確認し、
+// ストアのチェックサムを以前の検査で記録した値と比べる（記録がない場合は記録する）。
+// 破損を検出したセグメントは隔離し（読み取りは api.ErrCorruptSegment を返す）、Hooks.OnCorruptSegment を呼び出す。
+// 隔離中のセグメントとアクティブセグメントは検査しない。
+// Config.Segment.Scrub.Interval を設定すると定期的に実行されるが、運用者が任意の時点で実行することもできる。
+// 戻り値:
+//   - ScrubResult: 検査したセグメントと、破損を検出したセグメント
+//   - error: 検査を実行できなかった場合（破損の検出はエラーにしない）
+func (l *Log) Scrub() (ScrubResult, error) {
+	return l.scrub(nil)
+}
+
+// startScrubber: 書き込み済みセグメントを Scrub.Interval ごとに検査するゴルーチンを開始する（内部関数）
+// Scrub.Interval が設定されていない場合は何もしない。
+func (l *Log) startScrubber() {
+	if l.Config.Segment.Scrub.Interval == 0 {
+		return
+	}
+	done := make(chan struct{})
+	l.scrubDone = done
+	Every(l.Config.clock(), l.Config.Segment.Scrub.Interval, done, &l.scrubWG, func(time.Time) {
+		// 失敗しても次の間隔で再試行する（破損は Hooks.OnCorruptSegment で通知する）
+		_, _ = l.scrub(done)
+	})
+}
+
+// stopScrubber: 定期的なスクラブを停止し、ゴルーチンの終了を待つ（内部関数）
+// 検査の途中の場合は、読み取りの区切りで中断する。
+func (l *Log) stopScrubber() {
+	if l.scrubDone == nil {
+		return
+	}
+	close(l.scrubDone)
+	l.scrubDone = nil
+	l.scrubWG.Wait()
+}
+
+// scrub: 書き込み済みセグメントを検査する（内部関数）
+// セグメントの参照を取得してからロックを外して読み取るため、検査中も読み書きを止めない。
+// 引数:
+//   - done: 閉じられたら検査を中断するチャネル（nil の場合は中断しない）
+func (l *Log) scrub(done <-chan struct{}) (ScrubResult, error) {
+	l.mu.RLock()
+	var segments []*segment
+	for _, s := range l.segments {
+		if s == l.activeSegment.segment || s.quarantine != nil {
+			continue
+		}
+		s.acquire()
+		segments = append(segments, s)
+	}
+	l.mu.RUnlock()
+
+	var result ScrubResult
+	var err error
+	pace := &scrubPacer{done: done, rate: l.Config.Segment.Scrub.BytesPerSecond}
+	for _, s := range segments {
+		// 中断した場合やエラーが発生した場合も、残りのセグメントの参照は外す
+		if err == nil {
+			serr := l.scrubSegment(s, pace)
+			var corrupt corruptionError
+			switch {
+			case errors.As(serr, &corrupt):
+				if l.quarantineSegment(s, corrupt.error) {
+					result.Corrupt = append(result.Corrupt, s.baseOffset)
+				}
+				fallthrough
+			case serr == nil:
+				result.Segments++
+				result.Bytes += s.store.size
+			default:
+				err = serr
+			}
+		}
+		if rerr := s.release(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	return result, err
+}
+
+// quarantineSegment: セグメントを隔離し、Hooks.OnCorruptSegment を呼び出す（内部関数）
+// 検査中に Truncate でログストアから外されたセグメントは隔離しない。
+// 戻り値:
+//   - bool: 隔離した場合 true
+func (l *Log) quarantineSegment(s *segment, corrupt error) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	found := false
+	for _, seg := range l.segments {
+		found = found || seg == s
+	}
+	if !found || s.quarantine != nil {
+		return false
+	}
+	s.quarantine = &api.ErrCorruptSegment{
+		BaseOffset: s.baseOffset,
+		NextOffset: s.nextOffset,
+		Reason:     corrupt.Error(),
+	}
+	l.Config.Hooks.onCorruptSegment(s.baseOffset, corrupt)
+	return true
+}
+
+// scrubSegment: 書き込み済みセグメントのストアを先頭から読み取って検査する（内部関数）
+// 戻り値:
+//   - error: 破損を検出した場合は corruptionError、検査を続けられない場合はそれ以外のエラー
+//     （中断した場合は errScrubStopped）
+func (l *Log) scrubSegment(s *segment, pace *scrubPacer) error {
+	crc := crc32.New(castagnoli)
+	r := &countingReader{r: io.TeeReader(pace.reader(io.NewSectionReader(s.store, 0, int64(s.store.size))), crc)}
+	if err := s.scrubRecords(bufio.NewReaderSize(r, scrubChunk), r); err != nil {
+		if errors.Is(err, errScrubStopped) {
+			return err
+		}
+		return corruptionError{err}
+	}
+	return s.checkScrubSum(crc)
+}
+
+// scrubRecords: ストアのレコードを順に読み取り、インデックスのエントリと一致することを確認する（内部関数）
+// レコード（バッチの場合はバッチ内のレコード）ごとに、インデックスに同じ相対オフセットと位置のエントリがあり、
+// Protocol Buffers としてデコードでき、記録されたオフセットが一致することを確認する。
+// 引数:
+//   - br: ストアの内容
+//   - r: br が読み取っている Reader（読み取ったバイト数からレコードの位置を求める）
+//
+// 戻り値:
+//   - error: 破損を検出した場合、または読み取りを中断した場合（errScrubStopped）
+func (s *segment) scrubRecords(br *bufio.Reader, r *countingReader) error {
+	entries := s.index.size / entWidth
+	next := uint64(0) // 次に一致を確認するインデックスのエントリ
+	for {
+		pos := r.n - uint64(br.Buffered())
+		size, err := readHeader(br, s.store.format)
+		if err == io.EOF && pos == s.store.size {
+			break
+		}
+		if err != nil {
+			return scrubError(pos, err)
+		}
+		if !s.store.validSize(pos, r.n-uint64(br.Buffered())-pos, size) {
+			return scrubError(pos, ErrCorruptRecord)
+		}
+		p := make([]byte, size)
+		if _, err = io.ReadFull(br, p); err != nil {
+			return scrubError(pos, err)
+		}
+
+		records := [][]byte{p}
+		if isBatch(p) {
+			base, batch, err := decodeBatch(p)
+			if err != nil {
+				return scrubError(pos, err)
+			}
+			if base != s.baseOffset+next {
+				return fmt.Errorf("batch at position %d starts at offset %d, want %d", pos, base, s.baseOffset+next)
+			}
+			records = batch
+		}
+		for _, b := range records {
+			if next >= entries {
+				return fmt.Errorf("record at position %d has no index entry", pos)
+			}
+			off, entryPos, err := s.index.Read(int64(next))
+			if err != nil {
+				return err
+			}
+			if uint64(off) != next || entryPos != pos {
+				return fmt.Errorf("%w: index entry %d points to offset %d at position %d, want position %d",
+					ErrIndexMismatch, next, off, entryPos, pos)
+			}
+			record := &api.Record{}
+			if err = proto.Unmarshal(b, record); err != nil {
+				return scrubError(pos, err)
+			}
+			if record.Offset != s.baseOffset+next {
+				return fmt.Errorf("record at position %d has offset %d, want %d", pos, record.Offset, s.baseOffset+next)
+			}
+			next++
+		}
+	}
+	if next != entries {
+		return fmt.Errorf("%w: %d index entries for %d records", ErrIndexMismatch, entries, next)
+	}
+	return nil
+}
+
+// scrubError: ストアの読み取りで発生したエラーを、破損の内容として返す（内部関数）
+// 中断による errScrubStopped はそのまま返す。
+func scrubError(pos uint64, err error) error {
+	if errors.Is(err, errScrubStopped) {
+		return err
+	}
+	return fmt.Errorf("record at position %d: %w", pos, err)
+}
+
+// checkScrubSum: ストアのチェックサムを以前の検査で記録した値と比べる（内部関数）
+// 記録がない場合（まだ検査していない、または分割した）は記録する。
+// 戻り値:
+//   - error: チェックサムが一致しない場合は corruptionError、チェックサムのファイルを読み書きできない場合はそれ以外のエラー
+func (s *segment) checkScrubSum(crc hash.Hash32) error {
+	sum := enc.AppendUint32(enc.AppendUint64(nil, s.store.size), crc.Sum32())
+	path := scrubPath(s.store.Name())
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if _, err = writeTempFile(path+".tmp", bytes.NewReader(sum)); err != nil {
+			return err
+		}
+		return os.Rename(path+".tmp", path)
+	}
+	if err != nil {
+		return err
+	}
+	if len(want) != len(sum) {
+		return corruptionError{fmt.Errorf("invalid scrub checksum file %s", path)}
+	}
+	if size := enc.Uint64(want); size != s.store.size {
+		return corruptionError{fmt.Errorf("store size %d does not match the scrubbed size %d", s.store.size, size)}
+	}
+	if c := enc.Uint32(want[lenWidth:]); c != crc.Sum32() {
+		return corruptionError{fmt.Errorf("store checksum %08x does not match the scrubbed checksum %08x", crc.Sum32(), c)}
+	}
+	return nil
+}
+
+// countingReader: 読み取ったバイト数を数える Reader
+type countingReader struct {
+	r io.Reader
+	n uint64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += uint64(n)
+	return n, err
+}
+
+// scrubPacer: スクラブの読み取りの速度を制限する
+// 1回のスクラブで読み取った合計のバイト数が、経過時間 × rate を超えないように待つ。
+// ディスクの帯域の制限のため、Config.Clock ではなく実際の時間で待つ。
+type scrubPacer struct {
+	done  <-chan struct{} // 閉じられたら読み取りを中断する
+	rate  uint64          // 1秒あたりの最大バイト数（0 の場合は制限しない）
+	start time.Time
+	bytes uint64
+}
+
+// reader: 読み取りの速度を制限する Reader を返す
+func (p *scrubPacer) reader(r io.Reader) io.Reader {
+	return &pacedReader{r: r, pace: p}
+}
+
+// wait: n バイトを読み取った後、制限の速度に収まるまで待つ
+// 戻り値:
+//   - error: 待っている間に中断された場合（errScrubStopped）
+func (p *scrubPacer) wait(n int) error {
+	select {
+	case <-p.done:
+		return errScrubStopped
+	default:
+	}
+	if p.rate == 0 {
+		return nil
+	}
+	if p.start.IsZero() {
+		p.start = time.Now()
+	}
+	p.bytes += uint64(n)
+	ahead := time.Duration(float64(p.bytes)/float64(p.rate)*float64(time.Second)) - time.Since(p.start)
+	if ahead <= 0 {
+		return nil
+	}
+	t := time.NewTimer(ahead)
+	defer t.Stop()
+	select {
+	case <-p.done:
+		return errScrubStopped
+	case <-t.C:
+		return nil
+	}
+}
+
+// pacedReader: scrubPacer で読み取りの速度を制限する Reader
+type pacedReader struct {
+	r    io.Reader
+	pace *scrubPacer
+}
+
+func (r *pacedReader) Read(p []byte) (int, error) {
+	if len(p) > scrubChunk {
+		p = p[:scrubChunk]
+	}
+	n, err := r.r.Read(p)
+	if werr := r.pace.wait(n); werr != nil {
+		return n, werr
+	}
+	return n, err
+}
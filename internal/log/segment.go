@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync/atomic"
+	"time"
 
 	api "github.com/kentakki416/proglog/api/v1"
 
@@ -15,11 +17,70 @@ import (
 // 組み合わせたログの基本単位。ディスク容量が有限なため、ログを複数のセグメントに分割して管理する。
 // 各セグメントは baseOffset から始まる連続したオフセット範囲を担当する。
 type segment struct {
-	store      *store // ストアファイル（実際のレコードデータを保存）
-	index      *index // インデックスファイル（オフセットとストア内位置の対応表）
-	baseOffset uint64 // このセグメントの開始オフセット（例: 0, 1000, 2000）
-	nextOffset uint64 // 次のレコードを追加する際の絶対オフセット（例: 0, 1001, 2001）
-	config     Config // セグメントの設定（最大サイズなど）
+	store      *store    // ストアファイル（実際のレコードデータを保存）
+	index      *index    // インデックスファイル（オフセットとストア内位置の対応表）
+	baseOffset uint64    // このセグメントの開始オフセット（例: 0, 1000, 2000）
+	nextOffset uint64    // 次のレコードを追加する際の絶対オフセット（例: 0, 1001, 2001）
+	config     Config    // セグメントの設定（最大サイズなど）
+	createdAt  time.Time // セグメントの作成時刻（既存のセグメントを開いた場合はストアファイルの最終更新時刻）
+	// スクラブで検出した破損（nil でない場合は隔離中で、読み取りはこのエラーを返す）
+	// Log のロックを取得して読み書きする。
+	quarantine *api.ErrCorruptSegment
+	// 参照カウント（ログストアの参照 1 と、ロックの外で読み取り中の Reader などの参照の合計）
+	// Truncate はログストアの参照を外すだけで、読み取り中の参照がすべて外れた時点でセグメントを削除する。
+	refs atomic.Int32
+	// ファイルを置き換えてログストアから外したセグメント（最後の参照が外れたときは閉じるだけで、ファイルは削除しない）
+	detached atomic.Bool
+	// 配信時刻を指定したレコードのタイマーインデックス（オフセットの順、Log のロックを取得して読み書きする）
+	timers []timer
+	// タイマーインデックスのファイル（アクティブセグメントで最初に配信時刻を指定したレコードを追加するときに開く）
+	timersFile *os.File
+}
+
+// writableSegment: レコードを追加できるセグメント（ログストアのアクティブセグメント）
+// 追加のメソッド（Append、AppendBatch）はこの型にだけ定義し、書き込み済みのセグメント（*segment）には
+// 型の上で書き込めないようにする。書き込み済みのセグメントのファイルは seal で読み取り専用にする。
+type writableSegment struct {
+	*segment
+}
+
+// ファイルのパーミッション
+const (
+	segmentFileMode = 0600 // アクティブセグメントのファイル
+	sealedFileMode  = 0400 // 書き込み済みのセグメントのファイル（読み取り専用）
+)
+
+// newWritableSegment: レコードを追加できるセグメントを作成または既存のセグメントを開く
+// ログストアのアクティブセグメントと、自己診断などの一時的なセグメントにだけ使用する。
+func newWritableSegment(dir string, baseOffset uint64, c Config) (*writableSegment, error) {
+	s, err := newSegment(dir, baseOffset, c)
+	if err != nil {
+		return nil, err
+	}
+	return &writableSegment{segment: s}, nil
+}
+
+// seal: 書き込み済みになったセグメントのファイルを読み取り専用にする
+// 開いているファイルは閉じるまでそのまま使えるため、インデックスを閉じるときの切り詰めなどには影響しない。
+// ファイルが既に削除されている場合（InstallSegment で置き換えた空のセグメントなど）は何もしない。
+func (s *segment) seal() error {
+	for _, name := range []string{s.store.Name(), s.index.Name(), timersPath(s.store.Name())} {
+		if err := os.Chmod(name, sealedFileMode); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsealFiles: 読み取り専用にしたセグメントのファイルを書き込み可能に戻す（内部関数）
+// セグメントを開くとき（インデックスを mmap のために拡張する）に使用する。
+func unsealFiles(paths ...string) error {
+	for _, path := range paths {
+		if err := os.Chmod(path, segmentFileMode); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
 }
 
 // newSegment: 新しいセグメントを作成または既存のセグメントを開く
@@ -37,21 +98,46 @@ func newSegment(dir string, baseOffset uint64, c Config) (*segment, error) {
 		baseOffset: baseOffset,
 		config:     c,
 	}
+	s.refs.Store(1)
+
+	// ファイル名: "{baseOffset}.store"、"{baseOffset}.index"（例: "0.store", "1000.index"）
+	storePath := filepath.Join(dir, fmt.Sprintf("%d%s", baseOffset, ".store"))
+	indexPath := filepath.Join(dir, fmt.Sprintf("%d%s", baseOffset, ".index"))
 
-	// ストアファイルを開く、なければ作成
-	// ファイル名: "{baseOffset}.store"（例: "0.store", "1000.store"）
-	// O_RDWR: 読み書き可能、O_CREATE: 存在しなければ作成、O_APPEND: 追加モード
-	storeFile, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("%d%s", baseOffset, ".store")), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	// 新しいセグメントのファイルは一時ファイルに作成してからリネームする
+	// （作成中にクラッシュしても、中途半端なファイルが setup で開かれないように）
+	if _, err := os.Stat(storePath); os.IsNotExist(err) {
+		if err = createSegmentFiles(dir, indexPath, storePath); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else if err = unsealFiles(storePath, indexPath, timersPath(storePath)); err != nil {
+		return nil, err
+	}
+
+	// ストアファイルを開く
+	// O_RDWR: 読み書き可能、O_APPEND: 追加モード
+	storeFile, err := os.OpenFile(storePath, os.O_RDWR|os.O_APPEND, segmentFileMode)
 	if err != nil {
 		return nil, err
 	}
-	if s.store, err = newStore(storeFile); err != nil {
+	if s.store, err = newStore(storeFile, c); err != nil {
 		return nil, err
 	}
+	// 作成時刻は保存していないため、既存のセグメントは最終更新時刻を作成時刻とみなす
+	// （経過時間による切り替えが遅れる方向にずれるだけで、早まることはない）
+	s.createdAt = c.clock().Now()
+	if s.store.size > 0 {
+		fi, err := storeFile.Stat()
+		if err != nil {
+			return nil, err
+		}
+		s.createdAt = fi.ModTime()
+	}
 
-	// インデックスファイルを開く、なければ作成
-	// ファイル名: "{baseOffset}.index"（例: "0.index", "1000.index"）
-	indexFile, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("%d%s", baseOffset, ".index")), os.O_RDWR|os.O_CREATE, 0600)
+	// インデックスファイルを開く、なければ作成（ストアファイルだけが残っている場合）
+	indexFile, err := os.OpenFile(indexPath, os.O_RDWR|os.O_CREATE, segmentFileMode)
 	if err != nil {
 		return nil, err
 	}
@@ -59,6 +145,11 @@ func newSegment(dir string, baseOffset uint64, c Config) (*segment, error) {
 		return nil, err
 	}
 
+	// 書き込み途中でクラッシュした場合に備えて、インデックスとストアの整合性を回復する
+	if err = s.recover(); err != nil {
+		return nil, err
+	}
+
 	// 既存のインデックスから最後のエントリを読み取り、nextOffset を決定
 	// インデックスが空（新規セグメント）の場合は baseOffset から開始
 	// 既存のセグメントの場合は、最後のオフセット + 1 から開始
@@ -72,9 +163,121 @@ func newSegment(dir string, baseOffset uint64, c Config) (*segment, error) {
 		// off は baseOffset からの相対位置なので、baseOffset + off + 1 が次のオフセット
 		s.nextOffset = baseOffset + uint64(off) + 1
 	}
+
+	// 配信時刻を指定したレコードのタイマーインデックスを読み込む
+	if err = s.loadTimers(); err != nil {
+		return nil, err
+	}
 	return s, nil
 }
 
+// createSegmentFiles: 新しいセグメントの空のファイルを作成する（内部関数）
+// それぞれ一時ファイル（".tmp"）に作成してディスクに同期してからリネームし、最後にディレクトリを同期する。
+// インデックス、ストアの順に作成するため、ストアファイルがあればインデックスファイルもある。
+// 引数:
+//   - dir: セグメントファイルを保存するディレクトリ
+//   - paths: 作成するファイルのパス（作成する順）
+//
+// 戻り値:
+//   - error: エラーが発生した場合
+func createSegmentFiles(dir string, paths ...string) error {
+	for _, path := range paths {
+		f, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, segmentFileMode)
+		if err != nil {
+			return err
+		}
+		if err = f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+		if err = f.Close(); err != nil {
+			return err
+		}
+		if err = os.Rename(path+".tmp", path); err != nil {
+			return err
+		}
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// recover: クラッシュで途中まで書き込まれたレコードを取り除く
+// インデックスのエントリはストアへの書き込み後に記録されるため、インデックスのエントリを
+// レコードのコミットマーカーとして扱う。末尾から順に、エントリの相対オフセットが位置と一致し、
+// かつ長さ情報とデータ本体がストア内に収まっているエントリを探し、それより後ろの
+// インデックスエントリとストアのデータを切り詰める。
+// これにより、長さ情報だけが書き込まれた状態のレコードを読み取ってしまうことを防ぐ。
+//
+// 戻り値:
+//   - error: エラーが発生した場合
+func (s *segment) recover() error {
+	end := uint64(0)
+	for s.index.size > 0 {
+		n := s.index.size/entWidth - 1
+		off, pos, err := s.index.Read(int64(n))
+		if err != nil {
+			return err
+		}
+		if uint64(off) == n {
+			// 開いた直後のストアはバッファが空のため、ロックやフラッシュをせずに読み取れる
+			size, hn, err := s.store.header(pos)
+			if err != nil && err != ErrCorruptRecord {
+				return err
+			}
+			if err == nil {
+				// バッチの場合は、バッチ内のすべてのレコードのエントリが記録されている場合のみコミット済みとみなす
+				first, ok, err := s.batchCommitted(pos, n)
+				if err != nil {
+					return err
+				}
+				if ok {
+					end = pos + hn + size
+					break
+				}
+				// バッチのエントリをまとめて取り除く
+				s.index.size = first * entWidth
+				continue
+			}
+		}
+		// コミットされていないエントリを取り除く
+		s.index.size = n * entWidth
+	}
+
+	// 最後のコミット済みレコードより後ろのデータを切り詰める
+	if end < s.store.size {
+		return s.store.truncate(end)
+	}
+	return nil
+}
+
+// batchCommitted: ストアの pos にあるエントリが、相対オフセット n までのインデックスのエントリとともに
+// すべて書き込まれているかを確認する（内部関数）
+// 単一のレコードは常にコミット済みとみなす。
+// 戻り値:
+//   - uint64: コミットされていない場合に取り除くべき最初のエントリの相対オフセット
+//   - bool: コミット済みの場合 true
+//   - error: ストアの読み取りに失敗した場合
+func (s *segment) batchCommitted(pos, n uint64) (uint64, bool, error) {
+	p, err := s.store.Read(pos)
+	if err != nil {
+		return 0, false, err
+	}
+	if !isBatch(p) {
+		return 0, true, nil
+	}
+	base, records, err := decodeBatch(p)
+	if err != nil || base < s.baseOffset || base-s.baseOffset > n {
+		// 壊れたバッチはこのエントリだけを取り除く（残りのエントリも同じ位置を指すため、順に取り除かれる）
+		return n, false, nil
+	}
+	first := base - s.baseOffset
+	return first, first+uint64(len(records))-1 == n, nil
+}
+
 // Append: レコードをセグメントに追加する
 // プロセス:
 //  1. レコードにオフセットを設定
@@ -89,7 +292,7 @@ func newSegment(dir string, baseOffset uint64, c Config) (*segment, error) {
 // 戻り値:
 //   - offset: 割り当てられたオフセット（例: 0, 1, 2, ... または 1000, 1001, 1002, ...）
 //   - error: エラーが発生した場合
-func (s *segment) Append(record *api.Record) (offset uint64, err error) {
+func (s *writableSegment) Append(record *api.Record) (offset uint64, err error) {
 	// 現在の nextOffset をレコードのオフセットとして使用
 	cur := s.nextOffset
 	record.Offset = cur
@@ -107,16 +310,98 @@ func (s *segment) Append(record *api.Record) (offset uint64, err error) {
 		return 0, err
 	}
 
+	// 配信時刻を指定したレコードは、コミットする前にタイマーインデックスに記録する
+	var timers []timer
+	if record.DeliverAfter > 0 {
+		timers = []timer{{deliverAfter: record.DeliverAfter, offset: cur}}
+	}
+	nt, err := s.addTimers(timers)
+	if err != nil {
+		if terr := s.store.truncate(pos); terr != nil {
+			return 0, terr
+		}
+		return 0, err
+	}
+
 	// インデックスに「相対オフセット」と「ストア内位置」の対応を記録
 	// 相対オフセット = 絶対オフセット - baseOffset
 	// 例: baseOffset = 1000, cur = 1005 の場合、相対オフセット = 5
 	//     インデックスには (5, pos) が記録される
 	if err = s.index.Write(uint32(s.nextOffset-uint64(s.baseOffset)), pos); err != nil {
+		// インデックスに記録できなかったレコードをストアから取り除く
+		// （残すと Reader などストアを直接読み取る処理に、存在しないレコードが現れるため）
+		if terr := s.truncateTimers(nt); terr != nil {
+			return 0, terr
+		}
+		if terr := s.store.truncate(pos); terr != nil {
+			return 0, terr
+		}
 		return 0, err
 	}
 
 	// 次のレコード用のオフセットをインクリメント
 	s.nextOffset++
+	s.config.writes.addRecord(uint64(len(p)))
+	return cur, nil
+}
+
+// AppendBatch: 複数のレコードを1つのバッチとしてセグメントに追加する
+// バッチはストアに1回で書き込み、インデックスにはレコードごとにバッチの位置を指すエントリを記録する。
+// 呼び出し側で、インデックスに len(records) 個のエントリを追加する余地があることを確認しておく必要がある。
+// 引数:
+//   - records: 追加するレコード（Offset フィールドは自動設定される）
+//
+// 戻り値:
+//   - offset: 先頭のレコードに割り当てられたオフセット（続くレコードには連続したオフセットが割り当てられる）
+//   - error: エラーが発生した場合
+func (s *writableSegment) AppendBatch(records []*api.Record) (offset uint64, err error) {
+	cur := s.nextOffset
+	ps := make([][]byte, len(records))
+	for i, record := range records {
+		record.Offset = cur + uint64(i)
+		if ps[i], err = proto.Marshal(record); err != nil {
+			return 0, err
+		}
+	}
+
+	_, pos, err := s.store.Append(encodeBatch(cur, ps))
+	if err != nil {
+		return 0, err
+	}
+
+	var timers []timer
+	for _, record := range records {
+		if record.DeliverAfter > 0 {
+			timers = append(timers, timer{deliverAfter: record.DeliverAfter, offset: record.Offset})
+		}
+	}
+	nt, err := s.addTimers(timers)
+	if err != nil {
+		if terr := s.store.truncate(pos); terr != nil {
+			return 0, terr
+		}
+		return 0, err
+	}
+
+	start := s.index.size
+	for i := range records {
+		if err = s.index.Write(uint32(cur+uint64(i)-s.baseOffset), pos); err != nil {
+			// 一部のレコードだけが読み取れる状態にならないように、バッチ全体を取り除く
+			s.index.size = start
+			if terr := s.truncateTimers(nt); terr != nil {
+				return 0, terr
+			}
+			if terr := s.store.truncate(pos); terr != nil {
+				return 0, terr
+			}
+			return 0, err
+		}
+	}
+
+	s.nextOffset += uint64(len(records))
+	for _, p := range ps {
+		s.config.writes.addRecord(uint64(len(p)))
+	}
 	return cur, nil
 }
 
@@ -143,14 +428,26 @@ func (s *segment) Read(off uint64) (*api.Record, error) {
 
 	// ストアファイルから pos の位置からデータを読み取り
 	p, err := s.store.Read(pos)
+	if err == ErrCorruptRecord {
+		return nil, corruptRecord(off, err)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	// Protocol Buffers 形式からレコードにデシリアライズ（バイナリ形式から構造体に変換）
-	record := &api.Record{}
-	err = proto.Unmarshal(p, record)
-	return record, err
+	// バッチの場合は、バッチの中から指定されたオフセットのレコードを取り出す
+	record, err := unmarshalRecord(p, off)
+	if err != nil {
+		return nil, corruptRecord(off, err)
+	}
+	return record, nil
+}
+
+// corruptRecord: レコードのデータが壊れていることを表す api.ErrCorruptRecord を返す（内部関数）
+// 読み取りの I/O エラーとは区別し、コンシューマーが壊れたレコードだけを読み飛ばせるようにする。
+func corruptRecord(off uint64, err error) error {
+	return api.ErrCorruptRecord{Offset: off, Reason: err.Error()}
 }
 
 // IsMaxed: セグメントが最大サイズに達したかどうかをチェック
@@ -182,7 +479,12 @@ func (s *segment) Remove() error {
 	if err := s.Close(); err != nil {
 		return err
 	}
+	return s.removeFiles()
+}
 
+// removeFiles: セグメントのファイルを削除する（内部関数）
+// 開いているファイルは、閉じるまでそのまま読み書きできる。
+func (s *segment) removeFiles() error {
 	// インデックスファイルを削除（例: "0.index"）
 	if err := os.Remove(s.index.Name()); err != nil {
 		return err
@@ -192,9 +494,48 @@ func (s *segment) Remove() error {
 	if err := os.Remove(s.store.Name()); err != nil {
 		return err
 	}
+
+	// スクラブのチェックサムを削除（例: "0.scrub"、まだ検査していないセグメントにはない）
+	if err := os.Remove(scrubPath(s.store.Name())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// タイマーインデックスを削除（例: "0.timers"）
+	if err := os.Remove(timersPath(s.store.Name())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
 	return nil
 }
 
+// acquire: ロックの外で読み取るためにセグメントの参照を取得する（内部関数）
+// セグメントがログストアのセグメントの一覧にある間に（l.mu のロックを取得した状態で）呼び出す必要がある。
+// 読み取りが終わったら release で参照を外す。
+func (s *segment) acquire() {
+	s.refs.Add(1)
+}
+
+// release: セグメントの参照を外す（内部関数）
+// 最後の参照が外れた場合（Truncate でログストアから外された後）は、セグメントを閉じてファイルを削除する。
+// 戻り値:
+//   - error: セグメントの削除に失敗した場合
+func (s *segment) release() error {
+	if s.refs.Add(-1) > 0 {
+		return nil
+	}
+	if s.detached.Load() {
+		return s.Close()
+	}
+	return s.Remove()
+}
+
+// detach: ファイルを置き換えてログストアの一覧から外したセグメントの、ログストアの参照を外す（内部関数）
+// SplitSegment と InstallSegment で同じ名前のファイルに置き換えたセグメントに使用する。
+// 読み取り中の参照がなければすぐに閉じ、あれば最後の参照が外れたときに閉じる（ファイルは削除しない）。
+func (s *segment) detach() error {
+	s.detached.Store(true)
+	return s.release()
+}
+
 // Close: セグメントを閉じてリソースをクリーンアップ
 // インデックスとストアの両方を適切に閉じる
 // プロセス:
@@ -205,13 +546,22 @@ func (s *segment) Remove() error {
 //   - error: エラーが発生した場合
 func (s *segment) Close() error {
 	// インデックスを閉じる（メモリマップの同期、ファイルサイズの調整）
-	if err := s.index.Close(); err != nil {
-		return err
+	// 失敗してもストアは閉じる（バッファのデータを失わないようにするため）
+	ierr := s.index.Close()
+	// タイマーインデックスのファイルを同期して閉じる
+	if s.timersFile != nil {
+		if err := s.timersFile.Sync(); err != nil && ierr == nil {
+			ierr = err
+		}
+		if err := s.timersFile.Close(); err != nil && ierr == nil {
+			ierr = err
+		}
+		s.timersFile = nil
 	}
 
 	// ストアを閉じる（バッファのフラッシュ、ファイルのクローズ）
 	if err := s.store.Close(); err != nil {
 		return err
 	}
-	return nil
+	return ierr
 }
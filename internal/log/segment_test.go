@@ -3,6 +3,7 @@ package log
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
 	api "github.com/kentakki416/proglog/api/v1"
@@ -20,7 +21,7 @@ func TestSegment(t *testing.T) {
 	c.Segment.MaxStoreBytes = 1024
 	c.Segment.MaxIndexBytes = entWidth * 3
 
-	s, err := newSegment(dir, 16, c)
+	s, err := newWritableSegment(dir, 16, c)
 	require.NoError(t, err)
 	require.Equal(t, uint64(16), s.nextOffset)
 	require.False(t, s.IsMaxed())
@@ -43,18 +44,96 @@ func TestSegment(t *testing.T) {
 	require.NoError(t, s.Close())
 
 	p, _ := proto.Marshal(want)
-	c.Segment.MaxStoreBytes = uint64(len(p)+lenWidth) * 4
+	// インデックスに記録されなかった4件目のレコードは再構築時に取り除かれる
+	c.Segment.MaxStoreBytes = uint64(len(p)+lenWidth) * 3
 	c.Segment.MaxIndexBytes = 1024
 	// 既存のセグメントを再構築
-	s, err = newSegment(dir, 16, c)
+	s, err = newWritableSegment(dir, 16, c)
 	require.NoError(t, err)
 	// ストアが最大
 	require.True(t, s.IsMaxed())
 
 	require.NoError(t, s.Remove())
 
-	s, err = newSegment(dir, 16, c)
+	s, err = newWritableSegment(dir, 16, c)
 	require.NoError(t, err)
 	require.False(t, s.IsMaxed())
 	require.NoError(t, s.Close())
 }
+
+func TestSegmentRecover(t *testing.T) {
+	dir, _ := os.MkdirTemp("", "segment-recover-test")
+	defer os.RemoveAll(dir)
+
+	want := &api.Record{Value: []byte("hello world")}
+
+	c := Config{}
+	c.Segment.MaxStoreBytes = 1024
+	c.Segment.MaxIndexBytes = 1024
+
+	s, err := newWritableSegment(dir, 16, c)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = s.Append(want)
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.Close())
+
+	// 最後のレコードのデータ本体が途中までしか書き込まれなかった状態を再現する
+	p, _ := proto.Marshal(want)
+	width := int64(len(p) + lenWidth)
+	storePath := filepath.Join(dir, "16.store")
+	require.NoError(t, os.Truncate(storePath, width*3-4))
+	// クラッシュ時はインデックスが拡張されたままになっている
+	require.NoError(t, os.Truncate(filepath.Join(dir, "16.index"), int64(c.Segment.MaxIndexBytes)))
+
+	s, err = newWritableSegment(dir, 16, c)
+	require.NoError(t, err)
+	require.Equal(t, uint64(18), s.nextOffset)
+	require.Equal(t, uint64(width*2), s.store.size)
+
+	_, err = s.Read(18)
+	require.Error(t, err)
+
+	off, err := s.Append(want)
+	require.NoError(t, err)
+	require.Equal(t, uint64(18), off)
+	got, err := s.Read(off)
+	require.NoError(t, err)
+	require.Equal(t, want.Value, got.Value)
+	require.NoError(t, s.Close())
+}
+
+func TestSegmentRecoverBatch(t *testing.T) {
+	dir, _ := os.MkdirTemp("", "segment-recover-batch-test")
+	defer os.RemoveAll(dir)
+
+	c := Config{}
+	c.Segment.MaxStoreBytes = 1024
+	c.Segment.MaxIndexBytes = 1024
+
+	s, err := newWritableSegment(dir, 0, c)
+	require.NoError(t, err)
+	_, err = s.Append(&api.Record{Value: []byte("single")})
+	require.NoError(t, err)
+	size := s.store.size
+	_, err = s.AppendBatch([]*api.Record{
+		{Value: []byte("a")},
+		{Value: []byte("b")},
+		{Value: []byte("c")},
+	})
+	require.NoError(t, err)
+
+	// バッチのインデックスのエントリが途中までしか書き込まれなかった状態を再現する
+	s.index.size -= entWidth
+	require.NoError(t, s.Close())
+
+	// バッチ全体が取り除かれ、バッチの前のレコードだけが残る
+	s, err = newWritableSegment(dir, 0, c)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), s.nextOffset)
+	require.Equal(t, size, s.store.size)
+	_, err = s.Read(1)
+	require.Error(t, err)
+	require.NoError(t, s.Close())
+}
@@ -0,0 +1,167 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrSelfTest: 起動時の自己診断で、ストアやインデックスのエンコードが想定と異なっていた場合のエラー
+// 異なるバージョンのバイナリやビルド環境の違い（エンディアンなど）で保存形式がずれたまま起動すると、
+// 既存のログを誤って読み取ったり、他のノードが読めないセグメントを書き込んだりするため、ログストアを開かない。
+var ErrSelfTest = errors.New("self-test failed")
+
+// selfTestDir: 自己診断のセグメントを作成する一時ディレクトリの名前の接頭辞
+const selfTestDir = ".selftest-"
+
+// selfTested: 自己診断に成功した保存形式（プロセスごとに保存形式ごとに1回だけ実行する）
+var selfTested sync.Map
+
+// canaryRecords: 自己診断で書き込むレコード
+// 開始オフセット 1 のセグメントに追加するため、それぞれオフセット 1 と 2 が割り当てられる。
+var canaryRecords = []*api.Record{
+	{Value: []byte("canary"), Key: []byte("k")},
+	{Value: []byte("canary"), Key: []byte("k")},
+}
+
+// canaryPayloads: canaryRecords をシリアライズした結果の既知の値
+// value(1) = "canary"、offset(2) = 1 または 2、key(3) = "k" の順にエンコードされる。
+var canaryPayloads = [][]byte{
+	{0x0a, 0x06, 'c', 'a', 'n', 'a', 'r', 'y', 0x10, 0x01, 0x1a, 0x01, 'k'},
+	{0x0a, 0x06, 'c', 'a', 'n', 'a', 'r', 'y', 0x10, 0x02, 0x1a, 0x01, 'k'},
+}
+
+// canaryHeaders: 保存形式ごとの、長さ情報 13（canaryPayloads のバイト数）と 300 の既知のエンコード
+var canaryHeaders = map[int][2][]byte{
+	FormatV1: {
+		{0, 0, 0, 0, 0, 0, 0, 0x0d},
+		{0, 0, 0, 0, 0, 0, 0x01, 0x2c},
+	},
+	FormatV2: {
+		{0x0d},
+		{0xac, 0x02},
+	},
+}
+
+// selfTest: ログストアを開く前に、保存形式の自己診断を実行する（内部関数）
+// 成功した保存形式はプロセス内で記録し、2回目以降は実行しない。
+// 引数:
+//   - dir: 自己診断のセグメントを一時的に作成するディレクトリ（ログストアと同じファイルシステムで確認するため）
+//   - c: ログストアの設定（Segment.Format は確定済みであること）
+func selfTest(dir string, c Config) error {
+	if _, ok := selfTested.Load(c.Segment.Format); ok {
+		return nil
+	}
+	if err := SelfTest(dir, c); err != nil {
+		return err
+	}
+	selfTested.Store(c.Segment.Format, true)
+	return nil
+}
+
+// SelfTest: ストアとインデックスのエンコードが既知の値と一致するかを確認する
+// 長さ情報のエンコードとデコードを既知の値と比較し、一時ディレクトリに作成したセグメントに
+// 既知のレコードを書き込んで、ファイルの内容と読み取った結果を確認する。
+// NewLog はログストアを開く前に実行し、失敗した場合はログストアを開かない。
+// 引数:
+//   - dir: 自己診断のセグメントを一時的に作成するディレクトリ（終了時に削除する）
+//   - c: ログストアの設定（Segment.Format が 0 の場合は FormatV1）
+//
+// 戻り値:
+//   - error: 一致しなかった場合は ErrSelfTest をラップしたエラー、ファイルの操作に失敗した場合はそのエラー
+func SelfTest(dir string, c Config) error {
+	format := c.Segment.Format
+	if format == 0 {
+		format = FormatV1
+	}
+	headers, ok := canaryHeaders[format]
+	if !ok {
+		return fmt.Errorf("unsupported store format: %d", format)
+	}
+
+	// 長さ情報のエンコードとデコード
+	for i, size := range []uint64{uint64(len(canaryPayloads[0])), 300} {
+		if got := appendHeader(nil, format, size); !bytes.Equal(got, headers[i]) {
+			return fmt.Errorf("%w: format %d encodes length %d as %x, want %x", ErrSelfTest, format, size, got, headers[i])
+		}
+		if got, n := decodeHeader(headers[i], format); got != size || n != uint64(len(headers[i])) {
+			return fmt.Errorf("%w: format %d decodes %x as length %d", ErrSelfTest, format, headers[i], got)
+		}
+	}
+
+	tmp, err := os.MkdirTemp(dir, selfTestDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	// 自己診断のセグメントは設定の保存形式だけを引き継ぎ、カウンターなどには影響させない
+	var sc Config
+	sc.Segment.MaxStoreBytes = 1024
+	sc.Segment.MaxIndexBytes = 1024
+	sc.Segment.Format = format
+	sc.Segment.DirectIO = c.Segment.DirectIO
+	s, err := newWritableSegment(tmp, 1, sc)
+	if err != nil {
+		return err
+	}
+	for _, record := range canaryRecords {
+		if _, err = s.Append(proto.Clone(record).(*api.Record)); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	if err = s.Close(); err != nil {
+		return err
+	}
+
+	// ファイルの内容: ストアは [長さ情報][データ] の繰り返し、インデックスは [相対オフセット(4)][位置(8)] の繰り返し
+	var wantStore, wantIndex []byte
+	for i, payload := range canaryPayloads {
+		wantIndex = append(wantIndex, 0, 0, 0, byte(i))
+		wantIndex = append(wantIndex, 0, 0, 0, 0, 0, 0, 0, byte(len(wantStore)))
+		wantStore = append(wantStore, headers[0]...)
+		wantStore = append(wantStore, payload...)
+	}
+	for _, f := range []struct {
+		name string
+		want []byte
+	}{
+		{"1.store", wantStore},
+		{"1.index", wantIndex},
+	} {
+		got, err := os.ReadFile(filepath.Join(tmp, f.name))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, f.want) {
+			return fmt.Errorf("%w: format %d wrote %s as %x, want %x", ErrSelfTest, format, f.name, got, f.want)
+		}
+	}
+
+	// 開き直して、インデックスから次のオフセットを復元し、書き込んだレコードを読み取れること
+	r, err := newSegment(tmp, 1, sc)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if want := uint64(1 + len(canaryRecords)); r.nextOffset != want {
+		return fmt.Errorf("%w: format %d recovered next offset %d, want %d", ErrSelfTest, format, r.nextOffset, want)
+	}
+	for i, want := range canaryRecords {
+		got, err := r.Read(uint64(1 + i))
+		if err != nil {
+			return fmt.Errorf("%w: format %d read offset %d: %v", ErrSelfTest, format, 1+i, err)
+		}
+		if got.Offset != uint64(1+i) || !bytes.Equal(got.Value, want.Value) || !bytes.Equal(got.Key, want.Key) {
+			return fmt.Errorf("%w: format %d read offset %d as %v", ErrSelfTest, format, 1+i, got)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfTest(t *testing.T) {
+	for _, format := range []int{0, FormatV1, FormatV2} {
+		dir := t.TempDir()
+		c := Config{}
+		c.Segment.Format = format
+		require.NoError(t, SelfTest(dir, c))
+
+		// 一時ディレクトリは残らない
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	}
+
+	c := Config{}
+	c.Segment.Format = 3
+	require.Error(t, SelfTest(t.TempDir(), c))
+}
+
+func TestSelfTestMismatch(t *testing.T) {
+	// 既知の値と異なるエンコードになる場合（バージョンの食い違いを想定）
+	headers := canaryHeaders[FormatV2]
+	canaryHeaders[FormatV2] = [2][]byte{{0, 0x0d}, headers[1]}
+	selfTested.Delete(FormatV2)
+	defer func() {
+		canaryHeaders[FormatV2] = headers
+		selfTested.Delete(FormatV2)
+	}()
+
+	c := Config{}
+	c.Segment.Format = FormatV2
+	err := SelfTest(t.TempDir(), c)
+	require.True(t, errors.Is(err, ErrSelfTest), err)
+
+	// ログストアは開かない
+	_, err = NewLog(t.TempDir(), c)
+	require.True(t, errors.Is(err, ErrSelfTest), err)
+}
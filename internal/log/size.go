@@ -0,0 +1,25 @@
+package log
+
+// Size: ログストアが保持しているレコード数とストアファイルの合計バイト数を返す
+// 書き込みやセグメントの削除のたびに更新しているため、ファイルの情報を取得したりオフセットの差を計算したりせずに参照できる。
+// ロックを取得しないため、メトリクスなどから頻繁に呼び出してもよい。
+// 戻り値:
+//   - records: 保持しているレコード数（Truncate で削除したレコードを含まない）
+//   - bytes: ストアファイルの合計バイト数（バッファ内のデータを含み、インデックスファイルを含まない）
+func (l *Log) Size() (records uint64, bytes uint64) {
+	return uint64(l.records.Load()), uint64(l.bytes.Load())
+}
+
+// addSize: セグメントのレコード数とバイト数をログストアの合計に加える（内部関数）
+// セグメントを一覧に追加したときに呼び出す。
+func (l *Log) addSize(s *segment) {
+	l.records.Add(int64(s.nextOffset - s.baseOffset))
+	l.bytes.Add(int64(s.store.size))
+}
+
+// subSize: セグメントのレコード数とバイト数をログストアの合計から引く（内部関数）
+// セグメントを一覧から外したときに呼び出す。
+func (l *Log) subSize(s *segment) {
+	l.records.Add(-int64(s.nextOffset - s.baseOffset))
+	l.bytes.Add(-int64(s.store.size))
+}
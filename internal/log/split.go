@@ -0,0 +1,243 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// splitExt: セグメントの分割の途中であることを記録するファイルの拡張子
+const splitExt = ".split"
+
+// SplitSegment: 既存のセグメントを指定されたオフセットで2つのセグメントに分割する（管理操作）
+// 設定変更で MaxStoreBytes を小さくした場合や、セグメントの一部だけをアーカイブしたい場合に使用する。
+// 分割後は [baseOffset, atOffset) と [atOffset, nextOffset) の2つのセグメントになる。
+// アクティブセグメントを分割した場合、後半のセグメントが新しいアクティブセグメントになる。
+// 引数:
+//   - baseOffset: 分割するセグメントの開始オフセット
+//   - atOffset: 後半のセグメントの開始オフセット（baseOffset < atOffset < nextOffset）
+//
+// 戻り値:
+//   - error: エラーが発生した場合（セグメントが見つからない、オフセットが範囲外など）
+func (l *Log) SplitSegment(baseOffset, atOffset uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 分割するセグメントを検索
+	i := -1
+	for j, s := range l.segments {
+		if s.baseOffset == baseOffset {
+			i = j
+			break
+		}
+	}
+	if i == -1 {
+		return fmt.Errorf("segment not found: %d", baseOffset)
+	}
+	s := l.segments[i]
+	if s.quarantine != nil {
+		return *s.quarantine
+	}
+	if atOffset <= s.baseOffset || s.nextOffset <= atOffset {
+		return api.ErrOffsetOutOfRange{Offset: atOffset}
+	}
+
+	// 分割位置のレコードのストア内位置を取得
+	rel := atOffset - s.baseOffset
+	_, splitPos, err := s.index.Read(int64(rel))
+	if err != nil {
+		return err
+	}
+	// バッチは分割できないため、直前のレコードが同じバッチに含まれている場合はエラーにする
+	_, prevPos, err := s.index.Read(int64(rel - 1))
+	if err != nil {
+		return err
+	}
+	if prevPos == splitPos {
+		return fmt.Errorf("cannot split segment %d inside a batch at offset %d", baseOffset, atOffset)
+	}
+
+	// 前半と後半のセグメントのファイルを一時ファイルとして作成し、分割の記録を作成してから一時ファイルを配置する
+	// 元のセグメントのファイルは変更しないため、ロックの外で読み取り中の Reader は参照を外すまで読み取りを続けられる
+	// 配置の途中でクラッシュした場合は、開き直すときに記録に従って残りの一時ファイルを配置する（setup の finishSplit）
+	files := splitFiles(l.Dir, baseOffset, atOffset)
+	marker := splitPath(l.Dir, baseOffset)
+	err = l.writeSplit(s, files, rel, splitPos)
+	if err == nil {
+		_, err = writeTempFile(marker+".tmp", strings.NewReader(strconv.FormatUint(atOffset, 10)))
+	}
+	if err == nil {
+		err = os.Rename(marker+".tmp", marker)
+	}
+	if err != nil {
+		// 記録を作成する前に失敗した場合は、元のセグメントのファイルは変更していない
+		for _, name := range files {
+			os.Remove(name + ".tmp")
+		}
+		os.Remove(marker + ".tmp")
+		return err
+	}
+	// 記録を作成した後は元に戻せない（前半のセグメントのファイルを置き換える）ため、失敗した場合はログを異常状態にする
+	// 元のセグメントはメモリ上ではそのまま使えるが、書き込んだレコードは開き直すと失われるため書き込みを拒否する
+	if err = finishSplit(l.Dir, baseOffset, atOffset); err != nil {
+		l.unhealthy = err
+		return err
+	}
+
+	// 両方のセグメントを開いて、セグメントリストを更新
+	head, err := newSegment(l.Dir, s.baseOffset, l.Config)
+	if err != nil {
+		l.unhealthy = err
+		return err
+	}
+	tailSeg, err := newSegment(l.Dir, atOffset, l.Config)
+	if err != nil {
+		head.Close()
+		l.unhealthy = err
+		return err
+	}
+	segments := make([]*segment, 0, len(l.segments)+1)
+	segments = append(segments, l.segments[:i]...)
+	segments = append(segments, head, tailSeg)
+	segments = append(segments, l.segments[i+1:]...)
+	l.segments = segments
+	// 元のセグメントは、読み取り中の参照がすべて外れたときに閉じる
+	if err = s.detach(); err != nil {
+		return err
+	}
+	// 前半のセグメントは書き込み済みになる。後半のセグメントは分割したセグメントがアクティブだった場合のみ書き込みを続ける
+	if err = head.seal(); err != nil {
+		return err
+	}
+	if l.activeSegment.segment == s {
+		l.activeSegment = &writableSegment{segment: tailSeg}
+		return nil
+	}
+	return tailSeg.seal()
+}
+
+// splitFiles: 分割後の前半と後半のセグメントのファイルのパスを返す（内部関数）
+// 戻り値:
+//   - [4]string: 後半のストア、後半のインデックス、前半のストア、前半のインデックスの順のパス
+//     （この順に配置して、前半のファイルを置き換える前に後半のファイルがそろうようにする）
+func splitFiles(dir string, baseOffset, atOffset uint64) [4]string {
+	return [4]string{
+		filepath.Join(dir, fmt.Sprintf("%d%s", atOffset, ".store")),
+		filepath.Join(dir, fmt.Sprintf("%d%s", atOffset, ".index")),
+		filepath.Join(dir, fmt.Sprintf("%d%s", baseOffset, ".store")),
+		filepath.Join(dir, fmt.Sprintf("%d%s", baseOffset, ".index")),
+	}
+}
+
+// writeSplit: 分割後の前半と後半のセグメントのファイルを一時ファイルに書き込む（内部関数）
+// 引数:
+//   - s: 分割するセグメント
+//   - files: splitFiles で求めたパス
+//   - rel: 後半のセグメントの最初のレコードの相対オフセット
+//   - splitPos: 後半のセグメントの最初のレコードのストア内位置
+func (l *Log) writeSplit(s *segment, files [4]string, rel, splitPos uint64) error {
+	entries := s.index.size / entWidth
+	for _, f := range []struct {
+		store, index string
+		from, to     uint64 // ストアの範囲
+		first, last  uint64 // インデックスのエントリの範囲
+	}{
+		{files[2], files[3], 0, splitPos, 0, rel},
+		{files[0], files[1], splitPos, s.store.size, rel, entries},
+	} {
+		// ストア: 範囲のデータをそのままコピー（ReadAt はバッファをフラッシュする）
+		n, err := writeTempFile(f.store+".tmp", io.NewSectionReader(s.store, int64(f.from), int64(f.to-f.from)))
+		if err != nil {
+			return err
+		}
+		l.Config.writes.addRewrite(uint64(n))
+		// インデックス: 相対オフセットとストア内位置をそれぞれのセグメント基準に変換
+		index := make([]byte, (f.last-f.first)*entWidth)
+		for j := f.first; j < f.last; j++ {
+			off, pos, err := s.index.Read(int64(j))
+			if err != nil {
+				return err
+			}
+			e := index[(j-f.first)*entWidth:]
+			enc.PutUint32(e[:offWidth], off-uint32(f.first))
+			enc.PutUint64(e[offWidth:entWidth], pos-f.from)
+		}
+		if n, err = writeTempFile(f.index+".tmp", bytes.NewReader(index)); err != nil {
+			return err
+		}
+		l.Config.writes.addRewrite(uint64(n))
+	}
+	return nil
+}
+
+// splitPath: 分割の途中であることを記録するファイルのパスを返す（内部関数）
+// ファイルには後半のセグメントの開始オフセットを記録する。
+func splitPath(dir string, baseOffset uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%d%s", baseOffset, splitExt))
+}
+
+// finishSplit: 分割した前半と後半のセグメントの一時ファイルを配置し、分割の記録を削除する（内部関数）
+// SplitSegment の配置と、配置の途中でクラッシュした場合の setup での再開の両方で使用する。
+// 配置済みのファイル（一時ファイルがないもの）はそのままにする。
+// 引数:
+//   - dir: ログストアのディレクトリ
+//   - baseOffset: 分割したセグメントの開始オフセット
+//   - atOffset: 後半のセグメントの開始オフセット
+//
+// 戻り値:
+//   - error: エラーが発生した場合（分割の記録は残る）
+func finishSplit(dir string, baseOffset, atOffset uint64) error {
+	for i, name := range splitFiles(dir, baseOffset, atOffset) {
+		if i == 2 {
+			if err := failpoint(FailpointSegmentSplit); err != nil {
+				return err
+			}
+		}
+		if err := os.Rename(name+".tmp", name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	// 内容が変わるため、スクラブのチェックサムは削除する（次の検査で記録し直す）
+	// タイマーインデックスも削除して、開き直すときに前半と後半のセグメントのレコードから作り直す
+	head := filepath.Join(dir, fmt.Sprintf("%d%s", baseOffset, ".store"))
+	for _, name := range []string{scrubPath(head), timersPath(head)} {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Remove(splitPath(dir, baseOffset))
+}
+
+// resumeSplits: 分割の途中でクラッシュしたセグメントの残りの一時ファイルを配置する（内部関数）
+// 分割の記録は一時ファイルをすべてディスクに同期した後に作成するため、記録があれば配置を完了できる。
+// setup で一時ファイルを削除する前に呼び出す。
+func resumeSplits(dir string) error {
+	markers, err := filepath.Glob(filepath.Join(dir, "*"+splitExt))
+	if err != nil {
+		return err
+	}
+	for _, marker := range markers {
+		baseOffset, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(marker), splitExt), 10, 0)
+		if err != nil {
+			continue
+		}
+		b, err := os.ReadFile(marker)
+		if err != nil {
+			return err
+		}
+		atOffset, err := strconv.ParseUint(string(b), 10, 0)
+		if err != nil {
+			return fmt.Errorf("split marker %s: %w", marker, err)
+		}
+		if err = finishSplit(dir, baseOffset, atOffset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
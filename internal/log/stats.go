@@ -0,0 +1,40 @@
+package log
+
+import "time"
+
+// SegmentStat: セグメントの統計情報
+// 保持期間の異常やセグメントサイズの偏りを運用者が確認するために使用する。
+type SegmentStat struct {
+	BaseOffset  uint64    // セグメントの開始オフセット
+	NextOffset  uint64    // セグメントの次のオフセット（最後のレコードのオフセット + 1）
+	Records     uint64    // セグメントに含まれるレコード数
+	StoreBytes  uint64    // ストアファイルのバイト数（バッファ内のデータを含む）
+	IndexBytes  uint64    // インデックスファイルの有効なバイト数
+	CreatedAt   time.Time // セグメントの作成時刻（Config.Clock で記録する。セグメントの経過時間の計算に使用する）
+	Active      bool      // 書き込み中のセグメント（アクティブセグメント）かどうか
+	Quarantined bool      // スクラブで破損を検出して隔離したセグメントかどうか
+}
+
+// SegmentStats: すべてのセグメントの統計情報を baseOffset の昇順で返す
+// 戻り値:
+//   - []SegmentStat: セグメントの統計情報
+//   - error: 統計情報を取得できなかった場合
+func (l *Log) SegmentStats() ([]SegmentStat, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	stats := make([]SegmentStat, 0, len(l.segments))
+	for _, s := range l.segments {
+		stats = append(stats, SegmentStat{
+			BaseOffset:  s.baseOffset,
+			NextOffset:  s.nextOffset,
+			Records:     s.nextOffset - s.baseOffset,
+			StoreBytes:  s.store.size,
+			IndexBytes:  s.index.size,
+			CreatedAt:   s.createdAt,
+			Active:      s == l.activeSegment.segment,
+			Quarantined: s.quarantine != nil,
+		})
+	}
+	return stats, nil
+}
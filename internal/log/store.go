@@ -3,6 +3,8 @@ package log
 import (
 	"bufio"
 	"encoding/binary"
+	"errors"
+	"io"
 	"os"
 	"sync"
 )
@@ -17,16 +19,39 @@ const (
 	lenWidth = 8
 )
 
+// ストアの保存形式（Config.Segment.Format）
+const (
+	// FormatV1: [長さ情報(8バイト)][データ]
+	FormatV1 = 1
+	// FormatV2: [長さ情報(varint、1〜10バイト)][データ]
+	// 数十バイトの小さいレコードでは、固定長の長さ情報がストアの大きな割合を占めるため、可変長にして削減する。
+	FormatV2 = 2
+)
+
+// ErrCorruptRecord: レコードの長さ情報が不正な場合のエラー
+// 書き込み途中のクラッシュなどで長さ情報が壊れていると、巨大なバッファを確保してしまうため、
+// 読み取る前に検出してこのエラーを返す。
+var ErrCorruptRecord = errors.New("corrupt record")
+
+// ErrRecordTooLarge: 追加するレコードが MaxRecordBytes を超えている場合のエラー
+var ErrRecordTooLarge = errors.New("record too large")
+
 // store: ファイルベースのログストレージ
 type store struct {
-	*os.File               // 埋め込みでos.Fileのメソッドを直接使用
-	mu       sync.Mutex    // 並行アクセス制御（複数goroutineからの同時アクセス防止）
-	buf      *bufio.Writer // バッファリングでI/O性能向上
-	size     uint64        // 現在のファイルサイズ（次のレコードの開始位置計算用）
+	*os.File              // 埋め込みでos.Fileのメソッドを直接使用
+	mu       sync.Mutex   // 並行アクセス制御（複数goroutineからの同時アクセス防止）
+	buf      *writeBuffer // バッファリングでI/O性能向上
+	size     uint64       // 現在のファイルサイズ（次のレコードの開始位置計算用）
+	maxSize  uint64       // 1レコードの最大バイト数（0 の場合は制限なし）
+	format   int          // 長さ情報の形式（FormatV1 または FormatV2）
+
+	// DirectIO の場合に、書き出し済みのデータを読み取るために O_DIRECT で開いたファイル（それ以外は nil）
+	direct    *os.File
+	directBuf []byte // direct で読み取るための境界をそろえたバッファ（読み取りのたびに再利用する）
 }
 
 // newStore: ファイルからstoreインスタンスを作成
-func newStore(f *os.File) (*store, error) {
+func newStore(f *os.File, c Config) (*store, error) {
 	fi, err := os.Stat(f.Name())
 	if err != nil {
 		return nil, err
@@ -35,33 +60,96 @@ func newStore(f *os.File) (*store, error) {
 	// 既存ファイルのサイズを取得（次のレコードの開始位置を決定するため）
 	size := uint64(fi.Size())
 
-	return &store{
-		File: f,
-		size: size,
-		buf:  bufio.NewWriter(f),
-	}, nil
+	format := c.Segment.Format
+	if format == 0 {
+		format = FormatV1
+	}
+
+	s := &store{
+		File:    f,
+		size:    size,
+		buf:     newWriteBuffer(countingWriter{Writer: f, writes: c.writes}, defaultBufferSize),
+		maxSize: c.Segment.MaxRecordBytes,
+		format:  format,
+	}
+	if c.Segment.DirectIO {
+		if s.direct, err = openDirect(f.Name()); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// appendHeader: 長さ情報を保存形式に従ってエンコードし、b に追加する
+func appendHeader(b []byte, format int, size uint64) []byte {
+	if format == FormatV2 {
+		return binary.AppendUvarint(b, size)
+	}
+	return enc.AppendUint64(b, size)
+}
+
+// decodeHeader: b の先頭から保存形式に従って長さ情報をデコードする
+// 戻り値:
+//   - uint64: データのバイト数
+//   - uint64: 長さ情報のバイト数（b が短すぎる、または長さ情報が不正な場合は 0）
+func decodeHeader(b []byte, format int) (uint64, uint64) {
+	if format == FormatV2 {
+		size, n := binary.Uvarint(b)
+		if n <= 0 {
+			return 0, 0
+		}
+		return size, uint64(n)
+	}
+	if len(b) < lenWidth {
+		return 0, 0
+	}
+	return enc.Uint64(b), lenWidth
+}
+
+// readHeader: r から保存形式に従って長さ情報を読み取る
+// 戻り値:
+//   - uint64: データのバイト数
+//   - error: r が終わりに達した場合は io.EOF、長さ情報の途中で終わった場合は io.ErrUnexpectedEOF
+func readHeader(r *bufio.Reader, format int) (uint64, error) {
+	if format == FormatV2 {
+		return binary.ReadUvarint(r)
+	}
+	header := make([]byte, lenWidth)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, err
+	}
+	return enc.Uint64(header), nil
 }
 
 // Append: レコードをバッファに追加（ファイルには書き込まない）
-// レコード構造: [長さ情報(8バイト)][データ] - 可変長データの境界を明確にするため
+// レコード構造: [長さ情報][データ] - 可変長データの境界を明確にするため
+// 長さ情報は FormatV1 では 8 バイト、FormatV2 では varint で書き込む
 func (s *store) Append(p []byte) (n uint64, pos uint64, err error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.maxSize > 0 && uint64(len(p)) > s.maxSize {
+		return 0, 0, ErrRecordTooLarge
+	}
+	if err := failpoint(FailpointStoreWrite); err != nil {
+		return 0, 0, err
+	}
+
 	pos = s.size
 
-	// 長さ情報をバイナリ形式で書き込み（可変長データの境界を明確にするため）
-	if err := binary.Write(s.buf, enc, uint64(len(p))); err != nil {
+	// 長さ情報とデータが収まる余地を確保する（バッファが一杯の場合は書き出す）
+	// 書き出しに失敗した場合（ディスクが一杯の場合など）は、レコードの一部だけが残らないように何も追加しない
+	header := appendHeader(make([]byte, 0, binary.MaxVarintLen64), s.format, uint64(len(p)))
+	if err := s.buf.Reserve(len(header) + len(p)); err != nil {
 		return 0, 0, err
 	}
 
+	// 長さ情報をバイナリ形式で書き込み（可変長データの境界を明確にするため）
+	s.buf.Write(header)
 	// 実際のデータを書き込み
-	w, err := s.buf.Write(p)
-	if err != nil {
-		return 0, 0, err
-	}
+	s.buf.Write(p)
 
-	w += lenWidth
+	w := len(header) + len(p)
 	s.size += uint64(w)
 
 	return uint64(w), pos, nil
@@ -73,37 +161,141 @@ func (s *store) Read(pos uint64) ([]byte, error) {
 	defer s.mu.Unlock()
 
 	// バッファをフラッシュ（最新データを確実にファイルに反映するため）
-	if err := s.buf.Flush(); err != nil {
-		return nil, err
-	}
+	// 失敗した場合（ディスクが一杯の場合など）も、書き出せなかったデータはバッファから読み取れる
+	_ = s.buf.Flush()
 
 	// 長さ情報を読み取り
-	size := make([]byte, lenWidth)
-	if _, err := s.File.ReadAt(size, int64(pos)); err != nil {
+	// 長さ情報が不正な場合は、バッファを確保する前にエラーを返す
+	recordSize, n, err := s.header(pos)
+	if err != nil {
 		return nil, err
 	}
 
-	// データサイズを取得して実際のデータを読み取り
-	recordSize := enc.Uint64(size)
+	// 実際のデータを読み取り
 	b := make([]byte, recordSize)
-	if _, err := s.File.ReadAt(b, int64(pos+lenWidth)); err != nil {
+	if _, err := s.readAt(b, int64(pos+n)); err != nil {
 		return nil, err
 	}
 
 	return b, nil
 }
 
+// header: pos にあるレコードの長さ情報を読み取って検証する
+// 呼び出し側でロックを取得しておく必要がある。
+// 戻り値:
+//   - uint64: データのバイト数
+//   - uint64: 長さ情報のバイト数
+//   - error: 長さ情報が不正な場合（ErrCorruptRecord）
+func (s *store) header(pos uint64) (uint64, uint64, error) {
+	if pos >= s.size {
+		return 0, 0, ErrCorruptRecord
+	}
+	b := make([]byte, min(binary.MaxVarintLen64, s.size-pos))
+	if s.format != FormatV2 {
+		b = b[:min(lenWidth, len(b))]
+	}
+	if _, err := s.readAt(b, int64(pos)); err != nil {
+		return 0, 0, err
+	}
+	size, n := decodeHeader(b, s.format)
+	if n == 0 || !s.validSize(pos, n, size) {
+		return 0, 0, ErrCorruptRecord
+	}
+	return size, n, nil
+}
+
 // ReadAt: io.ReaderAtインターフェースの実装
 func (s *store) ReadAt(p []byte, off int64) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// バッファをフラッシュ（最新データを確実にファイルに反映するため）
-	if err := s.buf.Flush(); err != nil {
-		return 0, err
+	// 失敗した場合も、書き出せなかったデータはバッファから読み取れる
+	_ = s.buf.Flush()
+
+	return s.readAt(p, off)
+}
+
+// readAt: ファイルとバッファから off の位置のデータを読み取る（内部関数）
+// まだ書き出していない範囲はバッファから読み取るため、書き出しに失敗している間
+// （ディスクが一杯の場合など）も、追加済みのすべてのレコードを読み取れる。
+// 呼び出し側でロックを取得しておく必要がある。
+func (s *store) readAt(p []byte, off int64) (int, error) {
+	// ファイルに書き出し済みのバイト数（これより後ろはバッファにある）
+	flushed := int64(s.size) - int64(s.buf.Buffered())
+	n := 0
+	if off < flushed {
+		m := int(min(int64(len(p)), flushed-off))
+		readAt := s.File.ReadAt
+		if s.direct != nil {
+			readAt = s.directReadAt
+		}
+		k, err := readAt(p[:m], off)
+		if n = k; err != nil {
+			return n, err
+		}
+	}
+	if n < len(p) {
+		start := off + int64(n) - flushed
+		if start < int64(s.buf.Buffered()) {
+			n += copy(p[n:], s.buf.buf[start:])
+		}
+		if n < len(p) {
+			return n, io.EOF
+		}
 	}
+	return n, nil
+}
+
+// validSize: pos にあるレコードの長さ情報 size が妥当かどうかを判定する
+// レコードがストアに収まらない、または最大バイト数を超える場合は不正とみなす。
+// 引数:
+//   - pos: レコードの位置
+//   - n: 長さ情報のバイト数
+//   - size: 長さ情報が示すデータのバイト数
+func (s *store) validSize(pos, n, size uint64) bool {
+	if pos+n > s.size || size > s.size-pos-n {
+		return false
+	}
+	return s.maxSize == 0 || size <= s.maxSize
+}
+
+// truncate: ストアを指定されたサイズに切り詰める
+// 書き込み途中でクラッシュしたレコードを取り除くために使用する。
+// 切り詰める範囲がバッファ内だけの場合はバッファだけを切り詰める（ディスクが一杯でも失敗しない）。
+func (s *store) truncate(size uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flushed := s.size - uint64(s.buf.Buffered())
+	if size >= flushed {
+		s.buf.buf = s.buf.buf[:size-flushed]
+		s.size = size
+		return nil
+	}
+	s.buf.buf = s.buf.buf[:0]
+	if err := s.File.Truncate(int64(size)); err != nil {
+		return err
+	}
+	s.size = size
+	return nil
+}
+
+// Flush: バッファのデータをファイルに書き出す
+func (s *store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Flush()
+}
 
-	return s.File.ReadAt(p, off)
+// Sync: バッファのデータをファイルに書き出し、ディスクに同期する
+func (s *store) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+	return s.File.Sync()
 }
 
 // Close: リソースのクリーンアップ
@@ -117,5 +309,63 @@ func (s *store) Close() error {
 		return err
 	}
 
+	if s.direct != nil {
+		if err := s.direct.Close(); err != nil {
+			return err
+		}
+	}
 	return s.File.Close()
 }
+
+// defaultBufferSize: ストアの書き込みバッファのサイズ（bufio のデフォルトと同じ）
+const defaultBufferSize = 4096
+
+// writeBuffer: 書き出しに失敗してもデータを失わない書き込みバッファ
+// bufio.Writer は一度書き出しに失敗すると以降の書き込みがすべて失敗するため、ディスクが一杯になった後に
+// 空き容量が戻っても復旧できない。writeBuffer は書き出せなかったデータをバッファに残し、次の書き出しで再試行する。
+type writeBuffer struct {
+	w    io.Writer
+	buf  []byte
+	size int // このサイズを超える前に書き出す（1つのレコードがこれより大きい場合はバッファが一時的に大きくなる）
+}
+
+func newWriteBuffer(w io.Writer, size int) *writeBuffer {
+	return &writeBuffer{w: w, buf: make([]byte, 0, size), size: size}
+}
+
+// Reserve: n バイトを追加してもバッファのサイズを超えないように、必要であればバッファを書き出す
+// 戻り値:
+//   - error: 書き出しに失敗した場合（書き出せなかったデータはバッファに残る）
+func (b *writeBuffer) Reserve(n int) error {
+	if len(b.buf) > 0 && len(b.buf)+n > b.size {
+		return b.Flush()
+	}
+	return nil
+}
+
+// Write: p をバッファに追加する（書き出しは Reserve または Flush で行うため、失敗しない）
+func (b *writeBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// Buffered: まだ書き出していないバイト数を返す
+func (b *writeBuffer) Buffered() int {
+	return len(b.buf)
+}
+
+// Flush: バッファのデータを書き出す
+// 一部だけを書き出せた場合は、書き出せなかった残りのデータをバッファに残す。
+func (b *writeBuffer) Flush() error {
+	for len(b.buf) > 0 {
+		n, err := b.w.Write(b.buf)
+		b.buf = b.buf[:copy(b.buf, b.buf[n:])]
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return io.ErrShortWrite
+		}
+	}
+	return nil
+}
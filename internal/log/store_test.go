@@ -1,8 +1,10 @@
 package log
 
 import (
+	"io"
 	"os"
 	"testing"
+	"unsafe"
 
 	"github.com/stretchr/testify/require"
 )
@@ -17,14 +19,14 @@ func TestStoreAppendRead(t *testing.T) {
 	require.NoError(t, err)
 	defer os.Remove(f.Name())
 
-	s, err := newStore(f)
+	s, err := newStore(f, Config{})
 	require.NoError(t, err)
 
 	testAppend(t, s)
 	testRead(t, s)
 	testReadAt(t, s)
 
-	s, err = newStore(f)
+	s, err = newStore(f, Config{})
 	require.NoError(t, err)
 	testRead(t, s)
 }
@@ -72,7 +74,7 @@ func TestStoreClose(t *testing.T) {
 	f, err := os.CreateTemp("", "store_close_test")
 	require.NoError(t, err)
 	defer os.Remove(f.Name())
-	s, err := newStore(f)
+	s, err := newStore(f, Config{})
 	require.NoError(t, err)
 	_, _, err = s.Append(write)
 	require.NoError(t, err)
@@ -100,3 +102,135 @@ func openFile(name string) (file *os.File, size int64, err error) {
 	}
 	return f, fi.Size(), nil
 }
+
+func TestStoreReadCorruptLength(t *testing.T) {
+	f, err := os.CreateTemp("", "store_corrupt_length_test")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	s, err := newStore(f, Config{})
+	require.NoError(t, err)
+	_, _, err = s.Append(write)
+	require.NoError(t, err)
+
+	// 長さ情報だけが書き込まれ、データ本体が書き込まれていない状態を再現する
+	header := make([]byte, lenWidth)
+	enc.PutUint64(header, 1<<40)
+	_, err = s.buf.Write(header)
+	require.NoError(t, err)
+	s.size += lenWidth
+
+	_, err = s.Read(width)
+	require.Equal(t, ErrCorruptRecord, err)
+
+	// 正常なレコードは引き続き読み取れる
+	read, err := s.Read(0)
+	require.NoError(t, err)
+	require.Equal(t, write, read)
+	require.NoError(t, s.Close())
+}
+
+func TestStoreMaxRecordBytes(t *testing.T) {
+	f, err := os.CreateTemp("", "store_max_record_bytes_test")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	s, err := newStore(f, Config{})
+	require.NoError(t, err)
+	_, _, err = s.Append(write)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	// 既存のレコードより小さい上限で開き直す
+	f, err = os.OpenFile(f.Name(), os.O_RDWR|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	c := Config{}
+	c.Segment.MaxRecordBytes = uint64(len(write)) - 1
+	s, err = newStore(f, c)
+	require.NoError(t, err)
+
+	_, err = s.Read(0)
+	require.Equal(t, ErrCorruptRecord, err)
+
+	_, _, err = s.Append(write)
+	require.Equal(t, ErrRecordTooLarge, err)
+	require.NoError(t, s.Close())
+}
+
+func TestStoreFormatV2(t *testing.T) {
+	f, err := os.CreateTemp("", "store_format_v2_test")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	c := Config{}
+	c.Segment.Format = FormatV2
+	s, err := newStore(f, c)
+	require.NoError(t, err)
+
+	// 長さ情報は 1 バイトの varint で書き込まれる
+	width := uint64(len(write)) + 1
+	for i := uint64(1); i < 4; i++ {
+		n, pos, err := s.Append(write)
+		require.NoError(t, err)
+		require.Equal(t, width, n)
+		require.Equal(t, width*(i-1), pos)
+	}
+	for pos := uint64(0); pos < width*3; pos += width {
+		read, err := s.Read(pos)
+		require.NoError(t, err)
+		require.Equal(t, write, read)
+	}
+
+	// 不正な varint の長さ情報は破損として検出する
+	_, err = s.buf.Write([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+	require.NoError(t, err)
+	s.size += 11
+	_, err = s.Read(width * 3)
+	require.Equal(t, ErrCorruptRecord, err)
+	require.NoError(t, s.Close())
+}
+
+func TestStoreDirectIO(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "store_direct_io_test")
+	require.NoError(t, err)
+	if d, err := openDirect(f.Name()); err != nil {
+		t.Skipf("direct I/O is not available: %v", err)
+	} else {
+		d.Close()
+	}
+
+	c := Config{}
+	c.Segment.DirectIO = true
+	s, err := newStore(f, c)
+	require.NoError(t, err)
+	require.NotNil(t, s.direct)
+
+	// ブロックの境界をまたぐように、書き出し済みのレコードとバッファ内のレコードを追加する
+	const records = 500
+	for i := uint64(0); i < records; i++ {
+		_, pos, err := s.Append(write)
+		require.NoError(t, err)
+		require.Equal(t, width*i, pos)
+	}
+	require.NotZero(t, s.buf.Buffered())
+	for pos := uint64(0); pos < width*records; pos += width {
+		read, err := s.Read(pos)
+		require.NoError(t, err)
+		require.Equal(t, write, read)
+	}
+
+	// 境界にそろっていない位置と長さでも読み取れ、末尾を超える場合は io.EOF を返す
+	b := make([]byte, directAlignment)
+	n, err := s.ReadAt(b, directAlignment-3)
+	require.NoError(t, err)
+	require.Equal(t, len(b), n)
+	off := int64(width*records) - 5
+	n, err = s.ReadAt(b, off)
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, 5, n)
+	require.Equal(t, write[len(write)-5:], b[:n])
+
+	// 読み取りに使うバッファはアドレスが境界にそろっている
+	require.Zero(t, uintptr(unsafe.Pointer(&s.directBuf[0]))%directAlignment)
+	require.NoError(t, s.Close())
+}
@@ -0,0 +1,142 @@
+package log
+
+import (
+	"errors"
+	"sync"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// SlowPolicy: 購読者がログの末尾から MaxLag より遅れた場合の扱い
+type SlowPolicy int
+
+const (
+	// SlowWait: 遅れても自分のペースで読み続ける（ログから読み取るため、レコードは失われない）
+	SlowWait SlowPolicy = iota
+	// SlowDisconnect: 購読を終了する（Err は ErrSlowSubscriber を返す）
+	SlowDisconnect
+	// SlowSkip: 間のレコードを読み飛ばし、ログの末尾から MaxLag 件前のレコードから読み直す
+	SlowSkip
+)
+
+// ErrSlowSubscriber: 購読者がログの末尾から MaxLag より遅れたため、購読を終了した場合のエラー
+var ErrSlowSubscriber = errors.New("subscriber fell too far behind")
+
+// SubscribeOptions: 購読の設定
+type SubscribeOptions struct {
+	// レコードを渡すチャネルのバッファの数（0 の場合は 64）
+	Buffer int
+	// ログの末尾からこの件数より遅れたら Policy を適用する（0 の場合は遅れを制限しない）
+	MaxLag uint64
+	// 遅れた場合の扱い
+	Policy SlowPolicy
+}
+
+// Subscription: ログの購読
+// C からオフセット順にレコードを受け取る。購読が終了すると C は閉じられ、終了の原因は Err で確認できる。
+type Subscription struct {
+	C <-chan *api.Record
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+	err  error // 購読が異常終了した原因（C が閉じられた後に読み取る）
+}
+
+// Subscribe: 指定したオフセット以降のレコードを購読する
+// gRPC を経由せずに、同じプロセス内で新しく追加されたレコードを受け取るために使用する。
+// 購読はバックグラウンドのゴルーチンがログから順番に読み取ってチャネルに送るため、
+// 受け取りが遅くても書き込みを妨げない。Truncate で削除されたレコードは読み飛ばす。
+// ログを閉じると購読も終了する。
+// 引数:
+//   - from: 購読を開始するオフセット
+//   - opts: 購読の設定
+//
+// 戻り値:
+//   - *Subscription: 購読（使い終わったら Close を呼び出す）
+func (l *Log) Subscribe(from uint64, opts SubscribeOptions) *Subscription {
+	if opts.Buffer == 0 {
+		opts.Buffer = 64
+	}
+	ch := make(chan *api.Record, opts.Buffer)
+	s := &Subscription{C: ch, done: make(chan struct{})}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(ch)
+		s.err = l.subscribe(from, opts, ch, s.done)
+	}()
+	return s
+}
+
+// subscribe: 購読のレコードを読み取ってチャネルに送る（内部関数）
+// 戻り値:
+//   - error: 購読が異常終了した場合（購読またはログを閉じた場合は nil）
+func (l *Log) subscribe(off uint64, opts SubscribeOptions, ch chan<- *api.Record, done <-chan struct{}) error {
+	r := l.NewSequentialReader()
+	for {
+		// 読み取りの前に通知用のチャネルを取得しておく（読み取り後の追加を取りこぼさないため）
+		l.mu.RLock()
+		appended := l.appended
+		lowest := l.segments[0].baseOffset
+		next := l.segments[len(l.segments)-1].nextOffset
+		l.mu.RUnlock()
+
+		if off < lowest {
+			off = lowest
+		}
+		if opts.MaxLag != 0 && next > off && next-off > opts.MaxLag {
+			switch opts.Policy {
+			case SlowDisconnect:
+				return ErrSlowSubscriber
+			case SlowSkip:
+				off = next - opts.MaxLag
+			}
+		}
+
+		record, err := r.Read(off)
+		if _, ok := err.(api.ErrOffsetOutOfRange); ok {
+			// まだレコードが追加されていない: 待つ
+			select {
+			case <-appended:
+				continue
+			case <-l.closed:
+				return nil
+			case <-done:
+				return nil
+			}
+		}
+		if err != nil {
+			select {
+			case <-l.closed:
+				// 読み取り中にログが閉じられた
+				return nil
+			default:
+				return err
+			}
+		}
+
+		select {
+		case ch <- record:
+			off++
+		case <-l.closed:
+			return nil
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// Close: 購読を終了し、バックグラウンドのゴルーチンが終了するまで待つ
+func (s *Subscription) Close() {
+	s.once.Do(func() { close(s.done) })
+	s.wg.Wait()
+}
+
+// Err: 購読が異常終了した原因を返す（C が閉じられた後に呼び出す）
+// 戻り値:
+//   - error: 遅れすぎた場合は ErrSlowSubscriber、読み取りに失敗した場合はそのエラー（正常に終了した場合は nil）
+func (s *Subscription) Err() error {
+	s.wg.Wait()
+	return s.err
+}
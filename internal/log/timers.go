@@ -0,0 +1,150 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"strings"
+)
+
+// timersExt: セグメントのタイマーインデックスのファイルの拡張子（例: "0.timers"）
+// 配信時刻（deliver_after）を指定したレコードだけを [配信時刻(8バイト)][オフセット(8バイト)] の形式で記録する。
+// レコードから作り直せる補助的なファイルのため、存在しない場合（この機能より前のセグメント、
+// InstallSegment や SplitSegment で配置したセグメント）はセグメントを開くときにレコードを読み取って作成する。
+const timersExt = ".timers"
+
+// timerWidth: タイマーインデックスの1エントリのバイト数
+const timerWidth = 16
+
+// timer: 配信時刻を指定したレコードのタイマーインデックスのエントリ
+type timer struct {
+	deliverAfter int64  // 配信時刻（Unix ミリ秒）
+	offset       uint64 // レコードのオフセット
+}
+
+// timersPath: ストアファイルのパスから、同じセグメントのタイマーインデックスのパスを返す
+func timersPath(storePath string) string {
+	return strings.TrimSuffix(storePath, ".store") + timersExt
+}
+
+// loadTimers: セグメントのタイマーインデックスを読み込む（ファイルがない場合はレコードを読み取って作成する）（内部関数）
+// nextOffset を決定した後に呼び出す。クラッシュでコミットされなかったレコードのエントリ（nextOffset 以降）と
+// 末尾の書きかけのエントリは切り詰める（エントリはオフセットの順に追記されるため、末尾にだけある）。
+func (s *segment) loadTimers() error {
+	path := timersPath(s.store.Name())
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s.rebuildTimers(path)
+	}
+	if err != nil {
+		return err
+	}
+	n := 0
+	for ; (n+1)*timerWidth <= len(b); n++ {
+		e := b[n*timerWidth:]
+		t := timer{deliverAfter: int64(enc.Uint64(e[:8])), offset: enc.Uint64(e[8:timerWidth])}
+		if t.offset < s.baseOffset || t.offset >= s.nextOffset {
+			break
+		}
+		s.timers = append(s.timers, t)
+	}
+	if n*timerWidth < len(b) {
+		return os.Truncate(path, int64(n*timerWidth))
+	}
+	return nil
+}
+
+// rebuildTimers: セグメントのレコードを読み取ってタイマーインデックスを作成する（内部関数）
+// 途中でクラッシュしても不完全なファイルが残らないように、一時ファイルに書き込んでからリネームする。
+// 読み取れない（壊れた）レコードは配信時刻が分からないため、タイマーインデックスに含めない。
+func (s *segment) rebuildTimers(path string) error {
+	var buf bytes.Buffer
+	for off := s.baseOffset; off < s.nextOffset; off++ {
+		record, err := s.Read(off)
+		if err != nil {
+			continue
+		}
+		if record.DeliverAfter > 0 {
+			t := timer{deliverAfter: record.DeliverAfter, offset: off}
+			s.timers = append(s.timers, t)
+			buf.Write(t.encode())
+		}
+	}
+	if _, err := writeTempFile(path+".tmp", &buf); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// addTimers: 追加するレコードのうち配信時刻を指定したもののエントリをタイマーインデックスに追記する（内部関数）
+// インデックスのエントリ（レコードのコミットマーカー）を書き込む前に呼び出す。
+// 戻り値:
+//   - int: 追記する前のエントリの数（インデックスへの書き込みに失敗した場合に truncateTimers で戻す）
+//   - error: エラーが発生した場合
+func (s *writableSegment) addTimers(timers []timer) (int, error) {
+	n := len(s.timers)
+	if len(timers) == 0 {
+		return n, nil
+	}
+	if s.timersFile == nil {
+		f, err := os.OpenFile(timersPath(s.store.Name()), os.O_WRONLY|os.O_APPEND|os.O_CREATE, segmentFileMode)
+		if err != nil {
+			return n, err
+		}
+		s.timersFile = f
+	}
+	b := make([]byte, 0, len(timers)*timerWidth)
+	for _, t := range timers {
+		b = append(b, t.encode()...)
+	}
+	if _, err := s.timersFile.Write(b); err != nil {
+		return n, s.truncateTimers(n)
+	}
+	s.timers = append(s.timers, timers...)
+	return n, nil
+}
+
+// truncateTimers: タイマーインデックスを n 個のエントリに戻す（内部関数）
+func (s *writableSegment) truncateTimers(n int) error {
+	if n == len(s.timers) && s.timersFile == nil {
+		return nil
+	}
+	s.timers = s.timers[:n]
+	return os.Truncate(timersPath(s.store.Name()), int64(n*timerWidth))
+}
+
+// encode: エントリをタイマーインデックスのファイルの形式にエンコードする
+func (t timer) encode() []byte {
+	b := make([]byte, timerWidth)
+	enc.PutUint64(b[:8], uint64(t.deliverAfter))
+	enc.PutUint64(b[8:], t.offset)
+	return b
+}
+
+// DelayedSince: before より前のオフセットのレコードのうち、配信時刻が since より後のもののオフセットを返す
+// ConsumeStream が以前のストリームで配信時刻の前に読み飛ばしたレコードを、配信時刻になったら送信するために使用する
+// （レコードを読み取らずに、セグメントのタイマーインデックスだけから探す）。
+// 引数:
+//   - since: 配信時刻の下限（Unix ミリ秒、この時刻ちょうどのレコードは含まない）
+//   - before: オフセットの上限（このオフセットのレコードは含まない）
+//
+// 戻り値:
+//   - []uint64: 該当するレコードのオフセット（小さい順）
+func (l *Log) DelayedSince(since int64, before uint64) []uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var offsets []uint64
+	for _, s := range l.segments {
+		if s.baseOffset >= before {
+			break
+		}
+		for _, t := range s.timers {
+			if t.offset >= before {
+				break
+			}
+			if t.deliverAfter > since {
+				offsets = append(offsets, t.offset)
+			}
+		}
+	}
+	return offsets
+}
@@ -0,0 +1,460 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// deletingDir: 削除中のトピックを移動するディレクトリ名
+// トピックの削除は「印を付けてから削除」の2段階で行う。まずこのディレクトリへリネームして
+// トピックを即座に見えなくし、その後バックグラウンドでファイルを削除する。
+// 削除の途中でプロセスが終了しても、次回起動時に残りが削除される。
+const deletingDir = ".deleting"
+
+// trashDir: 削除したトピックを保持期間（Config.Topic.DeleteRetention）の間保持するディレクトリ名
+// 保持期間内であれば Undelete で元に戻せる。保持期間を過ぎると削除中ディレクトリへ移動して削除する。
+// ゴミ箱内のディレクトリ名は "{トピック名}.{削除時刻（UnixNano）}" となる。
+const trashDir = ".trash"
+
+// maxPurgeInterval: ゴミ箱から保持期間を過ぎたトピックを削除する間隔の上限
+const maxPurgeInterval = time.Hour
+
+// topicName: トピック名として使用できる文字列（ディレクトリ名としてそのまま使用するため制限する）
+var topicName = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9._-]*$`)
+
+// Topics: 複数のトピック（それぞれが独立したログ）を管理する
+// 各トピックのログは "{データディレクトリ}/{トピック名}/{パーティション}/" ディレクトリに保存される
+// （Config.Topic.FlatLayout の場合は "{データディレクトリ}/{トピック名}/"）。
+// データディレクトリは Dir と Config.Topic.DataDirs で、削除中ディレクトリとゴミ箱はデータディレクトリごとに作成する。
+type Topics struct {
+	mu sync.RWMutex
+
+	Dir    string // トピックのディレクトリを保存するディレクトリ
+	Config Config // 各トピックのログの設定
+
+	logs      map[string]*Log   // トピック名 → ログ
+	refs      map[string]int    // トピック名 → Acquire して Release していない数
+	dirs      []string          // データディレクトリ（Dir と Config.Topic.DataDirs）
+	placement map[string]string // トピック名 → 配置したデータディレクトリ
+	removal   sync.WaitGroup    // バックグラウンドで実行中の削除
+	done      chan struct{}     // ゴミ箱を定期的に空にするゴルーチンを停止するためのチャネル
+	closeOnce sync.Once         // Close を複数回呼び出しても done を一度だけ閉じる
+
+	// データディレクトリの空き容量を返す関数（テストで差し替える）
+	freeBytes func(dir string) (uint64, error)
+}
+
+// NewTopics: トピックの管理を作成し、既存のトピックを開く
+// 既存のトピックは、すべてのデータディレクトリ（dir と Config.Topic.DataDirs）から探して開く。
+// 前回の実行で削除しきれなかったトピックがあれば、バックグラウンドで削除する。
+// 引数:
+//   - dir: トピックのディレクトリを保存するディレクトリ
+//   - c: 各トピックのログの設定
+//
+// 戻り値:
+//   - *Topics: 初期化されたトピックの管理
+//   - error: エラーが発生した場合（同じトピックが複数のデータディレクトリにある場合を含む）
+func NewTopics(dir string, c Config) (*Topics, error) {
+	dirs, err := dataDirs(dir, c.Topic.DataDirs)
+	if err != nil {
+		return nil, err
+	}
+	t := &Topics{
+		Dir:       dir,
+		Config:    c,
+		logs:      make(map[string]*Log),
+		refs:      make(map[string]int),
+		dirs:      dirs,
+		placement: make(map[string]string),
+		done:      make(chan struct{}),
+		freeBytes: diskFreeBytes,
+	}
+	for _, dataDir := range dirs {
+		if err = t.openDataDir(dataDir); err != nil {
+			return nil, err
+		}
+	}
+
+	// 削除の途中だったトピックと、保持期間を過ぎたトピックを削除する
+	if err = t.expire(c.clock().Now()); err != nil {
+		return nil, err
+	}
+	t.purge()
+
+	// ゴミ箱を定期的に空にする
+	if c.Topic.DeleteRetention > 0 {
+		interval := c.Topic.DeleteRetention
+		if interval > maxPurgeInterval {
+			interval = maxPurgeInterval
+		}
+		Every(c.clock(), interval, t.done, &t.removal, t.runExpire)
+	}
+	return t, nil
+}
+
+// openDataDir: データディレクトリの既存のトピックを開く（内部関数）
+// 削除中ディレクトリとゴミ箱がなければ作成する。
+func (t *Topics) openDataDir(dataDir string) error {
+	for _, d := range []string{deletingDir, trashDir} {
+		if err := os.MkdirAll(filepath.Join(dataDir, d), 0755); err != nil {
+			return err
+		}
+	}
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || !topicName.MatchString(name) {
+			continue
+		}
+		if other, ok := t.placement[name]; ok {
+			return fmt.Errorf("topic %q exists in both %s and %s", name, other, dataDir)
+		}
+		t.placement[name] = dataDir
+		if err = t.openLayout(name); err != nil {
+			return err
+		}
+		l, err := NewLog(t.logDir(name), t.Config)
+		if err != nil {
+			return err
+		}
+		t.logs[name] = l
+	}
+	return nil
+}
+
+// Create: 新しいトピックを作成する
+// トピックは空き容量が最も大きいデータディレクトリに配置する。
+// 引数:
+//   - name: トピック名
+//
+// 戻り値:
+//   - *Log: 作成したトピックのログ
+//   - error: エラーが発生した場合（既に存在する場合は api.ErrTopicExists）
+func (t *Topics) Create(name string) (*Log, error) {
+	if !topicName.MatchString(name) {
+		return nil, fmt.Errorf("invalid topic name: %q", name)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.logs[name]; ok {
+		return nil, api.ErrTopicExists{Topic: name}
+	}
+	dataDir, err := t.place()
+	if err != nil {
+		return nil, err
+	}
+	t.placement[name] = dataDir
+	dir := t.logDir(name)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		delete(t.placement, name)
+		return nil, err
+	}
+	l, err := NewLog(dir, t.Config)
+	if err != nil {
+		delete(t.placement, name)
+		return nil, err
+	}
+	t.logs[name] = l
+	return l, nil
+}
+
+// Get: トピックのログを取得する
+// 取得したログを使用している間に Delete でトピックが削除されると、ログは閉じられる。
+// リクエストの処理などで使用する間は、Acquire で取得して削除されないようにする。
+// 引数:
+//   - name: トピック名
+//
+// 戻り値:
+//   - *Log: トピックのログ
+//   - error: トピックが存在しない場合（api.ErrTopicNotFound）
+func (t *Topics) Get(name string) (*Log, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	l, ok := t.logs[name]
+	if !ok {
+		return nil, api.ErrTopicNotFound{Topic: name}
+	}
+	return l, nil
+}
+
+// Acquire: トピックのログを使用中として取得する
+// Release を呼び出すまでは Delete で削除されない（Delete は ErrLogInUse で失敗する）。
+// 引数:
+//   - name: トピック名
+//
+// 戻り値:
+//   - *Log: トピックのログ
+//   - error: トピックが存在しない場合（api.ErrTopicNotFound）
+func (t *Topics) Acquire(name string) (*Log, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	l, ok := t.logs[name]
+	if !ok {
+		return nil, api.ErrTopicNotFound{Topic: name}
+	}
+	t.refs[name]++
+	return l, nil
+}
+
+// Release: Acquire で取得したトピックのログの使用を終える
+func (t *Topics) Release(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.refs[name] <= 1 {
+		delete(t.refs, name)
+		return
+	}
+	t.refs[name]--
+}
+
+// List: すべてのトピック名を名前順に返す
+// 戻り値:
+//   - []string: トピック名の一覧
+func (t *Topics) List() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	names := make([]string, 0, len(t.logs))
+	for name := range t.logs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Delete: トピックを削除する
+// トピックのログを閉じて移動する（この時点でトピックは見えなくなる）。
+// 保持期間（Config.Topic.DeleteRetention）が設定されている場合はゴミ箱へ移動し、
+// 保持期間内であれば Undelete で元に戻せる。設定されていない場合は削除中ディレクトリへ移動し、
+// ファイルの削除はバックグラウンドで行う。
+// Acquire で取得されて使用中のトピックは、読み書きの途中でログを閉じないように削除しない。
+// 移動に失敗した場合は、トピックのログを開き直して削除する前の状態に戻す（Get で取得し直す必要がある）。
+// 引数:
+//   - name: トピック名
+//
+// 戻り値:
+//   - error: エラーが発生した場合（トピックが存在しない場合は api.ErrTopicNotFound、使用中の場合は ErrLogInUse）
+func (t *Topics) Delete(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	l, ok := t.logs[name]
+	if !ok {
+		return api.ErrTopicNotFound{Topic: name}
+	}
+	if t.refs[name] > 0 {
+		return fmt.Errorf("topic %q: %w", name, ErrLogInUse)
+	}
+	if err := l.Close(); err != nil {
+		return err
+	}
+
+	// 同じ名前のトピックを再作成・再削除しても衝突しないように、時刻を付けて移動する
+	dest := deletingDir
+	if t.Config.Topic.DeleteRetention > 0 {
+		dest = trashDir
+	}
+	// データディレクトリをまたいでリネームできないように、トピックと同じデータディレクトリ内に移動する
+	marked := filepath.Join(
+		t.placement[name],
+		dest,
+		name+"."+strconv.FormatInt(t.Config.clock().Now().UnixNano(), 10),
+	)
+	if err := os.Rename(t.topicDir(name), marked); err != nil {
+		// 閉じたログが残らないように、移動できなかったトピックは開き直す
+		reopened, oerr := NewLog(t.logDir(name), t.Config)
+		if oerr != nil {
+			delete(t.logs, name)
+			delete(t.placement, name)
+			return errors.Join(err, oerr)
+		}
+		t.logs[name] = reopened
+		return err
+	}
+	delete(t.logs, name)
+	delete(t.placement, name)
+
+	if dest == deletingDir {
+		t.purge()
+	}
+	return nil
+}
+
+// Undelete: ゴミ箱にある削除済みのトピックを元に戻す
+// 同じ名前のトピックが複数回削除されている場合は、最後に削除したものを戻す。
+// トピックは削除する前と同じデータディレクトリに戻す。
+// 引数:
+//   - name: トピック名
+//
+// 戻り値:
+//   - *Log: 元に戻したトピックのログ
+//   - error: エラーが発生した場合（ゴミ箱にない場合は api.ErrTopicNotFound、
+//     同じ名前のトピックが既に存在する場合は api.ErrTopicExists）
+func (t *Topics) Undelete(name string) (*Log, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.logs[name]; ok {
+		return nil, api.ErrTopicExists{Topic: name}
+	}
+
+	trashed, err := t.trashed()
+	if err != nil {
+		return nil, err
+	}
+	var latest string
+	var latestAt time.Time
+	for path, at := range trashed {
+		if trashedName(filepath.Base(path)) == name && at.After(latestAt) {
+			latest, latestAt = path, at
+		}
+	}
+	if latest == "" {
+		return nil, api.ErrTopicNotFound{Topic: name}
+	}
+
+	t.placement[name] = dataDirOf(latest)
+	if err = os.Rename(latest, t.topicDir(name)); err != nil {
+		delete(t.placement, name)
+		return nil, err
+	}
+	// 以前のレイアウトで削除したトピックも、現在のレイアウトで開く
+	if err = t.openLayout(name); err != nil {
+		return nil, err
+	}
+	l, err := NewLog(t.logDir(name), t.Config)
+	if err != nil {
+		delete(t.placement, name)
+		return nil, err
+	}
+	t.logs[name] = l
+	return l, nil
+}
+
+// Close: すべてのトピックのログを閉じる
+// ゴミ箱を定期的に空にするゴルーチンを停止し、バックグラウンドで実行中の削除が終わるまで待つ。
+// 複数回呼び出してもよい（閉じたログは閉じ直さない）。
+// 戻り値:
+//   - error: エラーが発生した場合
+func (t *Topics) Close() error {
+	// ゴミ箱を空にするゴルーチンはロックを取得するため、ロックを取得する前に停止を待つ
+	t.closeOnce.Do(func() { close(t.done) })
+	t.removal.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// 閉じたログは一覧から外し、もう一度呼び出されても閉じ直さない
+	for name, l := range t.logs {
+		delete(t.logs, name)
+		if err := l.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purge: 削除中ディレクトリの内容をバックグラウンドで削除する（内部関数）
+func (t *Topics) purge() {
+	t.removal.Add(1)
+	go func() {
+		defer t.removal.Done()
+		for _, dataDir := range t.dirs {
+			dir := filepath.Join(dataDir, deletingDir)
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, e := range entries {
+				os.RemoveAll(filepath.Join(dir, e.Name()))
+			}
+		}
+	}()
+}
+
+// runExpire: 保持期間を過ぎたトピックをゴミ箱から削除する（内部関数）
+// ゴミ箱を定期的に空にするために、DeleteRetention の間隔（最大 maxPurgeInterval）ごとに呼び出す。
+func (t *Topics) runExpire(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.expire(now); err == nil {
+		t.purge()
+	}
+}
+
+// expire: ゴミ箱の中で保持期間を過ぎたトピックを削除中ディレクトリへ移動する（内部関数）
+// 呼び出し側で t.mu のロックを取得しておく必要がある（NewTopics の場合を除く）。
+// 引数:
+//   - now: 現在時刻
+//
+// 戻り値:
+//   - error: エラーが発生した場合
+func (t *Topics) expire(now time.Time) error {
+	trashed, err := t.trashed()
+	if err != nil {
+		return err
+	}
+	for path, at := range trashed {
+		if now.Sub(at) < t.Config.Topic.DeleteRetention {
+			continue
+		}
+		if err = os.Rename(
+			path,
+			filepath.Join(dataDirOf(path), deletingDir, filepath.Base(path)),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trashed: すべてのデータディレクトリのゴミ箱の中のディレクトリのパスと削除時刻を返す（内部関数）
+func (t *Topics) trashed() (map[string]time.Time, error) {
+	trashed := make(map[string]time.Time)
+	for _, dataDir := range t.dirs {
+		dir := filepath.Join(dataDir, trashDir)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			ext := filepath.Ext(e.Name())
+			nanos, err := strconv.ParseInt(strings.TrimPrefix(ext, "."), 10, 64)
+			if err != nil {
+				continue
+			}
+			trashed[filepath.Join(dir, e.Name())] = time.Unix(0, nanos)
+		}
+	}
+	return trashed, nil
+}
+
+// dataDirOf: ゴミ箱または削除中ディレクトリの中のパスから、データディレクトリを取り出す
+// 例: "/data1/.trash/orders.1700000000000000000" → "/data1"
+func dataDirOf(path string) string {
+	return filepath.Dir(filepath.Dir(path))
+}
+
+// trashedName: ゴミ箱の中のディレクトリ名からトピック名を取り出す
+// 例: "orders.1700000000000000000" → "orders"
+func trashedName(dir string) string {
+	return strings.TrimSuffix(dir, filepath.Ext(dir))
+}
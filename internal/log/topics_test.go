@@ -0,0 +1,255 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopics(t *testing.T) {
+	dir, err := os.MkdirTemp("", "topics-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	topics, err := NewTopics(dir, Config{})
+	require.NoError(t, err)
+	require.Empty(t, topics.List())
+
+	orders, err := topics.Create("orders")
+	require.NoError(t, err)
+	_, err = topics.Create("payments")
+	require.NoError(t, err)
+	require.Equal(t, []string{"orders", "payments"}, topics.List())
+
+	// 同じ名前のトピックは作成できない
+	_, err = topics.Create("orders")
+	require.Equal(t, api.ErrTopicExists{Topic: "orders"}, err)
+	// ディレクトリ名として不正なトピック名は使用できない
+	_, err = topics.Create("../orders")
+	require.Error(t, err)
+	_, err = topics.Create(deletingDir)
+	require.Error(t, err)
+
+	off, err := orders.Append(&api.Record{Value: []byte("hello world")})
+	require.NoError(t, err)
+
+	// 使用中のトピックは削除できない
+	_, err = topics.Acquire("payments")
+	require.NoError(t, err)
+	require.ErrorIs(t, topics.Delete("payments"), ErrLogInUse)
+	require.Equal(t, []string{"orders", "payments"}, topics.List())
+	topics.Release("payments")
+
+	// トピックは削除するとすぐに見えなくなる
+	require.NoError(t, topics.Delete("payments"))
+	require.Equal(t, []string{"orders"}, topics.List())
+	_, err = topics.Get("payments")
+	require.Equal(t, api.ErrTopicNotFound{Topic: "payments"}, err)
+	require.Equal(t, api.ErrTopicNotFound{Topic: "payments"}, topics.Delete("payments"))
+	require.NoError(t, topics.Close())
+
+	// バックグラウンドの削除が終わると、ファイルも残っていない
+	_, err = os.Stat(filepath.Join(dir, "payments"))
+	require.True(t, os.IsNotExist(err))
+	entries, err := os.ReadDir(filepath.Join(dir, deletingDir))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// 既存のトピックは開き直しても復元される
+	topics, err = NewTopics(dir, Config{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"orders"}, topics.List())
+	orders, err = topics.Get("orders")
+	require.NoError(t, err)
+	read, err := orders.Read(off)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello world"), read.Value)
+	require.NoError(t, topics.Close())
+	// 複数回閉じてもよい
+	require.NoError(t, topics.Close())
+}
+
+func TestTopicsUndelete(t *testing.T) {
+	dir, err := os.MkdirTemp("", "topics-undelete-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clock := NewFakeClock(time.Now())
+	c := Config{Clock: clock}
+	c.Topic.DeleteRetention = time.Hour
+	topics, err := NewTopics(dir, c)
+	require.NoError(t, err)
+
+	orders, err := topics.Create("orders")
+	require.NoError(t, err)
+	off, err := orders.Append(&api.Record{Value: []byte("hello world")})
+	require.NoError(t, err)
+
+	// ゴミ箱にないトピックは元に戻せない
+	_, err = topics.Undelete("orders")
+	require.Equal(t, api.ErrTopicExists{Topic: "orders"}, err)
+	_, err = topics.Undelete("payments")
+	require.Equal(t, api.ErrTopicNotFound{Topic: "payments"}, err)
+
+	// 削除したトピックは見えなくなるが、保持期間内であれば元に戻せる
+	require.NoError(t, topics.Delete("orders"))
+	require.Empty(t, topics.List())
+	orders, err = topics.Undelete("orders")
+	require.NoError(t, err)
+	require.Equal(t, []string{"orders"}, topics.List())
+	read, err := orders.Read(off)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello world"), read.Value)
+
+	// 保持期間を過ぎたトピックはゴミ箱から削除される
+	require.NoError(t, topics.Delete("orders"))
+	clock.Advance(c.Topic.DeleteRetention)
+	require.Eventually(t, func() bool {
+		entries, err := os.ReadDir(filepath.Join(dir, trashDir))
+		return err == nil && len(entries) == 0
+	}, time.Second, time.Millisecond)
+	_, err = topics.Undelete("orders")
+	require.Equal(t, api.ErrTopicNotFound{Topic: "orders"}, err)
+
+	// ゴミ箱へ移動できない場合は削除に失敗し、トピックを開き直して使い続けられる
+	orders, err = topics.Create("orders")
+	require.NoError(t, err)
+	off, err = orders.Append(&api.Record{Value: []byte("kept")})
+	require.NoError(t, err)
+	trash := filepath.Join(dir, trashDir)
+	require.NoError(t, os.Remove(trash))
+	require.NoError(t, os.WriteFile(trash, nil, 0644))
+	require.Error(t, topics.Delete("orders"))
+	require.Equal(t, []string{"orders"}, topics.List())
+	orders, err = topics.Get("orders")
+	require.NoError(t, err)
+	read, err = orders.Read(off)
+	require.NoError(t, err)
+	require.Equal(t, []byte("kept"), read.Value)
+	_, err = orders.Append(&api.Record{Value: []byte("after")})
+	require.NoError(t, err)
+
+	// 移動できるようになれば削除できる
+	require.NoError(t, os.Remove(trash))
+	require.NoError(t, os.Mkdir(trash, 0755))
+	require.NoError(t, topics.Delete("orders"))
+	require.Empty(t, topics.List())
+	require.NoError(t, topics.Close())
+}
+
+func TestTopicsLayoutMigration(t *testing.T) {
+	dir := t.TempDir()
+	flat := Config{}
+	flat.Topic.FlatLayout = true
+
+	// 以前のレイアウトでトピックを作成する
+	topics, err := NewTopics(dir, flat)
+	require.NoError(t, err)
+	for _, name := range []string{"orders", "payments"} {
+		l, err := topics.Create(name)
+		require.NoError(t, err)
+		_, err = l.Append(&api.Record{Value: []byte(name)})
+		require.NoError(t, err)
+	}
+	require.NoError(t, topics.Close())
+	require.FileExists(t, filepath.Join(dir, "orders", "0.store"))
+
+	// payments の移行の途中でプロセスが終了した状態にする
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "payments", migratingDir), 0755))
+	require.NoError(t, os.Rename(
+		filepath.Join(dir, "payments", "0.store"),
+		filepath.Join(dir, "payments", migratingDir, "0.store"),
+	))
+
+	// パーティションのレイアウトで開くと移行される
+	topics, err = NewTopics(dir, Config{})
+	require.NoError(t, err)
+	for _, name := range []string{"orders", "payments"} {
+		l, err := topics.Get(name)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(dir, name, defaultPartition), l.Dir)
+		record, err := l.Read(0)
+		require.NoError(t, err)
+		require.Equal(t, []byte(name), record.Value)
+		require.NoFileExists(t, filepath.Join(dir, name, "0.store"))
+		require.NoDirExists(t, filepath.Join(dir, name, migratingDir))
+	}
+	_, err = topics.Create("refunds")
+	require.NoError(t, err)
+	require.DirExists(t, filepath.Join(dir, "refunds", defaultPartition))
+	require.NoError(t, topics.Close())
+
+	// 移行済みのトピックは以前のレイアウトでは開けない
+	_, err = NewTopics(dir, flat)
+	require.ErrorContains(t, err, "partitioned")
+}
+
+func TestTopicsDataDirs(t *testing.T) {
+	root := t.TempDir()
+	disk1, disk2 := filepath.Join(root, "disk1"), filepath.Join(root, "disk2")
+	require.NoError(t, os.Mkdir(disk1, 0755))
+
+	c := Config{}
+	c.Topic.DeleteRetention = time.Hour
+	c.Topic.DataDirs = []string{disk2}
+	topics, err := NewTopics(disk1, c)
+	require.NoError(t, err)
+
+	// 新しいトピックは空き容量が最も大きいデータディレクトリに配置する
+	free := map[string]uint64{disk1: 100, disk2: 200}
+	topics.freeBytes = func(dir string) (uint64, error) { return free[dir], nil }
+	orders, err := topics.Create("orders")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(disk2, "orders", defaultPartition), orders.Dir)
+	// 空き容量が同じ場合は、配置しているトピックが少ない方を選ぶ
+	free[disk1], free[disk2] = 50, 50
+	_, err = topics.Create("payments")
+	require.NoError(t, err)
+	free[disk2] = 60
+	_, err = topics.Create("refunds")
+	require.NoError(t, err)
+	for name, want := range map[string]string{"orders": disk2, "payments": disk1, "refunds": disk2} {
+		dataDir, err := topics.DataDir(name)
+		require.NoError(t, err)
+		require.Equal(t, want, dataDir, name)
+	}
+	_, err = orders.Append(&api.Record{Value: []byte("hello world")})
+	require.NoError(t, err)
+
+	// 削除したトピックは同じデータディレクトリのゴミ箱に移動し、元に戻すと同じデータディレクトリに戻る
+	require.NoError(t, topics.Delete("orders"))
+	entries, err := os.ReadDir(filepath.Join(disk2, trashDir))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	_, err = topics.DataDir("orders")
+	require.Equal(t, api.ErrTopicNotFound{Topic: "orders"}, err)
+	orders, err = topics.Undelete("orders")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(disk2, "orders", defaultPartition), orders.Dir)
+	require.NoError(t, topics.Close())
+
+	// 開き直すと、すべてのデータディレクトリからトピックを開く
+	topics, err = NewTopics(disk1, c)
+	require.NoError(t, err)
+	require.Equal(t, []string{"orders", "payments", "refunds"}, topics.List())
+	orders, err = topics.Get("orders")
+	require.NoError(t, err)
+	record, err := orders.Read(0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello world"), record.Value)
+	require.NoError(t, topics.Close())
+
+	// 同じトピックが複数のデータディレクトリにある場合は開けない
+	require.NoError(t, os.MkdirAll(filepath.Join(disk1, "orders", defaultPartition), 0755))
+	_, err = NewTopics(disk1, c)
+	require.ErrorContains(t, err, "exists in both")
+
+	// 同じデータディレクトリは重複して指定できない
+	c.Topic.DataDirs = []string{disk1 + "/"}
+	_, err = NewTopics(disk1, c)
+	require.ErrorContains(t, err, "duplicate data directory")
+}
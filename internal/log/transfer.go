@@ -0,0 +1,240 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// SealedSegment: 書き込みが終わったセグメント（アクティブでないセグメント）
+// 新しいレプリカや遅れているレプリカに、レコードを1件ずつ再生する代わりに
+// セグメントファイルをそのまま転送するために使用する。
+type SealedSegment struct {
+	BaseOffset uint64            // セグメントの開始オフセット
+	NextOffset uint64            // セグメントの最後のレコードのオフセット + 1
+	Format     int               // ストアの保存形式（FormatV1 または FormatV2、InstallSegment に渡す）
+	Store      *io.SectionReader // ストアファイルの内容
+	Index      *io.SectionReader // インデックスファイルの内容（有効なエントリ分のみ）
+	release    func() error      // セグメントの参照を外す（一度だけ実行される）
+}
+
+// Close: セグメントの参照を外す
+// 読み取りが終わったら呼び出す。呼び出すまでは、Truncate で削除されてもセグメントのファイルは残る。
+func (s SealedSegment) Close() error {
+	if s.release == nil {
+		return nil
+	}
+	return s.release()
+}
+
+// SealedSegments: 指定されたオフセット以降のレコードを含む、書き込み済みセグメントの一覧を返す
+// アクティブセグメントは書き込み中のため含めない。破損を他のノードに広げないように、
+// 隔離中のセグメントとそれ以降のセグメントも含めない。
+// 書き込み済みセグメントは変更されないため、返された Reader はロックなしで読み取れる。
+// 各セグメントの参照を取得するため、読み取り中に Truncate で削除されても読み取りを続けられる。
+// 読み取りが終わったら、各セグメントの Close を呼び出して参照を外す必要がある。
+// 引数:
+//   - from: このオフセット以降のレコードを含むセグメントを返す
+//
+// 戻り値:
+//   - []SealedSegment: baseOffset の昇順に並んだ書き込み済みセグメント
+func (l *Log) SealedSegments(from uint64) []SealedSegment {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var segments []SealedSegment
+	for _, s := range l.segments {
+		if s == l.activeSegment.segment || s.quarantine != nil {
+			break
+		}
+		if s.nextOffset <= from {
+			continue
+		}
+		s.acquire()
+		segments = append(segments, SealedSegment{
+			BaseOffset: s.baseOffset,
+			NextOffset: s.nextOffset,
+			Format:     l.Config.Segment.Format,
+			Store:      io.NewSectionReader(s.store, 0, int64(s.store.size)),
+			// mmap への書き込みはファイルにも反映されるため、ファイルから直接読み取れる
+			Index:   io.NewSectionReader(s.index.file, 0, int64(s.index.size)),
+			release: sync.OnceValue(s.release),
+		})
+	}
+	return segments
+}
+
+// InstallSegment: 転送されたセグメントファイルをログの末尾に追加する
+// セグメントの baseOffset はログの次のオフセット（最大オフセット + 1）と一致している必要がある。
+// アクティブセグメントが空の場合は削除して置き換え、インストール後は
+// インストールしたセグメントの次のオフセットから新しいアクティブセグメントを作成する。
+// セグメントファイルは、同じ保存形式（Config.Segment.Format）のログストアから転送されたものである必要があり、
+// 保存形式が異なる場合は（別の形式としてレコードを読み取らないように）ファイルを書き込む前に失敗する。
+// 開始オフセットが続かないなど、受け取ったセグメントの検証に失敗した場合はインストールせずにエラーを返し、
+// ログは異常状態にしない（Healthy が失敗するのは、インストール済みのセグメントの不変条件が破られた場合だけ）。
+// 引数:
+//   - baseOffset: インストールするセグメントの開始オフセット
+//   - format: 転送元のセグメントのストアの保存形式（SealedSegment.Format、0 の場合は FormatV1）
+//   - store: ストアファイルの内容
+//   - index: インデックスファイルの内容（有効なエントリ分のみ）
+//
+// 戻り値:
+//   - error: エラーが発生した場合（オフセットが続かない場合は api.ErrOffsetGap）
+func (l *Log) InstallSegment(baseOffset uint64, format int, store, index io.Reader) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.unhealthy != nil {
+		return l.unhealthy
+	}
+	if format == 0 {
+		format = FormatV1
+	}
+	if format != l.Config.Segment.Format {
+		return fmt.Errorf("segment %d has store format %d, but the log uses format %d", baseOffset, format, l.Config.Segment.Format)
+	}
+
+	// セグメントはログの次のオフセットから始まっていなければならない
+	// 受け取ったセグメントの検証の失敗ではログは壊れていないため、異常状態にはしない（checkOffset を使わない）
+	active := l.activeSegment
+	if active.nextOffset != baseOffset {
+		return api.ErrOffsetGap{Expected: active.nextOffset, Actual: baseOffset}
+	}
+
+	// 一時ファイルに書き込んでからリネームする（途中で失敗しても setup() が読み込まないように）
+	storePath := filepath.Join(l.Dir, fmt.Sprintf("%d%s", baseOffset, ".store"))
+	indexPath := filepath.Join(l.Dir, fmt.Sprintf("%d%s", baseOffset, ".index"))
+	storeBytes, err := writeTempFile(storePath+".tmp", store)
+	if err != nil {
+		return err
+	}
+	n, err := writeTempFile(indexPath+".tmp", index)
+	if err != nil {
+		return err
+	}
+	l.Config.writes.addStore(uint64(storeBytes))
+	l.Config.writes.addIndex(uint64(n))
+	// インデックスは mmap のために MaxIndexBytes まで拡張されるため、それを超えるとエントリが失われる
+	if uint64(n) > l.Config.Segment.MaxIndexBytes || uint64(n)%entWidth != 0 || n == 0 {
+		os.Remove(storePath + ".tmp")
+		os.Remove(indexPath + ".tmp")
+		return fmt.Errorf("invalid index size for segment %d: %d bytes", baseOffset, n)
+	}
+
+	// セグメント内のオフセットにギャップがないことを確認する
+	if err = checkIndex(indexPath+".tmp", baseOffset); err != nil {
+		os.Remove(storePath + ".tmp")
+		os.Remove(indexPath + ".tmp")
+		return err
+	}
+
+	// 空のアクティブセグメントは同じファイル名を使うため、先にファイルを削除する
+	// ロックの外で読み取り中の Reader は開いているファイルを使い続け、参照を外したときにセグメントが閉じられる
+	if active.baseOffset == baseOffset {
+		if err = active.removeFiles(); err != nil {
+			os.Remove(storePath + ".tmp")
+			os.Remove(indexPath + ".tmp")
+			return err
+		}
+		l.segments = l.segments[:len(l.segments)-1]
+		l.subSize(active.segment)
+		// 削除したセグメントと同じファイル名を使うため、newSegment で読み取り専用にしない
+		l.activeSegment = nil
+		if err = active.detach(); err != nil {
+			return l.abortInstall(baseOffset, err)
+		}
+	}
+
+	if err = os.Rename(storePath+".tmp", storePath); err != nil {
+		return l.abortInstall(baseOffset, err)
+	}
+	if err = os.Rename(indexPath+".tmp", indexPath); err != nil {
+		return l.abortInstall(baseOffset, err)
+	}
+	if err = failpoint(FailpointSegmentInstall); err != nil {
+		return l.abortInstall(baseOffset, err)
+	}
+
+	// インストールしたセグメントを開き、その次のオフセットから新しいアクティブセグメントを作成する
+	if err = l.newSegment(baseOffset); err != nil {
+		return l.abortInstall(baseOffset, err)
+	}
+	// インストールしたレコードのシーケンス番号を重複排除の状態に反映する
+	if err = l.scanProducers(baseOffset); err != nil {
+		return err
+	}
+	return l.newSegment(l.activeSegment.nextOffset)
+}
+
+// abortInstall: インストールに失敗したセグメントのファイルを削除し、ログをインストールの前の状態に戻す（内部関数）
+// 空のアクティブセグメントを削除して置き換えていた場合は、同じ開始オフセットで作り直して書き込みを続けられるようにする。
+// 作り直せない場合はアクティブセグメントがないため、ログを異常状態にする。
+// 呼び出し側で l.mu のロックを取得しておく必要がある。
+// 引数:
+//   - baseOffset: インストールしようとしたセグメントの開始オフセット
+//   - err: インストールに失敗した原因のエラー
+//
+// 戻り値:
+//   - error: 原因のエラー（作り直しにも失敗した場合は両方のエラー）
+func (l *Log) abortInstall(baseOffset uint64, err error) error {
+	for _, ext := range []string{".store", ".index"} {
+		path := filepath.Join(l.Dir, fmt.Sprintf("%d%s", baseOffset, ext))
+		os.Remove(path + ".tmp")
+		os.Remove(path)
+	}
+	if l.activeSegment != nil {
+		return err
+	}
+	if rerr := l.newSegment(baseOffset); rerr != nil {
+		l.unhealthy = errors.Join(err, rerr)
+		return l.unhealthy
+	}
+	return err
+}
+
+// checkIndex: インデックスファイルのエントリの相対オフセットが 0 から連続しているかを確認する（内部関数）
+// インストールする前のファイルを検証するため、連続していなくてもログは異常状態にしない。
+// 引数:
+//   - name: インデックスファイルのパス
+//   - baseOffset: セグメントの開始オフセット
+//
+// 戻り値:
+//   - error: 連続していない場合（api.ErrOffsetGap）
+func checkIndex(name string, baseOffset uint64) error {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < uint64(len(b))/entWidth; i++ {
+		off := uint64(enc.Uint32(b[i*entWidth : i*entWidth+offWidth]))
+		if off != i {
+			return api.ErrOffsetGap{Expected: baseOffset + i, Actual: baseOffset + off}
+		}
+	}
+	return nil
+}
+
+// writeTempFile: Reader の内容をファイルに書き込み、ディスクに同期する
+// 戻り値:
+//   - int64: 書き込んだバイト数
+//   - error: エラーが発生した場合
+func writeTempFile(name string, r io.Reader) (int64, error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return n, err
+	}
+	if err = failpoint(FailpointSync); err != nil {
+		return n, err
+	}
+	return n, f.Sync()
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrIndexMismatch: インデックスとストアの内容が食い違っている場合のエラー
+// ディスクの障害や外部からのファイルの変更で、インデックスのエントリがストアのレコードを指さなくなった状態を表す。
+var ErrIndexMismatch = errors.New("index does not match store")
+
+// VerifyIndex: すべてのセグメントでインデックスとストアが一致しているかを確認する
+// セグメントのレコード数とインデックスのエントリ数が等しく、最後のエントリが
+// ストア内に収まるレコードを指していることを確認する（すべてのレコードは読み取らない）。
+// 戻り値:
+//   - error: 食い違っている場合（ErrIndexMismatch をラップしたエラー）
+func (l *Log) VerifyIndex() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, s := range l.segments {
+		if err := s.verify(); err != nil {
+			return fmt.Errorf("segment %d: %w", s.baseOffset, err)
+		}
+	}
+	return nil
+}
+
+// verify: セグメントのインデックスとストアが一致しているかを確認する（内部関数）
+// 呼び出し側で Log のロックを取得しておく必要がある。
+func (s *segment) verify() error {
+	entries := s.index.size / entWidth
+	if records := s.nextOffset - s.baseOffset; entries != records {
+		return fmt.Errorf("%w: %d index entries for %d records", ErrIndexMismatch, entries, records)
+	}
+	if entries == 0 {
+		return nil
+	}
+	off, pos, err := s.index.Read(-1)
+	if err != nil {
+		return err
+	}
+	if uint64(off) != entries-1 {
+		return fmt.Errorf("%w: last index entry has offset %d, want %d", ErrIndexMismatch, off, entries-1)
+	}
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	if _, _, err := s.store.header(pos); err != nil {
+		if err == ErrCorruptRecord {
+			return fmt.Errorf("%w: last index entry points to invalid store position %d", ErrIndexMismatch, pos)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package log
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// WriteStats: ログストアがディスクに書き込んだバイト数の累計（ログストアを開いてからの値）
+// レコードのデータ量に対する書き込み量の比率（書き込みの増幅）から、SSD の消耗や
+// セグメントの書き直し（分割や、今後のコンパクション）のコストを見積もるために使用する。
+type WriteStats struct {
+	// Append と AppendBatch で追加したレコードのデータのバイト数（長さ情報を除く）
+	RecordBytes uint64
+	// ストアファイルに書き出したバイト数（長さ情報と、InstallSegment で転送されたストアファイルを含む）
+	StoreBytes uint64
+	// インデックスファイルに書き込んだエントリのバイト数（InstallSegment で転送されたインデックスファイルを含む）
+	IndexBytes uint64
+	// 既存のデータを別のファイルに書き直したバイト数（SplitSegment などの管理操作によるもの）
+	RewriteBytes uint64
+}
+
+// writeStats: WriteStats の集計に使うカウンター（内部型）
+// セグメントのストアとインデックスが Config を通して同じカウンターを共有する。
+// Config から直接作成したストアやインデックス（テストなど）では nil になるため、各メソッドは nil でも使用できる。
+type writeStats struct {
+	record  atomic.Uint64
+	store   atomic.Uint64
+	index   atomic.Uint64
+	rewrite atomic.Uint64
+}
+
+// WriteStats: ログストアを開いてからディスクに書き込んだバイト数の累計を返す
+// ロックを取得しないため、メトリクスなどから頻繁に呼び出してもよい。
+func (l *Log) WriteStats() WriteStats {
+	w := l.Config.writes
+	return WriteStats{
+		RecordBytes:  w.record.Load(),
+		StoreBytes:   w.store.Load(),
+		IndexBytes:   w.index.Load(),
+		RewriteBytes: w.rewrite.Load(),
+	}
+}
+
+func (w *writeStats) addRecord(n uint64) {
+	if w != nil {
+		w.record.Add(n)
+	}
+}
+
+func (w *writeStats) addStore(n uint64) {
+	if w != nil {
+		w.store.Add(n)
+	}
+}
+
+func (w *writeStats) addIndex(n uint64) {
+	if w != nil {
+		w.index.Add(n)
+	}
+}
+
+func (w *writeStats) addRewrite(n uint64) {
+	if w != nil {
+		w.rewrite.Add(n)
+	}
+}
+
+// countingWriter: 書き込んだバイト数をストアのカウンターに加える io.Writer（内部型）
+type countingWriter struct {
+	io.Writer
+	writes *writeStats
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.writes.addStore(uint64(n))
+	return n, err
+}
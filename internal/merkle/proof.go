@@ -0,0 +1,178 @@
+package merkle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// InclusionProof: オフセットのレコードが size 個の葉を持つツリーに含まれることの包含証明（RFC 6962 の PATH）を返す
+// 大きな部分木のハッシュはメモリに保持しているため、ツリーの大きさの対数に比例する計算で作成できる。
+// 引数:
+//   - off: レコードのオフセット
+//   - size: 証明の対象のツリーの葉の数
+//
+// 戻り値:
+//   - [][]byte: 葉に近い順の兄弟のノードのハッシュ
+//   - error: size がツリーより大きい場合は api.ErrTreeSize、オフセットが size 個の葉に含まれない場合は api.ErrOffsetOutOfRange
+func (t *Tree) InclusionProof(off, size uint64) ([][]byte, error) {
+	first, err := t.checkSize(size)
+	if err != nil {
+		return nil, err
+	}
+	if off < first || off-first >= size {
+		return nil, api.ErrOffsetOutOfRange{Offset: off}
+	}
+	return t.path(off-first, 0, size)
+}
+
+// ConsistencyProof: oldSize 個の葉のツリーが newSize 個の葉のツリーの先頭部分であることの一貫性証明（RFC 6962 の PROOF）を返す
+// oldSize が 0 または newSize と等しい場合は空の証明を返す。
+// 引数:
+//   - oldSize: 古いツリーの葉の数
+//   - newSize: 新しいツリーの葉の数
+//
+// 戻り値:
+//   - [][]byte: 証明のノードのハッシュ
+//   - error: newSize がツリーより大きい場合は api.ErrTreeSize
+func (t *Tree) ConsistencyProof(oldSize, newSize uint64) ([][]byte, error) {
+	if oldSize > newSize {
+		return nil, fmt.Errorf("merkle: old size %d is larger than new size %d", oldSize, newSize)
+	}
+	if _, err := t.checkSize(newSize); err != nil {
+		return nil, err
+	}
+	if oldSize == 0 || oldSize == newSize {
+		return nil, nil
+	}
+	return t.subproof(oldSize, 0, newSize, true)
+}
+
+// checkSize: size 個の葉がツリーに追加済みであることを確認し、葉 0 のオフセットを返す（内部関数）
+func (t *Tree) checkSize(size uint64) (uint64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if size > t.size {
+		return 0, api.ErrTreeSize{Size: size, TreeSize: t.size}
+	}
+	return t.first, nil
+}
+
+// path: 葉 [lo, hi) の部分木における m 番目の葉の包含証明を返す（RFC 6962 2.1.1）
+func (t *Tree) path(m, lo, hi uint64) ([][]byte, error) {
+	if hi-lo == 1 {
+		return nil, nil
+	}
+	k := split(hi - lo)
+	var (
+		proof [][]byte
+		h     []byte
+		err   error
+	)
+	if m < k {
+		if proof, err = t.path(m, lo, lo+k); err == nil {
+			h, err = t.rangeHash(lo+k, hi)
+		}
+	} else {
+		if proof, err = t.path(m-k, lo+k, hi); err == nil {
+			h, err = t.rangeHash(lo, lo+k)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(proof, h), nil
+}
+
+// subproof: 葉 [lo, hi) の部分木における先頭 m 個の葉の一貫性証明を返す（RFC 6962 2.1.2）
+// complete は先頭 m 個の葉の部分木が、古いツリー全体と一致するかどうか（一致する場合はそのハッシュを含めない）。
+func (t *Tree) subproof(m, lo, hi uint64, complete bool) ([][]byte, error) {
+	if m == hi-lo {
+		if complete {
+			return nil, nil
+		}
+		h, err := t.rangeHash(lo, hi)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{h}, nil
+	}
+	k := split(hi - lo)
+	var (
+		proof [][]byte
+		h     []byte
+		err   error
+	)
+	if m <= k {
+		if proof, err = t.subproof(m, lo, lo+k, complete); err == nil {
+			h, err = t.rangeHash(lo+k, hi)
+		}
+	} else {
+		if proof, err = t.subproof(m-k, lo+k, hi, false); err == nil {
+			h, err = t.rangeHash(lo, lo+k)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(proof, h), nil
+}
+
+// rangeHash: 葉 [lo, hi) の部分木のハッシュ（RFC 6962 の MTH）を返す
+// lo は path と subproof が分割した部分木の境界（hi - lo 以上の2のべき乗の倍数）である必要がある。
+// 範囲を左から大きい順の完全二分木に分け、保持しているハッシュ（nodes）を使って計算するため、
+// 証明の作成で読み取る葉の数はツリーの大きさによらない（証明全体で O(log n) 回の計算）。
+func (t *Tree) rangeHash(lo, hi uint64) ([]byte, error) {
+	var frontier [][]byte
+	for lo < hi {
+		// lo から始まる、範囲に収まる最大の完全二分木
+		level := 0
+		for lo%(2<<level) == 0 && lo+(2<<level) <= hi {
+			level++
+		}
+		h, err := t.nodeHash(lo, level)
+		if err != nil {
+			return nil, err
+		}
+		frontier = append(frontier, h)
+		lo += 1 << level
+	}
+	return fold(frontier), nil
+}
+
+// nodeHash: 葉 lo から始まる高さ level の完全二分木のハッシュを返す（内部関数）
+// 高さが cacheLevel 以上の場合は保持しているハッシュを返し、それより小さい場合はファイルの葉のハッシュから計算する。
+func (t *Tree) nodeHash(lo uint64, level int) ([]byte, error) {
+	if level >= cacheLevel {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return t.nodes[level-cacheLevel][lo>>level], nil
+	}
+	return t.leafRangeHash(lo, lo+1<<level)
+}
+
+// leafRangeHash: 葉 [lo, hi) の部分木のハッシュをファイルの葉のハッシュから計算する（内部関数）
+// 葉を順に読み取りながら完全二分木をまとめていくため、メモリは葉の数の対数に比例する分だけ使用する。
+func (t *Tree) leafRangeHash(lo, hi uint64) ([]byte, error) {
+	r := bufio.NewReader(io.NewSectionReader(t.file, headerWidth+int64(lo)*hashWidth, int64(hi-lo)*hashWidth))
+	var frontier [][]byte
+	for n := uint64(0); n < hi-lo; n++ {
+		h := make([]byte, hashWidth)
+		if _, err := io.ReadFull(r, h); err != nil {
+			return nil, err
+		}
+		frontier = push(frontier, n, h)
+	}
+	return fold(frontier), nil
+}
+
+// split: 葉の数 n（2以上）より小さい最大の2のべき乗を返す（部分木を左右に分ける位置）
+func split(n uint64) uint64 {
+	k := uint64(1)
+	for k*2 < n {
+		k *= 2
+	}
+	return k
+}
@@ -0,0 +1,130 @@
+package merkle
+
+import (
+	"crypto/ed25519"
+	"path/filepath"
+	"testing"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProofs: すべてのツリーのサイズと葉の位置で、作成した証明をクライアントの検証関数で検証できることをテストする
+func TestProofs(t *testing.T) {
+	lc := log.Config{}
+	lc.Segment.InitialOffset = 100
+	l, err := log.NewLog(t.TempDir(), lc)
+	require.NoError(t, err)
+	defer l.Close()
+	const n = 13
+	appendRecords(t, l, n)
+
+	_, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	tree, err := NewTree(filepath.Join(t.TempDir(), "tree"), l, Config{Signer: key})
+	require.NoError(t, err)
+	defer tree.Close()
+	waitFor(t, tree, n)
+
+	var (
+		records []*api.Record
+		leaves  [][]byte
+		roots   [][]byte
+	)
+	for i := 0; i < n; i++ {
+		record, err := l.Read(100 + uint64(i))
+		require.NoError(t, err)
+		leaf, err := api.LeafHash(record)
+		require.NoError(t, err)
+		records = append(records, record)
+		leaves = append(leaves, leaf)
+	}
+	for size := 0; size <= n; size++ {
+		roots = append(roots, api.RootHash(leaves[:size]))
+	}
+
+	for size := uint64(1); size <= n; size++ {
+		head := &api.TreeHead{TreeSize: size, RootHash: roots[size], FirstOffset: 100}
+		for i := uint64(0); i < size; i++ {
+			proof, err := tree.InclusionProof(100+i, size)
+			require.NoError(t, err)
+			require.NoError(t, api.VerifyRecordInclusion(head, records[i], proof), "size %d index %d", size, i)
+			// 別の葉や別のサイズのルートでは検証に失敗する
+			require.ErrorIs(t, api.VerifyInclusion(leaves[(i+1)%n], i, size, proof, roots[size]), api.ErrInvalidProof)
+			require.ErrorIs(t, api.VerifyInclusion(leaves[i], i, size, proof, roots[size-1]), api.ErrInvalidProof)
+		}
+		for old := uint64(0); old <= size; old++ {
+			proof, err := tree.ConsistencyProof(old, size)
+			require.NoError(t, err)
+			require.NoError(t, api.VerifyConsistency(old, size, roots[old], roots[size], proof), "old %d new %d", old, size)
+			if old > 0 && old < size {
+				// 書き換えられた古いツリーのルートでは検証に失敗する
+				require.ErrorIs(t, api.VerifyConsistency(old, size, roots[old-1], roots[size], proof), api.ErrInvalidProof)
+			}
+		}
+	}
+
+	// ツリーに追加されていないサイズや、ツリーに含まれないオフセットは失敗する
+	_, err = tree.InclusionProof(100, n+1)
+	require.Equal(t, api.ErrTreeSize{Size: n + 1, TreeSize: n}, err)
+	_, err = tree.InclusionProof(99, n)
+	require.IsType(t, api.ErrOffsetOutOfRange{}, err)
+	_, err = tree.InclusionProof(100+3, 3)
+	require.IsType(t, api.ErrOffsetOutOfRange{}, err)
+	_, err = tree.ConsistencyProof(1, n+1)
+	require.IsType(t, api.ErrTreeSize{}, err)
+	_, err = tree.ConsistencyProof(3, 2)
+	require.Error(t, err)
+}
+
+// TestLargeProofs: 保持している大きな部分木のハッシュを使う証明を、再起動の前後で検証できることをテストする
+func TestLargeProofs(t *testing.T) {
+	l, err := log.NewLog(t.TempDir(), log.Config{})
+	require.NoError(t, err)
+	defer l.Close()
+	const n = 2<<cacheLevel + 37
+	appendRecords(t, l, n)
+
+	var (
+		records []*api.Record
+		leaves  [][]byte
+	)
+	for i := 0; i < n; i++ {
+		record, err := l.Read(uint64(i))
+		require.NoError(t, err)
+		leaf, err := api.LeafHash(record)
+		require.NoError(t, err)
+		records = append(records, record)
+		leaves = append(leaves, leaf)
+	}
+
+	_, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "tree")
+	for _, restart := range []bool{false, true} {
+		tree, err := NewTree(path, l, Config{Signer: key})
+		require.NoError(t, err)
+		waitFor(t, tree, n)
+		for _, size := range []uint64{1 << cacheLevel, 1<<cacheLevel + 1, 2 << cacheLevel, n} {
+			head := &api.TreeHead{TreeSize: size, RootHash: api.RootHash(leaves[:size])}
+			for _, i := range []uint64{0, 1<<cacheLevel - 1, 1 << cacheLevel, size - 1} {
+				if i >= size {
+					continue
+				}
+				proof, err := tree.InclusionProof(i, size)
+				require.NoError(t, err)
+				require.NoError(t, api.VerifyRecordInclusion(head, records[i], proof), "restart %v size %d index %d", restart, size, i)
+			}
+			for _, old := range []uint64{1, 1 << cacheLevel, 1<<cacheLevel + 300, size} {
+				if old > size {
+					continue
+				}
+				proof, err := tree.ConsistencyProof(old, size)
+				require.NoError(t, err)
+				require.NoError(t, api.VerifyConsistency(old, size, api.RootHash(leaves[:old]), head.RootHash, proof), "restart %v old %d new %d", restart, old, size)
+			}
+		}
+		require.NoError(t, tree.Close())
+	}
+}
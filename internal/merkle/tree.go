@@ -0,0 +1,397 @@
+// Package merkle: ログのレコードを葉とする Merkle ツリー（RFC 6962）を保持し、署名付きのツリーヘッドを定期的に作成する
+// 監査者はツリーヘッドを保存しておき、後から受け取ったツリーヘッドや読み取ったレコードと突き合わせることで、
+// 過去のレコードが書き換えられていないことを確認できる（検証可能なログ）。
+package merkle
+
+import (
+	"bufio"
+	"crypto"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// enc: ツリーのファイルに保存する数値のエンコーディング（ログストアと同じビッグエンディアン）
+var enc = binary.BigEndian
+
+const (
+	headerWidth   = 16                     // ファイルの先頭の、葉 0 のオフセットとログストアのエポックのバイト数
+	hashWidth     = 32                     // 葉のハッシュ（SHA-256）のバイト数
+	batchSize     = 1000                   // 1回の書き込みでツリーに追加するレコードの最大数
+	retryInterval = 100 * time.Millisecond // ログの読み取りに失敗した場合に再試行するまでの間隔
+	// メモリに保持する完全二分木のハッシュの最小の高さ（2^cacheLevel 個の葉を持つ部分木から保持する）
+	// それより小さい部分木のハッシュは、ファイルの葉のハッシュ（最大 2^cacheLevel 個）から計算する。
+	// 保持するハッシュは葉の数の 1/2^(cacheLevel-1) 程度になる（葉 100 万個で約 60KB）。
+	cacheLevel = 10
+)
+
+// DefaultSignInterval: Config.SignInterval が 0 の場合にツリーヘッドに署名する間隔
+const DefaultSignInterval = time.Minute
+
+// Log: ツリーが読み取るログストア（例: log.Log）
+type Log interface {
+	Read(uint64) (*api.Record, error)
+	LowestOffset() (uint64, error)
+	Appended() <-chan struct{}
+	Epoch() uint64
+}
+
+// Config: ツリーの設定
+type Config struct {
+	// ツリーヘッドに署名する鍵（必須）
+	// 監査者が受領証と同じ公開鍵で検証できるように、通常は server.Config.ReceiptKey と同じ鍵を使用する。
+	Signer crypto.Signer
+	// ツリーヘッドに含めるノードの識別子
+	NodeID string
+	// ツリーヘッドに署名する間隔（0 の場合は DefaultSignInterval）
+	// 間隔の間に追加されたレコードは、次のツリーヘッドに含まれる。
+	SignInterval time.Duration
+	// ツリーヘッドのタイムスタンプと署名の間隔に使用する時計（nil の場合は log.SystemClock）
+	Clock log.Clock
+}
+
+// Tree: ログのレコードを順に読み取り、葉のハッシュをファイルに追記していく Merkle ツリー
+// ファイルは [葉 0 のオフセット(8バイト)][ログストアのエポック(8バイト)][葉のハッシュ(32バイト)]... の形式で、再起動後は続きから追加する。
+// 葉 0 はツリーを作成したときにログが保持している最小のオフセットのレコードで、それより前のレコードは含まない。
+// ログストアが ResetAt でリセットされた（エポックが変わった）場合は、リセット前のレコードの葉を捨てて、
+// リセット後の最小のオフセットを葉 0 とするツリーを作り直す（停止中にリセットされた場合も、開いたときに作り直す）。
+// ツリーに追加する前に Truncate でレコードが削除された場合は、ツリーを続けられないため追加を止める
+// （保持期間がツリーの読み取りより短くならないように設定する）。
+type Tree struct {
+	file *os.File
+	log  Log
+	c    Config
+
+	mu       sync.Mutex
+	first    uint64        // 葉 0 のオフセット
+	epoch    uint64        // 葉を読み取ったログストアのエポック
+	size     uint64        // 葉の数
+	frontier [][]byte      // size を2進数で分解した完全二分木のルート（大きい順）
+	nodes    [][][]byte    // 高さ cacheLevel 以上の完全二分木のハッシュ（nodes[高さ - cacheLevel][左からの位置]）
+	head     *api.TreeHead // 最新の署名付きのツリーヘッド
+
+	done chan struct{}  // ログの読み取りを停止するためのチャネル
+	wg   sync.WaitGroup // ログを読み取るゴルーチンの終了待ち
+}
+
+// NewTree: ツリーを作成または既存のツリーを開き、ログの読み取りとツリーヘッドの署名を開始する
+// 引数:
+//   - path: ツリーを保存するファイルのパス
+//   - l: 読み取るログストア
+//   - c: ツリーの設定
+//
+// 戻り値:
+//   - *Tree: 作成されたツリー
+//   - error: エラーが発生した場合
+func NewTree(path string, l Log, c Config) (*Tree, error) {
+	if c.Signer == nil {
+		return nil, errors.New("merkle: a signer is required")
+	}
+	if c.SignInterval == 0 {
+		c.SignInterval = DefaultSignInterval
+	}
+	if c.Clock == nil {
+		c.Clock = log.SystemClock
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tree{
+		file: file,
+		log:  l,
+		c:    c,
+		done: make(chan struct{}),
+	}
+	if err = t.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err = t.sign(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	t.wg.Add(1)
+	go t.run()
+	return t, nil
+}
+
+// load: ファイルから葉のハッシュを読み込んで、現在のツリーを復元する（内部関数）
+// 新しいファイルの場合と、ファイルを作成した後にログストアがリセットされていた場合は、ツリーを作り直す。
+// 書き込みの途中でクラッシュした場合に残る、末尾の不完全なハッシュは切り捨てる。
+func (t *Tree) load() error {
+	fi, err := t.file.Stat()
+	if err != nil {
+		return err
+	}
+	epoch := t.log.Epoch()
+	if fi.Size() < headerWidth {
+		return t.reset(epoch)
+	}
+
+	n := (fi.Size() - headerWidth) / hashWidth
+	if err = t.file.Truncate(headerWidth + n*hashWidth); err != nil {
+		return err
+	}
+	r := bufio.NewReader(io.NewSectionReader(t.file, 0, headerWidth+n*hashWidth))
+	header := make([]byte, headerWidth)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return err
+	}
+	if enc.Uint64(header[8:]) != epoch {
+		return t.reset(epoch)
+	}
+	t.first = enc.Uint64(header)
+	t.epoch = epoch
+	for i := int64(0); i < n; i++ {
+		leaf := make([]byte, hashWidth)
+		if _, err = io.ReadFull(r, leaf); err != nil {
+			return err
+		}
+		t.push(leaf)
+	}
+	return nil
+}
+
+// reset: 葉を捨てて、ログが保持している最小のオフセットを葉 0 とする空のツリーにする（内部関数）
+// 呼び出し元でロックを保持するか、ゴルーチンの開始前に呼び出す。
+// 引数:
+//   - epoch: ツリーの葉を読み取るログストアのエポック
+func (t *Tree) reset(epoch uint64) error {
+	first, err := t.log.LowestOffset()
+	if err != nil {
+		return err
+	}
+	header := make([]byte, headerWidth)
+	enc.PutUint64(header, first)
+	enc.PutUint64(header[8:], epoch)
+	if _, err = t.file.WriteAt(header, 0); err != nil {
+		return err
+	}
+	if err = t.file.Truncate(headerWidth); err != nil {
+		return err
+	}
+	if err = t.file.Sync(); err != nil {
+		return err
+	}
+	t.first = first
+	t.epoch = epoch
+	t.size = 0
+	t.frontier = nil
+	t.nodes = nil
+	t.head = nil
+	return nil
+}
+
+// push: 葉を追加して frontier を更新する（呼び出し元でロックを保持するか、ゴルーチンの開始前に呼び出す）
+// 葉を追加して完成した高さ cacheLevel 以上の完全二分木のハッシュは、証明の作成に使用するため nodes に保持する。
+func (t *Tree) push(leaf []byte) {
+	h := leaf
+	level := 0
+	for n := t.size; n&1 == 1; n >>= 1 {
+		last := len(t.frontier) - 1
+		h = api.NodeHash(t.frontier[last], h)
+		t.frontier = t.frontier[:last]
+		// 同じ高さの完全二分木は左から順に完成するため、末尾に追加すればその位置になる
+		if level++; level >= cacheLevel {
+			for len(t.nodes) <= level-cacheLevel {
+				t.nodes = append(t.nodes, nil)
+			}
+			t.nodes[level-cacheLevel] = append(t.nodes[level-cacheLevel], h)
+		}
+	}
+	t.frontier = append(t.frontier, h)
+	t.size++
+}
+
+// root: 現在のツリーのルートハッシュを返す（呼び出し元でロックを保持する）
+func (t *Tree) root() []byte {
+	return fold(t.frontier)
+}
+
+// push: n 個の葉の frontier に葉を追加した frontier を返す
+// 同じ大きさの完全二分木が2つ並んだら1つにまとめる（2進数の繰り上がりと同じ）。
+func push(frontier [][]byte, n uint64, leaf []byte) [][]byte {
+	h := leaf
+	for ; n&1 == 1; n >>= 1 {
+		last := len(frontier) - 1
+		h = api.NodeHash(frontier[last], h)
+		frontier = frontier[:last]
+	}
+	return append(frontier, h)
+}
+
+// fold: frontier の完全二分木をまとめたツリーのルートハッシュ（RFC 6962 の MTH）を返す
+func fold(frontier [][]byte) []byte {
+	if len(frontier) == 0 {
+		return api.RootHash(nil)
+	}
+	// 右側の小さい木から順に、左側の大きい木と結合する
+	h := frontier[len(frontier)-1]
+	for i := len(frontier) - 2; i >= 0; i-- {
+		h = api.NodeHash(frontier[i], h)
+	}
+	return h
+}
+
+// Size: ツリーに追加したレコードの数を返す
+func (t *Tree) Size() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.size
+}
+
+// SignedTreeHead: 最新の署名付きのツリーヘッドを返す
+// 署名は SignInterval ごとに行うため、直前に追加されたレコードは含まれていない場合がある。
+func (t *Tree) SignedTreeHead() *api.TreeHead {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.head
+}
+
+// Close: ログの読み取りとツリーヘッドの署名を停止してツリーを閉じる
+func (t *Tree) Close() error {
+	close(t.done)
+	t.wg.Wait()
+	return t.file.Close()
+}
+
+// run: ログのレコードをツリーに追加し、SignInterval ごとにツリーヘッドに署名することを繰り返す
+func (t *Tree) run() {
+	defer t.wg.Done()
+	ticker := t.c.Clock.NewTicker(t.c.SignInterval)
+	defer ticker.Stop()
+	retry := t.c.Clock.NewTicker(retryInterval)
+	defer retry.Stop()
+	for {
+		// 読み取りの前にチャネルを取得して、読み取り後に追加されたレコードを見逃さないようにする
+		appended := t.log.Appended()
+		n, err := t.apply()
+		if err == nil && n == batchSize {
+			// まだ追加していないレコードが残っている可能性がある
+			continue
+		}
+		// 失敗した場合だけ、retryInterval の後に再試行する
+		var retryC <-chan time.Time
+		if err != nil {
+			retryC = retry.C()
+			appended = nil
+		}
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C():
+			// 署名に失敗した場合は、前のツリーヘッドのまま次の間隔で再試行する
+			_ = t.sign()
+		case <-appended:
+		case <-retryC:
+		}
+	}
+}
+
+// apply: 次のオフセットから最大 batchSize 件のレコードの葉のハッシュをファイルに追記する
+// ログストアがリセットされていた場合は、ツリーを作り直して空のツリーのツリーヘッドに署名してから追加する。
+// 読み取りの途中でリセットされた場合は、読み取った葉を追加しない（次の呼び出しで作り直す）。
+// 戻り値:
+//   - int: 追加したレコードの数
+//   - error: エラーが発生した場合（追加していないレコードが削除されていた場合を含む）
+func (t *Tree) apply() (int, error) {
+	epoch := t.log.Epoch()
+	t.mu.Lock()
+	if epoch != t.epoch {
+		err := t.reset(epoch)
+		if err == nil {
+			err = t.signHead()
+		}
+		if err != nil {
+			t.mu.Unlock()
+			return 0, err
+		}
+	}
+	next := t.first + t.size
+	t.mu.Unlock()
+
+	var leaves [][]byte
+	for len(leaves) < batchSize {
+		record, err := t.log.Read(next)
+		if _, ok := err.(api.ErrOffsetOutOfRange); ok {
+			lowest, lerr := t.log.LowestOffset()
+			if lerr != nil {
+				return 0, lerr
+			}
+			if next < lowest {
+				return 0, fmt.Errorf("merkle: record %d was deleted before it was added to the tree", next)
+			}
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		leaf, err := api.LeafHash(record)
+		if err != nil {
+			return 0, err
+		}
+		leaves = append(leaves, leaf)
+		next++
+	}
+	if len(leaves) == 0 {
+		return 0, nil
+	}
+
+	b := make([]byte, 0, len(leaves)*hashWidth)
+	for _, leaf := range leaves {
+		b = append(b, leaf...)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.log.Epoch() != t.epoch {
+		return 0, nil
+	}
+	if _, err := t.file.WriteAt(b, headerWidth+int64(t.size)*hashWidth); err != nil {
+		return 0, err
+	}
+	if err := t.file.Sync(); err != nil {
+		return 0, err
+	}
+	for _, leaf := range leaves {
+		t.push(leaf)
+	}
+	return len(leaves), nil
+}
+
+// sign: 現在のツリーのツリーヘッドに署名する（前回の署名からレコードが追加されていない場合は何もしない）
+// ログストアがリセットされていて、まだツリーを作り直していない場合も何もしない（リセット前のツリーに署名しない）。
+func (t *Tree) sign() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.signHead()
+}
+
+// signHead: 現在のツリーのツリーヘッドに署名する（呼び出し元でロックを保持する、条件は sign と同じ）
+func (t *Tree) signHead() error {
+	if t.head != nil && t.head.TreeSize == t.size {
+		return nil
+	}
+	if t.log.Epoch() != t.epoch {
+		return nil
+	}
+	head := &api.TreeHead{
+		TreeSize:    t.size,
+		RootHash:    t.root(),
+		FirstOffset: t.first,
+		Timestamp:   t.c.Clock.Now().UnixMilli(),
+		NodeId:      t.c.NodeID,
+	}
+	if err := api.SignTreeHead(head, t.c.Signer); err != nil {
+		return err
+	}
+	t.head = head
+	return nil
+}
@@ -0,0 +1,155 @@
+package merkle
+
+import (
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTree(t *testing.T) {
+	dir := t.TempDir()
+	logDir := filepath.Join(dir, "log")
+	require.NoError(t, os.Mkdir(logDir, 0755))
+	l, err := log.NewLog(logDir, log.Config{})
+	require.NoError(t, err)
+	defer l.Close()
+
+	pub, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	clock := log.NewFakeClock(time.UnixMilli(1700000000000))
+	c := Config{Signer: key, NodeID: "node-1", SignInterval: time.Second, Clock: clock}
+
+	// 空のツリーのツリーヘッドは作成時に署名される
+	path := filepath.Join(dir, "tree")
+	tree, err := NewTree(path, l, c)
+	require.NoError(t, err)
+	head := tree.SignedTreeHead()
+	require.Equal(t, uint64(0), head.TreeSize)
+	require.Equal(t, api.RootHash(nil), head.RootHash)
+	require.NoError(t, api.VerifyTreeHead(pub, head))
+
+	// 葉の数が2のべき乗でない場合も含めて、レコードから計算したルートハッシュと一致する
+	appendRecords(t, l, 5)
+	waitFor(t, tree, 5)
+	clock.Advance(time.Second)
+	head = waitForHead(t, tree, 5)
+	require.Equal(t, rootOf(t, l, 0, 5), head.RootHash)
+	require.Equal(t, "node-1", head.NodeId)
+	require.Equal(t, uint64(0), head.FirstOffset)
+	require.NoError(t, api.VerifyTreeHead(pub, head))
+
+	// 署名を書き換えたツリーヘッドは検証に失敗する
+	forged := proto.Clone(head).(*api.TreeHead)
+	forged.TreeSize++
+	require.ErrorIs(t, api.VerifyTreeHead(pub, forged), api.ErrInvalidTreeHead)
+	require.NoError(t, tree.Close())
+
+	// 再起動後は保存した葉の続きから追加する（末尾の書きかけのハッシュは切り捨てる）
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	appendRecords(t, l, 2)
+	tree, err = NewTree(path, l, c)
+	require.NoError(t, err)
+	waitFor(t, tree, 7)
+	clock.Advance(time.Second)
+	head = waitForHead(t, tree, 7)
+	require.Equal(t, rootOf(t, l, 0, 7), head.RootHash)
+
+	// ログストアがリセットされたら、リセット後のレコードでツリーを作り直す
+	require.NoError(t, l.ResetAt(50))
+	appendRecords(t, l, 3)
+	waitFor(t, tree, 3)
+	clock.Advance(time.Second)
+	head = waitForHead(t, tree, 3)
+	require.Equal(t, uint64(50), head.FirstOffset)
+	require.Equal(t, rootOf(t, l, 50, 3), head.RootHash)
+	require.NoError(t, tree.Close())
+
+	// 停止中にリセットされた場合は、開いたときに作り直す
+	require.NoError(t, l.ResetAt(80))
+	appendRecords(t, l, 1)
+	tree, err = NewTree(path, l, c)
+	require.NoError(t, err)
+	defer tree.Close()
+	waitFor(t, tree, 1)
+	clock.Advance(time.Second)
+	head = waitForHead(t, tree, 1)
+	require.Equal(t, uint64(80), head.FirstOffset)
+	require.Equal(t, rootOf(t, l, 80, 1), head.RootHash)
+}
+
+func TestTreeFirstOffset(t *testing.T) {
+	dir := t.TempDir()
+	lc := log.Config{}
+	lc.Segment.InitialOffset = 10
+	l, err := log.NewLog(dir, lc)
+	require.NoError(t, err)
+	defer l.Close()
+	appendRecords(t, l, 3)
+
+	_, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	tree, err := NewTree(filepath.Join(t.TempDir(), "tree"), l, Config{Signer: key})
+	require.NoError(t, err)
+	defer tree.Close()
+	waitFor(t, tree, 3)
+
+	// 葉 0 はツリーを作成したときにログが保持している最小のオフセットのレコード
+	require.NoError(t, tree.sign())
+	head := tree.SignedTreeHead()
+	require.Equal(t, uint64(10), head.FirstOffset)
+	require.Equal(t, rootOf(t, l, 10, 3), head.RootHash)
+
+	_, err = NewTree(filepath.Join(t.TempDir(), "tree"), l, Config{})
+	require.Error(t, err)
+}
+
+// appendRecords: ログに n 件のレコードを追加する
+func appendRecords(t *testing.T, l *log.Log, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		_, err := l.Append(&api.Record{Value: []byte("record")})
+		require.NoError(t, err)
+	}
+}
+
+// rootOf: first から n 件のレコードを読み取って、Merkle ツリーのルートハッシュを計算する
+func rootOf(t *testing.T, l *log.Log, first uint64, n int) []byte {
+	t.Helper()
+	var leaves [][]byte
+	for i := 0; i < n; i++ {
+		record, err := l.Read(first + uint64(i))
+		require.NoError(t, err)
+		leaf, err := api.LeafHash(record)
+		require.NoError(t, err)
+		leaves = append(leaves, leaf)
+	}
+	return api.RootHash(leaves)
+}
+
+// waitFor: ツリーに size 件のレコードが追加されるまで待つ
+func waitFor(t *testing.T, tree *Tree, size uint64) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return tree.Size() == size
+	}, time.Second, 10*time.Millisecond)
+}
+
+// waitForHead: size 件のレコードを含むツリーヘッドが署名されるまで待つ
+func waitForHead(t *testing.T, tree *Tree, size uint64) *api.TreeHead {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return tree.SignedTreeHead().TreeSize == size
+	}, time.Second, 10*time.Millisecond)
+	return tree.SignedTreeHead()
+}
@@ -0,0 +1,154 @@
+package offsets
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// Checkpoint: コンシューマーグループのコミット済みオフセットの一覧
+// JSON でエクスポート・インポートし、コンシューマーの切り替え（ブルー/グリーン）や
+// 障害時のコンシューマーの位置の復旧に使用する。
+type Checkpoint struct {
+	// Groups: コンシューマーグループ -> トピック -> コミット済みオフセット
+	// トピックを指定しないログ（デフォルトのログストア）は空文字のトピックで表す。
+	Groups map[string]map[string]uint64 `json:"groups"`
+}
+
+// Store: コンシューマーグループのコミット済みオフセットを保存するストア
+// 状態はコミットのたびにファイルに書き込むため、再起動後も保持される。
+type Store struct {
+	mu     sync.Mutex
+	path   string
+	groups map[string]map[string]uint64
+}
+
+// NewStore: ファイルからストアを作成する（ファイルがなければ空のストアを作成する）
+// 引数:
+//   - path: コミット済みオフセットを保存するファイルのパス
+//
+// 戻り値:
+//   - *Store: 作成されたストア
+//   - error: エラーが発生した場合
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		groups: make(map[string]map[string]uint64),
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Checkpoint
+	if err = json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	s.merge(c)
+	return s, nil
+}
+
+// Commit: コンシューマーグループがトピックのどこまで処理したかを記録する
+// 引数:
+//   - group: コンシューマーグループ
+//   - topic: トピック名（空文字の場合はデフォルトのログストア）
+//   - offset: コミットするオフセット（次に読み取るオフセット）
+//
+// 戻り値:
+//   - error: エラーが発生した場合
+func (s *Store) Commit(group, topic string, offset uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.merge(Checkpoint{Groups: map[string]map[string]uint64{group: {topic: offset}}})
+	return s.write()
+}
+
+// Fetch: コンシューマーグループがトピックにコミットしたオフセットを返す
+// 引数:
+//   - group: コンシューマーグループ
+//   - topic: トピック名（空文字の場合はデフォルトのログストア）
+//
+// 戻り値:
+//   - uint64: コミット済みオフセット
+//   - error: コミットされていない場合（api.ErrOffsetNotCommitted）
+func (s *Store) Fetch(group, topic string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	off, ok := s.groups[group][topic]
+	if !ok {
+		return 0, api.ErrOffsetNotCommitted{Group: group, Topic: topic}
+	}
+	return off, nil
+}
+
+// Export: すべてのコミット済みオフセットを返す
+func (s *Store) Export() Checkpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := Checkpoint{Groups: make(map[string]map[string]uint64, len(s.groups))}
+	for group, topics := range s.groups {
+		c.Groups[group] = make(map[string]uint64, len(topics))
+		for topic, off := range topics {
+			c.Groups[group][topic] = off
+		}
+	}
+	return c
+}
+
+// Import: エクスポートしたコミット済みオフセットを取り込む
+// 引数:
+//   - c: 取り込むコミット済みオフセット
+//   - replace: true の場合は既存のオフセットをすべて置き換え、false の場合は c に含まれるものだけを上書きする
+//
+// 戻り値:
+//   - int: 取り込んだオフセットの数
+//   - error: エラーが発生した場合
+func (s *Store) Import(c Checkpoint, replace bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if replace {
+		s.groups = make(map[string]map[string]uint64)
+	}
+	n := s.merge(c)
+	return n, s.write()
+}
+
+// merge: コミット済みオフセットを上書きする（内部関数）
+// 戻り値:
+//   - int: 上書きしたオフセットの数
+func (s *Store) merge(c Checkpoint) int {
+	n := 0
+	for group, topics := range c.Groups {
+		if s.groups[group] == nil {
+			s.groups[group] = make(map[string]uint64, len(topics))
+		}
+		for topic, off := range topics {
+			s.groups[group][topic] = off
+			n++
+		}
+	}
+	return n
+}
+
+// write: コミット済みオフセットをファイルに書き込む（内部関数）
+// 一時ファイルに書き込んでからリネームすることで、書き込み途中でクラッシュしても壊れたファイルが残らないようにする。
+func (s *Store) write() error {
+	b, err := json.Marshal(Checkpoint{Groups: s.groups})
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err = os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
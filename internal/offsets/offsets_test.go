@@ -0,0 +1,59 @@
+package offsets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := os.MkdirTemp("", "offsets-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "offsets.json")
+	s, err := NewStore(path)
+	require.NoError(t, err)
+
+	_, err = s.Fetch("billing", "orders")
+	require.Equal(t, api.ErrOffsetNotCommitted{Group: "billing", Topic: "orders"}, err)
+
+	require.NoError(t, s.Commit("billing", "orders", 10))
+	require.NoError(t, s.Commit("billing", "", 3))
+	require.NoError(t, s.Commit("search", "orders", 7))
+
+	// 再起動後もコミット済みオフセットが保持される
+	s, err = NewStore(path)
+	require.NoError(t, err)
+	off, err := s.Fetch("billing", "orders")
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), off)
+
+	exported := s.Export()
+	require.Equal(t, map[string]map[string]uint64{
+		"billing": {"orders": 10, "": 3},
+		"search":  {"orders": 7},
+	}, exported.Groups)
+
+	// マージでは含まれるオフセットだけが上書きされる
+	n, err := s.Import(Checkpoint{Groups: map[string]map[string]uint64{
+		"billing": {"orders": 20},
+	}}, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	off, err = s.Fetch("billing", "orders")
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), off)
+	off, err = s.Fetch("search", "orders")
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), off)
+
+	// 置き換えでは既存のオフセットがすべて置き換えられる
+	n, err = s.Import(exported, true)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, exported, s.Export())
+}
@@ -0,0 +1,185 @@
+package push
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+	dto "github.com/prometheus/client_model/go"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	metricsv1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// otlpExportMethod: OTLP/gRPC でメトリクスを送信するメソッド
+// collector のパッケージは grpc-gateway に依存するため、ExportMetricsServiceRequest とワイヤー形式が同じ
+// MetricsData を送信し、レスポンス（部分的な成功の情報）は読み捨てる。
+const otlpExportMethod = "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export"
+
+// OTLP: メトリクスを OTLP/gRPC で OpenTelemetry Collector などに送信する Exporter
+// カウンターは累積の Sum、ゲージは Gauge、ヒストグラムは累積の Histogram、サマリーは Summary として送信し、
+// ラベルはデータポイントの属性になる。
+type OTLP struct {
+	cc       grpc.ClientConnInterface
+	resource *resourcev1.Resource
+	clock    log.Clock
+	start    uint64 // 累積のメトリクスの開始時刻（Exporter を作成した時刻）
+}
+
+// NewOTLP: OTLP/gRPC でメトリクスを送信する Exporter を作成する
+// 引数:
+//   - cc: 送信先への gRPC の接続
+//   - resource: リソースの属性（例: {"service.name": "proglog"}）
+//   - clock: データポイントの時刻に使用する時計（nil の場合は log.SystemClock）
+//
+// 戻り値:
+//   - *OTLP: 作成された Exporter
+func NewOTLP(cc grpc.ClientConnInterface, resource map[string]string, clock log.Clock) *OTLP {
+	if clock == nil {
+		clock = log.SystemClock
+	}
+	keys := make([]string, 0, len(resource))
+	for k := range resource {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	res := &resourcev1.Resource{}
+	for _, k := range keys {
+		res.Attributes = append(res.Attributes, stringAttribute(k, resource[k]))
+	}
+	return &OTLP{cc: cc, resource: res, clock: clock, start: unixNano(clock.Now())}
+}
+
+// Export: メトリクスを OTLP のメッセージに変換して送信する
+func (o *OTLP) Export(ctx context.Context, families []*dto.MetricFamily) error {
+	now := unixNano(o.clock.Now())
+	var metrics []*metricsv1.Metric
+	for _, f := range families {
+		if m := o.metric(f, now); m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+	req := &metricsv1.MetricsData{ResourceMetrics: []*metricsv1.ResourceMetrics{{
+		Resource: o.resource,
+		ScopeMetrics: []*metricsv1.ScopeMetrics{{
+			Scope:   &commonv1.InstrumentationScope{Name: "proglog"},
+			Metrics: metrics,
+		}},
+	}}}
+	return o.cc.Invoke(ctx, otlpExportMethod, req, &emptypb.Empty{})
+}
+
+// metric: メトリクスファミリーを OTLP のメトリクスに変換する（対応していない種類の場合は nil）
+func (o *OTLP) metric(f *dto.MetricFamily, now uint64) *metricsv1.Metric {
+	m := &metricsv1.Metric{Name: f.GetName(), Description: f.GetHelp()}
+	switch f.GetType() {
+	case dto.MetricType_COUNTER:
+		sum := &metricsv1.Sum{
+			AggregationTemporality: metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			IsMonotonic:            true,
+		}
+		for _, pm := range f.Metric {
+			sum.DataPoints = append(sum.DataPoints, o.number(pm, pm.Counter.GetValue(), now))
+		}
+		m.Data = &metricsv1.Metric_Sum{Sum: sum}
+	case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+		gauge := &metricsv1.Gauge{}
+		for _, pm := range f.Metric {
+			v := pm.Gauge.GetValue()
+			if f.GetType() == dto.MetricType_UNTYPED {
+				v = pm.Untyped.GetValue()
+			}
+			gauge.DataPoints = append(gauge.DataPoints, o.number(pm, v, now))
+		}
+		m.Data = &metricsv1.Metric_Gauge{Gauge: gauge}
+	case dto.MetricType_HISTOGRAM:
+		hist := &metricsv1.Histogram{
+			AggregationTemporality: metricsv1.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+		}
+		for _, pm := range f.Metric {
+			hist.DataPoints = append(hist.DataPoints, o.histogram(pm, now))
+		}
+		m.Data = &metricsv1.Metric_Histogram{Histogram: hist}
+	case dto.MetricType_SUMMARY:
+		summary := &metricsv1.Summary{}
+		for _, pm := range f.Metric {
+			dp := &metricsv1.SummaryDataPoint{
+				Attributes:        attributes(pm.Label),
+				StartTimeUnixNano: o.start,
+				TimeUnixNano:      now,
+				Count:             pm.Summary.GetSampleCount(),
+				Sum:               pm.Summary.GetSampleSum(),
+			}
+			for _, q := range pm.Summary.Quantile {
+				dp.QuantileValues = append(dp.QuantileValues, &metricsv1.SummaryDataPoint_ValueAtQuantile{
+					Quantile: q.GetQuantile(),
+					Value:    q.GetValue(),
+				})
+			}
+			summary.DataPoints = append(summary.DataPoints, dp)
+		}
+		m.Data = &metricsv1.Metric_Summary{Summary: summary}
+	default:
+		return nil
+	}
+	return m
+}
+
+// number: カウンターまたはゲージのデータポイントを作成する
+func (o *OTLP) number(pm *dto.Metric, v float64, now uint64) *metricsv1.NumberDataPoint {
+	return &metricsv1.NumberDataPoint{
+		Attributes:        attributes(pm.Label),
+		StartTimeUnixNano: o.start,
+		TimeUnixNano:      now,
+		Value:             &metricsv1.NumberDataPoint_AsDouble{AsDouble: v},
+	}
+}
+
+// histogram: ヒストグラムのデータポイントを作成する
+// Prometheus のバケットは上限以下の累積の数だが、OTLP のバケットは境界の間ごとの数で、
+// 最後に最大の境界を超えた数が続く。
+func (o *OTLP) histogram(pm *dto.Metric, now uint64) *metricsv1.HistogramDataPoint {
+	h := pm.Histogram
+	sum := h.GetSampleSum()
+	dp := &metricsv1.HistogramDataPoint{
+		Attributes:        attributes(pm.Label),
+		StartTimeUnixNano: o.start,
+		TimeUnixNano:      now,
+		Count:             h.GetSampleCount(),
+		Sum:               &sum,
+	}
+	var prev uint64
+	for _, b := range h.Bucket {
+		if math.IsInf(b.GetUpperBound(), 1) {
+			continue
+		}
+		dp.ExplicitBounds = append(dp.ExplicitBounds, b.GetUpperBound())
+		dp.BucketCounts = append(dp.BucketCounts, b.GetCumulativeCount()-prev)
+		prev = b.GetCumulativeCount()
+	}
+	dp.BucketCounts = append(dp.BucketCounts, h.GetSampleCount()-prev)
+	return dp
+}
+
+// attributes: ラベルを OTLP の属性に変換する
+func attributes(labels []*dto.LabelPair) []*commonv1.KeyValue {
+	attrs := make([]*commonv1.KeyValue, 0, len(labels))
+	for _, l := range labels {
+		attrs = append(attrs, stringAttribute(l.GetName(), l.GetValue()))
+	}
+	return attrs
+}
+
+// stringAttribute: 文字列の属性を作成する
+func stringAttribute(k, v string) *commonv1.KeyValue {
+	return &commonv1.KeyValue{Key: k, Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: v}}}
+}
+
+// unixNano: 時刻を unix ナノ秒に変換する
+func unixNano(t time.Time) uint64 {
+	return uint64(t.UnixNano())
+}
@@ -0,0 +1,94 @@
+package push
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Exporter: 収集したメトリクスを送信する（例: Statsd、OTLP）
+type Exporter interface {
+	Export(ctx context.Context, families []*dto.MetricFamily) error
+}
+
+// Config: メトリクスを定期的に送信する設定
+type Config struct {
+	// メトリクスの取得元（nil の場合は prometheus.DefaultGatherer）
+	Gatherer prometheus.Gatherer
+	// 送信先
+	Exporter Exporter
+	// 送信する間隔（0 の場合は10秒）
+	Interval time.Duration
+	// 1回の送信の期限（0 の場合は Interval）
+	Timeout time.Duration
+	// 定期的な送信に使用する時計（nil の場合は log.SystemClock）
+	Clock log.Clock
+	// 送信に失敗したときのエラーを受け取るコールバック（nil の場合は捨てる）
+	OnError func(error)
+}
+
+// Pusher: Prometheus のメトリクスを定期的に送信する
+// スクレイパーのない環境で、Prometheus のプル型の代わりにメトリクスをプッシュするために使用する。
+type Pusher struct {
+	config Config
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// Start: メトリクスの定期的な送信を開始する
+// 引数:
+//   - config: 送信の設定
+//
+// 戻り値:
+//   - *Pusher: 開始した送信（使い終わったら Close を呼び出す）
+func Start(config Config) *Pusher {
+	if config.Gatherer == nil {
+		config.Gatherer = prometheus.DefaultGatherer
+	}
+	if config.Interval == 0 {
+		config.Interval = 10 * time.Second
+	}
+	if config.Timeout == 0 {
+		config.Timeout = config.Interval
+	}
+	if config.Clock == nil {
+		config.Clock = log.SystemClock
+	}
+	p := &Pusher{config: config, done: make(chan struct{})}
+	log.Every(config.Clock, config.Interval, p.done, &p.wg, func(time.Time) {
+		p.push()
+	})
+	return p
+}
+
+// Close: 定期的な送信を停止し、最後のメトリクスを送信する
+func (p *Pusher) Close() {
+	close(p.done)
+	p.wg.Wait()
+	p.push()
+}
+
+// push: メトリクスを収集して送信する
+func (p *Pusher) push() {
+	families, err := p.config.Gatherer.Gather()
+	if err != nil {
+		// 一部のコレクターが失敗しても、収集できたメトリクスは送信する
+		p.error(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.Timeout)
+	defer cancel()
+	if err := p.config.Exporter.Export(ctx, families); err != nil {
+		p.error(err)
+	}
+}
+
+// error: エラーをコールバックに渡す
+func (p *Pusher) error(err error) {
+	if p.config.OnError != nil {
+		p.config.OnError(err)
+	}
+}
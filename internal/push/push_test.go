@@ -0,0 +1,167 @@
+package push
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	metricsv1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// newRegistry: テスト用のメトリクスを登録したレジストリを作成する
+func newRegistry(t *testing.T) (*prometheus.Registry, *prometheus.CounterVec, prometheus.Histogram) {
+	reg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"topic"})
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds", Buckets: []float64{0.1, 1}})
+	lag := prometheus.NewGauge(prometheus.GaugeOpts{Name: "lag"})
+	require.NoError(t, reg.Register(requests))
+	require.NoError(t, reg.Register(latency))
+	require.NoError(t, reg.Register(lag))
+	lag.Set(7)
+	return reg, requests, latency
+}
+
+func TestStatsd(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+	recv := func() []string {
+		buf := make([]byte, 2048)
+		pc.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+		return strings.Split(string(buf[:n]), "\n")
+	}
+
+	reg, requests, latency := newRegistry(t)
+	requests.WithLabelValues("orders").Add(3)
+	latency.Observe(0.5)
+
+	for scenario, tc := range map[string]struct {
+		tags bool
+		want []string
+	}{
+		"dogstatsd tags": {
+			tags: true,
+			want: []string{
+				"proglog.lag:7|g",
+				"proglog.latency_seconds_count:1|c",
+				"proglog.latency_seconds_sum:0.5|c",
+				"proglog.requests_total:3|c|#topic:orders",
+			},
+		},
+		"label values in name": {
+			want: []string{
+				"proglog.lag:7|g",
+				"proglog.latency_seconds_count:1|c",
+				"proglog.latency_seconds_sum:0.5|c",
+				"proglog.requests_total.orders:3|c",
+			},
+		},
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			s, err := NewStatsd(pc.LocalAddr().String(), "proglog.", tc.tags)
+			require.NoError(t, err)
+			defer s.Close()
+
+			p := Start(Config{Gatherer: reg, Exporter: s, Clock: log.NewFakeClock(time.Unix(0, 0))})
+			p.Close()
+			require.Equal(t, tc.want, recv())
+		})
+	}
+}
+
+func TestPusherInterval(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+	s, err := NewStatsd(pc.LocalAddr().String(), "", false)
+	require.NoError(t, err)
+	defer s.Close()
+
+	reg, requests, _ := newRegistry(t)
+	clock := log.NewFakeClock(time.Unix(0, 0))
+	p := Start(Config{Gatherer: reg, Exporter: s, Interval: time.Second, Clock: clock})
+	defer p.Close()
+
+	// カウンターは前回の送信からの増分を送る
+	buf := make([]byte, 2048)
+	for _, tc := range []struct {
+		add  float64
+		want string
+	}{{2, "requests_total.orders:2|c"}, {5, "requests_total.orders:5|c"}} {
+		requests.WithLabelValues("orders").Add(tc.add)
+		clock.Advance(time.Second)
+		pc.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+		require.Contains(t, strings.Split(string(buf[:n]), "\n"), tc.want)
+	}
+}
+
+// metricsServer: 受け取った MetricsData をチャネルに送るテスト用の OTLP の受信側
+type metricsServer chan *metricsv1.MetricsData
+
+func TestOTLP(t *testing.T) {
+	received := make(metricsServer, 1)
+	srv := grpc.NewServer()
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "opentelemetry.proto.collector.metrics.v1.MetricsService",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Export",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := new(metricsv1.MetricsData)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				srv.(metricsServer) <- req
+				return &emptypb.Empty{}, nil
+			},
+		}},
+	}, received)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(l)
+	defer srv.Stop()
+	cc, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer cc.Close()
+
+	reg, requests, latency := newRegistry(t)
+	requests.WithLabelValues("orders").Add(3)
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+	latency.Observe(5)
+
+	o := NewOTLP(cc, map[string]string{"service.name": "proglog"}, log.NewFakeClock(time.Unix(10, 0)))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.NoError(t, o.Export(context.Background(), families))
+
+	req := <-received
+	rm := req.ResourceMetrics[0]
+	require.Equal(t, "service.name", rm.Resource.Attributes[0].Key)
+	metrics := map[string]*metricsv1.Metric{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+	require.Equal(t, 7.0, metrics["lag"].GetGauge().DataPoints[0].GetAsDouble())
+	sum := metrics["requests_total"].GetSum()
+	require.True(t, sum.IsMonotonic)
+	require.Equal(t, 3.0, sum.DataPoints[0].GetAsDouble())
+	require.Equal(t, "orders", sum.DataPoints[0].Attributes[0].Value.GetStringValue())
+	hist := metrics["latency_seconds"].GetHistogram().DataPoints[0]
+	require.Equal(t, uint64(3), hist.Count)
+	require.Equal(t, []float64{0.1, 1}, hist.ExplicitBounds)
+	require.Equal(t, []uint64{1, 1, 1}, hist.BucketCounts)
+	require.Equal(t, uint64(10e9), hist.TimeUnixNano)
+}
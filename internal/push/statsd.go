@@ -0,0 +1,150 @@
+package push
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// statsdPacketBytes: 1つの UDP パケットに詰める最大のバイト数（フラグメントしない大きさ）
+const statsdPacketBytes = 1432
+
+// Statsd: メトリクスを statsd の形式で UDP で送信する Exporter
+// ゲージはそのまま（|g）、カウンターは前回の送信からの増分（|c）、ヒストグラムとサマリーは
+// _count と _sum の増分（|c）を送信し、サマリーは分位数をゲージ（_q<分位数>）としても送信する。
+type Statsd struct {
+	conn   net.Conn
+	prefix string
+	tags   bool
+	last   map[string]float64 // カウンターの前回送信した値（増分を計算するため）
+}
+
+// NewStatsd: statsd にメトリクスを送信する Exporter を作成する
+// 引数:
+//   - addr: statsd のアドレス（例: "localhost:8125"）
+//   - prefix: メトリクス名の前に付ける文字列（例: "proglog."）
+//   - tags: ラベルを DogStatsD 形式のタグ（|#name:value）で送信するかどうか
+//     （false の場合、ラベルの値を "." で区切ってメトリクス名に付ける）
+//
+// 戻り値:
+//   - *Statsd: 作成された Exporter
+//   - error: エラーが発生した場合
+func NewStatsd(addr, prefix string, tags bool) (*Statsd, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Statsd{conn: conn, prefix: prefix, tags: tags, last: make(map[string]float64)}, nil
+}
+
+// Close: 送信に使用するソケットを閉じる
+func (s *Statsd) Close() error {
+	return s.conn.Close()
+}
+
+// Export: メトリクスを statsd の行に変換してパケットにまとめて送信する
+func (s *Statsd) Export(ctx context.Context, families []*dto.MetricFamily) error {
+	var lines []string
+	for _, f := range families {
+		for _, m := range f.Metric {
+			name, tags := s.series(f.GetName(), m.Label)
+			switch f.GetType() {
+			case dto.MetricType_COUNTER:
+				lines = append(lines, s.counter(name, tags, m.Counter.GetValue()))
+			case dto.MetricType_GAUGE:
+				lines = append(lines, s.line(name, tags, m.Gauge.GetValue(), "g"))
+			case dto.MetricType_UNTYPED:
+				lines = append(lines, s.line(name, tags, m.Untyped.GetValue(), "g"))
+			case dto.MetricType_HISTOGRAM:
+				lines = append(lines,
+					s.counter(name+"_count", tags, float64(m.Histogram.GetSampleCount())),
+					s.counter(name+"_sum", tags, m.Histogram.GetSampleSum()))
+			case dto.MetricType_SUMMARY:
+				lines = append(lines,
+					s.counter(name+"_count", tags, float64(m.Summary.GetSampleCount())),
+					s.counter(name+"_sum", tags, m.Summary.GetSampleSum()))
+				for _, q := range m.Summary.Quantile {
+					qname := name + "_q" + strings.ReplaceAll(strconv.FormatFloat(q.GetQuantile(), 'f', -1, 64), ".", "")
+					lines = append(lines, s.line(qname, tags, q.GetValue(), "g"))
+				}
+			}
+		}
+	}
+	return s.send(lines)
+}
+
+// series: メトリクス名とラベルから、statsd のメトリクス名とタグを作成する
+func (s *Statsd) series(name string, labels []*dto.LabelPair) (string, string) {
+	name = s.prefix + name
+	if len(labels) == 0 {
+		return name, ""
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+	if !s.tags {
+		for _, l := range labels {
+			if v := l.GetValue(); v != "" {
+				name += "." + sanitize(v)
+			}
+		}
+		return name, ""
+	}
+	tags := make([]string, 0, len(labels))
+	for _, l := range labels {
+		tags = append(tags, l.GetName()+":"+sanitize(l.GetValue()))
+	}
+	return name, "|#" + strings.Join(tags, ",")
+}
+
+// counter: カウンターの前回の送信からの増分の行を作成する
+// 値が減った場合（プロセスの再起動など）は、現在の値を増分とする。
+func (s *Statsd) counter(name, tags string, v float64) string {
+	key := name + tags
+	delta := v - s.last[key]
+	if delta < 0 {
+		delta = v
+	}
+	s.last[key] = v
+	return s.line(name, tags, delta, "c")
+}
+
+// line: statsd の1行を作成する（名前:値|種類[|#タグ]）
+func (s *Statsd) line(name, tags string, v float64, kind string) string {
+	return name + ":" + strconv.FormatFloat(v, 'f', -1, 64) + "|" + kind + tags
+}
+
+// send: 行をパケットの大きさまでまとめて送信する
+func (s *Statsd) send(lines []string) error {
+	var packet []byte
+	for _, line := range lines {
+		if len(packet) > 0 && len(packet)+1+len(line) > statsdPacketBytes {
+			if _, err := s.conn.Write(packet); err != nil {
+				return err
+			}
+			packet = packet[:0]
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+	if len(packet) == 0 {
+		return nil
+	}
+	_, err := s.conn.Write(packet)
+	return err
+}
+
+// sanitize: statsd の区切り文字（: | , # と空白）を "_" に置き換える
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', ',', '#', ' ', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
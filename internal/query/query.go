@@ -0,0 +1,333 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// Filter: レコードの絞り込み条件
+// 条件は "フィールド 演算子 値" の形式で、AND で区切って複数指定できる（すべての条件を満たすレコードに一致する）。
+// フィールド:
+//   - time: レコードのタイムスタンプ（値は RFC3339 形式の文字列、または unix ミリ秒）
+//   - key: レコードのキー
+//   - header.<名前>: レコードのヘッダー
+//   - value.<パス>: JSON 形式の値のフィールド（パスはドットで区切る、例: value.user.id）
+//
+// 演算子: =, !=, <, <=, >, >=, ^=（前方一致）
+// 例: time >= "2024-01-01T00:00:00Z" AND key ^= "user-" AND header.source = "web" AND value.status = 500
+type Filter struct {
+	conds []cond
+}
+
+// cond: 1つの条件
+type cond struct {
+	field string // time, key, header, value
+	path  string // ヘッダー名（header の場合）
+	keys  []string
+	op    string
+	str   string // 比較する文字列
+	num   int64  // 比較するタイムスタンプ（time の場合）
+	json  any    // 比較する JSON の値（value の場合）
+}
+
+// 各フィールドで使用できる演算子
+var ops = map[string]map[string]bool{
+	"time":   {"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true},
+	"key":    {"=": true, "!=": true, "^=": true},
+	"header": {"=": true, "!=": true, "^=": true},
+	"value":  {"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "^=": true},
+}
+
+// Parse: 絞り込み条件の式を解析する
+// 空の式はすべてのレコードに一致する。
+// 引数:
+//   - expr: 絞り込み条件の式
+//
+// 戻り値:
+//   - *Filter: 解析した絞り込み条件
+//   - error: 式が不正な場合
+func Parse(expr string) (*Filter, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	f := &Filter{}
+	for len(tokens) > 0 {
+		if len(f.conds) > 0 {
+			if !strings.EqualFold(tokens[0].text, "and") || tokens[0].quoted {
+				return nil, fmt.Errorf("expected AND, got %q", tokens[0].text)
+			}
+			tokens = tokens[1:]
+		}
+		if len(tokens) < 3 {
+			return nil, fmt.Errorf("incomplete condition at end of filter")
+		}
+		c, err := parseCond(tokens[0], tokens[1], tokens[2])
+		if err != nil {
+			return nil, err
+		}
+		f.conds = append(f.conds, c)
+		tokens = tokens[3:]
+	}
+	return f, nil
+}
+
+// parseCond: "フィールド 演算子 値" の3つのトークンから条件を作成する
+func parseCond(field, op, value token) (cond, error) {
+	if field.quoted || field.op {
+		return cond{}, fmt.Errorf("expected field, got %q", field.text)
+	}
+	c := cond{field: field.text, op: op.text}
+	if name, path, ok := strings.Cut(field.text, "."); ok {
+		c.field = name
+		switch name {
+		case "header":
+			c.path = path
+		case "value":
+			c.keys = strings.Split(path, ".")
+		default:
+			return cond{}, fmt.Errorf("unknown field %q", field.text)
+		}
+	} else if c.field == "header" || c.field == "value" {
+		return cond{}, fmt.Errorf("field %q requires a name", field.text)
+	}
+	allowed, ok := ops[c.field]
+	if !ok {
+		return cond{}, fmt.Errorf("unknown field %q", field.text)
+	}
+	if !op.op || !allowed[op.text] {
+		return cond{}, fmt.Errorf("invalid operator %q for field %q", op.text, field.text)
+	}
+	if value.op {
+		return cond{}, fmt.Errorf("expected value, got %q", value.text)
+	}
+
+	switch c.field {
+	case "time":
+		if value.quoted {
+			t, err := time.Parse(time.RFC3339Nano, value.text)
+			if err != nil {
+				return cond{}, err
+			}
+			c.num = t.UnixMilli()
+		} else {
+			n, err := strconv.ParseInt(value.text, 10, 64)
+			if err != nil {
+				return cond{}, fmt.Errorf("invalid timestamp %q", value.text)
+			}
+			c.num = n
+		}
+	case "key", "header":
+		c.str = value.text
+	case "value":
+		if value.quoted {
+			c.json = value.text
+		} else if err := json.Unmarshal([]byte(value.text), &c.json); err != nil {
+			return cond{}, fmt.Errorf("invalid value %q", value.text)
+		}
+		if _, ok := c.json.(string); c.op == "^=" && !ok {
+			return cond{}, fmt.Errorf("operator ^= requires a string")
+		}
+	}
+	return c, nil
+}
+
+// Match: レコードが絞り込み条件を満たすかどうかを判定する
+// 引数:
+//   - record: 判定するレコード
+//
+// 戻り値:
+//   - bool: すべての条件を満たす場合 true
+func (f *Filter) Match(record *api.Record) bool {
+	// 値の JSON は最初に必要になったときに一度だけデコードする
+	var (
+		doc     any
+		decoded bool
+		valid   bool
+	)
+	for _, c := range f.conds {
+		switch c.field {
+		case "time":
+			if !compareInt(record.Timestamp, c.op, c.num) {
+				return false
+			}
+		case "key":
+			if !compareString(string(record.Key), c.op, c.str) {
+				return false
+			}
+		case "header":
+			v, ok := record.Headers[c.path]
+			if !ok || !compareString(v, c.op, c.str) {
+				return false
+			}
+		case "value":
+			if !decoded {
+				valid = json.Unmarshal(record.Value, &doc) == nil
+				decoded = true
+			}
+			if !valid {
+				return false
+			}
+			v, ok := lookup(doc, c.keys)
+			if !ok || !compareJSON(v, c.op, c.json) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// lookup: JSON のドキュメントからパスのフィールドを取り出す
+func lookup(doc any, keys []string) (any, bool) {
+	for _, k := range keys {
+		m, ok := doc.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if doc, ok = m[k]; !ok {
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// compareInt: 整数を演算子で比較する
+func compareInt(a int64, op string, b int64) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// compareString: 文字列を演算子で比較する
+func compareString(a, op, b string) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "^=":
+		return strings.HasPrefix(a, b)
+	}
+	return false
+}
+
+// compareJSON: JSON の値を演算子で比較する
+// 大小の比較は、数値同士または文字列同士の場合のみ行う。
+func compareJSON(a any, op string, b any) bool {
+	switch op {
+	case "=":
+		return equalJSON(a, b)
+	case "!=":
+		return !equalJSON(a, b)
+	case "^=":
+		as, ok := a.(string)
+		return ok && strings.HasPrefix(as, b.(string))
+	}
+	switch bv := b.(type) {
+	case float64:
+		if av, ok := a.(float64); ok {
+			return compareFloat(av, op, bv)
+		}
+	case string:
+		if av, ok := a.(string); ok {
+			return compareFloat(float64(strings.Compare(av, bv)), op, 0)
+		}
+	}
+	return false
+}
+
+// equalJSON: JSON のスカラー値が等しいかどうかを判定する（オブジェクトや配列は等しくないものとする）
+func equalJSON(a, b any) bool {
+	switch a.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	return a == b
+}
+
+// compareFloat: 浮動小数点数を大小の演算子で比較する
+func compareFloat(a float64, op string, b float64) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// token: 式を構成するトークン
+type token struct {
+	text   string
+	quoted bool // 引用符で囲まれた文字列かどうか
+	op     bool // 演算子かどうか
+}
+
+// tokenize: 式をトークンに分割する
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		switch ch := expr[i]; {
+		case unicode.IsSpace(rune(ch)):
+			i++
+		case ch == '"':
+			// エスケープされていない閉じ引用符までを文字列とする
+			j := i + 1
+			for ; j < len(expr) && expr[j] != '"'; j++ {
+				if expr[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			s, err := strconv.Unquote(expr[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %w", i, err)
+			}
+			tokens = append(tokens, token{text: s, quoted: true})
+			i = j + 1
+		case strings.ContainsRune("=!<>^", rune(ch)):
+			j := i + 1
+			if j < len(expr) && expr[j] == '=' {
+				j++
+			}
+			op := expr[i:j]
+			if op == "!" || op == "^" {
+				return nil, fmt.Errorf("invalid operator %q at position %d", op, i)
+			}
+			tokens = append(tokens, token{text: op, op: true})
+			i = j
+		default:
+			j := i
+			for j < len(expr) && !unicode.IsSpace(rune(expr[j])) && !strings.ContainsRune("\"=!<>^", rune(expr[j])) {
+				j++
+			}
+			tokens = append(tokens, token{text: expr[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
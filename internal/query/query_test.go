@@ -0,0 +1,65 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC).UnixMilli()
+	record := &api.Record{
+		Key:       []byte("user-42"),
+		Value:     []byte(`{"status": 500, "user": {"name": "alice"}, "ok": false}`),
+		Headers:   map[string]string{"source": "web"},
+		Timestamp: ts,
+	}
+
+	for filter, want := range map[string]bool{
+		``:                                          true,
+		`key = "user-42"`:                           true,
+		`key ^= "user-"`:                            true,
+		`key ^= "order-"`:                           false,
+		`key != "user-42"`:                          false,
+		`header.source = "web"`:                     true,
+		`header.source = web`:                       true,
+		`header.missing = "web"`:                    false,
+		`time >= "2024-01-01T00:00:00Z"`:            true,
+		`time < "2024-01-01T12:00:00Z"`:             false,
+		"time = 1704110400000":                      true,
+		`value.status = 500`:                        true,
+		`value.status >= 400 AND value.ok = false`:  true,
+		`value.status < 500`:                        false,
+		`value.user.name = "alice"`:                 true,
+		`value.user.name ^= "bob"`:                  false,
+		`value.user = "alice"`:                      false,
+		`value.missing = null`:                      false,
+		`key ^= "user-" and header.source != "web"`: false,
+	} {
+		t.Run(filter, func(t *testing.T) {
+			f, err := Parse(filter)
+			require.NoError(t, err)
+			require.Equal(t, want, f.Match(record))
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, filter := range []string{
+		`key`,
+		`key =`,
+		`size = 1`,
+		`key < "a"`,
+		`header = "a"`,
+		`time >= "yesterday"`,
+		`value.status ^= 5`,
+		`key = "a" key = "b"`,
+		`key = "unterminated`,
+		`key ! "a"`,
+	} {
+		_, err := Parse(filter)
+		require.Error(t, err, filter)
+	}
+}
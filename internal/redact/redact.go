@@ -0,0 +1,186 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// DefaultReplacement: 置換後の文字列が指定されていない場合に使用する文字列
+const DefaultReplacement = "[REDACTED]"
+
+// Rule: 1つのマスキングのルール
+// Field には次のいずれかを指定する（query.Filter や export.Column と同じ名前）:
+//   - key: レコードのキー
+//   - value: レコードの値
+//   - header.<名前>: レコードのヘッダー
+//   - value.<パス>: JSON 形式の値のフィールド（パスはドットで区切り、* は任意のキーまたは配列のすべての要素に一致する）
+//
+// Pattern を指定した場合は一致した部分だけを Replace で置き換え（Replace では $1 などで部分一致を参照できる）、
+// 指定しない場合はフィールド全体を置き換える。
+type Rule struct {
+	Field   string `json:"field"`
+	Pattern string `json:"pattern,omitempty"`
+	Replace string `json:"replace,omitempty"` // 空の場合は DefaultReplacement
+}
+
+// rule: コンパイル済みのルール（内部型）
+type rule struct {
+	field   string   // key, value, header, json
+	header  string   // ヘッダー名（header の場合）
+	path    []string // JSON のパス（json の場合）
+	re      *regexp.Regexp
+	replace string
+}
+
+// Redactor: レコードのキー、値、ヘッダーに含まれる個人情報などをマスキングする
+// 書き込む前（永続化する前）やエクスポートする前（クラスタの外に出る前）にレコードに適用する。
+type Redactor struct {
+	rules []rule
+}
+
+// New: ルールをコンパイルして Redactor を作成する
+// 引数:
+//   - rules: 適用するルール（指定した順に適用する）
+//
+// 戻り値:
+//   - *Redactor: 作成した Redactor
+//   - error: フィールドまたは正規表現が不正な場合
+func New(rules []Rule) (*Redactor, error) {
+	r := &Redactor{}
+	for _, spec := range rules {
+		c := rule{replace: spec.Replace}
+		if c.replace == "" {
+			c.replace = DefaultReplacement
+		}
+		switch {
+		case spec.Field == "key" || spec.Field == "value":
+			c.field = spec.Field
+		case strings.HasPrefix(spec.Field, "header.") && len(spec.Field) > len("header."):
+			c.field, c.header = "header", strings.TrimPrefix(spec.Field, "header.")
+		case strings.HasPrefix(spec.Field, "value.") && len(spec.Field) > len("value."):
+			c.field, c.path = "json", strings.Split(strings.TrimPrefix(spec.Field, "value."), ".")
+		default:
+			return nil, fmt.Errorf("unknown redaction field: %q", spec.Field)
+		}
+		if spec.Pattern != "" {
+			re, err := regexp.Compile(spec.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("redaction pattern for %s: %w", spec.Field, err)
+			}
+			c.re = re
+		}
+		r.rules = append(r.rules, c)
+	}
+	return r, nil
+}
+
+// ParseRules: JSON の配列で書かれたルールを読み取って Redactor を作成する
+// 例: [{"field": "value.user.email"}, {"field": "value", "pattern": "\\d{4}-\\d{4}-\\d{4}-\\d{4}"}]
+func ParseRules(r io.Reader) (*Redactor, error) {
+	var rules []Rule
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("redaction rules: %w", err)
+	}
+	return New(rules)
+}
+
+// Apply: レコードにすべてのルールを適用する（レコードを書き換える）
+// 値が JSON でない場合、JSON のパスを指定したルールは適用しない。
+// JSON のパスを指定したルールを適用した値は、オブジェクトのキーの順序が変わる場合がある。
+func (r *Redactor) Apply(record *api.Record) {
+	for _, c := range r.rules {
+		switch c.field {
+		case "key":
+			record.Key = c.bytes(record.Key)
+		case "value":
+			record.Value = c.bytes(record.Value)
+		case "header":
+			if v, ok := record.Headers[c.header]; ok {
+				record.Headers[c.header] = c.string(v)
+			}
+		case "json":
+			record.Value = c.json(record.Value)
+		}
+	}
+}
+
+// bytes: バイト列の一致した部分（パターンがない場合は全体）を置き換える（内部関数）
+func (c rule) bytes(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+	if c.re == nil {
+		return []byte(c.replace)
+	}
+	return c.re.ReplaceAll(b, []byte(c.replace))
+}
+
+// string: 文字列の一致した部分（パターンがない場合は全体）を置き換える（内部関数）
+func (c rule) string(s string) string {
+	if c.re == nil {
+		return c.replace
+	}
+	return c.re.ReplaceAllString(s, c.replace)
+}
+
+// json: JSON の値のパスにあるフィールドを置き換える（内部関数）
+// パスに一致するフィールドがない場合や JSON でない場合は、元の値をそのまま返す。
+func (c rule) json(b []byte) []byte {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return b
+	}
+	v, changed := c.walk(v, c.path)
+	if !changed {
+		return b
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return b
+	}
+	return out
+}
+
+// walk: パスをたどって末尾の値を置き換える（内部関数）
+// パターンを指定したルールは文字列の値だけを置き換え、指定しないルールは値の型にかかわらず文字列で置き換える。
+func (c rule) walk(v any, path []string) (any, bool) {
+	if len(path) == 0 {
+		if s, ok := v.(string); ok {
+			return c.string(s), true
+		}
+		if c.re == nil {
+			return c.replace, true
+		}
+		return v, false
+	}
+	changed := false
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if path[0] == "*" || path[0] == k {
+				var ok bool
+				if t[k], ok = c.walk(child, path[1:]); ok {
+					changed = true
+				}
+			}
+		}
+	case []any:
+		if path[0] == "*" {
+			for i, child := range t {
+				var ok bool
+				if t[i], ok = c.walk(child, path[1:]); ok {
+					changed = true
+				}
+			}
+		}
+	}
+	return v, changed
+}
@@ -0,0 +1,71 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor(t *testing.T) {
+	for scenario, tc := range map[string]struct {
+		rules []Rule
+		in    *api.Record
+		want  *api.Record
+	}{
+		"whole key": {
+			rules: []Rule{{Field: "key"}},
+			in:    &api.Record{Key: []byte("alice@example.com"), Value: []byte("v")},
+			want:  &api.Record{Key: []byte(DefaultReplacement), Value: []byte("v")},
+		},
+		"value pattern with group": {
+			rules: []Rule{{Field: "value", Pattern: `(\d{4})-\d{4}-\d{4}-(\d{4})`, Replace: "$1-****-****-$2"}},
+			in:    &api.Record{Value: []byte("card 1234-5678-9012-3456 ok")},
+			want:  &api.Record{Value: []byte("card 1234-****-****-3456 ok")},
+		},
+		"header": {
+			rules: []Rule{{Field: "header.authorization"}, {Field: "header.missing"}},
+			in:    &api.Record{Value: []byte("v"), Headers: map[string]string{"authorization": "Bearer x", "source": "web"}},
+			want:  &api.Record{Value: []byte("v"), Headers: map[string]string{"authorization": DefaultReplacement, "source": "web"}},
+		},
+		"json path": {
+			rules: []Rule{{Field: "value.user.email", Replace: "***"}, {Field: "value.user.age"}},
+			in:    &api.Record{Value: []byte(`{"user":{"email":"a@b.c","age":42,"id":7}}`)},
+			want:  &api.Record{Value: []byte(`{"user":{"age":"[REDACTED]","email":"***","id":7}}`)},
+		},
+		"json path wildcard with pattern": {
+			rules: []Rule{{Field: "value.items.*.phone", Pattern: `\d(\d{2})$`, Replace: "X$1"}},
+			in:    &api.Record{Value: []byte(`{"items":[{"phone":"555123"},{"phone":9},{"name":"x"}]}`)},
+			want:  &api.Record{Value: []byte(`{"items":[{"phone":"555X23"},{"phone":9},{"name":"x"}]}`)},
+		},
+		"json path on non-json value": {
+			rules: []Rule{{Field: "value.user"}},
+			in:    &api.Record{Value: []byte("plain text")},
+			want:  &api.Record{Value: []byte("plain text")},
+		},
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			r, err := New(tc.rules)
+			require.NoError(t, err)
+			r.Apply(tc.in)
+			require.Equal(t, tc.want, tc.in)
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	r, err := ParseRules(strings.NewReader(`[{"field": "value.email"}, {"field": "key", "pattern": "\\d+"}]`))
+	require.NoError(t, err)
+	require.Len(t, r.rules, 2)
+
+	for _, spec := range []string{
+		`[{"field": "size"}]`,
+		`[{"field": "header."}]`,
+		`[{"field": "value", "pattern": "("}]`,
+		`{"field": "key"}`,
+	} {
+		_, err = ParseRules(strings.NewReader(spec))
+		require.Error(t, err, spec)
+	}
+}
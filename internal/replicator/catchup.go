@@ -0,0 +1,97 @@
+package replicator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	replpb "github.com/kentakki416/proglog/api/replication/v1"
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// castagnoli: セグメントのチャンクのチェックサムに使用する CRC32 テーブル
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// CatchUp: リーダーから書き込み済みセグメントを取得して、ローカルのログを追いつかせる
+// レコードを1件ずつ再生する代わりに、セグメントファイル（ストア + インデックス）を
+// そのまま受信してインストールするため、大きなログでもノードの起動が速い。
+// アクティブセグメントは転送されないため、残りのレコードは通常の複製（ReplicateEntries）で取得する必要がある
+// （ACL のルールを記録する acl.DefaultTopic のように小さなトピックは、アクティブセグメントにすべてのレコードが残る）。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - client: リーダーの複製の API のクライアント
+//   - topic: 追いつかせるトピック（空文字の場合はリーダーのデフォルトのログストア）
+//   - l: 追いつかせるローカルのログ
+//
+// 戻り値:
+//   - uint64: インストールしたセグメントの数
+//   - error: エラーが発生した場合（チェックサムの不一致など）
+func CatchUp(ctx context.Context, client replpb.ReplicationClient, topic string, l *log.Log) (uint64, error) {
+	// ローカルのログの次のオフセット（空のログの場合は開始オフセット）を含むセグメントから取得する
+	stream, err := client.FetchSegment(ctx, &replpb.FetchSegmentRequest{Topic: topic, FromOffset: l.NextOffset()})
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		installed  uint64
+		baseOffset uint64
+		format     int
+		store      bytes.Buffer
+		index      bytes.Buffer
+	)
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return installed, nil
+		}
+		if err != nil {
+			return installed, err
+		}
+
+		// 転送中の破損を検出する
+		if crc32.Checksum(chunk.Data, castagnoli) != chunk.Checksum {
+			return installed, fmt.Errorf(
+				"checksum mismatch in segment %d at position %d",
+				chunk.BaseOffset,
+				chunk.Position,
+			)
+		}
+
+		// 新しいセグメントの最初のチャンク
+		if chunk.File == api.SegmentChunk_STORE && chunk.Position == 0 {
+			baseOffset = chunk.BaseOffset
+			format = int(chunk.Format)
+			store.Reset()
+			index.Reset()
+		}
+
+		buf := &store
+		if chunk.File == api.SegmentChunk_INDEX {
+			buf = &index
+		}
+		if chunk.BaseOffset != baseOffset || chunk.Position != uint64(buf.Len()) {
+			return installed, fmt.Errorf(
+				"unexpected chunk for segment %d at position %d",
+				chunk.BaseOffset,
+				chunk.Position,
+			)
+		}
+		buf.Write(chunk.Data)
+
+		// インデックスの最後のチャンクを受信したら、セグメントをインストールする
+		if chunk.File == api.SegmentChunk_INDEX && chunk.Last {
+			// 既にローカルにあるセグメントはスキップする
+			if baseOffset < l.NextOffset() {
+				continue
+			}
+			if err = l.InstallSegment(baseOffset, format, &store, &index); err != nil {
+				return installed, err
+			}
+			installed++
+		}
+	}
+}
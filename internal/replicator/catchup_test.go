@@ -0,0 +1,137 @@
+package replicator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	replpb "github.com/kentakki416/proglog/api/replication/v1"
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/config"
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/kentakki416/proglog/internal/server"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestCatchUp(t *testing.T) {
+	c := log.Config{}
+	c.Segment.MaxStoreBytes = 32
+
+	// リーダー: 複数のセグメントにまたがるレコードを持つログ
+	leaderDir, err := os.MkdirTemp("", "catchup-leader")
+	require.NoError(t, err)
+	defer os.RemoveAll(leaderDir)
+	leader, err := log.NewLog(leaderDir, c)
+	require.NoError(t, err)
+	for i := 0; i < 7; i++ {
+		_, err = leader.Append(&api.Record{Value: []byte("hello world")})
+		require.NoError(t, err)
+	}
+
+	// 複製の API は Peers のノードだけが呼び出せるため、Unix ドメインソケットの接続をフォロワーのノードとして認証する
+	socket := filepath.Join(t.TempDir(), "leader.sock")
+	listeners, err := server.Listen("unix:" + socket)
+	require.NoError(t, err)
+	// リーダーのトピック: デフォルトのログストアとは別のレコードを持つ
+	topics, err := log.NewTopics(t.TempDir(), c)
+	require.NoError(t, err)
+	defer topics.Close()
+	orders, err := topics.Create("orders")
+	require.NoError(t, err)
+	for i := 0; i < 7; i++ {
+		_, err = orders.Append(&api.Record{Value: []byte("hello orders")})
+		require.NoError(t, err)
+	}
+
+	config := &server.Config{
+		CommitLog: leader,
+		Topics:    topics,
+		Credentials: config.CredentialsFunc(func() (credentials.TransportCredentials, error) {
+			return server.UnixSocketCredentials(nil, "follower"), nil
+		}),
+	}
+	config.Replication.Enabled = true
+	config.Replication.Peers = []string{"follower"}
+	config.Replication.TermsFile = filepath.Join(t.TempDir(), "terms.json")
+	srv, err := server.NewGRPCServer(config)
+	require.NoError(t, err)
+	go server.Serve(srv, listeners)
+	defer srv.Stop()
+
+	cc, err := grpc.NewClient("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer cc.Close()
+
+	// フォロワー: 空のログ
+	followerDir, err := os.MkdirTemp("", "catchup-follower")
+	require.NoError(t, err)
+	defer os.RemoveAll(followerDir)
+	follower, err := log.NewLog(followerDir, c)
+	require.NoError(t, err)
+
+	n, err := CatchUp(context.Background(), replpb.NewReplicationClient(cc), "", follower)
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(leader.SealedSegments(0))), n)
+
+	// 書き込み済みセグメントのレコードはすべてフォロワーでも読み取れる
+	sealed := leader.SealedSegments(0)
+	for off := uint64(0); off < sealed[len(sealed)-1].NextOffset; off++ {
+		want, err := leader.Read(off)
+		require.NoError(t, err)
+		got, err := follower.Read(off)
+		require.NoError(t, err)
+		require.Equal(t, want.Value, got.Value)
+		require.Equal(t, want.Offset, got.Offset)
+	}
+
+	// 追いついた後に再度実行しても、インストールするセグメントはない
+	n, err = CatchUp(context.Background(), replpb.NewReplicationClient(cc), "", follower)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), n)
+	require.NoError(t, follower.Close())
+
+	// 0 以外のオフセットから始まる空のログは、その開始オフセットのセグメントから追いつく
+	// （直前のオフセットから始まるセグメントをインストールしないように、1件ずつのセグメントを作る）
+	for i := 0; i < 4; i++ {
+		_, err = leader.Append(&api.Record{Value: bytes.Repeat([]byte("x"), 32)})
+		require.NoError(t, err)
+	}
+	sealed = leader.SealedSegments(0)
+	start := sealed[len(sealed)-1].BaseOffset
+	require.Equal(t, start-1, sealed[len(sealed)-2].BaseOffset)
+	resetDir, err := os.MkdirTemp("", "catchup-reset")
+	require.NoError(t, err)
+	defer os.RemoveAll(resetDir)
+	reset, err := log.NewLog(resetDir, c)
+	require.NoError(t, err)
+	require.NoError(t, reset.ResetAt(start))
+	n, err = CatchUp(context.Background(), replpb.NewReplicationClient(cc), "", reset)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), n)
+	require.Equal(t, sealed[len(sealed)-1].NextOffset, reset.NextOffset())
+	require.NoError(t, reset.Close())
+
+	// トピックを指定すると、そのトピックのセグメントから追いつく
+	topicDir, err := os.MkdirTemp("", "catchup-topic")
+	require.NoError(t, err)
+	defer os.RemoveAll(topicDir)
+	topicFollower, err := log.NewLog(topicDir, c)
+	require.NoError(t, err)
+	defer topicFollower.Close()
+	sealed = orders.SealedSegments(0)
+	for _, s := range sealed {
+		s.Close()
+	}
+	n, err = CatchUp(context.Background(), replpb.NewReplicationClient(cc), "orders", topicFollower)
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(sealed)), n)
+	require.Equal(t, sealed[len(sealed)-1].NextOffset, topicFollower.NextOffset())
+	record, err := topicFollower.Read(0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello orders"), record.Value)
+}
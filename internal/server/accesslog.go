@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// AccessLogEntry: アクセスログの1つの RPC のエントリ
+// Writer には JSON Lines 形式で、トピックには JSON をレコードの値として書き込む。
+type AccessLogEntry struct {
+	Time     time.Time `json:"time"`
+	Method   string    `json:"method"`             // 例: /log.v1.Log/Produce
+	Identity string    `json:"identity,omitempty"` // クライアント証明書のサブジェクト（TLS を使用していない場合は空）
+	Topic    string    `json:"topic,omitempty"`    // 空の場合はデフォルトのログストア
+	// RPC で書き込んだ、または読み取ったレコードのオフセットの範囲（オフセットを含まない RPC では省略する）
+	FirstOffset *uint64 `json:"first_offset,omitempty"`
+	LastOffset  *uint64 `json:"last_offset,omitempty"`
+	Messages    int     `json:"messages"`  // 送受信したメッセージの数（単一リクエストの RPC では成功した場合 2）
+	BytesIn     int     `json:"bytes_in"`  // 受信したメッセージのシリアライズ後のバイト数
+	BytesOut    int     `json:"bytes_out"` // 送信したメッセージのシリアライズ後のバイト数
+	LatencyMs   float64 `json:"latency_ms"`
+	Code        string  `json:"code"` // gRPC のステータスコード（例: OK、NotFound）
+}
+
+// observe: 送受信したメッセージをエントリに反映する（内部関数）
+// トピックとオフセットは、生成されたメッセージの Get メソッドから取り出す。
+func (e *AccessLogEntry) observe(msg any, in bool) {
+	e.Messages++
+	if m, ok := msg.(proto.Message); ok {
+		if in {
+			e.BytesIn += proto.Size(m)
+		} else {
+			e.BytesOut += proto.Size(m)
+		}
+	}
+	if m, ok := msg.(interface{ GetTopic() string }); ok && e.Topic == "" {
+		e.Topic = m.GetTopic()
+	}
+	switch m := msg.(type) {
+	case *api.ConsumeResponse:
+		// 複数のレコードを返す場合、records に record も含まれる
+		records := m.GetRecords()
+		if len(records) == 0 && m.GetRecord() != nil {
+			records = []*api.Record{m.GetRecord()}
+		}
+		for _, record := range records {
+			e.observeOffset(record.GetOffset())
+		}
+	case interface{ GetOffset() uint64 }:
+		e.observeOffset(m.GetOffset())
+	}
+}
+
+// observeOffset: オフセットの範囲を広げる（内部関数）
+func (e *AccessLogEntry) observeOffset(off uint64) {
+	if e.FirstOffset == nil || off < *e.FirstOffset {
+		e.FirstOffset = &off
+	}
+	if e.LastOffset == nil || off > *e.LastOffset {
+		e.LastOffset = &off
+	}
+}
+
+// accessLogger: RPC ごとのアクセスログを記録する（内部型）
+type accessLogger struct {
+	*Config
+	mu sync.Mutex // Writer への書き込みを直列化する
+}
+
+// accessLogInterceptors: アクセスログを記録するインターセプターを作成する
+// 出力先（Writer と Topic）が設定されていない場合は nil を返す。
+// サブジェクトを記録するため、認証のインターセプターの後に追加する必要がある。
+func (c *Config) accessLogInterceptors() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	if c.AccessLog.Writer == nil && c.AccessLog.Topic == "" {
+		return nil, nil
+	}
+	a := &accessLogger{Config: c}
+	return a.unary, a.stream
+}
+
+// unary: 単一リクエストの RPC 用のアクセスログのインターセプター
+func (a *accessLogger) unary(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	start := a.clock().Now()
+	res, err := handler(ctx, req)
+
+	e := &AccessLogEntry{Method: info.FullMethod}
+	e.observe(req, true)
+	if err == nil {
+		e.observe(res, false)
+	}
+	a.record(ctx, e, start, err)
+	return res, err
+}
+
+// stream: ストリーミング RPC 用のアクセスログのインターセプター
+func (a *accessLogger) stream(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	start := a.clock().Now()
+	s := &accessLogStream{ServerStream: ss, entry: &AccessLogEntry{Method: info.FullMethod}}
+	err := handler(srv, s)
+	a.record(ss.Context(), s.entry, start, err)
+	return err
+}
+
+// record: サンプリングの対象であればエントリを出力先に書き込む
+// 失敗した RPC は割合にかかわらず常に記録する。書き込みに失敗しても RPC は失敗させない。
+// アクセスログのトピック（AccessLog.Topic）に対する RPC は、アクセスログを読み取るたびにエントリが増え続けないように記録しない。
+func (a *accessLogger) record(ctx context.Context, e *AccessLogEntry, start time.Time, err error) {
+	if a.AccessLog.Topic != "" && e.Topic == a.AccessLog.Topic {
+		return
+	}
+	rate := a.AccessLog.SampleRate
+	if err == nil && rate > 0 && rate < 1 && rand.Float64() >= rate {
+		return
+	}
+
+	now := a.clock().Now()
+	e.Time = start
+	e.Identity = subject(ctx)
+	e.LatencyMs = float64(now.Sub(start)) / float64(time.Millisecond)
+	e.Code = status.Code(err).String()
+	b, jerr := json.Marshal(e)
+	if jerr != nil {
+		return
+	}
+
+	if w := a.AccessLog.Writer; w != nil {
+		a.mu.Lock()
+		_, _ = w.Write(append(b, '\n'))
+		a.mu.Unlock()
+	}
+	if a.AccessLog.Topic != "" && a.Topics != nil {
+		if l, err := a.Topics.Acquire(a.AccessLog.Topic); err == nil {
+			_, _ = l.Append(&api.Record{Value: b, Timestamp: now.UnixMilli()})
+			a.Topics.Release(a.AccessLog.Topic)
+		}
+	}
+}
+
+// accessLogStream: 送受信したメッセージをアクセスログのエントリに反映するサーバーストリーム
+type accessLogStream struct {
+	grpc.ServerStream
+	entry *AccessLogEntry // ハンドラーのゴルーチンからのみ更新する
+}
+
+// SendMsg: 送信に成功したメッセージをエントリに反映する
+func (s *accessLogStream) SendMsg(m any) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.entry.observe(m, false)
+	return nil
+}
+
+// RecvMsg: 受信したメッセージをエントリに反映する
+func (s *accessLogStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.entry.observe(m, true)
+	return nil
+}
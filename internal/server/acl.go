@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	adminpb "github.com/kentakki416/proglog/api/admin/v1"
+	"github.com/kentakki416/proglog/internal/acl"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errACLDisabled: ACL のポリシーが設定されていないサーバーで ACL を操作した場合のエラー
+var errACLDisabled = status.Error(codes.FailedPrecondition, "ACL management is not enabled on this server")
+
+// ListACLs: ACL のすべてのルールを返す
+func (a *adminServer) ListACLs(ctx context.Context, req *adminpb.ListACLsRequest) (*adminpb.ListACLsResponse, error) {
+	if err := a.srv.authorize(a.admin(ctx), objectWildcard, listACLsAction); err != nil {
+		return nil, err
+	}
+	if a.srv.ACL == nil {
+		return nil, errACLDisabled
+	}
+	res := &adminpb.ListACLsResponse{}
+	for _, r := range a.srv.ACL.Rules() {
+		res.Rules = append(res.Rules, &adminpb.ACLRule{Subject: r.Subject, Object: r.Object, Action: r.Action})
+	}
+	return res, nil
+}
+
+// AddACL: ACL のルールを追加する
+// 内部のトピックに記録してから返すため、再起動しても失われず、転送したセグメントでほかのノードにも反映される。
+// 戻り値:
+//   - error: ルールの項目が指定されていない場合は codes.InvalidArgument
+func (a *adminServer) AddACL(ctx context.Context, req *adminpb.AddACLRequest) (*adminpb.AddACLResponse, error) {
+	if err := a.srv.authorize(a.admin(ctx), objectWildcard, addACLAction); err != nil {
+		return nil, err
+	}
+	if a.srv.ACL == nil {
+		return nil, errACLDisabled
+	}
+	if err := a.srv.ACL.Add(aclRule(req.Rule)); errors.Is(err, acl.ErrInvalidRule) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if err != nil {
+		return nil, err
+	}
+	return &adminpb.AddACLResponse{}, nil
+}
+
+// RemoveACL: ACL のルールを削除する
+// 戻り値:
+//   - error: ルールが存在しない場合は codes.NotFound
+func (a *adminServer) RemoveACL(ctx context.Context, req *adminpb.RemoveACLRequest) (*adminpb.RemoveACLResponse, error) {
+	if err := a.srv.authorize(a.admin(ctx), objectWildcard, removeACLAction); err != nil {
+		return nil, err
+	}
+	if a.srv.ACL == nil {
+		return nil, errACLDisabled
+	}
+	if err := a.srv.ACL.Remove(aclRule(req.Rule)); errors.Is(err, acl.ErrRuleNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, err
+	}
+	return &adminpb.RemoveACLResponse{}, nil
+}
+
+// aclRule: リクエストのルールを acl.Rule に変換する（内部関数）
+func aclRule(r *adminpb.ACLRule) acl.Rule {
+	return acl.Rule{Subject: r.GetSubject(), Object: r.GetObject(), Action: r.GetAction()}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+
+	adminpb "github.com/kentakki416/proglog/api/admin/v1"
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// AdminAPIVersion: 管理 API（admin.v1.Admin）のバージョン
+// データの読み書きの API（MaxAPIVersion）とは独立して、管理 API に互換性のない変更をしたときに上げる。
+const AdminAPIVersion = 1
+
+// adminActionPrefix: 管理 API で認可するときにアクションの名前に付ける接頭辞
+// 例えば管理 API の CreateTopic は "admin:create_topic" で認可するため、
+// Log サービスの互換用の RPC の権限とは別に管理 API の権限を付与できる。
+const adminActionPrefix = "admin:"
+
+// adminContextKey: 管理 API の呼び出しであることをコンテキストに記録するためのキー
+type adminContextKey struct{}
+
+// adminAction: 管理 API の呼び出しであれば、アクションの名前に接頭辞を付ける
+func adminAction(ctx context.Context, action string) string {
+	if ctx.Value(adminContextKey{}) != nil {
+		return adminActionPrefix + action
+	}
+	return action
+}
+
+// adminServer: 管理 API（admin.v1.Admin）の実装
+// 処理は Log サービスの同じ名前の RPC と共有し、認可するアクションだけを管理 API 用に切り替える。
+type adminServer struct {
+	adminpb.UnimplementedAdminServer
+	srv *grpcServer
+}
+
+// admin: コンテキストに管理 API の呼び出しであることを記録する
+func (a *adminServer) admin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, adminContextKey{}, true)
+}
+
+// CreateTopic: トピックを作成する
+func (a *adminServer) CreateTopic(ctx context.Context, req *api.CreateTopicRequest) (*api.CreateTopicResponse, error) {
+	return a.srv.CreateTopic(a.admin(ctx), req)
+}
+
+// DeleteTopic: トピックを削除する
+func (a *adminServer) DeleteTopic(ctx context.Context, req *api.DeleteTopicRequest) (*api.DeleteTopicResponse, error) {
+	return a.srv.DeleteTopic(a.admin(ctx), req)
+}
+
+// UndeleteTopic: 削除したトピックを復元する
+func (a *adminServer) UndeleteTopic(ctx context.Context, req *api.UndeleteTopicRequest) (*api.UndeleteTopicResponse, error) {
+	return a.srv.UndeleteTopic(a.admin(ctx), req)
+}
+
+// ListTopics: トピックの一覧を返す
+func (a *adminServer) ListTopics(ctx context.Context, req *api.ListTopicsRequest) (*api.ListTopicsResponse, error) {
+	return a.srv.ListTopics(a.admin(ctx), req)
+}
+
+// TruncateLog: 古いレコードを削除する
+func (a *adminServer) TruncateLog(ctx context.Context, req *api.TruncateLogRequest) (*api.TruncateLogResponse, error) {
+	return a.srv.TruncateLog(a.admin(ctx), req)
+}
+
+// FlushLog: ログをディスクに同期する
+func (a *adminServer) FlushLog(ctx context.Context, req *api.FlushLogRequest) (*api.FlushLogResponse, error) {
+	return a.srv.FlushLog(a.admin(ctx), req)
+}
+
+// GetLogInfo: セグメントの統計情報を返す
+func (a *adminServer) GetLogInfo(ctx context.Context, req *api.GetLogInfoRequest) (*api.GetLogInfoResponse, error) {
+	return a.srv.GetLogInfo(a.admin(ctx), req)
+}
+
+// ExportOffsets: コミット済みオフセットをエクスポートする
+func (a *adminServer) ExportOffsets(ctx context.Context, req *api.ExportOffsetsRequest) (*api.ExportOffsetsResponse, error) {
+	return a.srv.ExportOffsets(a.admin(ctx), req)
+}
+
+// ImportOffsets: コミット済みオフセットをインポートする
+func (a *adminServer) ImportOffsets(ctx context.Context, req *api.ImportOffsetsRequest) (*api.ImportOffsetsResponse, error) {
+	return a.srv.ImportOffsets(a.admin(ctx), req)
+}
+
+// StartExport: ログの範囲のファイルへのエクスポートを開始する
+func (a *adminServer) StartExport(ctx context.Context, req *api.StartExportRequest) (*api.StartExportResponse, error) {
+	return a.srv.StartExport(a.admin(ctx), req)
+}
+
+// GetExport: エクスポートのジョブの状態を返す
+func (a *adminServer) GetExport(ctx context.Context, req *api.GetExportRequest) (*api.ExportJob, error) {
+	return a.srv.GetExport(a.admin(ctx), req)
+}
+
+// GetConsumerLag: コンシューマーの遅延を返す
+func (a *adminServer) GetConsumerLag(ctx context.Context, req *api.GetConsumerLagRequest) (*api.GetConsumerLagResponse, error) {
+	return a.srv.GetConsumerLag(a.admin(ctx), req)
+}
+
+// GetUsage: クライアントごとの使用量を返す
+func (a *adminServer) GetUsage(ctx context.Context, req *api.GetUsageRequest) (*api.GetUsageResponse, error) {
+	return a.srv.GetUsage(a.admin(ctx), req)
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultAuthCacheEntries: AuthCacheConfig.MaxEntries が 0 の場合にキャッシュする判定の数
+const DefaultAuthCacheEntries = 10000
+
+// AuthCacheConfig: 認可の判定のキャッシュの設定
+type AuthCacheConfig struct {
+	// 判定をキャッシュする時間（ポリシーを変更してから、キャッシュした判定が使われなくなるまでの最大の時間）
+	TTL time.Duration
+	// キャッシュする判定の数の上限（0 の場合は DefaultAuthCacheEntries）
+	// 上限に達した場合は、期限切れの判定を捨て、それでも上限に達している場合はすべて捨てる。
+	MaxEntries int
+	// 判定の期限に使用する時計（nil の場合は log.SystemClock）
+	Clock log.Clock
+}
+
+// CachingAuthorizer: 認可の判定を (サブジェクト, オブジェクト, アクション) ごとにキャッシュする Authorizer
+// 書き込みや読み取りのリクエストごとの認可が、高いスループットでポリシーの評価のボトルネックにならないようにする。
+// 許可と拒否の両方をキャッシュする。ただし、拒否（codes.PermissionDenied）以外の gRPC のステータスのエラー
+// （ポリシーを評価するサービスの Unavailable など）は一時的な失敗のためキャッシュしない。
+// ポリシーを再読み込みしたら Invalidate を呼び出して、古い判定を捨てる
+// （Config.ACL のポリシーの変更では、NewGRPCServer が登録するため呼び出す必要はない）。
+type CachingAuthorizer struct {
+	authorizer Authorizer
+	config     AuthCacheConfig
+
+	mu         sync.Mutex
+	entries    map[authCacheKey]authCacheEntry
+	generation uint64 // Invalidate のたびに進める（Invalidate の前に始めた判定をキャッシュしないため）
+}
+
+// authCacheKey: キャッシュする判定のキー
+type authCacheKey struct {
+	subject, object, action string
+}
+
+// authCacheEntry: キャッシュした判定
+type authCacheEntry struct {
+	err     error     // 判定の結果（許可の場合は nil）
+	expires time.Time // この時刻以降は判定を使わない
+}
+
+// NewCachingAuthorizer: 判定をキャッシュする Authorizer を作成する
+// 引数:
+//   - a: 判定を行う Authorizer
+//   - c: キャッシュの設定
+//
+// 戻り値:
+//   - *CachingAuthorizer: Config.Authorizer に設定する Authorizer
+func NewCachingAuthorizer(a Authorizer, c AuthCacheConfig) *CachingAuthorizer {
+	if c.MaxEntries == 0 {
+		c.MaxEntries = DefaultAuthCacheEntries
+	}
+	if c.Clock == nil {
+		c.Clock = log.SystemClock
+	}
+	return &CachingAuthorizer{
+		authorizer: a,
+		config:     c,
+		entries:    make(map[authCacheKey]authCacheEntry),
+	}
+}
+
+// Authorize: キャッシュした判定があればそれを返し、なければ判定してキャッシュする
+func (a *CachingAuthorizer) Authorize(subject, object, action string) error {
+	key := authCacheKey{subject: subject, object: object, action: action}
+	now := a.config.Clock.Now()
+	a.mu.Lock()
+	e, ok := a.entries[key]
+	generation := a.generation
+	a.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.err
+	}
+
+	err := a.authorizer.Authorize(subject, object, action)
+	if st, ok := status.FromError(err); err != nil && ok && st.Code() != codes.PermissionDenied {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.generation != generation {
+		// 判定の途中でポリシーが再読み込みされた: 古いポリシーの判定かもしれないためキャッシュしない
+		return err
+	}
+	if len(a.entries) >= a.config.MaxEntries {
+		a.evict(now)
+	}
+	a.entries[key] = authCacheEntry{err: err, expires: now.Add(a.config.TTL)}
+	return err
+}
+
+// evict: 期限切れの判定を捨て、それでも上限に達している場合はすべて捨てる（内部関数）
+// 呼び出し側で a.mu のロックを取得しておく必要がある。
+func (a *CachingAuthorizer) evict(now time.Time) {
+	for key, e := range a.entries {
+		if !now.Before(e.expires) {
+			delete(a.entries, key)
+		}
+	}
+	if len(a.entries) >= a.config.MaxEntries {
+		clear(a.entries)
+	}
+}
+
+// Invalidate: キャッシュしたすべての判定を捨てる
+// ポリシーを再読み込みしたときに呼び出し、変更前の判定が TTL の間使われ続けないようにする。
+func (a *CachingAuthorizer) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	clear(a.entries)
+	a.generation++
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/compression"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// API のバージョン
+// 互換性のない変更（フィールドの意味の変更など）をしたときに MaxAPIVersion を上げ、
+// 古いクライアントをサポートしなくなったときに MinAPIVersion を上げる。
+const (
+	MinAPIVersion = 1
+	MaxAPIVersion = 1
+)
+
+// GetCapabilities で返す、サーバーの設定によって有効になる機能の名前
+const (
+	FeatureTopics          = "topics"           // トピックの作成とトピックを指定した読み書き
+	FeatureConsumerOffsets = "consumer_offsets" // コンシューマーグループのオフセットのコミット
+	FeatureSessions        = "sessions"         // クライアントのセッション（ハートビート）
+	FeatureKeyValueView    = "key_value_view"   // GetValue によるキーごとの最新の値の参照
+	FeatureExport          = "export"           // StartExport によるファイルへのエクスポート
+	FeatureUsage           = "usage"            // GetUsage による使用量の取得
+	FeatureUpcast          = "upcast"           // Consume での古いスキーマのレコードの書き換え
+	FeatureReceipts        = "receipts"         // Produce の署名付きの受領証
+	FeatureTreeHeads       = "tree_heads"       // GetTreeHead による署名付きのツリーヘッドの取得
+	FeatureDelayedRecords  = "delayed_records"  // deliver_after による配信時刻の指定と、ConsumeStream での配信時刻後の送信
+	FeatureRelativeOffsets = "relative_offsets" // ConsumeRequest.origin による末尾や先頭からの相対的なオフセットの指定
+	FeatureACLManagement   = "acl_management"   // 管理 API による ACL のルールの変更
+)
+
+// GetCapabilities: サーバーが対応している API のバージョンと機能を返す
+// バージョンの異なるクライアントとサーバーが、使用する機能を事前に決められるようにする
+// （対応していない RPC を呼び出して Unimplemented などで失敗する前に判断できる）。
+// 接続を確立した直後に呼び出すことを想定しているため、認可は行わない。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: クライアントが対応している最新の API のバージョンを含むリクエスト
+//
+// 戻り値:
+//   - *api.GetCapabilitiesResponse: 使用する API のバージョン、有効な機能、圧縮方式、レコードの最大バイト数
+//   - error: クライアントのバージョンが MinAPIVersion より古い場合は codes.FailedPrecondition
+func (s *grpcServer) GetCapabilities(ctx context.Context, req *api.GetCapabilitiesRequest) (*api.GetCapabilitiesResponse, error) {
+	version := uint32(MaxAPIVersion)
+	if v := req.ClientApiVersion; v != 0 {
+		if v < MinAPIVersion {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"client API version %d is older than the oldest supported version %d", v, MinAPIVersion)
+		}
+		version = min(v, version)
+	}
+
+	res := &api.GetCapabilitiesResponse{
+		ApiVersion:        version,
+		MinApiVersion:     MinAPIVersion,
+		MaxApiVersion:     MaxAPIVersion,
+		AdminApiVersion:   AdminAPIVersion,
+		Features:          s.features(),
+		CompressionCodecs: compression.Registered(),
+		NodeId:            s.NodeID,
+		ReceiptPublicKey:  s.receiptPublicKey(),
+		MaxMessageBytes:   uint64(s.maxMessageBytes()),
+	}
+	if l, ok := s.CommitLog.(recordLimitLog); ok {
+		res.MaxRecordBytes = l.MaxRecordBytes()
+	}
+	return res, nil
+}
+
+// features: サーバーの設定で有効になっている機能の名前を返す（内部関数）
+func (c *Config) features() []string {
+	var features []string
+	for _, f := range []struct {
+		name    string
+		enabled bool
+	}{
+		{FeatureTopics, c.Topics != nil},
+		{FeatureConsumerOffsets, c.Offsets != nil},
+		{FeatureSessions, c.Sessions != nil},
+		{FeatureKeyValueView, c.View != nil},
+		{FeatureExport, c.ExportDir != ""},
+		{FeatureUsage, c.Usage != nil},
+		{FeatureUpcast, c.Upcasts != nil},
+		{FeatureReceipts, c.ReceiptKey != nil},
+		{FeatureTreeHeads, c.TreeHeads != nil},
+		{FeatureDelayedRecords, isDelayLog(c.CommitLog)},
+		{FeatureRelativeOffsets, isEndOffsetLog(c.CommitLog)},
+		{FeatureACLManagement, c.ACL != nil},
+	} {
+		if f.enabled {
+			features = append(features, f.name)
+		}
+	}
+	return features
+}
+
+// isDelayLog: ログストアが配信時刻を指定したレコードを探せるかを返す（内部関数）
+func isDelayLog(clog CommitLog) bool {
+	_, ok := clog.(delayLog)
+	return ok
+}
+
+// isEndOffsetLog: ログストアが次に追加するレコードのオフセットを返せるかを返す（内部関数）
+func isEndOffsetLog(clog CommitLog) bool {
+	_, ok := clog.(endOffsetLog)
+	return ok
+}
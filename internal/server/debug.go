@@ -0,0 +1,121 @@
+package server
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	rpprof "runtime/pprof"
+	"time"
+)
+
+// DebugConfig: 診断用の管理サーバーの設定
+type DebugConfig struct {
+	// 待ち受けるアドレス（ループバックアドレスのみ、例: "127.0.0.1:6060"）
+	Addr string
+	// POST /debug/dump で書き出すゴルーチンとヒープのダンプの出力先（空の場合はダンプを書き出せない）
+	DumpDir string
+}
+
+// NewDebugServer: pprof、expvar、ダンプの書き出しを提供する診断用の HTTP サーバーを作成する
+// 本番環境で再ビルドせずに性能の問題を調べるために使用する。プロファイルやメモリの内容が見えるため、
+// ループバックアドレス以外では待ち受けない。
+// エンドポイント:
+//   - /debug/pprof/: net/http/pprof のプロファイル
+//   - /debug/vars: expvar の変数
+//   - POST /debug/dump: ゴルーチンのスタックとヒーププロファイルを DumpDir に書き出し、パスを JSON で返す
+//
+// 引数:
+//   - config: 管理サーバーの設定
+//
+// 戻り値:
+//   - *http.Server: 作成されたサーバー（ListenAndServe で起動する）
+//   - error: アドレスがループバックアドレスでない場合
+func NewDebugServer(config DebugConfig) (*http.Server, error) {
+	host, _, err := net.SplitHostPort(config.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("debug server must listen on a loopback address: %q", config.Addr)
+	}
+	return &http.Server{
+		Addr:    config.Addr,
+		Handler: newDebugHandler(config),
+	}, nil
+}
+
+// newDebugHandler: 診断用のエンドポイントのハンドラーを作成する（内部関数）
+func newDebugHandler(config DebugConfig) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/dump", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if config.DumpDir == "" {
+			http.Error(w, "dump directory is not configured", http.StatusNotFound)
+			return
+		}
+		paths, err := writeDumps(config.DumpDir, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(paths)
+	})
+	return mux
+}
+
+// writeDumps: ゴルーチンのスタックとヒーププロファイルをファイルに書き出す
+// 戻り値:
+//   - map[string]string: ダンプの種類（goroutines、heap）ごとのファイルのパス
+//   - error: エラーが発生した場合
+func writeDumps(dir string, now time.Time) (map[string]string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	stamp := now.UTC().Format("20060102T150405.000000000Z")
+	paths := map[string]string{
+		"goroutines": filepath.Join(dir, "goroutines-"+stamp+".txt"),
+		"heap":       filepath.Join(dir, "heap-"+stamp+".pprof"),
+	}
+	// ゴルーチンのスタックは、デバッグの目的で読みやすいテキスト形式（debug=2）で書き出す
+	if err := writeDump(paths["goroutines"], func(w io.Writer) error {
+		return rpprof.Lookup("goroutine").WriteTo(w, 2)
+	}); err != nil {
+		return nil, err
+	}
+	// 直近の割り当てを反映させるため、ヒーププロファイルの前に GC を実行する
+	runtime.GC()
+	if err := writeDump(paths["heap"], rpprof.WriteHeapProfile); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// writeDump: ダンプをファイルに書き出す
+func writeDump(path string, write func(io.Writer) error) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"sort"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// delayLog: 配信時刻（deliver_after）を指定したレコードを探せるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、ConsumeStream は
+// 開始オフセットより前の、まだ配信時刻が来ていないレコードも配信時刻になったら送信する。
+type delayLog interface {
+	DelayedSince(since int64, before uint64) []uint64
+}
+
+// delayPollInterval: ConsumeStream が読み飛ばしたレコードの配信時刻が来たかを確認する間隔
+const delayPollInterval = 100 * time.Millisecond
+
+// checkDelivery: レコードの配信時刻が来ているかを確認する
+// 戻り値:
+//   - error: 配信時刻がまだ来ていない場合は api.ErrRecordDelayed
+func (s *grpcServer) checkDelivery(record *api.Record) error {
+	if record.DeliverAfter > s.clock().Now().UnixMilli() {
+		return api.ErrRecordDelayed{Offset: record.Offset, DeliverAfter: record.DeliverAfter}
+	}
+	return nil
+}
+
+// delayedRecord: ConsumeStream が配信時刻の前に読み飛ばしたレコード
+type delayedRecord struct {
+	offset       uint64
+	deliverAfter int64
+}
+
+// delayQueue: ConsumeStream が配信時刻の前に読み飛ばしたレコードを、配信時刻の順に保持する
+// レコードの本体は保持せず、配信時刻になったらログストアから読み取り直す。
+type delayQueue struct {
+	clock   log.Clock
+	records []delayedRecord // 配信時刻の順（同じ配信時刻の場合はオフセットの順）
+	ticker  log.Ticker      // 配信時刻を確認するタイマー（レコードを保持している間だけ動かす）
+}
+
+// add: 読み飛ばしたレコードを追加する
+func (q *delayQueue) add(offset uint64, deliverAfter int64) {
+	i := sort.Search(len(q.records), func(i int) bool {
+		r := q.records[i]
+		return r.deliverAfter > deliverAfter || (r.deliverAfter == deliverAfter && r.offset > offset)
+	})
+	q.records = append(q.records, delayedRecord{})
+	copy(q.records[i+1:], q.records[i:])
+	q.records[i] = delayedRecord{offset: offset, deliverAfter: deliverAfter}
+	if q.ticker == nil {
+		q.ticker = q.clock.NewTicker(delayPollInterval)
+	}
+}
+
+// due: 配信時刻が来たレコードのオフセットを、配信時刻の順に取り出す
+func (q *delayQueue) due() []uint64 {
+	if len(q.records) == 0 {
+		return nil
+	}
+	now := q.clock.Now().UnixMilli()
+	n := sort.Search(len(q.records), func(i int) bool {
+		return q.records[i].deliverAfter > now
+	})
+	offsets := make([]uint64, n)
+	for i, r := range q.records[:n] {
+		offsets[i] = r.offset
+	}
+	q.records = q.records[n:]
+	if len(q.records) == 0 {
+		q.stop()
+	}
+	return offsets
+}
+
+// wait: 配信時刻を確認する時刻になったら送信するチャネルを返す（保持しているレコードがない場合は nil）
+func (q *delayQueue) wait() <-chan time.Time {
+	if q.ticker == nil {
+		return nil
+	}
+	return q.ticker.C()
+}
+
+// stop: 配信時刻を確認するタイマーを止める
+func (q *delayQueue) stop() {
+	if q.ticker != nil {
+		q.ticker.Stop()
+		q.ticker = nil
+	}
+}
+
+// sendDue: 読み飛ばしたレコードのうち、配信時刻が来たものを送信する
+// 送信するレスポンスの next_offset は、ストリームが現在読み取っているオフセットにする
+// （クライアントが next_offset から再開したときに、既に受信したレコードを読み直さないように）。
+// 配信時刻の前に Truncate で削除されたレコードは送信しない。
+func (s *grpcServer) sendDue(clog CommitLog, q *delayQueue, next uint64, stream api.Log_ConsumeStreamServer) error {
+	for _, off := range q.due() {
+		record, err := clog.Read(off)
+		if _, ok := err.(api.ErrOffsetOutOfRange); ok {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		res, err := s.consumeResponse(record)
+		if err != nil {
+			return err
+		}
+		res.NextOffset = next
+		if err = stream.Send(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadDelayed: ストリームの開始オフセットより前の、since より後に配信時刻が来るレコードを delayQueue に追加する
+// 配信時刻が既に来ているレコードは、次の sendDue で送信される。
+func (s *grpcServer) loadDelayed(clog CommitLog, q *delayQueue, since int64, before uint64) error {
+	dl, ok := clog.(delayLog)
+	if !ok {
+		return nil
+	}
+	for _, off := range dl.DelayedSince(since, before) {
+		record, err := clog.Read(off)
+		if _, ok := err.(api.ErrOffsetOutOfRange); ok || corruptGap(off, err) != nil {
+			// 削除されたレコードと壊れたレコードは、以前のストリームでも送信できないため読み飛ばす
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		q.add(off, record.DeliverAfter)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/export"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultExportJobTTL: Config.ExportJobTTL が 0 の場合に、完了したエクスポートのジョブの状態を保持する時間
+const DefaultExportJobTTL = 24 * time.Hour
+
+// DefaultExportJobs: Config.MaxExportJobs が 0 の場合に、状態を保持するエクスポートのジョブの数
+const DefaultExportJobs = 1000
+
+// exportJobs: 実行中と完了したエクスポートのジョブ（サーバーを再起動すると失われる）
+type exportJobs struct {
+	mu       sync.Mutex
+	jobs     map[string]*api.ExportJob
+	finished map[string]time.Time // 完了したジョブの ID → 完了した時刻
+	next     uint64               // 同じ時刻に開始したジョブを区別するための連番
+}
+
+// evict: 保持期間を過ぎた完了したジョブを捨て、それでも上限を超えている場合は完了した古いジョブから捨てる（内部関数）
+// 実行中のジョブは捨てない。呼び出し側で j.mu のロックを取得しておく必要がある。
+// 引数:
+//   - now: 現在時刻
+//   - ttl: 完了したジョブを保持する時間
+//   - limit: 保持するジョブの数の上限
+func (j *exportJobs) evict(now time.Time, ttl time.Duration, limit int) {
+	var finished []string
+	for id, at := range j.finished {
+		if now.Sub(at) >= ttl {
+			delete(j.jobs, id)
+			delete(j.finished, id)
+			continue
+		}
+		finished = append(finished, id)
+	}
+	if len(j.jobs) <= limit {
+		return
+	}
+	sort.Slice(finished, func(a, b int) bool {
+		return j.finished[finished[a]].Before(j.finished[finished[b]])
+	})
+	for _, id := range finished {
+		if len(j.jobs) <= limit {
+			return
+		}
+		delete(j.jobs, id)
+		delete(j.finished, id)
+	}
+}
+
+// evictExports: Config の保持期間と上限でエクスポートのジョブを捨てる（内部関数）
+// 呼び出し側で s.exports.mu のロックを取得しておく必要がある。
+// 引数:
+//   - room: これから追加するジョブの数（上限からこの数を引いた数までジョブを捨てる）
+//
+// 戻り値:
+//   - int: 保持するジョブの数の上限
+func (s *grpcServer) evictExports(room int) int {
+	ttl := s.ExportJobTTL
+	if ttl == 0 {
+		ttl = DefaultExportJobTTL
+	}
+	limit := s.MaxExportJobs
+	if limit == 0 {
+		limit = DefaultExportJobs
+	}
+	s.exports.evict(s.clock().Now(), ttl, limit-room)
+	return limit
+}
+
+// StartExport: ログの範囲を Parquet ファイルとしてエクスポートするジョブをバックグラウンドで開始する（管理操作）
+// ファイルはサーバーの ExportDir に "<ジョブ ID>.parquet" として書き込まれ、進捗は GetExport で確認できる。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: トピック、オフセットと時刻の範囲、出力する列を含むリクエスト
+//
+// 戻り値:
+//   - *api.StartExportResponse: 開始したジョブの ID
+//   - error: エラーが発生した場合（列の指定が不正な場合は codes.InvalidArgument、
+//     実行中のジョブが MaxExportJobs に達している場合は codes.ResourceExhausted）
+func (s *grpcServer) StartExport(ctx context.Context, req *api.StartExportRequest) (*api.StartExportResponse, error) {
+	if err := s.authorize(ctx, topicObject(req.Topic), exportAction); err != nil {
+		return nil, err
+	}
+	if s.ExportDir == "" {
+		return nil, errExportDisabled
+	}
+	cols, err := export.ParseColumns(req.Columns)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	clog, release, err := s.commitLog(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	// ジョブを開始した場合は、ジョブの終了時にログストアの使用を終える
+	started := false
+	defer func() {
+		if !started {
+			release()
+		}
+	}()
+	r := export.Range{
+		FromOffset: req.FromOffset,
+		ToOffset:   req.ToOffset,
+		FromTime:   req.FromTime,
+		ToTime:     req.ToTime,
+	}
+	// 削除済みのオフセットからエクスポートを開始しないようにする
+	if ll, ok := clog.(lowestOffsetLog); ok {
+		lowest, err := ll.LowestOffset()
+		if err != nil {
+			return nil, err
+		}
+		r.FromOffset = max(r.FromOffset, lowest)
+	}
+	if err := os.MkdirAll(s.ExportDir, 0755); err != nil {
+		return nil, err
+	}
+
+	s.exports.mu.Lock()
+	if limit := s.evictExports(1); len(s.exports.jobs) >= limit {
+		s.exports.mu.Unlock()
+		return nil, status.Errorf(codes.ResourceExhausted, "too many running export jobs (limit %d)", limit)
+	}
+	id := fmt.Sprintf("%d-%d", s.clock().Now().UnixNano(), s.exports.next)
+	s.exports.next++
+	job := &api.ExportJob{
+		JobId: id,
+		State: api.ExportJob_RUNNING,
+		Path:  filepath.Join(s.ExportDir, id+".parquet"),
+	}
+	s.exports.jobs[id] = job
+	s.exports.mu.Unlock()
+
+	started = true
+	go s.runExport(job.JobId, job.Path, clog, release, r, cols)
+	return &api.StartExportResponse{JobId: id}, nil
+}
+
+// runExport: エクスポートのジョブを実行し、完了したらジョブの状態を更新する
+// 一時ファイルに書き込んでから名前を変更するため、完了したファイルだけが "<ジョブ ID>.parquet" として見える。
+// 書き込みが終わったら release でログストアの使用を終える。
+func (s *grpcServer) runExport(id, path string, clog CommitLog, release func(), r export.Range, cols []export.Column) {
+	var l export.Log = clog
+	if sl, ok := clog.(sequentialLog); ok {
+		l = sl.NewSequentialReader()
+	}
+	l = redactedLog{l, s}
+	n, err := writeExport(path, l, r, cols)
+	release()
+
+	s.exports.mu.Lock()
+	defer s.exports.mu.Unlock()
+	job := s.exports.jobs[id]
+	s.exports.finished[id] = s.clock().Now()
+	job.Records = uint64(n)
+	if err != nil {
+		job.State = api.ExportJob_FAILED
+		job.Error = err.Error()
+		return
+	}
+	job.State = api.ExportJob_DONE
+}
+
+// redactedLog: 読み取ったレコードを ExportRedactor でマスキングして返す export.Log
+type redactedLog struct {
+	export.Log
+	srv *grpcServer
+}
+
+func (l redactedLog) Read(off uint64) (*api.Record, error) {
+	record, err := l.Log.Read(off)
+	if err != nil {
+		return nil, err
+	}
+	l.srv.redactExport(record)
+	return record, nil
+}
+
+// writeExport: ログの範囲を Parquet ファイルに書き込む
+func writeExport(path string, l export.Log, r export.Range, cols []export.Column) (int64, error) {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	n, err := export.Parquet(f, l, r, cols)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return n, err
+}
+
+// GetExport: エクスポートのジョブの状態を返す（管理操作）
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: ジョブの ID を含むリクエスト
+//
+// 戻り値:
+//   - *api.ExportJob: ジョブの状態、出力先のパス、書き込んだレコードの数
+//   - error: エラーが発生した場合（ジョブが存在しない場合、完了してから ExportJobTTL を過ぎた場合は codes.NotFound）
+func (s *grpcServer) GetExport(ctx context.Context, req *api.GetExportRequest) (*api.ExportJob, error) {
+	if err := s.authorize(ctx, objectWildcard, exportAction); err != nil {
+		return nil, err
+	}
+	if s.ExportDir == "" {
+		return nil, errExportDisabled
+	}
+	s.exports.mu.Lock()
+	defer s.exports.mu.Unlock()
+	s.evictExports(0)
+	job, ok := s.exports.jobs[req.JobId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "export job %q not found", req.JobId)
+	}
+	return proto.Clone(job).(*api.ExportJob), nil
+}
+
+// errExportDisabled: エクスポート先のディレクトリが設定されていないサーバーでエクスポートした場合のエラー
+var errExportDisabled = status.Error(codes.FailedPrecondition, "export is not enabled on this server")
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// faultInterceptors: 遅延、エラー、接続のリセットを注入するインターセプターを作成する（テスト用）
+// Faults.Enabled が true でない場合は nil を返す。
+func (c *Config) faultInterceptors() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	if !c.Faults.Enabled {
+		return nil, nil
+	}
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := c.injectFault(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := c.injectFault(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+	return unary, stream
+}
+
+// injectFault: RPC を処理する前に、設定に従って遅延させ、エラーを返すか接続をリセットする（内部関数）
+// ストリームの場合は開始時にだけ注入する。
+// 戻り値:
+//   - error: 注入したエラー（注入しない場合は nil）
+func (c *Config) injectFault(ctx context.Context, method string) error {
+	f := &c.Faults
+	if len(f.Methods) > 0 && !slices.Contains(f.Methods, method) {
+		return nil
+	}
+
+	if delay := f.Latency + jitter(f.Jitter); delay > 0 {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	if f.ResetRate > 0 && rand.Float64() < f.ResetRate {
+		if p, ok := peer.FromContext(ctx); ok && f.Listener != nil && f.Listener.reset(p.Addr) {
+			return status.Error(codes.Unavailable, "injected fault: connection reset")
+		}
+	}
+	if f.ErrorRate > 0 && rand.Float64() < f.ErrorRate {
+		code := f.ErrorCode
+		if code == codes.OK {
+			code = codes.Unavailable
+		}
+		return status.Error(code, "injected fault")
+	}
+	return nil
+}
+
+// jitter: 0 以上 max 未満のランダムな時間を返す（max が 0 以下の場合は 0）
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
+
+// FaultListener: 受け付けた接続を記録し、故障の注入で接続をリセットできるようにするリスナー（テスト用）
+// 接続のリセットを注入する場合は、サーバーにこのリスナーを渡し、Config.Faults.Listener にも設定する。
+type FaultListener struct {
+	net.Listener
+
+	mu    sync.Mutex
+	conns map[string]net.Conn // リモートアドレスごとの受け付けた接続
+}
+
+// NewFaultListener: 接続をリセットできるリスナーを作成する
+// 引数:
+//   - l: 元のリスナー
+//
+// 戻り値:
+//   - *FaultListener: 作成されたリスナー
+func NewFaultListener(l net.Listener) *FaultListener {
+	return &FaultListener{Listener: l, conns: make(map[string]net.Conn)}
+}
+
+// Accept: 接続を受け付けて記録する
+func (l *FaultListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	key := conn.RemoteAddr().String()
+	l.mu.Lock()
+	l.conns[key] = conn
+	l.mu.Unlock()
+	return &faultConn{Conn: conn, listener: l, key: key}, nil
+}
+
+// faultConn: 閉じたときに FaultListener の記録から外す接続
+type faultConn struct {
+	net.Conn
+	listener *FaultListener
+	key      string
+}
+
+// Close: 記録から外して接続を閉じる
+func (c *faultConn) Close() error {
+	c.listener.mu.Lock()
+	if c.listener.conns[c.key] == c.Conn {
+		delete(c.listener.conns, c.key)
+	}
+	c.listener.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// reset: リモートアドレスの接続をリセットする（内部関数）
+// TCP の場合は linger を 0 にして閉じ、クライアントに RST を送る。
+// 戻り値:
+//   - bool: 接続をリセットした場合は true（記録されていない接続の場合は false）
+func (l *FaultListener) reset(addr net.Addr) bool {
+	l.mu.Lock()
+	conn, ok := l.conns[addr.String()]
+	delete(l.conns, addr.String())
+	l.mu.Unlock()
+	if !ok {
+		return false
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+	_ = conn.Close()
+	return true
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+const (
+	// defaultPageLimit: limit が指定されていない場合に1ページで返すレコードの数
+	defaultPageLimit = 100
+	// maxPageLimit: 1ページで返すレコードの最大数
+	maxPageLimit = 1000
+)
+
+// errCursorExpired: カーソルを発行した後にログストアが Reset されたか、カーソルの位置のレコードが削除された場合のエラー
+var errCursorExpired = errors.New("cursor expired")
+
+// Cursor: HTTP ゲートウェイのページングで次に読み取る位置
+// クライアントには不透明な文字列（Encode の結果）として渡し、そのまま次のリクエストの after に指定してもらう。
+type Cursor struct {
+	Epoch  uint64 // カーソルを発行したときのオフセットのエポック
+	Offset uint64 // 次に読み取るオフセット
+}
+
+// cursorLen: エンコードしたカーソルのバイト数（エポック(8) + オフセット(8)）
+const cursorLen = 16
+
+// Encode: カーソルを URL に含められる文字列にエンコードする
+func (c Cursor) Encode() string {
+	b := make([]byte, cursorLen)
+	binary.BigEndian.PutUint64(b[:8], c.Epoch)
+	binary.BigEndian.PutUint64(b[8:], c.Offset)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseCursor: Encode でエンコードしたカーソルを解析する
+// 引数:
+//   - s: エンコードされたカーソル
+//
+// 戻り値:
+//   - Cursor: 解析したカーソル
+//   - error: 形式が不正な場合
+func ParseCursor(s string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) != cursorLen {
+		return Cursor{}, fmt.Errorf("invalid cursor %q", s)
+	}
+	return Cursor{
+		Epoch:  binary.BigEndian.Uint64(b[:8]),
+		Offset: binary.BigEndian.Uint64(b[8:]),
+	}, nil
+}
+
+// GatewayRecord: HTTP ゲートウェイが返すレコード（キーと値は JSON の base64 文字列になる）
+type GatewayRecord struct {
+	Offset    uint64            `json:"offset"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Key       []byte            `json:"key,omitempty"`
+	Value     []byte            `json:"value"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// RecordsPage: GET /records のレスポンス
+type RecordsPage struct {
+	Records []GatewayRecord `json:"records"`
+	// 次のページを読み取るためのカーソル（末尾に達した場合も返すため、後から追加されたレコードをポーリングできる）
+	NextCursor string `json:"next_cursor"`
+}
+
+// gatewayHandler: ログの履歴をページングして読み取る HTTP ハンドラー
+type gatewayHandler struct {
+	srv *grpcServer
+}
+
+// NewGatewayHandler: ログのレコードを HTTP で読み取るゲートウェイのハンドラーを作成する
+// GET /records?after=<cursor>&limit=N&topic=<topic> で、after のカーソルの位置から最大 limit 件のレコードと、
+// 次のページのカーソル（next_cursor）を返す。after を省略した場合は保持している最小のオフセットから読み取る。
+// カーソルはオフセットとエポックをエンコードした不透明な文字列で、ログストアが Reset された後や、
+// カーソルの位置のレコードが削除された後に指定すると 410 Gone を返す（after を省略して読み直す必要がある）。
+// Authorizer が設定されている場合は、gRPC の Consume と同じように、クライアント証明書の CN をサブジェクトとして
+// トピックの読み取りを認可する。そのため、クライアント証明書を検証しない TLS の設定（または TLS なし）では作成できない。
+// 読み取ったレコードは ExportRedactor でマスキングして返す。
+// 引数:
+//   - config: サーバーの設定（読み取り元のログストアとトピック、認可、マスキング）
+//   - tlsConfig: ハンドラーを公開する HTTP サーバーの TLS の設定（TLS を使用しない場合は nil）
+//
+// 戻り値:
+//   - http.Handler: 作成されたハンドラー
+//   - error: サーバーの設定が不正な場合、または Authorizer があるのにクライアント証明書を検証しない場合
+func NewGatewayHandler(config *Config, tlsConfig *tls.Config) (http.Handler, error) {
+	if config.Authorizer != nil && (tlsConfig == nil || tlsConfig.ClientAuth < tls.VerifyClientCertIfGiven) {
+		return nil, errors.New("gateway with an authorizer requires TLS with verified client certificates")
+	}
+	srv, err := newgrpcServer(config)
+	if err != nil {
+		return nil, err
+	}
+	r := mux.NewRouter()
+	r.Handle("/records", &gatewayHandler{srv: srv}).Methods("GET")
+	return r, nil
+}
+
+// ServeHTTP: カーソルの位置からレコードを読み取り、次のページのカーソルとともに返す
+func (h *gatewayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := defaultPageLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxPageLimit)
+	}
+
+	topic := q.Get("topic")
+	ctx := context.WithValue(r.Context(), subjectContextKey{}, requestSubject(r))
+	if err := h.srv.authorize(ctx, topicObject(topic), consumeAction); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+	clog, release, err := h.srv.commitLog(topic)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+	defer release()
+	page, err := h.page(clog, q.Get("after"), limit)
+	var cursorErr cursorError
+	switch {
+	case errors.Is(err, errCursorExpired):
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	case errors.As(err, &cursorErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// requestSubject: HTTP リクエストの検証済みのクライアント証明書から、サブジェクト（CN）を取り出す
+// クライアント証明書がない場合は空文字列を返す。
+func requestSubject(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName
+}
+
+// cursorError: after に指定されたカーソルの形式が不正な場合のエラー
+type cursorError struct{ error }
+
+// page: カーソルの位置から最大 limit 件のレコードを読み取る（内部関数）
+// 引数:
+//   - clog: 読み取り元のログストア
+//   - after: リクエストのカーソル（空の場合は保持している最小のオフセットから）
+//   - limit: 読み取るレコードの最大数
+func (h *gatewayHandler) page(clog CommitLog, after string, limit int) (*RecordsPage, error) {
+	var epoch, lowest uint64
+	if el, ok := clog.(epochLog); ok {
+		epoch = el.Epoch()
+	}
+	if ll, ok := clog.(lowestOffsetLog); ok {
+		off, err := ll.LowestOffset()
+		if err != nil {
+			return nil, err
+		}
+		lowest = off
+	}
+
+	cursor := Cursor{Epoch: epoch, Offset: lowest}
+	if after != "" {
+		c, err := ParseCursor(after)
+		if err != nil {
+			return nil, cursorError{err}
+		}
+		if c.Epoch != epoch {
+			return nil, fmt.Errorf("%w: log was reset (epoch %d, now %d)", errCursorExpired, c.Epoch, epoch)
+		}
+		if c.Offset < lowest {
+			return nil, fmt.Errorf("%w: offset %d was removed (lowest offset %d)", errCursorExpired, c.Offset, lowest)
+		}
+		cursor = c
+	}
+
+	page := &RecordsPage{Records: []GatewayRecord{}}
+	first, err := clog.Read(cursor.Offset)
+	if _, ok := err.(api.ErrOffsetOutOfRange); ok {
+		// 末尾に達している: 同じ位置のカーソルを返す
+		page.NextCursor = cursor.Encode()
+		return page, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res, err := h.srv.consumeRecords(clog, &api.ConsumeRequest{MaxRecords: uint32(limit)}, first)
+	if _, ok := err.(api.ErrRecordDelayed); ok {
+		// 先頭のレコードの配信時刻がまだ来ていない: 末尾と同じく、同じ位置のカーソルを返す
+		page.NextCursor = cursor.Encode()
+		return page, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	records := res.Records
+	if len(records) == 0 {
+		// limit が 1 の場合は record だけが設定される
+		records = []*api.Record{res.Record}
+	}
+	for _, record := range records {
+		page.Records = append(page.Records, GatewayRecord{
+			Offset:    record.Offset,
+			Timestamp: record.Timestamp,
+			Key:       record.Key,
+			Value:     record.Value,
+			Headers:   record.Headers,
+		})
+	}
+	cursor.Offset = res.NextOffset
+	page.NextCursor = cursor.Encode()
+	return page, nil
+}
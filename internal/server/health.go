@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+
+	"github.com/kentakki416/proglog/internal/health"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// healthReasonHeader: ヘルスチェックの応答ヘッダーで異常の理由を返すメタデータのキー
+// 標準のヘルスチェックの応答は状態しか持たないため、理由はヘッダーで返す（異常なチェックごとに1つの値）。
+const healthReasonHeader = "proglog-health-reason"
+
+// healthServer: セルフチェックの結果を返す gRPC の標準のヘルスチェックサービス
+// サービス名が空文字または Log サービスの場合に、すべてのセルフチェックをまとめた状態を返す。
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	monitor *health.Monitor
+}
+
+// Check: 最後に実行したセルフチェックの状態を返す
+// 異常な場合は NOT_SERVING を返し、異常の理由を proglog-health-reason ヘッダーに設定する。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 確認するサービス名を含むリクエスト
+//
+// 戻り値:
+//   - *healthpb.HealthCheckResponse: サービスの状態
+//   - error: 不明なサービス名の場合（codes.NotFound）
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if req.Service != "" && req.Service != "log.v1.Log" {
+		return nil, status.Errorf(codes.NotFound, "unknown service: %s", req.Service)
+	}
+	st := s.monitor.Status()
+	if !st.Healthy {
+		md := metadata.MD{}
+		md.Append(healthReasonHeader, st.Reasons()...)
+		if err := grpc.SetHeader(ctx, md); err != nil {
+			return nil, err
+		}
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// endOffsetLog: 次に追加するレコードのオフセットを返せるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、コンシューマーの遅延を計算できる。
+type endOffsetLog interface {
+	NextOffset() uint64
+}
+
+// GetConsumerLag: コンシューマーグループがトピックの末尾からどれだけ遅れているかを返す（管理操作）
+// コミット済みオフセットを持つグループとトピックの組ごとに、ログの末尾（次に追加するレコードのオフセット）との差を返す。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 対象のコンシューマーグループとトピック（空の場合はすべて）を含むリクエスト
+//
+// 戻り値:
+//   - *api.GetConsumerLagResponse: グループとトピックの順に並べた遅延
+//   - error: エラーが発生した場合
+func (s *grpcServer) GetConsumerLag(ctx context.Context, req *api.GetConsumerLagRequest) (*api.GetConsumerLagResponse, error) {
+	if err := s.authorize(ctx, objectWildcard, getConsumerLagAction); err != nil {
+		return nil, err
+	}
+	if s.Offsets == nil {
+		return nil, errOffsetsDisabled
+	}
+	return &api.GetConsumerLagResponse{Lags: s.consumerLags(req.Group, req.Topics)}, nil
+}
+
+// consumerLags: コミット済みオフセットとログの末尾からコンシューマーの遅延を計算する（内部関数）
+// 削除されたトピックや末尾のオフセットを返せないログストアの組は含めない。
+// コミット済みオフセットがログの末尾を超えている場合（ログをリセットした場合など）、遅延は 0 とする。
+// 引数:
+//   - group: 対象のコンシューマーグループ（空文字の場合はすべて）
+//   - topics: 対象のトピック（空の場合はすべて）
+//
+// 戻り値:
+//   - []*api.ConsumerLag: グループとトピックの順に並べた遅延
+func (c *Config) consumerLags(group string, topics []string) []*api.ConsumerLag {
+	var lags []*api.ConsumerLag
+	// 同じトピックの末尾のオフセットはグループ間で共有する
+	ends := make(map[string]uint64)
+	for g, committed := range c.Offsets.Export().Groups {
+		if group != "" && g != group {
+			continue
+		}
+		for topic, off := range committed {
+			if len(topics) > 0 && !slices.Contains(topics, topic) {
+				continue
+			}
+			end, ok := ends[topic]
+			if !ok {
+				clog, release, err := c.commitLog(topic)
+				if err != nil {
+					continue
+				}
+				elog, ok := clog.(endOffsetLog)
+				if !ok {
+					release()
+					continue
+				}
+				end = elog.NextOffset()
+				release()
+				ends[topic] = end
+			}
+			lag := &api.ConsumerLag{
+				Group:           g,
+				Topic:           topic,
+				CommittedOffset: off,
+				EndOffset:       end,
+			}
+			if end > off {
+				lag.Lag = end - off
+			}
+			lags = append(lags, lag)
+		}
+	}
+	sort.Slice(lags, func(i, j int) bool {
+		if lags[i].Group != lags[j].Group {
+			return lags[i].Group < lags[j].Group
+		}
+		return lags[i].Topic < lags[j].Topic
+	})
+	return lags
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// unixPrefix: Unix ドメインソケットで待ち受けるアドレスの接頭辞（例: "unix:///run/proglog.sock"、"unix:proglog.sock"）
+const unixPrefix = "unix:"
+
+// unixSocketMode: Unix ドメインソケットのファイルのパーミッション
+// ソケットに接続できることが UnixSocketCredentials のサブジェクトとして認証されることになるため、
+// 所有者とグループ（同じ Pod のサイドカーなど）だけが接続できるようにする。
+const unixSocketMode = 0660
+
+// Listen: 複数のアドレスで待ち受けるリスナーを作成する
+// 1つの gRPC サーバーを Serve で複数のリスナーに同時に公開するために使用する。
+// TCP のアドレスは "host:port" の形式で指定する（":8400" は IPv4 と IPv6 の両方で待ち受け、
+// "0.0.0.0:8400" と "[::1]:8400" のように別々に指定することもできる）。
+// "unix:" で始まるアドレスは Unix ドメインソケットで待ち受ける。前回のプロセスが残したソケットのファイルは削除して作り直し、
+// ソケット以外のファイルがある場合は失敗する。ソケットのファイルはリスナーを閉じると削除される。
+// 引数:
+//   - addrs: 待ち受けるアドレス
+//
+// 戻り値:
+//   - []net.Listener: アドレスの順のリスナー
+//   - error: いずれかのアドレスで待ち受けられない場合（作成済みのリスナーは閉じる）
+func Listen(addrs ...string) ([]net.Listener, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("no listen addresses")
+	}
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		l, err := listen(addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("listen on %q: %w", addr, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// listen: 1つのアドレスで待ち受けるリスナーを作成する（内部関数）
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	path = strings.TrimPrefix(path, "//")
+	if path == "" {
+		return nil, errors.New("empty unix socket path")
+	}
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode().Type() != os.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, unixSocketMode); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Serve: gRPC サーバーをすべてのリスナーで同時に公開する
+// いずれかのリスナーでエラーが発生した場合は、サーバーを停止して（他のリスナーも閉じて）そのエラーを返す。
+// サーバーを Stop または GracefulStop で停止した場合は nil を返す。
+func Serve(srv *grpc.Server, listeners []net.Listener) error {
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+	for _, l := range listeners {
+		wg.Add(1)
+		go func(l net.Listener) {
+			defer wg.Done()
+			if err := srv.Serve(l); err != nil {
+				once.Do(func() {
+					first = err
+					srv.Stop()
+				})
+			}
+		}(l)
+	}
+	wg.Wait()
+	return first
+}
+
+// UnixSocketCredentials: Unix ドメインソケットの接続では TLS を使用しない、サーバーのトランスポートの認証情報を作成する
+// 同じホスト（同じ Pod）のサイドカーからの接続で、TCP と TLS のオーバーヘッドをなくすために使用する。
+// TCP の接続は creds で認証する。Unix ドメインソケットの接続はソケットのファイルのパーミッションで保護されているとみなし、
+// subject をクライアントのサブジェクト（ACL の主体）とする。
+// 引数:
+//   - creds: TCP の接続の認証情報（nil の場合は TLS を使用しない）
+//   - subject: Unix ドメインソケットで接続したクライアントのサブジェクト（空の場合はサブジェクトなし）
+//
+// 戻り値:
+//   - credentials.TransportCredentials: grpc.Creds に渡す認証情報
+func UnixSocketCredentials(creds credentials.TransportCredentials, subject string) credentials.TransportCredentials {
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+	return &unixSocketCredentials{TransportCredentials: creds, subject: subject}
+}
+
+// unixSocketCredentials: Unix ドメインソケットの接続だけ TLS のハンドシェイクを省略する認証情報
+type unixSocketCredentials struct {
+	credentials.TransportCredentials
+	subject string
+}
+
+// unixAuthInfo: Unix ドメインソケットで接続したクライアントの認証情報
+type unixAuthInfo struct {
+	credentials.CommonAuthInfo
+	subject string
+}
+
+// AuthType: 認証の種類を返す
+func (unixAuthInfo) AuthType() string {
+	return "unix"
+}
+
+// ServerHandshake: Unix ドメインソケットの接続はそのまま受け付け、それ以外の接続は元の認証情報でハンドシェイクする
+func (c *unixSocketCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	if conn.LocalAddr().Network() != "unix" {
+		return c.TransportCredentials.ServerHandshake(conn)
+	}
+	return conn, unixAuthInfo{
+		// 同じホストの中の通信のため、盗聴と改ざんから保護されているとみなす（トークンなどの認証情報を送れるようにする）
+		CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+		subject:        c.subject,
+	}, nil
+}
+
+// Clone: 認証情報を複製する
+func (c *unixSocketCredentials) Clone() credentials.TransportCredentials {
+	return &unixSocketCredentials{TransportCredentials: c.TransportCredentials.Clone(), subject: c.subject}
+}
@@ -0,0 +1,327 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// segmentLabels: セグメントのメトリクスに付けるラベル（トピックが指定されていないログは topic が空になる）
+var segmentLabels = []string{"topic", "base_offset"}
+
+// セグメントのメトリクスの定義
+var (
+	segmentRecordsDesc = prometheus.NewDesc(
+		"proglog_segment_records", "Number of records in the segment.", segmentLabels, nil,
+	)
+	segmentStoreBytesDesc = prometheus.NewDesc(
+		"proglog_segment_store_bytes", "Size of the segment store file in bytes.", segmentLabels, nil,
+	)
+	segmentIndexBytesDesc = prometheus.NewDesc(
+		"proglog_segment_index_bytes", "Size of the segment index in bytes.", segmentLabels, nil,
+	)
+	segmentAgeDesc = prometheus.NewDesc(
+		"proglog_segment_age_seconds", "Seconds since the segment was created.", segmentLabels, nil,
+	)
+	segmentActiveDesc = prometheus.NewDesc(
+		"proglog_segment_active", "Whether the segment is the active segment (1) or sealed (0).", segmentLabels, nil,
+	)
+	logRecordsDesc = prometheus.NewDesc(
+		"proglog_log_records", "Number of records retained in the log.", []string{"topic"}, nil,
+	)
+	logBytesDesc = prometheus.NewDesc(
+		"proglog_log_bytes", "Total size of the log store files in bytes.", []string{"topic"}, nil,
+	)
+	logWrittenBytesDesc = prometheus.NewDesc(
+		"proglog_log_written_bytes_total",
+		"Bytes written to disk since the log was opened, by kind (record, store, index, rewrite).",
+		[]string{"topic", "kind"}, nil,
+	)
+)
+
+// ProduceDeadlineHeadroom: 書き込みが完了した時点での、クライアントの期限までの残り時間
+// 期限を指定したリクエストのみを記録する。値が小さいほど期限に近く、負の値は期限を過ぎたことを表す。
+// 埋め込み側が Prometheus のレジストリに登録して使用する。
+var ProduceDeadlineHeadroom = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "proglog_produce_deadline_headroom_seconds",
+	Help:    "Time left until the client deadline when a produce request finished appending.",
+	Buckets: []float64{0, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 30},
+})
+
+// ProduceDeadlineRejected: 期限までに書き込みが終わる見込みがなく、書き込む前に失敗させたリクエストの数
+var ProduceDeadlineRejected = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "proglog_produce_deadline_rejected_total",
+	Help: "Produce requests rejected before appending because the client deadline could not be met.",
+})
+
+// segmentCollector: セグメントごとの統計情報を Prometheus のゲージとして公開するコレクター
+// スクレイプのたびにログストアから統計情報を取得するため、削除されたセグメントのメトリクスが残らない。
+type segmentCollector struct {
+	*Config
+}
+
+// NewSegmentCollector: セグメントごとの統計情報を公開する Prometheus のコレクターを作成する
+// デフォルトのログストア（Config.CommitLog）とすべてのトピックのセグメントを対象とする。
+// 引数:
+//   - config: サーバーの設定
+//
+// 戻り値:
+//   - prometheus.Collector: レジストリに登録するコレクター
+func NewSegmentCollector(config *Config) prometheus.Collector {
+	return &segmentCollector{Config: config}
+}
+
+// Describe: コレクターが公開するメトリクスの定義を送信する
+func (c *segmentCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- segmentRecordsDesc
+	ch <- segmentStoreBytesDesc
+	ch <- segmentIndexBytesDesc
+	ch <- segmentAgeDesc
+	ch <- segmentActiveDesc
+	ch <- logRecordsDesc
+	ch <- logBytesDesc
+	ch <- logWrittenBytesDesc
+}
+
+// Collect: ログストアから統計情報を取得してメトリクスを送信する
+// 統計情報を取得できないログストアは無視する。
+func (c *segmentCollector) Collect(ch chan<- prometheus.Metric) {
+	now := c.clock().Now()
+	c.collect(ch, now, "", c.CommitLog)
+	if c.Topics == nil {
+		return
+	}
+	for _, topic := range c.Topics.List() {
+		l, err := c.Topics.Acquire(topic)
+		if err != nil {
+			// 一覧を取得した後に削除されたトピック
+			continue
+		}
+		c.collect(ch, now, topic, l)
+		c.Topics.Release(topic)
+	}
+}
+
+// collect: 1つのログストアのセグメントのメトリクスを送信する
+func (c *segmentCollector) collect(ch chan<- prometheus.Metric, now time.Time, topic string, clog CommitLog) {
+	if sl, ok := clog.(sizedLog); ok {
+		records, bytes := sl.Size()
+		ch <- prometheus.MustNewConstMetric(logRecordsDesc, prometheus.GaugeValue, float64(records), topic)
+		ch <- prometheus.MustNewConstMetric(logBytesDesc, prometheus.GaugeValue, float64(bytes), topic)
+	}
+	if wl, ok := clog.(writeStatsLog); ok {
+		// レコードのデータ量（record）との比率が書き込みの増幅になる
+		w := wl.WriteStats()
+		for kind, n := range map[string]uint64{
+			"record":  w.RecordBytes,
+			"store":   w.StoreBytes,
+			"index":   w.IndexBytes,
+			"rewrite": w.RewriteBytes,
+		} {
+			ch <- prometheus.MustNewConstMetric(logWrittenBytesDesc, prometheus.CounterValue, float64(n), topic, kind)
+		}
+	}
+	slog, ok := clog.(statsLog)
+	if !ok {
+		return
+	}
+	stats, err := slog.SegmentStats()
+	if err != nil {
+		return
+	}
+	for _, st := range stats {
+		labels := []string{topic, strconv.FormatUint(st.BaseOffset, 10)}
+		active := 0.0
+		if st.Active {
+			active = 1
+		}
+		ch <- prometheus.MustNewConstMetric(segmentRecordsDesc, prometheus.GaugeValue, float64(st.Records), labels...)
+		ch <- prometheus.MustNewConstMetric(segmentStoreBytesDesc, prometheus.GaugeValue, float64(st.StoreBytes), labels...)
+		ch <- prometheus.MustNewConstMetric(segmentIndexBytesDesc, prometheus.GaugeValue, float64(st.IndexBytes), labels...)
+		ch <- prometheus.MustNewConstMetric(segmentAgeDesc, prometheus.GaugeValue, segmentAge(now, st).Seconds(), labels...)
+		ch <- prometheus.MustNewConstMetric(segmentActiveDesc, prometheus.GaugeValue, active, labels...)
+	}
+}
+
+// segmentAge: セグメントが作成されてからの経過時間を返す
+// 時刻のずれで作成時刻が未来になっている場合は 0 を返す。
+func segmentAge(now time.Time, st log.SegmentStat) time.Duration {
+	if age := now.Sub(st.CreatedAt); age > 0 {
+		return age
+	}
+	return 0
+}
+
+// activeSessionsDesc: コンシューマーグループとトピックごとの生きているセッションの数
+var activeSessionsDesc = prometheus.NewDesc(
+	"proglog_active_sessions", "Number of live client sessions.", []string{"group", "topic"}, nil,
+)
+
+// sessionCollector: 生きているセッションの数を Prometheus のゲージとして公開するコレクター
+// スクレイプのたびに期限切れのセッションを除いて数えるため、停止したクライアントが数に残らない。
+type sessionCollector struct {
+	*Config
+}
+
+// NewSessionCollector: 生きているセッションの数を公開する Prometheus のコレクターを作成する
+// 引数:
+//   - config: サーバーの設定（Sessions が nil の場合はメトリクスを送信しない）
+//
+// 戻り値:
+//   - prometheus.Collector: レジストリに登録するコレクター
+func NewSessionCollector(config *Config) prometheus.Collector {
+	return &sessionCollector{Config: config}
+}
+
+// Describe: コレクターが公開するメトリクスの定義を送信する
+func (c *sessionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeSessionsDesc
+}
+
+// Collect: グループとトピックごとにセッションを数えてメトリクスを送信する
+func (c *sessionCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.Sessions == nil {
+		return
+	}
+	counts := make(map[[2]string]int)
+	for _, s := range c.Sessions.Active() {
+		counts[[2]string{s.Group, s.Topic}]++
+	}
+	for labels, n := range counts {
+		ch <- prometheus.MustNewConstMetric(activeSessionsDesc, prometheus.GaugeValue, float64(n), labels[0], labels[1])
+	}
+}
+
+// consumerLagDesc: コンシューマーグループとトピックごとの、ログの末尾からの遅れ（レコード数）
+var consumerLagDesc = prometheus.NewDesc(
+	"proglog_consumer_lag", "Number of records between the committed offset and the end of the log.", []string{"group", "topic"}, nil,
+)
+
+// lagCollector: コンシューマーの遅延を Prometheus のゲージとして公開するコレクター
+// スクレイプのたびにコミット済みオフセットとログの末尾から計算するため、削除されたトピックのメトリクスが残らない。
+type lagCollector struct {
+	*Config
+}
+
+// NewLagCollector: コンシューマーの遅延を公開する Prometheus のコレクターを作成する
+// 引数:
+//   - config: サーバーの設定（Offsets が nil の場合はメトリクスを送信しない）
+//
+// 戻り値:
+//   - prometheus.Collector: レジストリに登録するコレクター
+func NewLagCollector(config *Config) prometheus.Collector {
+	return &lagCollector{Config: config}
+}
+
+// Describe: コレクターが公開するメトリクスの定義を送信する
+func (c *lagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- consumerLagDesc
+}
+
+// Collect: グループとトピックごとの遅延を計算してメトリクスを送信する
+func (c *lagCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.Offsets == nil {
+		return
+	}
+	for _, lag := range c.consumerLags("", nil) {
+		ch <- prometheus.MustNewConstMetric(consumerLagDesc, prometheus.GaugeValue, float64(lag.Lag), lag.Group, lag.Topic)
+	}
+}
+
+// ヘルスチェックのメトリクスの定義
+var (
+	healthyDesc = prometheus.NewDesc(
+		"proglog_healthy", "Whether all self-checks passed (1) or any failed (0).", nil, nil,
+	)
+	healthCheckDesc = prometheus.NewDesc(
+		"proglog_health_check", "Whether the self-check passed (1) or failed (0).", []string{"check"}, nil,
+	)
+)
+
+// healthCollector: セルフチェックの結果を Prometheus のゲージとして公開するコレクター
+// チェックごとのゲージを公開するため、監視システムは特定の状態（ディスクの空き容量など）で警告できる。
+type healthCollector struct {
+	*Config
+}
+
+// NewHealthCollector: セルフチェックの結果を公開する Prometheus のコレクターを作成する
+// 引数:
+//   - config: サーバーの設定（Health が nil の場合はメトリクスを送信しない）
+//
+// 戻り値:
+//   - prometheus.Collector: レジストリに登録するコレクター
+func NewHealthCollector(config *Config) prometheus.Collector {
+	return &healthCollector{Config: config}
+}
+
+// Describe: コレクターが公開するメトリクスの定義を送信する
+func (c *healthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- healthyDesc
+	ch <- healthCheckDesc
+}
+
+// Collect: 最後に実行したセルフチェックの結果をメトリクスとして送信する
+func (c *healthCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.Health == nil {
+		return
+	}
+	st := c.Health.Status()
+	ch <- prometheus.MustNewConstMetric(healthyDesc, prometheus.GaugeValue, gauge(st.Healthy))
+	for _, r := range st.Results {
+		ch <- prometheus.MustNewConstMetric(healthCheckDesc, prometheus.GaugeValue, gauge(r.Err == nil), r.Name)
+	}
+}
+
+// gauge: 真偽値をゲージの値（1 または 0）に変換する
+func gauge(ok bool) float64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+// 識別子ごとの使用量の累計（direction は produced または consumed）
+var (
+	usageRecordsDesc = prometheus.NewDesc(
+		"proglog_usage_records_total", "Records produced or consumed by each client identity.", []string{"identity", "direction"}, nil,
+	)
+	usageBytesDesc = prometheus.NewDesc(
+		"proglog_usage_bytes_total", "Record bytes produced or consumed by each client identity.", []string{"identity", "direction"}, nil,
+	)
+)
+
+// usageCollector: 識別子ごとの使用量の累計を Prometheus のカウンターとして公開するコレクター
+type usageCollector struct {
+	*Config
+}
+
+// NewUsageCollector: 識別子ごとの使用量を公開する Prometheus のコレクターを作成する
+// 引数:
+//   - config: サーバーの設定（Usage が nil の場合はメトリクスを送信しない）
+//
+// 戻り値:
+//   - prometheus.Collector: レジストリに登録するコレクター
+func NewUsageCollector(config *Config) prometheus.Collector {
+	return &usageCollector{Config: config}
+}
+
+// Describe: コレクターが公開するメトリクスの定義を送信する
+func (c *usageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- usageRecordsDesc
+	ch <- usageBytesDesc
+}
+
+// Collect: 識別子ごとの使用量の累計を送信する
+func (c *usageCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.Usage == nil {
+		return
+	}
+	for identity, u := range c.Usage.Totals() {
+		ch <- prometheus.MustNewConstMetric(usageRecordsDesc, prometheus.CounterValue, float64(u.ProducedRecords), identity, "produced")
+		ch <- prometheus.MustNewConstMetric(usageRecordsDesc, prometheus.CounterValue, float64(u.ConsumedRecords), identity, "consumed")
+		ch <- prometheus.MustNewConstMetric(usageBytesDesc, prometheus.CounterValue, float64(u.ProducedBytes), identity, "produced")
+		ch <- prometheus.MustNewConstMetric(usageBytesDesc, prometheus.CounterValue, float64(u.ConsumedBytes), identity, "consumed")
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"github.com/kentakki416/proglog/internal/log"
+	"google.golang.org/grpc"
+)
+
+// messageOverheadBytes: 最大メッセージサイズを導出するときに、レコードの最大バイト数に加えるバイト数
+// リクエストやレスポンスのレコード以外のフィールド（トピック名、ヘッダー、Protocol Buffers のタグと長さ）に使用する。
+const messageOverheadBytes = 64 * 1024
+
+// maxMessageBytes: サーバーが送受信する gRPC メッセージの最大バイト数を返す（0 の場合は gRPC のデフォルト）
+// MaxMessageBytes が設定されていない場合は、CommitLog とトピックのレコードの最大バイト数の大きいほうから導出する
+// （バッチ全体のバイト数もレコードの最大バイト数以下に制限されている）。
+// レコードの上限より小さいメッセージの上限で、大きなレコードがトランスポート層の ResourceExhausted で失敗しないようにする。
+func (c *Config) maxMessageBytes() int {
+	if c.MaxMessageBytes != 0 {
+		return c.MaxMessageBytes
+	}
+	var limit uint64
+	if l, ok := c.CommitLog.(recordLimitLog); ok {
+		limit = l.MaxRecordBytes()
+	}
+	if c.Topics != nil {
+		topicLimit := c.Topics.Config.Segment.MaxRecordBytes
+		if topicLimit == 0 {
+			topicLimit = log.DefaultMaxRecordBytes
+		}
+		limit = max(limit, topicLimit)
+	}
+	if limit == 0 {
+		return 0
+	}
+	return int(limit + messageOverheadBytes)
+}
+
+// messageSizeOptions: 最大メッセージサイズの gRPC サーバーのオプションを作成する
+func (c *Config) messageSizeOptions() []grpc.ServerOption {
+	n := c.maxMessageBytes()
+	if n == 0 {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.MaxRecvMsgSize(n), grpc.MaxSendMsgSize(n)}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/offsets"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CommitOffset: コンシューマーグループがトピックのどこまで処理したかを記録する
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: コンシューマーグループ、トピック、コミットするオフセットを含むリクエスト
+//
+// 戻り値:
+//   - *api.CommitOffsetResponse: 空のレスポンス
+//   - error: エラーが発生した場合
+func (s *grpcServer) CommitOffset(ctx context.Context, req *api.CommitOffsetRequest) (*api.CommitOffsetResponse, error) {
+	if err := s.authorize(ctx, topicObject(req.Topic), consumeAction); err != nil {
+		return nil, err
+	}
+	if s.Offsets == nil {
+		return nil, errOffsetsDisabled
+	}
+	if req.Group == "" {
+		return nil, status.Error(codes.InvalidArgument, "group is required")
+	}
+	if err := s.Offsets.Commit(req.Group, req.Topic, req.Offset); err != nil {
+		return nil, err
+	}
+	return &api.CommitOffsetResponse{}, nil
+}
+
+// FetchOffset: コンシューマーグループがトピックにコミットしたオフセットを返す
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: コンシューマーグループとトピックを含むリクエスト
+//
+// 戻り値:
+//   - *api.FetchOffsetResponse: コミット済みオフセット
+//   - error: エラーが発生した場合（コミットされていない場合は codes.NotFound）
+func (s *grpcServer) FetchOffset(ctx context.Context, req *api.FetchOffsetRequest) (*api.FetchOffsetResponse, error) {
+	if err := s.authorize(ctx, topicObject(req.Topic), consumeAction); err != nil {
+		return nil, err
+	}
+	if s.Offsets == nil {
+		return nil, errOffsetsDisabled
+	}
+	off, err := s.Offsets.Fetch(req.Group, req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	return &api.FetchOffsetResponse{Offset: off}, nil
+}
+
+// ExportOffsets: すべてのコンシューマーグループのコミット済みオフセットを JSON で返す（管理操作）
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 空のリクエスト
+//
+// 戻り値:
+//   - *api.ExportOffsetsResponse: JSON 形式のコミット済みオフセット（offsets.Checkpoint）
+//   - error: エラーが発生した場合
+func (s *grpcServer) ExportOffsets(ctx context.Context, req *api.ExportOffsetsRequest) (*api.ExportOffsetsResponse, error) {
+	if err := s.authorize(ctx, objectWildcard, exportOffsetsAction); err != nil {
+		return nil, err
+	}
+	if s.Offsets == nil {
+		return nil, errOffsetsDisabled
+	}
+	b, err := json.Marshal(s.Offsets.Export())
+	if err != nil {
+		return nil, err
+	}
+	return &api.ExportOffsetsResponse{Checkpoint: b}, nil
+}
+
+// ImportOffsets: エクスポートしたコミット済みオフセットを取り込む（管理操作）
+// replace が true の場合は既存のオフセットをすべて置き換え、false の場合は含まれるものだけを上書きする。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: JSON 形式のコミット済みオフセットを含むリクエスト
+//
+// 戻り値:
+//   - *api.ImportOffsetsResponse: 取り込んだオフセットの数
+//   - error: エラーが発生した場合（JSON が不正な場合は codes.InvalidArgument）
+func (s *grpcServer) ImportOffsets(ctx context.Context, req *api.ImportOffsetsRequest) (*api.ImportOffsetsResponse, error) {
+	if err := s.authorize(ctx, objectWildcard, importOffsetsAction); err != nil {
+		return nil, err
+	}
+	if s.Offsets == nil {
+		return nil, errOffsetsDisabled
+	}
+	var c offsets.Checkpoint
+	if err := json.Unmarshal(req.Checkpoint, &c); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	n, err := s.Offsets.Import(c, req.Replace)
+	if err != nil {
+		return nil, err
+	}
+	return &api.ImportOffsetsResponse{Imported: uint64(n)}, nil
+}
+
+// errOffsetsDisabled: コミット済みオフセットのストアが設定されていないサーバーでオフセットを操作した場合のエラー
+var errOffsetsDisabled = status.Error(codes.FailedPrecondition, "committed offsets are not enabled on this server")
@@ -0,0 +1,87 @@
+package server
+
+import (
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/query"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Query: 絞り込み条件に一致するレコードをストリーミングで返す
+// サーバー側でセグメントを走査して条件を評価するため、調査のためにログ全体を
+// クライアントに転送する必要がない。ログの末尾に達するか、limit 件送信すると終了する。
+// 絞り込み条件の書式は query.Filter を参照。
+// 配信時刻（deliver_after）が来ていないレコードは、条件に一致しても読み飛ばす。
+// 引数:
+//   - req: 絞り込み条件、検索を開始するオフセット、最大件数（0 の場合は無制限）を含むリクエスト
+//   - stream: サーバーストリーム（クライアントに一致したレコードを送信）
+//
+// 戻り値:
+//   - error: エラーが発生した場合（絞り込み条件が不正な場合は codes.InvalidArgument）
+func (s *grpcServer) Query(req *api.QueryRequest, stream api.Log_QueryServer) error {
+	ctx := stream.Context()
+	if err := s.authorize(ctx, topicObject(req.Topic), consumeAction); err != nil {
+		return err
+	}
+
+	filter, err := query.Parse(req.Filter)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	clog, release, err := s.commitLog(req.Topic)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// 削除済みのオフセットから検索を開始しないようにする
+	off := req.FromOffset
+	if ll, ok := clog.(lowestOffsetLog); ok {
+		lowest, err := ll.LowestOffset()
+		if err != nil {
+			return err
+		}
+		if off < lowest {
+			off = lowest
+		}
+	}
+
+	// セグメントを順番に走査するため、先読みリーダーを使用する
+	read := clog.Read
+	if sl, ok := clog.(sequentialLog); ok {
+		read = sl.NewSequentialReader().Read
+	}
+
+	var sent uint64
+	for ; req.Limit == 0 || sent < req.Limit; off++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record, err := read(off)
+		if _, ok := err.(api.ErrOffsetOutOfRange); ok {
+			// ログの末尾に達した
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// 配信時刻（deliver_after）が来ていないレコードは、Consume と同じように返さない
+		if s.checkDelivery(record) != nil {
+			continue
+		}
+		// Consume と同じように書き換えとマスキングをしてから評価する（マスキングした値では絞り込めない）
+		res, err := s.consumeResponse(record)
+		if err != nil {
+			return err
+		}
+		if !filter.Match(res.Record) {
+			continue
+		}
+		if err := stream.Send(&api.QueryResponse{Record: res.Record}); err != nil {
+			return err
+		}
+		sent++
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"crypto/x509"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// receipt: 追加したレコードの署名付きの受領証を作成する
+// 重複排除で以前のレコードのオフセットを返した場合も正しい受領証になるように、
+// リクエストのレコードではなく、ログストアに保存されたレコードから checksum を計算する。
+// 書き込みは完了しているため、失敗した場合はオフセットを含めた codes.Internal を返す。
+// 引数:
+//   - clog: レコードを追加したログストア
+//   - topic: レコードを追加したトピック
+//   - offset: 割り当てられたオフセット
+//
+// 戻り値:
+//   - *api.ProduceReceipt: 署名した受領証
+//   - error: 受領証を作成できなかった場合
+func (s *grpcServer) receipt(clog CommitLog, topic string, offset uint64) (*api.ProduceReceipt, error) {
+	receipt, err := s.newReceipt(clog, topic, offset)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal,
+			"record appended at offset %d but the receipt could not be created: %v", offset, err)
+	}
+	return receipt, nil
+}
+
+// newReceipt: 受領証を作成して署名する（内部関数）
+func (s *grpcServer) newReceipt(clog CommitLog, topic string, offset uint64) (*api.ProduceReceipt, error) {
+	record, err := clog.Read(offset)
+	if err != nil {
+		return nil, err
+	}
+	checksum, err := api.RecordChecksum(record)
+	if err != nil {
+		return nil, err
+	}
+	receipt := &api.ProduceReceipt{
+		Topic:     topic,
+		Offset:    offset,
+		Checksum:  checksum,
+		Timestamp: s.clock().Now().UnixMilli(),
+		NodeId:    s.NodeID,
+	}
+	if err = api.SignReceipt(receipt, s.ReceiptKey); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// receiptPublicKey: 受領証を検証する公開鍵を PKIX（DER）形式で返す（受領証が無効の場合は nil）
+func (c *Config) receiptPublicKey() []byte {
+	if c.ReceiptKey == nil {
+		return nil
+	}
+	b, err := x509.MarshalPKIXPublicKey(c.ReceiptKey.Public())
+	if err != nil {
+		return nil
+	}
+	return b
+}
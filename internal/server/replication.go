@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	replpb "github.com/kentakki416/proglog/api/replication/v1"
+	api "github.com/kentakki416/proglog/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// replicationServer: ノード間の複製の API（replication.v1.Replication）の実装
+// 公開の Log サービスとは別のサービスにして、クライアント向けの ACL の影響を受けないようにする。
+type replicationServer struct {
+	replpb.UnimplementedReplicationServer
+	srv *grpcServer
+
+	mu    sync.Mutex        // 追加とタームの更新を直列化する
+	terms map[string]uint64 // トピックごとのこれまでに受け付けた最大のターム（空文字はデフォルトのログストア）
+}
+
+// newReplicationServer: Config.Replication.TermsFile からタームを復元して、複製の API を作成する（内部関数）
+func newReplicationServer(srv *grpcServer) (*replicationServer, error) {
+	r := &replicationServer{srv: srv, terms: make(map[string]uint64)}
+	b, err := os.ReadFile(srv.Replication.TermsFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &r.terms); err != nil {
+		return nil, fmt.Errorf("read replication terms: %w", err)
+	}
+	return r, nil
+}
+
+// writeTerms: タームをファイルに書き込む（内部関数）
+// 一時ファイルに書き込んでからリネームすることで、書き込み途中でクラッシュしても壊れたファイルが残らないようにする。
+// 呼び出し側で r.mu のロックを取得しておく必要がある。
+func (r *replicationServer) writeTerms() error {
+	b, err := json.Marshal(r.terms)
+	if err != nil {
+		return err
+	}
+	path := r.srv.Replication.TermsFile
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// authorizePeer: 呼び出し元が Config.Replication.Peers に含まれるノードかを確認する
+// Peers が空の場合はすべての接続を拒否する。
+func (r *replicationServer) authorizePeer(ctx context.Context) error {
+	if slices.Contains(r.srv.Replication.Peers, subject(ctx)) {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "%q is not a replication peer", subject(ctx))
+}
+
+// ReplicateEntries: リーダーが割り当てたオフセットのままレコードを追加する
+// ACL のルールを記録する内部のトピック（acl.DefaultTopic）にも追加でき、フォロワーの acl.Policy が
+// 追加されたルールの変更を反映する（クライアントからの書き込みは writableLog で拒否する）。
+// 古いタームのリクエストは、交代した古いリーダーからの書き込みとみなして codes.FailedPrecondition で拒否する。
+// 次のオフセットより前のレコードは再送とみなして読み飛ばし、後ろのレコード（間が空いている場合）は
+// api.ErrOffsetMismatch で拒否する（レスポンスやエラーの次のオフセットから送り直してもらう）。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: ターム、書き込み先のトピック、オフセット付きのレコード
+//
+// 戻り値:
+//   - *replpb.ReplicateEntriesResponse: 追加した後の次のオフセットと、受け付けた最大のターム
+//   - error: エラーが発生した場合
+func (r *replicationServer) ReplicateEntries(ctx context.Context, req *replpb.ReplicateEntriesRequest) (*replpb.ReplicateEntriesResponse, error) {
+	if err := r.authorizePeer(ctx); err != nil {
+		return nil, err
+	}
+	clog, release, err := r.srv.commitLog(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	al, ok := clog.(appendAtLog)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "log does not support appending at an offset")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	term := r.terms[req.Topic]
+	if req.Term < term {
+		return nil, status.Errorf(codes.FailedPrecondition, "stale term %d (current term %d)", req.Term, term)
+	}
+	if req.Term > term {
+		// レコードを追加する前に保存する（再起動後に古いタームの書き込みを受け付けないように）
+		r.terms[req.Topic] = req.Term
+		if err := r.writeTerms(); err != nil {
+			r.terms[req.Topic] = term
+			return nil, status.Errorf(codes.Internal, "persist replication term: %v", err)
+		}
+	}
+
+	for _, record := range req.Records {
+		_, err := al.AppendAt(record, record.Offset)
+		if mismatch, ok := err.(api.ErrOffsetMismatch); ok && record.Offset < mismatch.Expected {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	res := &replpb.ReplicateEntriesResponse{Term: r.terms[req.Topic]}
+	if el, ok := clog.(endOffsetLog); ok {
+		res.NextOffset = el.NextOffset()
+	}
+	return res, nil
+}
+
+// FetchSegment: 書き込み済みセグメントのファイルをチャンクに分割してストリーミングで送信する
+// セグメントファイルには ExportRedactor や配信時刻の前のレコードもそのまま含まれるため、複製元のノード（Peers）だけに送信する。
+func (r *replicationServer) FetchSegment(req *replpb.FetchSegmentRequest, stream grpc.ServerStreamingServer[api.SegmentChunk]) error {
+	if err := r.authorizePeer(stream.Context()); err != nil {
+		return err
+	}
+	clog, release, err := r.srv.commitLog(req.Topic)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return sendSegments(stream, clog, req.FromOffset)
+}
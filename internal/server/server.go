@@ -2,9 +2,41 @@ package server
 
 import (
 	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	adminpb "github.com/kentakki416/proglog/api/admin/v1"
+	replpb "github.com/kentakki416/proglog/api/replication/v1"
 	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/acl"
+	// クライアントが圧縮したリクエストを展開できるように、コンプレッサーを登録する
+	_ "github.com/kentakki416/proglog/internal/compression"
+	"github.com/kentakki416/proglog/internal/config"
+	"github.com/kentakki416/proglog/internal/health"
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/kentakki416/proglog/internal/offsets"
+	"github.com/kentakki416/proglog/internal/redact"
+	"github.com/kentakki416/proglog/internal/sessions"
+	"github.com/kentakki416/proglog/internal/upcast"
+	"github.com/kentakki416/proglog/internal/usage"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // CommitLog: ログストアへの読み書きを行うインターフェース
@@ -13,12 +45,364 @@ import (
 type CommitLog interface {
 	Append(*api.Record) (uint64, error) // レコードをログに追加し、割り当てられたオフセットを返す
 	Read(uint64) (*api.Record, error)   // 指定されたオフセットのレコードを読み取る
+	Truncate(uint64) error              // 指定されたオフセット以下のレコードのみを含むセグメントを削除する
+}
+
+// Authorizer: クライアント（サブジェクト）が操作（アクション）を実行できるかを判定するインターフェース
+// 認可に失敗した場合はエラーを返す。
+type Authorizer interface {
+	Authorize(subject, object, action string) error
+}
+
+// ACL で使用するオブジェクトとアクション
+const (
+	objectWildcard       = "*"                // ログ全体（トピックを指定しない操作）を表すオブジェクト
+	produceAction        = "produce"          // レコードの書き込み
+	consumeAction        = "consume"          // レコードの読み取り
+	truncateAction       = "truncate"         // 管理操作: 古いレコードの削除
+	createTopicAction    = "create_topic"     // 管理操作: トピックの作成
+	deleteTopicAction    = "delete_topic"     // 管理操作: トピックの削除
+	listTopicsAction     = "list_topics"      // 管理操作: トピックの一覧
+	undeleteTopicAction  = "undelete_topic"   // 管理操作: 削除したトピックの復元
+	getLogInfoAction     = "get_log_info"     // 管理操作: セグメントの統計情報の取得
+	exportOffsetsAction  = "export_offsets"   // 管理操作: コミット済みオフセットのエクスポート
+	importOffsetsAction  = "import_offsets"   // 管理操作: コミット済みオフセットのインポート
+	exportAction         = "export"           // 管理操作: ログの範囲のファイルへのエクスポート
+	getConsumerLagAction = "get_consumer_lag" // 管理操作: コンシューマーの遅延の取得
+	flushLogAction       = "flush_log"        // 管理操作: ログのディスクへの同期
+	getUsageAction       = "get_usage"        // 管理操作: クライアントごとの使用量の取得
+	listACLsAction       = "list_acls"        // 管理操作: ACL のルールの一覧
+	addACLAction         = "add_acl"          // 管理操作: ACL のルールの追加
+	removeACLAction      = "remove_acl"       // 管理操作: ACL のルールの削除
+	replicateAction      = "replicate"        // 割り当て済みのオフセットでのレコードの書き込み（複製）
+)
+
+// sequentialLog: 順次読み取り用の先読みリーダーを作成できるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、
+// ConsumeStream はストリームごとに先読みリーダーを作成して読み取りに使用する。
+type sequentialLog interface {
+	NewSequentialReader() *log.SequentialReader
+}
+
+// notifyingLog: 新しいレコードの追加を通知できるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、
+// ロングポーリングの Consume や ConsumeStream はポーリングせずに新しいレコードを待てる。
+type notifyingLog interface {
+	Appended() <-chan struct{}
+}
+
+// pollInterval: 追加を通知できないログストアで、新しいレコードを確認する間隔
+const pollInterval = 10 * time.Millisecond
+
+// segmentLog: 書き込み済みセグメントを公開できるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、
+// replication.v1.Replication の FetchSegment でセグメントファイルをそのまま転送できる。
+type segmentLog interface {
+	SealedSegments(from uint64) []log.SealedSegment
+}
+
+// batchLog: 複数のレコードを1つのバッチとして追加できるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、ProduceBatch を使用できる。
+type batchLog interface {
+	AppendBatch([]*api.Record) (uint64, error)
+}
+
+// statsLog: セグメントの統計情報を公開できるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、
+// GetLogInfo やメトリクスでセグメントごとの統計情報を返せる。
+type statsLog interface {
+	SegmentStats() ([]log.SegmentStat, error)
+}
+
+// lowestOffsetLog: 保持している最小のオフセットを返せるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、
+// Query は削除済みのオフセットを読み飛ばして、残っている最小のオフセットから検索する。
+type lowestOffsetLog interface {
+	LowestOffset() (uint64, error)
+}
+
+// locatingLog: レコードが保存されている場所を返せるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、
+// verbose を指定した Produce はレコードを含むセグメントとストア内の位置を返す。
+type locatingLog interface {
+	Locate(off uint64) (baseOffset, pos uint64, err error)
+}
+
+// sizedLog: 保持しているレコード数とバイト数を返せるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、GetLogInfo とメトリクスに合計を含める。
+type sizedLog interface {
+	Size() (records uint64, bytes uint64)
+}
+
+// writeStatsLog: ディスクに書き込んだバイト数の累計を返せるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、GetLogInfo とメトリクスに書き込み量を含める。
+type writeStatsLog interface {
+	WriteStats() log.WriteStats
+}
+
+// recordLimitLog: 1レコードの最大バイト数を返せるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、GetCapabilities で上限をクライアントに知らせる。
+type recordLimitLog interface {
+	MaxRecordBytes() uint64
+}
+
+// epochLog: オフセットのエポックを返せるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、HTTP ゲートウェイのカーソルに
+// エポックを含め、Reset の前に発行したカーソルを検出できる。
+type epochLog interface {
+	Epoch() uint64
+}
+
+// appendAtLog: 指定されたオフセットにレコードを追加できるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、replicate を指定した Produce を使用できる。
+type appendAtLog interface {
+	AppendAt(record *api.Record, off uint64) (uint64, error)
+}
+
+// flushingLog: 書き込んだデータをディスクに同期できるログストア
+// CommitLog がこのインターフェースも実装している場合（例: log.Log）、FlushLog で同期できる。
+type flushingLog interface {
+	Flush() error
+}
+
+// segmentChunkSize: FetchSegment で1つのメッセージに含めるファイルデータの最大バイト数
+const segmentChunkSize = 64 * 1024
+
+// castagnoli: セグメントのチャンクのチェックサムに使用する CRC32 テーブル
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ReadBarrier: 線形化可能な読み取り（LINEARIZABLE）を保証するためのインターフェース
+// 複製されたログでは、リーダーであることをクォーラムで確認して読み取りインデックスを取得し、
+// そのインデックスまでローカルのログに適用されるまで待つ（Raft の read-index）。
+// リーダーでない場合などはエラーを返す。
+type ReadBarrier interface {
+	Barrier(ctx context.Context) error
+}
+
+// KeyValueView: キーごとの最新の値を返すビュー（例: views.KeyValue）
+// キーが存在しない場合は api.ErrKeyNotFound を返す。
+type KeyValueView interface {
+	Get(key []byte) (value []byte, offset uint64, err error)
 }
 
 // Config: gRPC サーバーの設定
 // サーバーが使用するログストア（CommitLog）を保持する。
 type Config struct {
-	CommitLog CommitLog // ログストアの実装（例: log.Log）
+	CommitLog  CommitLog  // ログストアの実装（例: log.Log）
+	Authorizer Authorizer // 認可の実装（nil の場合は TruncateLog 以外のすべての操作を許可する）
+	// 実行中に変更できる ACL のポリシー（nil の場合、管理 API の ACL の操作は失敗する）
+	// 管理 API でルールを変更するだけで、認可には使用しない。認可に使用する場合は Authorizer にも設定する
+	// （判定をキャッシュする場合は NewCachingAuthorizer で包む。ルールを変更したときにキャッシュを捨てるように、
+	// NewGRPCServer が ACL.OnChange に Invalidate を登録する）。
+	ACL *acl.Policy
+	// 線形化可能な読み取りの実装（nil の場合、ローカルのログが唯一のコピーなので読み取りは常に線形化可能）
+	ReadBarrier ReadBarrier
+	// トピックの管理（nil の場合、トピックを指定したリクエストは失敗する）
+	// トピックを指定しないリクエストは CommitLog を使用する。
+	Topics *log.Topics
+	// キーごとの最新の値を返すビュー（nil の場合、GetValue は失敗する）
+	View KeyValueView
+	// コンシューマーグループのコミット済みオフセットのストア（nil の場合、オフセットの操作は失敗する）
+	Offsets *offsets.Store
+	// レコードのタイムスタンプに使用する時計（nil の場合は log.SystemClock）
+	Clock log.Clock
+	// クライアントのセッションのレジストリ（nil の場合、セッションの操作は失敗する）
+	Sessions *sessions.Registry
+	// セルフチェックのモニター（nil の場合、gRPC のヘルスチェックサービスを登録しない）
+	Health *health.Monitor
+	// Produce のリクエストの gRPC メタデータからレコードのヘッダーにコピーするキーの許可リスト（例: トレース ID、テナント ID）
+	// データの出どころをレコードと一緒に残すために使用する。レコードに同じ名前のヘッダーがある場合はコピーしない。
+	PropagateMetadata []string
+	// 書き込む前にレコードの個人情報などをマスキングする（nil の場合はマスキングしない）
+	ProduceRedactor *redact.Redactor
+	// クラスタの外に返す前にレコードをマスキングする（nil の場合はマスキングしない）
+	// Consume、ConsumeStream、Query、HTTP ゲートウェイで返すレコード（proglogctl の export jsonl を含む）と、
+	// StartExport でファイルに書き出すレコードのすべてに適用する。
+	// ログには元のレコードを残したまま、クラスタの外に出るデータだけをマスキングする場合に使用する。
+	ExportRedactor *redact.Redactor
+	// Consume で返す前に古いスキーマのバージョンのレコードを最新のバージョンに書き換える（nil の場合は書き換えない）
+	Upcasts *upcast.Registry
+	// StartExport でエクスポートしたファイルの出力先のディレクトリ（空の場合、エクスポートは失敗する）
+	ExportDir string
+	// 完了したエクスポートのジョブの状態を GetExport で確認できる時間（0 の場合は DefaultExportJobTTL）
+	// 期限を過ぎたジョブの状態は捨てる（エクスポートしたファイルは削除しない）。
+	ExportJobTTL time.Duration
+	// 状態を保持するエクスポートのジョブの数の上限（0 の場合は DefaultExportJobs）
+	// 上限に達した場合は完了した古いジョブから捨て、実行中のジョブだけで上限に達している場合は
+	// StartExport を codes.ResourceExhausted で拒否する。
+	MaxExportJobs int
+	// このサーバーの識別子（verbose を指定した Produce のレスポンスと受領証に含める）
+	NodeID string
+	// Produce の受領証に署名する鍵（nil の場合、受領証を要求した Produce は失敗する）
+	// Ed25519、ECDSA、RSA の鍵を使用でき、TLS のサーバー証明書の鍵を使い回すこともできる。
+	// プロデューサーは署名付きの受領証を保存し、レコードがそのオフセットで受け付けられたことを後から証明できる。
+	ReceiptKey crypto.Signer
+	// ログのレコードの Merkle ツリーの署名付きのツリーヘッド（nil の場合、GetTreeHead は失敗する）
+	TreeHeads TreeHeadSource
+	// クライアントの識別子ごとの書き込みと読み取りの量の集計（nil の場合は集計せず、GetUsage は失敗する）
+	Usage *usage.Tracker
+	// RPC ごとのアクセスログの設定（Writer と Topic のどちらも設定されていない場合は記録しない）
+	// 識別子、トピック、オフセット、バイト数、レイテンシを記録し、トラフィックの分析や課金に使用する。
+	AccessLog struct {
+		// エントリを JSON Lines 形式で書き込む先（ファイルなど、書き込みはサーバーが直列化する）
+		Writer io.Writer
+		// エントリを JSON のレコードとして追加するトピック（Topics で作成しておく必要がある）
+		// このトピックに対する RPC（アクセスログの読み取りなど）は記録しない。
+		Topic string
+		// 成功した RPC のうち記録する割合（0 より大きく 1 未満の場合にサンプリングし、それ以外はすべて記録する）
+		// 失敗した RPC は調査に必要なため、割合にかかわらず常に記録する。
+		SampleRate float64
+	}
+	// ノード間の複製の API（replication.v1.Replication）の設定
+	// 公開の ACL やクライアント向けの制限とは別に、複製元のノードだけを Peers で認証する。
+	Replication struct {
+		// true の場合だけ複製の API を登録する
+		Enabled bool
+		// 複製の API を呼び出せるノードの識別子（クライアント証明書のサブジェクト）
+		// Enabled の場合は必須（空の場合はすべての接続を拒否する）。TLS のクライアント証明書と組み合わせて設定する。
+		Peers []string
+		// トピックごとに受け付けた最大のタームを保存するファイルのパス（Enabled の場合は必須）
+		// 再起動後も、交代した古いリーダーからの書き込みを拒否できるようにする。
+		TermsFile string
+	}
+	// クライアントのリトライやバックオフを検証するための故障の注入（テスト用）
+	// Enabled を明示的に true にした場合だけ注入する。
+	Faults struct {
+		// true の場合だけ故障を注入する（本番の設定で誤って有効にならないように、他の項目だけでは有効にしない）
+		Enabled bool
+		// 対象の RPC の完全なメソッド名（例: /log.v1.Log/Produce、空の場合はすべての RPC）
+		Methods []string
+		// RPC を処理する前に待つ時間と、それに加えるランダムな時間の上限
+		Latency time.Duration
+		Jitter  time.Duration
+		// エラーを返す割合（0〜1）と返すステータスコード（OK の場合は Unavailable）
+		ErrorRate float64
+		ErrorCode codes.Code
+		// 接続をリセットする割合（0〜1）
+		// サーバーに NewFaultListener で作成したリスナーを渡し、Listener にも同じリスナーを設定する必要がある。
+		ResetRate float64
+		Listener  *FaultListener
+	}
+	// 接続の keepalive と寿命の設定（0 の項目は gRPC のデフォルトを使用する）
+	Keepalive struct {
+		// 接続が確立してからこの時間が経過したら GOAWAY を送って接続を閉じる（0 の場合は無制限）
+		// 長時間のストリームを持つクライアントにも再接続させ、ロードバランサーで負荷が偏らないようにする。
+		MaxConnectionAge time.Duration
+		// MaxConnectionAge の経過後、実行中の RPC の完了を待つ時間（0 の場合は無制限）
+		MaxConnectionAgeGrace time.Duration
+		// RPC のない状態がこの時間続いたら接続を閉じる（0 の場合は無制限）
+		MaxConnectionIdle time.Duration
+		// 通信のない状態がこの時間続いたら、クライアントが生きているかを ping で確認する（0 の場合は2時間）
+		Time time.Duration
+		// ping の応答をこの時間待っても返らない場合は、クライアントが停止したとみなして接続を閉じる（0 の場合は20秒）
+		Timeout time.Duration
+		// クライアントが keepalive の ping を送れる最小の間隔（これより頻繁に送るクライアントは切断する、0 の場合は5分）
+		MinTime time.Duration
+		// RPC がない接続でもクライアントが keepalive の ping を送ることを許可するかどうか
+		PermitWithoutStream bool
+	}
+	// トランスポートの認証情報（nil の場合は NewGRPCServer の grpcOpts で指定した認証情報、指定しない場合は平文）
+	// ALTS や SPIRE などの TLS 以外の認証方式を使用する場合に設定する。Subject(credentials.AuthInfo) string も
+	// 実装している場合は、接続の認証情報からクライアントのサブジェクトを取り出すのに使用する（空の場合は TLS のクライアント証明書から取り出す）。
+	Credentials config.CredentialsProvider
+	// 送受信する gRPC メッセージの最大バイト数（0 の場合はログストアのレコードの最大バイト数から導出する）
+	// 導出した値は、最大のレコード（またはバッチ）を含むメッセージを送受信できる大きさになる。
+	MaxMessageBytes int
+}
+
+// clock: 設定された時計を返す（設定されていない場合は log.SystemClock）
+func (c *Config) clock() log.Clock {
+	if c.Clock == nil {
+		return log.SystemClock
+	}
+	return c.Clock
+}
+
+// Validate: サーバーの設定の不変条件を確認する
+// NewGRPCServer が最初に呼び出し、誤った設定のサーバーを起動せずにすぐに失敗する
+// （例えば、故障の注入の割合の誤りや、アクセスログのトピックに必要な Topics の設定漏れ）。
+// 戻り値:
+//   - error: 満たしていない条件をすべてまとめたエラー（すべて満たしている場合は nil）
+func (c *Config) Validate() error {
+	var errs []error
+	if c.AccessLog.Topic != "" && c.Topics == nil {
+		errs = append(errs, fmt.Errorf("AccessLog.Topic %q requires Topics to be set", c.AccessLog.Topic))
+	}
+	if f := c.Faults; f.Enabled {
+		for name, rate := range map[string]float64{"Faults.ErrorRate": f.ErrorRate, "Faults.ResetRate": f.ResetRate} {
+			if rate < 0 || rate > 1 {
+				errs = append(errs, fmt.Errorf("%s must be between 0 and 1: %v", name, rate))
+			}
+		}
+		if f.ResetRate > 0 && f.Listener == nil {
+			errs = append(errs, errors.New("Faults.ResetRate requires Faults.Listener (wrap the server's listener with NewFaultListener)"))
+		}
+		if f.Latency < 0 || f.Jitter < 0 {
+			errs = append(errs, fmt.Errorf("Faults.Latency and Faults.Jitter must not be negative: %v, %v", f.Latency, f.Jitter))
+		}
+	}
+	k := c.Keepalive
+	for name, d := range map[string]time.Duration{
+		"Keepalive.MaxConnectionAge":      k.MaxConnectionAge,
+		"Keepalive.MaxConnectionAgeGrace": k.MaxConnectionAgeGrace,
+		"Keepalive.MaxConnectionIdle":     k.MaxConnectionIdle,
+		"Keepalive.Time":                  k.Time,
+		"Keepalive.Timeout":               k.Timeout,
+		"Keepalive.MinTime":               k.MinTime,
+	} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative: %v", name, d))
+		}
+	}
+	if c.MaxMessageBytes < 0 {
+		errs = append(errs, fmt.Errorf("MaxMessageBytes must not be negative: %d", c.MaxMessageBytes))
+	} else if c.MaxMessageBytes > 0 {
+		// 最大のレコードを含むメッセージを送受信できない上限は、大きなレコードを ResourceExhausted で失敗させる
+		derived := *c
+		derived.MaxMessageBytes = 0
+		if l, ok := c.CommitLog.(recordLimitLog); ok && uint64(c.MaxMessageBytes) < l.MaxRecordBytes() {
+			errs = append(errs, fmt.Errorf(
+				"MaxMessageBytes %d is less than the log's MaxRecordBytes %d (leave it 0 to derive %d)",
+				c.MaxMessageBytes, l.MaxRecordBytes(), derived.maxMessageBytes()))
+		}
+	}
+	if c.Replication.Enabled {
+		if len(c.Replication.Peers) == 0 {
+			errs = append(errs, errors.New("Replication.Peers is required when replication is enabled"))
+		}
+		if c.Replication.TermsFile == "" {
+			errs = append(errs, errors.New("Replication.TermsFile is required when replication is enabled"))
+		}
+	}
+	// ExportDir はエクスポートの開始時に作成するため、存在しなくてもよい
+	if fi, err := os.Stat(c.ExportDir); c.ExportDir != "" && err == nil && !fi.IsDir() {
+		errs = append(errs, fmt.Errorf("ExportDir %q is not a directory", c.ExportDir))
+	}
+	return errors.Join(errs...)
+}
+
+// keepaliveOptions: keepalive の設定から gRPC サーバーのオプションを作成する
+// 何も設定されていない場合は、呼び出し元が渡したオプション（または gRPC のデフォルト）を上書きしないようにオプションを返さない。
+func (c *Config) keepaliveOptions() []grpc.ServerOption {
+	k := c.Keepalive
+	var opts []grpc.ServerOption
+	if k.MaxConnectionAge != 0 || k.MaxConnectionAgeGrace != 0 || k.MaxConnectionIdle != 0 ||
+		k.Time != 0 || k.Timeout != 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionAge:      k.MaxConnectionAge,
+			MaxConnectionAgeGrace: k.MaxConnectionAgeGrace,
+			MaxConnectionIdle:     k.MaxConnectionIdle,
+			Time:                  k.Time,
+			Timeout:               k.Timeout,
+		}))
+	}
+	if k.MinTime != 0 || k.PermitWithoutStream {
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             k.MinTime,
+			PermitWithoutStream: k.PermitWithoutStream,
+		}))
+	}
+	return opts
 }
 
 // grpcServer が api.LogServer インターフェースを実装していることをコンパイル時に確認
@@ -30,10 +414,16 @@ var _ api.LogServer = (*grpcServer)(nil)
 type grpcServer struct {
 	api.UnimplementedLogServer // 未実装のメソッドのデフォルト実装（後方互換性のため）
 	*Config                    // サーバーの設定（埋め込みにより Config のフィールドに直接アクセス可能）
+
+	// 書き込みにかかる時間の移動平均（ナノ秒）
+	// クライアントの期限までに書き込みが終わる見込みがあるかを判定するために使用する。
+	appendLatency atomic.Int64
+
+	exports exportJobs // StartExport で開始したエクスポートのジョブ
 }
 
 // NewGRPCServer: 新しい gRPC サーバーを作成する
-// gRPC サーバーを初期化し、Log サービスを登録する。
+// gRPC サーバーを初期化し、Log サービスと管理 API（admin.v1.Admin）を登録する。
 // 引数:
 //   - config: サーバーの設定（ログストアなど）
 //
@@ -41,6 +431,53 @@ type grpcServer struct {
 //   - *grpc.Server: 初期化された gRPC サーバー
 //   - error: エラーが発生した場合
 func NewGRPCServer(config *Config, grpcOpts ...grpc.ServerOption) (*grpc.Server, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid server config: %w", err)
+	}
+	// ルールを変更したら、キャッシュした判定が TTL の間使われ続けないように捨てる
+	if cache, ok := config.Authorizer.(*CachingAuthorizer); ok && config.ACL != nil {
+		config.ACL.OnChange(cache.Invalidate)
+	}
+	if config.Credentials != nil {
+		creds, err := config.Credentials.TransportCredentials()
+		if err != nil {
+			return nil, fmt.Errorf("transport credentials: %w", err)
+		}
+		grpcOpts = append(grpcOpts, grpc.Creds(creds))
+	}
+	// 接続の認証情報からサブジェクトを取り出してコンテキストに設定するインターセプターを追加
+	unaryAuth, streamAuth := config.authInterceptors()
+	grpcOpts = append(grpcOpts,
+		grpc.ChainUnaryInterceptor(unaryAuth),
+		grpc.ChainStreamInterceptor(streamAuth),
+	)
+	// アクセスログはサブジェクトを記録するため、認証のインターセプターの後に追加する
+	if unary, stream := config.accessLogInterceptors(); unary != nil {
+		grpcOpts = append(grpcOpts,
+			grpc.ChainUnaryInterceptor(unary),
+			grpc.ChainStreamInterceptor(stream),
+		)
+	}
+	// 使用量の集計も識別子を使うため、認証のインターセプターの後に追加する
+	if unary, stream := config.usageInterceptors(); unary != nil {
+		grpcOpts = append(grpcOpts,
+			grpc.ChainUnaryInterceptor(unary),
+			grpc.ChainStreamInterceptor(stream),
+		)
+	}
+	// 注入したエラーもアクセスログに記録されるように、故障の注入は最後に追加する
+	if unary, stream := config.faultInterceptors(); unary != nil {
+		grpcOpts = append(grpcOpts,
+			grpc.ChainUnaryInterceptor(unary),
+			grpc.ChainStreamInterceptor(stream),
+		)
+	}
+
+	// keepalive と接続の寿命の設定を追加
+	grpcOpts = append(grpcOpts, config.keepaliveOptions()...)
+	// 呼び出し元が最大メッセージサイズを指定した場合はそちらを優先するため、先頭に追加する
+	grpcOpts = append(config.messageSizeOptions(), grpcOpts...)
+
 	// 新しい gRPC サーバーインスタンスを作成
 	gsrv := grpc.NewServer(grpcOpts...)
 
@@ -50,8 +487,19 @@ func NewGRPCServer(config *Config, grpcOpts ...grpc.ServerOption) (*grpc.Server,
 		return nil, err
 	}
 
-	// Log サービスを gRPC サーバーに登録
+	// Log サービスと管理 API を gRPC サーバーに登録
 	api.RegisterLogServer(gsrv, srv)
+	adminpb.RegisterAdminServer(gsrv, &adminServer{srv: srv})
+	if config.Replication.Enabled {
+		repl, err := newReplicationServer(srv)
+		if err != nil {
+			return nil, err
+		}
+		replpb.RegisterReplicationServer(gsrv, repl)
+	}
+	if config.Health != nil {
+		healthpb.RegisterHealthServer(gsrv, &healthServer{monitor: config.Health})
+	}
 	return gsrv, nil
 }
 
@@ -64,11 +512,15 @@ func NewGRPCServer(config *Config, grpcOpts ...grpc.ServerOption) (*grpc.Server,
 //   - error: エラーが発生した場合
 func newgrpcServer(config *Config) (srv *grpcServer, err error) {
 	srv = &grpcServer{Config: config}
+	srv.exports.jobs = make(map[string]*api.ExportJob)
+	srv.exports.finished = make(map[string]time.Time)
 	return srv, nil
 }
 
 // Produce: レコードをログに追加する（単一リクエスト）
 // クライアントから送信されたレコードをログストアに追加し、割り当てられたオフセットを返す。
+// クライアントの期限までに書き込みが終わる見込みがない場合は、書き込まずに codes.DeadlineExceeded を返す。
+// verbose が指定された場合は、レコードを含むセグメントの baseOffset、ストア内の位置、ノードの ID も返す。
 // 引数:
 //   - ctx: リクエストのコンテキスト（キャンセル、タイムアウトなど）
 //   - req: 追加するレコードを含むリクエスト
@@ -77,17 +529,171 @@ func newgrpcServer(config *Config) (srv *grpcServer, err error) {
 //   - *api.ProduceResponse: 割り当てられたオフセットを含むレスポンス
 //   - error: エラーが発生した場合
 func (s *grpcServer) Produce(ctx context.Context, req *api.ProduceRequest) (*api.ProduceResponse, error) {
+	// クライアントが書き込みを許可されているかを確認
+	if err := s.authorize(ctx, topicObject(req.Topic), produceAction); err != nil {
+		return nil, err
+	}
+	// オフセットはサーバーが割り当てる（複製の場合だけ指定できる）
+	if err := s.checkRecordOffsets(ctx, req.Topic, req.Replicate, req.Record); err != nil {
+		return nil, err
+	}
+
+	// 書き込み先のトピックのログストアを取得
+	clog, release, err := s.writableLog(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	al, ok := clog.(appendAtLog)
+	if req.Replicate && !ok {
+		return nil, status.Error(codes.Unimplemented, "log does not support appending at an offset")
+	}
+	if req.Receipt && s.ReceiptKey == nil {
+		return nil, status.Error(codes.FailedPrecondition, "produce receipts are not enabled on this server")
+	}
+
+	if req.Record != nil {
+		s.prepareRecord(ctx, req.Record, s.clock().Now().UnixMilli())
+	}
+
+	// 期限までに書き込みが終わる見込みがない場合は、書き込む前に失敗させる
+	// 書き込みを始めた後は期限を過ぎても完了させ、書き込まれたかどうかが曖昧にならないようにする
+	if err := s.checkDeadline(ctx); err != nil {
+		return nil, err
+	}
+
 	// ログストアにレコードを追加
-	offset, err := s.CommitLog.Append(req.Record)
+	start := time.Now()
+	var offset uint64
+	if req.Replicate {
+		offset, err = al.AppendAt(req.Record, req.Record.GetOffset())
+	} else {
+		offset, err = clog.Append(req.Record)
+	}
 	if err != nil {
 		return nil, err
 	}
+	s.observeAppend(ctx, time.Since(start))
 	// 割り当てられたオフセットを返す
-	return &api.ProduceResponse{Offset: offset}, nil
+	res := &api.ProduceResponse{Offset: offset}
+	if req.Verbose {
+		// 書き込みは完了しているため、場所を取得できなくても失敗させない
+		res.NodeId = s.NodeID
+		if ll, ok := clog.(locatingLog); ok {
+			res.SegmentBaseOffset, res.Position, _ = ll.Locate(offset)
+		}
+	}
+	if req.Receipt {
+		if res.Receipt, err = s.receipt(clog, req.Topic, offset); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// checkRecordOffsets: クライアントが指定したレコードのオフセットを検証する
+// 通常の書き込みではオフセットはサーバーが割り当てるため、0 以外のオフセットは codes.InvalidArgument で拒否する
+// （以前は黙って上書きしていたため、クライアントが指定したオフセットで書き込まれたと誤解されることがあった）。
+// replicate を指定した場合は、複製の権限（replicateAction）があればオフセットの指定を受け付ける。
+func (s *grpcServer) checkRecordOffsets(ctx context.Context, topic string, replicate bool, records ...*api.Record) error {
+	if replicate {
+		return s.authorize(ctx, topicObject(topic), replicateAction)
+	}
+	for _, record := range records {
+		if off := record.GetOffset(); off != 0 {
+			return status.Errorf(codes.InvalidArgument,
+				"record offset %d is assigned by the server (set replicate to append at a given offset)", off)
+		}
+	}
+	return nil
+}
+
+// prepareRecord: クライアントから受け取ったレコードを書き込める状態にする
+// Produce、ProduceBatch、Webhook のすべての書き込みで使用する（複製のレコードには使用しない）。
+// タイムスタンプが指定されていない場合はサーバーが受け付けた時刻を設定し、許可リストの gRPC メタデータをヘッダーにコピーしてから、
+// 永続化する前に ProduceRedactor でマスキングする（コピーしたメタデータもマスキングの対象にする）。
+// 引数:
+//   - ctx: リクエストのコンテキスト（gRPC メタデータを取り出す）
+//   - record: 書き込むレコード
+//   - now: サーバーが受け付けた時刻（UNIX ミリ秒）
+func (s *grpcServer) prepareRecord(ctx context.Context, record *api.Record, now int64) {
+	if record.Timestamp == 0 {
+		record.Timestamp = now
+	}
+	s.propagateMetadata(ctx, record)
+	if s.ProduceRedactor != nil {
+		s.ProduceRedactor.Apply(record)
+	}
+}
+
+// propagateMetadata: 許可リストにあるキーの gRPC メタデータをレコードのヘッダーにコピーする
+// 複数の値があるキーはカンマ区切りで連結する。レコードに同じ名前のヘッダーがある場合はクライアントの指定を優先する。
+func (s *grpcServer) propagateMetadata(ctx context.Context, record *api.Record) {
+	if len(s.PropagateMetadata) == 0 {
+		return
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return
+	}
+	for _, key := range s.PropagateMetadata {
+		key = strings.ToLower(key)
+		values := md.Get(key)
+		if len(values) == 0 {
+			continue
+		}
+		if _, ok := record.Headers[key]; ok {
+			continue
+		}
+		if record.Headers == nil {
+			record.Headers = make(map[string]string)
+		}
+		record.Headers[key] = strings.Join(values, ",")
+	}
+}
+
+// checkDeadline: クライアントの期限までに書き込みが終わる見込みがあるかを確認する
+// 期限を過ぎている場合、または残り時間が書き込みにかかる時間の移動平均より短い場合は
+// codes.DeadlineExceeded を返す（キャンセルされた場合は codes.Canceled）。
+func (s *grpcServer) checkDeadline(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		ProduceDeadlineRejected.Inc()
+		return status.FromContextError(err).Err()
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	if time.Until(deadline) < time.Duration(s.appendLatency.Load()) {
+		ProduceDeadlineRejected.Inc()
+		return status.Error(codes.DeadlineExceeded, "append is not expected to finish before the deadline")
+	}
+	return nil
+}
+
+// observeAppend: 書き込みにかかった時間を移動平均に反映し、期限までの残り時間を記録する
+func (s *grpcServer) observeAppend(ctx context.Context, d time.Duration) {
+	// 直近の書き込みを重視した指数移動平均（重み 1/8）
+	for {
+		old := s.appendLatency.Load()
+		next := old + (int64(d)-old)/8
+		if old == 0 {
+			next = int64(d)
+		}
+		if s.appendLatency.CompareAndSwap(old, next) {
+			break
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		ProduceDeadlineHeadroom.Observe(time.Until(deadline).Seconds())
+	}
 }
 
 // Consume: 指定されたオフセットのレコードを読み取る（単一リクエスト）
 // クライアントが指定したオフセットのレコードをログストアから読み取り、返す。
+// max_wait_ms が指定された場合はロングポーリングになり、レコードが追加されるまで
+// （min_bytes が指定された場合は、そのオフセット以降のレコードの値が合計 min_bytes 以上になるまで）
+// 最大 max_wait_ms 待ってから返す。ストリームを保持せずに効率よくポーリングするために使用する。
 // 引数:
 //   - ctx: リクエストのコンテキスト（キャンセル、タイムアウトなど）
 //   - req: 読み取るオフセットを含むリクエスト
@@ -96,13 +702,284 @@ func (s *grpcServer) Produce(ctx context.Context, req *api.ProduceRequest) (*api
 //   - *api.ConsumeResponse: 読み取ったレコードを含むレスポンス
 //   - error: エラーが発生した場合（オフセットが見つからない場合など）
 func (s *grpcServer) Consume(ctx context.Context, req *api.ConsumeRequest) (*api.ConsumeResponse, error) {
-	// ログストアからレコードを読み取る
-	record, err := s.CommitLog.Read(req.Offset)
+	// クライアントが読み取りを許可されているかを確認
+	if err := s.authorize(ctx, topicObject(req.Topic), consumeAction); err != nil {
+		return nil, err
+	}
+
+	// 線形化可能な読み取りが要求された場合、読み取り前にバリアを通す
+	if err := s.barrier(ctx, req.Consistency); err != nil {
+		return nil, err
+	}
+
+	// 読み取り元のトピックのログストアを取得
+	clog, release, err := s.commitLog(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	// 末尾や先頭からの相対的なオフセットを解決する
+	if err := resolveOffset(clog, req); err != nil {
+		return nil, err
+	}
+
+	// max_wait_ms が指定されていない場合は、待たずにレコードを読み取る
+	if req.MaxWaitMs == 0 {
+		record, err := clog.Read(req.Offset)
+		if err != nil {
+			return nil, err
+		}
+		return s.consumeRecords(clog, req, record)
+	}
+
+	// ロングポーリング: レコード（と min_bytes 分のデータ）が揃うまで最大 max_wait_ms 待つ
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(req.MaxWaitMs)*time.Millisecond)
+	defer cancel()
+	for {
+		// 読み取りの前に通知用のチャネルを取得しておく（読み取り後の追加を取りこぼさないため）
+		appended := waitAppend(clog)
+
+		record, err := clog.Read(req.Offset)
+		switch err.(type) {
+		case nil:
+			if ok, err := hasMinBytes(clog, req.Offset, req.MinBytes); err != nil {
+				return nil, err
+			} else if ok {
+				return s.consumeRecords(clog, req, record)
+			}
+		case api.ErrOffsetOutOfRange:
+			// まだレコードが追加されていない: 待つ
+		default:
+			return nil, err
+		}
+
+		select {
+		case <-appended:
+		case <-ctx.Done():
+			// 待機時間内にデータが揃わなかった場合、その時点の読み取り結果を返す
+			if err != nil {
+				return nil, err
+			}
+			return s.consumeRecords(clog, req, record)
+		}
+	}
+}
+
+// consumeResponse: 読み取ったレコードを最新のスキーマのバージョンに書き換え、ExportRedactor でマスキングしてレスポンスにする
+// Consume、ConsumeStream、Query、HTTP ゲートウェイのすべての読み取りで使用する。
+// 書き換えに失敗した場合は codes.Internal を返す（ログのレコードは変更しない）。
+func (s *grpcServer) consumeResponse(record *api.Record) (*api.ConsumeResponse, error) {
+	if s.Upcasts != nil {
+		if err := s.Upcasts.Apply(record); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	s.redactExport(record)
+	return &api.ConsumeResponse{Record: record, NextOffset: record.Offset + 1}, nil
+}
+
+// redactExport: クラスタの外に出るレコードを ExportRedactor でマスキングする（設定されていない場合は何もしない）
+func (s *grpcServer) redactExport(record *api.Record) {
+	if s.ExportRedactor != nil {
+		s.ExportRedactor.Apply(record)
+	}
+}
+
+// consumeRecords: 単一リクエストの Consume のレスポンスを作成する
+// max_records が 2 以上の場合だけ records を設定し、first に続くレコードをログの末尾まで、max_records 件まで読み取って含める
+// （単一のレコードの読み取りでは、record と records に同じレコードを重複して送らない）。
+// records の first を record と重複させると最大メッセージサイズを超える場合は、record を省略する。
+// max_bytes を指定した場合は、レコードのサイズの合計が超える手前で止める（first は常に含める）。
+// max_bytes に関係なく、レスポンスが gRPC の最大メッセージサイズを超える手前でも止める。
+// 配信時刻（deliver_after）が来ていないレコードの手前でも止める（first の配信時刻が来ていない場合は api.ErrRecordDelayed）。
+// 引数:
+//   - clog: 読み取り元のログストア
+//   - req: Consume のリクエスト
+//   - first: リクエストのオフセットから読み取ったレコード
+func (s *grpcServer) consumeRecords(clog CommitLog, req *api.ConsumeRequest, first *api.Record) (*api.ConsumeResponse, error) {
+	if err := s.checkDelivery(first); err != nil {
+		return nil, err
+	}
+	res, err := s.consumeResponse(first)
 	if err != nil {
 		return nil, err
 	}
-	// 読み取ったレコードを返す
-	return &api.ConsumeResponse{Record: record}, nil
+	if req.MaxRecords <= 1 {
+		return res, nil
+	}
+	res.Records = []*api.Record{res.Record}
+	size := uint64(proto.Size(res.Record))
+	msgSize, maxMsgSize := uint64(proto.Size(res)), uint64(s.maxMessageBytes())
+	if maxMsgSize > 0 && msgSize > maxMsgSize {
+		res.Record = nil
+		msgSize = uint64(proto.Size(res))
+	}
+	for uint32(len(res.Records)) < req.MaxRecords {
+		record, err := clog.Read(res.NextOffset)
+		if _, ok := err.(api.ErrOffsetOutOfRange); ok {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if s.checkDelivery(record) != nil {
+			break
+		}
+		next, err := s.consumeResponse(record)
+		if err != nil {
+			return nil, err
+		}
+		if size += uint64(proto.Size(next.Record)); req.MaxBytes > 0 && size > req.MaxBytes {
+			break
+		}
+		// レコードのタグと長さ情報の分（最大 1+10 バイト）も含めて見積もる
+		if msgSize += uint64(proto.Size(next.Record)) + 11; maxMsgSize > 0 && msgSize > maxMsgSize {
+			break
+		}
+		res.Records = append(res.Records, next.Record)
+		res.NextOffset = next.NextOffset
+	}
+	return res, nil
+}
+
+// waitAppend: 次にレコードが追加されたときに閉じられるチャネルを返す
+// ログストアが追加を通知できない場合は、pollInterval 後に閉じられるチャネルを返す。
+func waitAppend(clog CommitLog) <-chan struct{} {
+	if nl, ok := clog.(notifyingLog); ok {
+		return nl.Appended()
+	}
+	ch := make(chan struct{})
+	time.AfterFunc(pollInterval, func() { close(ch) })
+	return ch
+}
+
+// hasMinBytes: 指定されたオフセット以降のレコードの値が合計 minBytes 以上あるかを確認する
+// 引数:
+//   - clog: ログストア
+//   - off: 確認を開始するオフセット
+//   - minBytes: 必要なバイト数（0 の場合は常に true）
+//
+// 戻り値:
+//   - bool: minBytes 以上のデータがある場合 true
+//   - error: エラーが発生した場合
+func hasMinBytes(clog CommitLog, off, minBytes uint64) (bool, error) {
+	var n uint64
+	for n < minBytes {
+		record, err := clog.Read(off)
+		if _, ok := err.(api.ErrOffsetOutOfRange); ok {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		n += uint64(len(record.Value))
+		off++
+	}
+	return true, nil
+}
+
+// resolveOffset: リクエストの相対的なオフセット（origin）を、ログストアの絶対的なオフセットに置き換える
+// 呼び出しの開始時に一度だけ解決する（ConsumeStream は、その後に追加されたレコードも続けて読み取る）。
+// ORIGIN_LATEST で末尾から数えたオフセットが削除済みの場合は、保持している最小のオフセットから読み取る。
+// 戻り値:
+//   - error: 末尾のオフセットを返せないログストアで ORIGIN_LATEST が指定された場合は codes.Unimplemented
+func resolveOffset(clog CommitLog, req *api.ConsumeRequest) error {
+	if req.Origin == api.OffsetOrigin_ORIGIN_ABSOLUTE {
+		return nil
+	}
+	var lowest uint64
+	if ll, ok := clog.(lowestOffsetLog); ok {
+		var err error
+		if lowest, err = ll.LowestOffset(); err != nil {
+			return err
+		}
+	}
+	switch req.Origin {
+	case api.OffsetOrigin_ORIGIN_LATEST:
+		el, ok := clog.(endOffsetLog)
+		if !ok {
+			return status.Error(codes.Unimplemented, "log does not support offsets relative to the latest record")
+		}
+		next := el.NextOffset()
+		req.Offset = max(next-min(req.Offset, next), lowest)
+	case api.OffsetOrigin_ORIGIN_EARLIEST:
+		req.Offset += lowest
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown offset origin: %v", req.Origin)
+	}
+	req.Origin = api.OffsetOrigin_ORIGIN_ABSOLUTE
+	return nil
+}
+
+// newByteLimiter: 1秒あたりのバイト数で送信速度を制限するリミッターを作成する
+// バーストは1秒分とし、最初の1秒分は待たずに送信できる。
+func newByteLimiter(bytesPerSec uint64) *rate.Limiter {
+	burst := bytesPerSec
+	if burst > math.MaxInt32 {
+		burst = math.MaxInt32
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSec), int(burst))
+}
+
+// waitBytes: n バイトを送信できるまで待つ
+// バーストより大きなレコードは、バースト単位に分けて待つ。
+func waitBytes(ctx context.Context, limiter *rate.Limiter, n int) error {
+	for n > 0 {
+		c := min(n, limiter.Burst())
+		if err := limiter.WaitN(ctx, c); err != nil {
+			return err
+		}
+		n -= c
+	}
+	return nil
+}
+
+// ProduceBatch: 複数のレコードを1つのバッチとしてログに追加する
+// バッチのレコードはすべて追加されるか、まったく追加されないかのどちらかになり、連続したオフセットが割り当てられる。
+// 引数:
+//   - ctx: リクエストのコンテキスト（キャンセル、タイムアウトなど）
+//   - req: 追加するレコードを含むリクエスト
+//
+// 戻り値:
+//   - *api.ProduceBatchResponse: 先頭のレコードに割り当てられたオフセットを含むレスポンス
+//   - error: エラーが発生した場合（レコードが空の場合は codes.InvalidArgument）
+func (s *grpcServer) ProduceBatch(ctx context.Context, req *api.ProduceBatchRequest) (*api.ProduceBatchResponse, error) {
+	if err := s.authorize(ctx, topicObject(req.Topic), produceAction); err != nil {
+		return nil, err
+	}
+	if len(req.Records) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "records are required")
+	}
+	if err := s.checkRecordOffsets(ctx, req.Topic, false, req.Records...); err != nil {
+		return nil, err
+	}
+
+	clog, release, err := s.writableLog(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	blog, ok := clog.(batchLog)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "log does not support batches")
+	}
+
+	now := s.clock().Now().UnixMilli()
+	for _, record := range req.Records {
+		s.prepareRecord(ctx, record, now)
+	}
+
+	if err := s.checkDeadline(ctx); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	offset, err := blog.AppendBatch(req.Records)
+	if err != nil {
+		return nil, err
+	}
+	s.observeAppend(ctx, time.Since(start))
+	return &api.ProduceBatchResponse{Offset: offset}, nil
 }
 
 // ProduceStream: ストリーミングでレコードをログに追加する
@@ -138,6 +1015,12 @@ func (s *grpcServer) ProduceStream(stream api.Log_ProduceStreamServer) error {
 // ConsumeStream: ストリーミングでレコードを読み取る
 // 指定されたオフセットから順番にレコードを読み取り、ストリーミングでクライアントに送信する。
 // 範囲外のオフセットに達するまで、またはクライアントがストリームを終了するまで続行する。
+// rate_limit_bytes_per_sec が指定された場合は、レコードの値のバイト数がその速度を超えないように送信を遅らせる。
+// 壊れたレコードに達した場合は codes.DataLoss でストリームを終了するが、skip_corrupt が指定された場合は
+// 読み飛ばした範囲（gap）を送信して、その次のオフセットから読み取りを続ける。
+// 配信時刻（deliver_after）が来ていないレコードは読み飛ばし、配信時刻になったら送信する。
+// 開始オフセットより前のレコードも、配信時刻が delayed_since（省略時はストリームの開始時刻）より後であれば
+// 配信時刻になったら送信する（以前のストリームで読み飛ばしたレコードを、再接続後に受け取れるように）。
 // 引数:
 //   - req: 読み取りを開始するオフセットを含むリクエスト（req.Offset は読み取り中にインクリメントされる）
 //   - stream: サーバーストリーム（クライアントにレスポンスを送信）
@@ -145,26 +1028,114 @@ func (s *grpcServer) ProduceStream(stream api.Log_ProduceStreamServer) error {
 // 戻り値:
 //   - error: エラーが発生した場合（ストリームの終了、エラーなど）
 func (s *grpcServer) ConsumeStream(req *api.ConsumeRequest, stream api.Log_ConsumeStreamServer) error {
+	// クライアントが読み取りを許可されているかを確認（ストリームの開始時に一度だけ）
+	if err := s.authorize(stream.Context(), topicObject(req.Topic), consumeAction); err != nil {
+		return err
+	}
+
+	// 読み取り元のトピックのログストアを取得
+	clog, release, err := s.commitLog(req.Topic)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// 線形化可能な読み取りが要求された場合、ストリームの開始時にバリアを通す
+	if err := s.barrier(stream.Context(), req.Consistency); err != nil {
+		return err
+	}
+	// 末尾や先頭からの相対的なオフセットを解決する（バリアの後に解決して、末尾を最新の状態から数える）
+	if err := resolveOffset(clog, req); err != nil {
+		return err
+	}
+
+	// ストリームごとに先読みリーダーを用意する（連続したオフセットの読み取りでシークを減らすため）
+	read := clog.Read
+	if sl, ok := clog.(sequentialLog); ok {
+		read = sl.NewSequentialReader().Read
+	}
+
+	// 送信速度の上限が指定された場合、過去のデータの再生で下流のシステムに負荷をかけすぎないように
+	// ストリームごとにレコードの送信を遅らせる
+	var limiter *rate.Limiter
+	if req.RateLimitBytesPerSec > 0 {
+		limiter = newByteLimiter(req.RateLimitBytesPerSec)
+	}
+
+	// 配信時刻が来ていないため読み飛ばしたレコード
+	delays := &delayQueue{clock: s.clock()}
+	defer delays.stop()
+	since := req.DelayedSince
+	if since == 0 {
+		since = s.clock().Now().UnixMilli()
+	}
+	if err := s.loadDelayed(clog, delays, since, req.Offset); err != nil {
+		return err
+	}
+
 	for {
 		select {
 		case <-stream.Context().Done():
 			// クライアントがストリームを終了した場合、正常終了
 			return nil
 		default:
+			// 配信時刻が来たレコードを先に送信する
+			if err := s.sendDue(clog, delays, req.Offset, stream); err != nil {
+				return err
+			}
+
 			// 現在のオフセットのレコードを読み取る
-			res, err := s.Consume(stream.Context(), req)
+			// 読み取りの前に通知用のチャネルを取得しておく（読み取り後の追加を取りこぼさないため）
+			appended := waitAppend(clog)
+			record, err := read(req.Offset)
 			switch err.(type) {
 			case nil:
 				// エラーなし: レコードが見つかった
 			case api.ErrOffsetOutOfRange:
-				// 範囲外のオフセット: 次のオフセットを試す（ログの末尾に達した可能性）
+				// 範囲外のオフセット: ログの末尾に達したので、新しいレコードが追加されるまで待つ
+				// 読み飛ばしたレコードがある場合は、その配信時刻も確認する
+				select {
+				case <-appended:
+				case <-delays.wait():
+				case <-stream.Context().Done():
+					return nil
+				}
 				continue
 			default:
+				// 壊れたレコード: skip_corrupt が指定されていれば、読み飛ばしたことを通知して続ける
+				if gap := corruptGap(req.Offset, err); gap != nil && req.SkipCorrupt {
+					if err := stream.Send(&api.ConsumeResponse{Gap: gap, NextOffset: gap.ToOffset}); err != nil {
+						return err
+					}
+					req.Offset = gap.ToOffset
+					continue
+				}
 				// その他のエラー: ストリームを終了
 				return err
 			}
 
+			// 配信時刻が来ていないレコードは読み飛ばして、配信時刻になったら送信する
+			if s.checkDelivery(record) != nil {
+				delays.add(record.Offset, record.DeliverAfter)
+				req.Offset++
+				continue
+			}
+
+			if limiter != nil {
+				if err := waitBytes(stream.Context(), limiter, len(record.Value)); err != nil {
+					// クライアントがストリームを終了した場合、正常終了
+					if stream.Context().Err() != nil {
+						return nil
+					}
+					return err
+				}
+			}
+
 			// 読み取ったレコードをクライアントに送信
+			res, err := s.consumeResponse(record)
+			if err != nil {
+				return err
+			}
 			if err = stream.Send(res); err != nil {
 				return err
 			}
@@ -174,3 +1145,375 @@ func (s *grpcServer) ConsumeStream(req *api.ConsumeRequest, stream api.Log_Consu
 		}
 	}
 }
+
+// corruptGap: 壊れていて読み取れないレコードの範囲を返す
+// 引数:
+//   - off: 読み取ろうとしたオフセット
+//   - err: 読み取りのエラー
+//
+// 戻り値:
+//   - *api.ConsumeGap: 読み飛ばす範囲（壊れたレコードのエラーでない場合は nil）
+//     隔離中のセグメントの場合は、off からセグメントの末尾までを読み飛ばす
+func corruptGap(off uint64, err error) *api.ConsumeGap {
+	switch e := err.(type) {
+	case api.ErrCorruptRecord:
+		return &api.ConsumeGap{FromOffset: off, ToOffset: off + 1, Reason: e.Reason}
+	case api.ErrCorruptSegment:
+		return &api.ConsumeGap{FromOffset: off, ToOffset: e.NextOffset, Reason: e.Reason}
+	}
+	return nil
+}
+
+// TruncateLog: 指定されたオフセットより前のレコードを削除する（管理操作）
+// 外部の運用ツールから保持期間（リテンション）を制御できるようにするための RPC。
+// 削除はセグメント単位で行われるため、before_offset より前のレコードの一部が残る場合がある。
+// 書き込み中のセグメント（アクティブセグメント）は削除されない。
+// レコードを元に戻せない操作のため、Authorizer が設定されていない場合は誰にも許可しない。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 削除の境界となるオフセットを含むリクエスト
+//
+// 戻り値:
+//   - *api.TruncateLogResponse: 空のレスポンス
+//   - error: エラーが発生した場合（認可に失敗した場合、Authorizer が設定されていない場合、予約されたトピックの場合は codes.PermissionDenied）
+func (s *grpcServer) TruncateLog(ctx context.Context, req *api.TruncateLogRequest) (*api.TruncateLogResponse, error) {
+	if s.Authorizer == nil {
+		return nil, status.Error(codes.PermissionDenied, "truncating the log requires an authorizer on this server")
+	}
+	// 通常の書き込み・読み取りとは別のアクションで認可する
+	if err := s.authorize(ctx, topicObject(req.Topic), truncateAction); err != nil {
+		return nil, err
+	}
+	if err := reservedTopic(req.Topic); err != nil {
+		return nil, err
+	}
+
+	clog, release, err := s.commitLog(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// before_offset = 0 の場合、削除するレコードはない
+	if req.BeforeOffset == 0 {
+		return &api.TruncateLogResponse{}, nil
+	}
+
+	// Truncate は指定されたオフセット「以下」のレコードのみを含むセグメントを削除する
+	if err := clog.Truncate(req.BeforeOffset - 1); err != nil {
+		return nil, err
+	}
+	return &api.TruncateLogResponse{}, nil
+}
+
+// FlushLog: ログのすべてのデータをディスクに同期する（管理操作）
+// 運用者がプロセスを止めずにファイルシステムのスナップショットやバックアップを作成する前に呼び出す。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 対象のトピックを含むリクエスト
+//
+// 戻り値:
+//   - *api.FlushLogResponse: 同期した時点の次のオフセット（これより前のレコードはすべてディスクにある）
+//   - error: エラーが発生した場合（ログストアが同期できない場合は codes.Unimplemented）
+func (s *grpcServer) FlushLog(ctx context.Context, req *api.FlushLogRequest) (*api.FlushLogResponse, error) {
+	if err := s.authorize(ctx, topicObject(req.Topic), flushLogAction); err != nil {
+		return nil, err
+	}
+
+	clog, release, err := s.commitLog(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	fl, ok := clog.(flushingLog)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "log does not support flushing")
+	}
+	// 同期の後に追加されたレコードを含めないように、同期の前に次のオフセットを取得する
+	res := &api.FlushLogResponse{}
+	if el, ok := clog.(endOffsetLog); ok {
+		res.NextOffset = el.NextOffset()
+	}
+	if err := fl.Flush(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetLogInfo: セグメントごとの統計情報を返す（管理操作）
+// 運用者が保持期間の異常やセグメントサイズの偏りを確認するための RPC。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 対象のトピックを含むリクエスト
+//
+// 戻り値:
+//   - *api.GetLogInfoResponse: セグメントの統計情報（baseOffset の昇順）と、ログ全体のレコード数とバイト数
+//   - error: エラーが発生した場合（ログストアが統計情報を公開できない場合は codes.Unimplemented）
+func (s *grpcServer) GetLogInfo(ctx context.Context, req *api.GetLogInfoRequest) (*api.GetLogInfoResponse, error) {
+	if err := s.authorize(ctx, topicObject(req.Topic), getLogInfoAction); err != nil {
+		return nil, err
+	}
+
+	clog, release, err := s.commitLog(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	slog, ok := clog.(statsLog)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "log does not expose segment stats")
+	}
+	stats, err := slog.SegmentStats()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &api.GetLogInfoResponse{}
+	if sl, ok := clog.(sizedLog); ok {
+		res.Records, res.Bytes = sl.Size()
+	}
+	if wl, ok := clog.(writeStatsLog); ok {
+		w := wl.WriteStats()
+		res.Writes = &api.WriteStats{
+			RecordBytes:  w.RecordBytes,
+			StoreBytes:   w.StoreBytes,
+			IndexBytes:   w.IndexBytes,
+			RewriteBytes: w.RewriteBytes,
+		}
+	}
+	now := s.clock().Now()
+	for _, st := range stats {
+		res.Segments = append(res.Segments, &api.SegmentInfo{
+			BaseOffset: st.BaseOffset,
+			NextOffset: st.NextOffset,
+			Records:    st.Records,
+			StoreBytes: st.StoreBytes,
+			IndexBytes: st.IndexBytes,
+			AgeMs:      uint64(segmentAge(now, st).Milliseconds()),
+			Active:     st.Active,
+		})
+	}
+	return res, nil
+}
+
+// GetValue: ビューからキーの最新の値を返す
+// クライアントが自前でログを読み取って集計しなくても、キーごとの最新の値を参照できる（CQRS の読み取り側）。
+// ビューはログを非同期に読み取るため、直前に書き込んだレコードが反映されていない場合がある。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 参照するキーを含むリクエスト
+//
+// 戻り値:
+//   - *api.GetValueResponse: キーの最新の値と、その値を書き込んだレコードのオフセット
+//   - error: エラーが発生した場合（キーが存在しない場合は codes.NotFound）
+func (s *grpcServer) GetValue(ctx context.Context, req *api.GetValueRequest) (*api.GetValueResponse, error) {
+	if err := s.authorize(ctx, objectWildcard, consumeAction); err != nil {
+		return nil, err
+	}
+	if s.View == nil {
+		return nil, status.Error(codes.FailedPrecondition, "views are not enabled on this server")
+	}
+	value, offset, err := s.View.Get(req.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &api.GetValueResponse{Value: value, Offset: offset}, nil
+}
+
+// segmentSender: セグメントのチャンクを送信するサーバーストリーム
+type segmentSender interface {
+	Send(*api.SegmentChunk) error
+}
+
+// sendSegments: 指定されたオフセット以降の書き込み済みセグメントのファイルを送信する
+// 引数:
+//   - stream: サーバーストリーム
+//   - clog: 送信するセグメントを持つログストア
+//   - from: このオフセット以降のレコードを含むセグメントを送信する
+//
+// 戻り値:
+//   - error: ログストアがセグメントの転送に対応していない場合は codes.Unimplemented
+func sendSegments(stream segmentSender, clog CommitLog, from uint64) error {
+	sl, ok := clog.(segmentLog)
+	if !ok {
+		return status.Error(codes.Unimplemented, "commit log does not support segment transfer")
+	}
+
+	// 送信中に Truncate で削除されても送信を続けられるように、送信が終わるまでセグメントの参照を保持する
+	segments := sl.SealedSegments(from)
+	defer func() {
+		for _, seg := range segments {
+			seg.Close()
+		}
+	}()
+	for _, seg := range segments {
+		if err := sendSegmentFile(stream, seg, api.SegmentChunk_STORE, seg.Store); err != nil {
+			return err
+		}
+		if err := sendSegmentFile(stream, seg, api.SegmentChunk_INDEX, seg.Index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendSegmentFile: セグメントファイルをチャンクに分割して送信する
+// ファイルの最後のチャンクには Last を設定する（空のファイルでも必ず1つは送信する）。
+// 引数:
+//   - stream: サーバーストリーム
+//   - seg: 送信するセグメント（開始オフセットとストアの保存形式をチャンクに設定する）
+//   - file: ファイルの種類（ストアまたはインデックス）
+//   - r: ファイルの内容
+//
+// 戻り値:
+//   - error: エラーが発生した場合
+func sendSegmentFile(
+	stream segmentSender,
+	seg log.SealedSegment,
+	file api.SegmentChunk_File,
+	r *io.SectionReader,
+) error {
+	buf := make([]byte, segmentChunkSize)
+	var pos uint64
+	for {
+		n, err := r.ReadAt(buf, int64(pos))
+		if err != nil && err != io.EOF {
+			return err
+		}
+		last := pos+uint64(n) >= uint64(r.Size())
+		if n == 0 && !last {
+			return io.ErrUnexpectedEOF
+		}
+		if err = stream.Send(&api.SegmentChunk{
+			BaseOffset: seg.BaseOffset,
+			File:       file,
+			Position:   pos,
+			Data:       buf[:n],
+			Checksum:   crc32.Checksum(buf[:n], castagnoli),
+			Last:       last,
+			Format:     uint32(seg.Format),
+		}); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		pos += uint64(n)
+	}
+}
+
+// barrier: 要求された一貫性レベルを満たすまで待つ
+// EVENTUAL の場合、または ReadBarrier が設定されていない場合は何もしない。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - consistency: クライアントが要求した一貫性レベル
+//
+// 戻り値:
+//   - error: 一貫性レベルを満たせない場合（リーダーでない場合など）
+func (s *grpcServer) barrier(ctx context.Context, consistency api.Consistency) error {
+	if consistency != api.Consistency_LINEARIZABLE || s.ReadBarrier == nil {
+		return nil
+	}
+	err := s.ReadBarrier.Barrier(ctx)
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
+
+// authorize: コンテキストのサブジェクトがアクションを実行できるかを確認する
+// Authorizer が設定されていない場合は常に許可する。
+// 引数:
+//   - ctx: サブジェクトを含むコンテキスト
+//   - object: 操作の対象（トピック名、またはログ全体を表す objectWildcard）
+//   - action: 実行しようとしているアクション（例: produceAction。管理 API の呼び出しでは adminActionPrefix を付けて認可する）
+//
+// 戻り値:
+//   - error: 認可に失敗した場合（codes.PermissionDenied）
+func (s *grpcServer) authorize(ctx context.Context, object, action string) error {
+	if s.Authorizer == nil {
+		return nil
+	}
+	err := s.Authorizer.Authorize(subject(ctx), object, adminAction(ctx, action))
+	if err == nil {
+		return nil
+	}
+	// Authorizer が gRPC のステータスを返した場合はそのまま返す
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.PermissionDenied, err.Error())
+}
+
+// subjectContextKey: コンテキストにサブジェクトを保存するためのキー
+type subjectContextKey struct{}
+
+// subject: コンテキストからサブジェクト（認証のインターセプターが設定したクライアントの識別子）を取り出す
+func subject(ctx context.Context) string {
+	s, _ := ctx.Value(subjectContextKey{}).(string)
+	return s
+}
+
+// subjectProvider: 接続の認証情報からクライアントのサブジェクトを取り出す認証情報の提供元
+// Config.Credentials がこのインターフェースも実装している場合（例: SPIFFE ID や ALTS のサービスアカウントを使う場合）、
+// 認証のインターセプターはこれでサブジェクトを取り出す。
+type subjectProvider interface {
+	Subject(info credentials.AuthInfo) string
+}
+
+// peerSubject: 接続の認証情報からサブジェクトを取り出す
+// TLS の場合はクライアント証明書のコモンネーム、Unix ドメインソケットの接続（UnixSocketCredentials）の場合は指定されたサブジェクトを返す。
+// TLS を使用していない場合（またはクライアント証明書がない場合）は空文字を返す。
+func peerSubject(info credentials.AuthInfo) string {
+	switch info := info.(type) {
+	case credentials.TLSInfo:
+		if len(info.State.VerifiedChains) > 0 && len(info.State.VerifiedChains[0]) > 0 {
+			return info.State.VerifiedChains[0][0].Subject.CommonName
+		}
+	case unixAuthInfo:
+		return info.subject
+	}
+	return ""
+}
+
+// authenticate: 接続の認証情報から取り出したサブジェクトをコンテキストに設定する
+func (c *Config) authenticate(ctx context.Context) context.Context {
+	var sub string
+	if p, ok := peer.FromContext(ctx); ok && p.AuthInfo != nil {
+		if sp, ok := c.Credentials.(subjectProvider); ok {
+			sub = sp.Subject(p.AuthInfo)
+		}
+		if sub == "" {
+			sub = peerSubject(p.AuthInfo)
+		}
+	}
+	return context.WithValue(ctx, subjectContextKey{}, sub)
+}
+
+// authInterceptors: サブジェクトをコンテキストに設定する認証のインターセプターを作成する
+func (c *Config) authInterceptors() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(c.authenticate(ctx), req)
+	}
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &authenticatedStream{
+			ServerStream: ss,
+			ctx:          c.authenticate(ss.Context()),
+		})
+	}
+	return unary, stream
+}
+
+// authenticatedStream: サブジェクトを設定したコンテキストを返すようにしたサーバーストリーム
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context: サブジェクトを設定したコンテキストを返す
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -1,20 +1,55 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	adminpb "github.com/kentakki416/proglog/api/admin/v1"
+	replpb "github.com/kentakki416/proglog/api/replication/v1"
 	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/acl"
+	"github.com/kentakki416/proglog/internal/compression"
 	"github.com/kentakki416/proglog/internal/config"
+	"github.com/kentakki416/proglog/internal/health"
 	"github.com/kentakki416/proglog/internal/log"
+	"github.com/kentakki416/proglog/internal/merkle"
+	"github.com/kentakki416/proglog/internal/offsets"
+	"github.com/kentakki416/proglog/internal/redact"
+	"github.com/kentakki416/proglog/internal/sessions"
+	"github.com/kentakki416/proglog/internal/upcast"
+	"github.com/kentakki416/proglog/internal/usage"
+	"github.com/kentakki416/proglog/internal/views"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // TestServer: gRPC サーバーの統合テスト
@@ -33,6 +68,23 @@ func TestServer(t *testing.T) {
 		"produce/consume a message to/from the log succeeeds": testProduceConsume,
 		"produce/consume stream succeeds":                     testProduceConsumeStream,
 		"consume past log boundary fails":                     testConsumePastBoundary,
+		"truncate log removes old records":                    testTruncateLog,
+		"topic lifecycle":                                     testTopicLifecycle,
+		"long-poll consume waits for records":                 testLongPollConsume,
+		"log info and segment metrics":                        testLogInfo,
+		"get value from key-value view":                       testGetValue,
+		"query filters records":                               testQuery,
+		"rate-limited consume stream":                         testRateLimitedConsumeStream,
+		"export/import committed offsets":                     testExportImportOffsets,
+		"produce batch":                                       testProduceBatch,
+		"export log range as parquet":                         testExportParquet,
+		"session heartbeats":                                  testSessions,
+		"consumer lag":                                        testConsumerLag,
+		"flush log":                                           testFlushLog,
+		"capabilities":                                        testCapabilities,
+		"consume batch":                                       testConsumeBatch,
+		"client-supplied offsets":                             testProduceOffsets,
+		"offsets relative to the end":                         testRelativeOffsets,
 	} {
 		t.Run(scenario, func(t *testing.T) {
 			// 各テストシナリオごとに新しいサーバーとクライアントをセットアップ
@@ -108,10 +160,34 @@ func setupTest(t *testing.T, fn func(*Config)) (
 	clog, err := log.NewLog(dir, log.Config{})
 	require.NoError(t, err)
 
+	// トピックの管理を作成
+	topicsDir, err := os.MkdirTemp("", "server-test-topics")
+	require.NoError(t, err)
+	topicsConfig := log.Config{}
+	topicsConfig.Topic.DeleteRetention = time.Hour
+	topics, err := log.NewTopics(topicsDir, topicsConfig)
+	require.NoError(t, err)
+
+	// キーごとの最新の値を保持するビューを作成
+	viewDir, err := os.MkdirTemp("", "server-test-views")
+	require.NoError(t, err)
+	view, err := views.NewKeyValue(filepath.Join(viewDir, "kv.db"), clog)
+	require.NoError(t, err)
+
+	// コンシューマーグループのコミット済みオフセットのストアを作成
+	offsetStore, err := offsets.NewStore(filepath.Join(viewDir, "offsets.json"))
+	require.NoError(t, err)
+
 	// サーバーの設定を作成
 	cfg = &Config{
 		CommitLog: clog,
+		Topics:    topics,
+		View:      view,
+		Offsets:   offsetStore,
+		Clock:     log.NewFakeClock(time.UnixMilli(1700000000000)),
+		ExportDir: filepath.Join(viewDir, "exports"),
 	}
+	cfg.Sessions = sessions.NewRegistry(10*time.Second, cfg.Clock)
 	// オプションの設定関数が提供されている場合、実行する
 	if fn != nil {
 		fn(cfg)
@@ -131,7 +207,11 @@ func setupTest(t *testing.T, fn func(*Config)) (
 		cc.Close()    // クライアント接続を閉じる
 		server.Stop() // サーバーを停止
 		l.Close()     // リスナーを閉じる
+		view.Close()  // ビューを閉じる（ログの読み取りを停止）
 		clog.Remove() // ログストアのディレクトリを削除
+		os.RemoveAll(viewDir)
+		topics.Close()
+		os.RemoveAll(topicsDir)
 	}
 }
 
@@ -240,9 +320,9 @@ func testProduceConsumeStream(
 
 		// 各レコードを順番に送信
 		for offset, record := range records {
-			// レコードをストリームに送信
+			// レコードをストリームに送信（オフセットはサーバーが割り当てるため指定しない）
 			err = stream.Send(&api.ProduceRequest{
-				Record: record,
+				Record: &api.Record{Value: record.Value},
 			})
 			require.NoError(t, err)
 
@@ -275,10 +355,2363 @@ func testProduceConsumeStream(
 			require.NoError(t, err)
 
 			// 読み取ったレコードの値とオフセットが期待値と一致することを確認
+			// タイムスタンプはサーバーが受け付けた時刻が設定される
 			require.Equal(t, res.Record, &api.Record{
-				Value:  record.Value,
-				Offset: uint64(i),
+				Value:     record.Value,
+				Offset:    uint64(i),
+				Timestamp: config.Clock.Now().UnixMilli(),
 			})
 		}
 	}
 }
+
+// testTruncateLog: TruncateLog RPC で古いレコードが削除されることをテストする
+// Authorizer が設定されていないサーバーでは拒否されるため、削除は許可する Authorizer を設定したサーバーで確認する。
+// 引数:
+//   - t: テストヘルパー
+//   - client: gRPC クライアント（Authorizer が設定されていないサーバー）
+//   - config: サーバーの設定（このテストでは使用しない）
+func testTruncateLog(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+
+	_, err := client.TruncateLog(ctx, &api.TruncateLogRequest{BeforeOffset: 1})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	client, _, teardown := setupTest(t, func(c *Config) {
+		c.Authorizer = testAuthorizer{
+			produceAction:  true,
+			consumeAction:  true,
+			truncateAction: true,
+		}
+	})
+	defer teardown()
+
+	// セグメントが複数できるまでレコードを追加（デフォルトの MaxStoreBytes は 1KB）
+	var last uint64
+	for i := 0; i < 100; i++ {
+		produce, err := client.Produce(ctx, &api.ProduceRequest{
+			Record: &api.Record{Value: []byte("hello world")},
+		})
+		require.NoError(t, err)
+		last = produce.Offset
+	}
+
+	_, err = client.TruncateLog(ctx, &api.TruncateLogRequest{BeforeOffset: last})
+	require.NoError(t, err)
+
+	// 先頭のレコードは削除されている
+	_, err = client.Consume(ctx, &api.ConsumeRequest{Offset: 0})
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+
+	// 最後のレコードはアクティブセグメントにあるため残っている
+	consume, err := client.Consume(ctx, &api.ConsumeRequest{Offset: last})
+	require.NoError(t, err)
+	require.Equal(t, last, consume.Record.Offset)
+}
+
+// testFlushLog: FlushLog が同期した時点の次のオフセットを返すことをテストする
+func testConsumeBatch(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		_, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte(fmt.Sprint(i))}})
+		require.NoError(t, err)
+	}
+	offsets := func(records []*api.Record) []uint64 {
+		var offs []uint64
+		for _, record := range records {
+			offs = append(offs, record.Offset)
+		}
+		return offs
+	}
+
+	// 1件だけの読み取りでは records に重複して含めず、record と next_offset を返す
+	res, err := client.Consume(ctx, &api.ConsumeRequest{Offset: 1})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.Record.Offset)
+	require.Empty(t, res.Records)
+	require.Equal(t, uint64(2), res.NextOffset)
+
+	res, err = client.Consume(ctx, &api.ConsumeRequest{Offset: 1, MaxRecords: 3})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.Record.Offset)
+	require.Equal(t, []uint64{1, 2, 3}, offsets(res.Records))
+	require.Equal(t, uint64(4), res.NextOffset)
+
+	// ログの末尾で止まる
+	res, err = client.Consume(ctx, &api.ConsumeRequest{Offset: 3, MaxRecords: 10})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3, 4}, offsets(res.Records))
+	require.Equal(t, uint64(5), res.NextOffset)
+
+	// max_bytes を超える手前で止まるが、最初のレコードは常に返す
+	res, err = client.Consume(ctx, &api.ConsumeRequest{Offset: 0, MaxRecords: 10, MaxBytes: 1})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0}, offsets(res.Records))
+	// オフセット 0 以外のレコードはオフセットのフィールドの分（2 バイト）大きい
+	size := proto.Size(res.Record)
+	res, err = client.Consume(ctx, &api.ConsumeRequest{Offset: 0, MaxRecords: 10, MaxBytes: uint64(2*size + 2)})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 1}, offsets(res.Records))
+}
+
+func testCapabilities(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+
+	res, err := client.GetCapabilities(ctx, &api.GetCapabilitiesRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint32(MaxAPIVersion), res.ApiVersion)
+	require.Equal(t, uint32(MinAPIVersion), res.MinApiVersion)
+	require.Equal(t, uint32(AdminAPIVersion), res.AdminApiVersion)
+	require.Equal(t, []string{
+		FeatureTopics, FeatureConsumerOffsets, FeatureSessions, FeatureKeyValueView, FeatureExport,
+		FeatureDelayedRecords, FeatureRelativeOffsets,
+	}, res.Features)
+	require.ElementsMatch(t, []string{compression.Gzip, compression.Zstd}, res.CompressionCodecs)
+	require.Equal(t, uint64(4*1024*1024), res.MaxRecordBytes)
+	require.Equal(t, uint64(4*1024*1024+messageOverheadBytes), res.MaxMessageBytes)
+
+	// 新しいクライアントにはサーバーが対応している最新のバージョンを返す
+	res, err = client.GetCapabilities(ctx, &api.GetCapabilitiesRequest{ClientApiVersion: MaxAPIVersion + 1})
+	require.NoError(t, err)
+	require.Equal(t, uint32(MaxAPIVersion), res.ApiVersion)
+}
+
+func testFlushLog(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("hello world")}})
+		require.NoError(t, err)
+	}
+	res, err := client.FlushLog(ctx, &api.FlushLogRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), res.NextOffset)
+
+	_, err = client.FlushLog(ctx, &api.FlushLogRequest{Topic: "missing"})
+	require.Error(t, err)
+}
+
+// testRelativeOffsets: 末尾や先頭から数えたオフセットで、事前にオフセットを取得せずに読み取れることをテストする
+func testRelativeOffsets(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		_, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte(fmt.Sprint(i))}})
+		require.NoError(t, err)
+	}
+
+	// 最後の2件
+	res, err := client.Consume(ctx, &api.ConsumeRequest{Origin: api.OffsetOrigin_ORIGIN_LATEST, Offset: 2, MaxRecords: 10})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), res.Record.Offset)
+	require.Len(t, res.Records, 2)
+	require.Equal(t, uint64(5), res.NextOffset)
+
+	// 保持しているレコードより多く指定した場合は先頭から
+	res, err = client.Consume(ctx, &api.ConsumeRequest{Origin: api.OffsetOrigin_ORIGIN_LATEST, Offset: 100})
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), res.Record.Offset)
+
+	res, err = client.Consume(ctx, &api.ConsumeRequest{Origin: api.OffsetOrigin_ORIGIN_EARLIEST, Offset: 1})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.Record.Offset)
+
+	// 末尾から読み取るストリームは、最後のレコードとその後に追加されたレコードを受け取る
+	stream, err := client.ConsumeStream(ctx, &api.ConsumeRequest{Origin: api.OffsetOrigin_ORIGIN_LATEST, Offset: 1})
+	require.NoError(t, err)
+	got, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), got.Record.Offset)
+	_, err = client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("5")}})
+	require.NoError(t, err)
+	got, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), got.Record.Offset)
+	require.Equal(t, []byte("5"), got.Record.Value)
+}
+
+// testProduceOffsets: クライアントが指定したオフセットは拒否され、replicate を指定した場合だけ受け付けられることをテストする
+func testProduceOffsets(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+
+	_, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("a"), Offset: 5}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.ProduceBatch(ctx, &api.ProduceBatchRequest{Records: []*api.Record{
+		{Value: []byte("a")},
+		{Value: []byte("b"), Offset: 1},
+	}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// 複製では、割り当て済みのオフセットが次のオフセットと一致する場合だけ追加する
+	res, err := client.Produce(ctx, &api.ProduceRequest{Replicate: true, Record: &api.Record{Value: []byte("a"), Offset: 0}})
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), res.Offset)
+	_, err = client.Produce(ctx, &api.ProduceRequest{Replicate: true, Record: &api.Record{Value: []byte("b"), Offset: 5}})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	res, err = client.Produce(ctx, &api.ProduceRequest{Replicate: true, Record: &api.Record{Value: []byte("b"), Offset: 1}})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.Offset)
+}
+
+// testAuthorizer: アクションごとに許可・拒否を決めるテスト用の Authorizer
+type testAuthorizer map[string]bool
+
+// Authorize: 許可されたアクションでなければエラーを返す
+func (a testAuthorizer) Authorize(subject, object, action string) error {
+	if !a[action] {
+		return fmt.Errorf("%q is not permitted to %s on %s", subject, action, object)
+	}
+	return nil
+}
+
+// TestAuthorizer: Authorizer が設定されている場合に、アクションごとに認可されることをテストする
+func TestAuthorizer(t *testing.T) {
+	client, _, teardown := setupTest(t, func(c *Config) {
+		c.Authorizer = testAuthorizer{
+			produceAction: true,
+			consumeAction: true,
+		}
+	})
+	defer teardown()
+
+	ctx := context.Background()
+
+	// 書き込みと読み取りは許可されている
+	produce, err := client.Produce(ctx, &api.ProduceRequest{
+		Record: &api.Record{Value: []byte("hello world")},
+	})
+	require.NoError(t, err)
+	_, err = client.Consume(ctx, &api.ConsumeRequest{Offset: produce.Offset})
+	require.NoError(t, err)
+
+	// 管理操作は別のアクションなので拒否される
+	_, err = client.TruncateLog(ctx, &api.TruncateLogRequest{BeforeOffset: 1})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// 割り当て済みのオフセットでの書き込みには複製の権限が必要
+	_, err = client.Produce(ctx, &api.ProduceRequest{Replicate: true, Record: &api.Record{Value: []byte("x"), Offset: 1}})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+// TestAdminAPI: 管理 API が Log サービスとは別のアクションで認可されることをテストする
+func TestAdminAPI(t *testing.T) {
+	client, config, teardown := setupTest(t, func(c *Config) {
+		c.Authorizer = testAuthorizer{
+			adminActionPrefix + createTopicAction: true,
+			adminActionPrefix + listTopicsAction:  true,
+		}
+	})
+	defer teardown()
+
+	admin := adminpb.NewAdminClient(dialServer(t, config))
+
+	ctx := context.Background()
+	_, err := admin.CreateTopic(ctx, &api.CreateTopicRequest{Name: "orders"})
+	require.NoError(t, err)
+	topics, err := admin.ListTopics(ctx, &api.ListTopicsRequest{})
+	require.NoError(t, err)
+	require.Len(t, topics.Topics, 1)
+
+	// 管理 API の権限では、Log サービスの互換用の RPC は呼び出せない
+	_, err = client.CreateTopic(ctx, &api.CreateTopicRequest{Name: "payments"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// 許可されていない管理操作は拒否される
+	_, err = admin.TruncateLog(ctx, &api.TruncateLogRequest{BeforeOffset: 1})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+// dialServer: 同じ設定の gRPC サーバーを別のリスナーで起動して接続する
+// setupTest のクライアントは Log サービスのものだけなので、他のサービスのクライアントを作成するために使用する。
+func dialServer(t *testing.T, config *Config) *grpc.ClientConn {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server, err := NewGRPCServer(config)
+	require.NoError(t, err)
+	go server.Serve(l)
+	t.Cleanup(server.Stop)
+	cc, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { cc.Close() })
+	return cc
+}
+
+// TestReplication: 複製の API が公開の ACL とは別に認証され、オフセットとタームを検証して追加することをテストする
+func TestReplication(t *testing.T) {
+	termsFile := filepath.Join(t.TempDir(), "terms.json")
+	client, config, teardown := setupTest(t, func(c *Config) {
+		// 公開の API はすべて拒否する
+		c.Authorizer = testAuthorizer{}
+		c.Credentials = testCredentials{subject: "node-1"}
+		c.Replication.Enabled = true
+		c.Replication.Peers = []string{"node-1"}
+		c.Replication.TermsFile = termsFile
+	})
+	defer teardown()
+	repl := replpb.NewReplicationClient(dialServer(t, config))
+	ctx := context.Background()
+
+	_, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("a")}})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	records := func(offsets ...uint64) []*api.Record {
+		var records []*api.Record
+		for _, off := range offsets {
+			records = append(records, &api.Record{Value: []byte(fmt.Sprint(off)), Offset: off})
+		}
+		return records
+	}
+	res, err := repl.ReplicateEntries(ctx, &replpb.ReplicateEntriesRequest{Term: 1, Records: records(0, 1)})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), res.NextOffset)
+	require.Equal(t, uint64(1), res.Term)
+
+	// 再送したレコードは読み飛ばし、続きのレコードだけを追加する
+	res, err = repl.ReplicateEntries(ctx, &replpb.ReplicateEntriesRequest{Term: 2, Records: records(1, 2)})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), res.NextOffset)
+	record, err := config.CommitLog.Read(2)
+	require.NoError(t, err)
+	require.Equal(t, "2", string(record.Value))
+
+	// 間が空いたレコードと古いタームのリクエストは拒否する
+	_, err = repl.ReplicateEntries(ctx, &replpb.ReplicateEntriesRequest{Term: 2, Records: records(5)})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = repl.ReplicateEntries(ctx, &replpb.ReplicateEntriesRequest{Term: 1, Records: records(3)})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	stream, err := repl.FetchSegment(ctx, &replpb.FetchSegmentRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, io.EOF, err)
+
+	// タームはトピックごとに管理する
+	_, err = config.Topics.Create("orders")
+	require.NoError(t, err)
+	res, err = repl.ReplicateEntries(ctx, &replpb.ReplicateEntriesRequest{Topic: "orders", Term: 1, Records: records(0)})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.Term)
+
+	// 再起動してもタームを復元し、古いタームのリクエストを拒否する
+	repl = replpb.NewReplicationClient(dialServer(t, config))
+	_, err = repl.ReplicateEntries(ctx, &replpb.ReplicateEntriesRequest{Term: 1, Records: records(3)})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	res, err = repl.ReplicateEntries(ctx, &replpb.ReplicateEntriesRequest{Term: 2, Records: records(3)})
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), res.NextOffset)
+
+	// Peers に含まれないノードは拒否する
+	config.Replication.Peers = []string{"node-2"}
+	_, err = repl.ReplicateEntries(ctx, &replpb.ReplicateEntriesRequest{Term: 2, Records: records(4)})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	// Peers が空の場合はすべて拒否する
+	config.Replication.Peers = nil
+	_, err = repl.ReplicateEntries(ctx, &replpb.ReplicateEntriesRequest{Term: 2, Records: records(4)})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = NewGRPCServer(config)
+	require.Error(t, err)
+}
+
+// testReadBarrier: 呼び出し回数を数え、設定されたエラーを返すテスト用の ReadBarrier
+type testReadBarrier struct {
+	calls int
+	err   error
+}
+
+// Barrier: 呼び出し回数を記録して、設定されたエラーを返す
+func (b *testReadBarrier) Barrier(ctx context.Context) error {
+	b.calls++
+	return b.err
+}
+
+// TestLinearizableConsume: LINEARIZABLE を指定した読み取りのみが ReadBarrier を通ることをテストする
+func TestLinearizableConsume(t *testing.T) {
+	barrier := &testReadBarrier{}
+	client, _, teardown := setupTest(t, func(c *Config) {
+		c.ReadBarrier = barrier
+	})
+	defer teardown()
+
+	ctx := context.Background()
+	produce, err := client.Produce(ctx, &api.ProduceRequest{
+		Record: &api.Record{Value: []byte("hello world")},
+	})
+	require.NoError(t, err)
+
+	// デフォルト（EVENTUAL）の読み取りはバリアを通らない
+	_, err = client.Consume(ctx, &api.ConsumeRequest{Offset: produce.Offset})
+	require.NoError(t, err)
+	require.Equal(t, 0, barrier.calls)
+
+	_, err = client.Consume(ctx, &api.ConsumeRequest{
+		Offset:      produce.Offset,
+		Consistency: api.Consistency_LINEARIZABLE,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, barrier.calls)
+
+	// バリアが失敗した場合（リーダーでない場合など）、読み取りは失敗する
+	barrier.err = fmt.Errorf("not the leader")
+	_, err = client.Consume(ctx, &api.ConsumeRequest{
+		Offset:      produce.Offset,
+		Consistency: api.Consistency_LINEARIZABLE,
+	})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+// testTopicLifecycle: トピックの作成・一覧・削除と、トピックを指定した読み書きをテストする
+// 引数:
+//   - t: テストヘルパー
+//   - client: gRPC クライアント
+//   - config: サーバーの設定（このテストでは使用しない）
+func testTopicLifecycle(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+
+	// 存在しないトピックには書き込めない（暗黙的に作成されない）
+	_, err := client.Produce(ctx, &api.ProduceRequest{
+		Topic:  "orders",
+		Record: &api.Record{Value: []byte("hello world")},
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CreateTopic(ctx, &api.CreateTopicRequest{Name: "orders"})
+	require.NoError(t, err)
+	_, err = client.CreateTopic(ctx, &api.CreateTopicRequest{Name: "orders"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = client.CreateTopic(ctx, &api.CreateTopicRequest{Name: "../orders"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	list, err := client.ListTopics(ctx, &api.ListTopicsRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"orders"}, list.Topics)
+
+	// トピックごとに独立したオフセットが割り当てられる
+	produce, err := client.Produce(ctx, &api.ProduceRequest{
+		Topic:  "orders",
+		Record: &api.Record{Value: []byte("hello orders")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), produce.Offset)
+	consume, err := client.Consume(ctx, &api.ConsumeRequest{Topic: "orders", Offset: 0})
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello orders"), consume.Record.Value)
+	_, err = client.Consume(ctx, &api.ConsumeRequest{Offset: 0})
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+
+	// 読み取り中のストリームがあるトピックは削除できない
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := client.ConsumeStream(streamCtx, &api.ConsumeRequest{Topic: "orders", Offset: 0})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	_, err = client.DeleteTopic(ctx, &api.DeleteTopicRequest{Name: "orders"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	cancel()
+
+	// ストリームが終了すると削除できる
+	require.Eventually(t, func() bool {
+		_, err = client.DeleteTopic(ctx, &api.DeleteTopicRequest{Name: "orders"})
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	_, err = client.DeleteTopic(ctx, &api.DeleteTopicRequest{Name: "orders"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	list, err = client.ListTopics(ctx, &api.ListTopicsRequest{})
+	require.NoError(t, err)
+	require.Empty(t, list.Topics)
+
+	// 削除したトピックは保持期間内であれば元に戻せる
+	_, err = client.UndeleteTopic(ctx, &api.UndeleteTopicRequest{Name: "orders"})
+	require.NoError(t, err)
+	consume, err = client.Consume(ctx, &api.ConsumeRequest{Topic: "orders", Offset: 0})
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello orders"), consume.Record.Value)
+	_, err = client.UndeleteTopic(ctx, &api.UndeleteTopicRequest{Name: "orders"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+// TestCompression: クライアントが選択した圧縮方式で読み書きできることをテストする
+func TestCompression(t *testing.T) {
+	client, _, teardown := setupTest(t, nil)
+	defer teardown()
+
+	ctx := context.Background()
+	value := []byte(strings.Repeat("hello world ", 1024))
+	for _, name := range []string{compression.None, compression.Gzip, compression.Zstd} {
+		opt, err := compression.CallOption(name)
+		require.NoError(t, err)
+
+		produce, err := client.Produce(ctx, &api.ProduceRequest{
+			Record: &api.Record{Value: value},
+		}, opt)
+		require.NoError(t, err)
+
+		consume, err := client.Consume(ctx, &api.ConsumeRequest{Offset: produce.Offset}, opt)
+		require.NoError(t, err)
+		require.Equal(t, value, consume.Record.Value)
+	}
+
+	_, err := compression.CallOption("lz4")
+	require.Error(t, err)
+}
+
+// testLongPollConsume: max_wait_ms を指定した Consume が、レコードが追加されるまで待つことをテストする
+// 引数:
+//   - t: テストヘルパー
+//   - client: gRPC クライアント
+//   - config: サーバーの設定（このテストでは使用しない）
+func testLongPollConsume(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+
+	// 待機時間内にレコードが追加されなければ、範囲外のエラーを返す
+	_, err := client.Consume(ctx, &api.ConsumeRequest{Offset: 0, MaxWaitMs: 50})
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+
+	// 待機中に追加されたレコードを返す
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		client.Produce(ctx, &api.ProduceRequest{
+			Record: &api.Record{Value: []byte("first")},
+		})
+	}()
+	consume, err := client.Consume(ctx, &api.ConsumeRequest{Offset: 0, MaxWaitMs: 5000})
+	require.NoError(t, err)
+	require.Equal(t, []byte("first"), consume.Record.Value)
+
+	// min_bytes に達するまで待つ（"first" の 5 バイトでは足りない）
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		client.Produce(ctx, &api.ProduceRequest{
+			Record: &api.Record{Value: []byte("second")},
+		})
+	}()
+	start := time.Now()
+	consume, err = client.Consume(ctx, &api.ConsumeRequest{Offset: 0, MaxWaitMs: 5000, MinBytes: 8})
+	require.NoError(t, err)
+	require.Equal(t, []byte("first"), consume.Record.Value)
+	require.True(t, time.Since(start) >= 50*time.Millisecond)
+}
+
+func testLogInfo(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Produce(ctx, &api.ProduceRequest{
+			Record: &api.Record{Value: []byte("hello world")},
+		})
+		require.NoError(t, err)
+	}
+
+	res, err := client.GetLogInfo(ctx, &api.GetLogInfoRequest{})
+	require.NoError(t, err)
+	var records uint64
+	for _, seg := range res.Segments {
+		records += seg.Records
+	}
+	require.Equal(t, uint64(3), records)
+	require.Equal(t, uint64(3), res.Records)
+	var bytes uint64
+	for _, seg := range res.Segments {
+		bytes += seg.StoreBytes
+	}
+	require.Equal(t, bytes, res.Bytes)
+	require.True(t, res.Segments[len(res.Segments)-1].Active)
+	// インデックスには1レコードあたり 12 バイトのエントリを書き込む
+	require.Equal(t, uint64(3*12), res.Writes.IndexBytes)
+	require.NotZero(t, res.Writes.RecordBytes)
+	require.Zero(t, res.Writes.RewriteBytes)
+
+	// 存在しないトピック
+	_, err = client.GetLogInfo(ctx, &api.GetLogInfoRequest{Topic: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CreateTopic(ctx, &api.CreateTopicRequest{Name: "orders"})
+	require.NoError(t, err)
+
+	// デフォルトのログストアとトピックのセグメントのメトリクスが公開される
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(NewSegmentCollector(config)))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	topics := map[string]float64{}
+	logRecords := map[string]float64{}
+	indexWritten := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() == "proglog_log_written_bytes_total" {
+			for _, m := range family.GetMetric() {
+				if m.GetLabel()[0].GetValue() == "index" {
+					indexWritten[m.GetLabel()[1].GetValue()] = m.GetCounter().GetValue()
+				}
+			}
+		}
+		if family.GetName() == "proglog_log_records" {
+			for _, m := range family.GetMetric() {
+				logRecords[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+			}
+		}
+		if family.GetName() != "proglog_segment_records" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "topic" {
+					topics[label.GetValue()] += m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	require.Equal(t, map[string]float64{"": 3, "orders": 0}, topics)
+	require.Equal(t, topics, logRecords)
+	require.Equal(t, map[string]float64{"": 3 * 12, "orders": 0}, indexWritten)
+}
+
+// TestSegmentAge: セグメントの経過時間を、ファイルの更新時刻ではなく設定した時計で計算することをテストする
+func TestSegmentAge(t *testing.T) {
+	ctx := context.Background()
+	clock := log.NewFakeClock(time.UnixMilli(1700000000000))
+	clog, err := log.NewLog(t.TempDir(), log.Config{Clock: clock})
+	require.NoError(t, err)
+	defer clog.Close()
+	config := &Config{CommitLog: clog, Clock: clock}
+	client := api.NewLogClient(dialServer(t, config))
+
+	_, err = client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("hello world")}})
+	require.NoError(t, err)
+	clock.Advance(90 * time.Second)
+
+	res, err := client.GetLogInfo(ctx, &api.GetLogInfoRequest{})
+	require.NoError(t, err)
+	require.Len(t, res.Segments, 1)
+	require.Equal(t, uint64(90000), res.Segments[0].AgeMs)
+
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(NewSegmentCollector(config)))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var age float64
+	for _, family := range families {
+		if family.GetName() == "proglog_segment_age_seconds" {
+			age = family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	require.Equal(t, 90.0, age)
+}
+
+func testGetValue(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+
+	for _, value := range []string{"1", "2"} {
+		_, err := client.Produce(ctx, &api.ProduceRequest{
+			Record: &api.Record{Key: []byte("a"), Value: []byte(value)},
+		})
+		require.NoError(t, err)
+	}
+
+	// ビューはログを非同期に読み取るため、反映されるまで待つ
+	require.Eventually(t, func() bool {
+		res, err := client.GetValue(ctx, &api.GetValueRequest{Key: []byte("a")})
+		return err == nil && string(res.Value) == "2" && res.Offset == 1
+	}, time.Second, 10*time.Millisecond)
+
+	_, err := client.GetValue(ctx, &api.GetValueRequest{Key: []byte("missing")})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func testQuery(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+
+	for _, record := range []*api.Record{
+		{Key: []byte("user-1"), Value: []byte(`{"status": 200}`)},
+		{Key: []byte("user-2"), Value: []byte(`{"status": 500}`), Headers: map[string]string{"source": "web"}},
+		{Key: []byte("order-1"), Value: []byte(`{"status": 500}`)},
+		{Key: []byte("user-3"), Value: []byte(`{"status": 503}`), Headers: map[string]string{"source": "web"}},
+	} {
+		_, err := client.Produce(ctx, &api.ProduceRequest{Record: record})
+		require.NoError(t, err)
+	}
+
+	query := func(req *api.QueryRequest) []uint64 {
+		stream, err := client.Query(ctx, req)
+		require.NoError(t, err)
+		var offsets []uint64
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return offsets
+			}
+			require.NoError(t, err)
+			// サーバーが受け付けた時刻がタイムスタンプとして設定される
+			require.NotZero(t, res.Record.Timestamp)
+			offsets = append(offsets, res.Record.Offset)
+		}
+	}
+
+	require.Equal(t, []uint64{1, 3}, query(&api.QueryRequest{
+		Filter: `key ^= "user-" AND value.status >= 500`,
+	}))
+	require.Equal(t, []uint64{1}, query(&api.QueryRequest{
+		Filter: `header.source = "web"`,
+		Limit:  1,
+	}))
+	require.Equal(t, []uint64{3}, query(&api.QueryRequest{
+		Filter:     `header.source = "web"`,
+		FromOffset: 2,
+	}))
+
+	// 不正な絞り込み条件
+	stream, err := client.Query(ctx, &api.QueryRequest{Filter: "size > 1"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func testRateLimitedConsumeStream(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+
+	value := []byte(strings.Repeat("a", 500))
+	for i := 0; i < 3; i++ {
+		_, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: value}})
+		require.NoError(t, err)
+	}
+
+	// 1秒あたり 1000 バイトに制限すると、最初の 1000 バイト（2件）の後は 0.5 秒ごとに1件送信される
+	start := time.Now()
+	stream, err := client.ConsumeStream(ctx, &api.ConsumeRequest{RateLimitBytesPerSec: 1000})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		res, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, uint64(i), res.Record.Offset)
+	}
+	require.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+}
+
+func testExportImportOffsets(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+
+	_, err := client.FetchOffset(ctx, &api.FetchOffsetRequest{Group: "billing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CommitOffset(ctx, &api.CommitOffsetRequest{Group: "billing", Offset: 5})
+	require.NoError(t, err)
+	exported, err := client.ExportOffsets(ctx, &api.ExportOffsetsRequest{})
+	require.NoError(t, err)
+
+	// 別のグループの位置を進めた後、エクスポートした状態に置き換える
+	_, err = client.CommitOffset(ctx, &api.CommitOffsetRequest{Group: "search", Offset: 9})
+	require.NoError(t, err)
+	res, err := client.ImportOffsets(ctx, &api.ImportOffsetsRequest{
+		Checkpoint: exported.Checkpoint,
+		Replace:    true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.Imported)
+
+	fetched, err := client.FetchOffset(ctx, &api.FetchOffsetRequest{Group: "billing"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), fetched.Offset)
+	_, err = client.FetchOffset(ctx, &api.FetchOffsetRequest{Group: "search"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.ImportOffsets(ctx, &api.ImportOffsetsRequest{Checkpoint: []byte("not json")})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestProduceDeadline(t *testing.T) {
+	dir, err := os.MkdirTemp("", "produce-deadline-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clog, err := log.NewLog(dir, log.Config{})
+	require.NoError(t, err)
+	defer clog.Close()
+	srv, err := newgrpcServer(&Config{CommitLog: clog})
+	require.NoError(t, err)
+
+	req := func() *api.ProduceRequest {
+		return &api.ProduceRequest{Record: &api.Record{Value: []byte("hello world")}}
+	}
+
+	// 期限内に完了した書き込みは、期限までの残り時間が記録される
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err = srv.Produce(ctx, req())
+	require.NoError(t, err)
+	m := &dto.Metric{}
+	require.NoError(t, ProduceDeadlineHeadroom.Write(m))
+	require.NotZero(t, m.GetHistogram().GetSampleCount())
+
+	// 期限を過ぎたリクエストは書き込まずに失敗する
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = srv.Produce(expired, req())
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	// 残り時間が書き込みにかかる時間より短い場合も、書き込まずに失敗する
+	srv.appendLatency.Store(int64(time.Hour))
+	_, err = srv.Produce(ctx, req())
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	// 期限のないリクエストは影響を受けない
+	_, err = srv.Produce(context.Background(), req())
+	require.NoError(t, err)
+
+	highest, err := clog.HighestOffset()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), highest)
+}
+
+func testProduceBatch(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+
+	_, err := client.ProduceBatch(ctx, &api.ProduceBatchRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	values := []string{"a", "b", "c"}
+	req := &api.ProduceBatchRequest{}
+	for _, v := range values {
+		req.Records = append(req.Records, &api.Record{Value: []byte(v)})
+	}
+	res, err := client.ProduceBatch(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), res.Offset)
+
+	// バッチのレコードには連続したオフセットが割り当てられる
+	for i, v := range values {
+		consume, err := client.Consume(ctx, &api.ConsumeRequest{Offset: uint64(i)})
+		require.NoError(t, err)
+		require.Equal(t, []byte(v), consume.Record.Value)
+		require.Equal(t, config.Clock.Now().UnixMilli(), consume.Record.Timestamp)
+	}
+}
+
+// testExportParquet: エクスポートのジョブがログの範囲を Parquet ファイルに書き込むことをテストする
+func testExportParquet(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+	for _, v := range []string{"a", "b", "c"} {
+		_, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte(v)}})
+		require.NoError(t, err)
+	}
+
+	_, err := client.StartExport(ctx, &api.StartExportRequest{Columns: "size"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	started, err := client.StartExport(ctx, &api.StartExportRequest{FromOffset: 1, Columns: "offset,value:string"})
+	require.NoError(t, err)
+	var job *api.ExportJob
+	require.Eventually(t, func() bool {
+		job, err = client.GetExport(ctx, &api.GetExportRequest{JobId: started.JobId})
+		require.NoError(t, err)
+		return job.State != api.ExportJob_RUNNING
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, api.ExportJob_DONE, job.State, job.Error)
+	require.Equal(t, uint64(2), job.Records)
+	require.Equal(t, filepath.Join(config.ExportDir, started.JobId+".parquet"), job.Path)
+
+	b, err := os.ReadFile(job.Path)
+	require.NoError(t, err)
+	require.Equal(t, "PAR1", string(b[:4]))
+	require.Equal(t, "PAR1", string(b[len(b)-4:]))
+
+	_, err = client.GetExport(ctx, &api.GetExportRequest{JobId: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// TestExportJobs: 完了したエクスポートのジョブが保持期間を過ぎると捨てられ、ジョブの数が上限を超えないことをテストする
+func TestExportJobs(t *testing.T) {
+	client, config, teardown := setupTest(t, func(config *Config) {
+		config.ExportJobTTL = time.Minute
+		config.MaxExportJobs = 2
+	})
+	defer teardown()
+	ctx := context.Background()
+	clock := config.Clock.(*log.FakeClock)
+
+	_, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("a")}})
+	require.NoError(t, err)
+	start := func() string {
+		started, err := client.StartExport(ctx, &api.StartExportRequest{Columns: "offset"})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			job, err := client.GetExport(ctx, &api.GetExportRequest{JobId: started.JobId})
+			require.NoError(t, err)
+			return job.State == api.ExportJob_DONE
+		}, time.Second, 10*time.Millisecond)
+		return started.JobId
+	}
+
+	// 上限に達すると、完了した古いジョブから捨てる
+	first := start()
+	clock.Advance(time.Second)
+	second := start()
+	clock.Advance(time.Second)
+	third := start()
+	_, err = client.GetExport(ctx, &api.GetExportRequest{JobId: first})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.GetExport(ctx, &api.GetExportRequest{JobId: second})
+	require.NoError(t, err)
+
+	// 保持期間を過ぎた完了したジョブは捨てる
+	clock.Advance(time.Minute)
+	for _, id := range []string{second, third} {
+		_, err = client.GetExport(ctx, &api.GetExportRequest{JobId: id})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	}
+}
+
+// TestRedaction: 書き込む前とエクスポートする前に、それぞれのルールでレコードがマスキングされることをテストする
+func TestRedaction(t *testing.T) {
+	client, config, teardown := setupTest(t, func(config *Config) {
+		var err error
+		config.ProduceRedactor, err = redact.New([]redact.Rule{{Field: "value.email"}})
+		require.NoError(t, err)
+		config.ExportRedactor, err = redact.New([]redact.Rule{{Field: "value", Pattern: "alice", Replace: "user"}})
+		require.NoError(t, err)
+	})
+	defer teardown()
+	ctx := context.Background()
+
+	produce, err := client.Produce(ctx, &api.ProduceRequest{
+		Record: &api.Record{Value: []byte(`{"email":"alice@example.com","name":"alice"}`)},
+	})
+	require.NoError(t, err)
+	// クライアントに返すレコードには、書き込み時とエクスポート時の両方のマスキングが適用される
+	masked := `{"email":"[REDACTED]","name":"user"}`
+	consume, err := client.Consume(ctx, &api.ConsumeRequest{Offset: produce.Offset})
+	require.NoError(t, err)
+	require.Equal(t, masked, string(consume.Record.Value))
+
+	stream, err := client.ConsumeStream(ctx, &api.ConsumeRequest{Offset: produce.Offset})
+	require.NoError(t, err)
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, masked, string(res.Record.Value))
+
+	// Query はマスキングした値で絞り込む（元の値では一致しない）
+	query := func(filter string) []string {
+		stream, err := client.Query(ctx, &api.QueryRequest{Filter: filter})
+		require.NoError(t, err)
+		var values []string
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return values
+			}
+			require.NoError(t, err)
+			values = append(values, string(res.Record.Value))
+		}
+	}
+	require.Empty(t, query(`value.name = "alice"`))
+	require.Equal(t, []string{masked}, query(`value.name = "user"`))
+
+	started, err := client.StartExport(ctx, &api.StartExportRequest{Columns: "value:string"})
+	require.NoError(t, err)
+	var job *api.ExportJob
+	require.Eventually(t, func() bool {
+		job, err = client.GetExport(ctx, &api.GetExportRequest{JobId: started.JobId})
+		require.NoError(t, err)
+		return job.State != api.ExportJob_RUNNING
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, api.ExportJob_DONE, job.State, job.Error)
+	b, err := os.ReadFile(job.Path)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"name":"user"`)
+	require.NotContains(t, string(b), "alice")
+
+	// セグメントファイルはマスキングせずにそのまま送るため、Log サービスのクライアントは取得できない
+	// （セグメントの転送は複製元のノードだけが使える replication.v1.Replication の FetchSegment で行う）
+	fetch, err := dialServer(t, config).NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/log.v1.Log/FetchSegments")
+	require.NoError(t, err)
+	require.NoError(t, fetch.SendMsg(&replpb.FetchSegmentRequest{}))
+	require.NoError(t, fetch.CloseSend())
+	require.Equal(t, codes.Unimplemented, status.Code(fetch.RecvMsg(&api.SegmentChunk{})))
+
+	// ログには書き込み時のマスキングだけが適用されたレコードが残る
+	record, err := config.CommitLog.Read(produce.Offset)
+	require.NoError(t, err)
+	require.Contains(t, string(record.Value), `"name":"alice"`)
+}
+
+// TestVerboseProduce: verbose を指定した Produce がレコードの配置とノードの ID を返すことをテストする
+func TestVerboseProduce(t *testing.T) {
+	client, config, teardown := setupTest(t, func(config *Config) {
+		config.NodeID = "node-1"
+	})
+	defer teardown()
+	ctx := context.Background()
+
+	first, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("a")}})
+	require.NoError(t, err)
+	require.Empty(t, first.NodeId)
+
+	second, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("b")}, Verbose: true})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), second.Offset)
+	require.Equal(t, "node-1", second.NodeId)
+	base, pos, err := config.CommitLog.(locatingLog).Locate(second.Offset)
+	require.NoError(t, err)
+	require.Equal(t, base, second.SegmentBaseOffset)
+	require.Equal(t, pos, second.Position)
+	require.NotZero(t, second.Position)
+}
+
+// TestProduceReceipt: 受領証の署名を公開鍵で検証でき、保存されたレコードと一致することをテストする
+func TestProduceReceipt(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	client, config, teardown := setupTest(t, func(config *Config) {
+		config.NodeID = "node-1"
+		config.ReceiptKey = key
+	})
+	defer teardown()
+	ctx := context.Background()
+
+	res, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("audit")}, Receipt: true})
+	require.NoError(t, err)
+	receipt := res.Receipt
+	require.NotNil(t, receipt)
+	require.Equal(t, res.Offset, receipt.Offset)
+	require.Equal(t, "node-1", receipt.NodeId)
+	record, err := config.CommitLog.Read(res.Offset)
+	require.NoError(t, err)
+	require.NoError(t, api.VerifyReceipt(pub, receipt, record))
+
+	// 公開鍵は GetCapabilities でも取得できる
+	caps, err := client.GetCapabilities(ctx, &api.GetCapabilitiesRequest{})
+	require.NoError(t, err)
+	require.Contains(t, caps.Features, FeatureReceipts)
+	advertised, err := x509.ParsePKIXPublicKey(caps.ReceiptPublicKey)
+	require.NoError(t, err)
+	require.Equal(t, pub, advertised)
+
+	// 受領証を書き換えた場合や、別のレコードとは一致しない
+	forged := proto.Clone(receipt).(*api.ProduceReceipt)
+	forged.Offset++
+	require.ErrorIs(t, api.VerifyReceipt(pub, forged, nil), api.ErrInvalidReceipt)
+	record.Value = []byte("altered")
+	require.ErrorIs(t, api.VerifyReceipt(pub, receipt, record), api.ErrInvalidReceipt)
+
+	// 要求しない場合は受領証を返さない
+	res, err = client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("plain")}})
+	require.NoError(t, err)
+	require.Nil(t, res.Receipt)
+
+	// 署名鍵のないサーバーは、書き込む前に失敗させる
+	config.ReceiptKey = nil
+	_, err = client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("x")}, Receipt: true})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Equal(t, uint64(2), config.CommitLog.(endOffsetLog).NextOffset())
+}
+
+// TestGetTreeHead: 署名付きのツリーヘッドと証明を取得でき、レコードから計算したルートハッシュで検証できることをテストする
+func TestGetTreeHead(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	client, config, teardown := setupTest(t, nil)
+	defer teardown()
+	ctx := context.Background()
+
+	// ツリーヘッドが無効のサーバーは失敗させる
+	_, err = client.GetTreeHead(ctx, &api.GetTreeHeadRequest{})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	var leaves [][]byte
+	for _, v := range []string{"a", "b", "c"} {
+		res, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte(v)}})
+		require.NoError(t, err)
+		record, err := config.CommitLog.Read(res.Offset)
+		require.NoError(t, err)
+		leaf, err := api.LeafHash(record)
+		require.NoError(t, err)
+		leaves = append(leaves, leaf)
+	}
+	tree, err := merkle.NewTree(filepath.Join(t.TempDir(), "tree"), config.CommitLog.(merkle.Log), merkle.Config{
+		Signer: key,
+		NodeID: "node-1",
+		Clock:  config.Clock,
+	})
+	require.NoError(t, err)
+	defer tree.Close()
+	require.Eventually(t, func() bool { return tree.Size() == 3 }, time.Second, 10*time.Millisecond)
+	config.Clock.(*log.FakeClock).Advance(merkle.DefaultSignInterval)
+	config.TreeHeads = tree
+
+	var head *api.TreeHead
+	require.Eventually(t, func() bool {
+		res, err := client.GetTreeHead(ctx, &api.GetTreeHeadRequest{})
+		require.NoError(t, err)
+		head = res.TreeHead
+		return head.TreeSize == 3
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, api.RootHash(leaves), head.RootHash)
+	require.Equal(t, "node-1", head.NodeId)
+	require.NoError(t, api.VerifyTreeHead(pub, head))
+
+	caps, err := client.GetCapabilities(ctx, &api.GetCapabilitiesRequest{})
+	require.NoError(t, err)
+	require.Contains(t, caps.Features, FeatureTreeHeads)
+
+	// 最新のツリーヘッドに対する包含証明で、読み取ったレコードを検証できる
+	inclusion, err := client.GetInclusionProof(ctx, &api.GetInclusionProofRequest{Offset: 1})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), inclusion.LeafIndex)
+	require.Equal(t, uint64(3), inclusion.TreeSize)
+	require.True(t, proto.Equal(head, inclusion.TreeHead))
+	record, err := config.CommitLog.Read(1)
+	require.NoError(t, err)
+	require.NoError(t, api.VerifyRecordInclusion(head, record, inclusion.Hashes))
+	_, err = client.GetInclusionProof(ctx, &api.GetInclusionProofRequest{Offset: 1, TreeSize: 4})
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+
+	// 古いサイズのツリーから最新のツリーヘッドへの一貫性証明を検証できる
+	consistency, err := client.GetConsistencyProof(ctx, &api.GetConsistencyProofRequest{OldSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), consistency.NewSize)
+	require.NoError(t, api.VerifyConsistency(2, 3, api.RootHash(leaves[:2]), head.RootHash, consistency.Hashes))
+	_, err = client.GetConsistencyProof(ctx, &api.GetConsistencyProofRequest{OldSize: 3, NewSize: 2})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestDelayedRecords: 配信時刻（deliver_after）が来るまでレコードを Consume から隠し、配信時刻になったら
+// ConsumeStream で送信することをテストする
+func TestDelayedRecords(t *testing.T) {
+	client, config, teardown := setupTest(t, nil)
+	defer teardown()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := config.Clock.(*log.FakeClock)
+	start := clock.Now().UnixMilli()
+
+	for _, record := range []*api.Record{
+		{Value: []byte("now")},
+		{Value: []byte("later"), DeliverAfter: start + 1000},
+		{Value: []byte("next")},
+	} {
+		_, err := client.Produce(ctx, &api.ProduceRequest{Record: record})
+		require.NoError(t, err)
+	}
+
+	// 単一リクエストの Consume は配信時刻の前のレコードを返さない
+	_, err := client.Consume(ctx, &api.ConsumeRequest{Offset: 1})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Equal(t, api.ErrRecordDelayed{Offset: 1, DeliverAfter: start + 1000}.Error(), err.Error())
+	res, err := client.Consume(ctx, &api.ConsumeRequest{Offset: 0, MaxRecords: 10})
+	require.NoError(t, err)
+	require.Len(t, res.Records, 1)
+	require.Equal(t, uint64(1), res.NextOffset)
+
+	// ConsumeStream は読み飛ばして、配信時刻になったら現在の next_offset とともに送信する
+	stream, err := client.ConsumeStream(ctx, &api.ConsumeRequest{Offset: 0})
+	require.NoError(t, err)
+	for _, want := range []string{"now", "next"} {
+		res, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, want, string(res.Record.Value))
+	}
+
+	// Query も配信時刻の前のレコードを読み飛ばす
+	query := func() []string {
+		stream, err := client.Query(ctx, &api.QueryRequest{Filter: "time >= 0"})
+		require.NoError(t, err)
+		var values []string
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return values
+			}
+			require.NoError(t, err)
+			values = append(values, string(res.Record.Value))
+		}
+	}
+	require.Equal(t, []string{"now", "next"}, query())
+
+	clock.Advance(time.Second)
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "later", string(res.Record.Value))
+	require.Equal(t, uint64(3), res.NextOffset)
+	require.Equal(t, []string{"now", "later", "next"}, query())
+
+	// 再接続したストリームは、delayed_since より後に配信時刻が来たレコードを開始オフセットより前でも送信する
+	stream, err = client.ConsumeStream(ctx, &api.ConsumeRequest{Offset: 3, DelayedSince: start})
+	require.NoError(t, err)
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.Record.Offset)
+	require.Equal(t, uint64(3), res.NextOffset)
+
+	// 配信時刻が来ていないレコードは、単一リクエストの Consume で続けて返すレコードにも含めない
+	_, err = client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("delayed"), DeliverAfter: start + 5000}})
+	require.NoError(t, err)
+	res, err = client.Consume(ctx, &api.ConsumeRequest{Offset: 1, MaxRecords: 10})
+	require.NoError(t, err)
+	require.Len(t, res.Records, 2)
+	require.Equal(t, uint64(3), res.NextOffset)
+}
+
+// TestLargeRecord: レコードの最大バイト数から導出した最大メッセージサイズで、4MiB を超えるレコードを読み書きできることをテストする
+func TestLargeRecord(t *testing.T) {
+	client, _, teardown := setupTest(t, func(config *Config) {
+		c := log.Config{}
+		c.Segment.MaxStoreBytes = 16 * 1024 * 1024
+		c.Segment.MaxRecordBytes = 8 * 1024 * 1024
+		clog, err := log.NewLog(t.TempDir(), c)
+		require.NoError(t, err)
+		t.Cleanup(func() { clog.Close() })
+		config.CommitLog = clog
+	})
+	defer teardown()
+	ctx := context.Background()
+
+	caps, err := client.GetCapabilities(ctx, &api.GetCapabilitiesRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(8*1024*1024+messageOverheadBytes), caps.MaxMessageBytes)
+
+	value := bytes.Repeat([]byte("x"), 5*1024*1024)
+	produce, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: value}})
+	require.NoError(t, err)
+
+	// クライアントの既定の受信の上限（4MiB）では受け取れないため、サーバーの上限に合わせる
+	_, err = client.Consume(ctx, &api.ConsumeRequest{Offset: produce.Offset})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	consume, err := client.Consume(ctx, &api.ConsumeRequest{Offset: produce.Offset}, api.MessageSizeCallOptions(caps)...)
+	require.NoError(t, err)
+	require.Equal(t, value, consume.Record.Value)
+
+	// 複数のレコードを返す場合も、最大メッセージサイズを超える手前で止める
+	for i := 0; i < 2; i++ {
+		_, err = client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: value}})
+		require.NoError(t, err)
+	}
+	// （record と records に重複させると超える場合は record を省略する）
+	consume, err = client.Consume(ctx, &api.ConsumeRequest{Offset: produce.Offset, MaxRecords: 3}, api.MessageSizeCallOptions(caps)...)
+	require.NoError(t, err)
+	require.Nil(t, consume.Record)
+	require.Len(t, consume.Records, 1)
+
+	// レコードの上限を超えるレコードは、トランスポート層ではなくログストアで拒否される
+	_, err = client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: bytes.Repeat([]byte("x"), 8*1024*1024+1)}})
+	require.Error(t, err)
+	require.NotContains(t, status.Convert(err).Message(), "larger than max")
+}
+
+// TestPropagateMetadata: 許可リストにある gRPC メタデータがレコードのヘッダーにコピーされることをテストする
+func TestPropagateMetadata(t *testing.T) {
+	client, _, teardown := setupTest(t, func(config *Config) {
+		config.PropagateMetadata = []string{"X-Trace-Id", "x-tenant-id"}
+	})
+	defer teardown()
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"x-trace-id", "abc", "x-tenant-id", "t1", "x-secret", "s")
+
+	produce, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{
+		Value:   []byte("v"),
+		Headers: map[string]string{"x-tenant-id": "client"},
+	}})
+	require.NoError(t, err)
+	consume, err := client.Consume(ctx, &api.ConsumeRequest{Offset: produce.Offset})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"x-trace-id": "abc", "x-tenant-id": "client"}, consume.Record.Headers)
+
+	batch, err := client.ProduceBatch(ctx, &api.ProduceBatchRequest{Records: []*api.Record{{Value: []byte("v")}}})
+	require.NoError(t, err)
+	consume, err = client.Consume(ctx, &api.ConsumeRequest{Offset: batch.Offset})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"x-trace-id": "abc", "x-tenant-id": "t1"}, consume.Record.Headers)
+}
+
+// TestUpcast: Consume と ConsumeStream が古いバージョンのレコードを最新のバージョンに書き換えて返すことをテストする
+func TestUpcast(t *testing.T) {
+	client, config, teardown := setupTest(t, func(config *Config) {
+		config.Upcasts = upcast.NewRegistry()
+		require.NoError(t, config.Upcasts.Register("1", "2", func(record *api.Record) error {
+			record.Value = bytes.ToUpper(record.Value)
+			return nil
+		}))
+	})
+	defer teardown()
+	ctx := context.Background()
+
+	for _, record := range []*api.Record{
+		{Value: []byte("old"), Headers: map[string]string{upcast.VersionHeader: "1"}},
+		{Value: []byte("NEW"), Headers: map[string]string{upcast.VersionHeader: "2"}},
+	} {
+		_, err := client.Produce(ctx, &api.ProduceRequest{Record: record})
+		require.NoError(t, err)
+	}
+
+	consume, err := client.Consume(ctx, &api.ConsumeRequest{Offset: 0})
+	require.NoError(t, err)
+	require.Equal(t, []byte("OLD"), consume.Record.Value)
+	require.Equal(t, "2", consume.Record.Headers[upcast.VersionHeader])
+
+	stream, err := client.ConsumeStream(ctx, &api.ConsumeRequest{Offset: 0})
+	require.NoError(t, err)
+	for _, want := range []string{"OLD", "NEW"} {
+		res, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, want, string(res.Record.Value))
+		require.Equal(t, "2", res.Record.Headers[upcast.VersionHeader])
+	}
+
+	// ログのレコードは書き換えない
+	record, err := config.CommitLog.Read(0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("old"), record.Value)
+}
+
+// corruptLog: 指定されたオフセットの読み取りで破損のエラーを返すテスト用のログストア
+type corruptLog struct {
+	CommitLog
+	corrupt map[uint64]error
+}
+
+func (l corruptLog) Read(off uint64) (*api.Record, error) {
+	if err, ok := l.corrupt[off]; ok {
+		return nil, err
+	}
+	return l.CommitLog.Read(off)
+}
+
+// TestSkipCorrupt: skip_corrupt を指定したストリームが、壊れたレコードを読み飛ばして続くことをテストする
+func TestSkipCorrupt(t *testing.T) {
+	quarantined := api.ErrCorruptSegment{BaseOffset: 2, NextOffset: 4, Reason: "checksum mismatch"}
+	client, _, teardown := setupTest(t, func(config *Config) {
+		config.CommitLog = corruptLog{CommitLog: config.CommitLog, corrupt: map[uint64]error{
+			1: api.ErrCorruptRecord{Offset: 1, Reason: "bad record"},
+			2: quarantined,
+			3: quarantined,
+		}}
+	})
+	defer teardown()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		_, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte(fmt.Sprintf("v%d", i))}})
+		require.NoError(t, err)
+	}
+
+	// 指定しない場合は、壊れたレコードでストリームが終了する
+	stream, err := client.ConsumeStream(ctx, &api.ConsumeRequest{Offset: 0})
+	require.NoError(t, err)
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "v0", string(res.Record.Value))
+	_, err = stream.Recv()
+	require.Equal(t, codes.DataLoss, status.Code(err))
+
+	// 指定した場合は、読み飛ばした範囲を通知して続ける（隔離中のセグメントはまとめて読み飛ばす）
+	stream, err = client.ConsumeStream(ctx, &api.ConsumeRequest{Offset: 1, SkipCorrupt: true})
+	require.NoError(t, err)
+	for _, want := range []*api.ConsumeGap{
+		{FromOffset: 1, ToOffset: 2, Reason: "bad record"},
+		{FromOffset: 2, ToOffset: 4, Reason: "checksum mismatch"},
+	} {
+		res, err = stream.Recv()
+		require.NoError(t, err)
+		require.Nil(t, res.Record)
+		require.True(t, proto.Equal(want, res.Gap), res.Gap.String())
+		require.Equal(t, want.ToOffset, res.NextOffset)
+	}
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "v4", string(res.Record.Value))
+	require.Nil(t, res.Gap)
+}
+
+// TestMaxConnectionAge: 接続の寿命を過ぎると、実行中のストリームも猶予期間の後に閉じられることをテストする
+func TestMaxConnectionAge(t *testing.T) {
+	client, _, teardown := setupTest(t, func(config *Config) {
+		config.Keepalive.MaxConnectionAge = 100 * time.Millisecond
+		config.Keepalive.MaxConnectionAgeGrace = 100 * time.Millisecond
+	})
+	defer teardown()
+
+	// レコードがないため、ストリームは新しいレコードを待ち続ける
+	stream, err := client.ConsumeStream(context.Background(), &api.ConsumeRequest{})
+	require.NoError(t, err)
+	errc := make(chan error, 1)
+	go func() {
+		_, err := stream.Recv()
+		errc <- err
+	}()
+
+	select {
+	case err := <-errc:
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream was not closed after max connection age")
+	}
+}
+
+// testSessions: ハートビートを送り続けたセッションだけが生きているとみなされることをテストする
+func testSessions(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+	clock := config.Clock.(*log.FakeClock)
+
+	a, err := client.Heartbeat(ctx, &api.HeartbeatRequest{Group: "billing"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(10000), a.TimeoutMs)
+	b, err := client.Heartbeat(ctx, &api.HeartbeatRequest{Group: "billing"})
+	require.NoError(t, err)
+
+	// b だけがハートビートを送り続ける
+	clock.Advance(6 * time.Second)
+	_, err = client.Heartbeat(ctx, &api.HeartbeatRequest{SessionId: b.SessionId, Group: "billing"})
+	require.NoError(t, err)
+	clock.Advance(6 * time.Second)
+
+	_, err = client.Heartbeat(ctx, &api.HeartbeatRequest{SessionId: a.SessionId, Group: "billing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// 生きているセッションの数がメトリクスとして公開される
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(NewSessionCollector(config)))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	require.Equal(t, 1.0, families[0].GetMetric()[0].GetGauge().GetValue())
+
+	_, err = client.EndSession(ctx, &api.EndSessionRequest{SessionId: b.SessionId})
+	require.NoError(t, err)
+	require.Empty(t, config.Sessions.Active())
+	_, err = client.EndSession(ctx, &api.EndSessionRequest{SessionId: b.SessionId})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// testConsumerLag: コミット済みオフセットとログの末尾からコンシューマーの遅延が計算されることをテストする
+func testConsumerLag(t *testing.T, client api.LogClient, config *Config) {
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		_, err := client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("a")}})
+		require.NoError(t, err)
+	}
+	_, err := client.CreateTopic(ctx, &api.CreateTopicRequest{Name: "orders"})
+	require.NoError(t, err)
+	_, err = client.Produce(ctx, &api.ProduceRequest{Topic: "orders", Record: &api.Record{Value: []byte("b")}})
+	require.NoError(t, err)
+
+	for _, c := range []*api.CommitOffsetRequest{
+		{Group: "search", Offset: 2},
+		{Group: "billing", Offset: 5},
+		{Group: "billing", Topic: "orders", Offset: 0},
+		// 削除されたトピックは含まれない
+		{Group: "billing", Topic: "missing", Offset: 3},
+	} {
+		_, err = client.CommitOffset(ctx, c)
+		require.NoError(t, err)
+	}
+
+	res, err := client.GetConsumerLag(ctx, &api.GetConsumerLagRequest{})
+	require.NoError(t, err)
+	var got []string
+	for _, lag := range res.Lags {
+		got = append(got, fmt.Sprintf("%s/%s %d-%d=%d", lag.Group, lag.Topic, lag.EndOffset, lag.CommittedOffset, lag.Lag))
+	}
+	require.Equal(t, []string{
+		"billing/ 5-5=0",
+		"billing/orders 1-0=1",
+		"search/ 5-2=3",
+	}, got)
+
+	res, err = client.GetConsumerLag(ctx, &api.GetConsumerLagRequest{Group: "billing", Topics: []string{"orders"}})
+	require.NoError(t, err)
+	require.Len(t, res.Lags, 1)
+	require.Equal(t, uint64(1), res.Lags[0].Lag)
+
+	// 遅延がメトリクスとして公開される
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(NewLagCollector(config)))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	require.Len(t, families[0].GetMetric(), 3)
+}
+
+// TestHealth: セルフチェックの状態と異常の理由が標準のヘルスチェックサービスで返されることをテストする
+func TestHealth(t *testing.T) {
+	var failing atomic.Bool
+	monitor := health.NewMonitor(health.Config{
+		Checks: []health.Check{{Name: "disk_free", Run: func() error {
+			if failing.Load() {
+				return errors.New("10 bytes free")
+			}
+			return nil
+		}}},
+		Clock: log.NewFakeClock(time.UnixMilli(1700000000000)),
+	})
+	defer monitor.Close()
+	cfg := &Config{Health: monitor}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server, err := NewGRPCServer(cfg)
+	require.NoError(t, err)
+	go server.Serve(l)
+	defer server.Stop()
+	cc, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer cc.Close()
+	client := healthpb.NewHealthClient(cc)
+	ctx := context.Background()
+
+	res, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status)
+
+	failing.Store(true)
+	monitor.RunChecks()
+	var header metadata.MD
+	res, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "log.v1.Log"}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, res.Status)
+	require.Equal(t, []string{"disk_free: 10 bytes free"}, header.Get(healthReasonHeader))
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// チェックごとの結果がメトリクスとして公開される
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(NewHealthCollector(cfg)))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 2)
+	for _, f := range families {
+		require.Equal(t, 0.0, f.GetMetric()[0].GetGauge().GetValue())
+	}
+}
+
+// TestWebhook: Webhook のリクエストボディが送信元の情報とともにレコードとして書き込まれることをテストする
+func TestWebhook(t *testing.T) {
+	_, config, teardown := setupTest(t, nil)
+	defer teardown()
+
+	secret := []byte("s3cret")
+	handler, err := NewWebhookHandler(config, []Webhook{
+		{Path: "/hooks/github", Secret: secret, Headers: []string{"X-GitHub-Event"}, MaxBodyBytes: 32},
+		{Path: "/hooks/missing", Topic: "missing"},
+	})
+	require.NoError(t, err)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	post := func(path, body, signature string) *http.Response {
+		req, err := http.NewRequest("POST", srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "push")
+		if signature != "" {
+			req.Header.Set("X-Hub-Signature-256", signature)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res
+	}
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	body := `{"ref":"main"}`
+	require.Equal(t, http.StatusOK, post("/hooks/github", body, sign(body)).StatusCode)
+	require.Equal(t, http.StatusUnauthorized, post("/hooks/github", body, sign("other")).StatusCode)
+	require.Equal(t, http.StatusUnauthorized, post("/hooks/github", body, "").StatusCode)
+	long := strings.Repeat("x", 33)
+	require.Equal(t, http.StatusRequestEntityTooLarge, post("/hooks/github", long, sign(long)).StatusCode)
+	require.Equal(t, http.StatusNotFound, post("/hooks/missing", body, "").StatusCode)
+
+	record, err := config.CommitLog.Read(0)
+	require.NoError(t, err)
+	require.Equal(t, body, string(record.Value))
+	require.Equal(t, int64(1700000000000), record.Timestamp)
+	require.Equal(t, "/hooks/github", record.Headers["webhook"])
+	require.Equal(t, "application/json", record.Headers["content_type"])
+	require.Equal(t, "push", record.Headers["x-github-event"])
+	_, err = config.CommitLog.Read(1)
+	require.Error(t, err)
+
+	_, err = NewWebhookHandler(config, []Webhook{{Path: "/a"}, {Path: "/a"}})
+	require.Error(t, err)
+}
+
+// TestGateway: HTTP ゲートウェイでカーソルを使って履歴をページングして読み取れることをテストする
+func TestGateway(t *testing.T) {
+	_, config, teardown := setupTest(t, nil)
+	defer teardown()
+
+	for i := 0; i < 5; i++ {
+		_, err := config.CommitLog.Append(&api.Record{Value: []byte(fmt.Sprintf("v%d", i))})
+		require.NoError(t, err)
+	}
+	handler, err := NewGatewayHandler(config, nil)
+	require.NoError(t, err)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	get := func(query string) (*RecordsPage, int) {
+		res, err := http.Get(srv.URL + "/records?" + query)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, res.StatusCode
+		}
+		page := &RecordsPage{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(page))
+		return page, res.StatusCode
+	}
+
+	// カーソルをたどって、すべてのレコードを順番に読み取る
+	var values []string
+	cursor := ""
+	for i := 0; i < 3; i++ {
+		page, code := get("limit=2&after=" + cursor)
+		require.Equal(t, http.StatusOK, code)
+		for _, record := range page.Records {
+			values = append(values, string(record.Value))
+		}
+		cursor = page.NextCursor
+	}
+	require.Equal(t, []string{"v0", "v1", "v2", "v3", "v4"}, values)
+
+	// 末尾に達した場合は空のページと同じ位置のカーソルを返し、追加されたレコードを続きから読める
+	page, code := get("after=" + cursor)
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, page.Records)
+	require.Equal(t, cursor, page.NextCursor)
+	_, err = config.CommitLog.Append(&api.Record{Value: []byte("v5")})
+	require.NoError(t, err)
+	page, _ = get("after=" + cursor)
+	require.Len(t, page.Records, 1)
+	require.Equal(t, uint64(5), page.Records[0].Offset)
+
+	// 先頭のレコードの配信時刻が来ていない場合も、空のページと同じ位置のカーソルを返す
+	clock := config.Clock.(*log.FakeClock)
+	_, err = config.CommitLog.Append(&api.Record{Value: []byte("v6"), DeliverAfter: clock.Now().UnixMilli() + 1000})
+	require.NoError(t, err)
+	cursor = page.NextCursor
+	page, code = get("after=" + cursor)
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, page.Records)
+	require.Equal(t, cursor, page.NextCursor)
+	clock.Advance(time.Second)
+	page, _ = get("after=" + cursor)
+	require.Len(t, page.Records, 1)
+	require.Equal(t, "v6", string(page.Records[0].Value))
+
+	for name, tc := range map[string]struct {
+		query string
+		code  int
+	}{
+		"invalid cursor": {query: "after=not-a-cursor", code: http.StatusBadRequest},
+		"invalid limit":  {query: "limit=0", code: http.StatusBadRequest},
+		"missing topic":  {query: "topic=missing", code: http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, code := get(tc.query)
+			require.Equal(t, tc.code, code)
+		})
+	}
+
+	// Reset の前に発行したカーソルは使えない
+	require.NoError(t, config.CommitLog.(*log.Log).Reset())
+	_, code = get("after=" + cursor)
+	require.Equal(t, http.StatusGone, code)
+	page, code = get("")
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, page.Records)
+}
+
+// TestGatewayAuthorization: HTTP ゲートウェイがクライアント証明書のサブジェクトで読み取りを認可し、
+// レコードをマスキングして返すことをテストする
+func TestGatewayAuthorization(t *testing.T) {
+	_, config, teardown := setupTest(t, func(config *Config) {
+		config.Authorizer = subjectAuthorizer("alice")
+		var err error
+		config.ExportRedactor, err = redact.New([]redact.Rule{{Field: "value", Pattern: "secret", Replace: "xxx"}})
+		require.NoError(t, err)
+	})
+	defer teardown()
+	_, err := config.CommitLog.Append(&api.Record{Value: []byte("secret")})
+	require.NoError(t, err)
+
+	// クライアント証明書を検証しない場合は作成できない
+	_, err = NewGatewayHandler(config, nil)
+	require.Error(t, err)
+	_, err = NewGatewayHandler(config, &tls.Config{ClientAuth: tls.RequestClientCert})
+	require.Error(t, err)
+	handler, err := NewGatewayHandler(config, &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert})
+	require.NoError(t, err)
+
+	get := func(cn string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/records", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{
+			{Subject: pkix.Name{CommonName: cn}},
+		}}}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	require.Equal(t, http.StatusForbidden, get("mallory").Code)
+	w := get("alice")
+	require.Equal(t, http.StatusOK, w.Code)
+	page := &RecordsPage{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(page))
+	require.Len(t, page.Records, 1)
+	require.Equal(t, "xxx", string(page.Records[0].Value))
+}
+
+// TestDebugServer: 診断用の管理サーバーがループバックアドレスでのみ待ち受け、ダンプを書き出せることをテストする
+func TestDebugServer(t *testing.T) {
+	_, err := NewDebugServer(DebugConfig{Addr: ":6060"})
+	require.Error(t, err)
+	_, err = NewDebugServer(DebugConfig{Addr: "10.0.0.1:6060"})
+	require.Error(t, err)
+	srv, err := NewDebugServer(DebugConfig{Addr: "localhost:6060"})
+	require.NoError(t, err)
+	require.Equal(t, "localhost:6060", srv.Addr)
+
+	dir := t.TempDir()
+	ts := httptest.NewServer(newDebugHandler(DebugConfig{DumpDir: dir}))
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/debug/vars")
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = http.Get(ts.URL + "/debug/dump")
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	res, err = http.Post(ts.URL+"/debug/dump", "", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var paths map[string]string
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&paths))
+	b, err := os.ReadFile(paths["goroutines"])
+	require.NoError(t, err)
+	require.Contains(t, string(b), "goroutine ")
+	info, err := os.Stat(paths["heap"])
+	require.NoError(t, err)
+	require.NotZero(t, info.Size())
+}
+
+// TestAccessLog: RPC ごとのエントリがファイルとトピックに記録されることをテストする
+func TestAccessLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	client, config, teardown := setupTest(t, func(config *Config) {
+		_, err := config.Topics.Create("access")
+		require.NoError(t, err)
+		config.AccessLog.Writer = f
+		config.AccessLog.Topic = "access"
+	})
+	defer teardown()
+	ctx := context.Background()
+
+	for _, v := range []string{"hello", "world"} {
+		_, err = client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte(v)}})
+		require.NoError(t, err)
+	}
+	_, err = client.Consume(ctx, &api.ConsumeRequest{Offset: 10, Topic: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	// アクセスログのトピックの読み取りは記録しない（読み取るたびにエントリが増え続けないように）
+	_, err = client.Consume(ctx, &api.ConsumeRequest{Offset: 0, Topic: "access"})
+	require.NoError(t, err)
+
+	// ストリームのエントリは、ストリームが終了したときに送信したレコードのオフセットの範囲とともに記録される
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := client.ConsumeStream(streamCtx, &api.ConsumeRequest{Offset: 0})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = stream.Recv()
+		require.NoError(t, err)
+	}
+	cancel()
+
+	var entries []AccessLogEntry
+	require.Eventually(t, func() bool {
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		entries = nil
+		for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+			var e AccessLogEntry
+			require.NoError(t, json.Unmarshal([]byte(line), &e))
+			entries = append(entries, e)
+		}
+		return len(entries) == 4
+	}, time.Second, 10*time.Millisecond)
+
+	produce := entries[1]
+	require.Equal(t, "/log.v1.Log/Produce", produce.Method)
+	require.Equal(t, uint64(1), *produce.FirstOffset)
+	require.Equal(t, 2, produce.Messages)
+	require.NotZero(t, produce.BytesIn)
+	require.NotZero(t, produce.BytesOut)
+	require.Equal(t, "OK", produce.Code)
+
+	missing := entries[2]
+	require.Equal(t, "missing", missing.Topic)
+	require.Equal(t, 1, missing.Messages)
+	require.Equal(t, "NotFound", missing.Code)
+
+	consume := entries[3]
+	require.Equal(t, "/log.v1.Log/ConsumeStream", consume.Method)
+	require.Equal(t, uint64(0), *consume.FirstOffset)
+	require.Equal(t, uint64(1), *consume.LastOffset)
+	require.Equal(t, 3, consume.Messages)
+
+	// トピックにも同じエントリが追加される
+	access, err := config.Topics.Get("access")
+	require.NoError(t, err)
+	for i, want := range entries {
+		record, err := access.Read(uint64(i))
+		require.NoError(t, err)
+		var got AccessLogEntry
+		require.NoError(t, json.Unmarshal(record.Value, &got))
+		require.Equal(t, want.Method, got.Method)
+	}
+}
+
+// TestAccessLogSampling: サンプリングしても失敗した RPC は常に記録されることをテストする
+func TestAccessLogSampling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	client, _, teardown := setupTest(t, func(config *Config) {
+		config.AccessLog.Writer = f
+		config.AccessLog.SampleRate = 1e-12
+	})
+	defer teardown()
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		_, err = client.Produce(ctx, &api.ProduceRequest{Record: &api.Record{Value: []byte("v")}})
+		require.NoError(t, err)
+	}
+	_, err = client.Consume(ctx, &api.ConsumeRequest{Offset: 100})
+	require.Error(t, err)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], `"method":"/log.v1.Log/Consume"`)
+}
+
+// TestUsage: 書き込みと読み取りの量が識別子ごとに集計され、GetUsage とメトリクスで返されることをテストする
+func TestUsage(t *testing.T) {
+	client, config, teardown := setupTest(t, func(config *Config) {
+		config.Usage = usage.NewTracker(time.Minute, 0, config.Clock)
+	})
+	defer teardown()
+	ctx := context.Background()
+
+	records := []*api.Record{{Value: []byte("a")}, {Value: []byte("bb")}, {Value: []byte("ccc")}}
+	_, err := client.Produce(ctx, &api.ProduceRequest{Record: records[0]})
+	require.NoError(t, err)
+	_, err = client.ProduceBatch(ctx, &api.ProduceBatchRequest{Records: records[1:]})
+	require.NoError(t, err)
+	var produced uint64
+	for _, record := range records {
+		produced += uint64(proto.Size(record))
+	}
+
+	// 失敗した RPC は集計しない
+	_, err = client.Consume(ctx, &api.ConsumeRequest{Offset: 10})
+	require.Error(t, err)
+
+	var consumed uint64
+	stream, err := client.ConsumeStream(ctx, &api.ConsumeRequest{Offset: 1})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		res, err := stream.Recv()
+		require.NoError(t, err)
+		consumed += uint64(proto.Size(res.Record))
+	}
+	// Query で読み取ったレコードも集計する
+	query, err := client.Query(ctx, &api.QueryRequest{Filter: "time >= 0"})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		res, err := query.Recv()
+		require.NoError(t, err)
+		consumed += uint64(proto.Size(res.Record))
+	}
+
+	res, err := client.GetUsage(ctx, &api.GetUsageRequest{All: true})
+	require.NoError(t, err)
+	require.Equal(t, uint64(time.Minute.Milliseconds()), res.WindowMs)
+	require.Len(t, res.Windows, 1)
+	w := res.Windows[0]
+	require.Equal(t, config.Clock.Now().Truncate(time.Minute).UnixMilli(), w.StartMs)
+	require.Equal(t, uint64(3), w.ProducedRecords)
+	require.Equal(t, produced, w.ProducedBytes)
+	require.Equal(t, uint64(5), w.ConsumedRecords)
+	require.Equal(t, consumed, w.ConsumedBytes)
+
+	res, err = client.GetUsage(ctx, &api.GetUsageRequest{Identity: "someone-else"})
+	require.NoError(t, err)
+	require.Empty(t, res.Windows)
+
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(NewUsageCollector(config)))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	bytes := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "proglog_usage_bytes_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			bytes[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+		}
+	}
+	require.Equal(t, map[string]float64{"produced": float64(produced), "consumed": float64(consumed)}, bytes)
+}
+
+// TestFaultInjection: 明示的に有効にした場合だけ、設定した遅延とエラーが注入されることをテストする
+func TestFaultInjection(t *testing.T) {
+	for scenario, tc := range map[string]struct {
+		faults  func(config *Config)
+		code    codes.Code
+		minTime time.Duration
+	}{
+		"disabled without the flag": {
+			faults: func(config *Config) { config.Faults.ErrorRate = 1 },
+			code:   codes.OK,
+		},
+		"error on matching method": {
+			faults: func(config *Config) {
+				config.Faults.Enabled = true
+				config.Faults.Methods = []string{"/log.v1.Log/Produce"}
+				config.Faults.ErrorRate = 1
+				config.Faults.ErrorCode = codes.ResourceExhausted
+			},
+			code: codes.ResourceExhausted,
+		},
+		"other method untouched": {
+			faults: func(config *Config) {
+				config.Faults.Enabled = true
+				config.Faults.Methods = []string{"/log.v1.Log/Consume"}
+				config.Faults.ErrorRate = 1
+			},
+			code: codes.OK,
+		},
+		"latency": {
+			faults: func(config *Config) {
+				config.Faults.Enabled = true
+				config.Faults.Latency = 50 * time.Millisecond
+				config.Faults.Jitter = 10 * time.Millisecond
+			},
+			code:    codes.OK,
+			minTime: 50 * time.Millisecond,
+		},
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			client, _, teardown := setupTest(t, tc.faults)
+			defer teardown()
+
+			start := time.Now()
+			_, err := client.Produce(context.Background(), &api.ProduceRequest{Record: &api.Record{Value: []byte("v")}})
+			require.Equal(t, tc.code, status.Code(err))
+			require.GreaterOrEqual(t, time.Since(start), tc.minTime)
+		})
+	}
+}
+
+// TestFaultInjectionReset: FaultListener を使うと、注入した接続のリセットでクライアントの接続が切断されることをテストする
+func TestFaultInjectionReset(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	listener := NewFaultListener(l)
+
+	clog, err := log.NewLog(t.TempDir(), log.Config{})
+	require.NoError(t, err)
+	defer clog.Close()
+	cfg := &Config{CommitLog: clog}
+	cfg.Faults.Enabled = true
+	cfg.Faults.Methods = []string{"/log.v1.Log/Produce"}
+	cfg.Faults.ResetRate = 1
+	cfg.Faults.Listener = listener
+	server, err := NewGRPCServer(cfg)
+	require.NoError(t, err)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	cc, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer cc.Close()
+	client := api.NewLogClient(cc)
+
+	_, err = client.Produce(context.Background(), &api.ProduceRequest{Record: &api.Record{Value: []byte("v")}})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	// 接続は作り直され、対象外の RPC は成功する
+	_, err = client.FlushLog(context.Background(), &api.FlushLogRequest{})
+	require.NoError(t, err)
+}
+
+// TestConfigValidate: 不変条件を満たさない設定で NewGRPCServer がすぐに失敗することをテストする
+func TestConfigValidate(t *testing.T) {
+	clog, err := log.NewLog(t.TempDir(), log.Config{})
+	require.NoError(t, err)
+	defer clog.Close()
+
+	for scenario, tc := range map[string]struct {
+		configure func(c *Config)
+		message   string
+	}{
+		"access log topic without topics": {
+			configure: func(c *Config) { c.AccessLog.Topic = "access" },
+			message:   `AccessLog.Topic "access" requires Topics`,
+		},
+		"fault rate out of range": {
+			configure: func(c *Config) {
+				c.Faults.Enabled = true
+				c.Faults.ErrorRate = 1.5
+			},
+			message: "Faults.ErrorRate must be between 0 and 1",
+		},
+		"reset without listener": {
+			configure: func(c *Config) {
+				c.Faults.Enabled = true
+				c.Faults.ResetRate = 0.1
+			},
+			message: "Faults.ResetRate requires Faults.Listener",
+		},
+		"negative keepalive": {
+			configure: func(c *Config) { c.Keepalive.Time = -time.Second },
+			message:   "Keepalive.Time must not be negative",
+		},
+		"message smaller than a record": {
+			configure: func(c *Config) { c.MaxMessageBytes = 1024 },
+			message:   "MaxMessageBytes 1024 is less than the log's MaxRecordBytes 4194304",
+		},
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			c := &Config{CommitLog: clog}
+			tc.configure(c)
+			_, err := NewGRPCServer(c)
+			require.ErrorContains(t, err, tc.message)
+		})
+	}
+}
+
+// subjectAuthorizer: 1つのサブジェクトだけを許可するテスト用の Authorizer
+type subjectAuthorizer string
+
+// Authorize: 許可されたサブジェクトでなければエラーを返す
+func (a subjectAuthorizer) Authorize(subject, object, action string) error {
+	if subject != string(a) {
+		return fmt.Errorf("%q is not permitted to %s on %s", subject, action, object)
+	}
+	return nil
+}
+
+// TestListeners: 1つのサーバーを TCP と Unix ドメインソケットで同時に公開し、
+// Unix ドメインソケットの接続に指定したサブジェクトが設定されることをテストする
+func TestListeners(t *testing.T) {
+	dir := t.TempDir()
+	sock := filepath.Join(dir, "proglog.sock")
+	clog, err := log.NewLog(t.TempDir(), log.Config{})
+	require.NoError(t, err)
+	defer clog.Close()
+
+	// ソケット以外のファイルは上書きしない
+	require.NoError(t, os.WriteFile(sock, nil, 0644))
+	_, err = Listen("127.0.0.1:0", "unix://"+sock)
+	require.ErrorContains(t, err, "is not a socket")
+	require.NoError(t, os.Remove(sock))
+
+	listeners, err := Listen("127.0.0.1:0", "unix://"+sock)
+	require.NoError(t, err)
+	server, err := NewGRPCServer(
+		&Config{CommitLog: clog, Authorizer: subjectAuthorizer("sidecar")},
+		grpc.Creds(UnixSocketCredentials(nil, "sidecar")),
+	)
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() { served <- Serve(server, listeners) }()
+
+	dial := func(target string) api.LogClient {
+		cc, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		t.Cleanup(func() { cc.Close() })
+		return api.NewLogClient(cc)
+	}
+	ctx := context.Background()
+	record := &api.Record{Value: []byte("hello")}
+
+	_, err = dial("unix://"+sock).Produce(ctx, &api.ProduceRequest{Record: record})
+	require.NoError(t, err)
+	// TCP の接続はクライアント証明書がないため、サブジェクトがなく拒否される
+	_, err = dial(listeners[0].Addr().String()).Produce(ctx, &api.ProduceRequest{Record: record})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	server.Stop()
+	require.NoError(t, <-served)
+	require.NoFileExists(t, sock)
+}
+
+// testCredentials: 平文の接続に固定のサブジェクトを割り当てるテスト用の認証情報の提供元
+type testCredentials struct {
+	subject string
+}
+
+// TransportCredentials: 平文の認証情報を返す
+func (c testCredentials) TransportCredentials() (credentials.TransportCredentials, error) {
+	return insecure.NewCredentials(), nil
+}
+
+// Subject: 固定のサブジェクトを返す
+func (c testCredentials) Subject(info credentials.AuthInfo) string {
+	return c.subject
+}
+
+// TestCredentialsProvider: Config.Credentials の認証情報でサーバーを公開し、そのサブジェクトで認可されることをテストする
+func TestCredentialsProvider(t *testing.T) {
+	clog, err := log.NewLog(t.TempDir(), log.Config{})
+	require.NoError(t, err)
+	defer clog.Close()
+
+	_, err = NewGRPCServer(&Config{
+		CommitLog: clog,
+		Credentials: config.CredentialsFunc(func() (credentials.TransportCredentials, error) {
+			return nil, errors.New("workload API unavailable")
+		}),
+	})
+	require.ErrorContains(t, err, "workload API unavailable")
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server, err := NewGRPCServer(&Config{
+		CommitLog:   clog,
+		Authorizer:  subjectAuthorizer("spiffe://example.org/app"),
+		Credentials: testCredentials{subject: "spiffe://example.org/app"},
+	})
+	require.NoError(t, err)
+	go server.Serve(l)
+	defer server.Stop()
+
+	cc, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer cc.Close()
+	_, err = api.NewLogClient(cc).Produce(context.Background(), &api.ProduceRequest{Record: &api.Record{Value: []byte("v")}})
+	require.NoError(t, err)
+}
+
+// countingAuthorizer: 判定の回数を数え、denied のサブジェクトを拒否するテスト用の Authorizer
+type countingAuthorizer struct {
+	calls  atomic.Int64
+	denied string
+	err    error // 設定されている場合は判定せずにこのエラーを返す
+}
+
+// Authorize: 判定の回数を数えて、denied のサブジェクトを拒否する
+func (a *countingAuthorizer) Authorize(subject, object, action string) error {
+	a.calls.Add(1)
+	if a.err != nil {
+		return a.err
+	}
+	if subject == a.denied {
+		return fmt.Errorf("%q is not permitted to %s on %s", subject, action, object)
+	}
+	return nil
+}
+
+// TestCachingAuthorizer: 認可の判定が TTL の間キャッシュされ、Invalidate で捨てられることをテストする
+func TestCachingAuthorizer(t *testing.T) {
+	clock := log.NewFakeClock(time.UnixMilli(1700000000000))
+	inner := &countingAuthorizer{denied: "mallory"}
+	a := NewCachingAuthorizer(inner, AuthCacheConfig{TTL: time.Minute, MaxEntries: 2, Clock: clock})
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, a.Authorize("alice", "orders", produceAction))
+		require.Error(t, a.Authorize("mallory", "orders", produceAction))
+	}
+	require.Equal(t, int64(2), inner.calls.Load())
+
+	// 上限に達したら捨てる
+	require.NoError(t, a.Authorize("alice", "orders", consumeAction))
+	require.NoError(t, a.Authorize("alice", "orders", produceAction))
+	require.Equal(t, int64(4), inner.calls.Load())
+
+	// 期限が切れたら判定し直す
+	clock.Advance(time.Minute)
+	require.NoError(t, a.Authorize("alice", "orders", produceAction))
+	require.Equal(t, int64(5), inner.calls.Load())
+
+	// ポリシーを再読み込みしたら判定し直す
+	inner.denied = "alice"
+	a.Invalidate()
+	require.Error(t, a.Authorize("alice", "orders", produceAction))
+	require.Equal(t, int64(6), inner.calls.Load())
+
+	// 一時的な失敗はキャッシュしない
+	inner.err = status.Error(codes.Unavailable, "policy service unavailable")
+	a.Invalidate()
+	for i := 0; i < 2; i++ {
+		require.Equal(t, codes.Unavailable, status.Code(a.Authorize("bob", "orders", produceAction)))
+	}
+	require.Equal(t, int64(8), inner.calls.Load())
+}
+
+// TestACLManagement: 管理 API で ACL のルールを変更でき、キャッシュした判定が捨てられることをテストする
+func TestACLManagement(t *testing.T) {
+	clog, err := log.NewLog(t.TempDir(), log.Config{})
+	require.NoError(t, err)
+	defer clog.Close()
+	aclLog, err := log.NewLog(t.TempDir(), log.Config{})
+	require.NoError(t, err)
+	defer aclLog.Close()
+	policy, err := acl.NewPolicy(aclLog, acl.Config{Superusers: []string{"admin"}})
+	require.NoError(t, err)
+	defer policy.Close()
+	// ルールを変更したときにキャッシュを捨てる関数は、NewGRPCServer が登録する
+	cache := NewCachingAuthorizer(policy, AuthCacheConfig{TTL: time.Hour})
+
+	admin := adminpb.NewAdminClient(dialServer(t, &Config{
+		CommitLog:   clog,
+		ACL:         policy,
+		Authorizer:  cache,
+		Credentials: testCredentials{subject: "admin"},
+	}))
+	ctx := context.Background()
+	rule := &adminpb.ACLRule{Subject: "alice", Object: "*", Action: produceAction}
+
+	require.Error(t, cache.Authorize("alice", "orders", produceAction))
+	_, err = admin.AddACL(ctx, &adminpb.AddACLRequest{Rule: rule})
+	require.NoError(t, err)
+	require.NoError(t, cache.Authorize("alice", "orders", produceAction))
+	list, err := admin.ListACLs(ctx, &adminpb.ListACLsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Rules, 1)
+	require.True(t, proto.Equal(rule, list.Rules[0]))
+
+	_, err = admin.RemoveACL(ctx, &adminpb.RemoveACLRequest{Rule: rule})
+	require.NoError(t, err)
+	require.Error(t, cache.Authorize("alice", "orders", produceAction))
+	_, err = admin.RemoveACL(ctx, &adminpb.RemoveACLRequest{Rule: rule})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = admin.AddACL(ctx, &adminpb.AddACLRequest{Rule: &adminpb.ACLRule{Subject: "alice"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// ACL の内部のトピックには、すべてを許可された管理者でもクライアントから直接書き込めず、
+	// 削除・復元・古いレコードの削除もできない
+	topics, err := log.NewTopics(t.TempDir(), log.Config{})
+	require.NoError(t, err)
+	defer topics.Close()
+	_, err = topics.Create(acl.DefaultTopic)
+	require.NoError(t, err)
+	client := api.NewLogClient(dialServer(t, &Config{
+		CommitLog:   clog,
+		Topics:      topics,
+		Credentials: testCredentials{subject: "admin"},
+		Authorizer: testAuthorizer{
+			produceAction:       true,
+			truncateAction:      true,
+			deleteTopicAction:   true,
+			undeleteTopicAction: true,
+		},
+	}))
+	record := &api.Record{Value: []byte(`{"op":"add","rule":{"subject":"mallory","object":"*","action":"*"}}`)}
+	_, err = client.Produce(ctx, &api.ProduceRequest{Topic: acl.DefaultTopic, Record: record})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.ProduceBatch(ctx, &api.ProduceBatchRequest{Topic: acl.DefaultTopic, Records: []*api.Record{record}})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.TruncateLog(ctx, &api.TruncateLogRequest{Topic: acl.DefaultTopic, BeforeOffset: 1})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.DeleteTopic(ctx, &api.DeleteTopicRequest{Name: acl.DefaultTopic})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.UndeleteTopic(ctx, &api.UndeleteTopicRequest{Name: acl.DefaultTopic})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Contains(t, topics.List(), acl.DefaultTopic)
+
+	// ACL のポリシーが設定されていないサーバーでは失敗する
+	admin = adminpb.NewAdminClient(dialServer(t, &Config{CommitLog: clog}))
+	_, err = admin.ListACLs(ctx, &adminpb.ListACLsRequest{})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+// TestACLReplication: リーダーで変更した ACL のルールが、複製したフォロワーでも有効になることをテストする
+func TestACLReplication(t *testing.T) {
+	ctx := context.Background()
+	// node: ACL の内部のトピックのポリシーで認可するノードを作成する
+	node := func(subject string, fn func(*Config)) (*Config, *log.Log, *acl.Policy) {
+		clog, err := log.NewLog(t.TempDir(), log.Config{})
+		require.NoError(t, err)
+		t.Cleanup(func() { clog.Close() })
+		topics, err := log.NewTopics(t.TempDir(), log.Config{})
+		require.NoError(t, err)
+		t.Cleanup(func() { topics.Close() })
+		aclLog, err := topics.Create(acl.DefaultTopic)
+		require.NoError(t, err)
+		// ノードの接続のサブジェクトは管理者として、予約されたトピックへの書き込みが認可ではなく予約で拒否されることを確認する
+		policy, err := acl.NewPolicy(aclLog, acl.Config{Superusers: []string{subject}})
+		require.NoError(t, err)
+		t.Cleanup(policy.Close)
+		config := &Config{
+			CommitLog:   clog,
+			Topics:      topics,
+			ACL:         policy,
+			Authorizer:  policy,
+			Credentials: testCredentials{subject: subject},
+		}
+		if fn != nil {
+			fn(config)
+		}
+		return config, aclLog, policy
+	}
+	leader, leaderACL, _ := node("admin", nil)
+	follower, _, followerPolicy := node("node-1", func(c *Config) {
+		c.Replication.Enabled = true
+		c.Replication.Peers = []string{"node-1"}
+		c.Replication.TermsFile = filepath.Join(t.TempDir(), "terms.json")
+	})
+	admin := adminpb.NewAdminClient(dialServer(t, leader))
+	repl := replpb.NewReplicationClient(dialServer(t, follower))
+
+	// replicate: リーダーの ACL の内部のトピックのレコードをフォロワーに複製する
+	var next uint64
+	replicate := func() {
+		var records []*api.Record
+		for ; next < leaderACL.NextOffset(); next++ {
+			record, err := leaderACL.Read(next)
+			require.NoError(t, err)
+			records = append(records, record)
+		}
+		_, err := repl.ReplicateEntries(ctx, &replpb.ReplicateEntriesRequest{Topic: acl.DefaultTopic, Term: 1, Records: records})
+		require.NoError(t, err)
+	}
+
+	rule := &adminpb.ACLRule{Subject: "alice", Object: "orders", Action: produceAction}
+	_, err := admin.AddACL(ctx, &adminpb.AddACLRequest{Rule: rule})
+	require.NoError(t, err)
+	require.Error(t, followerPolicy.Authorize("alice", "orders", produceAction))
+	replicate()
+	require.Eventually(t, func() bool {
+		return followerPolicy.Authorize("alice", "orders", produceAction) == nil
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = admin.RemoveACL(ctx, &adminpb.RemoveACLRequest{Rule: rule})
+	require.NoError(t, err)
+	replicate()
+	require.Eventually(t, func() bool {
+		return followerPolicy.Authorize("alice", "orders", produceAction) != nil
+	}, time.Second, 10*time.Millisecond)
+
+	// フォロワーでも、クライアントは ACL の内部のトピックに直接書き込めない
+	client := api.NewLogClient(dialServer(t, follower))
+	_, err = client.Produce(ctx, &api.ProduceRequest{Topic: acl.DefaultTopic, Record: &api.Record{Value: []byte("{}")}})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/sessions"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Heartbeat: クライアントのセッションのハートビートを記録する
+// セッション ID を指定しない場合は新しいセッションを開始する。クライアントはレスポンスのタイムアウトより
+// 短い間隔でハートビートを送り続ける必要があり、途絶えたセッションは期限切れになる。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: セッション ID、コンシューマーグループ、読み取っているトピックを含むリクエスト
+//
+// 戻り値:
+//   - *api.HeartbeatResponse: セッション ID とタイムアウト
+//   - error: エラーが発生した場合（セッションが期限切れの場合は codes.NotFound）
+func (s *grpcServer) Heartbeat(ctx context.Context, req *api.HeartbeatRequest) (*api.HeartbeatResponse, error) {
+	if err := s.authorize(ctx, topicObject(req.Topic), consumeAction); err != nil {
+		return nil, err
+	}
+	if s.Sessions == nil {
+		return nil, errSessionsDisabled
+	}
+	session, err := s.Sessions.Heartbeat(req.SessionId, req.Group, req.Topic)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return &api.HeartbeatResponse{
+		SessionId: session.ID,
+		TimeoutMs: uint64(s.Sessions.Timeout().Milliseconds()),
+	}, nil
+}
+
+// EndSession: クライアントのセッションを期限切れを待たずに終了する
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: セッション ID を含むリクエスト
+//
+// 戻り値:
+//   - *api.EndSessionResponse: 空のレスポンス
+//   - error: エラーが発生した場合（セッションが存在しない場合は codes.NotFound）
+func (s *grpcServer) EndSession(ctx context.Context, req *api.EndSessionRequest) (*api.EndSessionResponse, error) {
+	if s.Sessions == nil {
+		return nil, errSessionsDisabled
+	}
+	// セッションを開始したときと同じトピックの権限で確認する
+	session, err := s.Sessions.Get(req.SessionId)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	if err := s.authorize(ctx, topicObject(session.Topic), consumeAction); err != nil {
+		return nil, err
+	}
+	if err := s.Sessions.End(req.SessionId); err != nil {
+		return nil, sessionError(err)
+	}
+	return &api.EndSessionResponse{}, nil
+}
+
+// sessionError: セッションのエラーを gRPC のステータスに変換する
+func sessionError(err error) error {
+	if err == sessions.ErrSessionNotFound {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return err
+}
+
+// errSessionsDisabled: セッションのレジストリが設定されていないサーバーでセッションを操作した場合のエラー
+var errSessionsDisabled = status.Error(codes.FailedPrecondition, "sessions are not enabled on this server")
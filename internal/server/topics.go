@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/acl"
+	"github.com/kentakki416/proglog/internal/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateTopic: 新しいトピックを作成する（管理操作）
+// トピックは書き込み時に暗黙的に作成されないため、使用する前にこの RPC で作成する必要がある。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 作成するトピック名を含むリクエスト
+//
+// 戻り値:
+//   - *api.CreateTopicResponse: 空のレスポンス
+//   - error: エラーが発生した場合（既に存在する場合は codes.AlreadyExists）
+func (s *grpcServer) CreateTopic(ctx context.Context, req *api.CreateTopicRequest) (*api.CreateTopicResponse, error) {
+	if err := s.authorize(ctx, req.Name, createTopicAction); err != nil {
+		return nil, err
+	}
+	if s.Topics == nil {
+		return nil, errTopicsDisabled
+	}
+	if _, err := s.Topics.Create(req.Name); err != nil {
+		return nil, topicError(err)
+	}
+	return &api.CreateTopicResponse{}, nil
+}
+
+// DeleteTopic: トピックを削除する（管理操作）
+// トピックはすぐに見えなくなる。削除したトピックの保持期間が設定されている場合は
+// 保持期間内であれば UndeleteTopic で元に戻せ、設定されていない場合はバックグラウンドで削除される。
+// 読み書き中のリクエスト（ConsumeStream やエクスポートのジョブなど）があるトピックは削除しない。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 削除するトピック名を含むリクエスト
+//
+// 戻り値:
+//   - *api.DeleteTopicResponse: 空のレスポンス
+//   - error: エラーが発生した場合（存在しない場合は codes.NotFound、使用中の場合は codes.FailedPrecondition、
+//     予約されたトピックの場合は codes.PermissionDenied）
+func (s *grpcServer) DeleteTopic(ctx context.Context, req *api.DeleteTopicRequest) (*api.DeleteTopicResponse, error) {
+	if err := s.authorize(ctx, req.Name, deleteTopicAction); err != nil {
+		return nil, err
+	}
+	if err := reservedTopic(req.Name); err != nil {
+		return nil, err
+	}
+	if s.Topics == nil {
+		return nil, errTopicsDisabled
+	}
+	if err := s.Topics.Delete(req.Name); err != nil {
+		if errors.Is(err, log.ErrLogInUse) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, topicError(err)
+	}
+	return &api.DeleteTopicResponse{}, nil
+}
+
+// UndeleteTopic: 削除したトピックをゴミ箱から元に戻す（管理操作）
+// 削除したトピックの保持期間（log.Config.Topic.DeleteRetention）内である必要がある。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 元に戻すトピック名を含むリクエスト
+//
+// 戻り値:
+//   - *api.UndeleteTopicResponse: 空のレスポンス
+//   - error: エラーが発生した場合（ゴミ箱にない場合は codes.NotFound、
+//     同じ名前のトピックが既に存在する場合は codes.AlreadyExists、予約されたトピックの場合は codes.PermissionDenied）
+func (s *grpcServer) UndeleteTopic(ctx context.Context, req *api.UndeleteTopicRequest) (*api.UndeleteTopicResponse, error) {
+	if err := s.authorize(ctx, req.Name, undeleteTopicAction); err != nil {
+		return nil, err
+	}
+	if err := reservedTopic(req.Name); err != nil {
+		return nil, err
+	}
+	if s.Topics == nil {
+		return nil, errTopicsDisabled
+	}
+	if _, err := s.Topics.Undelete(req.Name); err != nil {
+		return nil, topicError(err)
+	}
+	return &api.UndeleteTopicResponse{}, nil
+}
+
+// ListTopics: すべてのトピック名を返す（管理操作）
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 空のリクエスト
+//
+// 戻り値:
+//   - *api.ListTopicsResponse: トピック名の一覧（名前順）
+//   - error: エラーが発生した場合
+func (s *grpcServer) ListTopics(ctx context.Context, req *api.ListTopicsRequest) (*api.ListTopicsResponse, error) {
+	if err := s.authorize(ctx, objectWildcard, listTopicsAction); err != nil {
+		return nil, err
+	}
+	if s.Topics == nil {
+		return &api.ListTopicsResponse{}, nil
+	}
+	return &api.ListTopicsResponse{Topics: s.Topics.List()}, nil
+}
+
+// errTopicsDisabled: トピックの管理が設定されていないサーバーでトピックを操作した場合のエラー
+var errTopicsDisabled = status.Error(codes.FailedPrecondition, "topics are not enabled on this server")
+
+// commitLog: リクエストで指定されたトピックのログストアを返す
+// トピックが指定されていない場合は、デフォルトのログストア（Config.CommitLog）を返す。
+// トピックのログストアは使用中として取得するため、使い終わったら返された関数を呼び出す
+// （呼び出すまでは DeleteTopic でトピックを削除できない）。
+// 引数:
+//   - topic: トピック名（空文字の場合はデフォルトのログストア）
+//
+// 戻り値:
+//   - CommitLog: トピックのログストア
+//   - func(): ログストアの使用を終える関数（エラーの場合は nil）
+//   - error: トピックが存在しない場合（codes.NotFound）
+func (c *Config) commitLog(topic string) (CommitLog, func(), error) {
+	if topic == "" {
+		return c.CommitLog, func() {}, nil
+	}
+	if c.Topics == nil {
+		return nil, nil, errTopicsDisabled
+	}
+	l, err := c.Topics.Acquire(topic)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, func() { c.Topics.Release(topic) }, nil
+}
+
+// writableLog: クライアントが書き込むトピックのログストアを返す
+// ACL のルールを記録する内部のトピック（acl.DefaultTopic）は acl.Policy と複製元のノード（ReplicateEntries）だけが
+// 書き込めるように予約し、codes.PermissionDenied で拒否する（任意のルールを書き込んで権限を得られないようにする）。
+// 引数:
+//   - topic: トピック名（空文字の場合はデフォルトのログストア）
+//
+// 戻り値:
+//   - CommitLog: トピックのログストア
+//   - func(): ログストアの使用を終える関数（エラーの場合は nil）
+//   - error: 予約されたトピックの場合、またはトピックが存在しない場合
+func (c *Config) writableLog(topic string) (CommitLog, func(), error) {
+	if err := reservedTopic(topic); err != nil {
+		return nil, nil, err
+	}
+	return c.commitLog(topic)
+}
+
+// reservedTopic: クライアントが書き込みや削除をできない予約されたトピックの場合に codes.PermissionDenied を返す
+// ACL のルールを記録する内部のトピック（acl.DefaultTopic）はレコードを失うとルールが失われるため、
+// 書き込みに加えて、トピックの削除と復元、古いレコードの削除（TruncateLog）も拒否する。
+func reservedTopic(topic string) error {
+	if topic == acl.DefaultTopic {
+		return status.Errorf(codes.PermissionDenied, "topic %q is reserved", topic)
+	}
+	return nil
+}
+
+// topicObject: トピック名を ACL のオブジェクトに変換する
+// トピックが指定されていない場合はログ全体を表す objectWildcard を使用する。
+func topicObject(topic string) string {
+	if topic == "" {
+		return objectWildcard
+	}
+	return topic
+}
+
+// topicError: トピック名が不正な場合などのエラーを gRPC のステータスに変換する
+func topicError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
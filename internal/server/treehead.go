@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TreeHeadSource: ログのレコードの Merkle ツリーの署名付きのツリーヘッドを返す（例: merkle.Tree）
+// トピックを指定しない Log サービスのログストア（Config.CommitLog）のツリーを想定している。
+type TreeHeadSource interface {
+	SignedTreeHead() *api.TreeHead
+}
+
+// proofTree: 包含証明と一貫性証明を作成できるツリー
+// TreeHeads がこのインターフェースも実装している場合（例: merkle.Tree）、GetInclusionProof と GetConsistencyProof で証明を返す。
+type proofTree interface {
+	InclusionProof(off, size uint64) ([][]byte, error)
+	ConsistencyProof(oldSize, newSize uint64) ([][]byte, error)
+}
+
+// GetTreeHead: 最新の署名付きのツリーヘッドを返す
+// 監査者は定期的にツリーヘッドを取得して保存しておき、過去のレコードが書き換えられていないことの検証に使用する。
+// ツリーヘッドは定期的に署名されるため、直前に書き込んだレコードが含まれていない場合がある。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: リクエスト（パラメーターなし）
+//
+// 戻り値:
+//   - *api.GetTreeHeadResponse: 最新のツリーヘッド
+//   - error: エラーが発生した場合（ツリーヘッドが無効の場合は codes.FailedPrecondition）
+func (s *grpcServer) GetTreeHead(ctx context.Context, req *api.GetTreeHeadRequest) (*api.GetTreeHeadResponse, error) {
+	if err := s.authorize(ctx, objectWildcard, consumeAction); err != nil {
+		return nil, err
+	}
+	if s.TreeHeads == nil {
+		return nil, status.Error(codes.FailedPrecondition, "tree heads are not enabled on this server")
+	}
+	return &api.GetTreeHeadResponse{TreeHead: s.TreeHeads.SignedTreeHead()}, nil
+}
+
+// GetInclusionProof: オフセットのレコードがツリーに含まれることの包含証明を返す
+// 監査者は api.VerifyRecordInclusion で、読み取ったレコードが保存したツリーヘッドのツリーに含まれることを検証する。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: レコードのオフセットと、証明の対象のツリーのサイズ（0 の場合は最新のツリーヘッドのサイズ）
+//
+// 戻り値:
+//   - *api.GetInclusionProofResponse: 葉の位置、ツリーのサイズ、証明のノードのハッシュ
+//   - error: エラーが発生した場合（オフセットがツリーに含まれない場合は codes.OutOfRange）
+func (s *grpcServer) GetInclusionProof(ctx context.Context, req *api.GetInclusionProofRequest) (*api.GetInclusionProofResponse, error) {
+	tree, err := s.proofTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &api.GetInclusionProofResponse{TreeSize: req.TreeSize}
+	if res.TreeSize == 0 {
+		res.TreeHead = s.TreeHeads.SignedTreeHead()
+		res.TreeSize = res.TreeHead.TreeSize
+	}
+	hashes, err := tree.InclusionProof(req.Offset, res.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+	res.Hashes = hashes
+	// 証明を作成できたオフセットは葉 0 のオフセット以上
+	res.LeafIndex = req.Offset - s.TreeHeads.SignedTreeHead().FirstOffset
+	return res, nil
+}
+
+// GetConsistencyProof: 古いサイズのツリーが新しいサイズのツリーの先頭部分であることの一貫性証明を返す
+// 監査者は api.VerifyConsistency で、以前に保存したツリーヘッドから過去のレコードが書き換えられていないことを検証する。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 古いツリーのサイズと、新しいツリーのサイズ（0 の場合は最新のツリーヘッドのサイズ）
+//
+// 戻り値:
+//   - *api.GetConsistencyProofResponse: 新しいツリーのサイズと、証明のノードのハッシュ
+//   - error: エラーが発生した場合（古いサイズが新しいサイズより大きい場合は codes.InvalidArgument）
+func (s *grpcServer) GetConsistencyProof(ctx context.Context, req *api.GetConsistencyProofRequest) (*api.GetConsistencyProofResponse, error) {
+	tree, err := s.proofTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &api.GetConsistencyProofResponse{NewSize: req.NewSize}
+	if res.NewSize == 0 {
+		res.TreeHead = s.TreeHeads.SignedTreeHead()
+		res.NewSize = res.TreeHead.TreeSize
+	}
+	if req.OldSize > res.NewSize {
+		return nil, status.Errorf(codes.InvalidArgument, "old size %d is larger than new size %d", req.OldSize, res.NewSize)
+	}
+	if res.Hashes, err = tree.ConsistencyProof(req.OldSize, res.NewSize); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// proofTree: 証明の RPC を認可し、証明を作成できるツリーを返す（内部関数）
+func (s *grpcServer) proofTree(ctx context.Context) (proofTree, error) {
+	if err := s.authorize(ctx, objectWildcard, consumeAction); err != nil {
+		return nil, err
+	}
+	if s.TreeHeads == nil {
+		return nil, status.Error(codes.FailedPrecondition, "tree heads are not enabled on this server")
+	}
+	tree, ok := s.TreeHeads.(proofTree)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "tree does not support proofs")
+	}
+	return tree, nil
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/usage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// errUsageDisabled: 使用量の集計が設定されていないサーバーで使用量を取得した場合のエラー
+var errUsageDisabled = status.Error(codes.FailedPrecondition, "usage tracking is not enabled on this server")
+
+// GetUsage: クライアントの識別子ごとの書き込みと読み取りの量を期間ごとに返す
+// 複数のテナントが使うクラスタで、課金やクォータの判断に使用する。
+// 引数:
+//   - ctx: リクエストのコンテキスト
+//   - req: 対象の識別子（all を指定した場合はすべての識別子）を含むリクエスト
+//
+// 戻り値:
+//   - *api.GetUsageResponse: 保持している期間ごとの使用量（使用量のない期間は含まない）
+//   - error: エラーが発生した場合（集計が設定されていない場合は codes.FailedPrecondition）
+func (s *grpcServer) GetUsage(ctx context.Context, req *api.GetUsageRequest) (*api.GetUsageResponse, error) {
+	if err := s.authorize(ctx, objectWildcard, getUsageAction); err != nil {
+		return nil, err
+	}
+	if s.Usage == nil {
+		return nil, errUsageDisabled
+	}
+	res := &api.GetUsageResponse{WindowMs: uint64(s.Usage.Window().Milliseconds())}
+	for _, w := range s.Usage.Windows(req.Identity, req.All) {
+		res.Windows = append(res.Windows, &api.UsageWindow{
+			Identity:        w.Identity,
+			StartMs:         w.Start.UnixMilli(),
+			ProducedRecords: w.ProducedRecords,
+			ProducedBytes:   w.ProducedBytes,
+			ConsumedRecords: w.ConsumedRecords,
+			ConsumedBytes:   w.ConsumedBytes,
+		})
+	}
+	return res, nil
+}
+
+// messageUsage: 送受信したメッセージに含まれるレコードの使用量を返す（内部関数）
+// 書き込みのリクエストと読み取りのレスポンス以外のメッセージは 0 を返す。
+func messageUsage(msg any) usage.Usage {
+	var u usage.Usage
+	switch m := msg.(type) {
+	case *api.ProduceRequest:
+		if m.Record != nil {
+			u.ProducedRecords, u.ProducedBytes = 1, uint64(proto.Size(m.Record))
+		}
+	case *api.ProduceBatchRequest:
+		for _, record := range m.Records {
+			u.ProducedRecords++
+			u.ProducedBytes += uint64(proto.Size(record))
+		}
+	case *api.ConsumeResponse:
+		// 複数のレコードを返す場合、records に record も含まれる
+		records := m.Records
+		if len(records) == 0 && m.Record != nil {
+			records = []*api.Record{m.Record}
+		}
+		for _, record := range records {
+			u.ConsumedRecords++
+			u.ConsumedBytes += uint64(proto.Size(record))
+		}
+	case *api.QueryResponse:
+		if m.Record != nil {
+			u.ConsumedRecords, u.ConsumedBytes = 1, uint64(proto.Size(m.Record))
+		}
+	case *api.GetValueResponse:
+		// ビューが保持するキーの最新のレコードの値を読み取る
+		u.ConsumedRecords, u.ConsumedBytes = 1, uint64(len(m.Value))
+	}
+	return u
+}
+
+// usageInterceptors: 使用量を集計するインターセプターを作成する
+// 集計が設定されていない場合は nil を返す。識別子を使うため、認証のインターセプターの後に追加する必要がある。
+// 単一リクエストの RPC は成功した場合だけ、ストリームはメッセージごとに集計する。
+func (c *Config) usageInterceptors() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	if c.Usage == nil {
+		return nil, nil
+	}
+	tracker := c.Usage
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		// ハンドラーがレコードにオフセットなどを設定する前に、クライアントが送ったサイズを数える
+		u := messageUsage(req)
+		res, err := handler(ctx, req)
+		if err == nil {
+			u.Add(messageUsage(res))
+			if u != (usage.Usage{}) {
+				tracker.Add(subject(ctx), u)
+			}
+		}
+		return res, err
+	}
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &usageStream{ServerStream: ss, tracker: tracker, identity: subject(ss.Context())})
+	}
+	return unary, stream
+}
+
+// usageStream: 送受信したメッセージの使用量を集計するサーバーストリーム
+type usageStream struct {
+	grpc.ServerStream
+	tracker  *usage.Tracker
+	identity string
+}
+
+// SendMsg: 送信に成功したメッセージの使用量を集計する
+func (s *usageStream) SendMsg(m any) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.add(m)
+	return nil
+}
+
+// RecvMsg: 受信したメッセージの使用量を集計する
+func (s *usageStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.add(m)
+	return nil
+}
+
+func (s *usageStream) add(m any) {
+	if u := messageUsage(m); u != (usage.Usage{}) {
+		s.tracker.Add(s.identity, u)
+	}
+}
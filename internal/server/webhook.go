@@ -0,0 +1,166 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	api "github.com/kentakki416/proglog/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Webhook: 受け付ける Webhook の設定
+type Webhook struct {
+	Path  string // 受け付けるパス（例: /hooks/github）
+	Topic string // 書き込み先のトピック（空の場合はデフォルトのログストア）
+	// 署名の検証に使用する HMAC-SHA256 の鍵（空の場合は署名を検証しない）
+	Secret []byte
+	// 署名を含むリクエストヘッダー（空の場合は X-Hub-Signature-256）
+	// 値はリクエストボディの HMAC-SHA256 の16進数で、"sha256=" の接頭辞を付けてもよい。
+	SignatureHeader string
+	// レコードのヘッダーに記録するリクエストヘッダー（例: X-GitHub-Event）
+	// レコードのヘッダー名は小文字にしたリクエストヘッダー名になる。
+	Headers []string
+	// 受け付けるリクエストボディの最大バイト数（0 の場合は 1MB）
+	MaxBodyBytes int64
+}
+
+// defaultSignatureHeader: 署名を含むリクエストヘッダーが指定されていない場合に使用するヘッダー
+const defaultSignatureHeader = "X-Hub-Signature-256"
+
+// webhookHandler: Webhook のリクエストボディをレコードとしてログに書き込む HTTP ハンドラー
+type webhookHandler struct {
+	srv  *grpcServer
+	hook Webhook
+}
+
+// NewWebhookHandler: Webhook を受け付けてログに書き込む HTTP ハンドラーを作成する
+// POST されたリクエストボディをそのままレコードの値として書き込み、送信元の情報をレコードのヘッダーに記録する
+// （webhook: 受け付けたパス、content_type、user_agent、remote_addr と、Webhook.Headers で指定したヘッダー）。
+// 書き込みに成功すると、割り当てられたオフセットを {"offset": n} として返す。
+// 引数:
+//   - config: サーバーの設定（書き込み先のログストアとトピック、タイムスタンプの時計）
+//   - hooks: 受け付ける Webhook
+//
+// 戻り値:
+//   - http.Handler: 作成されたハンドラー
+//   - error: Webhook の設定が不正な場合
+func NewWebhookHandler(config *Config, hooks []Webhook) (http.Handler, error) {
+	srv, err := newgrpcServer(config)
+	if err != nil {
+		return nil, err
+	}
+	r := mux.NewRouter()
+	paths := make(map[string]bool)
+	for _, hook := range hooks {
+		if !strings.HasPrefix(hook.Path, "/") {
+			return nil, fmt.Errorf("webhook path must start with /: %q", hook.Path)
+		}
+		if paths[hook.Path] {
+			return nil, fmt.Errorf("duplicate webhook path %q", hook.Path)
+		}
+		paths[hook.Path] = true
+		if hook.SignatureHeader == "" {
+			hook.SignatureHeader = defaultSignatureHeader
+		}
+		if hook.MaxBodyBytes == 0 {
+			hook.MaxBodyBytes = 1024 * 1024
+		}
+		r.Handle(hook.Path, &webhookHandler{srv: srv, hook: hook}).Methods("POST")
+	}
+	return r, nil
+}
+
+// ServeHTTP: Webhook のリクエストを検証してレコードを書き込む
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.hook.MaxBodyBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.verify(body, r.Header.Get(h.hook.SignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	clog, release, err := h.srv.writableLog(h.hook.Topic)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+	defer release()
+	record := &api.Record{Value: body, Headers: h.headers(r)}
+	h.srv.prepareRecord(r.Context(), record, h.srv.clock().Now().UnixMilli())
+	off, err := clog.Append(record)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ProduceResponse{Offset: off})
+}
+
+// verify: リクエストボディの署名を検証する（鍵が設定されていない場合は常に成功する）
+func (h *webhookHandler) verify(body []byte, signature string) bool {
+	if len(h.hook.Secret) == 0 {
+		return true
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, h.hook.Secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// headers: レコードのヘッダーに記録する送信元の情報を取り出す
+func (h *webhookHandler) headers(r *http.Request) map[string]string {
+	headers := map[string]string{
+		"webhook":     h.hook.Path,
+		"remote_addr": r.RemoteAddr,
+	}
+	if v := r.Header.Get("Content-Type"); v != "" {
+		headers["content_type"] = v
+	}
+	if v := r.UserAgent(); v != "" {
+		headers["user_agent"] = v
+	}
+	for _, name := range h.hook.Headers {
+		if v := r.Header.Get(name); v != "" {
+			headers[strings.ToLower(name)] = v
+		}
+	}
+	return headers
+}
+
+// httpStatus: ログストアのエラーを HTTP のステータスコードに変換する
+func httpStatus(err error) int {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.InvalidArgument, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,162 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// ErrSessionNotFound: セッションが存在しない（または期限切れで削除された）場合のエラー
+// クライアントは ID を指定せずにハートビートを送り、新しいセッションを開始する。
+var ErrSessionNotFound = errors.New("session not found")
+
+// DefaultTimeout: タイムアウトが指定されていない場合の、ハートビートが途絶えてからセッションが期限切れになるまでの時間
+const DefaultTimeout = 10 * time.Second
+
+// Session: クライアント（コンシューマー）のセッション
+type Session struct {
+	ID       string
+	Group    string    // コンシューマーグループ（空の場合はグループに属さない）
+	Topic    string    // 読み取っているトピック（空文字の場合はデフォルトのログストア）
+	Started  time.Time // セッションを開始した時刻
+	LastSeen time.Time // 最後にハートビートを受け取った時刻
+}
+
+// Registry: 生きているクライアントのセッションを管理する
+// クライアントは定期的にハートビートを送り、Timeout の間ハートビートがないセッションは期限切れとして削除される。
+// 期限切れのセッションは参照のたびに削除するため、バックグラウンドの処理は持たない。
+// 状態はメモリ上にのみ保持し、サーバーを再起動するとクライアントは新しいセッションを開始し直す。
+type Registry struct {
+	mu       sync.Mutex
+	timeout  time.Duration
+	clock    log.Clock
+	sessions map[string]*Session
+}
+
+// NewRegistry: セッションのレジストリを作成する
+// 引数:
+//   - timeout: ハートビートが途絶えてからセッションが期限切れになるまでの時間（0 の場合は DefaultTimeout）
+//   - clock: 現在時刻の取得に使用する時計（nil の場合は log.SystemClock）
+//
+// 戻り値:
+//   - *Registry: 作成されたレジストリ
+func NewRegistry(timeout time.Duration, clock log.Clock) *Registry {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if clock == nil {
+		clock = log.SystemClock
+	}
+	return &Registry{
+		timeout:  timeout,
+		clock:    clock,
+		sessions: make(map[string]*Session),
+	}
+}
+
+// Timeout: ハートビートが途絶えてからセッションが期限切れになるまでの時間を返す
+func (r *Registry) Timeout() time.Duration {
+	return r.timeout
+}
+
+// Heartbeat: セッションのハートビートを記録する
+// ID が空の場合は新しいセッションを開始する。
+// 引数:
+//   - id: セッション ID（空の場合は新しいセッションを開始する）
+//   - group: コンシューマーグループ
+//   - topic: 読み取っているトピック
+//
+// 戻り値:
+//   - Session: ハートビートを記録したセッション
+//   - error: セッションが存在しない（期限切れを含む）場合は ErrSessionNotFound
+func (r *Registry) Heartbeat(id, group, topic string) (Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	r.expire(now)
+
+	if id == "" {
+		id, err := newID()
+		if err != nil {
+			return Session{}, err
+		}
+		s := &Session{ID: id, Group: group, Topic: topic, Started: now, LastSeen: now}
+		r.sessions[id] = s
+		return *s, nil
+	}
+	s, ok := r.sessions[id]
+	if !ok {
+		return Session{}, ErrSessionNotFound
+	}
+	s.Group, s.Topic, s.LastSeen = group, topic, now
+	return *s, nil
+}
+
+// Get: 生きているセッションを返す
+// 戻り値:
+//   - Session: セッション
+//   - error: セッションが存在しない（期限切れを含む）場合は ErrSessionNotFound
+func (r *Registry) Get(id string) (Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire(r.clock.Now())
+	s, ok := r.sessions[id]
+	if !ok {
+		return Session{}, ErrSessionNotFound
+	}
+	return *s, nil
+}
+
+// End: セッションを終了する（クライアントが停止するときに、期限切れを待たずに削除するため）
+// 戻り値:
+//   - error: セッションが存在しない場合は ErrSessionNotFound
+func (r *Registry) End(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.sessions[id]; !ok {
+		return ErrSessionNotFound
+	}
+	delete(r.sessions, id)
+	return nil
+}
+
+// Active: 生きているセッションを開始した順に返す
+func (r *Registry) Active() []Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire(r.clock.Now())
+	active := make([]Session, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		active = append(active, *s)
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if !active[i].Started.Equal(active[j].Started) {
+			return active[i].Started.Before(active[j].Started)
+		}
+		return active[i].ID < active[j].ID
+	})
+	return active
+}
+
+// expire: 期限切れのセッションを削除する（呼び出し元がロックを保持している必要がある）
+func (r *Registry) expire(now time.Time) {
+	for id, s := range r.sessions {
+		if now.Sub(s.LastSeen) >= r.timeout {
+			delete(r.sessions, id)
+		}
+	}
+}
+
+// newID: ランダムなセッション ID を作成する
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
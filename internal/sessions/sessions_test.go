@@ -0,0 +1,47 @@
+package sessions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	clock := log.NewFakeClock(time.Unix(0, 0))
+	r := NewRegistry(10*time.Second, clock)
+
+	a, err := r.Heartbeat("", "billing", "orders")
+	require.NoError(t, err)
+	require.NotEmpty(t, a.ID)
+	clock.Advance(time.Second)
+	b, err := r.Heartbeat("", "billing", "orders")
+	require.NoError(t, err)
+	require.NotEqual(t, a.ID, b.ID)
+	require.Equal(t, []string{a.ID, b.ID}, ids(r.Active()))
+
+	// ハートビートを送り続けたセッションだけが残る
+	clock.Advance(8 * time.Second)
+	_, err = r.Heartbeat(b.ID, "billing", "orders")
+	require.NoError(t, err)
+	clock.Advance(2 * time.Second)
+	require.Equal(t, []string{b.ID}, ids(r.Active()))
+	_, err = r.Heartbeat(a.ID, "billing", "orders")
+	require.Equal(t, ErrSessionNotFound, err)
+
+	got, err := r.Get(b.ID)
+	require.NoError(t, err)
+	require.Equal(t, "orders", got.Topic)
+	require.NoError(t, r.End(b.ID))
+	require.Empty(t, r.Active())
+	require.Equal(t, ErrSessionNotFound, r.End(b.ID))
+}
+
+func ids(sessions []Session) []string {
+	var ids []string
+	for _, s := range sessions {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
@@ -0,0 +1,215 @@
+// Package supervisor: プロセス内のコンポーネント（gRPC サーバー、レプリケーション、コネクターなど）を監視し、
+// 失敗したコンポーネントをバックオフを置いて再起動する
+// 1つのコンポーネントの失敗でプロセス全体が終了したり、止まったまま気付かれずに動き続けたりしないようにし、
+// コンポーネントごとの状態をヘルスチェックとして公開する。
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/health"
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// バックオフの設定が指定されていない場合の値
+const (
+	DefaultMinBackoff  = 100 * time.Millisecond // 最初の再起動までの待ち時間
+	DefaultMaxBackoff  = 30 * time.Second       // 再起動までの待ち時間の上限
+	DefaultStableAfter = time.Minute            // この時間以上動き続けた後の失敗は、最初の失敗として扱う
+)
+
+// Component: 監視するコンポーネント
+// Run は ctx がキャンセルされるまで動き続け、キャンセルされたら戻る。
+// ctx がキャンセルされる前に戻った場合（エラーが nil の場合やパニックした場合を含む）は失敗とみなして再起動する。
+type Component struct {
+	Name string // コンポーネントの名前（状態とヘルスチェックの理由に使用する）
+	Run  func(ctx context.Context) error
+}
+
+// Phase: コンポーネントの状態
+type Phase string
+
+const (
+	PhaseRunning Phase = "running" // 動いている
+	PhaseBackoff Phase = "backoff" // 失敗して、再起動を待っている
+	PhaseStopped Phase = "stopped" // Close で停止した
+)
+
+// State: コンポーネントの状態
+type State struct {
+	Name     string
+	Phase    Phase
+	Restarts int       // 再起動した回数
+	LastErr  error     // 最後に失敗したときのエラー（失敗していない場合は nil）
+	Since    time.Time // 現在の状態になった時刻
+}
+
+// Config: 監視の設定
+type Config struct {
+	Components []Component
+	// 最初の再起動までの待ち時間（0 の場合は DefaultMinBackoff）
+	// 続けて失敗するたびに2倍にし、MaxBackoff で頭打ちにする。
+	MinBackoff time.Duration
+	// 再起動までの待ち時間の上限（0 の場合は DefaultMaxBackoff）
+	MaxBackoff time.Duration
+	// この時間以上動き続けた後の失敗は、待ち時間を MinBackoff に戻す（0 の場合は DefaultStableAfter）
+	StableAfter time.Duration
+	// 時刻の取得と再起動の待ち時間に使用する時計（nil の場合は log.SystemClock）
+	Clock log.Clock
+	// OnChange: コンポーネントの状態が変わったときに呼び出される（任意、ログやメトリクスの記録に使用する）
+	OnChange func(State)
+}
+
+// Supervisor: コンポーネントを監視し、失敗したコンポーネントを再起動する
+type Supervisor struct {
+	Config
+
+	mu     sync.Mutex
+	states map[string]State
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Start: すべてのコンポーネントを開始し、監視を開始する
+// 引数:
+//   - c: 監視の設定
+//
+// 戻り値:
+//   - *Supervisor: 開始した監視（Close ですべてのコンポーネントを停止する）
+func Start(c Config) *Supervisor {
+	if c.MinBackoff == 0 {
+		c.MinBackoff = DefaultMinBackoff
+	}
+	if c.MaxBackoff == 0 {
+		c.MaxBackoff = DefaultMaxBackoff
+	}
+	if c.StableAfter == 0 {
+		c.StableAfter = DefaultStableAfter
+	}
+	if c.Clock == nil {
+		c.Clock = log.SystemClock
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Supervisor{Config: c, states: make(map[string]State), cancel: cancel}
+	for _, comp := range c.Components {
+		s.setState(State{Name: comp.Name, Phase: PhaseRunning, Since: c.Clock.Now()})
+	}
+	for _, comp := range c.Components {
+		s.wg.Add(1)
+		go s.supervise(ctx, comp)
+	}
+	return s
+}
+
+// supervise: コンポーネントを実行し、失敗したらバックオフを置いて再起動することを繰り返す（内部関数）
+func (s *Supervisor) supervise(ctx context.Context, comp Component) {
+	defer s.wg.Done()
+	backoff := s.MinBackoff
+	for {
+		started := s.Clock.Now()
+		err := run(ctx, comp)
+		if ctx.Err() != nil {
+			s.update(comp.Name, func(st *State) { st.Phase = PhaseStopped })
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("%s exited", comp.Name)
+		}
+		// 十分に長く動いていた場合は、続けて失敗しているわけではないため待ち時間を戻す
+		if s.Clock.Now().Sub(started) >= s.StableAfter {
+			backoff = s.MinBackoff
+		}
+
+		// 状態を公開する前にタイマーを作成して、状態を見てから時計を進めても見逃さないようにする
+		ticker := s.Clock.NewTicker(backoff)
+		s.update(comp.Name, func(st *State) {
+			st.Phase = PhaseBackoff
+			st.LastErr = err
+		})
+		select {
+		case <-ctx.Done():
+			ticker.Stop()
+			s.update(comp.Name, func(st *State) { st.Phase = PhaseStopped })
+			return
+		case <-ticker.C():
+			ticker.Stop()
+		}
+		backoff = min(backoff*2, s.MaxBackoff)
+		s.update(comp.Name, func(st *State) {
+			st.Phase = PhaseRunning
+			st.Restarts++
+		})
+	}
+}
+
+// run: コンポーネントを1回実行する（パニックはエラーとして返す）（内部関数）
+func run(ctx context.Context, comp Component) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s panicked: %v", comp.Name, r)
+		}
+	}()
+	return comp.Run(ctx)
+}
+
+// update: コンポーネントの状態を更新して、OnChange を呼び出す（内部関数）
+func (s *Supervisor) update(name string, fn func(*State)) {
+	s.mu.Lock()
+	st := s.states[name]
+	fn(&st)
+	st.Since = s.Clock.Now()
+	s.mu.Unlock()
+	s.setState(st)
+}
+
+// setState: コンポーネントの状態を設定して、OnChange を呼び出す（内部関数）
+func (s *Supervisor) setState(st State) {
+	s.mu.Lock()
+	s.states[st.Name] = st
+	s.mu.Unlock()
+	if s.OnChange != nil {
+		s.OnChange(st)
+	}
+}
+
+// States: すべてのコンポーネントの状態を名前の順に返す
+func (s *Supervisor) States() []State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states := make([]State, 0, len(s.states))
+	for _, st := range s.states {
+		states = append(states, st)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// Check: 再起動を待っているコンポーネントがある場合に異常とするセルフチェックを返す
+// health.Config.Checks に追加して、コンポーネントの失敗をヘルスチェックの理由として公開する。
+func (s *Supervisor) Check() health.Check {
+	return health.Check{Name: "components", Run: func() error {
+		var failed []string
+		for _, st := range s.States() {
+			if st.Phase == PhaseBackoff {
+				failed = append(failed, fmt.Sprintf("%s restarting after %v (%d restarts)", st.Name, st.LastErr, st.Restarts))
+			}
+		}
+		if len(failed) > 0 {
+			return errors.New(strings.Join(failed, "; "))
+		}
+		return nil
+	}}
+}
+
+// Close: すべてのコンポーネントを停止し、終了するまで待つ
+func (s *Supervisor) Close() {
+	s.cancel()
+	s.wg.Wait()
+}
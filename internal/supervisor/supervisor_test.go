@@ -0,0 +1,76 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupervisor(t *testing.T) {
+	clock := log.NewFakeClock(time.UnixMilli(1700000000000))
+	var runs atomic.Int32
+	s := Start(Config{
+		Components: []Component{
+			// 最初の2回は失敗（エラー、パニック）し、3回目からは停止されるまで動き続ける
+			{Name: "flaky", Run: func(ctx context.Context) error {
+				switch runs.Add(1) {
+				case 1:
+					return errors.New("connection refused")
+				case 2:
+					panic("boom")
+				}
+				<-ctx.Done()
+				return nil
+			}},
+			{Name: "stable", Run: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}},
+		},
+		MinBackoff: time.Second,
+		Clock:      clock,
+	})
+
+	// 失敗したコンポーネントは再起動を待ち、ヘルスチェックで異常になる
+	waitFor(t, s, "flaky", PhaseBackoff, 0)
+	require.EqualError(t, s.Check().Run(), "flaky restarting after connection refused (0 restarts)")
+
+	// 待ち時間が経過すると再起動する（続けて失敗した場合は待ち時間を2倍にする）
+	clock.Advance(time.Second)
+	waitFor(t, s, "flaky", PhaseBackoff, 1)
+	require.EqualError(t, s.States()[0].LastErr, "flaky panicked: boom")
+	clock.Advance(time.Second)
+	require.Equal(t, PhaseBackoff, s.States()[0].Phase)
+	clock.Advance(time.Second)
+	waitFor(t, s, "flaky", PhaseRunning, 2)
+	require.NoError(t, s.Check().Run())
+	require.Equal(t, int32(3), runs.Load())
+
+	// 他のコンポーネントは影響を受けない
+	stable := s.States()[1]
+	require.Equal(t, PhaseRunning, stable.Phase)
+	require.Zero(t, stable.Restarts)
+
+	s.Close()
+	for _, st := range s.States() {
+		require.Equal(t, PhaseStopped, st.Phase)
+	}
+}
+
+// waitFor: コンポーネントが指定した状態と再起動の回数になるまで待つ
+func waitFor(t *testing.T, s *Supervisor, name string, phase Phase, restarts int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		for _, st := range s.States() {
+			if st.Name == name {
+				return st.Phase == phase && st.Restarts == restarts
+			}
+		}
+		return false
+	}, time.Second, time.Millisecond)
+}
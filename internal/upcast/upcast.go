@@ -0,0 +1,83 @@
+package upcast
+
+import (
+	"fmt"
+	"sync"
+
+	api "github.com/kentakki416/proglog/api/v1"
+)
+
+// VersionHeader: レコードのスキーマのバージョンを表すヘッダーの名前
+const VersionHeader = "schema-version"
+
+// Func: 1つ前のバージョンのレコードを次のバージョンに書き換える関数
+// ヘッダーのバージョンは Registry が書き換えるため、Func ではキーと値（と他のヘッダー）だけを書き換える。
+type Func func(record *api.Record) error
+
+// step: 1つのバージョンからの変換（内部型）
+type step struct {
+	to string
+	fn Func
+}
+
+// Registry: スキーマのバージョンごとの変換の登録
+// 読み取ったレコードを、登録された変換を順にたどって最新のバージョンに書き換える（アップキャスト）。
+// 保持期間の長いイベントのストリームでも、ログを書き換える（オフラインで移行する）ことなくスキーマを変更できる。
+type Registry struct {
+	mu    sync.RWMutex
+	steps map[string]step
+}
+
+// NewRegistry: 空の Registry を作成する
+func NewRegistry() *Registry {
+	return &Registry{steps: make(map[string]step)}
+}
+
+// Register: バージョン from のレコードをバージョン to に書き換える変換を登録する
+// 例: Register("1", "2", v1ToV2) と Register("2", "3", v2ToV3) を登録すると、バージョン 1 のレコードは 3 になる。
+// 引数:
+//   - from: 変換前のバージョン（VersionHeader の値）
+//   - to: 変換後のバージョン
+//   - fn: レコードを書き換える関数
+//
+// 戻り値:
+//   - error: from と to が同じ場合、または from の変換が登録済みの場合
+func (r *Registry) Register(from, to string, fn Func) error {
+	if from == to {
+		return fmt.Errorf("upcast from %q to itself", from)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.steps[from]; ok {
+		return fmt.Errorf("upcast from %q is already registered", from)
+	}
+	r.steps[from] = step{to: to, fn: fn}
+	return nil
+}
+
+// Apply: レコードを最新のバージョンに書き換える（レコードを書き換える）
+// バージョンのヘッダーがないレコードや、変換が登録されていないバージョンのレコードはそのまま返す。
+// 変換に失敗した場合、レコードは途中のバージョンまで書き換えられている場合がある。
+// 戻り値:
+//   - error: 変換に失敗した場合、または変換が循環している場合
+func (r *Registry) Apply(record *api.Record) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	version, ok := record.Headers[VersionHeader]
+	if !ok {
+		return nil
+	}
+	// 登録された変換の数より多くたどる場合は循環している
+	for i := 0; i <= len(r.steps); i++ {
+		s, ok := r.steps[version]
+		if !ok {
+			return nil
+		}
+		if err := s.fn(record); err != nil {
+			return fmt.Errorf("upcast offset %d from version %s: %w", record.Offset, version, err)
+		}
+		version = s.to
+		record.Headers[VersionHeader] = version
+	}
+	return fmt.Errorf("upcast offset %d: cycle at version %s", record.Offset, version)
+}
@@ -0,0 +1,73 @@
+package upcast
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register("1", "2", func(record *api.Record) error {
+		record.Value = bytes.ReplaceAll(record.Value, []byte(`"name"`), []byte(`"full_name"`))
+		return nil
+	}))
+	require.NoError(t, r.Register("2", "3", func(record *api.Record) error {
+		record.Value = append(bytes.TrimSuffix(record.Value, []byte("}")), []byte(`,"country":"JP"}`)...)
+		return nil
+	}))
+	require.NoError(t, r.Register("bad", "4", func(*api.Record) error {
+		return errors.New("broken")
+	}))
+	require.Error(t, r.Register("1", "5", nil))
+	require.Error(t, r.Register("5", "5", nil))
+
+	for scenario, tc := range map[string]struct {
+		in      *api.Record
+		want    *api.Record
+		wantErr bool
+	}{
+		"oldest version": {
+			in:   &api.Record{Value: []byte(`{"name":"a"}`), Headers: map[string]string{VersionHeader: "1"}},
+			want: &api.Record{Value: []byte(`{"full_name":"a","country":"JP"}`), Headers: map[string]string{VersionHeader: "3"}},
+		},
+		"middle version": {
+			in:   &api.Record{Value: []byte(`{"full_name":"a"}`), Headers: map[string]string{VersionHeader: "2"}},
+			want: &api.Record{Value: []byte(`{"full_name":"a","country":"JP"}`), Headers: map[string]string{VersionHeader: "3"}},
+		},
+		"latest version": {
+			in:   &api.Record{Value: []byte("v"), Headers: map[string]string{VersionHeader: "3"}},
+			want: &api.Record{Value: []byte("v"), Headers: map[string]string{VersionHeader: "3"}},
+		},
+		"no version header": {
+			in:   &api.Record{Value: []byte("v")},
+			want: &api.Record{Value: []byte("v")},
+		},
+		"failing upcast": {
+			in:      &api.Record{Value: []byte("v"), Headers: map[string]string{VersionHeader: "bad"}},
+			wantErr: true,
+		},
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			err := r.Apply(tc.in)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, tc.in)
+		})
+	}
+}
+
+func TestRegistryCycle(t *testing.T) {
+	r := NewRegistry()
+	noop := func(*api.Record) error { return nil }
+	require.NoError(t, r.Register("a", "b", noop))
+	require.NoError(t, r.Register("b", "a", noop))
+	err := r.Apply(&api.Record{Headers: map[string]string{VersionHeader: "a"}})
+	require.ErrorContains(t, err, "cycle")
+}
@@ -0,0 +1,164 @@
+package usage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+)
+
+// デフォルトの集計の単位と保持する期間
+const (
+	DefaultWindow = time.Hour
+	DefaultRetain = 24
+)
+
+// Usage: 識別子ごとの書き込みと読み取りの量
+// バイト数はレコードをシリアライズしたサイズで、ヘッダーやキーを含む。
+type Usage struct {
+	ProducedRecords uint64
+	ProducedBytes   uint64
+	ConsumedRecords uint64
+	ConsumedBytes   uint64
+}
+
+// Add: 別の使用量を加える
+func (u *Usage) Add(o Usage) {
+	u.ProducedRecords += o.ProducedRecords
+	u.ProducedBytes += o.ProducedBytes
+	u.ConsumedRecords += o.ConsumedRecords
+	u.ConsumedBytes += o.ConsumedBytes
+}
+
+// Window: 1つの集計期間の識別子ごとの使用量
+type Window struct {
+	Identity string
+	Start    time.Time // 集計期間の開始時刻（Tracker の Window の倍数に切り捨てた時刻）
+	Usage
+}
+
+// bucket: 1つの集計期間の使用量（内部型）
+type bucket struct {
+	start time.Time
+	usage map[string]*Usage
+}
+
+// Tracker: クライアントの識別子ごとの使用量を一定の期間ごとに集計する
+// 課金やクォータの判断に使うため、直近 retain 個の期間の内訳と、起動してからの累計を保持する。
+// 状態はメモリ上にのみ保持し、サーバーを再起動すると 0 から集計し直す。
+type Tracker struct {
+	mu      sync.Mutex
+	window  time.Duration
+	retain  int
+	clock   log.Clock
+	buckets []*bucket // 開始時刻の昇順
+	totals  map[string]*Usage
+}
+
+// NewTracker: 使用量の集計を作成する
+// 引数:
+//   - window: 集計の単位とする期間（0 の場合は DefaultWindow）
+//   - retain: 内訳を保持する期間の数（0 の場合は DefaultRetain）
+//   - clock: 現在時刻の取得に使用する時計（nil の場合は log.SystemClock）
+//
+// 戻り値:
+//   - *Tracker: 作成された集計
+func NewTracker(window time.Duration, retain int, clock log.Clock) *Tracker {
+	if window == 0 {
+		window = DefaultWindow
+	}
+	if retain == 0 {
+		retain = DefaultRetain
+	}
+	if clock == nil {
+		clock = log.SystemClock
+	}
+	return &Tracker{
+		window: window,
+		retain: retain,
+		clock:  clock,
+		totals: make(map[string]*Usage),
+	}
+}
+
+// Window: 集計の単位とする期間を返す
+func (t *Tracker) Window() time.Duration {
+	return t.window
+}
+
+// Add: 識別子の現在の期間の使用量を加える
+// 引数:
+//   - identity: クライアントの識別子（クライアント証明書のサブジェクト、TLS を使用していない場合は空）
+//   - u: 加える使用量
+func (t *Tracker) Add(identity string, u Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start := t.clock.Now().Truncate(t.window)
+	t.prune(start)
+	var b *bucket
+	if n := len(t.buckets); n > 0 && t.buckets[n-1].start.Equal(start) {
+		b = t.buckets[n-1]
+	} else {
+		b = &bucket{start: start, usage: make(map[string]*Usage)}
+		t.buckets = append(t.buckets, b)
+	}
+	for _, m := range []map[string]*Usage{b.usage, t.totals} {
+		if m[identity] == nil {
+			m[identity] = &Usage{}
+		}
+		m[identity].Add(u)
+	}
+}
+
+// Windows: 保持している期間ごとの使用量を返す
+// 引数:
+//   - identity: 対象の識別子（all が true の場合は無視する）
+//   - all: すべての識別子の使用量を返す場合は true
+//
+// 戻り値:
+//   - []Window: 開始時刻、識別子の順に並べた使用量（使用量のない期間は含まない）
+func (t *Tracker) Windows(identity string, all bool) []Window {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(t.clock.Now().Truncate(t.window))
+	var windows []Window
+	for _, b := range t.buckets {
+		start := len(windows)
+		for id, u := range b.usage {
+			if all || id == identity {
+				windows = append(windows, Window{Identity: id, Start: b.start, Usage: *u})
+			}
+		}
+		sort.Slice(windows[start:], func(i, j int) bool {
+			return windows[start+i].Identity < windows[start+j].Identity
+		})
+	}
+	return windows
+}
+
+// Totals: 起動してからの識別子ごとの使用量の累計を返す（メトリクス用）
+func (t *Tracker) Totals() map[string]Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	totals := make(map[string]Usage, len(t.totals))
+	for id, u := range t.totals {
+		totals[id] = *u
+	}
+	return totals
+}
+
+// prune: 保持する期間を過ぎた集計期間を削除する（内部関数）
+// 呼び出し側でロックを取得しておく必要がある。
+// 引数:
+//   - current: 現在の集計期間の開始時刻
+func (t *Tracker) prune(current time.Time) {
+	oldest := current.Add(-time.Duration(t.retain-1) * t.window)
+	i := 0
+	for i < len(t.buckets) && t.buckets[i].start.Before(oldest) {
+		i++
+	}
+	t.buckets = t.buckets[i:]
+}
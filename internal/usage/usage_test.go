@@ -0,0 +1,41 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	clock := log.NewFakeClock(time.Unix(3600, 0))
+	tracker := NewTracker(time.Minute, 2, clock)
+
+	tracker.Add("alice", Usage{ProducedRecords: 1, ProducedBytes: 10})
+	tracker.Add("alice", Usage{ConsumedRecords: 2, ConsumedBytes: 20})
+	tracker.Add("bob", Usage{ProducedRecords: 1, ProducedBytes: 5})
+	clock.Advance(90 * time.Second)
+	tracker.Add("alice", Usage{ProducedRecords: 3, ProducedBytes: 30})
+
+	first, second := time.Unix(3600, 0), time.Unix(3660, 0)
+	require.Equal(t, []Window{
+		{Identity: "alice", Start: first, Usage: Usage{ProducedRecords: 1, ProducedBytes: 10, ConsumedRecords: 2, ConsumedBytes: 20}},
+		{Identity: "alice", Start: second, Usage: Usage{ProducedRecords: 3, ProducedBytes: 30}},
+	}, tracker.Windows("alice", false))
+	require.Equal(t, []Window{
+		{Identity: "alice", Start: first, Usage: Usage{ProducedRecords: 1, ProducedBytes: 10, ConsumedRecords: 2, ConsumedBytes: 20}},
+		{Identity: "bob", Start: first, Usage: Usage{ProducedRecords: 1, ProducedBytes: 5}},
+		{Identity: "alice", Start: second, Usage: Usage{ProducedRecords: 3, ProducedBytes: 30}},
+	}, tracker.Windows("", true))
+
+	// 保持する期間を過ぎた内訳は削除されるが、累計には残る
+	clock.Advance(time.Minute)
+	require.Equal(t, []Window{
+		{Identity: "alice", Start: second, Usage: Usage{ProducedRecords: 3, ProducedBytes: 30}},
+	}, tracker.Windows("", true))
+	require.Equal(t, map[string]Usage{
+		"alice": {ProducedRecords: 4, ProducedBytes: 40, ConsumedRecords: 2, ConsumedBytes: 20},
+		"bob":   {ProducedRecords: 1, ProducedBytes: 5},
+	}, tracker.Totals())
+}
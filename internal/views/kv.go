@@ -0,0 +1,219 @@
+package views
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	bolt "go.etcd.io/bbolt"
+)
+
+// enc: ビューに保存する数値のエンコーディング（ログストアと同じビッグエンディアン）
+var enc = binary.BigEndian
+
+// バケット名とメタデータのキー
+var (
+	valuesBucket = []byte("values") // キー -> [オフセット(8バイト)][値]
+	metaBucket   = []byte("meta")   // ビューのメタデータ
+	nextKey      = []byte("next")   // 次に適用するオフセット
+)
+
+const (
+	batchSize     = 1000                   // 1つのトランザクションで適用するレコードの最大数
+	retryInterval = 100 * time.Millisecond // ログの読み取りに失敗した場合に再試行するまでの間隔
+)
+
+// Log: ビューが読み取るログストア（例: log.Log）
+type Log interface {
+	Read(uint64) (*api.Record, error)
+	LowestOffset() (uint64, error)
+	Appended() <-chan struct{}
+}
+
+// KeyValue: ログを読み取り、キーごとの最新の値を保持するビュー（CQRS の読み取りモデル）
+// キーを持つレコードを順番に適用し、同じキーのレコードは後から追加されたもので上書きする。
+// 値が空のレコードはキーの削除（トゥームストーン）として扱い、キーを持たないレコードは無視する。
+// 適用済みのオフセットは値と同じトランザクションで保存するため、再起動後は続きから適用する。
+type KeyValue struct {
+	db  *bolt.DB
+	log Log
+
+	mu   sync.Mutex
+	next uint64 // 次に適用するオフセット
+
+	done chan struct{}  // ログの読み取りを停止するためのチャネル
+	wg   sync.WaitGroup // ログを読み取るゴルーチンの終了待ち
+}
+
+// NewKeyValue: ビューを作成または既存のビューを開き、ログの読み取りを開始する
+// 引数:
+//   - path: ビューを保存するファイルのパス
+//   - l: 読み取るログストア
+//
+// 戻り値:
+//   - *KeyValue: 作成されたビュー
+//   - error: エラーが発生した場合
+func NewKeyValue(path string, l Log) (*KeyValue, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	v := &KeyValue{
+		db:   db,
+		log:  l,
+		done: make(chan struct{}),
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(valuesBucket); err != nil {
+			return err
+		}
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		if b := meta.Get(nextKey); b != nil {
+			v.next = enc.Uint64(b)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	v.wg.Add(1)
+	go v.run()
+	return v, nil
+}
+
+// Get: キーの最新の値と、その値を書き込んだレコードのオフセットを返す
+// 引数:
+//   - key: レコードのキー
+//
+// 戻り値:
+//   - []byte: キーの最新の値
+//   - uint64: 値を書き込んだレコードのオフセット
+//   - error: キーが存在しない場合は api.ErrKeyNotFound
+func (v *KeyValue) Get(key []byte) ([]byte, uint64, error) {
+	var (
+		value  []byte
+		offset uint64
+	)
+	err := v.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(valuesBucket).Get(key)
+		if b == nil {
+			return api.ErrKeyNotFound{Key: key}
+		}
+		// トランザクションの外では参照できないため、コピーする
+		offset = enc.Uint64(b)
+		value = append([]byte(nil), b[8:]...)
+		return nil
+	})
+	return value, offset, err
+}
+
+// Next: 次に適用するオフセットを返す（これより前のレコードはすべてビューに反映されている）
+func (v *KeyValue) Next() uint64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.next
+}
+
+// Close: ログの読み取りを停止してビューを閉じる
+func (v *KeyValue) Close() error {
+	close(v.done)
+	v.wg.Wait()
+	return v.db.Close()
+}
+
+// run: ログの末尾に達するまでレコードを適用し、新しいレコードが追加されるのを待つことを繰り返す
+func (v *KeyValue) run() {
+	defer v.wg.Done()
+	for {
+		// 読み取りの前にチャネルを取得して、読み取り後に追加されたレコードを見逃さないようにする
+		appended := v.log.Appended()
+		n, err := v.apply()
+		if err != nil {
+			select {
+			case <-v.done:
+				return
+			case <-time.After(retryInterval):
+				continue
+			}
+		}
+		if n == batchSize {
+			// まだ適用していないレコードが残っている可能性がある
+			continue
+		}
+		select {
+		case <-v.done:
+			return
+		case <-appended:
+		}
+	}
+}
+
+// apply: 次のオフセットから最大 batchSize 件のレコードを1つのトランザクションで適用する
+// 戻り値:
+//   - int: 適用したレコードの数
+//   - error: エラーが発生した場合
+func (v *KeyValue) apply() (int, error) {
+	next := v.Next()
+	var records []*api.Record
+	for len(records) < batchSize {
+		record, err := v.log.Read(next)
+		if _, ok := err.(api.ErrOffsetOutOfRange); ok {
+			// 適用していないレコードが Truncate で削除されていた場合は、残っている最小のオフセットから続ける
+			lowest, lerr := v.log.LowestOffset()
+			if lerr != nil {
+				return 0, lerr
+			}
+			if next < lowest {
+				next = lowest
+				continue
+			}
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		records = append(records, record)
+		next++
+	}
+	if next == v.Next() {
+		return 0, nil
+	}
+
+	err := v.db.Update(func(tx *bolt.Tx) error {
+		values := tx.Bucket(valuesBucket)
+		for _, record := range records {
+			if len(record.Key) == 0 {
+				continue
+			}
+			if len(record.Value) == 0 {
+				if err := values.Delete(record.Key); err != nil {
+					return err
+				}
+				continue
+			}
+			b := make([]byte, 8+len(record.Value))
+			enc.PutUint64(b, record.Offset)
+			copy(b[8:], record.Value)
+			if err := values.Put(record.Key, b); err != nil {
+				return err
+			}
+		}
+		b := make([]byte, 8)
+		enc.PutUint64(b, next)
+		return tx.Bucket(metaBucket).Put(nextKey, b)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	v.mu.Lock()
+	v.next = next
+	v.mu.Unlock()
+	return len(records), nil
+}
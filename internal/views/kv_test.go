@@ -0,0 +1,76 @@
+package views
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	api "github.com/kentakki416/proglog/api/v1"
+	"github.com/kentakki416/proglog/internal/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyValue(t *testing.T) {
+	dir, err := os.MkdirTemp("", "views-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logDir := filepath.Join(dir, "log")
+	require.NoError(t, os.Mkdir(logDir, 0755))
+	l, err := log.NewLog(logDir, log.Config{})
+	require.NoError(t, err)
+	defer l.Close()
+
+	for _, record := range []*api.Record{
+		{Key: []byte("a"), Value: []byte("1")},
+		{Key: []byte("b"), Value: []byte("1")},
+		{Value: []byte("no key")},
+		{Key: []byte("a"), Value: []byte("2")},
+	} {
+		_, err := l.Append(record)
+		require.NoError(t, err)
+	}
+
+	path := filepath.Join(dir, "kv.db")
+	v, err := NewKeyValue(path, l)
+	require.NoError(t, err)
+	waitFor(t, v, 4)
+
+	value, offset, err := v.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("2"), value)
+	require.Equal(t, uint64(3), offset)
+
+	// ビューの作成後に追加されたレコードも反映される（値が空のレコードはキーを削除する）
+	_, err = l.Append(&api.Record{Key: []byte("b")})
+	require.NoError(t, err)
+	waitFor(t, v, 5)
+	_, _, err = v.Get([]byte("b"))
+	require.Equal(t, api.ErrKeyNotFound{Key: []byte("b")}, err)
+	require.NoError(t, v.Close())
+
+	// 再起動後は適用済みのオフセットの続きから適用する
+	_, err = l.Append(&api.Record{Key: []byte("c"), Value: []byte("3")})
+	require.NoError(t, err)
+	v, err = NewKeyValue(path, l)
+	require.NoError(t, err)
+	defer v.Close()
+	waitFor(t, v, 6)
+
+	value, offset, err = v.Get([]byte("c"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("3"), value)
+	require.Equal(t, uint64(5), offset)
+	value, _, err = v.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("2"), value)
+}
+
+// waitFor: ビューが next より前のレコードをすべて適用するまで待つ
+func waitFor(t *testing.T, v *KeyValue, next uint64) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return v.Next() == next
+	}, time.Second, 10*time.Millisecond)
+}